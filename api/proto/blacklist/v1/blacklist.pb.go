@@ -0,0 +1,475 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: blacklist/v1/blacklist.proto
+
+package blacklistv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CheckRequest 单个标识查询请求
+type CheckRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 标识类型：phone, id_card, device_id, email, ip, bank_card，为空时默认phone
+	IdentifierType string `protobuf:"bytes,1,opt,name=identifier_type,json=identifierType,proto3" json:"identifier_type,omitempty"`
+	// 哈希格式：md5, sha256, hmac_sha256，为空时默认md5
+	HashType string `protobuf:"bytes,2,opt,name=hash_type,json=hashType,proto3" json:"hash_type,omitempty"`
+	// 标识哈希，十六进制
+	IdentifierHash string `protobuf:"bytes,3,opt,name=identifier_hash,json=identifierHash,proto3" json:"identifier_hash,omitempty"`
+	// 客户端指定的关联ID，在对应的响应中原样返回
+	CorrelationId string `protobuf:"bytes,4,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	// 流式查询中每条请求的签名，单次调用不使用：
+	// hex(HMAC-SHA256(api_secret, 建立流时的nonce + 请求序号 + 请求消息))，
+	// 请求序号从1开始按发送顺序递增，请求消息为不含本字段的protobuf二进制序列化
+	Signature string `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	// 查询的名单标识，最多20个，为空时查询API密钥已授权的全部名单
+	Lists         []string `protobuf:"bytes,6,rep,name=lists,proto3" json:"lists,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckRequest) Reset() {
+	*x = CheckRequest{}
+	mi := &file_blacklist_v1_blacklist_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckRequest) ProtoMessage() {}
+
+func (x *CheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blacklist_v1_blacklist_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckRequest.ProtoReflect.Descriptor instead.
+func (*CheckRequest) Descriptor() ([]byte, []int) {
+	return file_blacklist_v1_blacklist_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CheckRequest) GetIdentifierType() string {
+	if x != nil {
+		return x.IdentifierType
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetHashType() string {
+	if x != nil {
+		return x.HashType
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetIdentifierHash() string {
+	if x != nil {
+		return x.IdentifierHash
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *CheckRequest) GetLists() []string {
+	if x != nil {
+		return x.Lists
+	}
+	return nil
+}
+
+// CheckResponse 单个标识查询结果
+type CheckResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	IsBlacklist    bool                   `protobuf:"varint,1,opt,name=is_blacklist,json=isBlacklist,proto3" json:"is_blacklist,omitempty"`
+	IdentifierType string                 `protobuf:"bytes,2,opt,name=identifier_type,json=identifierType,proto3" json:"identifier_type,omitempty"`
+	HashType       string                 `protobuf:"bytes,3,opt,name=hash_type,json=hashType,proto3" json:"hash_type,omitempty"`
+	IdentifierHash string                 `protobuf:"bytes,4,opt,name=identifier_hash,json=identifierHash,proto3" json:"identifier_hash,omitempty"`
+	// 风险分类，仅命中时返回
+	Category string `protobuf:"bytes,5,opt,name=category,proto3" json:"category,omitempty"`
+	// 风险分，仅命中时返回
+	RiskScore     int32  `protobuf:"varint,6,opt,name=risk_score,json=riskScore,proto3" json:"risk_score,omitempty"`
+	CorrelationId string `protobuf:"bytes,7,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	// 请求ID，用于查询日志排查，批量查询的结果中为空
+	RequestId string `protobuf:"bytes,8,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	// 命中的租户名单，仅命中时返回，不包含共享名单
+	HitLists []string `protobuf:"bytes,9,rep,name=hit_lists,json=hitLists,proto3" json:"hit_lists,omitempty"`
+	// 命中来源：tenant（租户名单）、shared（共享名单）、both（两者），仅命中时返回
+	HitSource string `protobuf:"bytes,10,opt,name=hit_source,json=hitSource,proto3" json:"hit_source,omitempty"`
+	// 命中黑名单但标识在租户白名单中，按未命中返回；风险分低于API密钥风险分阈值的命中不标记
+	Overridden    bool `protobuf:"varint,11,opt,name=overridden,proto3" json:"overridden,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckResponse) Reset() {
+	*x = CheckResponse{}
+	mi := &file_blacklist_v1_blacklist_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckResponse) ProtoMessage() {}
+
+func (x *CheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blacklist_v1_blacklist_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckResponse.ProtoReflect.Descriptor instead.
+func (*CheckResponse) Descriptor() ([]byte, []int) {
+	return file_blacklist_v1_blacklist_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CheckResponse) GetIsBlacklist() bool {
+	if x != nil {
+		return x.IsBlacklist
+	}
+	return false
+}
+
+func (x *CheckResponse) GetIdentifierType() string {
+	if x != nil {
+		return x.IdentifierType
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetHashType() string {
+	if x != nil {
+		return x.HashType
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetIdentifierHash() string {
+	if x != nil {
+		return x.IdentifierHash
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetRiskScore() int32 {
+	if x != nil {
+		return x.RiskScore
+	}
+	return 0
+}
+
+func (x *CheckResponse) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetHitLists() []string {
+	if x != nil {
+		return x.HitLists
+	}
+	return nil
+}
+
+func (x *CheckResponse) GetHitSource() string {
+	if x != nil {
+		return x.HitSource
+	}
+	return ""
+}
+
+func (x *CheckResponse) GetOverridden() bool {
+	if x != nil {
+		return x.Overridden
+	}
+	return false
+}
+
+// CheckBatchRequest 批量查询请求
+type CheckBatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 标识类型，为空时默认phone
+	IdentifierType string `protobuf:"bytes,1,opt,name=identifier_type,json=identifierType,proto3" json:"identifier_type,omitempty"`
+	// 哈希格式，为空时默认md5
+	HashType string `protobuf:"bytes,2,opt,name=hash_type,json=hashType,proto3" json:"hash_type,omitempty"`
+	// 标识哈希列表，最多100个
+	IdentifierHashes []string `protobuf:"bytes,3,rep,name=identifier_hashes,json=identifierHashes,proto3" json:"identifier_hashes,omitempty"`
+	// 查询的名单标识，最多20个，为空时查询API密钥已授权的全部名单
+	Lists         []string `protobuf:"bytes,4,rep,name=lists,proto3" json:"lists,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckBatchRequest) Reset() {
+	*x = CheckBatchRequest{}
+	mi := &file_blacklist_v1_blacklist_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckBatchRequest) ProtoMessage() {}
+
+func (x *CheckBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blacklist_v1_blacklist_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckBatchRequest.ProtoReflect.Descriptor instead.
+func (*CheckBatchRequest) Descriptor() ([]byte, []int) {
+	return file_blacklist_v1_blacklist_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CheckBatchRequest) GetIdentifierType() string {
+	if x != nil {
+		return x.IdentifierType
+	}
+	return ""
+}
+
+func (x *CheckBatchRequest) GetHashType() string {
+	if x != nil {
+		return x.HashType
+	}
+	return ""
+}
+
+func (x *CheckBatchRequest) GetIdentifierHashes() []string {
+	if x != nil {
+		return x.IdentifierHashes
+	}
+	return nil
+}
+
+func (x *CheckBatchRequest) GetLists() []string {
+	if x != nil {
+		return x.Lists
+	}
+	return nil
+}
+
+// CheckBatchResponse 批量查询结果，顺序与请求中的哈希一致
+type CheckBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*CheckResponse       `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	RequestId     string                 `protobuf:"bytes,2,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckBatchResponse) Reset() {
+	*x = CheckBatchResponse{}
+	mi := &file_blacklist_v1_blacklist_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckBatchResponse) ProtoMessage() {}
+
+func (x *CheckBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blacklist_v1_blacklist_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckBatchResponse.ProtoReflect.Descriptor instead.
+func (*CheckBatchResponse) Descriptor() ([]byte, []int) {
+	return file_blacklist_v1_blacklist_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CheckBatchResponse) GetResults() []*CheckResponse {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *CheckBatchResponse) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+var File_blacklist_v1_blacklist_proto protoreflect.FileDescriptor
+
+const file_blacklist_v1_blacklist_proto_rawDesc = "" +
+	"\n" +
+	"\x1cblacklist/v1/blacklist.proto\x12\x13shield.blacklist.v1\"\xd8\x01\n" +
+	"\fCheckRequest\x12'\n" +
+	"\x0fidentifier_type\x18\x01 \x01(\tR\x0eidentifierType\x12\x1b\n" +
+	"\thash_type\x18\x02 \x01(\tR\bhashType\x12'\n" +
+	"\x0fidentifier_hash\x18\x03 \x01(\tR\x0eidentifierHash\x12%\n" +
+	"\x0ecorrelation_id\x18\x04 \x01(\tR\rcorrelationId\x12\x1c\n" +
+	"\tsignature\x18\x05 \x01(\tR\tsignature\x12\x14\n" +
+	"\x05lists\x18\x06 \x03(\tR\x05lists\"\xfe\x02\n" +
+	"\rCheckResponse\x12!\n" +
+	"\fis_blacklist\x18\x01 \x01(\bR\visBlacklist\x12'\n" +
+	"\x0fidentifier_type\x18\x02 \x01(\tR\x0eidentifierType\x12\x1b\n" +
+	"\thash_type\x18\x03 \x01(\tR\bhashType\x12'\n" +
+	"\x0fidentifier_hash\x18\x04 \x01(\tR\x0eidentifierHash\x12\x1a\n" +
+	"\bcategory\x18\x05 \x01(\tR\bcategory\x12\x1d\n" +
+	"\n" +
+	"risk_score\x18\x06 \x01(\x05R\triskScore\x12%\n" +
+	"\x0ecorrelation_id\x18\a \x01(\tR\rcorrelationId\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\b \x01(\tR\trequestId\x12\x1b\n" +
+	"\thit_lists\x18\t \x03(\tR\bhitLists\x12\x1d\n" +
+	"\n" +
+	"hit_source\x18\n" +
+	" \x01(\tR\thitSource\x12\x1e\n" +
+	"\n" +
+	"overridden\x18\v \x01(\bR\n" +
+	"overridden\"\x9c\x01\n" +
+	"\x11CheckBatchRequest\x12'\n" +
+	"\x0fidentifier_type\x18\x01 \x01(\tR\x0eidentifierType\x12\x1b\n" +
+	"\thash_type\x18\x02 \x01(\tR\bhashType\x12+\n" +
+	"\x11identifier_hashes\x18\x03 \x03(\tR\x10identifierHashes\x12\x14\n" +
+	"\x05lists\x18\x04 \x03(\tR\x05lists\"q\n" +
+	"\x12CheckBatchResponse\x12<\n" +
+	"\aresults\x18\x01 \x03(\v2\".shield.blacklist.v1.CheckResponseR\aresults\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x02 \x01(\tR\trequestId2\x9b\x02\n" +
+	"\x10BlacklistService\x12N\n" +
+	"\x05Check\x12!.shield.blacklist.v1.CheckRequest\x1a\".shield.blacklist.v1.CheckResponse\x12]\n" +
+	"\n" +
+	"CheckBatch\x12&.shield.blacklist.v1.CheckBatchRequest\x1a'.shield.blacklist.v1.CheckBatchResponse\x12X\n" +
+	"\vCheckStream\x12!.shield.blacklist.v1.CheckRequest\x1a\".shield.blacklist.v1.CheckResponse(\x010\x01B?Z=github.com/varluffy/shield/api/proto/blacklist/v1;blacklistv1b\x06proto3"
+
+var (
+	file_blacklist_v1_blacklist_proto_rawDescOnce sync.Once
+	file_blacklist_v1_blacklist_proto_rawDescData []byte
+)
+
+func file_blacklist_v1_blacklist_proto_rawDescGZIP() []byte {
+	file_blacklist_v1_blacklist_proto_rawDescOnce.Do(func() {
+		file_blacklist_v1_blacklist_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_blacklist_v1_blacklist_proto_rawDesc), len(file_blacklist_v1_blacklist_proto_rawDesc)))
+	})
+	return file_blacklist_v1_blacklist_proto_rawDescData
+}
+
+var file_blacklist_v1_blacklist_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_blacklist_v1_blacklist_proto_goTypes = []any{
+	(*CheckRequest)(nil),       // 0: shield.blacklist.v1.CheckRequest
+	(*CheckResponse)(nil),      // 1: shield.blacklist.v1.CheckResponse
+	(*CheckBatchRequest)(nil),  // 2: shield.blacklist.v1.CheckBatchRequest
+	(*CheckBatchResponse)(nil), // 3: shield.blacklist.v1.CheckBatchResponse
+}
+var file_blacklist_v1_blacklist_proto_depIdxs = []int32{
+	1, // 0: shield.blacklist.v1.CheckBatchResponse.results:type_name -> shield.blacklist.v1.CheckResponse
+	0, // 1: shield.blacklist.v1.BlacklistService.Check:input_type -> shield.blacklist.v1.CheckRequest
+	2, // 2: shield.blacklist.v1.BlacklistService.CheckBatch:input_type -> shield.blacklist.v1.CheckBatchRequest
+	0, // 3: shield.blacklist.v1.BlacklistService.CheckStream:input_type -> shield.blacklist.v1.CheckRequest
+	1, // 4: shield.blacklist.v1.BlacklistService.Check:output_type -> shield.blacklist.v1.CheckResponse
+	3, // 5: shield.blacklist.v1.BlacklistService.CheckBatch:output_type -> shield.blacklist.v1.CheckBatchResponse
+	1, // 6: shield.blacklist.v1.BlacklistService.CheckStream:output_type -> shield.blacklist.v1.CheckResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_blacklist_v1_blacklist_proto_init() }
+func file_blacklist_v1_blacklist_proto_init() {
+	if File_blacklist_v1_blacklist_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_blacklist_v1_blacklist_proto_rawDesc), len(file_blacklist_v1_blacklist_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_blacklist_v1_blacklist_proto_goTypes,
+		DependencyIndexes: file_blacklist_v1_blacklist_proto_depIdxs,
+		MessageInfos:      file_blacklist_v1_blacklist_proto_msgTypes,
+	}.Build()
+	File_blacklist_v1_blacklist_proto = out.File
+	file_blacklist_v1_blacklist_proto_goTypes = nil
+	file_blacklist_v1_blacklist_proto_depIdxs = nil
+}
@@ -0,0 +1,210 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: blacklist/v1/blacklist.proto
+
+package blacklistv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	BlacklistService_Check_FullMethodName       = "/shield.blacklist.v1.BlacklistService/Check"
+	BlacklistService_CheckBatch_FullMethodName  = "/shield.blacklist.v1.BlacklistService/CheckBatch"
+	BlacklistService_CheckStream_FullMethodName = "/shield.blacklist.v1.BlacklistService/CheckStream"
+)
+
+// BlacklistServiceClient is the client API for BlacklistService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BlacklistService 黑名单查询服务，与HTTP查询接口使用相同的API密钥、IP白名单和速率限制。
+// 请求元数据需携带x-api-key、x-timestamp、x-nonce和x-signature，
+// 单次调用的签名为hex(HMAC-SHA256(api_secret, api_key + timestamp + nonce + 方法全名 + 请求消息))，
+// 方法全名如/shield.blacklist.v1.BlacklistService/Check，请求消息为按字段编号顺序的protobuf二进制序列化（各语言官方实现的默认序列化）。
+// 流式调用建立时的签名不含请求消息，流中每条请求另行通过CheckRequest.signature签名。
+type BlacklistServiceClient interface {
+	// Check 查询单个标识哈希
+	Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error)
+	// CheckBatch 批量查询，单次最多100个哈希
+	CheckBatch(ctx context.Context, in *CheckBatchRequest, opts ...grpc.CallOption) (*CheckBatchResponse, error)
+	// CheckStream 双向流查询，每条请求按顺序返回一条响应，每条请求单独计入速率限制
+	CheckStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[CheckRequest, CheckResponse], error)
+}
+
+type blacklistServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBlacklistServiceClient(cc grpc.ClientConnInterface) BlacklistServiceClient {
+	return &blacklistServiceClient{cc}
+}
+
+func (c *blacklistServiceClient) Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckResponse)
+	err := c.cc.Invoke(ctx, BlacklistService_Check_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blacklistServiceClient) CheckBatch(ctx context.Context, in *CheckBatchRequest, opts ...grpc.CallOption) (*CheckBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckBatchResponse)
+	err := c.cc.Invoke(ctx, BlacklistService_CheckBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *blacklistServiceClient) CheckStream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[CheckRequest, CheckResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BlacklistService_ServiceDesc.Streams[0], BlacklistService_CheckStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[CheckRequest, CheckResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BlacklistService_CheckStreamClient = grpc.BidiStreamingClient[CheckRequest, CheckResponse]
+
+// BlacklistServiceServer is the server API for BlacklistService service.
+// All implementations must embed UnimplementedBlacklistServiceServer
+// for forward compatibility.
+//
+// BlacklistService 黑名单查询服务，与HTTP查询接口使用相同的API密钥、IP白名单和速率限制。
+// 请求元数据需携带x-api-key、x-timestamp、x-nonce和x-signature，
+// 单次调用的签名为hex(HMAC-SHA256(api_secret, api_key + timestamp + nonce + 方法全名 + 请求消息))，
+// 方法全名如/shield.blacklist.v1.BlacklistService/Check，请求消息为按字段编号顺序的protobuf二进制序列化（各语言官方实现的默认序列化）。
+// 流式调用建立时的签名不含请求消息，流中每条请求另行通过CheckRequest.signature签名。
+type BlacklistServiceServer interface {
+	// Check 查询单个标识哈希
+	Check(context.Context, *CheckRequest) (*CheckResponse, error)
+	// CheckBatch 批量查询，单次最多100个哈希
+	CheckBatch(context.Context, *CheckBatchRequest) (*CheckBatchResponse, error)
+	// CheckStream 双向流查询，每条请求按顺序返回一条响应，每条请求单独计入速率限制
+	CheckStream(grpc.BidiStreamingServer[CheckRequest, CheckResponse]) error
+	mustEmbedUnimplementedBlacklistServiceServer()
+}
+
+// UnimplementedBlacklistServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBlacklistServiceServer struct{}
+
+func (UnimplementedBlacklistServiceServer) Check(context.Context, *CheckRequest) (*CheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
+}
+func (UnimplementedBlacklistServiceServer) CheckBatch(context.Context, *CheckBatchRequest) (*CheckBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckBatch not implemented")
+}
+func (UnimplementedBlacklistServiceServer) CheckStream(grpc.BidiStreamingServer[CheckRequest, CheckResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method CheckStream not implemented")
+}
+func (UnimplementedBlacklistServiceServer) mustEmbedUnimplementedBlacklistServiceServer() {}
+func (UnimplementedBlacklistServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeBlacklistServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BlacklistServiceServer will
+// result in compilation errors.
+type UnsafeBlacklistServiceServer interface {
+	mustEmbedUnimplementedBlacklistServiceServer()
+}
+
+func RegisterBlacklistServiceServer(s grpc.ServiceRegistrar, srv BlacklistServiceServer) {
+	// If the following call pancis, it indicates UnimplementedBlacklistServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BlacklistService_ServiceDesc, srv)
+}
+
+func _BlacklistService_Check_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlacklistServiceServer).Check(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BlacklistService_Check_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlacklistServiceServer).Check(ctx, req.(*CheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlacklistService_CheckBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BlacklistServiceServer).CheckBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BlacklistService_CheckBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BlacklistServiceServer).CheckBatch(ctx, req.(*CheckBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BlacklistService_CheckStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BlacklistServiceServer).CheckStream(&grpc.GenericServerStream[CheckRequest, CheckResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BlacklistService_CheckStreamServer = grpc.BidiStreamingServer[CheckRequest, CheckResponse]
+
+// BlacklistService_ServiceDesc is the grpc.ServiceDesc for BlacklistService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BlacklistService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "shield.blacklist.v1.BlacklistService",
+	HandlerType: (*BlacklistServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Check",
+			Handler:    _BlacklistService_Check_Handler,
+		},
+		{
+			MethodName: "CheckBatch",
+			Handler:    _BlacklistService_CheckBatch_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CheckStream",
+			Handler:       _BlacklistService_CheckStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "blacklist/v1/blacklist.proto",
+}
@@ -0,0 +1,12 @@
+-- Description: Add expiry time to phone blacklist entries
+-- Created: 20250801_100000
+
+-- +migrate Up
+ALTER TABLE `phone_blacklists`
+    ADD COLUMN `expires_at` datetime(3) DEFAULT NULL COMMENT '过期时间，为空表示永久有效' AFTER `is_active`,
+    ADD KEY `idx_phone_blacklists_expires_at` (`expires_at`);
+
+-- +migrate Down
+ALTER TABLE `phone_blacklists`
+    DROP KEY `idx_phone_blacklists_expires_at`,
+    DROP COLUMN `expires_at`;
@@ -0,0 +1,15 @@
+-- Description: Add identifier type to blacklist entries for multi-identifier lists
+-- Created: 20250805_100000
+
+-- +migrate Up
+ALTER TABLE `phone_blacklists`
+    ADD COLUMN `identifier_type` varchar(20) NOT NULL DEFAULT 'phone' COMMENT '标识类型：phone, id_card, device_id, email, ip, bank_card' AFTER `tenant_id`,
+    DROP KEY `uk_tenant_phone_md5`,
+    ADD UNIQUE KEY `uk_tenant_identifier` (`tenant_id`,`identifier_type`,`phone_md5`);
+
+-- +migrate Down
+DELETE FROM `phone_blacklists` WHERE `identifier_type` <> 'phone';
+ALTER TABLE `phone_blacklists`
+    DROP KEY `uk_tenant_identifier`,
+    ADD UNIQUE KEY `uk_tenant_phone_md5` (`tenant_id`,`phone_md5`),
+    DROP COLUMN `identifier_type`;
@@ -0,0 +1,32 @@
+-- Description: Add SHA-256 identifier hashes and per-tenant hash salts for blacklist entries
+-- Created: 20250810_100000
+
+-- +migrate Up
+ALTER TABLE `phone_blacklists`
+    MODIFY COLUMN `phone_md5` char(32) NOT NULL DEFAULT '' COMMENT '标识MD5，仅提供SHA-256的条目为空',
+    ADD COLUMN `identifier_sha256` char(64) NOT NULL DEFAULT '' COMMENT '标识SHA-256，历史MD5条目为空' AFTER `phone_md5`,
+    DROP KEY `uk_tenant_identifier`,
+    ADD UNIQUE KEY `uk_tenant_identifier` (`tenant_id`,`identifier_type`,`phone_md5`,`identifier_sha256`),
+    ADD KEY `idx_tenant_identifier_sha256` (`tenant_id`,`identifier_type`,`identifier_sha256`);
+
+CREATE TABLE IF NOT EXISTS `blacklist_tenant_settings` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `hash_salt` varchar(64) NOT NULL COMMENT 'HMAC-SHA256格式使用的租户盐',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    `deleted_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_tenant_id` (`tenant_id`),
+    KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='租户黑名单配置表';
+
+-- +migrate Down
+DROP TABLE IF EXISTS `blacklist_tenant_settings`;
+DELETE FROM `phone_blacklists` WHERE `phone_md5` = '';
+ALTER TABLE `phone_blacklists`
+    DROP KEY `idx_tenant_identifier_sha256`,
+    DROP KEY `uk_tenant_identifier`,
+    ADD UNIQUE KEY `uk_tenant_identifier` (`tenant_id`,`identifier_type`,`phone_md5`),
+    DROP COLUMN `identifier_sha256`,
+    MODIFY COLUMN `phone_md5` char(32) NOT NULL;
@@ -0,0 +1,18 @@
+-- Description: Add risk category and score to blacklist entries and score threshold to API credentials
+-- Created: 20250812_100000
+
+-- +migrate Up
+ALTER TABLE `phone_blacklists`
+    ADD COLUMN `category` varchar(30) NOT NULL DEFAULT '' COMMENT '风险分类：fraud, complaint, collection_harassment, other' AFTER `identifier_sha256`,
+    ADD COLUMN `risk_score` int NOT NULL DEFAULT '100' COMMENT '风险分 1-100' AFTER `category`;
+
+ALTER TABLE `blacklist_api_credentials`
+    ADD COLUMN `min_risk_score` int NOT NULL DEFAULT '0' COMMENT '仅返回风险分不低于该值的命中，0表示全部返回' AFTER `status`;
+
+-- +migrate Down
+ALTER TABLE `blacklist_api_credentials`
+    DROP COLUMN `min_risk_score`;
+
+ALTER TABLE `phone_blacklists`
+    DROP COLUMN `risk_score`,
+    DROP COLUMN `category`;
@@ -0,0 +1,61 @@
+-- Description: Create blacklist asynchronous import job tables
+-- Created: 20250815_100000
+
+-- +migrate Up
+-- 黑名单异步导入任务表
+CREATE TABLE IF NOT EXISTS `blacklist_import_jobs` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `uuid` char(36) NOT NULL,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `status` varchar(20) NOT NULL DEFAULT 'pending' COMMENT '状态：pending, running, completed, failed, cancelled',
+    `file_name` varchar(255) NOT NULL COMMENT '上传文件名',
+    `file_size` bigint NOT NULL COMMENT '文件大小(字节)',
+    `value_type` varchar(20) NOT NULL DEFAULT 'phone' COMMENT '文件内容类型：phone, md5, sha256',
+    `identifier_type` varchar(20) NOT NULL DEFAULT 'phone' COMMENT '标识类型',
+    `column_index` int NOT NULL DEFAULT '0' COMMENT '数据所在列，从0开始',
+    `column_name` varchar(100) NOT NULL DEFAULT '' COMMENT '按表头名称定位数据列',
+    `source` varchar(50) NOT NULL COMMENT '来源',
+    `reason` varchar(200) DEFAULT NULL COMMENT '加入黑名单原因',
+    `category` varchar(30) NOT NULL DEFAULT '' COMMENT '风险分类',
+    `risk_score` int NOT NULL DEFAULT '0' COMMENT '风险分，0表示默认',
+    `entry_expires_at` datetime(3) DEFAULT NULL COMMENT '导入条目的过期时间',
+    `operator_id` bigint unsigned DEFAULT NULL COMMENT '操作人ID',
+    `total_rows` bigint NOT NULL DEFAULT '0' COMMENT '数据行数',
+    `processed_rows` bigint NOT NULL DEFAULT '0' COMMENT '已提交的数据行数',
+    `accepted_count` bigint NOT NULL DEFAULT '0' COMMENT '新增条目数',
+    `duplicate_count` bigint NOT NULL DEFAULT '0' COMMENT '重复行数',
+    `invalid_count` bigint NOT NULL DEFAULT '0' COMMENT '无效行数',
+    `backfilled_count` bigint NOT NULL DEFAULT '0' COMMENT '补充SHA-256的历史条目数',
+    `row_errors` text COMMENT '无效行样例(JSON)',
+    `error_message` varchar(500) NOT NULL DEFAULT '' COMMENT '任务失败原因',
+    `cancel_requested` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否已请求取消',
+    `worker_id` varchar(64) NOT NULL DEFAULT '' COMMENT '执行实例',
+    `heartbeat_at` datetime(3) DEFAULT NULL COMMENT '执行实例心跳时间',
+    `started_at` datetime(3) DEFAULT NULL COMMENT '开始时间',
+    `finished_at` datetime(3) DEFAULT NULL COMMENT '结束时间',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    `deleted_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_blacklist_import_jobs_uuid` (`uuid`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_status` (`status`),
+    KEY `idx_operator_id` (`operator_id`),
+    KEY `idx_heartbeat_at` (`heartbeat_at`),
+    KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单异步导入任务表';
+
+-- 导入任务上传文件分片表
+CREATE TABLE IF NOT EXISTS `blacklist_import_job_files` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `job_id` bigint unsigned NOT NULL COMMENT '导入任务ID',
+    `seq` int NOT NULL COMMENT '分片序号',
+    `data` mediumblob NOT NULL COMMENT '分片内容',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_job_seq` (`job_id`,`seq`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='导入任务上传文件分片表';
+
+-- +migrate Down
+DROP TABLE IF EXISTS `blacklist_import_job_files`;
+DROP TABLE IF EXISTS `blacklist_import_jobs`;
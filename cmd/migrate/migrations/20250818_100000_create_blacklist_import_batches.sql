@@ -0,0 +1,48 @@
+-- Description: Create blacklist import batches and tag entries with their batch
+-- Created: 20250818_100000
+
+-- +migrate Up
+-- 黑名单导入批次表
+CREATE TABLE IF NOT EXISTS `blacklist_import_batches` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `uuid` char(36) NOT NULL,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `method` varchar(20) NOT NULL COMMENT '导入方式：api, file, job',
+    `status` varchar(20) NOT NULL DEFAULT 'importing' COMMENT '状态：importing, completed, rolling_back, rolled_back',
+    `identifier_type` varchar(20) NOT NULL DEFAULT 'phone' COMMENT '标识类型',
+    `source` varchar(50) NOT NULL COMMENT '来源',
+    `reason` varchar(200) DEFAULT NULL COMMENT '加入黑名单原因',
+    `file_name` varchar(255) NOT NULL DEFAULT '' COMMENT '导入文件名',
+    `import_job_uuid` varchar(36) NOT NULL DEFAULT '' COMMENT '异步导入任务ID',
+    `operator_id` bigint unsigned DEFAULT NULL COMMENT '操作人ID',
+    `entry_count` bigint NOT NULL DEFAULT '0' COMMENT '新增的条目数',
+    `rolled_back_count` bigint NOT NULL DEFAULT '0' COMMENT '回滚时删除的条目数',
+    `rolled_back_by` bigint unsigned NOT NULL DEFAULT '0' COMMENT '回滚操作人ID',
+    `rolled_back_at` datetime(3) DEFAULT NULL COMMENT '回滚时间',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    `deleted_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_blacklist_import_batches_uuid` (`uuid`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_status` (`status`),
+    KEY `idx_operator_id` (`operator_id`),
+    KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单导入批次表';
+
+ALTER TABLE `blacklist_import_jobs`
+    ADD COLUMN `batch_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '导入批次ID' AFTER `operator_id`;
+
+ALTER TABLE `phone_blacklists`
+    ADD COLUMN `batch_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '导入批次ID，0表示非批量导入' AFTER `expires_at`,
+    ADD KEY `idx_phone_blacklists_batch_id` (`batch_id`);
+
+-- +migrate Down
+ALTER TABLE `phone_blacklists`
+    DROP KEY `idx_phone_blacklists_batch_id`,
+    DROP COLUMN `batch_id`;
+
+ALTER TABLE `blacklist_import_jobs`
+    DROP COLUMN `batch_id`;
+
+DROP TABLE IF EXISTS `blacklist_import_batches`;
@@ -0,0 +1,25 @@
+-- Description: Create blacklist drift reports for DB-to-Redis reconciliation
+-- Created: 20250820_100000
+
+-- +migrate Up
+-- 黑名单Redis偏差检查记录表
+CREATE TABLE IF NOT EXISTS `blacklist_drift_reports` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `triggered_by` varchar(20) NOT NULL COMMENT '触发方式：scheduled, manual',
+    `active_count` bigint NOT NULL DEFAULT '0' COMMENT '数据库中的有效条目数',
+    `missing_count` bigint NOT NULL DEFAULT '0' COMMENT 'Redis缺失的成员数',
+    `extra_count` bigint NOT NULL DEFAULT '0' COMMENT 'Redis多余的成员数',
+    `repaired` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否已修复',
+    `error_message` varchar(500) NOT NULL DEFAULT '' COMMENT '检查或修复失败原因',
+    `duration_ms` bigint NOT NULL DEFAULT '0' COMMENT '耗时(毫秒)',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    `deleted_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单Redis偏差检查记录表';
+
+-- +migrate Down
+DROP TABLE IF EXISTS `blacklist_drift_reports`;
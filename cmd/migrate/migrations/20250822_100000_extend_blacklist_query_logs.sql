@@ -0,0 +1,31 @@
+-- Description: Extend blacklist query logs for per-identifier records and add per-credential log sample rate
+-- Created: 20250822_100000
+
+-- +migrate Up
+ALTER TABLE `blacklist_query_logs`
+    CHANGE COLUMN `phone_md5` `identifier_hash` varchar(64) NOT NULL DEFAULT '' COMMENT '查询的标识哈希，请求参数错误时为空',
+    ADD COLUMN `identifier_type` varchar(20) NOT NULL DEFAULT '' COMMENT '标识类型' AFTER `request_id`,
+    ADD COLUMN `hash_type` varchar(20) NOT NULL DEFAULT '' COMMENT '哈希格式' AFTER `identifier_type`,
+    ADD COLUMN `status_code` int NOT NULL DEFAULT '0' COMMENT 'HTTP状态码' AFTER `is_hit`,
+    ADD COLUMN `sampled` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否按采样率抽中' AFTER `response_time`,
+    ADD KEY `idx_request_id` (`request_id`),
+    ADD KEY `idx_tenant_hash` (`tenant_id`, `identifier_hash`);
+
+ALTER TABLE `blacklist_api_credentials`
+    ADD COLUMN `log_sample_rate` decimal(5,4) NOT NULL DEFAULT '0.0100' COMMENT '详细查询日志采样率 0-1，命中和错误请求不受采样率限制' AFTER `min_risk_score`;
+
+-- +migrate Down
+ALTER TABLE `blacklist_api_credentials`
+    DROP COLUMN `log_sample_rate`;
+
+-- 非MD5格式的哈希无法还原为char(32)，回滚时删除
+DELETE FROM `blacklist_query_logs` WHERE CHAR_LENGTH(`identifier_hash`) > 32;
+
+ALTER TABLE `blacklist_query_logs`
+    DROP KEY `idx_tenant_hash`,
+    DROP KEY `idx_request_id`,
+    DROP COLUMN `sampled`,
+    DROP COLUMN `status_code`,
+    DROP COLUMN `hash_type`,
+    DROP COLUMN `identifier_type`,
+    CHANGE COLUMN `identifier_hash` `phone_md5` char(32) NOT NULL COMMENT '查询的手机号MD5';
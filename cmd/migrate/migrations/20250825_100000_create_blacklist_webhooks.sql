@@ -0,0 +1,69 @@
+-- Description: Create blacklist webhook subscriptions, deliveries and delivery attempts
+-- Created: 20250825_100000
+
+-- +migrate Up
+-- 黑名单Webhook订阅表
+CREATE TABLE IF NOT EXISTS `blacklist_webhooks` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `uuid` char(36) NOT NULL,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `name` varchar(100) NOT NULL COMMENT '名称',
+    `url` varchar(500) NOT NULL COMMENT '接收地址',
+    `secret` varchar(64) NOT NULL COMMENT '签名密钥',
+    `event_types` varchar(255) NOT NULL COMMENT '订阅的事件类型，逗号分隔',
+    `status` varchar(20) NOT NULL DEFAULT 'active' COMMENT '状态：active, disabled',
+    `description` varchar(255) NOT NULL DEFAULT '' COMMENT '描述',
+    `created_by` bigint unsigned NOT NULL DEFAULT '0' COMMENT '创建人ID',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    `deleted_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_blacklist_webhooks_uuid` (`uuid`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单Webhook订阅表';
+
+-- 黑名单Webhook投递表
+CREATE TABLE IF NOT EXISTS `blacklist_webhook_deliveries` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `webhook_id` bigint unsigned NOT NULL COMMENT 'Webhook订阅ID',
+    `event_id` char(36) NOT NULL COMMENT '事件ID，重试时不变',
+    `event_type` varchar(50) NOT NULL COMMENT '事件类型',
+    `payload` mediumtext NOT NULL COMMENT '事件内容',
+    `status` varchar(20) NOT NULL DEFAULT 'pending' COMMENT '状态：pending, succeeded, dead',
+    `attempts` int NOT NULL DEFAULT '0' COMMENT '已投递次数',
+    `next_attempt_at` datetime(3) DEFAULT NULL COMMENT '下次投递时间',
+    `last_status_code` int NOT NULL DEFAULT '0' COMMENT '最近一次响应状态码',
+    `last_error` varchar(500) NOT NULL DEFAULT '' COMMENT '最近一次失败原因',
+    `last_attempt_at` datetime(3) DEFAULT NULL COMMENT '最近一次投递时间',
+    `delivered_at` datetime(3) DEFAULT NULL COMMENT '投递成功时间',
+    `worker_id` varchar(64) NOT NULL DEFAULT '' COMMENT '投递实例',
+    `lease_until` datetime(3) DEFAULT NULL COMMENT '投递租约到期时间',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    `deleted_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `idx_tenant_webhook` (`tenant_id`, `webhook_id`),
+    KEY `idx_status_next` (`status`, `next_attempt_at`),
+    KEY `idx_event_id` (`event_id`),
+    KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单Webhook投递表';
+
+-- 黑名单Webhook投递尝试记录表
+CREATE TABLE IF NOT EXISTS `blacklist_webhook_attempts` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `delivery_id` bigint unsigned NOT NULL COMMENT '投递ID',
+    `attempt` int NOT NULL COMMENT '第几次投递',
+    `status_code` int NOT NULL DEFAULT '0' COMMENT '响应状态码，0表示未收到响应',
+    `error` varchar(500) NOT NULL DEFAULT '' COMMENT '失败原因',
+    `duration_ms` bigint NOT NULL DEFAULT '0' COMMENT '耗时(毫秒)',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    PRIMARY KEY (`id`),
+    KEY `idx_delivery_id` (`delivery_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单Webhook投递尝试记录表';
+
+-- +migrate Down
+DROP TABLE IF EXISTS `blacklist_webhook_attempts`;
+DROP TABLE IF EXISTS `blacklist_webhook_deliveries`;
+DROP TABLE IF EXISTS `blacklist_webhooks`;
@@ -0,0 +1,43 @@
+-- Description: Create hourly and daily blacklist query stats rollup tables
+-- Created: 20250828_100000
+
+-- +migrate Up
+-- 黑名单查询小时统计表
+CREATE TABLE IF NOT EXISTS `blacklist_query_stats_hourly` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `api_key` varchar(64) NOT NULL DEFAULT '' COMMENT 'API密钥，为空表示租户汇总',
+    `period_start` datetime NOT NULL COMMENT '统计时段开始时间',
+    `total_queries` bigint NOT NULL DEFAULT '0' COMMENT '查询次数',
+    `hit_count` bigint NOT NULL DEFAULT '0' COMMENT '命中次数',
+    `latency_sum` bigint NOT NULL DEFAULT '0' COMMENT '延迟总和(毫秒)',
+    `latency_count` bigint NOT NULL DEFAULT '0' COMMENT '记录延迟的查询次数',
+    `peak_minute_queries` bigint NOT NULL DEFAULT '0' COMMENT '时段内单分钟最大查询次数',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_tenant_key_period` (`tenant_id`, `api_key`, `period_start`),
+    KEY `idx_period_start` (`period_start`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单查询小时统计表';
+
+-- 黑名单查询日统计表
+CREATE TABLE IF NOT EXISTS `blacklist_query_stats_daily` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `api_key` varchar(64) NOT NULL DEFAULT '' COMMENT 'API密钥，为空表示租户汇总',
+    `period_start` datetime NOT NULL COMMENT '统计日期',
+    `total_queries` bigint NOT NULL DEFAULT '0' COMMENT '查询次数',
+    `hit_count` bigint NOT NULL DEFAULT '0' COMMENT '命中次数',
+    `latency_sum` bigint NOT NULL DEFAULT '0' COMMENT '延迟总和(毫秒)',
+    `latency_count` bigint NOT NULL DEFAULT '0' COMMENT '记录延迟的查询次数',
+    `peak_minute_queries` bigint NOT NULL DEFAULT '0' COMMENT '当日单分钟最大查询次数',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_tenant_key_period` (`tenant_id`, `api_key`, `period_start`),
+    KEY `idx_period_start` (`period_start`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单查询日统计表';
+
+-- +migrate Down
+DROP TABLE IF EXISTS `blacklist_query_stats_daily`;
+DROP TABLE IF EXISTS `blacklist_query_stats_hourly`;
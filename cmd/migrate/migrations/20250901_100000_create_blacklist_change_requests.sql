@@ -0,0 +1,42 @@
+-- Description: Add per-tenant approval mode and blacklist change requests for maker-checker review
+-- Created: 20250901_100000
+
+-- +migrate Up
+ALTER TABLE `blacklist_tenant_settings`
+    ADD COLUMN `require_approval` tinyint(1) NOT NULL DEFAULT '0' COMMENT '新增和删除条目是否需要审批' AFTER `hash_salt`;
+
+-- 黑名单变更申请表
+CREATE TABLE IF NOT EXISTS `blacklist_change_requests` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `uuid` char(36) NOT NULL,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `action` varchar(20) NOT NULL COMMENT '变更类型：create, delete',
+    `status` varchar(20) NOT NULL DEFAULT 'pending' COMMENT '状态：pending, approved, rejected',
+    `blacklist_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '删除申请的目标条目，新增申请通过后为新建条目',
+    `identifier_type` varchar(20) NOT NULL DEFAULT 'phone' COMMENT '标识类型',
+    `phone_md5` char(32) NOT NULL DEFAULT '' COMMENT '标识MD5',
+    `identifier_sha256` char(64) NOT NULL DEFAULT '' COMMENT '标识SHA-256',
+    `source` varchar(50) NOT NULL DEFAULT '' COMMENT '来源',
+    `reason` varchar(200) DEFAULT NULL COMMENT '加入黑名单原因',
+    `category` varchar(30) NOT NULL DEFAULT '' COMMENT '风险分类',
+    `risk_score` int NOT NULL DEFAULT '0' COMMENT '风险分，0表示默认',
+    `expires_at` datetime(3) DEFAULT NULL COMMENT '条目过期时间',
+    `requested_by` bigint unsigned NOT NULL COMMENT '申请人ID',
+    `reviewed_by` bigint unsigned NOT NULL DEFAULT '0' COMMENT '审批人ID',
+    `reviewed_at` datetime(3) DEFAULT NULL COMMENT '审批时间',
+    `review_comment` varchar(500) NOT NULL DEFAULT '' COMMENT '审批意见',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    `deleted_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_blacklist_change_requests_uuid` (`uuid`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_status` (`status`),
+    KEY `idx_blacklist_id` (`blacklist_id`),
+    KEY `idx_requested_by` (`requested_by`),
+    KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单变更申请表';
+
+-- +migrate Down
+DROP TABLE IF EXISTS `blacklist_change_requests`;
+ALTER TABLE `blacklist_tenant_settings` DROP COLUMN `require_approval`;
@@ -0,0 +1,29 @@
+-- Description: Create per-tenant allowlist entries that override blacklist hits
+-- Created: 20250903_100000
+
+-- +migrate Up
+-- 黑名单白名单表
+CREATE TABLE IF NOT EXISTS `blacklist_allowlist_entries` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `uuid` char(36) NOT NULL,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `identifier_type` varchar(20) NOT NULL DEFAULT 'phone' COMMENT '标识类型',
+    `phone_md5` char(32) NOT NULL DEFAULT '' COMMENT '标识MD5',
+    `identifier_sha256` char(64) NOT NULL DEFAULT '' COMMENT '标识SHA-256',
+    `reason` varchar(200) NOT NULL COMMENT '加入白名单原因',
+    `operator_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '操作人ID',
+    `expires_at` datetime(3) DEFAULT NULL COMMENT '过期时间，为空表示永久有效',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    `deleted_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_blacklist_allowlist_entries_uuid` (`uuid`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_phone_md5` (`phone_md5`),
+    KEY `idx_identifier_sha256` (`identifier_sha256`),
+    KEY `idx_expires_at` (`expires_at`),
+    KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单白名单表';
+
+-- +migrate Down
+DROP TABLE IF EXISTS `blacklist_allowlist_entries`;
@@ -0,0 +1,46 @@
+-- Description: Add named blacklist lists within a tenant and per-credential list grants
+-- Created: 20250905_100000
+
+-- +migrate Up
+-- 黑名单名单表，list_id为0的条目属于隐式的默认名单
+CREATE TABLE IF NOT EXISTS `blacklist_lists` (
+    `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+    `uuid` char(36) NOT NULL,
+    `tenant_id` bigint unsigned NOT NULL COMMENT '租户ID',
+    `name` varchar(50) NOT NULL COMMENT '名单标识，租户内唯一，创建后不可修改',
+    `description` varchar(200) NOT NULL DEFAULT '' COMMENT '名单描述',
+    `created_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+    `updated_at` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
+    `deleted_at` datetime(3) DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_blacklist_lists_uuid` (`uuid`),
+    KEY `idx_tenant_id` (`tenant_id`),
+    KEY `idx_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='黑名单名单表';
+
+-- list_id放在唯一键末尾，按租户、类型和哈希的查询仍可使用该索引
+ALTER TABLE `phone_blacklists`
+    ADD COLUMN `list_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '所属名单ID，0表示默认名单' AFTER `tenant_id`,
+    DROP KEY `uk_tenant_identifier`,
+    ADD UNIQUE KEY `uk_tenant_identifier` (`tenant_id`,`identifier_type`,`phone_md5`,`identifier_sha256`,`list_id`);
+
+ALTER TABLE `blacklist_change_requests`
+    ADD COLUMN `list_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '新增申请的目标名单ID，0表示默认名单' AFTER `blacklist_id`;
+
+ALTER TABLE `blacklist_api_credentials`
+    ADD COLUMN `lists` varchar(500) NOT NULL DEFAULT '' COMMENT '可查询的名单标识，逗号分隔，为空表示仅默认名单' AFTER `min_risk_score`;
+
+-- +migrate Down
+ALTER TABLE `blacklist_api_credentials`
+    DROP COLUMN `lists`;
+
+ALTER TABLE `blacklist_change_requests`
+    DROP COLUMN `list_id`;
+
+DELETE FROM `phone_blacklists` WHERE `list_id` <> 0;
+ALTER TABLE `phone_blacklists`
+    DROP KEY `uk_tenant_identifier`,
+    ADD UNIQUE KEY `uk_tenant_identifier` (`tenant_id`,`identifier_type`,`phone_md5`,`identifier_sha256`),
+    DROP COLUMN `list_id`;
+
+DROP TABLE IF EXISTS `blacklist_lists`;
@@ -0,0 +1,21 @@
+-- Description: Add consortium shared blacklist owned by the system tenant with per-tenant subscription and contribution
+-- Created: 20250906_100000
+
+-- +migrate Up
+-- 共享名单的条目归属系统租户（tenant_id=0），contributor_tenant_id记录贡献租户，仅系统管理员和贡献租户本身可见
+ALTER TABLE `phone_blacklists`
+    ADD COLUMN `contributor_tenant_id` bigint unsigned NOT NULL DEFAULT '0' COMMENT '共享名单条目的贡献租户ID，0表示由系统管理员维护' AFTER `list_id`,
+    ADD KEY `idx_phone_blacklists_contributor_tenant_id` (`contributor_tenant_id`);
+
+ALTER TABLE `blacklist_tenant_settings`
+    ADD COLUMN `shared_subscribed` tinyint(1) NOT NULL DEFAULT '0' COMMENT '查询时是否同时查询共享名单' AFTER `require_approval`,
+    ADD COLUMN `shared_contributor` tinyint(1) NOT NULL DEFAULT '0' COMMENT '是否可向共享名单贡献条目' AFTER `shared_subscribed`;
+
+-- +migrate Down
+ALTER TABLE `blacklist_tenant_settings`
+    DROP COLUMN `shared_contributor`,
+    DROP COLUMN `shared_subscribed`;
+
+ALTER TABLE `phone_blacklists`
+    DROP KEY `idx_phone_blacklists_contributor_tenant_id`,
+    DROP COLUMN `contributor_tenant_id`;
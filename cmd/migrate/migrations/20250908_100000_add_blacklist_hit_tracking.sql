@@ -0,0 +1,17 @@
+-- Description: Track per-entry hit count and last hit time, aggregated in Redis and flushed periodically
+-- Created: 20250908_100000
+
+-- +migrate Up
+-- 命中次数和最后命中时间由后台任务每分钟从Redis汇总写入，不更新updated_at
+ALTER TABLE `phone_blacklists`
+    ADD COLUMN `hit_count` bigint unsigned NOT NULL DEFAULT '0' COMMENT '累计命中次数' AFTER `contributor_tenant_id`,
+    ADD COLUMN `last_hit_at` datetime(3) DEFAULT NULL COMMENT '最后命中时间，为空表示从未命中' AFTER `hit_count`,
+    ADD KEY `idx_phone_blacklists_hit_count` (`hit_count`),
+    ADD KEY `idx_phone_blacklists_last_hit_at` (`last_hit_at`);
+
+-- +migrate Down
+ALTER TABLE `phone_blacklists`
+    DROP KEY `idx_phone_blacklists_last_hit_at`,
+    DROP KEY `idx_phone_blacklists_hit_count`,
+    DROP COLUMN `last_hit_at`,
+    DROP COLUMN `hit_count`;
@@ -0,0 +1,11 @@
+-- Description: Add per-credential daily quota for k-anonymity prefix range queries
+-- Created: 20250910_100000
+
+-- +migrate Up
+-- 默认0，已有API密钥需显式开启前缀范围查询
+ALTER TABLE `blacklist_api_credentials`
+    ADD COLUMN `range_query_limit` int NOT NULL DEFAULT '0' COMMENT '每日可查询的不同哈希前缀数，0表示不允许前缀范围查询' AFTER `log_sample_rate`;
+
+-- +migrate Down
+ALTER TABLE `blacklist_api_credentials`
+    DROP COLUMN `range_query_limit`;
@@ -0,0 +1,15 @@
+-- Description: Move consortium shared blacklist entries from the system tenant to a reserved owner ID
+-- Created: 20250911_100000
+
+-- +migrate Up
+-- 共享名单条目改为归属保留ID（18446744073709551615，不对应任何租户），系统租户的黑名单管理接口不再能写入共享名单
+-- 系统租户默认名单的条目均为共享名单条目，命名名单的条目仍属于系统租户自己
+-- Redis中的共享名单数据由偏差检查任务按数据库重建，系统租户残留的旧数据需调用一次同步接口清理
+UPDATE `phone_blacklists`
+SET `tenant_id` = 18446744073709551615
+WHERE `tenant_id` = 0 AND `list_id` = 0;
+
+-- +migrate Down
+UPDATE `phone_blacklists`
+SET `tenant_id` = 0
+WHERE `tenant_id` = 18446744073709551615;
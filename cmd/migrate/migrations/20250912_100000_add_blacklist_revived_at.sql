@@ -0,0 +1,11 @@
+-- Description: Record when an inactive, expired or deleted blacklist entry is revived so batch rollback can restore it instead of deleting it
+-- Created: 20250912_100000
+
+-- +migrate Up
+-- 恢复记录时created_at重置为恢复时间，revived_at标记该记录由历史记录恢复
+ALTER TABLE `phone_blacklists`
+    ADD COLUMN `revived_at` datetime(3) DEFAULT NULL COMMENT '最近一次恢复失效、过期或已删除记录的时间，为空表示新建后未恢复过' AFTER `last_hit_at`;
+
+-- +migrate Down
+ALTER TABLE `phone_blacklists`
+    DROP COLUMN `revived_at`;
@@ -22,6 +22,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -31,6 +32,7 @@ import (
 	_ "github.com/varluffy/shield/docs" // swagger docs
 	"github.com/varluffy/shield/internal/routes"
 	"github.com/varluffy/shield/internal/wire"
+	"github.com/varluffy/shield/pkg/metrics"
 	"github.com/varluffy/shield/pkg/response"
 	"github.com/varluffy/shield/pkg/validator"
 	"go.uber.org/zap"
@@ -74,6 +76,7 @@ func main() {
 		app.FieldPermissionHandler,
 		app.BlacklistHandler,
 		app.ApiCredentialHandler,
+		app.WebhookHandler,
 		app.AuthMiddleware,
 		app.PermissionMiddleware,
 		app.BlacklistAuthMiddleware,
@@ -109,6 +112,53 @@ func main() {
 		}
 	}()
 
+	// 启动gRPC服务器
+	if app.GRPCServer.Enabled() {
+		lis, err := net.Listen("tcp", app.GRPCServer.Addr())
+		if err != nil {
+			app.Logger.Fatal("Failed to listen gRPC address",
+				zap.String("address", app.GRPCServer.Addr()),
+				zap.Error(err),
+			)
+		}
+
+		go func() {
+			app.Logger.Info("gRPC server starting",
+				zap.String("address", app.GRPCServer.Addr()),
+			)
+
+			if err := app.GRPCServer.Serve(lis); err != nil {
+				app.Logger.Fatal("Failed to start gRPC server",
+					zap.Error(err),
+				)
+			}
+		}()
+	}
+
+	// 启动独立端口的指标服务器
+	var metricsServer *http.Server
+	if app.Config.Metrics != nil && app.Config.Metrics.Enabled && app.Config.Metrics.Port > 0 {
+		mux := http.NewServeMux()
+		mux.Handle(app.Config.Metrics.Path, metrics.Handler(app.Config.Metrics.Token))
+		metricsServer = &http.Server{
+			Addr:              fmt.Sprintf("%s:%d", app.Config.Metrics.Host, app.Config.Metrics.Port),
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		go func() {
+			app.Logger.Info("Metrics server starting",
+				zap.String("address", metricsServer.Addr),
+			)
+
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				app.Logger.Fatal("Failed to start metrics server",
+					zap.Error(err),
+				)
+			}
+		}()
+	}
+
 	// 等待中断信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -126,6 +176,41 @@ func main() {
 		)
 	}
 
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			app.Logger.Warn("Metrics server forced to shutdown",
+				zap.Error(err),
+			)
+		}
+	}
+
+	// 等待进行中的gRPC调用和流结束，查询日志和Webhook事件需在此之后写入
+	if app.GRPCServer.Enabled() {
+		app.GRPCServer.Stop(ctx)
+	}
+
+	// 停止过期清理、导入任务、偏差检查、统计汇总和命中次数写入，进行中的导入任务在当前批次提交后停止
+	// 导入任务会产生Webhook事件，需在关闭Webhook服务和数据库连接之前
+	if err := app.BlacklistService.Close(ctx); err != nil {
+		app.Logger.Warn("Blacklist background tasks shutdown timed out",
+			zap.Error(err),
+		)
+	}
+
+	// 写入队列中剩余的查询日志，需在关闭数据库连接之前
+	if err := app.QueryLogWriter.Close(ctx); err != nil {
+		app.Logger.Warn("Query log writer flush timed out",
+			zap.Error(err),
+		)
+	}
+
+	// 为队列中剩余的Webhook事件生成投递并等待进行中的投递结束，需在关闭数据库连接之前
+	if err := app.WebhookService.Close(ctx); err != nil {
+		app.Logger.Warn("Webhook service shutdown timed out",
+			zap.Error(err),
+		)
+	}
+
 	// 关闭数据库连接
 	if sqlDB, err := app.DB.DB(); err == nil {
 		sqlDB.Close()
@@ -22,6 +22,8 @@ type Config struct {
 	Auth       *AuthConfig       `mapstructure:"auth,omitempty"`
 	HTTPClient *HTTPClientConfig `mapstructure:"http_client,omitempty"`
 	Captcha    *CaptchaConfig    `mapstructure:"captcha,omitempty"`
+	GRPC       *GRPCConfig       `mapstructure:"grpc,omitempty"`
+	Metrics    *MetricsConfig    `mapstructure:"metrics,omitempty"`
 }
 
 // AppConfig 应用配置
@@ -181,6 +183,42 @@ type CaptchaConfig struct {
 	Expiration time.Duration `mapstructure:"expiration" default:"5m"`
 }
 
+// GRPCConfig gRPC服务配置，未配置或未启用时不启动gRPC服务
+type GRPCConfig struct {
+	// Enabled 是否启用gRPC服务
+	Enabled bool `mapstructure:"enabled" default:"false"`
+
+	// Host 监听地址
+	Host string `mapstructure:"host" default:"0.0.0.0"`
+
+	// Port 监听端口
+	Port int `mapstructure:"port" default:"9090"`
+
+	// MaxConcurrentStreams 单个连接的最大并发流数量
+	MaxConcurrentStreams uint32 `mapstructure:"max_concurrent_streams" default:"1000"`
+
+	// MaxRecvMsgSize 单条请求消息的最大大小 (字节)
+	MaxRecvMsgSize int `mapstructure:"max_recv_msg_size" default:"1048576"` // 1MB
+}
+
+// MetricsConfig Prometheus指标配置，未配置或未启用时不暴露指标
+type MetricsConfig struct {
+	// Enabled 是否启用指标
+	Enabled bool `mapstructure:"enabled" default:"false"`
+
+	// Host 独立端口的监听地址
+	Host string `mapstructure:"host" default:"0.0.0.0"`
+
+	// Port 独立的指标端口，为0时在HTTP服务端口上暴露，此时必须配置Token
+	Port int `mapstructure:"port" default:"0"`
+
+	// Path 指标路径
+	Path string `mapstructure:"path" default:"/metrics"`
+
+	// Token 访问令牌，不为空时请求需携带Authorization: Bearer {token}
+	Token string `mapstructure:"token"`
+}
+
 // ConfigLoader 配置加载器
 type ConfigLoader struct {
 	viper *viper.Viper
@@ -283,6 +321,19 @@ func (c *ConfigLoader) setDefaults() {
 	c.viper.SetDefault("log.level", "info")
 	c.viper.SetDefault("log.format", "json")
 	c.viper.SetDefault("log.output", "stdout")
+
+	// gRPC默认值（默认不启用）
+	c.viper.SetDefault("grpc.enabled", false)
+	c.viper.SetDefault("grpc.host", "0.0.0.0")
+	c.viper.SetDefault("grpc.port", 9090)
+	c.viper.SetDefault("grpc.max_concurrent_streams", 1000)
+	c.viper.SetDefault("grpc.max_recv_msg_size", 1048576)
+
+	// 指标默认值（默认不启用）
+	c.viper.SetDefault("metrics.enabled", false)
+	c.viper.SetDefault("metrics.host", "0.0.0.0")
+	c.viper.SetDefault("metrics.port", 0)
+	c.viper.SetDefault("metrics.path", "/metrics")
 }
 
 // validateConfig 验证配置
@@ -302,6 +353,22 @@ func (c *ConfigLoader) validateConfig(cfg *Config) error {
 		return fmt.Errorf("JWT secret is required when auth is enabled")
 	}
 
+	// 验证gRPC端口（如果启用了gRPC）
+	if cfg.GRPC != nil && cfg.GRPC.Enabled && (cfg.GRPC.Port < 1 || cfg.GRPC.Port > 65535 || cfg.GRPC.Port == cfg.Server.Port) {
+		return fmt.Errorf("grpc port must be between 1 and 65535 and differ from server port")
+	}
+
+	// 验证指标端口（如果启用了指标），与HTTP服务共用端口时必须配置访问令牌
+	if cfg.Metrics != nil && cfg.Metrics.Enabled {
+		if cfg.Metrics.Port < 0 || cfg.Metrics.Port > 65535 || cfg.Metrics.Port == cfg.Server.Port ||
+			(cfg.GRPC != nil && cfg.GRPC.Enabled && cfg.Metrics.Port == cfg.GRPC.Port) {
+			return fmt.Errorf("metrics port must be between 0 and 65535 and differ from server and grpc ports")
+		}
+		if cfg.Metrics.Port == 0 && cfg.Metrics.Token == "" {
+			return fmt.Errorf("metrics token is required when metrics are served on the server port")
+		}
+	}
+
 	return nil
 }
 
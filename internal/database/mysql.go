@@ -109,6 +109,19 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.PhoneBlacklist{},
 		&models.BlacklistApiCredential{},
 		&models.BlacklistQueryLog{},
+		&models.BlacklistTenantSetting{},
+		&models.BlacklistImportJob{},
+		&models.BlacklistImportJobFile{},
+		&models.BlacklistImportBatch{},
+		&models.BlacklistDriftReport{},
+		&models.BlacklistWebhook{},
+		&models.BlacklistWebhookDelivery{},
+		&models.BlacklistWebhookAttempt{},
+		&models.BlacklistQueryStatHourly{},
+		&models.BlacklistQueryStatDaily{},
+		&models.BlacklistChangeRequest{},
+		&models.BlacklistAllowlistEntry{},
+		&models.BlacklistList{},
 	)
 }
 
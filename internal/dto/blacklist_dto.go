@@ -3,24 +3,149 @@
 package dto
 
 import (
-	"github.com/varluffy/shield/internal/models"
+	"encoding/json"
+	"math"
+	"strings"
 	"time"
+
+	"github.com/varluffy/shield/internal/models"
 )
 
 // CheckBlacklistRequest 黑名单查询请求
+// 仅传phone_md5时按手机号MD5查询，其他类型通过identifier_type指定
+// 哈希格式通过hash_type指定，默认md5；非md5格式必须使用identifier_hash
 type CheckBlacklistRequest struct {
-	PhoneMD5 string `json:"phone_md5" binding:"required,len=32" example:"5d41402abc4b2a76b9719d911017c592"`
+	PhoneMD5       string `json:"phone_md5" binding:"omitempty,len=32" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierType string `json:"identifier_type" binding:"omitempty,oneof=phone id_card device_id email ip bank_card" example:"phone"`
+	IdentifierMD5  string `json:"identifier_md5" binding:"omitempty,len=32" example:"5d41402abc4b2a76b9719d911017c592"`
+	HashType       string `json:"hash_type" binding:"omitempty,oneof=md5 sha256 hmac_sha256" example:"sha256"`
+	IdentifierHash string `json:"identifier_hash" binding:"omitempty,hexadecimal" example:"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`
+	// Lists 查询的名单标识，为空时查询API密钥已授权的全部名单
+	Lists []string `json:"lists" binding:"omitempty,max=20" example:"default,loan_fraud"`
+}
+
+// Resolve 获取查询的标识类型、哈希格式和哈希值
+func (r *CheckBlacklistRequest) Resolve() (string, string, string, bool) {
+	identifierType, ok := resolveIdentifierType(r.IdentifierType)
+	if !ok {
+		return "", "", "", false
+	}
+	hashType, hash, ok := resolveHash(identifierType, r.HashType, r.IdentifierHash, r.IdentifierMD5, r.PhoneMD5)
+	if !ok {
+		return "", "", "", false
+	}
+	return identifierType, hashType, hash, true
 }
 
 // CheckBlacklistResponse 黑名单查询响应
 type CheckBlacklistResponse struct {
-	IsBlacklist bool   `json:"is_blacklist" example:"true"`
-	PhoneMD5    string `json:"phone_md5" example:"5d41402abc4b2a76b9719d911017c592"`
+	IsBlacklist    bool     `json:"is_blacklist" example:"true"`
+	PhoneMD5       string   `json:"phone_md5,omitempty" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierType string   `json:"identifier_type" example:"phone"`
+	IdentifierMD5  string   `json:"identifier_md5,omitempty" example:"5d41402abc4b2a76b9719d911017c592"`
+	HashType       string   `json:"hash_type" example:"md5"`
+	IdentifierHash string   `json:"identifier_hash" example:"5d41402abc4b2a76b9719d911017c592"`
+	Category       string   `json:"category,omitempty" example:"fraud"`       // 风险分类，仅命中时返回
+	RiskScore      int      `json:"risk_score,omitempty" example:"90"`        // 风险分，仅命中时返回
+	Overridden     bool     `json:"overridden,omitempty" example:"false"`     // 命中黑名单但在租户白名单中，按未命中返回
+	HitLists       []string `json:"hit_lists,omitempty" example:"loan_fraud"` // 命中的名单，仅命中时返回
+	HitSource      string   `json:"hit_source,omitempty" example:"tenant"`    // 命中来源 tenant/shared/both，仅命中时返回
+}
+
+// NewCheckBlacklistResponse 创建查询响应，MD5格式同时返回identifier_md5，手机号MD5返回phone_md5兼容旧版客户端
+// 命中时需通过WithRisk补充风险信息
+func NewCheckBlacklistResponse(identifierType, hashType, hash string, isBlacklist bool) CheckBlacklistResponse {
+	resp := CheckBlacklistResponse{
+		IsBlacklist:    isBlacklist,
+		IdentifierType: identifierType,
+		HashType:       hashType,
+		IdentifierHash: hash,
+	}
+	if hashType == models.HashTypeMD5 {
+		resp.IdentifierMD5 = hash
+		if identifierType == models.IdentifierTypePhone {
+			resp.PhoneMD5 = hash
+		}
+	}
+	return resp
+}
+
+// WithRisk 补充命中条目的风险分类和风险分
+func (r CheckBlacklistResponse) WithRisk(category string, riskScore int) CheckBlacklistResponse {
+	if r.IsBlacklist {
+		r.Category = category
+		r.RiskScore = riskScore
+	}
+	return r
+}
+
+// WithHitLists 补充命中的名单
+func (r CheckBlacklistResponse) WithHitLists(lists []string) CheckBlacklistResponse {
+	if r.IsBlacklist {
+		r.HitLists = lists
+	}
+	return r
+}
+
+// WithHitSource 补充命中来源，区分租户名单和共享名单
+func (r CheckBlacklistResponse) WithHitSource(source string) CheckBlacklistResponse {
+	if r.IsBlacklist {
+		r.HitSource = source
+	}
+	return r
+}
+
+// WithOverridden 标记命中黑名单但被租户白名单豁免
+func (r CheckBlacklistResponse) WithOverridden(overridden bool) CheckBlacklistResponse {
+	r.Overridden = overridden
+	return r
 }
 
 // CheckBlacklistBatchRequest 批量黑名单查询请求
+// 仅传phone_md5_list时按手机号MD5查询，其他类型通过identifier_type指定
+// 哈希格式通过hash_type指定，默认md5；非md5格式必须使用identifier_hash_list
 type CheckBlacklistBatchRequest struct {
-	PhoneMD5List []string `json:"phone_md5_list" binding:"required,min=1,max=100"`
+	PhoneMD5List       []string `json:"phone_md5_list" binding:"omitempty,max=100"`
+	IdentifierType     string   `json:"identifier_type" binding:"omitempty,oneof=phone id_card device_id email ip bank_card" example:"phone"`
+	IdentifierMD5List  []string `json:"identifier_md5_list" binding:"omitempty,max=100"`
+	HashType           string   `json:"hash_type" binding:"omitempty,oneof=md5 sha256 hmac_sha256" example:"sha256"`
+	IdentifierHashList []string `json:"identifier_hash_list" binding:"omitempty,max=100"`
+	// Lists 查询的名单标识，为空时查询API密钥已授权的全部名单
+	Lists []string `json:"lists" binding:"omitempty,max=20" example:"default,loan_fraud"`
+}
+
+// Resolve 获取查询的标识类型、哈希格式和哈希列表，列表中任一哈希格式错误时返回false
+func (r *CheckBlacklistBatchRequest) Resolve() (string, string, []string, bool) {
+	identifierType, ok := resolveIdentifierType(r.IdentifierType)
+	if !ok {
+		return "", "", nil, false
+	}
+
+	hashType := r.HashType
+	if hashType == "" {
+		hashType = models.HashTypeMD5
+	}
+
+	list := r.IdentifierHashList
+	if len(list) == 0 && hashType == models.HashTypeMD5 {
+		list = r.IdentifierMD5List
+		if len(list) == 0 && identifierType == models.IdentifierTypePhone {
+			list = r.PhoneMD5List
+		}
+	}
+	if len(list) == 0 {
+		return "", "", nil, false
+	}
+
+	hashes := make([]string, 0, len(list))
+	for _, value := range list {
+		hash, ok := normalizeHash(hashType, value)
+		if !ok {
+			return "", "", nil, false
+		}
+		hashes = append(hashes, hash)
+	}
+	return identifierType, hashType, hashes, true
 }
 
 // CheckBlacklistBatchResponse 批量黑名单查询响应
@@ -28,64 +153,447 @@ type CheckBlacklistBatchResponse struct {
 	Results []CheckBlacklistResponse `json:"results"`
 }
 
+// RangeQueryRequest 前缀范围查询请求
+// 只提交哈希的前5-10位十六进制字符，服务端返回名单中相同前缀条目的后缀，由调用方在本地比对完整哈希
+type RangeQueryRequest struct {
+	IdentifierType string `json:"identifier_type" binding:"omitempty,oneof=phone id_card device_id email ip bank_card" example:"phone"`
+	HashType       string `json:"hash_type" binding:"omitempty,oneof=md5 sha256 hmac_sha256" example:"sha256"`
+	Prefix         string `json:"prefix" binding:"required,min=5,max=10" example:"2cf24"`
+	// Lists 查询的名单标识，为空时查询API密钥已授权的全部名单
+	Lists []string `json:"lists" binding:"omitempty,max=20" example:"default,loan_fraud"`
+}
+
+// Resolve 获取查询的标识类型、哈希格式和小写前缀，前缀包含非十六进制字符时返回false
+func (r *RangeQueryRequest) Resolve() (string, string, string, bool) {
+	identifierType, ok := resolveIdentifierType(r.IdentifierType)
+	if !ok {
+		return "", "", "", false
+	}
+	hashType := r.HashType
+	if hashType == "" {
+		hashType = models.HashTypeMD5
+	}
+	prefix := strings.ToLower(r.Prefix)
+	for _, c := range prefix {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", "", "", false
+		}
+	}
+	return identifierType, hashType, prefix, true
+}
+
+// RangeQueryResponse 前缀范围查询响应，suffixes为空表示该前缀下没有命中的条目
+type RangeQueryResponse struct {
+	IdentifierType string            `json:"identifier_type" example:"phone"`
+	HashType       string            `json:"hash_type" example:"sha256"`
+	Prefix         string            `json:"prefix" example:"2cf24"`
+	Suffixes       []RangeSuffixItem `json:"suffixes"`
+}
+
+// RangeSuffixItem 前缀范围查询匹配的条目，前缀与后缀拼接即为完整哈希
+type RangeSuffixItem struct {
+	Suffix    string   `json:"suffix" example:"dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`
+	Category  string   `json:"category,omitempty" example:"fraud"`
+	RiskScore int      `json:"risk_score" example:"90"`
+	HitLists  []string `json:"hit_lists,omitempty" example:"loan_fraud"` // 命中的名单
+	HitSource string   `json:"hit_source" example:"tenant"`              // 命中来源 tenant/shared/both
+}
+
 // CreateBlacklistRequest 创建黑名单请求
+// MD5和SHA-256至少提供一种，同时提供时服务端可按两种格式以及HMAC-SHA256匹配
 type CreateBlacklistRequest struct {
-	PhoneMD5 string `json:"phone_md5" binding:"required,len=32" example:"5d41402abc4b2a76b9719d911017c592"`
-	Source   string `json:"source" binding:"required" example:"manual"`
-	Reason   string `json:"reason" example:"用户投诉"`
+	PhoneMD5         string `json:"phone_md5" binding:"omitempty,len=32" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierType   string `json:"identifier_type" binding:"omitempty,oneof=phone id_card device_id email ip bank_card" example:"phone"`
+	IdentifierMD5    string `json:"identifier_md5" binding:"omitempty,len=32" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierSHA256 string `json:"identifier_sha256" binding:"omitempty,len=64,hexadecimal" example:"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`
+	Source           string `json:"source" binding:"required" example:"manual"`
+	Reason           string `json:"reason" example:"用户投诉"`
+	Category         string `json:"category" binding:"omitempty,oneof=fraud complaint collection_harassment other" example:"complaint"`
+	RiskScore        int    `json:"risk_score" binding:"omitempty,min=1,max=100" example:"60"` // 为空时默认100
+	List             string `json:"list" binding:"omitempty,max=50" example:"loan_fraud"`      // 目标名单标识，为空时写入默认名单
+	// ExpiresAt 过期时间，与ExpireDays二选一，均为空表示永久有效
+	ExpiresAt  *time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
+	ExpireDays int        `json:"expire_days" binding:"omitempty,min=1,max=3650" example:"90"`
+}
+
+// ResolveExpiresAt 计算过期时间
+func (r *CreateBlacklistRequest) ResolveExpiresAt(now time.Time) *time.Time {
+	return resolveExpiresAt(r.ExpiresAt, r.ExpireDays, now)
 }
 
-// ToModel 转换为模型
+// Resolve 获取标识类型、MD5和SHA-256
+func (r *CreateBlacklistRequest) Resolve() (string, BlacklistHashItem, bool) {
+	identifierType, ok := resolveIdentifierType(r.IdentifierType)
+	if !ok {
+		return "", BlacklistHashItem{}, false
+	}
+
+	valueMD5 := r.IdentifierMD5
+	if valueMD5 == "" && identifierType == models.IdentifierTypePhone {
+		valueMD5 = r.PhoneMD5
+	}
+	item, ok := BlacklistHashItem{MD5: valueMD5, SHA256: r.IdentifierSHA256}.normalize()
+	if !ok {
+		return "", BlacklistHashItem{}, false
+	}
+	return identifierType, item, true
+}
+
+// ToModel 转换为模型，调用前需通过Resolve校验标识
 func (r *CreateBlacklistRequest) ToModel(tenantID, operatorID uint64) *models.PhoneBlacklist {
+	identifierType, item, _ := r.Resolve()
 	return &models.PhoneBlacklist{
-		TenantModel: models.TenantModel{TenantID: tenantID},
-		PhoneMD5:    r.PhoneMD5,
-		Source:      r.Source,
-		Reason:      r.Reason,
-		OperatorID:  operatorID,
-		IsActive:    true,
+		TenantModel:      models.TenantModel{TenantID: tenantID},
+		IdentifierType:   identifierType,
+		PhoneMD5:         item.MD5,
+		IdentifierSHA256: item.SHA256,
+		Source:           r.Source,
+		Reason:           r.Reason,
+		Category:         r.Category,
+		RiskScore:        r.RiskScore,
+		OperatorID:       operatorID,
+		IsActive:         true,
+		ExpiresAt:        r.ResolveExpiresAt(time.Now()),
 	}
 }
 
+// BlacklistHashItem 同一标识的MD5和SHA-256，至少提供一种
+type BlacklistHashItem struct {
+	MD5    string `json:"md5" example:"5d41402abc4b2a76b9719d911017c592"`
+	SHA256 string `json:"sha256" example:"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`
+}
+
+// normalize 校验并统一为小写十六进制
+func (i BlacklistHashItem) normalize() (BlacklistHashItem, bool) {
+	if i.MD5 == "" && i.SHA256 == "" {
+		return i, false
+	}
+	var ok bool
+	if i.MD5 != "" {
+		if i.MD5, ok = normalizeHash(models.HashTypeMD5, i.MD5); !ok {
+			return i, false
+		}
+	}
+	if i.SHA256 != "" {
+		if i.SHA256, ok = normalizeHash(models.HashTypeSHA256, i.SHA256); !ok {
+			return i, false
+		}
+	}
+	return i, true
+}
+
 // BatchImportBlacklistRequest 批量导入黑名单请求
+// 可分别提供MD5列表、SHA-256列表，或通过items成对提供同一标识的两种格式
+// 名单中已有相同标识（任一哈希格式相同）的记录时补充其缺少的哈希格式而不是新增条目，已有全部哈希格式的条目计入duplicate_count
 type BatchImportBlacklistRequest struct {
-	PhoneMD5List []string `json:"phone_md5_list" binding:"required,min=1,max=10000"`
-	Source       string   `json:"source" binding:"required" example:"import"`
-	Reason       string   `json:"reason" example:"批量导入"`
+	PhoneMD5List         []string            `json:"phone_md5_list" binding:"omitempty,max=10000"`
+	IdentifierType       string              `json:"identifier_type" binding:"omitempty,oneof=phone id_card device_id email ip bank_card" example:"phone"`
+	IdentifierMD5List    []string            `json:"identifier_md5_list" binding:"omitempty,max=10000"`
+	IdentifierSHA256List []string            `json:"identifier_sha256_list" binding:"omitempty,max=10000"`
+	Items                []BlacklistHashItem `json:"items" binding:"omitempty,max=10000"`
+	Source               string              `json:"source" binding:"required" example:"import"`
+	Reason               string              `json:"reason" example:"批量导入"`
+	Category             string              `json:"category" binding:"omitempty,oneof=fraud complaint collection_harassment other" example:"fraud"`
+	RiskScore            int                 `json:"risk_score" binding:"omitempty,min=1,max=100" example:"90"` // 为空时默认100
+	List                 string              `json:"list" binding:"omitempty,max=50" example:"loan_fraud"`      // 目标名单标识，为空时写入默认名单
+	// ExpiresAt 过期时间，与ExpireDays二选一，均为空表示永久有效
+	ExpiresAt  *time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
+	ExpireDays int        `json:"expire_days" binding:"omitempty,min=1,max=3650" example:"90"`
 }
 
-// GetBlacklistRequest 获取黑名单列表请求
-type GetBlacklistRequest struct {
+// ResolveExpiresAt 计算过期时间
+func (r *BatchImportBlacklistRequest) ResolveExpiresAt(now time.Time) *time.Time {
+	return resolveExpiresAt(r.ExpiresAt, r.ExpireDays, now)
+}
+
+// Resolve 获取标识类型和待导入的哈希列表，任一哈希格式错误或总数超过10000时返回false
+func (r *BatchImportBlacklistRequest) Resolve() (string, []BlacklistHashItem, bool) {
+	identifierType, ok := resolveIdentifierType(r.IdentifierType)
+	if !ok {
+		return "", nil, false
+	}
+
+	md5List := r.IdentifierMD5List
+	if len(md5List) == 0 && identifierType == models.IdentifierTypePhone {
+		md5List = r.PhoneMD5List
+	}
+
+	items := make([]BlacklistHashItem, 0, len(md5List)+len(r.IdentifierSHA256List)+len(r.Items))
+	for _, valueMD5 := range md5List {
+		items = append(items, BlacklistHashItem{MD5: valueMD5})
+	}
+	for _, valueSHA256 := range r.IdentifierSHA256List {
+		items = append(items, BlacklistHashItem{SHA256: valueSHA256})
+	}
+	items = append(items, r.Items...)
+	if len(items) == 0 || len(items) > 10000 {
+		return "", nil, false
+	}
+
+	for idx, item := range items {
+		if items[idx], ok = item.normalize(); !ok {
+			return "", nil, false
+		}
+	}
+	return identifierType, items, true
+}
+
+// ImportBlacklistFileRequest 文件导入黑名单请求（multipart/form-data，文件字段为file）
+// 文件为CSV或XLSX格式的明文手机号，服务端规范化后计算哈希
+type ImportBlacklistFileRequest struct {
+	Column     int    `form:"column,default=1" binding:"min=1,max=1000" example:"1"` // 手机号所在列，从1开始
+	ColumnName string `form:"column_name" binding:"max=100" example:"手机号"`           // 按表头名称定位手机号列，优先于column
+	Source     string `form:"source,default=file_import" binding:"max=50" example:"file_import"`
+	Reason     string `form:"reason" binding:"max=200" example:"运营导入"`
+	Category   string `form:"category" binding:"omitempty,oneof=fraud complaint collection_harassment other" example:"fraud"`
+	RiskScore  int    `form:"risk_score" binding:"omitempty,min=1,max=100" example:"90"` // 为空时默认100
+	// ExpiresAt 过期时间（RFC3339），与ExpireDays二选一，均为空表示永久有效
+	ExpiresAt  *time.Time `form:"expires_at" time_format:"2006-01-02T15:04:05Z07:00"`
+	ExpireDays int        `form:"expire_days" binding:"omitempty,min=1,max=3650" example:"90"`
+}
+
+// ResolveExpiresAt 计算过期时间
+func (r *ImportBlacklistFileRequest) ResolveExpiresAt(now time.Time) *time.Time {
+	return resolveExpiresAt(r.ExpiresAt, r.ExpireDays, now)
+}
+
+// SubmitImportJobRequest 提交异步导入任务请求（multipart/form-data，文件字段为file）
+// value_type为phone时文件内容为明文手机号，为md5/sha256时为对应格式的哈希
+type SubmitImportJobRequest struct {
+	ImportBlacklistFileRequest
+	ValueType      string `form:"value_type,default=phone" binding:"oneof=phone md5 sha256" example:"phone"`
+	IdentifierType string `form:"identifier_type" binding:"omitempty,oneof=phone id_card device_id email ip bank_card" example:"phone"` // value_type为哈希时有效
+}
+
+// ListImportJobsRequest 获取导入任务列表请求
+type ListImportJobsRequest struct {
 	Page     int `form:"page,default=1" binding:"min=1"`
 	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
 }
 
+// ListImportBatchesRequest 获取导入批次列表请求
+type ListImportBatchesRequest struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// GetImportBatchRequest 获取导入批次详情请求，分页参数用于批次中的条目
+type GetImportBatchRequest struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// ListDriftReportsRequest 获取Redis偏差检查记录请求
+type ListDriftReportsRequest struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// SearchQueryLogsRequest 搜索查询日志请求，过滤条件均为可选，按查询时间倒序
+type SearchQueryLogsRequest struct {
+	APIKey         string     `form:"api_key" binding:"max=64"`
+	IdentifierHash string     `form:"identifier_hash" binding:"omitempty,max=64,hexadecimal"`
+	IsHit          *bool      `form:"is_hit"`
+	RequestID      string     `form:"request_id" binding:"max=64"`
+	From           *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To             *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor         uint64     `form:"cursor"` // 上一页返回的next_cursor，首页不传
+	Limit          int        `form:"limit,default=50" binding:"min=1,max=200"`
+}
+
+// ValidRange 查询时间范围是否有效
+func (r *SearchQueryLogsRequest) ValidRange() bool {
+	return r.From == nil || r.To == nil || r.From.Before(*r.To)
+}
+
+// QueryLogTimelineRequest 单个哈希的查询时间线请求，按查询时间正序
+type QueryLogTimelineRequest struct {
+	IdentifierHash string     `form:"identifier_hash" binding:"required,max=64,hexadecimal"`
+	APIKey         string     `form:"api_key" binding:"max=64"`
+	From           *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To             *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Cursor         uint64     `form:"cursor"` // 上一页返回的next_cursor，首页不传
+	Limit          int        `form:"limit,default=50" binding:"min=1,max=200"`
+}
+
+// ValidRange 查询时间范围是否有效
+func (r *QueryLogTimelineRequest) ValidRange() bool {
+	return r.From == nil || r.To == nil || r.From.Before(*r.To)
+}
+
+// CreateWebhookRequest 创建Webhook订阅请求
+type CreateWebhookRequest struct {
+	Name        string   `json:"name" binding:"required,max=100" example:"风控告警"`
+	URL         string   `json:"url" binding:"required,url,max=500" example:"https://partner.example.com/webhooks/shield"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1,dive,required" example:"blacklist.hit,blacklist.batch.imported"`
+	Description string   `json:"description" binding:"max=255" example:"命中告警推送"`
+}
+
+// UpdateWebhookRequest 更新Webhook订阅请求，未传的字段不修改
+type UpdateWebhookRequest struct {
+	Name        *string  `json:"name" binding:"omitempty,max=100" example:"风控告警"`
+	URL         *string  `json:"url" binding:"omitempty,url,max=500" example:"https://partner.example.com/webhooks/shield"`
+	EventTypes  []string `json:"event_types" binding:"omitempty,min=1,dive,required" example:"blacklist.hit"`
+	Status      *string  `json:"status" binding:"omitempty,oneof=active disabled" example:"active"`
+	Description *string  `json:"description" binding:"omitempty,max=255" example:"命中告警推送"`
+}
+
+// ListWebhooksRequest 获取Webhook订阅列表请求
+type ListWebhooksRequest struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// ListWebhookDeliveriesRequest 获取Webhook投递记录请求，过滤条件均为可选
+type ListWebhookDeliveriesRequest struct {
+	WebhookID string `form:"webhook_id" binding:"max=36"` // Webhook订阅UUID
+	Status    string `form:"status" binding:"omitempty,oneof=pending succeeded dead"`
+	Page      int    `form:"page,default=1" binding:"min=1"`
+	PageSize  int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// resolveIdentifierType 解析标识类型，未指定时默认为手机号
+func resolveIdentifierType(identifierType string) (string, bool) {
+	if identifierType == "" {
+		identifierType = models.IdentifierTypePhone
+	}
+	return identifierType, models.IsValidIdentifierType(identifierType)
+}
+
+// resolveHash 解析哈希格式和哈希值，未指定格式时默认为MD5
+// MD5格式兼容identifier_md5字段，手机号类型还兼容phone_md5字段，其他格式必须使用identifier_hash
+func resolveHash(identifierType, hashType, identifierHash, identifierMD5, phoneMD5 string) (string, string, bool) {
+	if hashType == "" {
+		hashType = models.HashTypeMD5
+	}
+
+	hash := identifierHash
+	if hash == "" && hashType == models.HashTypeMD5 {
+		hash = identifierMD5
+		if hash == "" && identifierType == models.IdentifierTypePhone {
+			hash = phoneMD5
+		}
+	}
+
+	hash, ok := normalizeHash(hashType, hash)
+	if !ok {
+		return "", "", false
+	}
+	return hashType, hash, true
+}
+
+// normalizeHash 校验哈希长度和字符，统一为小写十六进制
+func normalizeHash(hashType, hash string) (string, bool) {
+	length := models.HashLength(hashType)
+	if length == 0 || len(hash) != length {
+		return "", false
+	}
+	hash = strings.ToLower(hash)
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", false
+		}
+	}
+	return hash, true
+}
+
+// resolveExpiresAt 优先使用指定的过期时间，否则按天数计算
+func resolveExpiresAt(expiresAt *time.Time, expireDays int, now time.Time) *time.Time {
+	if expiresAt != nil {
+		return expiresAt
+	}
+	if expireDays > 0 {
+		t := now.AddDate(0, 0, expireDays)
+		return &t
+	}
+	return nil
+}
+
+// GetBlacklistRequest 获取黑名单列表请求
+type GetBlacklistRequest struct {
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=20" binding:"min=1,max=100"`
+	SortBy   string `form:"sort_by,default=created" binding:"oneof=created hits last_hit" example:"hits"` // 排序字段：创建时间、命中次数、最后命中时间
+	Order    string `form:"order,default=desc" binding:"oneof=asc desc" example:"asc"`                    // 升序时从未命中的条目排在最前
+}
+
+// ExportBlacklistRequest 导出黑名单请求，过滤条件均为可选
+type ExportBlacklistRequest struct {
+	Format      string     `form:"format,default=csv" binding:"oneof=csv ndjson"`
+	Source      string     `form:"source" binding:"max=50"`
+	Active      *bool      `form:"active"`
+	CreatedFrom *time.Time `form:"created_from" time_format:"2006-01-02T15:04:05Z07:00"`
+	CreatedTo   *time.Time `form:"created_to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// ValidRange 创建时间范围是否有效
+func (r *ExportBlacklistRequest) ValidRange() bool {
+	return r.CreatedFrom == nil || r.CreatedTo == nil || r.CreatedFrom.Before(*r.CreatedTo)
+}
+
+// AuditFilters 审计日志中记录的过滤条件，仅包含已指定的条件
+func (r *ExportBlacklistRequest) AuditFilters() map[string]interface{} {
+	filters := make(map[string]interface{})
+	if r.Source != "" {
+		filters["source"] = r.Source
+	}
+	if r.Active != nil {
+		filters["active"] = *r.Active
+	}
+	if r.CreatedFrom != nil {
+		filters["created_from"] = r.CreatedFrom.Format(time.RFC3339)
+	}
+	if r.CreatedTo != nil {
+		filters["created_to"] = r.CreatedTo.Format(time.RFC3339)
+	}
+	return filters
+}
+
 // BlacklistInfo 黑名单信息
 type BlacklistInfo struct {
-	ID         uint64    `json:"id" example:"1"`
-	UUID       string    `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
-	PhoneMD5   string    `json:"phone_md5" example:"5d41402abc4b2a76b9719d911017c592"`
-	Source     string    `json:"source" example:"manual"`
-	Reason     string    `json:"reason" example:"用户投诉"`
-	OperatorID uint64    `json:"operator_id" example:"1"`
-	IsActive   bool      `json:"is_active" example:"true"`
-	CreatedAt  time.Time `json:"created_at" example:"2024-01-01T10:00:00Z"`
-	UpdatedAt  time.Time `json:"updated_at" example:"2024-01-01T10:00:00Z"`
+	ID               uint64     `json:"id" example:"1"`
+	UUID             string     `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ListID           uint64     `json:"list_id" example:"0"` // 所属名单ID，0表示默认名单
+	IdentifierType   string     `json:"identifier_type" example:"phone"`
+	PhoneMD5         string     `json:"phone_md5" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierSHA256 string     `json:"identifier_sha256" example:"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`
+	Source           string     `json:"source" example:"manual"`
+	Reason           string     `json:"reason" example:"用户投诉"`
+	Category         string     `json:"category" example:"complaint"`
+	RiskScore        int        `json:"risk_score" example:"60"`
+	OperatorID       uint64     `json:"operator_id" example:"1"`
+	IsActive         bool       `json:"is_active" example:"true"`
+	ExpiresAt        *time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
+	CreatedAt        time.Time  `json:"created_at" example:"2024-01-01T10:00:00Z"`
+	UpdatedAt        time.Time  `json:"updated_at" example:"2024-01-01T10:00:00Z"`
+
+	// HitCount 累计命中次数，LastHitAt 最后命中时间（为空表示从未命中），每分钟汇总一次
+	HitCount  uint64     `json:"hit_count" example:"12"`
+	LastHitAt *time.Time `json:"last_hit_at" example:"2024-06-01T08:30:00Z"`
 }
 
 // NewBlacklistInfo 从模型创建黑名单信息
 func NewBlacklistInfo(blacklist *models.PhoneBlacklist) BlacklistInfo {
-	return BlacklistInfo{
-		ID:         blacklist.ID,
-		UUID:       blacklist.UUID,
-		PhoneMD5:   blacklist.PhoneMD5,
-		Source:     blacklist.Source,
-		Reason:     blacklist.Reason,
-		OperatorID: blacklist.OperatorID,
-		IsActive:   blacklist.IsActive,
-		CreatedAt:  blacklist.CreatedAt,
-		UpdatedAt:  blacklist.UpdatedAt,
+	info := BlacklistInfo{
+		ID:               blacklist.ID,
+		UUID:             blacklist.UUID,
+		ListID:           blacklist.ListID,
+		IdentifierType:   blacklist.IdentifierType,
+		PhoneMD5:         blacklist.PhoneMD5,
+		IdentifierSHA256: blacklist.IdentifierSHA256,
+		Source:           blacklist.Source,
+		Reason:           blacklist.Reason,
+		Category:         blacklist.Category,
+		RiskScore:        blacklist.RiskScore,
+		OperatorID:       blacklist.OperatorID,
+		IsActive:         blacklist.IsActive,
+		ExpiresAt:        blacklist.ExpiresAt,
+		CreatedAt:        blacklist.CreatedAt,
+		UpdatedAt:        blacklist.UpdatedAt,
 	}
+	info.HitCount = blacklist.HitCount
+	info.LastHitAt = blacklist.LastHitAt
+	return info
 }
 
 // GetBlacklistResponse 获取黑名单列表响应
@@ -109,6 +617,12 @@ type CreateApiCredentialRequest struct {
 	RateLimit   int        `json:"rate_limit" binding:"min=1,max=10000" example:"1000"`
 	IPWhitelist string     `json:"ip_whitelist" example:"192.168.1.0/24,10.0.0.1"`
 	ExpiresAt   *time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
+	// MinRiskScore 查询时仅返回风险分不低于该值的命中，0表示全部返回
+	MinRiskScore int `json:"min_risk_score" binding:"min=0,max=100" example:"60"`
+	// LogSampleRate 详细查询日志采样率 0-1，为空时默认0.01，命中和错误请求始终记录
+	LogSampleRate *float64 `json:"log_sample_rate" binding:"omitempty,min=0,max=1" example:"0.05"`
+	// RangeQueryLimit 每日可查询的不同哈希前缀数，0表示不允许前缀范围查询
+	RangeQueryLimit int `json:"range_query_limit" binding:"min=0,max=1000000" example:"10000"`
 }
 
 // CreateApiCredentialResponse 创建API密钥响应
@@ -124,6 +638,20 @@ type UpdateApiCredentialRequest struct {
 	RateLimit   int        `json:"rate_limit" binding:"min=1,max=10000" example:"1000"`
 	IPWhitelist string     `json:"ip_whitelist" example:"192.168.1.0/24,10.0.0.1"`
 	ExpiresAt   *time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
+	// MinRiskScore 查询时仅返回风险分不低于该值的命中，0表示全部返回
+	MinRiskScore int `json:"min_risk_score" binding:"min=0,max=100" example:"60"`
+	// LogSampleRate 详细查询日志采样率 0-1，为空时默认0.01，命中和错误请求始终记录
+	LogSampleRate *float64 `json:"log_sample_rate" binding:"omitempty,min=0,max=1" example:"0.05"`
+	// RangeQueryLimit 每日可查询的不同哈希前缀数，0表示不允许前缀范围查询
+	RangeQueryLimit int `json:"range_query_limit" binding:"min=0,max=1000000" example:"10000"`
+}
+
+// ResolveLogSampleRate 解析详细查询日志采样率，未指定时使用默认值
+func ResolveLogSampleRate(rate *float64) float64 {
+	if rate == nil {
+		return models.DefaultLogSampleRate
+	}
+	return *rate
 }
 
 // UpdateStatusRequest 更新状态请求
@@ -139,43 +667,93 @@ type RegenerateSecretResponse struct {
 
 // ApiCredentialInfo API密钥信息
 type ApiCredentialInfo struct {
-	ID          uint64     `json:"id" example:"1"`
-	UUID        string     `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
-	APIKey      string     `json:"api_key" example:"ak_1234567890abcdef"`
-	Name        string     `json:"name" example:"测试密钥"`
-	Description string     `json:"description" example:"用于测试的API密钥"`
-	RateLimit   int        `json:"rate_limit" example:"1000"`
-	Status      string     `json:"status" example:"active"`
-	LastUsedAt  *time.Time `json:"last_used_at" example:"2024-01-01T10:00:00Z"`
-	ExpiresAt   *time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
-	CreatedAt   time.Time  `json:"created_at" example:"2024-01-01T10:00:00Z"`
-	UpdatedAt   time.Time  `json:"updated_at" example:"2024-01-01T10:00:00Z"`
+	ID              uint64     `json:"id" example:"1"`
+	UUID            string     `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	APIKey          string     `json:"api_key" example:"ak_1234567890abcdef"`
+	Name            string     `json:"name" example:"测试密钥"`
+	Description     string     `json:"description" example:"用于测试的API密钥"`
+	RateLimit       int        `json:"rate_limit" example:"1000"`
+	Status          string     `json:"status" example:"active"`
+	MinRiskScore    int        `json:"min_risk_score" example:"60"`
+	Lists           []string   `json:"lists" example:"default"` // 可查询的名单标识
+	LogSampleRate   float64    `json:"log_sample_rate" example:"0.01"`
+	RangeQueryLimit int        `json:"range_query_limit" example:"10000"` // 每日可查询的不同哈希前缀数，0表示不允许前缀范围查询
+	LastUsedAt      *time.Time `json:"last_used_at" example:"2024-01-01T10:00:00Z"`
+	ExpiresAt       *time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
+	CreatedAt       time.Time  `json:"created_at" example:"2024-01-01T10:00:00Z"`
+	UpdatedAt       time.Time  `json:"updated_at" example:"2024-01-01T10:00:00Z"`
 }
 
 // NewApiCredentialInfo 从模型创建API密钥信息
 func NewApiCredentialInfo(credential *models.BlacklistApiCredential) ApiCredentialInfo {
 	return ApiCredentialInfo{
-		ID:          credential.ID,
-		UUID:        credential.UUID,
-		APIKey:      credential.APIKey,
-		Name:        credential.Name,
-		Description: credential.Description,
-		RateLimit:   credential.RateLimit,
-		Status:      credential.Status,
-		LastUsedAt:  credential.LastUsedAt,
-		ExpiresAt:   credential.ExpiresAt,
-		CreatedAt:   credential.CreatedAt,
-		UpdatedAt:   credential.UpdatedAt,
+		ID:              credential.ID,
+		UUID:            credential.UUID,
+		APIKey:          credential.APIKey,
+		Name:            credential.Name,
+		Description:     credential.Description,
+		RateLimit:       credential.RateLimit,
+		Status:          credential.Status,
+		MinRiskScore:    credential.MinRiskScore,
+		Lists:           credential.ListNames(),
+		LogSampleRate:   credential.LogSampleRate,
+		RangeQueryLimit: credential.RangeQueryLimit,
+		LastUsedAt:      credential.LastUsedAt,
+		ExpiresAt:       credential.ExpiresAt,
+		CreatedAt:       credential.CreatedAt,
+		UpdatedAt:       credential.UpdatedAt,
 	}
 }
 
-// QueryStatsResponse 查询统计响应
+// QueryStatsRangeRequest 按时间范围查询统计请求，from和to都为空时按hours查询最近的Redis统计
+type QueryStatsRangeRequest struct {
+	From        *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Granularity string     `form:"granularity,default=day" binding:"oneof=hour day" example:"day"`
+}
+
+// HasRange 是否指定了时间范围
+func (r *QueryStatsRangeRequest) HasRange() bool {
+	return r.From != nil || r.To != nil
+}
+
+// ValidRange 时间范围是否有效，from和to需同时指定
+func (r *QueryStatsRangeRequest) ValidRange() bool {
+	return validStatsRange(r.From, r.To)
+}
+
+// QueryStatsResponse 查询统计响应，指定时间范围时返回粒度、QPS和时间序列
 type QueryStatsResponse struct {
-	TotalQueries int64   `json:"total_queries" example:"1000"`
-	HitCount     int64   `json:"hit_count" example:"150"`
-	MissCount    int64   `json:"miss_count" example:"850"`
-	HitRate      float64 `json:"hit_rate" example:"15.0"`
-	AvgLatency   float64 `json:"avg_latency_ms" example:"5.2"`
+	TotalQueries int64        `json:"total_queries" example:"1000"`
+	HitCount     int64        `json:"hit_count" example:"150"`
+	MissCount    int64        `json:"miss_count" example:"850"`
+	HitRate      float64      `json:"hit_rate" example:"15.0"`
+	AvgLatency   float64      `json:"avg_latency_ms" example:"5.2"`
+	Granularity  string       `json:"granularity,omitempty" example:"day"`
+	From         *time.Time   `json:"from,omitempty"`
+	To           *time.Time   `json:"to,omitempty"`
+	QPS          float64      `json:"qps,omitempty" example:"0.5"`
+	PeakQPS      float64      `json:"peak_qps,omitempty" example:"12.5"`
+	Series       []StatsPoint `json:"series,omitempty"` // 按时间升序
+}
+
+// FilterStatsResponse 本地过滤器统计响应
+type FilterStatsResponse struct {
+	Ready                      bool      `json:"ready" example:"true"`
+	Entries                    int64     `json:"entries" example:"120000"`
+	Capacity                   int       `json:"capacity" example:"240000"`
+	SizeBytes                  int       `json:"size_bytes" example:"431360"`
+	HashFunctions              int       `json:"hash_functions" example:"10"`
+	Lookups                    int64     `json:"lookups" example:"100000"`
+	Negatives                  int64     `json:"negatives" example:"98000"`
+	Positives                  int64     `json:"positives" example:"2000"`
+	FalsePositives             int64     `json:"false_positives" example:"12"`
+	Bypassed                   int64     `json:"bypassed" example:"0"`
+	Removals                   int64     `json:"removals" example:"3"`
+	SyncGaps                   int64     `json:"sync_gaps" example:"0"` // 发现同步消息丢失而重建的次数
+	ObservedFalsePositiveRate  float64   `json:"observed_false_positive_rate" example:"0.0001"`
+	EstimatedFalsePositiveRate float64   `json:"estimated_false_positive_rate" example:"0.0001"`
+	BuiltAt                    time.Time `json:"built_at"`
 }
 
 // MinuteStatsRequest 分钟级统计请求
@@ -204,9 +782,772 @@ type MinutePoint struct {
 	AvgLatency   float64 `json:"avg_latency_ms"`
 }
 
+// APIKeyStatsRequest API密钥统计请求
+// 未指定时间范围时minute粒度最多120个数据点，hour粒度最多48个，day粒度最多366个；指定时间范围时仅支持hour和day粒度
+type APIKeyStatsRequest struct {
+	Granularity string     `form:"granularity,default=minute" binding:"oneof=minute hour day" example:"minute"`
+	Points      int        `form:"points" binding:"omitempty,min=1,max=366" example:"60"` // 为空时minute粒度60个，hour粒度24个，day粒度30个
+	From        *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// ValidRange 时间范围是否有效，from和to需同时指定
+func (r *APIKeyStatsRequest) ValidRange() bool {
+	return validStatsRange(r.From, r.To)
+}
+
+// TopConsumersRequest 调用量排行请求
+type TopConsumersRequest struct {
+	Granularity string     `form:"granularity,default=hour" binding:"oneof=minute hour day" example:"hour"`
+	Points      int        `form:"points" binding:"omitempty,min=1,max=366" example:"24"` // 为空时minute粒度60个，hour粒度24个，day粒度30个
+	From        *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	SortBy      string     `form:"sort_by,default=total" binding:"oneof=total hits latency" example:"total"`
+	Limit       int        `form:"limit,default=10" binding:"min=1,max=100" example:"10"`
+}
+
+// ValidRange 时间范围是否有效，from和to需同时指定
+func (r *TopConsumersRequest) ValidRange() bool {
+	return validStatsRange(r.From, r.To)
+}
+
+// validStatsRange 统计时间范围需同时指定from和to，且from早于to
+func validStatsRange(from, to *time.Time) bool {
+	if from == nil || to == nil {
+		return from == nil && to == nil
+	}
+	return from.Before(*to)
+}
+
+// StatsPoint 统计数据点
+type StatsPoint struct {
+	Time         time.Time `json:"time"` // 时间段开始时间
+	TotalQueries int64     `json:"total_queries" example:"1200"`
+	HitCount     int64     `json:"hit_count" example:"36"`
+	HitRate      float64   `json:"hit_rate" example:"3.0"`
+	QPS          float64   `json:"qps" example:"20.0"`
+	AvgLatency   float64   `json:"avg_latency_ms" example:"4.8"`
+}
+
+// APIKeyStatsResponse API密钥统计响应，排行中不返回series
+type APIKeyStatsResponse struct {
+	APIKey       string       `json:"api_key" example:"ak_1234567890abcdef"`
+	Name         string       `json:"name" example:"合作方A"`     // 从汇总表排行时已删除的密钥为空
+	Status       string       `json:"status" example:"active"` // 从汇总表排行时已删除的密钥为空
+	TotalQueries int64        `json:"total_queries" example:"72000"`
+	HitCount     int64        `json:"hit_count" example:"2160"`
+	MissCount    int64        `json:"miss_count" example:"69840"`
+	HitRate      float64      `json:"hit_rate" example:"3.0"`
+	QPS          float64      `json:"qps" example:"20.0"`
+	PeakQPS      float64      `json:"peak_qps" example:"35.5"`
+	AvgLatency   float64      `json:"avg_latency_ms" example:"4.8"`
+	Series       []StatsPoint `json:"series,omitempty"` // 按时间升序
+}
+
+// TopConsumersResponse 调用量排行响应
+type TopConsumersResponse struct {
+	Granularity string                `json:"granularity" example:"hour"`
+	Points      int                   `json:"points,omitempty" example:"24"` // 指定时间范围时为空
+	From        *time.Time            `json:"from,omitempty"`
+	To          *time.Time            `json:"to,omitempty"`
+	SortBy      string                `json:"sort_by" example:"total"`
+	Items       []APIKeyStatsResponse `json:"items"`
+}
+
 // BatchImportResponse 批量导入响应
 type BatchImportResponse struct {
-	SuccessCount int      `json:"success_count" example:"100"`
-	FailedCount  int      `json:"failed_count" example:"0"`
-	FailedItems  []string `json:"failed_items" example:"[]"`
+	BatchID         string   `json:"batch_id" example:"123e4567-e89b-12d3-a456-426614174000"` // 导入批次ID，可用于回滚
+	SuccessCount    int      `json:"success_count" example:"100"`
+	BackfilledCount int      `json:"backfilled_count" example:"20"` // 为已有条目补充缺少哈希格式的数量，包含在success_count中
+	DuplicateCount  int      `json:"duplicate_count" example:"0"`   // 名单中已存在的条目数，不重复写入
+	FailedCount     int      `json:"failed_count" example:"0"`
+	FailedItems     []string `json:"failed_items" example:"[]"`
+}
+
+// ImportBlacklistFileResponse 文件导入响应
+type ImportBlacklistFileResponse struct {
+	BatchID         string                `json:"batch_id" example:"123e4567-e89b-12d3-a456-426614174000"` // 导入批次ID，可用于回滚
+	AcceptedCount   int                   `json:"accepted_count" example:"95"`
+	DuplicateCount  int                   `json:"duplicate_count" example:"3"`
+	InvalidCount    int                   `json:"invalid_count" example:"2"`
+	BackfilledCount int                   `json:"backfilled_count" example:"1"` // 重复行中为已有条目补充缺少哈希格式的数量
+	Truncated       bool                  `json:"truncated" example:"false"`    // 超过单次导入行数上限，超出的行未处理
+	Rows            []ImportFileRowReport `json:"rows"`
+}
+
+// ImportFileRowReport 单行导入结果
+type ImportFileRowReport struct {
+	Row    int    `json:"row" example:"2"`
+	Value  string `json:"value" example:"+86 138-0013-8000"`
+	Status string `json:"status" example:"accepted"` // accepted, duplicate, invalid
+	Reason string `json:"reason,omitempty" example:"已在黑名单中"`
+}
+
+// ImportJobInfo 导入任务信息
+type ImportJobInfo struct {
+	JobID           string               `json:"job_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Status          string               `json:"status" example:"running"` // pending, running, completed, failed, cancelled
+	FileName        string               `json:"file_name" example:"phones.csv"`
+	FileSize        int64                `json:"file_size" example:"12000000"`
+	ValueType       string               `json:"value_type" example:"phone"`
+	IdentifierType  string               `json:"identifier_type" example:"phone"`
+	TotalRows       int64                `json:"total_rows" example:"1000000"` // 开始执行后统计，0表示尚未统计
+	ProcessedRows   int64                `json:"processed_rows" example:"250000"`
+	Progress        float64              `json:"progress" example:"25"` // 百分比
+	AcceptedCount   int64                `json:"accepted_count" example:"240000"`
+	DuplicateCount  int64                `json:"duplicate_count" example:"9000"`
+	InvalidCount    int64                `json:"invalid_count" example:"1000"`
+	BackfilledCount int64                `json:"backfilled_count" example:"0"`
+	RowErrors       []ImportRowErrorInfo `json:"row_errors,omitempty"` // 无效行样例，最多100条，仅详情返回
+	ErrorMessage    string               `json:"error_message,omitempty"`
+	CancelRequested bool                 `json:"cancel_requested" example:"false"`
+	CreatedAt       time.Time            `json:"created_at"`
+	StartedAt       *time.Time           `json:"started_at"`
+	FinishedAt      *time.Time           `json:"finished_at"`
+}
+
+// ImportRowErrorInfo 无效行
+type ImportRowErrorInfo struct {
+	Row    int    `json:"row" example:"12"`
+	Value  string `json:"value" example:"1380013800"`
+	Reason string `json:"reason" example:"手机号格式错误"`
+}
+
+// NewImportJobInfo 根据导入任务构建响应，不包含无效行样例
+func NewImportJobInfo(job *models.BlacklistImportJob) ImportJobInfo {
+	info := ImportJobInfo{
+		JobID:           job.UUID,
+		Status:          job.Status,
+		FileName:        job.FileName,
+		FileSize:        job.FileSize,
+		ValueType:       job.ValueType,
+		IdentifierType:  job.IdentifierType,
+		TotalRows:       job.TotalRows,
+		ProcessedRows:   job.ProcessedRows,
+		AcceptedCount:   job.AcceptedCount,
+		DuplicateCount:  job.DuplicateCount,
+		InvalidCount:    job.InvalidCount,
+		BackfilledCount: job.BackfilledCount,
+		ErrorMessage:    job.ErrorMessage,
+		CancelRequested: job.CancelRequested,
+		CreatedAt:       job.CreatedAt,
+		StartedAt:       job.StartedAt,
+		FinishedAt:      job.FinishedAt,
+	}
+	switch {
+	case job.Status == models.ImportJobStatusCompleted:
+		info.Progress = 100
+	case job.TotalRows > 0:
+		info.Progress = math.Round(float64(job.ProcessedRows)*10000/float64(job.TotalRows)) / 100
+	}
+	return info
+}
+
+// ListImportJobsResponse 导入任务列表响应
+type ListImportJobsResponse struct {
+	Items      []ImportJobInfo `json:"items"`
+	Pagination PaginationInfo  `json:"pagination"`
+}
+
+// ImportBatchInfo 导入批次信息
+type ImportBatchInfo struct {
+	BatchID         string     `json:"batch_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Method          string     `json:"method" example:"file"` // api, file, job
+	Status          string     `json:"status" example:"completed"`
+	IdentifierType  string     `json:"identifier_type" example:"phone"`
+	Source          string     `json:"source" example:"file_import"`
+	Reason          string     `json:"reason" example:"运营名单"`
+	FileName        string     `json:"file_name" example:"phones.xlsx"`
+	ImportJobID     string     `json:"import_job_id" example:""` // 异步导入任务ID
+	OperatorID      uint64     `json:"operator_id" example:"1"`
+	EntryCount      int64      `json:"entry_count" example:"1000"`
+	RolledBackCount int64      `json:"rolled_back_count" example:"0"`
+	RolledBackBy    uint64     `json:"rolled_back_by" example:"0"`
+	RolledBackAt    *time.Time `json:"rolled_back_at"`
+	CreatedAt       time.Time  `json:"created_at" example:"2024-01-01T10:00:00Z"`
+}
+
+// NewImportBatchInfo 从导入批次构建批次信息
+func NewImportBatchInfo(batch *models.BlacklistImportBatch) ImportBatchInfo {
+	return ImportBatchInfo{
+		BatchID:         batch.UUID,
+		Method:          batch.Method,
+		Status:          batch.Status,
+		IdentifierType:  batch.IdentifierType,
+		Source:          batch.Source,
+		Reason:          batch.Reason,
+		FileName:        batch.FileName,
+		ImportJobID:     batch.ImportJobUUID,
+		OperatorID:      batch.OperatorID,
+		EntryCount:      batch.EntryCount,
+		RolledBackCount: batch.RolledBackCount,
+		RolledBackBy:    batch.RolledBackBy,
+		RolledBackAt:    batch.RolledBackAt,
+		CreatedAt:       batch.CreatedAt,
+	}
+}
+
+// ListImportBatchesResponse 导入批次列表响应
+type ListImportBatchesResponse struct {
+	Items      []ImportBatchInfo `json:"items"`
+	Pagination PaginationInfo    `json:"pagination"`
+}
+
+// ImportBatchDetailResponse 导入批次详情响应，entries为批次中仍存在的条目
+type ImportBatchDetailResponse struct {
+	Batch      ImportBatchInfo `json:"batch"`
+	Entries    []BlacklistInfo `json:"entries"`
+	Pagination PaginationInfo  `json:"pagination"`
+}
+
+// SyncBlacklistResponse 同步黑名单到Redis响应，新增和移除数量按全部哈希格式的Redis成员统计
+type SyncBlacklistResponse struct {
+	Message      string `json:"message" example:"黑名单数据同步成功"`
+	TotalCount   int    `json:"total_count" example:"1000"` // 数据库中的有效条目数
+	AddedCount   int    `json:"added_count" example:"12"`   // 同步前Redis中缺失的成员数
+	RemovedCount int    `json:"removed_count" example:"3"`  // 同步前Redis中多余的成员数
+}
+
+// DriftReportInfo Redis偏差检查记录，成员数按全部哈希格式统计
+type DriftReportInfo struct {
+	ID           uint64    `json:"id" example:"1"`
+	TriggeredBy  string    `json:"triggered_by" example:"scheduled"` // scheduled, manual
+	ActiveCount  int64     `json:"active_count" example:"1000"`      // 数据库中的有效条目数
+	MissingCount int64     `json:"missing_count" example:"2"`        // Redis缺失的成员数
+	ExtraCount   int64     `json:"extra_count" example:"0"`          // Redis多余的成员数
+	Repaired     bool      `json:"repaired" example:"true"`
+	ErrorMessage string    `json:"error_message" example:""`
+	DurationMs   int64     `json:"duration_ms" example:"35"`
+	CheckedAt    time.Time `json:"checked_at" example:"2024-01-01T10:00:00Z"`
+}
+
+// NewDriftReportInfo 从检查记录构建偏差信息
+func NewDriftReportInfo(report *models.BlacklistDriftReport) DriftReportInfo {
+	return DriftReportInfo{
+		ID:           report.ID,
+		TriggeredBy:  report.TriggeredBy,
+		ActiveCount:  report.ActiveCount,
+		MissingCount: report.MissingCount,
+		ExtraCount:   report.ExtraCount,
+		Repaired:     report.Repaired,
+		ErrorMessage: report.ErrorMessage,
+		DurationMs:   report.DurationMs,
+		CheckedAt:    report.CreatedAt,
+	}
+}
+
+// ListDriftReportsResponse Redis偏差检查记录列表响应
+type ListDriftReportsResponse struct {
+	Items      []DriftReportInfo `json:"items"`
+	Pagination PaginationInfo    `json:"pagination"`
+}
+
+// QueryLogInfo 查询日志，批量查询中每个哈希一条记录
+type QueryLogInfo struct {
+	ID             uint64    `json:"id" example:"1024"`
+	APIKey         string    `json:"api_key" example:"ak_1234567890abcdef"`
+	RequestID      string    `json:"request_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	IdentifierType string    `json:"identifier_type" example:"phone"`
+	HashType       string    `json:"hash_type" example:"md5"`
+	IdentifierHash string    `json:"identifier_hash" example:"5d41402abc4b2a76b9719d911017c592"`
+	IsHit          bool      `json:"is_hit" example:"true"`
+	StatusCode     int       `json:"status_code" example:"200"`
+	ResponseTime   int       `json:"response_time_ms" example:"3"`
+	Sampled        bool      `json:"sampled" example:"false"` // 是否为采样记录，未采样时仅记录命中和失败的查询
+	ClientIP       string    `json:"client_ip" example:"203.0.113.10"`
+	QueriedAt      time.Time `json:"queried_at" example:"2024-01-01T10:00:00Z"`
+}
+
+// NewQueryLogInfo 从查询日志模型构建日志信息
+func NewQueryLogInfo(log *models.BlacklistQueryLog) QueryLogInfo {
+	return QueryLogInfo{
+		ID:             log.ID,
+		APIKey:         log.APIKey,
+		RequestID:      log.RequestID,
+		IdentifierType: log.IdentifierType,
+		HashType:       log.HashType,
+		IdentifierHash: log.IdentifierHash,
+		IsHit:          log.IsHit,
+		StatusCode:     log.StatusCode,
+		ResponseTime:   log.ResponseTime,
+		Sampled:        log.Sampled,
+		ClientIP:       log.ClientIP,
+		QueriedAt:      log.CreatedAt,
+	}
+}
+
+// NewQueryLogInfos 批量构建查询日志信息
+func NewQueryLogInfos(logs []*models.BlacklistQueryLog) []QueryLogInfo {
+	items := make([]QueryLogInfo, 0, len(logs))
+	for _, log := range logs {
+		items = append(items, NewQueryLogInfo(log))
+	}
+	return items
+}
+
+// SearchQueryLogsResponse 搜索查询日志响应
+type SearchQueryLogsResponse struct {
+	Items      []QueryLogInfo `json:"items"`
+	NextCursor uint64         `json:"next_cursor" example:"1000"` // 下一页游标，没有更多数据时为0
+	HasMore    bool           `json:"has_more" example:"true"`
+}
+
+// QueryLogSummary 查询时间线汇总
+type QueryLogSummary struct {
+	TotalQueries int64      `json:"total_queries" example:"12"`
+	HitCount     int64      `json:"hit_count" example:"10"`
+	FirstQueryAt *time.Time `json:"first_query_at" example:"2024-01-01T10:00:00Z"`
+	LastQueryAt  *time.Time `json:"last_query_at" example:"2024-01-08T10:00:00Z"`
+	APIKeys      []string   `json:"api_keys"` // 查询过该哈希的API Key
+}
+
+// QueryLogTimelineResponse 查询时间线响应，汇总信息仅在第一页返回
+type QueryLogTimelineResponse struct {
+	IdentifierHash string           `json:"identifier_hash" example:"5d41402abc4b2a76b9719d911017c592"`
+	Summary        *QueryLogSummary `json:"summary,omitempty"`
+	Items          []QueryLogInfo   `json:"items"`
+	NextCursor     uint64           `json:"next_cursor" example:"1000"`
+	HasMore        bool             `json:"has_more" example:"false"`
+}
+
+// HashSaltResponse 租户盐响应
+type HashSaltResponse struct {
+	HashSalt  string `json:"hash_salt" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Algorithm string `json:"algorithm" example:"hex(HMAC-SHA256(key=hash_salt, message=hex(SHA256(normalized_identifier))))"`
+}
+
+// WebhookInfo Webhook订阅信息
+type WebhookInfo struct {
+	ID          uint64    `json:"id" example:"1"` // 与投递记录中的webhook_id对应
+	UUID        string    `json:"uuid" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Name        string    `json:"name" example:"风控告警"`
+	URL         string    `json:"url" example:"https://partner.example.com/webhooks/shield"`
+	EventTypes  []string  `json:"event_types" example:"blacklist.hit"`
+	Status      string    `json:"status" example:"active"`
+	Description string    `json:"description" example:"命中告警推送"`
+	CreatedBy   uint64    `json:"created_by" example:"1"`
+	CreatedAt   time.Time `json:"created_at" example:"2024-01-01T10:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2024-01-01T10:00:00Z"`
+}
+
+// NewWebhookInfo 从订阅模型构建订阅信息
+func NewWebhookInfo(webhook *models.BlacklistWebhook) WebhookInfo {
+	return WebhookInfo{
+		ID:          webhook.ID,
+		UUID:        webhook.UUID,
+		Name:        webhook.Name,
+		URL:         webhook.URL,
+		EventTypes:  webhook.EventTypeList(),
+		Status:      webhook.Status,
+		Description: webhook.Description,
+		CreatedBy:   webhook.CreatedBy,
+		CreatedAt:   webhook.CreatedAt,
+		UpdatedAt:   webhook.UpdatedAt,
+	}
+}
+
+// WebhookSecretResponse 创建订阅和轮换密钥响应，签名密钥仅在此时返回
+type WebhookSecretResponse struct {
+	WebhookInfo
+	Secret string `json:"secret" example:"abc123..."`
+}
+
+// ListWebhooksResponse Webhook订阅列表响应
+type ListWebhooksResponse struct {
+	Items      []WebhookInfo  `json:"items"`
+	Pagination PaginationInfo `json:"pagination"`
+}
+
+// WebhookDeliveryInfo Webhook投递信息，status为dead的记录即死信
+type WebhookDeliveryInfo struct {
+	ID             uint64     `json:"id" example:"1"`
+	WebhookID      uint64     `json:"webhook_id" example:"1"`
+	EventID        string     `json:"event_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	EventType      string     `json:"event_type" example:"blacklist.hit"`
+	Status         string     `json:"status" example:"pending"` // pending, succeeded, dead
+	Attempts       int        `json:"attempts" example:"2"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code" example:"503"`
+	LastError      string     `json:"last_error" example:"接收方返回状态码503"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at" example:"2024-01-01T10:00:00Z"`
+}
+
+// NewWebhookDeliveryInfo 从投递模型构建投递信息
+func NewWebhookDeliveryInfo(delivery *models.BlacklistWebhookDelivery) WebhookDeliveryInfo {
+	return WebhookDeliveryInfo{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextAttemptAt:  delivery.NextAttemptAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		LastAttemptAt:  delivery.LastAttemptAt,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
+
+// ListWebhookDeliveriesResponse Webhook投递记录列表响应
+type ListWebhookDeliveriesResponse struct {
+	Items      []WebhookDeliveryInfo `json:"items"`
+	Pagination PaginationInfo        `json:"pagination"`
+}
+
+// WebhookAttemptInfo 单次投递尝试
+type WebhookAttemptInfo struct {
+	Attempt     int       `json:"attempt" example:"1"`
+	StatusCode  int       `json:"status_code" example:"503"` // 0表示未收到响应
+	Error       string    `json:"error" example:"接收方返回状态码503"`
+	DurationMs  int64     `json:"duration_ms" example:"120"`
+	AttemptedAt time.Time `json:"attempted_at" example:"2024-01-01T10:00:00Z"`
+}
+
+// WebhookDeliveryDetailResponse Webhook投递详情响应，包含推送的事件内容和每次尝试的结果
+type WebhookDeliveryDetailResponse struct {
+	WebhookDeliveryInfo
+	Payload    json.RawMessage      `json:"payload" swaggertype:"object"`
+	AttemptLog []WebhookAttemptInfo `json:"attempt_log"`
+}
+
+// NewWebhookDeliveryDetailResponse 从投递及其尝试记录构建投递详情
+func NewWebhookDeliveryDetailResponse(delivery *models.BlacklistWebhookDelivery, attempts []*models.BlacklistWebhookAttempt) WebhookDeliveryDetailResponse {
+	attemptLog := make([]WebhookAttemptInfo, 0, len(attempts))
+	for _, attempt := range attempts {
+		attemptLog = append(attemptLog, WebhookAttemptInfo{
+			Attempt:     attempt.Attempt,
+			StatusCode:  attempt.StatusCode,
+			Error:       attempt.Error,
+			DurationMs:  attempt.DurationMs,
+			AttemptedAt: attempt.CreatedAt,
+		})
+	}
+	return WebhookDeliveryDetailResponse{
+		WebhookDeliveryInfo: NewWebhookDeliveryInfo(delivery),
+		Payload:             json.RawMessage(delivery.Payload),
+		AttemptLog:          attemptLog,
+	}
+}
+
+// BlacklistApprovalSettingResponse 租户黑名单审批配置
+type BlacklistApprovalSettingResponse struct {
+	RequireApproval bool `json:"require_approval" example:"true"` // 新增和删除条目是否需要审批
+}
+
+// UpdateBlacklistApprovalSettingRequest 更新租户黑名单审批配置请求
+type UpdateBlacklistApprovalSettingRequest struct {
+	RequireApproval *bool `json:"require_approval" binding:"required" example:"true"`
+}
+
+// ListChangeRequestsRequest 获取变更申请列表请求
+type ListChangeRequestsRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+	Page     int    `form:"page,default=1" binding:"min=1"`
+	PageSize int    `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// ReviewChangeRequestRequest 审批变更申请请求
+type ReviewChangeRequestRequest struct {
+	Comment string `json:"comment" binding:"max=500" example:"已核实投诉记录"`
+}
+
+// BlacklistChangeRequestInfo 黑名单变更申请信息，删除申请的条目信息为提交时的快照
+type BlacklistChangeRequestInfo struct {
+	RequestID        string     `json:"request_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Action           string     `json:"action" example:"create"`  // create, delete
+	Status           string     `json:"status" example:"pending"` // pending, approved, rejected
+	BlacklistID      uint64     `json:"blacklist_id" example:"0"` // 删除申请的目标条目，新增申请通过后为新建条目
+	ListID           uint64     `json:"list_id" example:"0"`      // 目标名单ID，0表示默认名单
+	IdentifierType   string     `json:"identifier_type" example:"phone"`
+	PhoneMD5         string     `json:"phone_md5" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierSHA256 string     `json:"identifier_sha256" example:""`
+	Source           string     `json:"source" example:"manual"`
+	Reason           string     `json:"reason" example:"用户投诉"`
+	Category         string     `json:"category" example:"complaint"`
+	RiskScore        int        `json:"risk_score" example:"60"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	RequestedBy      uint64     `json:"requested_by" example:"1"`
+	ReviewedBy       uint64     `json:"reviewed_by" example:"0"`
+	ReviewedAt       *time.Time `json:"reviewed_at"`
+	ReviewComment    string     `json:"review_comment" example:""`
+	CreatedAt        time.Time  `json:"created_at" example:"2024-01-01T10:00:00Z"`
+}
+
+// NewBlacklistChangeRequestInfo 从变更申请构建申请信息
+func NewBlacklistChangeRequestInfo(request *models.BlacklistChangeRequest) BlacklistChangeRequestInfo {
+	return BlacklistChangeRequestInfo{
+		RequestID:        request.UUID,
+		Action:           request.Action,
+		Status:           request.Status,
+		BlacklistID:      request.BlacklistID,
+		ListID:           request.ListID,
+		IdentifierType:   request.IdentifierType,
+		PhoneMD5:         request.PhoneMD5,
+		IdentifierSHA256: request.IdentifierSHA256,
+		Source:           request.Source,
+		Reason:           request.Reason,
+		Category:         request.Category,
+		RiskScore:        request.RiskScore,
+		ExpiresAt:        request.ExpiresAt,
+		RequestedBy:      request.RequestedBy,
+		ReviewedBy:       request.ReviewedBy,
+		ReviewedAt:       request.ReviewedAt,
+		ReviewComment:    request.ReviewComment,
+		CreatedAt:        request.CreatedAt,
+	}
+}
+
+// ListChangeRequestsResponse 变更申请列表响应
+type ListChangeRequestsResponse struct {
+	Items      []BlacklistChangeRequestInfo `json:"items"`
+	Pagination PaginationInfo               `json:"pagination"`
+}
+
+// CreateAllowlistEntryRequest 创建白名单条目请求
+// MD5和SHA-256至少提供一种，同时提供时可按两种格式以及HMAC-SHA256豁免
+type CreateAllowlistEntryRequest struct {
+	PhoneMD5         string `json:"phone_md5" binding:"omitempty,len=32" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierType   string `json:"identifier_type" binding:"omitempty,oneof=phone id_card device_id email ip bank_card" example:"phone"`
+	IdentifierMD5    string `json:"identifier_md5" binding:"omitempty,len=32" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierSHA256 string `json:"identifier_sha256" binding:"omitempty,len=64,hexadecimal" example:"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`
+	Reason           string `json:"reason" binding:"required,max=200" example:"客户申诉已核实"`
+	// ExpiresAt 过期时间，与ExpireDays二选一，均为空表示永久有效
+	ExpiresAt  *time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
+	ExpireDays int        `json:"expire_days" binding:"omitempty,min=1,max=3650" example:"30"`
+}
+
+// Resolve 获取标识类型、MD5和SHA-256
+func (r *CreateAllowlistEntryRequest) Resolve() (string, BlacklistHashItem, bool) {
+	identifierType, ok := resolveIdentifierType(r.IdentifierType)
+	if !ok {
+		return "", BlacklistHashItem{}, false
+	}
+
+	valueMD5 := r.IdentifierMD5
+	if valueMD5 == "" && identifierType == models.IdentifierTypePhone {
+		valueMD5 = r.PhoneMD5
+	}
+	item, ok := BlacklistHashItem{MD5: valueMD5, SHA256: r.IdentifierSHA256}.normalize()
+	if !ok {
+		return "", BlacklistHashItem{}, false
+	}
+	return identifierType, item, true
+}
+
+// ToModel 转换为模型，调用前需通过Resolve校验标识
+func (r *CreateAllowlistEntryRequest) ToModel(tenantID, operatorID uint64) *models.BlacklistAllowlistEntry {
+	identifierType, item, _ := r.Resolve()
+	return &models.BlacklistAllowlistEntry{
+		TenantModel:      models.TenantModel{TenantID: tenantID},
+		IdentifierType:   identifierType,
+		PhoneMD5:         item.MD5,
+		IdentifierSHA256: item.SHA256,
+		Reason:           r.Reason,
+		OperatorID:       operatorID,
+		ExpiresAt:        resolveExpiresAt(r.ExpiresAt, r.ExpireDays, time.Now()),
+	}
+}
+
+// UpdateAllowlistEntryRequest 更新白名单条目请求，过期时间均为空时改为永久有效
+type UpdateAllowlistEntryRequest struct {
+	Reason     string     `json:"reason" binding:"required,max=200" example:"客户申诉已核实，延长豁免"`
+	ExpiresAt  *time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
+	ExpireDays int        `json:"expire_days" binding:"omitempty,min=1,max=3650" example:"30"`
+}
+
+// ResolveExpiresAt 计算过期时间
+func (r *UpdateAllowlistEntryRequest) ResolveExpiresAt(now time.Time) *time.Time {
+	return resolveExpiresAt(r.ExpiresAt, r.ExpireDays, now)
+}
+
+// ListAllowlistEntriesRequest 获取白名单条目列表请求
+type ListAllowlistEntriesRequest struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// AllowlistEntryInfo 白名单条目信息
+type AllowlistEntryInfo struct {
+	EntryID          string     `json:"entry_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	IdentifierType   string     `json:"identifier_type" example:"phone"`
+	PhoneMD5         string     `json:"phone_md5" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierSHA256 string     `json:"identifier_sha256" example:""`
+	Reason           string     `json:"reason" example:"客户申诉已核实"`
+	OperatorID       uint64     `json:"operator_id" example:"1"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	IsExpired        bool       `json:"is_expired" example:"false"`
+	CreatedAt        time.Time  `json:"created_at" example:"2024-01-01T10:00:00Z"`
+	UpdatedAt        time.Time  `json:"updated_at" example:"2024-01-01T10:00:00Z"`
+}
+
+// NewAllowlistEntryInfo 从白名单条目构建条目信息
+func NewAllowlistEntryInfo(entry *models.BlacklistAllowlistEntry) AllowlistEntryInfo {
+	return AllowlistEntryInfo{
+		EntryID:          entry.UUID,
+		IdentifierType:   entry.IdentifierType,
+		PhoneMD5:         entry.PhoneMD5,
+		IdentifierSHA256: entry.IdentifierSHA256,
+		Reason:           entry.Reason,
+		OperatorID:       entry.OperatorID,
+		ExpiresAt:        entry.ExpiresAt,
+		IsExpired:        entry.IsExpired(time.Now()),
+		CreatedAt:        entry.CreatedAt,
+		UpdatedAt:        entry.UpdatedAt,
+	}
+}
+
+// ListAllowlistEntriesResponse 白名单条目列表响应
+type ListAllowlistEntriesResponse struct {
+	Items      []AllowlistEntryInfo `json:"items"`
+	Pagination PaginationInfo       `json:"pagination"`
+}
+
+// CreateBlacklistListRequest 创建名单请求
+type CreateBlacklistListRequest struct {
+	Name        string `json:"name" binding:"required,max=50" example:"loan_fraud"` // 名单标识，小写字母开头，由小写字母、数字、下划线和连字符组成
+	Description string `json:"description" binding:"max=200" example:"信贷欺诈名单"`
+}
+
+// UpdateBlacklistListRequest 更新名单请求，名单标识不可修改
+type UpdateBlacklistListRequest struct {
+	Description string `json:"description" binding:"max=200" example:"信贷欺诈名单"`
+}
+
+// BlacklistListInfo 名单信息
+type BlacklistListInfo struct {
+	ListID      string    `json:"list_id" example:"123e4567-e89b-12d3-a456-426614174000"`
+	ID          uint64    `json:"id" example:"1"` // 条目中的list_id
+	Name        string    `json:"name" example:"loan_fraud"`
+	Description string    `json:"description" example:"信贷欺诈名单"`
+	CreatedAt   time.Time `json:"created_at" example:"2024-01-01T10:00:00Z"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2024-01-01T10:00:00Z"`
+}
+
+// NewBlacklistListInfo 从名单构建名单信息
+func NewBlacklistListInfo(list *models.BlacklistList) BlacklistListInfo {
+	return BlacklistListInfo{
+		ListID:      list.UUID,
+		ID:          list.ID,
+		Name:        list.Name,
+		Description: list.Description,
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+	}
+}
+
+// ListBlacklistListsResponse 名单列表响应，不包含隐式的默认名单
+type ListBlacklistListsResponse struct {
+	Items []BlacklistListInfo `json:"items"`
+}
+
+// UpdateCredentialListsRequest 设置API密钥名单授权请求，为空时仅可查询默认名单
+type UpdateCredentialListsRequest struct {
+	Lists []string `json:"lists" binding:"max=50" example:"default,loan_fraud"`
+}
+
+// CredentialListsResponse API密钥名单授权响应
+type CredentialListsResponse struct {
+	CredentialID uint64   `json:"credential_id" example:"1"`
+	Lists        []string `json:"lists" example:"default,loan_fraud"`
+}
+
+// BlacklistSharedSettingResponse 租户共享名单配置
+type BlacklistSharedSettingResponse struct {
+	SharedSubscribed  bool `json:"shared_subscribed" example:"true"`   // 查询时是否同时查询共享名单
+	SharedContributor bool `json:"shared_contributor" example:"false"` // 是否可向共享名单贡献条目
+}
+
+// UpdateBlacklistSharedSettingRequest 更新租户共享名单配置请求
+type UpdateBlacklistSharedSettingRequest struct {
+	SharedSubscribed  *bool `json:"shared_subscribed" binding:"required" example:"true"`
+	SharedContributor *bool `json:"shared_contributor" binding:"required" example:"false"`
+}
+
+// ContributeSharedRequest 向共享名单贡献条目请求，MD5和SHA-256至少提供一种
+type ContributeSharedRequest struct {
+	PhoneMD5         string `json:"phone_md5" binding:"omitempty,len=32" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierType   string `json:"identifier_type" binding:"omitempty,oneof=phone id_card device_id email ip bank_card" example:"phone"`
+	IdentifierMD5    string `json:"identifier_md5" binding:"omitempty,len=32" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierSHA256 string `json:"identifier_sha256" binding:"omitempty,len=64,hexadecimal" example:"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"`
+	Reason           string `json:"reason" binding:"max=200" example:"已确认的欺诈号码"` // 仅系统管理员可见
+	Category         string `json:"category" binding:"omitempty,oneof=fraud complaint collection_harassment other" example:"fraud"`
+	RiskScore        int    `json:"risk_score" binding:"omitempty,min=1,max=100" example:"90"` // 为空时默认100
+	// ExpiresAt 过期时间，与ExpireDays二选一，均为空表示永久有效
+	ExpiresAt  *time.Time `json:"expires_at" example:"2024-12-31T23:59:59Z"`
+	ExpireDays int        `json:"expire_days" binding:"omitempty,min=1,max=3650" example:"90"`
+}
+
+// createRequest 转换为创建请求，复用标识校验和模型转换
+func (r *ContributeSharedRequest) createRequest() *CreateBlacklistRequest {
+	return &CreateBlacklistRequest{
+		PhoneMD5:         r.PhoneMD5,
+		IdentifierType:   r.IdentifierType,
+		IdentifierMD5:    r.IdentifierMD5,
+		IdentifierSHA256: r.IdentifierSHA256,
+		Source:           "shared",
+		Reason:           r.Reason,
+		Category:         r.Category,
+		RiskScore:        r.RiskScore,
+		ExpiresAt:        r.ExpiresAt,
+		ExpireDays:       r.ExpireDays,
+	}
+}
+
+// Resolve 校验标识
+func (r *ContributeSharedRequest) Resolve() bool {
+	_, _, ok := r.createRequest().Resolve()
+	return ok
+}
+
+// ToModel 转换为模型，归属租户和贡献租户由服务层设置，调用前需通过Resolve校验标识
+func (r *ContributeSharedRequest) ToModel(operatorID uint64) *models.PhoneBlacklist {
+	return r.createRequest().ToModel(models.SharedTenantID, operatorID)
+}
+
+// ListSharedContributionsRequest 获取本租户贡献的共享名单条目请求
+type ListSharedContributionsRequest struct {
+	Page     int `form:"page,default=1" binding:"min=1"`
+	PageSize int `form:"page_size,default=20" binding:"min=1,max=100"`
+}
+
+// SharedContributionInfo 本租户贡献的共享名单条目，系统租户查看时为共享名单的全部条目
+type SharedContributionInfo struct {
+	ID                  uint64     `json:"id" example:"1"`
+	ContributorTenantID uint64     `json:"contributor_tenant_id" example:"2"` // 贡献租户ID，0表示由系统管理员维护
+	IdentifierType      string     `json:"identifier_type" example:"phone"`
+	PhoneMD5            string     `json:"phone_md5" example:"5d41402abc4b2a76b9719d911017c592"`
+	IdentifierSHA256    string     `json:"identifier_sha256" example:""`
+	Reason              string     `json:"reason" example:"已确认的欺诈号码"`
+	Category            string     `json:"category" example:"fraud"`
+	RiskScore           int        `json:"risk_score" example:"90"`
+	OperatorID          uint64     `json:"operator_id" example:"1"`
+	ExpiresAt           *time.Time `json:"expires_at"`
+	CreatedAt           time.Time  `json:"created_at" example:"2024-01-01T10:00:00Z"`
+}
+
+// NewSharedContributionInfo 从共享名单条目构建贡献信息
+func NewSharedContributionInfo(blacklist *models.PhoneBlacklist) SharedContributionInfo {
+	return SharedContributionInfo{
+		ID:                  blacklist.ID,
+		ContributorTenantID: blacklist.ContributorTenantID,
+		IdentifierType:      blacklist.IdentifierType,
+		PhoneMD5:            blacklist.PhoneMD5,
+		IdentifierSHA256:    blacklist.IdentifierSHA256,
+		Reason:              blacklist.Reason,
+		Category:            blacklist.Category,
+		RiskScore:           blacklist.RiskScore,
+		OperatorID:          blacklist.OperatorID,
+		ExpiresAt:           blacklist.ExpiresAt,
+		CreatedAt:           blacklist.CreatedAt,
+	}
+}
+
+// ListSharedContributionsResponse 本租户贡献的共享名单条目列表响应
+type ListSharedContributionsResponse struct {
+	Items      []SharedContributionInfo `json:"items"`
+	Pagination PaginationInfo           `json:"pagination"`
 }
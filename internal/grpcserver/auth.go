@@ -0,0 +1,208 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"strings"
+
+	blacklistv1 "github.com/varluffy/shield/api/proto/blacklist/v1"
+	"github.com/varluffy/shield/internal/models"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// 鉴权元数据，与HTTP接口的请求头对应
+const (
+	metadataAPIKey    = "x-api-key"
+	metadataTimestamp = "x-timestamp"
+	metadataNonce     = "x-nonce"
+	metadataSignature = "x-signature"
+	metadataRealIP    = "x-real-ip"
+	metadataForwarded = "x-forwarded-for"
+	metadataUserAgent = "user-agent"
+	metadataRequestID = "x-request-id"
+)
+
+// authInfo 鉴权通过后的调用方信息
+type authInfo struct {
+	apiKey     string
+	nonce      string
+	credential *models.BlacklistApiCredential
+	clientIP   string
+	userAgent  string
+}
+
+type authInfoKey struct{}
+
+// authFromContext 获取鉴权信息
+func authFromContext(ctx context.Context) (*authInfo, bool) {
+	info, ok := ctx.Value(authInfoKey{}).(*authInfo)
+	return info, ok
+}
+
+// unaryAuthInterceptor 单次调用鉴权：签名、IP白名单和速率限制与HTTP接口一致，签名覆盖请求消息
+func (s *Server) unaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	message, ok := req.(proto.Message)
+	if !ok {
+		return nil, status.Error(codes.Internal, "不支持的请求类型")
+	}
+	payload, err := marshalPayload(message)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "请求消息格式错误")
+	}
+	auth, err := s.authenticate(ctx, info.FullMethod, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkRateLimit(ctx, auth.apiKey); err != nil {
+		return nil, err
+	}
+	return handler(context.WithValue(ctx, authInfoKey{}, auth), req)
+}
+
+// streamAuthInterceptor 流式调用鉴权：建立流时验证签名和IP白名单，每条消息的签名和速率限制由处理方法逐条检查
+func (s *Server) streamAuthInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	auth, err := s.authenticate(ss.Context(), info.FullMethod, "")
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authServerStream{
+		ServerStream: ss,
+		ctx:          context.WithValue(ss.Context(), authInfoKey{}, auth),
+	})
+}
+
+// authenticate 验证调用的HMAC签名和IP白名单
+// 签名内容为api_key+timestamp+nonce+完整方法名+请求消息，建立流时请求消息为空
+func (s *Server) authenticate(ctx context.Context, fullMethod, payload string) (*authInfo, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	apiKey := firstMetadata(md, metadataAPIKey)
+	timestamp := firstMetadata(md, metadataTimestamp)
+	nonce := firstMetadata(md, metadataNonce)
+	signature := firstMetadata(md, metadataSignature)
+
+	if apiKey == "" || timestamp == "" || nonce == "" || signature == "" {
+		s.logger.WarnWithTrace(ctx, "gRPC调用缺少鉴权元数据",
+			zap.String("method", fullMethod))
+		return nil, status.Error(codes.Unauthenticated, "缺少鉴权信息")
+	}
+
+	credential, err := s.authService.ValidateHMACSignature(ctx, apiKey, timestamp, nonce, signature, fullMethod+payload)
+	if err != nil {
+		s.logger.WarnWithTrace(ctx, "HMAC签名验证失败",
+			zap.String("api_key", apiKey),
+			zap.String("method", fullMethod),
+			zap.Error(err))
+		return nil, status.Error(codes.Unauthenticated, "鉴权失败")
+	}
+
+	clientIP := clientIPFromContext(ctx, md)
+	if !s.authService.IsIPAllowed(clientIP, credential.IPWhitelist) {
+		s.logger.WarnWithTrace(ctx, "IP地址不在白名单中",
+			zap.String("api_key", apiKey),
+			zap.String("client_ip", clientIP),
+			zap.String("ip_whitelist", credential.IPWhitelist))
+		return nil, status.Error(codes.PermissionDenied, "IP地址不在白名单中")
+	}
+
+	// 异步更新API密钥使用时间
+	s.authService.UpdateAPIKeyUsage(ctx, apiKey)
+
+	return &authInfo{
+		apiKey:     apiKey,
+		nonce:      nonce,
+		credential: credential,
+		clientIP:   clientIP,
+		userAgent:  firstMetadata(md, metadataUserAgent),
+	}, nil
+}
+
+// verifyStreamMessage 验证流中第sequence条请求的签名：HMAC-SHA256(api_secret, nonce+序号+不含签名的请求消息)
+// 签名绑定建立流时已验证的nonce和请求顺序，请求不能被替换、重排或在其他流中重放
+func verifyStreamMessage(auth *authInfo, sequence int64, req *blacklistv1.CheckRequest) error {
+	unsigned := proto.Clone(req).(*blacklistv1.CheckRequest)
+	unsigned.Signature = ""
+	payload, err := marshalPayload(unsigned)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, "请求消息格式错误")
+	}
+
+	mac := hmac.New(sha256.New, []byte(auth.credential.APISecret))
+	mac.Write([]byte(auth.nonce + strconv.FormatInt(sequence, 10) + payload))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(req.GetSignature()), []byte(expected)) {
+		return status.Error(codes.Unauthenticated, "请求签名验证失败")
+	}
+	return nil
+}
+
+// marshalPayload 按字段编号顺序序列化请求消息，与客户端签名时的序列化结果一致
+func marshalPayload(message proto.Message) (string, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// checkRateLimit 检查API密钥的速率限制
+func (s *Server) checkRateLimit(ctx context.Context, apiKey string) error {
+	if err := s.authService.CheckRateLimit(ctx, apiKey); err != nil {
+		s.logger.WarnWithTrace(ctx, "请求频率超限",
+			zap.String("api_key", apiKey),
+			zap.Error(err))
+		return status.Error(codes.ResourceExhausted, "请求频率超限")
+	}
+	return nil
+}
+
+// authServerStream 携带鉴权信息的服务端流
+type authServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context 返回携带鉴权信息的上下文
+func (s *authServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// clientIPFromContext 获取客户端真实IP，优先使用代理转发的元数据
+func clientIPFromContext(ctx context.Context, md metadata.MD) string {
+	if ip := firstMetadata(md, metadataRealIP); ip != "" {
+		return ip
+	}
+
+	if ips := firstMetadata(md, metadataForwarded); ips != "" {
+		parts := strings.Split(ips, ",")
+		return strings.TrimSpace(parts[0])
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	addr := p.Addr.String()
+	ip, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return ip
+}
+
+// firstMetadata 获取元数据的第一个值
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
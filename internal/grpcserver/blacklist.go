@@ -0,0 +1,323 @@
+package grpcserver
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	blacklistv1 "github.com/varluffy/shield/api/proto/blacklist/v1"
+	"github.com/varluffy/shield/internal/dto"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/services"
+	"github.com/varluffy/shield/pkg/errors"
+	"github.com/varluffy/shield/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// 查询参数上限，与HTTP查询接口一致
+const (
+	maxBatchSize  = 100 // 批量查询单次最多哈希数
+	maxCheckLists = 20  // 单次查询最多指定的名单数
+)
+
+// blacklistServer 黑名单查询服务实现
+type blacklistServer struct {
+	blacklistv1.UnimplementedBlacklistServiceServer
+
+	blacklistService services.BlacklistService
+	listService      services.ListService
+	authService      services.BlacklistAuthService
+	webhookService   services.WebhookService
+	logger           *logger.Logger
+}
+
+// Check 查询单个标识哈希
+func (s *blacklistServer) Check(ctx context.Context, req *blacklistv1.CheckRequest) (*blacklistv1.CheckResponse, error) {
+	requestID := uuid.New().String()
+	_ = grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, requestID))
+	return s.check(ctx, requestID, req)
+}
+
+// CheckStream 双向流查询，每条请求按顺序返回一条响应
+// 任一请求签名错误、参数错误、超出速率限制或查询失败时结束流
+func (s *blacklistServer) CheckStream(stream blacklistv1.BlacklistService_CheckStreamServer) error {
+	ctx := stream.Context()
+	auth, ok := authFromContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "鉴权信息丢失")
+	}
+
+	var sequence int64
+	for {
+		req, err := stream.Recv()
+		if stderrors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		sequence++
+		if err := verifyStreamMessage(auth, sequence, req); err != nil {
+			s.logger.WarnWithTrace(ctx, "流式请求签名验证失败",
+				zap.String("api_key", auth.apiKey),
+				zap.Int64("sequence", sequence))
+			return err
+		}
+
+		if err := s.authService.CheckRateLimit(ctx, auth.apiKey); err != nil {
+			s.logger.WarnWithTrace(ctx, "请求频率超限",
+				zap.String("api_key", auth.apiKey),
+				zap.Error(err))
+			return status.Error(codes.ResourceExhausted, "请求频率超限")
+		}
+
+		resp, err := s.check(ctx, uuid.New().String(), req)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// check 查询单个标识哈希，记录查询统计和查询日志
+func (s *blacklistServer) check(ctx context.Context, requestID string, req *blacklistv1.CheckRequest) (*blacklistv1.CheckResponse, error) {
+	start := time.Now()
+	auth, ok := authFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "鉴权信息丢失")
+	}
+	tenantID := auth.credential.TenantID
+
+	checkReq := dto.CheckBlacklistRequest{
+		IdentifierType: req.GetIdentifierType(),
+		HashType:       req.GetHashType(),
+		IdentifierHash: req.GetIdentifierHash(),
+	}
+	identifierType, hashType, hash, ok := checkReq.Resolve()
+	if !ok {
+		s.recordQueryLog(ctx, auth, requestID, req.GetIdentifierType(), req.GetHashType(), nil, false, http.StatusBadRequest, start)
+		return nil, status.Error(codes.InvalidArgument, "缺少查询标识或格式错误")
+	}
+	if len(req.GetLists()) > maxCheckLists {
+		s.recordQueryLog(ctx, auth, requestID, identifierType, hashType, nil, false, http.StatusBadRequest, start)
+		return nil, status.Errorf(codes.InvalidArgument, "单次最多指定%d个名单", maxCheckLists)
+	}
+
+	// 按API密钥的名单授权确定查询范围，未指定名单时查询已授权的全部名单
+	var result *services.CheckResult
+	lists, err := s.listService.ResolveCheckLists(ctx, tenantID, auth.credential, req.GetLists())
+	if err == nil {
+		result, err = s.blacklistService.CheckIdentifier(ctx, tenantID, identifierType, hashType, hash, lists)
+	}
+	if err != nil {
+		s.logger.ErrorWithTrace(ctx, "黑名单查询失败",
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("identifier_type", identifierType),
+			zap.String("hash_type", hashType),
+			zap.String("hash", hash),
+			zap.Error(err))
+		statusCode, grpcErr := checkError(err)
+		s.recordQueryLog(ctx, auth, requestID, identifierType, hashType, map[string]bool{hash: false}, false, statusCode, start)
+		return nil, grpcErr
+	}
+
+	// 低于API密钥风险分阈值的命中视为未命中
+	isBlacklist := result.Hit && result.RiskScore >= auth.credential.MinRiskScore
+	if isBlacklist {
+		s.publishHits(ctx, auth, tenantID, requestID, identifierType, hashType, []services.WebhookHitItem{
+			{IdentifierHash: hash, Category: result.Category, RiskScore: result.RiskScore},
+		})
+	}
+
+	// 更新查询统计（异步执行，不影响响应）
+	latencyMs := time.Since(start).Milliseconds()
+	go s.blacklistService.UpdateQueryMetrics(context.Background(), tenantID, auth.apiKey, isBlacklist, latencyMs)
+	s.recordQueryLog(ctx, auth, requestID, identifierType, hashType, map[string]bool{hash: isBlacklist}, isBlacklist, http.StatusOK, start)
+
+	resp := newCheckResponse(identifierType, hashType, hash, isBlacklist, result, auth.credential.MinRiskScore)
+	resp.CorrelationId = req.GetCorrelationId()
+	resp.RequestId = requestID
+	return resp, nil
+}
+
+// CheckBatch 批量查询，结果顺序与请求中的哈希一致
+func (s *blacklistServer) CheckBatch(ctx context.Context, req *blacklistv1.CheckBatchRequest) (*blacklistv1.CheckBatchResponse, error) {
+	start := time.Now()
+	requestID := uuid.New().String()
+	_ = grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, requestID))
+
+	auth, ok := authFromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "鉴权信息丢失")
+	}
+	tenantID := auth.credential.TenantID
+
+	if len(req.GetIdentifierHashes()) > maxBatchSize {
+		s.recordQueryLog(ctx, auth, requestID, req.GetIdentifierType(), req.GetHashType(), nil, false, http.StatusBadRequest, start)
+		return nil, status.Errorf(codes.InvalidArgument, "单次最多查询%d个哈希", maxBatchSize)
+	}
+
+	batchReq := dto.CheckBlacklistBatchRequest{
+		IdentifierType:     req.GetIdentifierType(),
+		HashType:           req.GetHashType(),
+		IdentifierHashList: req.GetIdentifierHashes(),
+	}
+	identifierType, hashType, hashList, ok := batchReq.Resolve()
+	if !ok {
+		s.recordQueryLog(ctx, auth, requestID, req.GetIdentifierType(), req.GetHashType(), nil, false, http.StatusBadRequest, start)
+		return nil, status.Error(codes.InvalidArgument, "缺少查询标识或哈希格式错误")
+	}
+	if len(req.GetLists()) > maxCheckLists {
+		s.recordQueryLog(ctx, auth, requestID, identifierType, hashType, nil, false, http.StatusBadRequest, start)
+		return nil, status.Errorf(codes.InvalidArgument, "单次最多指定%d个名单", maxCheckLists)
+	}
+
+	var results map[string]*services.CheckResult
+	lists, err := s.listService.ResolveCheckLists(ctx, tenantID, auth.credential, req.GetLists())
+	if err == nil {
+		results, err = s.blacklistService.CheckIdentifierBatch(ctx, tenantID, identifierType, hashType, hashList, lists)
+	}
+	if err != nil {
+		s.logger.ErrorWithTrace(ctx, "批量黑名单查询失败",
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("identifier_type", identifierType),
+			zap.String("hash_type", hashType),
+			zap.Int("batch_size", len(hashList)),
+			zap.Error(err))
+		failed := make(map[string]bool, len(hashList))
+		for _, hash := range hashList {
+			failed[hash] = false
+		}
+		statusCode, grpcErr := checkError(err)
+		s.recordQueryLog(ctx, auth, requestID, identifierType, hashType, failed, false, statusCode, start)
+		return nil, grpcErr
+	}
+
+	// 构建响应，低于API密钥风险分阈值的命中视为未命中
+	resp := &blacklistv1.CheckBatchResponse{
+		Results:   make([]*blacklistv1.CheckResponse, 0, len(hashList)),
+		RequestId: requestID,
+	}
+	hits := make(map[string]bool, len(hashList))
+	var hitItems []services.WebhookHitItem
+	for _, hash := range hashList {
+		result := results[hash]
+		isBlacklist := result.Hit && result.RiskScore >= auth.credential.MinRiskScore
+		if isBlacklist {
+			hitItems = append(hitItems, services.WebhookHitItem{
+				IdentifierHash: hash,
+				Category:       result.Category,
+				RiskScore:      result.RiskScore,
+			})
+		}
+		hits[hash] = isBlacklist
+		resp.Results = append(resp.Results, newCheckResponse(identifierType, hashType, hash, isBlacklist, result, auth.credential.MinRiskScore))
+	}
+
+	hitCount := len(hitItems)
+	if hitCount > 0 {
+		s.publishHits(ctx, auth, tenantID, requestID, identifierType, hashType, hitItems)
+	}
+
+	// 更新查询统计（异步执行，不影响响应），超过一半命中时认为是命中
+	latencyMs := time.Since(start).Milliseconds()
+	isHit := float64(hitCount)/float64(len(hashList)) > 0.5
+	go s.blacklistService.UpdateQueryMetrics(context.Background(), tenantID, auth.apiKey, isHit, latencyMs)
+	s.recordQueryLog(ctx, auth, requestID, identifierType, hashType, hits, false, http.StatusOK, start)
+
+	s.logger.DebugWithTrace(ctx, "gRPC批量黑名单查询成功",
+		zap.Uint64("tenant_id", tenantID),
+		zap.Int("total_count", len(hashList)),
+		zap.Int("hit_count", hitCount),
+		zap.Duration("duration", time.Since(start)))
+
+	return resp, nil
+}
+
+// publishHits 发布命中事件，由Webhook服务异步投递给订阅了命中事件的接收方
+func (s *blacklistServer) publishHits(ctx context.Context, auth *authInfo, tenantID uint64, requestID, identifierType, hashType string, hits []services.WebhookHitItem) {
+	s.webhookService.Publish(ctx, tenantID, models.WebhookEventHit, services.WebhookHitData{
+		APIKey:         auth.apiKey,
+		RequestID:      requestID,
+		IdentifierType: identifierType,
+		HashType:       hashType,
+		Hits:           hits,
+	})
+}
+
+// grpcCodes 业务错误的HTTP状态码对应的gRPC状态码
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:         codes.InvalidArgument,
+	http.StatusUnauthorized:       codes.Unauthenticated,
+	http.StatusForbidden:          codes.PermissionDenied,
+	http.StatusNotFound:           codes.NotFound,
+	http.StatusRequestTimeout:     codes.DeadlineExceeded,
+	http.StatusConflict:           codes.Aborted,
+	http.StatusTooManyRequests:    codes.ResourceExhausted,
+	http.StatusServiceUnavailable: codes.Unavailable,
+}
+
+// checkError 将查询失败的错误转换为查询日志中的HTTP状态码和gRPC状态，与HTTP接口的同类结果一致
+// 业务错误（如名单不存在、未授权的名单、数据库回退超限）按其HTTP状态码对应，其余为Internal
+func checkError(err error) (int, error) {
+	bizErr, ok := err.(*errors.BusinessError)
+	if !ok {
+		return http.StatusInternalServerError, status.Error(codes.Internal, "查询失败")
+	}
+
+	code, ok := grpcCodes[bizErr.HTTPStatus]
+	if !ok {
+		return bizErr.HTTPStatus, status.Error(codes.Internal, "查询失败")
+	}
+	message := bizErr.Details
+	if message == "" {
+		message = bizErr.Message
+	}
+	return bizErr.HTTPStatus, status.Error(code, message)
+}
+
+// recordQueryLog 记录查询日志，状态码与HTTP接口的同类结果一致，便于统一检索
+func (s *blacklistServer) recordQueryLog(ctx context.Context, auth *authInfo, requestID, identifierType, hashType string, results map[string]bool, isHit bool, statusCode int, start time.Time) {
+	s.authService.RecordQueryLog(ctx, &services.QueryLogRecord{
+		TenantID:       auth.credential.TenantID,
+		APIKey:         auth.apiKey,
+		RequestID:      requestID,
+		IdentifierType: identifierType,
+		HashType:       hashType,
+		Results:        results,
+		IsHit:          isHit,
+		StatusCode:     statusCode,
+		ResponseTime:   int(time.Since(start).Milliseconds()),
+		ClientIP:       auth.clientIP,
+		UserAgent:      auth.userAgent,
+		SampleRate:     auth.credential.LogSampleRate,
+	})
+}
+
+// newCheckResponse 构建单个标识的查询结果，风险信息、命中的名单和命中来源仅命中时返回，均不计入低于风险分阈值的命中
+func newCheckResponse(identifierType, hashType, hash string, isBlacklist bool, result *services.CheckResult, minRiskScore int) *blacklistv1.CheckResponse {
+	resp := &blacklistv1.CheckResponse{
+		IsBlacklist:    isBlacklist,
+		IdentifierType: identifierType,
+		HashType:       hashType,
+		IdentifierHash: hash,
+		Overridden:     result.OverriddenAbove(minRiskScore),
+	}
+	if isBlacklist {
+		resp.Category = result.Category
+		resp.RiskScore = int32(result.RiskScore)
+		resp.HitLists = result.HitLists(minRiskScore)
+		resp.HitSource = result.HitSource(minRiskScore)
+	}
+	return resp
+}
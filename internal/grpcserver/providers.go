@@ -0,0 +1,8 @@
+package grpcserver
+
+import "github.com/google/wire"
+
+// ProviderSet gRPC服务的依赖注入Provider集合
+var ProviderSet = wire.NewSet(
+	NewServer,
+)
@@ -0,0 +1,135 @@
+// Package grpcserver provides the gRPC server for high-volume blacklist checks.
+// It shares API credentials, IP whitelists, rate limits and query statistics with the HTTP API.
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime/debug"
+
+	blacklistv1 "github.com/varluffy/shield/api/proto/blacklist/v1"
+	"github.com/varluffy/shield/internal/config"
+	"github.com/varluffy/shield/internal/services"
+	"github.com/varluffy/shield/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC服务参数默认值
+const (
+	defaultMaxConcurrentStreams = 1000
+	defaultMaxRecvMsgSize       = 1 << 20 // 1MB
+)
+
+// Server gRPC服务
+type Server struct {
+	cfg         config.GRPCConfig
+	server      *grpc.Server
+	authService services.BlacklistAuthService
+	logger      *logger.Logger
+}
+
+// NewServer 创建gRPC服务并注册黑名单查询服务
+func NewServer(
+	cfg *config.Config,
+	blacklistService services.BlacklistService,
+	listService services.ListService,
+	authService services.BlacklistAuthService,
+	webhookService services.WebhookService,
+	logger *logger.Logger,
+) *Server {
+	var grpcCfg config.GRPCConfig
+	if cfg.GRPC != nil {
+		grpcCfg = *cfg.GRPC
+	}
+	if grpcCfg.MaxConcurrentStreams == 0 {
+		grpcCfg.MaxConcurrentStreams = defaultMaxConcurrentStreams
+	}
+	if grpcCfg.MaxRecvMsgSize <= 0 {
+		grpcCfg.MaxRecvMsgSize = defaultMaxRecvMsgSize
+	}
+
+	s := &Server{
+		cfg:         grpcCfg,
+		authService: authService,
+		logger:      logger,
+	}
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.unaryRecoveryInterceptor, s.unaryAuthInterceptor),
+		grpc.ChainStreamInterceptor(s.streamRecoveryInterceptor, s.streamAuthInterceptor),
+		grpc.MaxConcurrentStreams(grpcCfg.MaxConcurrentStreams),
+		grpc.MaxRecvMsgSize(grpcCfg.MaxRecvMsgSize),
+	)
+	blacklistv1.RegisterBlacklistServiceServer(s.server, &blacklistServer{
+		blacklistService: blacklistService,
+		listService:      listService,
+		authService:      authService,
+		webhookService:   webhookService,
+		logger:           logger,
+	})
+	return s
+}
+
+// Enabled 是否启用gRPC服务
+func (s *Server) Enabled() bool {
+	return s.cfg.Enabled
+}
+
+// Addr 监听地址
+func (s *Server) Addr() string {
+	return fmt.Sprintf("%s:%d", s.cfg.Host, s.cfg.Port)
+}
+
+// Serve 在指定监听器上处理请求，直到Stop被调用
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Stop 停止接收新连接并等待进行中的调用和流结束，ctx到期时强制关闭
+func (s *Server) Stop(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
+
+// unaryRecoveryInterceptor 恢复单次调用中的panic，返回Internal错误
+func (s *Server) unaryRecoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.logPanic(ctx, info.FullMethod, recovered)
+			err = status.Error(codes.Internal, "服务内部错误")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// streamRecoveryInterceptor 恢复流式调用中的panic，返回Internal错误
+func (s *Server) streamRecoveryInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.logPanic(ss.Context(), info.FullMethod, recovered)
+			err = status.Error(codes.Internal, "服务内部错误")
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// logPanic 记录panic信息
+func (s *Server) logPanic(ctx context.Context, method string, recovered interface{}) {
+	s.logger.ErrorWithTrace(ctx, "Panic recovered",
+		zap.Any("panic", recovered),
+		zap.String("method", method),
+		zap.String("stack", string(debug.Stack())),
+	)
+}
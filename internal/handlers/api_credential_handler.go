@@ -72,12 +72,15 @@ func (h *ApiCredentialHandler) CreateApiCredential(c *gin.Context) {
 
 	// 转换为模型
 	credential := &models.BlacklistApiCredential{
-		TenantModel: models.TenantModel{TenantID: tenantIDUint64},
-		Name:        req.Name,
-		Description: req.Description,
-		RateLimit:   req.RateLimit,
-		IPWhitelist: req.IPWhitelist,
-		ExpiresAt:   req.ExpiresAt,
+		TenantModel:     models.TenantModel{TenantID: tenantIDUint64},
+		Name:            req.Name,
+		Description:     req.Description,
+		RateLimit:       req.RateLimit,
+		IPWhitelist:     req.IPWhitelist,
+		ExpiresAt:       req.ExpiresAt,
+		MinRiskScore:    req.MinRiskScore,
+		LogSampleRate:   dto.ResolveLogSampleRate(req.LogSampleRate),
+		RangeQueryLimit: req.RangeQueryLimit,
 	}
 
 	// 创建API密钥
@@ -238,11 +241,14 @@ func (h *ApiCredentialHandler) UpdateApiCredential(c *gin.Context) {
 		TenantModel: models.TenantModel{
 			ID: id,
 		},
-		Name:        req.Name,
-		Description: req.Description,
-		RateLimit:   req.RateLimit,
-		IPWhitelist: req.IPWhitelist,
-		ExpiresAt:   req.ExpiresAt,
+		Name:            req.Name,
+		Description:     req.Description,
+		RateLimit:       req.RateLimit,
+		IPWhitelist:     req.IPWhitelist,
+		ExpiresAt:       req.ExpiresAt,
+		MinRiskScore:    req.MinRiskScore,
+		LogSampleRate:   dto.ResolveLogSampleRate(req.LogSampleRate),
+		RangeQueryLimit: req.RangeQueryLimit,
 	}
 
 	// 更新API密钥
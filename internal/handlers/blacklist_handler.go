@@ -4,41 +4,63 @@ package handlers
 
 import (
 	"context"
+	"io"
+	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/varluffy/shield/internal/dto"
 	"github.com/varluffy/shield/internal/middleware"
+	"github.com/varluffy/shield/internal/models"
 	"github.com/varluffy/shield/internal/services"
 	"github.com/varluffy/shield/pkg/errors"
 	"github.com/varluffy/shield/pkg/logger"
 	"github.com/varluffy/shield/pkg/response"
+	"github.com/varluffy/shield/pkg/sheet"
 	"go.uber.org/zap"
 )
 
 // BlacklistHandler 黑名单处理器
 type BlacklistHandler struct {
-	blacklistService services.BlacklistService
-	logger           *logger.Logger
-	responseWriter   *response.ResponseWriter
+	blacklistService  services.BlacklistService
+	listService       services.ListService
+	sharedListService services.SharedListService
+	allowlistService  services.AllowlistService
+	approvalService   services.ApprovalService
+	auditService      services.PermissionAuditService
+	webhookService    services.WebhookService
+	logger            *logger.Logger
+	responseWriter    *response.ResponseWriter
 }
 
 // NewBlacklistHandler 创建黑名单处理器
 func NewBlacklistHandler(
 	blacklistService services.BlacklistService,
+	listService services.ListService,
+	sharedListService services.SharedListService,
+	allowlistService services.AllowlistService,
+	approvalService services.ApprovalService,
+	auditService services.PermissionAuditService,
+	webhookService services.WebhookService,
 	logger *logger.Logger,
 ) *BlacklistHandler {
 	return &BlacklistHandler{
-		blacklistService: blacklistService,
-		logger:           logger,
-		responseWriter:   response.NewResponseWriter(logger),
+		blacklistService:  blacklistService,
+		listService:       listService,
+		sharedListService: sharedListService,
+		allowlistService:  allowlistService,
+		approvalService:   approvalService,
+		auditService:      auditService,
+		webhookService:    webhookService,
+		logger:            logger,
+		responseWriter:    response.NewResponseWriter(logger),
 	}
 }
 
 // CheckBlacklist 检查手机号MD5是否在黑名单中
 // @Summary 检查黑名单
-// @Description 检查手机号MD5是否在黑名单中
+// @Description 检查标识哈希是否在黑名单中，仅传phone_md5时按手机号MD5查询，其他类型通过identifier_type指定，SHA-256和HMAC-SHA256格式通过hash_type和identifier_hash指定；命中时返回风险分类和风险分，低于API密钥风险分阈值的命中视为未命中；lists指定查询的名单，为空时查询API密钥已授权的全部名单，命中时通过hit_lists返回命中的名单；租户订阅共享名单时同时查询共享名单，命中时通过hit_source返回命中来源tenant/shared/both
 // @Tags 黑名单查询
 // @Accept json
 // @Produce json
@@ -52,6 +74,7 @@ func NewBlacklistHandler(
 // @Failure 401 {object} response.Response
 // @Failure 429 {object} response.Response
 // @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response "Redis不可用且名单条目过多，HMAC-SHA256格式暂时无法查询"
 // @Router /blacklist/check [post]
 func (h *BlacklistHandler) CheckBlacklist(c *gin.Context) {
 	start := time.Now()
@@ -80,23 +103,57 @@ func (h *BlacklistHandler) CheckBlacklist(c *gin.Context) {
 		return
 	}
 
+	identifierType, hashType, hash, ok := req.Resolve()
+	if !ok {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("缺少查询标识或格式错误"))
+		return
+	}
+
 	// 设置上下文信息供日志中间件使用
-	c.Set("phone_md5", req.PhoneMD5)
+	c.Set("phone_md5", hash)
+	c.Set("identifier_type", identifierType)
+	c.Set("hash_type", hashType)
+
+	// 按API密钥的名单授权确定查询范围
+	lists, err := h.listService.ResolveCheckLists(ctx, tenantIDUint64, requestCredential(c), req.Lists)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "解析查询名单失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Strings("lists", req.Lists),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
 
 	// 检查黑名单
-	isBlacklist, err := h.blacklistService.CheckPhoneMD5(ctx, tenantIDUint64, req.PhoneMD5)
+	result, err := h.blacklistService.CheckIdentifier(ctx, tenantIDUint64, identifierType, hashType, hash, lists)
 	if err != nil {
 		h.logger.ErrorWithTrace(ctx, "黑名单查询失败",
 			zap.Uint64("tenant_id", tenantIDUint64),
-			zap.String("phone_md5", req.PhoneMD5),
+			zap.String("identifier_type", identifierType),
+			zap.String("hash_type", hashType),
+			zap.String("hash", hash),
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrInternalError("查询失败"))
+		if _, ok := err.(*errors.BusinessError); !ok {
+			err = errors.ErrInternalError("查询失败")
+		}
+		h.responseWriter.Error(c, err)
 		return
 	}
 
+	// 低于API密钥风险分阈值的命中视为未命中
+	minRiskScore := credentialMinRiskScore(c)
+	isBlacklist := result.Hit && result.RiskScore >= minRiskScore
+
 	// 设置结果供日志中间件使用
 	c.Set("blacklist_result", isBlacklist)
 
+	if isBlacklist {
+		h.publishHits(c, tenantIDUint64, identifierType, hashType, []services.WebhookHitItem{
+			{IdentifierHash: hash, Category: result.Category, RiskScore: result.RiskScore},
+		})
+	}
+
 	// 计算响应时间
 	latencyMs := time.Since(start).Milliseconds()
 
@@ -106,14 +163,17 @@ func (h *BlacklistHandler) CheckBlacklist(c *gin.Context) {
 		h.blacklistService.UpdateQueryMetrics(context.Background(), tenantIDUint64, apiKey, isBlacklist, latencyMs)
 	}()
 
-	resp := dto.CheckBlacklistResponse{
-		IsBlacklist: isBlacklist,
-		PhoneMD5:    req.PhoneMD5,
-	}
+	resp := dto.NewCheckBlacklistResponse(identifierType, hashType, hash, isBlacklist).
+		WithRisk(result.Category, result.RiskScore).
+		WithOverridden(result.OverriddenAbove(minRiskScore)).
+		WithHitLists(result.HitLists(minRiskScore)).
+		WithHitSource(result.HitSource(minRiskScore))
 
 	h.logger.DebugWithTrace(ctx, "黑名单查询成功",
 		zap.Uint64("tenant_id", tenantIDUint64),
-		zap.String("phone_md5", req.PhoneMD5),
+		zap.String("identifier_type", identifierType),
+		zap.String("hash_type", hashType),
+		zap.String("hash", hash),
 		zap.Bool("is_blacklist", isBlacklist),
 		zap.Duration("duration", time.Since(start)))
 
@@ -122,7 +182,7 @@ func (h *BlacklistHandler) CheckBlacklist(c *gin.Context) {
 
 // CheckBlacklistBatch 批量检查手机号MD5是否在黑名单中
 // @Summary 批量检查黑名单
-// @Description 批量检查手机号MD5是否在黑名单中
+// @Description 批量检查标识哈希是否在黑名单中，仅传phone_md5_list时按手机号MD5查询，其他类型通过identifier_type指定，SHA-256和HMAC-SHA256格式通过hash_type和identifier_hash_list指定；命中时返回风险分类和风险分，低于API密钥风险分阈值的命中视为未命中；lists指定查询的名单，为空时查询API密钥已授权的全部名单，命中时通过hit_lists返回命中的名单；租户订阅共享名单时同时查询共享名单，命中时通过hit_source返回命中来源tenant/shared/both
 // @Tags 黑名单查询
 // @Accept json
 // @Produce json
@@ -136,6 +196,7 @@ func (h *BlacklistHandler) CheckBlacklist(c *gin.Context) {
 // @Failure 401 {object} response.Response
 // @Failure 429 {object} response.Response
 // @Failure 500 {object} response.Response
+// @Failure 503 {object} response.Response "Redis不可用且名单条目过多，HMAC-SHA256格式暂时无法查询"
 // @Router /blacklist/check-batch [post]
 func (h *BlacklistHandler) CheckBlacklistBatch(c *gin.Context) {
 	start := time.Now()
@@ -149,14 +210,11 @@ func (h *BlacklistHandler) CheckBlacklistBatch(c *gin.Context) {
 		return
 	}
 
-	// 验证MD5格式
-	for _, phoneMD5 := range req.PhoneMD5List {
-		if len(phoneMD5) != 32 {
-			h.logger.WarnWithTrace(ctx, "MD5格式错误",
-				zap.String("phone_md5", phoneMD5))
-			h.responseWriter.Error(c, errors.ErrValidationFailed("MD5格式错误"))
-			return
-		}
+	// 解析并验证哈希格式
+	identifierType, hashType, hashList, ok := req.Resolve()
+	if !ok {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("缺少查询标识或哈希格式错误"))
+		return
 	}
 
 	// 从上下文获取租户ID
@@ -174,29 +232,67 @@ func (h *BlacklistHandler) CheckBlacklistBatch(c *gin.Context) {
 		return
 	}
 
+	// 设置上下文信息供日志中间件使用，查询失败时按query_hashes记录
+	c.Set("identifier_type", identifierType)
+	c.Set("hash_type", hashType)
+	c.Set("query_hashes", hashList)
+
+	// 按API密钥的名单授权确定查询范围
+	lists, err := h.listService.ResolveCheckLists(ctx, tenantIDUint64, requestCredential(c), req.Lists)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "解析查询名单失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Strings("lists", req.Lists),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
 	// 批量检查黑名单
-	results, err := h.blacklistService.CheckPhoneMD5Batch(ctx, tenantIDUint64, req.PhoneMD5List)
+	results, err := h.blacklistService.CheckIdentifierBatch(ctx, tenantIDUint64, identifierType, hashType, hashList, lists)
 	if err != nil {
 		h.logger.ErrorWithTrace(ctx, "批量黑名单查询失败",
 			zap.Uint64("tenant_id", tenantIDUint64),
-			zap.Int("batch_size", len(req.PhoneMD5List)),
+			zap.String("identifier_type", identifierType),
+			zap.String("hash_type", hashType),
+			zap.Int("batch_size", len(hashList)),
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrInternalError("查询失败"))
+		if _, ok := err.(*errors.BusinessError); !ok {
+			err = errors.ErrInternalError("查询失败")
+		}
+		h.responseWriter.Error(c, err)
 		return
 	}
 
-	// 构建响应
-	responseList := make([]dto.CheckBlacklistResponse, 0, len(req.PhoneMD5List))
-	hitCount := 0
-	for _, phoneMD5 := range req.PhoneMD5List {
-		isBlacklist := results[phoneMD5]
+	// 构建响应，低于API密钥风险分阈值的命中视为未命中
+	minRiskScore := credentialMinRiskScore(c)
+	responseList := make([]dto.CheckBlacklistResponse, 0, len(hashList))
+	hits := make(map[string]bool, len(hashList))
+	var hitItems []services.WebhookHitItem
+	for _, hash := range hashList {
+		result := results[hash]
+		isBlacklist := result.Hit && result.RiskScore >= minRiskScore
 		if isBlacklist {
-			hitCount++
+			hitItems = append(hitItems, services.WebhookHitItem{
+				IdentifierHash: hash,
+				Category:       result.Category,
+				RiskScore:      result.RiskScore,
+			})
 		}
-		responseList = append(responseList, dto.CheckBlacklistResponse{
-			IsBlacklist: isBlacklist,
-			PhoneMD5:    phoneMD5,
-		})
+		hits[hash] = isBlacklist
+		responseList = append(responseList, dto.NewCheckBlacklistResponse(identifierType, hashType, hash, isBlacklist).
+			WithRisk(result.Category, result.RiskScore).
+			WithOverridden(result.OverriddenAbove(minRiskScore)).
+			WithHitLists(result.HitLists(minRiskScore)).
+			WithHitSource(result.HitSource(minRiskScore)))
+	}
+
+	// 设置结果供日志中间件使用
+	c.Set("blacklist_results", hits)
+
+	hitCount := len(hitItems)
+	if hitCount > 0 {
+		h.publishHits(c, tenantIDUint64, identifierType, hashType, hitItems)
 	}
 
 	resp := dto.CheckBlacklistBatchResponse{
@@ -210,32 +306,142 @@ func (h *BlacklistHandler) CheckBlacklistBatch(c *gin.Context) {
 	go func() {
 		apiKey := c.GetString("api_key")
 		// 对于批量查询，我们记录平均的命中情况
-		avgHitRate := float64(hitCount) / float64(len(req.PhoneMD5List))
+		avgHitRate := float64(hitCount) / float64(len(hashList))
 		isHit := avgHitRate > 0.5 // 如果超过一半命中，认为是命中
 		h.blacklistService.UpdateQueryMetrics(context.Background(), tenantIDUint64, apiKey, isHit, latencyMs)
 	}()
 
 	h.logger.DebugWithTrace(ctx, "批量黑名单查询成功",
 		zap.Uint64("tenant_id", tenantIDUint64),
-		zap.Int("total_count", len(req.PhoneMD5List)),
+		zap.Int("total_count", len(hashList)),
 		zap.Int("hit_count", hitCount),
 		zap.Duration("duration", time.Since(start)))
 
 	h.responseWriter.Success(c, resp)
 }
 
+// RangeQuery 按哈希前缀查询黑名单
+// @Summary 前缀范围查询黑名单
+// @Description k-匿名查询模式：只提交哈希的前5-10位十六进制字符，返回名单中相同前缀的命中条目后缀，由调用方在本地比对完整哈希，服务端不接触完整哈希；需API密钥开启前缀查询配额(range_query_limit)，未开启或当日查询的不同前缀数超过配额时返回403；单个前缀匹配的条目过多时返回400，需使用更长的前缀；低于API密钥风险分阈值的条目不返回，白名单豁免的条目不返回；lists和共享名单的查询范围与单条查询一致
+// @Tags 黑名单查询
+// @Accept json
+// @Produce json
+// @Param X-API-Key header string true "API密钥"
+// @Param X-Timestamp header string true "时间戳"
+// @Param X-Nonce header string true "随机数"
+// @Param X-Signature header string true "HMAC签名"
+// @Param request body dto.RangeQueryRequest true "前缀查询请求"
+// @Success 200 {object} response.Response{data=dto.RangeQueryResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /blacklist/range [post]
+func (h *BlacklistHandler) RangeQuery(c *gin.Context) {
+	start := time.Now()
+	ctx := c.Request.Context()
+
+	var req dto.RangeQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	identifierType, hashType, prefix, ok := req.Resolve()
+	if !ok {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("标识类型或前缀格式错误"))
+		return
+	}
+
+	credential := requestCredential(c)
+	if credential == nil {
+		h.logger.ErrorWithTrace(ctx, "API密钥信息未找到")
+		h.responseWriter.Error(c, errors.ErrInternalError("API密钥信息丢失"))
+		return
+	}
+	tenantID := credential.TenantID
+
+	// 设置上下文信息供日志中间件使用，不记录前缀
+	c.Set("identifier_type", identifierType)
+	c.Set("hash_type", hashType)
+
+	// 按API密钥的名单授权确定查询范围
+	lists, err := h.listService.ResolveCheckLists(ctx, tenantID, credential, req.Lists)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "解析查询名单失败",
+			zap.Uint64("tenant_id", tenantID),
+			zap.Strings("lists", req.Lists),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	matches, err := h.blacklistService.RangeQuery(ctx, credential, identifierType, hashType, prefix, lists)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "前缀范围查询失败",
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("identifier_type", identifierType),
+			zap.String("hash_type", hashType),
+			zap.Int("prefix_length", len(prefix)),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	// 低于API密钥风险分阈值的条目不返回
+	minRiskScore := credentialMinRiskScore(c)
+	suffixes := make([]dto.RangeSuffixItem, 0, len(matches))
+	for _, match := range matches {
+		if match.Result.RiskScore < minRiskScore {
+			continue
+		}
+		suffixes = append(suffixes, dto.RangeSuffixItem{
+			Suffix:    match.Hash[len(prefix):],
+			Category:  match.Result.Category,
+			RiskScore: match.Result.RiskScore,
+			HitLists:  match.Result.HitLists(minRiskScore),
+			HitSource: match.Result.HitSource(minRiskScore),
+		})
+	}
+
+	// 更新查询统计（异步执行，不影响响应），是否命中由调用方在本地判断，按未命中统计
+	latencyMs := time.Since(start).Milliseconds()
+	go func() {
+		apiKey := c.GetString("api_key")
+		h.blacklistService.UpdateQueryMetrics(context.Background(), tenantID, apiKey, false, latencyMs)
+	}()
+
+	h.logger.DebugWithTrace(ctx, "前缀范围查询成功",
+		zap.Uint64("tenant_id", tenantID),
+		zap.Int("prefix_length", len(prefix)),
+		zap.Int("suffix_count", len(suffixes)),
+		zap.Duration("duration", time.Since(start)))
+
+	h.responseWriter.Success(c, dto.RangeQueryResponse{
+		IdentifierType: identifierType,
+		HashType:       hashType,
+		Prefix:         prefix,
+		Suffixes:       suffixes,
+	})
+}
+
 // CreateBlacklist 创建黑名单记录
 // @Summary 创建黑名单
-// @Description 创建新的黑名单记录
+// @Description 创建新的黑名单记录；租户启用审批时不直接创建，返回待审批的变更申请(dto.BlacklistChangeRequestInfo)
 // @Tags 黑名单管理
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body dto.CreateBlacklistRequest true "创建请求"
 // @Success 201 {object} response.Response{data=dto.BlacklistInfo}
+// @Success 200 {object} response.Response{data=dto.BlacklistChangeRequestInfo} "租户启用审批时返回变更申请"
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response "标识已存在于名单中"
 // @Failure 500 {object} response.Response
 // @Router /admin/blacklist [post]
 func (h *BlacklistHandler) CreateBlacklist(c *gin.Context) {
@@ -260,17 +466,43 @@ func (h *BlacklistHandler) CreateBlacklist(c *gin.Context) {
 	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
 	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
 
+	if _, _, ok := req.Resolve(); !ok {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("缺少黑名单标识或格式错误"))
+		return
+	}
+
+	listID, err := h.listService.ResolveListID(ctx, tenantIDUint64, req.List)
+	if err != nil {
+		h.responseWriter.Error(c, err)
+		return
+	}
+
 	// 转换为模型
 	blacklist := req.ToModel(tenantIDUint64, operatorIDUint64)
+	blacklist.ListID = listID
+	if blacklist.IsExpired(time.Now()) {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("过期时间必须晚于当前时间"))
+		return
+	}
 
-	// 创建黑名单记录
-	err := h.blacklistService.CreateBlacklist(ctx, blacklist)
+	// 创建黑名单记录，租户启用审批时仅提交申请
+	changeRequest, err := h.approvalService.RequestCreateBlacklist(ctx, blacklist)
 	if err != nil {
 		h.logger.ErrorWithTrace(ctx, "创建黑名单记录失败",
 			zap.Uint64("tenant_id", tenantIDUint64),
-			zap.String("phone_md5", req.PhoneMD5),
+			zap.String("identifier_type", blacklist.IdentifierType),
+			zap.String("md5", blacklist.PhoneMD5),
+			zap.String("sha256", blacklist.IdentifierSHA256),
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrInternalError("创建失败"))
+		if _, ok := err.(*errors.BusinessError); !ok {
+			err = errors.ErrInternalError("创建失败")
+		}
+		h.responseWriter.Error(c, err)
+		return
+	}
+	if changeRequest != nil {
+		h.logChangeRequestAudit(c, tenantIDUint64, operatorIDUint64, models.AuditActionSubmit, changeRequest)
+		h.responseWriter.Success(c, dto.NewBlacklistChangeRequestInfo(changeRequest))
 		return
 	}
 
@@ -278,7 +510,8 @@ func (h *BlacklistHandler) CreateBlacklist(c *gin.Context) {
 
 	h.logger.InfoWithTrace(ctx, "创建黑名单记录成功",
 		zap.Uint64("tenant_id", tenantIDUint64),
-		zap.String("phone_md5", req.PhoneMD5),
+		zap.String("identifier_type", blacklist.IdentifierType),
+		zap.String("md5", blacklist.PhoneMD5),
 		zap.Uint64("operator_id", operatorIDUint64))
 
 	h.responseWriter.Success(c, resp)
@@ -286,7 +519,7 @@ func (h *BlacklistHandler) CreateBlacklist(c *gin.Context) {
 
 // BatchImportBlacklist 批量导入黑名单
 // @Summary 批量导入黑名单
-// @Description 批量导入黑名单记录
+// @Description 批量导入黑名单记录；租户启用审批时返回403，需逐条提交新增申请
 // @Tags 黑名单管理
 // @Accept json
 // @Produce json
@@ -320,175 +553,341 @@ func (h *BlacklistHandler) BatchImportBlacklist(c *gin.Context) {
 	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
 	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
 
+	identifierType, hashItems, ok := req.Resolve()
+	if !ok {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("导入列表为空、超过10000条或哈希格式错误"))
+		return
+	}
+	items := make([]services.IdentifierHashes, 0, len(hashItems))
+	for _, item := range hashItems {
+		items = append(items, services.IdentifierHashes{MD5: item.MD5, SHA256: item.SHA256})
+	}
+
+	expiresAt := req.ResolveExpiresAt(time.Now())
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("过期时间必须晚于当前时间"))
+		return
+	}
+
+	listID, err := h.listService.ResolveListID(ctx, tenantIDUint64, req.List)
+	if err != nil {
+		h.responseWriter.Error(c, err)
+		return
+	}
+
 	// 批量导入
-	err := h.blacklistService.BatchImportBlacklist(
-		ctx,
-		tenantIDUint64,
-		req.PhoneMD5List,
-		req.Source,
-		req.Reason,
-		operatorIDUint64,
-	)
+	result, err := h.blacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+		TenantID:       tenantIDUint64,
+		ListID:         listID,
+		IdentifierType: identifierType,
+		Items:          items,
+		Source:         req.Source,
+		Reason:         req.Reason,
+		Category:       req.Category,
+		RiskScore:      req.RiskScore,
+		OperatorID:     operatorIDUint64,
+		ExpiresAt:      expiresAt,
+	})
 	if err != nil {
 		h.logger.ErrorWithTrace(ctx, "批量导入黑名单失败",
 			zap.Uint64("tenant_id", tenantIDUint64),
-			zap.Int("count", len(req.PhoneMD5List)),
+			zap.Int("count", len(items)),
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrInternalError("导入失败"))
+		if _, ok := err.(*errors.BusinessError); !ok {
+			err = errors.ErrInternalError("导入失败")
+		}
+		h.responseWriter.Error(c, err)
 		return
 	}
 
 	resp := dto.BatchImportResponse{
-		SuccessCount: len(req.PhoneMD5List),
-		FailedCount:  0,
-		FailedItems:  []string{},
+		BatchID:         result.BatchID,
+		SuccessCount:    result.Created + result.Backfilled,
+		BackfilledCount: result.Backfilled,
+		DuplicateCount:  result.Duplicate,
+		FailedCount:     0,
+		FailedItems:     []string{},
 	}
 
 	h.logger.InfoWithTrace(ctx, "批量导入黑名单成功",
 		zap.Uint64("tenant_id", tenantIDUint64),
-		zap.Int("count", len(req.PhoneMD5List)),
+		zap.String("identifier_type", identifierType),
+		zap.Int("created", result.Created),
+		zap.Int("backfilled", result.Backfilled),
+		zap.Int("duplicate", result.Duplicate),
 		zap.Uint64("operator_id", operatorIDUint64))
 
 	h.responseWriter.Success(c, resp)
 }
 
-// GetBlacklistList 获取黑名单列表
-// @Summary 获取黑名单列表
-// @Description 分页获取黑名单列表
+// maxImportFileSize 导入文件大小上限
+const maxImportFileSize = 20 << 20
+
+// ImportBlacklistFile 文件导入黑名单
+// @Summary 文件导入黑名单
+// @Description 上传CSV或XLSX格式的明文手机号文件，逐行读取并规范化（去除+86、空格和横线）后在服务端计算哈希导入，返回每行的导入结果（accepted新增、duplicate重复、invalid无效）；租户启用审批时返回403
 // @Tags 黑名单管理
-// @Accept json
+// @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
-// @Param page query int false "页码" default(1)
-// @Param page_size query int false "每页数量" default(20)
-// @Success 200 {object} response.Response{data=dto.GetBlacklistResponse}
+// @Param file formData file true "CSV或XLSX文件，最大20MB，XLSX仅读取第一个工作表"
+// @Param column formData int false "手机号所在列，从1开始" default(1)
+// @Param column_name formData string false "按表头名称定位手机号列，优先于column"
+// @Param source formData string false "来源" default(file_import)
+// @Param reason formData string false "原因"
+// @Param category formData string false "风险分类" Enums(fraud, complaint, collection_harassment, other)
+// @Param risk_score formData int false "风险分1-100，默认100"
+// @Param expires_at formData string false "过期时间（RFC3339）"
+// @Param expire_days formData int false "过期天数"
+// @Success 200 {object} response.Response{data=dto.ImportBlacklistFileResponse}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 403 {object} response.Response
 // @Failure 500 {object} response.Response
-// @Router /admin/blacklist [get]
-func (h *BlacklistHandler) GetBlacklistList(c *gin.Context) {
+// @Router /admin/blacklist/import/file [post]
+func (h *BlacklistHandler) ImportBlacklistFile(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	var req dto.GetBlacklistRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
+	var req dto.ImportBlacklistFileRequest
+	if err := c.ShouldBind(&req); err != nil {
 		h.logger.WarnWithTrace(ctx, "参数绑定失败",
 			zap.Error(err))
 		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
 		return
 	}
 
-	// 获取租户ID
-	tenantID, exists := middleware.GetCurrentTenantID(c)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("缺少导入文件"))
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("导入文件不能超过20MB"))
+		return
+	}
+
+	expiresAt := req.ResolveExpiresAt(time.Now())
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("过期时间必须晚于当前时间"))
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
 		h.responseWriter.Error(c, errors.ErrUnauthorized())
 		return
 	}
 
 	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
 
-	// 获取黑名单列表
-	blacklists, total, err := h.blacklistService.GetBlacklistByTenant(
-		ctx,
-		tenantIDUint64,
-		req.Page,
-		req.PageSize,
-	)
+	file, err := fileHeader.Open()
 	if err != nil {
-		h.logger.ErrorWithTrace(ctx, "获取黑名单列表失败",
-			zap.Uint64("tenant_id", tenantIDUint64),
+		h.logger.ErrorWithTrace(ctx, "打开导入文件失败",
+			zap.String("filename", fileHeader.Filename),
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrInternalError("获取列表失败"))
+		h.responseWriter.Error(c, errors.ErrInternalError("读取导入文件失败"))
 		return
 	}
+	defer file.Close()
 
-	// 构建响应
-	items := make([]dto.BlacklistInfo, len(blacklists))
-	for i, blacklist := range blacklists {
-		items[i] = dto.NewBlacklistInfo(blacklist)
+	rows, err := sheet.NewReader(file, fileHeader.Size, fileHeader.Filename)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "导入文件格式错误",
+			zap.String("filename", fileHeader.Filename),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("仅支持CSV和XLSX文件"))
+		return
 	}
 
-	totalPages := (total + int64(req.PageSize) - 1) / int64(req.PageSize)
+	result, err := h.blacklistService.ImportBlacklistFile(ctx, &services.FileImportParams{
+		TenantID:   tenantIDUint64,
+		Rows:       rows,
+		FileName:   fileHeader.Filename,
+		Column:     req.Column - 1,
+		ColumnName: req.ColumnName,
+		Source:     req.Source,
+		Reason:     req.Reason,
+		Category:   req.Category,
+		RiskScore:  req.RiskScore,
+		OperatorID: operatorIDUint64,
+		ExpiresAt:  expiresAt,
+	})
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "文件导入黑名单失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("filename", fileHeader.Filename),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
 
-	resp := dto.GetBlacklistResponse{
-		Items: items,
-		Pagination: dto.PaginationInfo{
-			Page:       req.Page,
-			PageSize:   req.PageSize,
-			Total:      total,
-			TotalPages: totalPages,
-		},
+	resp := dto.ImportBlacklistFileResponse{
+		BatchID:         result.BatchID,
+		AcceptedCount:   result.Accepted,
+		DuplicateCount:  result.Duplicate,
+		InvalidCount:    result.Invalid,
+		BackfilledCount: result.Backfilled,
+		Truncated:       result.Truncated,
+		Rows:            make([]dto.ImportFileRowReport, 0, len(result.Rows)),
+	}
+	for _, row := range result.Rows {
+		resp.Rows = append(resp.Rows, dto.ImportFileRowReport{
+			Row:    row.Row,
+			Value:  row.Value,
+			Status: row.Status,
+			Reason: row.Reason,
+		})
 	}
 
+	h.logger.InfoWithTrace(ctx, "文件导入黑名单成功",
+		zap.Uint64("tenant_id", tenantIDUint64),
+		zap.String("filename", fileHeader.Filename),
+		zap.Int("accepted", result.Accepted),
+		zap.Int("duplicate", result.Duplicate),
+		zap.Int("invalid", result.Invalid),
+		zap.Uint64("operator_id", operatorIDUint64))
+
 	h.responseWriter.Success(c, resp)
 }
 
-// DeleteBlacklist 删除黑名单记录
-// @Summary 删除黑名单
-// @Description 删除指定的黑名单记录
+// maxImportJobFileSize 异步导入任务文件大小上限
+const maxImportJobFileSize = 500 << 20
+
+// SubmitImportJob 提交异步导入任务
+// @Summary 提交异步导入任务
+// @Description 上传CSV或XLSX文件创建异步导入任务，立即返回任务ID；后台按批次写入数据库和Redis，可查询进度或取消。value_type为phone时内容为明文手机号，为md5/sha256时为对应格式的哈希。任务在服务重启后从最后提交的批次继续，不会重复写入；租户启用审批时返回403，提交后启用审批的任务不再继续执行
 // @Tags 黑名单管理
-// @Accept json
+// @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
-// @Param id path int true "黑名单ID"
-// @Success 200 {object} response.Response
+// @Param file formData file true "CSV或XLSX文件，最大500MB，XLSX仅读取第一个工作表"
+// @Param value_type formData string false "文件内容类型" Enums(phone, md5, sha256) default(phone)
+// @Param identifier_type formData string false "标识类型，value_type为哈希时有效" default(phone)
+// @Param column formData int false "数据所在列，从1开始" default(1)
+// @Param column_name formData string false "按表头名称定位数据列，优先于column"
+// @Param source formData string false "来源" default(file_import)
+// @Param reason formData string false "原因"
+// @Param category formData string false "风险分类" Enums(fraud, complaint, collection_harassment, other)
+// @Param risk_score formData int false "风险分1-100，默认100"
+// @Param expires_at formData string false "过期时间（RFC3339）"
+// @Param expire_days formData int false "过期天数"
+// @Success 200 {object} response.Response{data=dto.ImportJobInfo}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 403 {object} response.Response
-// @Failure 404 {object} response.Response
 // @Failure 500 {object} response.Response
-// @Router /admin/blacklist/{id} [delete]
-func (h *BlacklistHandler) DeleteBlacklist(c *gin.Context) {
+// @Router /admin/blacklist/import-jobs [post]
+func (h *BlacklistHandler) SubmitImportJob(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	idStr := c.Param("id")
-	id, err := strconv.ParseUint(idStr, 10, 64)
-	if err != nil {
-		h.logger.WarnWithTrace(ctx, "ID参数格式错误",
-			zap.String("id", idStr),
+	var req dto.SubmitImportJobRequest
+	if err := c.ShouldBind(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrInvalidRequest())
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
 		return
 	}
 
-	// 删除黑名单记录
-	err = h.blacklistService.DeleteBlacklist(ctx, id)
+	fileHeader, err := c.FormFile("file")
 	if err != nil {
-		h.logger.ErrorWithTrace(ctx, "删除黑名单记录失败",
-			zap.Uint64("id", id),
+		h.responseWriter.Error(c, errors.ErrValidationFailed("缺少导入文件"))
+		return
+	}
+	if fileHeader.Size > maxImportJobFileSize {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("导入文件不能超过500MB"))
+		return
+	}
+
+	expiresAt := req.ResolveExpiresAt(time.Now())
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("过期时间必须晚于当前时间"))
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "打开导入文件失败",
+			zap.String("filename", fileHeader.Filename),
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrInternalError("删除失败"))
+		h.responseWriter.Error(c, errors.ErrInternalError("读取导入文件失败"))
 		return
 	}
+	defer file.Close()
 
-	h.logger.InfoWithTrace(ctx, "删除黑名单记录成功",
-		zap.Uint64("id", id))
+	// 提交前校验文件格式，避免创建必然失败的任务
+	if _, err := sheet.NewReader(file, fileHeader.Size, fileHeader.Filename); err != nil {
+		h.logger.WarnWithTrace(ctx, "导入文件格式错误",
+			zap.String("filename", fileHeader.Filename),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("仅支持CSV和XLSX文件"))
+		return
+	}
 
-	h.responseWriter.Success(c, nil)
+	job, err := h.blacklistService.SubmitImportJob(ctx, &services.ImportJobParams{
+		TenantID:       tenantIDUint64,
+		FileName:       fileHeader.Filename,
+		FileSize:       fileHeader.Size,
+		ValueType:      req.ValueType,
+		IdentifierType: req.IdentifierType,
+		Column:         req.Column - 1,
+		ColumnName:     req.ColumnName,
+		Source:         req.Source,
+		Reason:         req.Reason,
+		Category:       req.Category,
+		RiskScore:      req.RiskScore,
+		OperatorID:     operatorIDUint64,
+		ExpiresAt:      expiresAt,
+	}, io.NewSectionReader(file, 0, fileHeader.Size))
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "提交导入任务失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("filename", fileHeader.Filename),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewImportJobInfo(job))
 }
 
-// GetQueryStats 获取查询统计
-// @Summary 获取查询统计
-// @Description 获取黑名单查询统计信息
+// ListImportJobs 获取导入任务列表
+// @Summary 获取导入任务列表
+// @Description 分页获取当前租户的异步导入任务，按提交时间倒序
 // @Tags 黑名单管理
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param hours query int false "统计小时数" default(24)
-// @Success 200 {object} response.Response{data=dto.QueryStatsResponse}
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=dto.ListImportJobsResponse}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 403 {object} response.Response
 // @Failure 500 {object} response.Response
-// @Router /admin/blacklist/stats [get]
-func (h *BlacklistHandler) GetQueryStats(c *gin.Context) {
+// @Router /admin/blacklist/import-jobs [get]
+func (h *BlacklistHandler) ListImportJobs(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	hoursStr := c.DefaultQuery("hours", "24")
-	hours, err := strconv.Atoi(hoursStr)
-	if err != nil || hours <= 0 || hours > 168 { // 最多7天
-		hours = 24
+	var req dto.ListImportJobsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
 	}
 
 	// 获取租户ID
@@ -501,113 +900,2418 @@ func (h *BlacklistHandler) GetQueryStats(c *gin.Context) {
 
 	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
 
-	// 获取统计信息
-	stats, err := h.blacklistService.GetQueryStats(ctx, tenantIDUint64, hours)
+	jobs, total, err := h.blacklistService.ListImportJobs(ctx, tenantIDUint64, req.Page, req.PageSize)
 	if err != nil {
-		h.logger.ErrorWithTrace(ctx, "获取查询统计失败",
+		h.logger.ErrorWithTrace(ctx, "获取导入任务列表失败",
 			zap.Uint64("tenant_id", tenantIDUint64),
-			zap.Int("hours", hours),
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrInternalError("获取统计失败"))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取列表失败"))
+		return
+	}
+
+	items := make([]dto.ImportJobInfo, len(jobs))
+	for i, job := range jobs {
+		items[i] = dto.NewImportJobInfo(job)
+	}
+
+	h.responseWriter.Success(c, dto.ListImportJobsResponse{
+		Items: items,
+		Pagination: dto.PaginationInfo{
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// GetImportJob 获取导入任务详情
+// @Summary 获取导入任务详情
+// @Description 获取导入任务的状态、进度、计数和无效行样例
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "任务ID"
+// @Success 200 {object} response.Response{data=dto.ImportJobInfo}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/import-jobs/{id} [get]
+func (h *BlacklistHandler) GetImportJob(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	job, err := h.blacklistService.GetImportJob(ctx, tenantIDUint64, c.Param("id"))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取导入任务失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("job_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	info := dto.NewImportJobInfo(job)
+	for _, rowError := range services.DecodeImportRowErrors(job.RowErrors) {
+		info.RowErrors = append(info.RowErrors, dto.ImportRowErrorInfo{
+			Row:    rowError.Row,
+			Value:  rowError.Value,
+			Reason: rowError.Reason,
+		})
+	}
+
+	h.responseWriter.Success(c, info)
+}
+
+// CancelImportJob 取消导入任务
+// @Summary 取消导入任务
+// @Description 取消等待中或执行中的导入任务，执行中的任务在当前批次提交后停止，已提交的条目保留
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "任务ID"
+// @Success 200 {object} response.Response{data=dto.ImportJobInfo}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/import-jobs/{id}/cancel [post]
+func (h *BlacklistHandler) CancelImportJob(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	job, err := h.blacklistService.CancelImportJob(ctx, tenantIDUint64, c.Param("id"))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "取消导入任务失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("job_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewImportJobInfo(job))
+}
+
+// ListImportBatches 获取导入批次列表
+// @Summary 获取导入批次列表
+// @Description 分页获取当前租户的导入批次，每次批量导入、文件导入或异步导入任务对应一个批次，按创建时间倒序
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=dto.ListImportBatchesResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/batches [get]
+func (h *BlacklistHandler) ListImportBatches(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.ListImportBatchesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	batches, total, err := h.blacklistService.ListImportBatches(ctx, tenantIDUint64, req.Page, req.PageSize)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取导入批次列表失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取列表失败"))
+		return
+	}
+
+	items := make([]dto.ImportBatchInfo, len(batches))
+	for i, batch := range batches {
+		items[i] = dto.NewImportBatchInfo(batch)
+	}
+
+	h.responseWriter.Success(c, dto.ListImportBatchesResponse{
+		Items: items,
+		Pagination: dto.PaginationInfo{
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// GetImportBatch 获取导入批次详情
+// @Summary 获取导入批次详情
+// @Description 获取导入批次信息，并分页返回批次中仍存在的条目
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "批次ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=dto.ImportBatchDetailResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/batches/{id} [get]
+func (h *BlacklistHandler) GetImportBatch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.GetImportBatchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	batch, err := h.blacklistService.GetImportBatch(ctx, tenantIDUint64, c.Param("id"))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取导入批次失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("batch_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	blacklists, total, err := h.blacklistService.GetImportBatchEntries(ctx, batch, req.Page, req.PageSize)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取导入批次条目失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("batch_id", batch.UUID),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取批次条目失败"))
+		return
+	}
+
+	entries := make([]dto.BlacklistInfo, len(blacklists))
+	for i, blacklist := range blacklists {
+		entries[i] = dto.NewBlacklistInfo(blacklist)
+	}
+
+	h.responseWriter.Success(c, dto.ImportBatchDetailResponse{
+		Batch:   dto.NewImportBatchInfo(batch),
+		Entries: entries,
+		Pagination: dto.PaginationInfo{
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// RollbackImportBatch 回滚导入批次
+// @Summary 回滚导入批次
+// @Description 从数据库和Redis中删除导入批次新增的全部条目，导入尚未结束的批次不能回滚；回滚中断时可重新调用；租户启用审批时返回403
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "批次ID"
+// @Success 200 {object} response.Response{data=dto.ImportBatchInfo}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/batches/{id}/rollback [post]
+func (h *BlacklistHandler) RollbackImportBatch(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	batch, err := h.blacklistService.RollbackImportBatch(ctx, tenantIDUint64, c.Param("id"), operatorIDUint64)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "回滚导入批次失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("batch_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewImportBatchInfo(batch))
+}
+
+// GetBlacklistList 获取黑名单列表
+// @Summary 获取黑名单列表
+// @Description 分页获取黑名单列表，包含各条目的累计命中次数和最后命中时间（每分钟汇总一次），可按命中次数或最后命中时间升序查找长期未命中的条目
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param sort_by query string false "排序字段 created/hits/last_hit" default(created)
+// @Param order query string false "排序方向 asc/desc，升序时从未命中的条目排在最前" default(desc)
+// @Success 200 {object} response.Response{data=dto.GetBlacklistResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist [get]
+func (h *BlacklistHandler) GetBlacklistList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.GetBlacklistRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	// 获取黑名单列表
+	blacklists, total, err := h.blacklistService.GetBlacklistByTenant(
+		ctx,
+		tenantIDUint64,
+		req.SortBy,
+		req.Order,
+		req.Page,
+		req.PageSize,
+	)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取黑名单列表失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取列表失败"))
+		return
+	}
+
+	// 构建响应
+	items := make([]dto.BlacklistInfo, len(blacklists))
+	for i, blacklist := range blacklists {
+		items[i] = dto.NewBlacklistInfo(blacklist)
+	}
+
+	totalPages := (total + int64(req.PageSize) - 1) / int64(req.PageSize)
+
+	resp := dto.GetBlacklistResponse{
+		Items: items,
+		Pagination: dto.PaginationInfo{
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: totalPages,
+		},
+	}
+
+	h.responseWriter.Success(c, resp)
+}
+
+// ExportBlacklist 导出黑名单
+// @Summary 导出黑名单
+// @Description 以CSV或NDJSON格式流式导出租户的全部黑名单条目，可按来源、有效状态和创建时间范围过滤；导出操作记录在审计日志中
+// @Tags 黑名单管理
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "导出格式" Enums(csv, ndjson) default(csv)
+// @Param source query string false "来源"
+// @Param active query bool false "true仅导出有效且未过期的条目，false仅导出已失效或已过期的条目"
+// @Param created_from query string false "创建时间下限（含），RFC3339格式"
+// @Param created_to query string false "创建时间上限（不含），RFC3339格式"
+// @Success 200 {file} file "导出文件"
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/export [get]
+func (h *BlacklistHandler) ExportBlacklist(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.ExportBlacklistRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+	if !req.ValidRange() {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("created_from必须早于created_to"))
+		return
+	}
+
+	// 获取用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	contentType := "text/csv; charset=utf-8"
+	if req.Format == services.ExportFormatNDJSON {
+		contentType = "application/x-ndjson"
+	}
+	fileName := "blacklist_" + time.Now().Format("20060102150405") + "." + req.Format
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+fileName+`"`)
+	c.Status(http.StatusOK)
+
+	// 响应头发出后无法再返回错误响应，导出中途失败时文件不完整，记录在日志和审计日志中（completed=false）
+	exported, err := h.blacklistService.ExportBlacklist(ctx, &services.ExportParams{
+		TenantID:    tenantIDUint64,
+		Format:      req.Format,
+		Source:      req.Source,
+		Active:      req.Active,
+		CreatedFrom: req.CreatedFrom,
+		CreatedTo:   req.CreatedTo,
+	}, c.Writer)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "导出黑名单失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Int64("exported", exported),
+			zap.Error(err))
+	}
+
+	// 客户端断开时请求上下文已取消，审计日志使用独立的上下文写入
+	auditErr := h.auditService.LogBlacklistExport(context.WithoutCancel(ctx), services.LogBlacklistExportRequest{
+		TenantID:   tenantIDUint64,
+		OperatorID: operatorIDUint64,
+		Format:     req.Format,
+		Filters:    req.AuditFilters(),
+		Exported:   exported,
+		Completed:  err == nil,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+	if auditErr != nil {
+		h.logger.ErrorWithTrace(ctx, "记录导出审计日志失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(auditErr))
+	}
+}
+
+// DeleteBlacklist 删除黑名单记录
+// @Summary 删除黑名单
+// @Description 删除指定的黑名单记录；租户启用审批时不直接删除，返回待审批的变更申请
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "黑名单ID"
+// @Success 200 {object} response.Response{data=dto.BlacklistChangeRequestInfo} "直接删除时data为空"
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/{id} [delete]
+func (h *BlacklistHandler) DeleteBlacklist(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "ID参数格式错误",
+			zap.String("id", idStr),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInvalidRequest())
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	// 删除黑名单记录，租户启用审批时仅提交申请
+	changeRequest, err := h.approvalService.RequestDeleteBlacklist(ctx, tenantIDUint64, id, operatorIDUint64)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "删除黑名单记录失败",
+			zap.Uint64("id", id),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+	if changeRequest != nil {
+		h.logChangeRequestAudit(c, tenantIDUint64, operatorIDUint64, models.AuditActionSubmit, changeRequest)
+		h.responseWriter.Success(c, dto.NewBlacklistChangeRequestInfo(changeRequest))
+		return
+	}
+
+	h.logger.InfoWithTrace(ctx, "删除黑名单记录成功",
+		zap.Uint64("id", id))
+
+	h.responseWriter.Success(c, nil)
+}
+
+// GetQueryStats 获取查询统计
+// @Summary 获取查询统计
+// @Description 获取黑名单查询统计信息；指定from和to时从MySQL汇总表读取任意时间范围的统计和时间序列，当前小时的数据最多延迟5分钟
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param hours query int false "统计小时数，未指定时间范围时读取最近的Redis统计" default(24)
+// @Param from query string false "开始时间（RFC3339），与to同时指定时从汇总表读取"
+// @Param to query string false "结束时间（RFC3339，不包含）"
+// @Param granularity query string false "指定时间范围时的统计粒度 hour/day，hour粒度最多31天，day粒度最多366天" default(day)
+// @Success 200 {object} response.Response{data=dto.QueryStatsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/stats [get]
+func (h *BlacklistHandler) GetQueryStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	hoursStr := c.DefaultQuery("hours", "24")
+	hours, err := strconv.Atoi(hoursStr)
+	if err != nil || hours <= 0 || hours > 168 { // 最多7天
+		hours = 24
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	var rangeReq dto.QueryStatsRangeRequest
+	if err := c.ShouldBindQuery(&rangeReq); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+	if rangeReq.HasRange() {
+		h.getRangeQueryStats(c, tenantIDUint64, &rangeReq)
+		return
+	}
+
+	// 获取统计信息
+	stats, err := h.blacklistService.GetQueryStats(ctx, tenantIDUint64, hours)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取查询统计失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Int("hours", hours),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取统计失败"))
+		return
+	}
+
+	resp := dto.QueryStatsResponse{
+		TotalQueries: stats.TotalQueries,
+		HitCount:     stats.HitCount,
+		MissCount:    stats.MissCount,
+		HitRate:      stats.HitRate,
+		AvgLatency:   stats.AvgLatency,
+	}
+
+	h.responseWriter.Success(c, resp)
+}
+
+// getRangeQueryStats 从汇总表获取租户在时间范围内的查询统计
+func (h *BlacklistHandler) getRangeQueryStats(c *gin.Context, tenantID uint64, req *dto.QueryStatsRangeRequest) {
+	ctx := c.Request.Context()
+
+	if !req.ValidRange() {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("from和to需同时指定且from早于to"))
+		return
+	}
+
+	stats, err := h.blacklistService.GetTenantStats(ctx, tenantID, newStatsWindow(req.Granularity, 0, req.From, req.To))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取查询统计失败",
+			zap.Uint64("tenant_id", tenantID),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	item := newAPIKeyStatsResponse(stats)
+	resp := dto.QueryStatsResponse{
+		TotalQueries: stats.TotalQueries,
+		HitCount:     stats.HitCount,
+		MissCount:    stats.MissCount,
+		HitRate:      stats.HitRate,
+		AvgLatency:   stats.AvgLatency,
+		Granularity:  req.Granularity,
+		From:         req.From,
+		To:           req.To,
+		QPS:          stats.QPS,
+		PeakQPS:      stats.PeakQPS,
+		Series:       item.Series,
+	}
+
+	h.responseWriter.Success(c, resp)
+}
+
+// GetMinuteStats 获取分钟级统计
+// @Summary 获取分钟级查询统计
+// @Description 获取最近N分钟的查询统计数据，包括QPS、命中率等
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param minutes query int false "统计分钟数" default(5) minimum(1) maximum(60)
+// @Success 200 {object} dto.MinuteStatsResponse "统计信息"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /admin/blacklist/stats/minutes [get]
+func (h *BlacklistHandler) GetMinuteStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 参数验证
+	var req dto.MinuteStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数验证失败"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID := c.GetUint64("tenant_id")
+
+	// 获取分钟级统计
+	stats, err := h.blacklistService.GetMinuteStats(ctx, tenantID, req.Minutes)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取分钟级统计失败",
+			zap.Uint64("tenant_id", tenantID),
+			zap.Int("minutes", req.Minutes),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取统计失败"))
+		return
+	}
+
+	// 转换响应
+	resp := dto.MinuteStatsResponse{
+		Timestamp:    stats.Timestamp,
+		TotalQueries: stats.TotalQueries,
+		HitCount:     stats.HitCount,
+		MissCount:    stats.MissCount,
+		HitRate:      stats.HitRate,
+		QPS:          stats.QPS,
+		AvgLatency:   stats.AvgLatency,
+		MinuteData:   make([]dto.MinutePoint, len(stats.MinuteData)),
+	}
+
+	// 转换分钟数据
+	for i, point := range stats.MinuteData {
+		resp.MinuteData[i] = dto.MinutePoint{
+			Minute:       point.Minute,
+			TotalQueries: point.TotalQueries,
+			HitCount:     point.HitCount,
+			QPS:          point.QPS,
+			AvgLatency:   point.AvgLatency,
+		}
+	}
+
+	h.logger.InfoWithTrace(ctx, "获取分钟级统计成功",
+		zap.Uint64("tenant_id", tenantID),
+		zap.Int("minutes", req.Minutes),
+		zap.Int64("total_queries", stats.TotalQueries))
+
+	h.responseWriter.Success(c, resp)
+}
+
+// GetAPIKeyStats 获取API密钥统计
+// @Summary 获取API密钥统计
+// @Description 获取单个API密钥最近一段时间或指定时间范围的查询量、QPS、命中率和平均延迟，以及按时间升序的数据序列；最近数据点minute粒度最多120个，hour粒度最多48个，day粒度最多366个；指定from和to或使用day粒度时从MySQL汇总表读取
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param api_key path string true "API密钥"
+// @Param granularity query string false "统计粒度 minute/hour/day，指定时间范围时只能为hour或day" default(minute)
+// @Param points query int false "数据点数，为空时minute粒度60个，hour粒度24个，day粒度30个"
+// @Param from query string false "开始时间（RFC3339），需与to同时指定"
+// @Param to query string false "结束时间（RFC3339，不包含）"
+// @Success 200 {object} response.Response{data=dto.APIKeyStatsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/stats/api-keys/{api_key} [get]
+func (h *BlacklistHandler) GetAPIKeyStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.APIKeyStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+	if !req.ValidRange() {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("from和to需同时指定且from早于to"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	stats, err := h.blacklistService.GetAPIKeyStats(ctx, tenantIDUint64, c.Param("api_key"), newStatsWindow(req.Granularity, req.Points, req.From, req.To))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取API密钥统计失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("api_key", c.Param("api_key")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, newAPIKeyStatsResponse(stats))
+}
+
+// GetTopConsumers 获取API密钥调用量排行
+// @Summary 获取API密钥调用量排行
+// @Description 按查询量、命中数或平均延迟对租户的API密钥排序，返回最近一段时间或指定时间范围的汇总统计；从MySQL汇总表读取时包含已删除的API密钥
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param granularity query string false "统计粒度 minute/hour/day，指定时间范围时只能为hour或day" default(hour)
+// @Param points query int false "数据点数，为空时minute粒度60个，hour粒度24个，day粒度30个"
+// @Param from query string false "开始时间（RFC3339），需与to同时指定"
+// @Param to query string false "结束时间（RFC3339，不包含）"
+// @Param sort_by query string false "排序字段 total/hits/latency" default(total)
+// @Param limit query int false "返回数量" default(10) minimum(1) maximum(100)
+// @Success 200 {object} response.Response{data=dto.TopConsumersResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/stats/top-consumers [get]
+func (h *BlacklistHandler) GetTopConsumers(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.TopConsumersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+	if !req.ValidRange() {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("from和to需同时指定且from早于to"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	window := newStatsWindow(req.Granularity, req.Points, req.From, req.To)
+	stats, err := h.blacklistService.GetTopConsumers(ctx, tenantIDUint64, window, req.SortBy, req.Limit)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取API密钥调用量排行失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	resp := dto.TopConsumersResponse{
+		Granularity: window.Granularity,
+		Points:      window.Points,
+		From:        req.From,
+		To:          req.To,
+		SortBy:      req.SortBy,
+		Items:       make([]dto.APIKeyStatsResponse, 0, len(stats)),
+	}
+	for _, item := range stats {
+		resp.Items = append(resp.Items, newAPIKeyStatsResponse(item))
+	}
+
+	h.responseWriter.Success(c, resp)
+}
+
+// GetFilterStats 获取本地过滤器统计
+// @Summary 获取本地过滤器统计
+// @Description 获取租户本地布隆过滤器的容量、内存占用、过滤次数及误判率
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=dto.FilterStatsResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/filter/stats [get]
+func (h *BlacklistHandler) GetFilterStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	stats, err := h.blacklistService.GetFilterStats(ctx, tenantIDUint64)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取过滤器统计失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取统计失败"))
+		return
+	}
+
+	resp := dto.FilterStatsResponse{
+		Ready:                      stats.Ready,
+		Entries:                    stats.Entries,
+		Capacity:                   stats.Capacity,
+		SizeBytes:                  stats.SizeBytes,
+		HashFunctions:              stats.HashFunctions,
+		Lookups:                    stats.Lookups,
+		Negatives:                  stats.Negatives,
+		Positives:                  stats.Positives,
+		FalsePositives:             stats.FalsePositives,
+		Bypassed:                   stats.Bypassed,
+		Removals:                   stats.Removals,
+		SyncGaps:                   stats.SyncGaps,
+		ObservedFalsePositiveRate:  stats.ObservedFalsePositiveRate,
+		EstimatedFalsePositiveRate: stats.EstimatedFalsePositiveRate,
+		BuiltAt:                    stats.BuiltAt,
+	}
+
+	h.responseWriter.Success(c, resp)
+}
+
+// SyncBlacklistToRedis 同步黑名单数据到Redis
+// @Summary 同步黑名单到Redis
+// @Description 按数据库分批重建租户的Redis黑名单数据，完成后原子切换，同步期间查询不受影响；同一租户同时只允许一个同步
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=dto.SyncBlacklistResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/sync [post]
+func (h *BlacklistHandler) SyncBlacklistToRedis(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	// 同步数据到Redis
+	result, err := h.blacklistService.SyncToRedis(ctx, tenantIDUint64)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "同步黑名单数据到Redis失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logger.InfoWithTrace(ctx, "同步黑名单数据到Redis成功",
+		zap.Uint64("tenant_id", tenantIDUint64),
+		zap.Int("added", result.Added),
+		zap.Int("removed", result.Removed))
+
+	h.responseWriter.Success(c, dto.SyncBlacklistResponse{
+		Message:      "黑名单数据同步成功",
+		TotalCount:   result.Total,
+		AddedCount:   result.Added,
+		RemovedCount: result.Removed,
+	})
+}
+
+// ListDriftReports 获取Redis偏差检查记录
+// @Summary 获取Redis偏差检查记录
+// @Description 分页获取当前租户Redis与数据库的偏差检查记录，按检查时间倒序；后台每15分钟检查一次，发现偏差时自动按数据库修复，记录保留30天
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=dto.ListDriftReportsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/drift [get]
+func (h *BlacklistHandler) ListDriftReports(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.ListDriftReportsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	reports, total, err := h.blacklistService.ListDriftReports(ctx, tenantIDUint64, req.Page, req.PageSize)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取偏差检查记录失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取列表失败"))
+		return
+	}
+
+	items := make([]dto.DriftReportInfo, len(reports))
+	for i, report := range reports {
+		items[i] = dto.NewDriftReportInfo(report)
+	}
+
+	h.responseWriter.Success(c, dto.ListDriftReportsResponse{
+		Items: items,
+		Pagination: dto.PaginationInfo{
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// CheckDrift 立即检查Redis偏差
+// @Summary 立即检查Redis偏差
+// @Description 立即比较当前租户数据库中的有效条目与Redis数据，发现偏差时按数据库修复并返回检查记录
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=dto.DriftReportInfo}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/drift/check [post]
+func (h *BlacklistHandler) CheckDrift(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	report, err := h.blacklistService.CheckDrift(ctx, tenantIDUint64)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "检查Redis偏差失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewDriftReportInfo(report))
+}
+
+// SearchQueryLogs 搜索查询日志
+// @Summary 搜索查询日志
+// @Description 按API Key、哈希、命中结果、请求ID和查询时间搜索当前租户的查询日志，按查询时间倒序，使用next_cursor翻页。未采样的请求仅记录命中和失败的查询
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param api_key query string false "API Key"
+// @Param identifier_hash query string false "查询的哈希值"
+// @Param is_hit query bool false "是否命中"
+// @Param request_id query string false "请求ID，即查询响应头X-Request-ID"
+// @Param from query string false "查询时间下限（含），RFC3339格式"
+// @Param to query string false "查询时间上限（不含），RFC3339格式"
+// @Param cursor query int false "上一页返回的next_cursor"
+// @Param limit query int false "每页数量，最大200" default(50)
+// @Success 200 {object} response.Response{data=dto.SearchQueryLogsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/query-logs [get]
+func (h *BlacklistHandler) SearchQueryLogs(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.SearchQueryLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+	if !req.ValidRange() {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("from必须早于to"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	page, err := h.blacklistService.SearchQueryLogs(ctx, &services.QueryLogSearchParams{
+		TenantID:       tenantIDUint64,
+		APIKey:         req.APIKey,
+		IdentifierHash: req.IdentifierHash,
+		IsHit:          req.IsHit,
+		RequestID:      req.RequestID,
+		From:           req.From,
+		To:             req.To,
+		Cursor:         req.Cursor,
+		Limit:          req.Limit,
+	})
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "搜索查询日志失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("搜索查询日志失败"))
+		return
+	}
+
+	h.responseWriter.Success(c, dto.SearchQueryLogsResponse{
+		Items:      dto.NewQueryLogInfos(page.Items),
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	})
+}
+
+// GetQueryLogTimeline 获取哈希的查询时间线
+// @Summary 获取哈希的查询时间线
+// @Description 获取当前租户对单个哈希的全部查询记录，按查询时间正序，使用next_cursor翻页；第一页附带查询次数、命中次数、首次和最近查询时间及查询过的API Key
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param identifier_hash query string true "查询的哈希值"
+// @Param api_key query string false "API Key"
+// @Param from query string false "查询时间下限（含），RFC3339格式"
+// @Param to query string false "查询时间上限（不含），RFC3339格式"
+// @Param cursor query int false "上一页返回的next_cursor"
+// @Param limit query int false "每页数量，最大200" default(50)
+// @Success 200 {object} response.Response{data=dto.QueryLogTimelineResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/query-logs/timeline [get]
+func (h *BlacklistHandler) GetQueryLogTimeline(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.QueryLogTimelineRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+	if !req.ValidRange() {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("from必须早于to"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	timeline, err := h.blacklistService.GetQueryLogTimeline(ctx, &services.QueryLogSearchParams{
+		TenantID:       tenantIDUint64,
+		APIKey:         req.APIKey,
+		IdentifierHash: req.IdentifierHash,
+		From:           req.From,
+		To:             req.To,
+		Cursor:         req.Cursor,
+		Limit:          req.Limit,
+	})
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取查询时间线失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("identifier_hash", req.IdentifierHash),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取查询时间线失败"))
+		return
+	}
+
+	resp := dto.QueryLogTimelineResponse{
+		IdentifierHash: req.IdentifierHash,
+		Items:          dto.NewQueryLogInfos(timeline.Items),
+		NextCursor:     timeline.NextCursor,
+		HasMore:        timeline.HasMore,
+	}
+	if summary := timeline.Summary; summary != nil {
+		resp.Summary = &dto.QueryLogSummary{
+			TotalQueries: summary.Total,
+			HitCount:     summary.HitCount,
+			FirstQueryAt: summary.FirstQueryAt,
+			LastQueryAt:  summary.LastQueryAt,
+			APIKeys:      summary.APIKeys,
+		}
+	}
+
+	h.responseWriter.Success(c, resp)
+}
+
+// GetHashSalt 获取租户盐
+// @Summary 获取租户盐
+// @Description 获取HMAC-SHA256格式使用的租户盐，首次获取时自动生成
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=dto.HashSaltResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/hash-salt [get]
+func (h *BlacklistHandler) GetHashSalt(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	salt, err := h.blacklistService.GetHashSalt(ctx, tenantIDUint64)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取租户盐失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取租户盐失败"))
+		return
+	}
+
+	h.responseWriter.Success(c, newHashSaltResponse(salt))
+}
+
+// RotateHashSalt 轮换租户盐
+// @Summary 轮换租户盐
+// @Description 生成新的租户盐并重建HMAC-SHA256集合，轮换后使用旧盐计算的查询将不再命中
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=dto.HashSaltResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/hash-salt/rotate [post]
+func (h *BlacklistHandler) RotateHashSalt(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	salt, err := h.blacklistService.RotateHashSalt(ctx, tenantIDUint64)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "轮换租户盐失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logger.InfoWithTrace(ctx, "轮换租户盐成功",
+		zap.Uint64("tenant_id", tenantIDUint64))
+
+	h.responseWriter.Success(c, newHashSaltResponse(salt))
+}
+
+// GetApprovalSetting 获取审批配置
+// @Summary 获取审批配置
+// @Description 获取当前租户新增和删除黑名单条目是否需要审批
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=dto.BlacklistApprovalSettingResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/approval-setting [get]
+func (h *BlacklistHandler) GetApprovalSetting(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	required, err := h.approvalService.GetApprovalRequired(ctx, tenantIDUint64)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取审批配置失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取审批配置失败"))
+		return
+	}
+
+	h.responseWriter.Success(c, dto.BlacklistApprovalSettingResponse{RequireApproval: required})
+}
+
+// UpdateApprovalSetting 更新审批配置
+// @Summary 更新审批配置
+// @Description 启用后通过创建和删除接口提交的变更需由申请人以外的用户审批通过后才写入数据库和Redis；关闭后已提交的申请仍可审批
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.UpdateBlacklistApprovalSettingRequest true "审批配置"
+// @Success 200 {object} response.Response{data=dto.BlacklistApprovalSettingResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/approval-setting [put]
+func (h *BlacklistHandler) UpdateApprovalSetting(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.UpdateBlacklistApprovalSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	previous, err := h.approvalService.GetApprovalRequired(ctx, tenantIDUint64)
+	if err == nil {
+		err = h.approvalService.SetApprovalRequired(ctx, tenantIDUint64, *req.RequireApproval)
+	}
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "更新审批配置失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("更新审批配置失败"))
+		return
+	}
+
+	auditErr := h.auditService.LogBlacklistApprovalSetting(context.WithoutCancel(ctx), services.LogBlacklistApprovalSettingRequest{
+		TenantID:   tenantIDUint64,
+		OperatorID: operatorIDUint64,
+		OldValue:   previous,
+		NewValue:   *req.RequireApproval,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+	if auditErr != nil {
+		h.logger.ErrorWithTrace(ctx, "记录审批配置审计日志失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(auditErr))
+	}
+
+	h.responseWriter.Success(c, dto.BlacklistApprovalSettingResponse{RequireApproval: *req.RequireApproval})
+}
+
+// ListChangeRequests 获取变更申请列表
+// @Summary 获取变更申请列表
+// @Description 分页获取当前租户的黑名单变更申请，按提交时间倒序
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "状态 pending/approved/rejected，为空时返回全部"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=dto.ListChangeRequestsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/change-requests [get]
+func (h *BlacklistHandler) ListChangeRequests(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.ListChangeRequestsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	requests, total, err := h.approvalService.ListChangeRequests(ctx, tenantIDUint64, req.Status, req.Page, req.PageSize)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取变更申请列表失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取列表失败"))
+		return
+	}
+
+	items := make([]dto.BlacklistChangeRequestInfo, len(requests))
+	for i, request := range requests {
+		items[i] = dto.NewBlacklistChangeRequestInfo(request)
+	}
+
+	h.responseWriter.Success(c, dto.ListChangeRequestsResponse{
+		Items: items,
+		Pagination: dto.PaginationInfo{
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// GetChangeRequest 获取变更申请详情
+// @Summary 获取变更申请详情
+// @Description 获取黑名单变更申请，删除申请的条目信息为提交时的快照
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "申请ID"
+// @Success 200 {object} response.Response{data=dto.BlacklistChangeRequestInfo}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/change-requests/{id} [get]
+func (h *BlacklistHandler) GetChangeRequest(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	request, err := h.approvalService.GetChangeRequest(ctx, tenantIDUint64, c.Param("id"))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取变更申请失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("request_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewBlacklistChangeRequestInfo(request))
+}
+
+// ApproveChangeRequest 审批通过变更申请
+// @Summary 审批通过变更申请
+// @Description 审批通过后立即写入数据库并同步Redis，申请人不能审批自己的申请；变更失败时申请恢复为待审批
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "申请ID"
+// @Param request body dto.ReviewChangeRequestRequest false "审批意见"
+// @Success 200 {object} response.Response{data=dto.BlacklistChangeRequestInfo}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/change-requests/{id}/approve [post]
+func (h *BlacklistHandler) ApproveChangeRequest(c *gin.Context) {
+	h.reviewChangeRequest(c, models.AuditActionApprove)
+}
+
+// RejectChangeRequest 驳回变更申请
+// @Summary 驳回变更申请
+// @Description 驳回待审批的变更申请，申请人不能驳回自己的申请
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "申请ID"
+// @Param request body dto.ReviewChangeRequestRequest false "驳回原因"
+// @Success 200 {object} response.Response{data=dto.BlacklistChangeRequestInfo}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/change-requests/{id}/reject [post]
+func (h *BlacklistHandler) RejectChangeRequest(c *gin.Context) {
+	h.reviewChangeRequest(c, models.AuditActionReject)
+}
+
+// reviewChangeRequest 审批变更申请，action为approve或reject
+func (h *BlacklistHandler) reviewChangeRequest(c *gin.Context, action string) {
+	ctx := c.Request.Context()
+
+	var req dto.ReviewChangeRequestRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.WarnWithTrace(ctx, "参数绑定失败",
+				zap.Error(err))
+			h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+			return
+		}
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	reviewerIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	review := h.approvalService.ApproveChangeRequest
+	if action == models.AuditActionReject {
+		review = h.approvalService.RejectChangeRequest
+	}
+	request, err := review(ctx, tenantIDUint64, c.Param("id"), reviewerIDUint64, req.Comment)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "审批变更申请失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("request_id", c.Param("id")),
+			zap.String("action", action),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logChangeRequestAudit(c, tenantIDUint64, reviewerIDUint64, action, request)
+	h.responseWriter.Success(c, dto.NewBlacklistChangeRequestInfo(request))
+}
+
+// logChangeRequestAudit 记录变更申请的提交或审批到审计日志
+func (h *BlacklistHandler) logChangeRequestAudit(c *gin.Context, tenantID, operatorID uint64, action string, request *models.BlacklistChangeRequest) {
+	ctx := c.Request.Context()
+
+	auditErr := h.auditService.LogBlacklistChange(context.WithoutCancel(ctx), services.LogBlacklistChangeRequest{
+		TenantID:      tenantID,
+		OperatorID:    operatorID,
+		Action:        action,
+		ChangeRequest: request,
+		Reason:        request.ReviewComment,
+		IPAddress:     c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+	})
+	if auditErr != nil {
+		h.logger.ErrorWithTrace(ctx, "记录变更申请审计日志失败",
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("request_id", request.UUID),
+			zap.String("action", action),
+			zap.Error(auditErr))
+	}
+}
+
+// CreateAllowlistEntry 创建白名单条目
+// @Summary 创建白名单条目
+// @Description 为当前租户添加白名单条目，有效期内命中黑名单的标识按未命中返回并标记overridden；MD5和SHA-256至少提供一种，原因必填，过期时间为空表示永久有效
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateAllowlistEntryRequest true "白名单条目"
+// @Success 200 {object} response.Response{data=dto.AllowlistEntryInfo}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/allowlist [post]
+func (h *BlacklistHandler) CreateAllowlistEntry(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.CreateAllowlistEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	if _, _, ok := req.Resolve(); !ok {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("MD5和SHA-256至少提供一种且格式正确"))
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	entry := req.ToModel(tenantIDUint64, operatorIDUint64)
+	if err := h.allowlistService.CreateAllowlistEntry(ctx, entry); err != nil {
+		h.logger.WarnWithTrace(ctx, "创建白名单条目失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logAllowlistAudit(c, tenantIDUint64, operatorIDUint64, models.AuditActionCreate, nil, entry)
+	h.responseWriter.Success(c, dto.NewAllowlistEntryInfo(entry))
+}
+
+// ListAllowlistEntries 获取白名单条目列表
+// @Summary 获取白名单条目列表
+// @Description 分页获取当前租户的白名单条目，包含已过期的条目，按创建时间倒序
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=dto.ListAllowlistEntriesResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/allowlist [get]
+func (h *BlacklistHandler) ListAllowlistEntries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.ListAllowlistEntriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	entries, total, err := h.allowlistService.ListAllowlistEntries(ctx, tenantIDUint64, req.Page, req.PageSize)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取白名单条目列表失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取列表失败"))
+		return
+	}
+
+	items := make([]dto.AllowlistEntryInfo, len(entries))
+	for i, entry := range entries {
+		items[i] = dto.NewAllowlistEntryInfo(entry)
+	}
+
+	h.responseWriter.Success(c, dto.ListAllowlistEntriesResponse{
+		Items: items,
+		Pagination: dto.PaginationInfo{
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// GetAllowlistEntry 获取白名单条目详情
+// @Summary 获取白名单条目详情
+// @Description 获取当前租户的白名单条目
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "条目ID"
+// @Success 200 {object} response.Response{data=dto.AllowlistEntryInfo}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/allowlist/{id} [get]
+func (h *BlacklistHandler) GetAllowlistEntry(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	entry, err := h.allowlistService.GetAllowlistEntry(ctx, tenantIDUint64, c.Param("id"))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取白名单条目失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("entry_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewAllowlistEntryInfo(entry))
+}
+
+// UpdateAllowlistEntry 更新白名单条目
+// @Summary 更新白名单条目
+// @Description 更新白名单条目的原因和过期时间，标识不可修改；过期时间均为空时改为永久有效
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "条目ID"
+// @Param request body dto.UpdateAllowlistEntryRequest true "更新内容"
+// @Success 200 {object} response.Response{data=dto.AllowlistEntryInfo}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/allowlist/{id} [put]
+func (h *BlacklistHandler) UpdateAllowlistEntry(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.UpdateAllowlistEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	old, entry, err := h.allowlistService.UpdateAllowlistEntry(ctx, tenantIDUint64, c.Param("id"), &services.AllowlistUpdateParams{
+		Reason:     req.Reason,
+		ExpiresAt:  req.ResolveExpiresAt(time.Now()),
+		OperatorID: operatorIDUint64,
+	})
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "更新白名单条目失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("entry_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logAllowlistAudit(c, tenantIDUint64, operatorIDUint64, models.AuditActionUpdate, old, entry)
+	h.responseWriter.Success(c, dto.NewAllowlistEntryInfo(entry))
+}
+
+// DeleteAllowlistEntry 删除白名单条目
+// @Summary 删除白名单条目
+// @Description 删除后该标识恢复按黑名单拦截
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "条目ID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/allowlist/{id} [delete]
+func (h *BlacklistHandler) DeleteAllowlistEntry(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	entry, err := h.allowlistService.DeleteAllowlistEntry(ctx, tenantIDUint64, c.Param("id"))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "删除白名单条目失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("entry_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logAllowlistAudit(c, tenantIDUint64, operatorIDUint64, models.AuditActionDelete, entry, nil)
+	h.responseWriter.Success(c, nil)
+}
+
+// logAllowlistAudit 记录白名单条目的变更到审计日志
+func (h *BlacklistHandler) logAllowlistAudit(c *gin.Context, tenantID, operatorID uint64, action string, oldEntry, newEntry *models.BlacklistAllowlistEntry) {
+	ctx := c.Request.Context()
+
+	auditErr := h.auditService.LogBlacklistAllowlist(context.WithoutCancel(ctx), services.LogBlacklistAllowlistRequest{
+		TenantID:   tenantID,
+		OperatorID: operatorID,
+		Action:     action,
+		OldEntry:   oldEntry,
+		NewEntry:   newEntry,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+	if auditErr != nil {
+		h.logger.ErrorWithTrace(ctx, "记录白名单审计日志失败",
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("action", action),
+			zap.Error(auditErr))
+	}
+}
+
+// CreateList 创建名单
+// @Summary 创建名单
+// @Description 在当前租户下创建命名名单，名单标识在租户内唯一且创建后不可修改，default为保留的默认名单标识
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateBlacklistListRequest true "创建请求"
+// @Success 200 {object} response.Response{data=dto.BlacklistListInfo}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/lists [post]
+func (h *BlacklistHandler) CreateList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.CreateBlacklistListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	list := &models.BlacklistList{
+		TenantModel: models.TenantModel{TenantID: tenantIDUint64},
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := h.listService.CreateList(ctx, list); err != nil {
+		h.logger.WarnWithTrace(ctx, "创建名单失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("name", req.Name),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logListAudit(c, tenantIDUint64, operatorIDUint64, models.AuditActionCreate, nil, list)
+	h.responseWriter.Success(c, dto.NewBlacklistListInfo(list))
+}
+
+// ListLists 获取名单列表
+// @Summary 获取名单列表
+// @Description 获取当前租户的全部命名名单，按名单标识排序，不包含隐式的默认名单
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=dto.ListBlacklistListsResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/lists [get]
+func (h *BlacklistHandler) ListLists(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	lists, err := h.listService.ListLists(ctx, tenantIDUint64)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取名单列表失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取列表失败"))
+		return
+	}
+
+	items := make([]dto.BlacklistListInfo, len(lists))
+	for i, list := range lists {
+		items[i] = dto.NewBlacklistListInfo(list)
+	}
+
+	h.responseWriter.Success(c, dto.ListBlacklistListsResponse{Items: items})
+}
+
+// GetList 获取名单详情
+// @Summary 获取名单详情
+// @Description 获取当前租户的命名名单
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "名单ID"
+// @Success 200 {object} response.Response{data=dto.BlacklistListInfo}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/lists/{id} [get]
+func (h *BlacklistHandler) GetList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	list, err := h.listService.GetList(ctx, tenantIDUint64, c.Param("id"))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取名单失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("list_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewBlacklistListInfo(list))
+}
+
+// UpdateList 更新名单
+// @Summary 更新名单
+// @Description 更新名单描述，名单标识不可修改
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "名单ID"
+// @Param request body dto.UpdateBlacklistListRequest true "更新内容"
+// @Success 200 {object} response.Response{data=dto.BlacklistListInfo}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/lists/{id} [put]
+func (h *BlacklistHandler) UpdateList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.UpdateBlacklistListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	old, list, err := h.listService.UpdateList(ctx, tenantIDUint64, c.Param("id"), req.Description)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "更新名单失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("list_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logListAudit(c, tenantIDUint64, operatorIDUint64, models.AuditActionUpdate, old, list)
+	h.responseWriter.Success(c, dto.NewBlacklistListInfo(list))
+}
+
+// DeleteList 删除名单
+// @Summary 删除名单
+// @Description 删除命名名单，名单中仍有有效条目或已授权给API密钥时不允许删除
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "名单ID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/lists/{id} [delete]
+func (h *BlacklistHandler) DeleteList(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	list, err := h.listService.DeleteList(ctx, tenantIDUint64, c.Param("id"))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "删除名单失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("list_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logListAudit(c, tenantIDUint64, operatorIDUint64, models.AuditActionDelete, list, nil)
+	h.responseWriter.Success(c, nil)
+}
+
+// logListAudit 记录名单的变更到审计日志
+func (h *BlacklistHandler) logListAudit(c *gin.Context, tenantID, operatorID uint64, action string, oldList, newList *models.BlacklistList) {
+	ctx := c.Request.Context()
+
+	auditErr := h.auditService.LogBlacklistList(context.WithoutCancel(ctx), services.LogBlacklistListRequest{
+		TenantID:   tenantID,
+		OperatorID: operatorID,
+		Action:     action,
+		OldList:    oldList,
+		NewList:    newList,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+	if auditErr != nil {
+		h.logger.ErrorWithTrace(ctx, "记录名单审计日志失败",
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("action", action),
+			zap.Error(auditErr))
+	}
+}
+
+// GetCredentialLists 获取API密钥的名单授权
+// @Summary 获取API密钥的名单授权
+// @Description 获取API密钥可查询的名单标识，未设置授权时仅可查询默认名单
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API密钥ID"
+// @Success 200 {object} response.Response{data=dto.CredentialListsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/credentials/{id}/lists [get]
+func (h *BlacklistHandler) GetCredentialLists(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	credentialID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.responseWriter.Error(c, errors.ErrInvalidRequest())
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	lists, err := h.listService.GetCredentialLists(ctx, tenantIDUint64, credentialID)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取API密钥名单授权失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Uint64("credential_id", credentialID),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.CredentialListsResponse{CredentialID: credentialID, Lists: lists})
+}
+
+// UpdateCredentialLists 设置API密钥的名单授权
+// @Summary 设置API密钥的名单授权
+// @Description 设置API密钥可查询的名单，default表示默认名单，为空时仅可查询默认名单；变更即时生效
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API密钥ID"
+// @Param request body dto.UpdateCredentialListsRequest true "名单授权"
+// @Success 200 {object} response.Response{data=dto.CredentialListsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/credentials/{id}/lists [put]
+func (h *BlacklistHandler) UpdateCredentialLists(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	credentialID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.responseWriter.Error(c, errors.ErrInvalidRequest())
+		return
+	}
+
+	var req dto.UpdateCredentialListsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	oldLists, lists, err := h.listService.SetCredentialLists(ctx, tenantIDUint64, credentialID, req.Lists)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "设置API密钥名单授权失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Uint64("credential_id", credentialID),
+			zap.Strings("lists", req.Lists),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
 		return
 	}
 
-	resp := dto.QueryStatsResponse{
-		TotalQueries: stats.TotalQueries,
-		HitCount:     stats.HitCount,
-		MissCount:    stats.MissCount,
-		HitRate:      stats.HitRate,
-		AvgLatency:   stats.AvgLatency,
+	auditErr := h.auditService.LogApiCredentialLists(context.WithoutCancel(ctx), services.LogApiCredentialListsRequest{
+		TenantID:     tenantIDUint64,
+		OperatorID:   operatorIDUint64,
+		CredentialID: credentialID,
+		OldLists:     oldLists,
+		NewLists:     lists,
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+	})
+	if auditErr != nil {
+		h.logger.ErrorWithTrace(ctx, "记录API密钥名单授权审计日志失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Uint64("credential_id", credentialID),
+			zap.Error(auditErr))
 	}
 
-	h.responseWriter.Success(c, resp)
+	h.responseWriter.Success(c, dto.CredentialListsResponse{CredentialID: credentialID, Lists: lists})
 }
 
-// GetMinuteStats 获取分钟级统计
-// @Summary 获取分钟级查询统计
-// @Description 获取最近N分钟的查询统计数据，包括QPS、命中率等
+// GetSharedSetting 获取共享名单配置
+// @Summary 获取共享名单配置
+// @Description 获取当前租户是否订阅共享名单以及是否可向共享名单贡献条目
 // @Tags 黑名单管理
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param minutes query int false "统计分钟数" default(5) minimum(1) maximum(60)
-// @Success 200 {object} dto.MinuteStatsResponse "统计信息"
-// @Failure 400 {object} response.Response "请求参数错误"
-// @Failure 401 {object} response.Response "未授权"
-// @Failure 500 {object} response.Response "服务器内部错误"
-// @Router /admin/blacklist/stats/minutes [get]
-func (h *BlacklistHandler) GetMinuteStats(c *gin.Context) {
+// @Success 200 {object} response.Response{data=dto.BlacklistSharedSettingResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/shared-setting [get]
+func (h *BlacklistHandler) GetSharedSetting(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	// 参数验证
-	var req dto.MinuteStatsRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	setting, err := h.sharedListService.GetSharedSetting(ctx, tenantIDUint64)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取共享名单配置失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取共享名单配置失败"))
+		return
+	}
+
+	h.responseWriter.Success(c, dto.BlacklistSharedSettingResponse{
+		SharedSubscribed:  setting.Subscribed,
+		SharedContributor: setting.Contributor,
+	})
+}
+
+// UpdateSharedSetting 更新共享名单配置
+// @Summary 更新共享名单配置
+// @Description 订阅后查询时同时查询系统维护的共享名单（HMAC-SHA256格式除外），命中时通过hit_source区分来源；加入贡献后可向共享名单贡献条目，退出贡献不影响已贡献的条目；各实例的订阅变更最迟30秒后生效
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.UpdateBlacklistSharedSettingRequest true "共享名单配置"
+// @Success 200 {object} response.Response{data=dto.BlacklistSharedSettingResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/shared-setting [put]
+func (h *BlacklistHandler) UpdateSharedSetting(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.UpdateBlacklistSharedSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.WarnWithTrace(ctx, "参数绑定失败",
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrValidationFailed("参数验证失败"))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
 		return
 	}
 
-	// 获取租户ID
-	tenantID := c.GetUint64("tenant_id")
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
 
-	// 获取分钟级统计
-	stats, err := h.blacklistService.GetMinuteStats(ctx, tenantID, req.Minutes)
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	setting := &services.SharedSetting{
+		Subscribed:  *req.SharedSubscribed,
+		Contributor: *req.SharedContributor,
+	}
+	previous, err := h.sharedListService.SetSharedSetting(ctx, tenantIDUint64, setting)
 	if err != nil {
-		h.logger.ErrorWithTrace(ctx, "获取分钟级统计失败",
-			zap.Uint64("tenant_id", tenantID),
-			zap.Int("minutes", req.Minutes),
+		h.logger.ErrorWithTrace(ctx, "更新共享名单配置失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrInternalError("获取统计失败"))
+		h.responseWriter.Error(c, err)
 		return
 	}
 
-	// 转换响应
-	resp := dto.MinuteStatsResponse{
-		Timestamp:    stats.Timestamp,
-		TotalQueries: stats.TotalQueries,
-		HitCount:     stats.HitCount,
-		MissCount:    stats.MissCount,
-		HitRate:      stats.HitRate,
-		QPS:          stats.QPS,
-		AvgLatency:   stats.AvgLatency,
-		MinuteData:   make([]dto.MinutePoint, len(stats.MinuteData)),
+	auditErr := h.auditService.LogBlacklistSharedSetting(context.WithoutCancel(ctx), services.LogBlacklistSharedSettingRequest{
+		TenantID:       tenantIDUint64,
+		OperatorID:     operatorIDUint64,
+		OldSubscribed:  previous.Subscribed,
+		OldContributor: previous.Contributor,
+		NewSubscribed:  setting.Subscribed,
+		NewContributor: setting.Contributor,
+		IPAddress:      c.ClientIP(),
+		UserAgent:      c.Request.UserAgent(),
+	})
+	if auditErr != nil {
+		h.logger.ErrorWithTrace(ctx, "记录共享名单配置审计日志失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(auditErr))
 	}
 
-	// 转换分钟数据
-	for i, point := range stats.MinuteData {
-		resp.MinuteData[i] = dto.MinutePoint{
-			Minute:       point.Minute,
-			TotalQueries: point.TotalQueries,
-			HitCount:     point.HitCount,
-			QPS:          point.QPS,
-			AvgLatency:   point.AvgLatency,
-		}
+	h.responseWriter.Success(c, dto.BlacklistSharedSettingResponse{
+		SharedSubscribed:  setting.Subscribed,
+		SharedContributor: setting.Contributor,
+	})
+}
+
+// ContributeShared 向共享名单贡献条目
+// @Summary 向共享名单贡献条目
+// @Description 已加入贡献的租户向共享名单贡献条目，系统租户无需加入贡献，用于系统管理员维护共享名单；条目归属共享名单，贡献方仅对系统管理员可见；同一标识已在共享名单中时返回冲突；贡献租户启用审批时返回403
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ContributeSharedRequest true "贡献请求"
+// @Success 200 {object} response.Response{data=dto.SharedContributionInfo}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/shared/contributions [post]
+func (h *BlacklistHandler) ContributeShared(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.ContributeSharedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
 	}
 
-	h.logger.InfoWithTrace(ctx, "获取分钟级统计成功",
-		zap.Uint64("tenant_id", tenantID),
-		zap.Int("minutes", req.Minutes),
-		zap.Int64("total_queries", stats.TotalQueries))
+	if !req.Resolve() {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("缺少黑名单标识或格式错误"))
+		return
+	}
 
-	h.responseWriter.Success(c, resp)
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	blacklist := req.ToModel(operatorIDUint64)
+	if blacklist.IsExpired(time.Now()) {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("过期时间必须晚于当前时间"))
+		return
+	}
+
+	if err := h.blacklistService.ContributeShared(ctx, tenantIDUint64, blacklist); err != nil {
+		h.logger.WarnWithTrace(ctx, "贡献共享名单条目失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("identifier_type", blacklist.IdentifierType),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logSharedContributionAudit(c, tenantIDUint64, operatorIDUint64, models.AuditActionCreate, blacklist)
+	h.responseWriter.Success(c, dto.NewSharedContributionInfo(blacklist))
 }
 
-// SyncBlacklistToRedis 同步黑名单数据到Redis
-// @Summary 同步黑名单到Redis
-// @Description 将租户的黑名单数据同步到Redis缓存
+// ListSharedContributions 获取本租户贡献的共享名单条目
+// @Summary 获取本租户贡献的共享名单条目
+// @Description 分页获取当前租户贡献到共享名单的有效条目，按贡献时间倒序，不包含其他租户贡献的条目；系统租户获取共享名单的全部条目及其贡献租户
 // @Tags 黑名单管理
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} response.Response
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=dto.ListSharedContributionsResponse}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 403 {object} response.Response
 // @Failure 500 {object} response.Response
-// @Router /admin/blacklist/sync [post]
-func (h *BlacklistHandler) SyncBlacklistToRedis(c *gin.Context) {
+// @Router /admin/blacklist/shared/contributions [get]
+func (h *BlacklistHandler) ListSharedContributions(c *gin.Context) {
 	ctx := c.Request.Context()
 
+	var req dto.ListSharedContributionsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
 	// 获取租户ID
 	tenantID, exists := middleware.GetCurrentTenantID(c)
 	if !exists {
@@ -618,20 +3322,188 @@ func (h *BlacklistHandler) SyncBlacklistToRedis(c *gin.Context) {
 
 	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
 
-	// 同步数据到Redis
-	err := h.blacklistService.SyncToRedis(ctx, tenantIDUint64)
+	entries, total, err := h.blacklistService.ListSharedContributions(ctx, tenantIDUint64, req.Page, req.PageSize)
 	if err != nil {
-		h.logger.ErrorWithTrace(ctx, "同步黑名单数据到Redis失败",
+		h.logger.ErrorWithTrace(ctx, "获取共享名单贡献列表失败",
 			zap.Uint64("tenant_id", tenantIDUint64),
 			zap.Error(err))
-		h.responseWriter.Error(c, errors.ErrInternalError("同步失败"))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取列表失败"))
 		return
 	}
 
-	h.logger.InfoWithTrace(ctx, "同步黑名单数据到Redis成功",
-		zap.Uint64("tenant_id", tenantIDUint64))
+	items := make([]dto.SharedContributionInfo, len(entries))
+	for i, entry := range entries {
+		items[i] = dto.NewSharedContributionInfo(entry)
+	}
+
+	h.responseWriter.Success(c, dto.ListSharedContributionsResponse{
+		Items: items,
+		Pagination: dto.PaginationInfo{
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// WithdrawSharedContribution 撤回共享名单条目
+// @Summary 撤回共享名单条目
+// @Description 撤回当前租户贡献到共享名单的条目，其他租户贡献的条目返回不存在，系统租户可删除任意条目；租户启用审批时返回403
+// @Tags 黑名单管理
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "条目ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/shared/contributions/{id} [delete]
+func (h *BlacklistHandler) WithdrawSharedContribution(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "ID参数格式错误",
+			zap.String("id", idStr),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInvalidRequest())
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	blacklist, err := h.blacklistService.WithdrawSharedContribution(ctx, tenantIDUint64, id)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "撤回共享名单条目失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Uint64("id", id),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.logSharedContributionAudit(c, tenantIDUint64, operatorIDUint64, models.AuditActionDelete, blacklist)
+	h.responseWriter.Success(c, nil)
+}
+
+// logSharedContributionAudit 记录共享名单条目的贡献和撤回到贡献租户的审计日志
+func (h *BlacklistHandler) logSharedContributionAudit(c *gin.Context, tenantID, operatorID uint64, action string, entry *models.PhoneBlacklist) {
+	ctx := c.Request.Context()
+
+	auditErr := h.auditService.LogBlacklistSharedContribution(context.WithoutCancel(ctx), services.LogBlacklistSharedContributionRequest{
+		TenantID:   tenantID,
+		OperatorID: operatorID,
+		Action:     action,
+		Entry:      entry,
+		IPAddress:  c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	})
+	if auditErr != nil {
+		h.logger.ErrorWithTrace(ctx, "记录共享名单审计日志失败",
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("action", action),
+			zap.Error(auditErr))
+	}
+}
+
+// newHashSaltResponse 创建租户盐响应
+func newHashSaltResponse(salt string) dto.HashSaltResponse {
+	return dto.HashSaltResponse{
+		HashSalt:  salt,
+		Algorithm: "hex(HMAC-SHA256(key=hash_salt, message=hex(SHA256(normalized_identifier))))",
+	}
+}
+
+// requestCredential 获取当前请求的API密钥，由认证中间件写入上下文
+func requestCredential(c *gin.Context) *models.BlacklistApiCredential {
+	if value, exists := c.Get("credential"); exists {
+		if credential, ok := value.(*models.BlacklistApiCredential); ok {
+			return credential
+		}
+	}
+	return nil
+}
 
-	h.responseWriter.Success(c, gin.H{
-		"message": "黑名单数据同步成功",
+// credentialMinRiskScore 获取当前API密钥的风险分阈值，未设置时返回0
+func credentialMinRiskScore(c *gin.Context) int {
+	if credential := requestCredential(c); credential != nil {
+		return credential.MinRiskScore
+	}
+	return 0
+}
+
+// publishHits 发布命中事件，由Webhook服务异步投递给订阅了命中事件的接收方
+func (h *BlacklistHandler) publishHits(c *gin.Context, tenantID uint64, identifierType, hashType string, hits []services.WebhookHitItem) {
+	h.webhookService.Publish(c.Request.Context(), tenantID, models.WebhookEventHit, services.WebhookHitData{
+		APIKey:         c.GetString("api_key"),
+		RequestID:      c.GetString("request_id"),
+		IdentifierType: identifierType,
+		HashType:       hashType,
+		Hits:           hits,
 	})
 }
+
+// newStatsWindow 创建统计窗口，指定时间范围时忽略数据点数
+// 未指定数据点数时minute粒度取最近60分钟，hour粒度取最近24小时，day粒度取最近30天
+func newStatsWindow(granularity string, points int, from, to *time.Time) services.StatsWindow {
+	window := services.StatsWindow{Granularity: granularity}
+	if from != nil && to != nil {
+		window.From = *from
+		window.To = *to
+		return window
+	}
+
+	window.Points = points
+	if points == 0 {
+		switch granularity {
+		case services.StatsGranularityHour:
+			window.Points = 24
+		case services.StatsGranularityDay:
+			window.Points = 30
+		default:
+			window.Points = 60
+		}
+	}
+	return window
+}
+
+// newAPIKeyStatsResponse 转换API密钥统计，排行中的统计不包含序列
+func newAPIKeyStatsResponse(stats *services.APIKeyStats) dto.APIKeyStatsResponse {
+	resp := dto.APIKeyStatsResponse{
+		APIKey:       stats.APIKey,
+		Name:         stats.Name,
+		Status:       stats.Status,
+		TotalQueries: stats.TotalQueries,
+		HitCount:     stats.HitCount,
+		MissCount:    stats.MissCount,
+		HitRate:      stats.HitRate,
+		QPS:          stats.QPS,
+		PeakQPS:      stats.PeakQPS,
+		AvgLatency:   stats.AvgLatency,
+	}
+	for _, point := range stats.Series {
+		resp.Series = append(resp.Series, dto.StatsPoint{
+			Time:         point.Time,
+			TotalQueries: point.TotalQueries,
+			HitCount:     point.HitCount,
+			HitRate:      point.HitRate,
+			QPS:          point.QPS,
+			AvgLatency:   point.AvgLatency,
+		})
+	}
+	return resp
+}
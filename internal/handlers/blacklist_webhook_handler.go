@@ -0,0 +1,493 @@
+// Package handlers provides HTTP request handlers.
+// This file contains blacklist webhook handler for webhook subscriptions and deliveries.
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/varluffy/shield/internal/dto"
+	"github.com/varluffy/shield/internal/middleware"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/services"
+	"github.com/varluffy/shield/pkg/errors"
+	"github.com/varluffy/shield/pkg/logger"
+	"github.com/varluffy/shield/pkg/response"
+	"go.uber.org/zap"
+)
+
+// BlacklistWebhookHandler 黑名单Webhook处理器
+type BlacklistWebhookHandler struct {
+	webhookService services.WebhookService
+	logger         *logger.Logger
+	responseWriter *response.ResponseWriter
+}
+
+// NewBlacklistWebhookHandler 创建黑名单Webhook处理器
+func NewBlacklistWebhookHandler(
+	webhookService services.WebhookService,
+	logger *logger.Logger,
+) *BlacklistWebhookHandler {
+	return &BlacklistWebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+		responseWriter: response.NewResponseWriter(logger),
+	}
+}
+
+// CreateWebhook 创建Webhook订阅
+// @Summary 创建Webhook订阅
+// @Description 订阅黑名单事件，事件类型：blacklist.hit, blacklist.entry.created, blacklist.entry.deleted, blacklist.batch.imported, blacklist.batch.rolled_back。签名密钥仅在创建时返回
+// @Tags 黑名单Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateWebhookRequest true "创建请求"
+// @Success 200 {object} response.Response{data=dto.WebhookSecretResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/webhooks [post]
+func (h *BlacklistWebhookHandler) CreateWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取当前用户信息
+	userID, _, tenantID, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "用户信息未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+	operatorIDUint64, _ := strconv.ParseUint(userID, 10, 64)
+
+	webhook := &models.BlacklistWebhook{
+		TenantModel: models.TenantModel{TenantID: tenantIDUint64},
+		Name:        req.Name,
+		URL:         req.URL,
+		EventTypes:  strings.Join(req.EventTypes, ","),
+		Description: req.Description,
+		CreatedBy:   operatorIDUint64,
+	}
+	if err := h.webhookService.CreateWebhook(ctx, webhook); err != nil {
+		h.logger.WarnWithTrace(ctx, "创建Webhook订阅失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.WebhookSecretResponse{
+		WebhookInfo: dto.NewWebhookInfo(webhook),
+		Secret:      webhook.Secret, // 仅在创建和轮换时返回
+	})
+}
+
+// ListWebhooks 获取Webhook订阅列表
+// @Summary 获取Webhook订阅列表
+// @Description 分页获取当前租户的Webhook订阅，按创建时间倒序
+// @Tags 黑名单Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=dto.ListWebhooksResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/webhooks [get]
+func (h *BlacklistWebhookHandler) ListWebhooks(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.ListWebhooksRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	webhooks, total, err := h.webhookService.ListWebhooks(ctx, tenantIDUint64, req.Page, req.PageSize)
+	if err != nil {
+		h.logger.ErrorWithTrace(ctx, "获取Webhook订阅列表失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrInternalError("获取列表失败"))
+		return
+	}
+
+	items := make([]dto.WebhookInfo, len(webhooks))
+	for i, webhook := range webhooks {
+		items[i] = dto.NewWebhookInfo(webhook)
+	}
+
+	h.responseWriter.Success(c, dto.ListWebhooksResponse{
+		Items: items,
+		Pagination: dto.PaginationInfo{
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// GetWebhook 获取Webhook订阅详情
+// @Summary 获取Webhook订阅详情
+// @Description 获取Webhook订阅信息，不返回签名密钥
+// @Tags 黑名单Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "订阅UUID"
+// @Success 200 {object} response.Response{data=dto.WebhookInfo}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/webhooks/{id} [get]
+func (h *BlacklistWebhookHandler) GetWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	webhook, err := h.webhookService.GetWebhook(ctx, tenantIDUint64, c.Param("id"))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取Webhook订阅失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("webhook_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewWebhookInfo(webhook))
+}
+
+// UpdateWebhook 更新Webhook订阅
+// @Summary 更新Webhook订阅
+// @Description 更新Webhook订阅，未传的字段不修改。停用或删除后尚未完成的投递在下次投递时进入死信
+// @Tags 黑名单Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "订阅UUID"
+// @Param request body dto.UpdateWebhookRequest true "更新请求"
+// @Success 200 {object} response.Response{data=dto.WebhookInfo}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/webhooks/{id} [put]
+func (h *BlacklistWebhookHandler) UpdateWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	webhook, err := h.webhookService.UpdateWebhook(ctx, tenantIDUint64, c.Param("id"), &services.WebhookUpdateParams{
+		Name:        req.Name,
+		URL:         req.URL,
+		EventTypes:  req.EventTypes,
+		Status:      req.Status,
+		Description: req.Description,
+	})
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "更新Webhook订阅失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("webhook_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewWebhookInfo(webhook))
+}
+
+// DeleteWebhook 删除Webhook订阅
+// @Summary 删除Webhook订阅
+// @Description 删除Webhook订阅，投递记录保留
+// @Tags 黑名单Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "订阅UUID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/webhooks/{id} [delete]
+func (h *BlacklistWebhookHandler) DeleteWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	if err := h.webhookService.DeleteWebhook(ctx, tenantIDUint64, c.Param("id")); err != nil {
+		h.logger.WarnWithTrace(ctx, "删除Webhook订阅失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("webhook_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, nil)
+}
+
+// RotateWebhookSecret 轮换Webhook签名密钥
+// @Summary 轮换Webhook签名密钥
+// @Description 重新生成签名密钥，之后的投递（包括重试）使用新密钥签名。新密钥仅显示一次
+// @Tags 黑名单Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "订阅UUID"
+// @Success 200 {object} response.Response{data=dto.WebhookSecretResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/webhooks/{id}/rotate-secret [post]
+func (h *BlacklistWebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	webhook, err := h.webhookService.RotateWebhookSecret(ctx, tenantIDUint64, c.Param("id"))
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "轮换Webhook签名密钥失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.String("webhook_id", c.Param("id")),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.WebhookSecretResponse{
+		WebhookInfo: dto.NewWebhookInfo(webhook),
+		Secret:      webhook.Secret,
+	})
+}
+
+// ListWebhookDeliveries 获取Webhook投递记录
+// @Summary 获取Webhook投递记录
+// @Description 分页获取当前租户的投递记录，按创建时间倒序。status=dead即死信，可调用重新投递接口
+// @Tags 黑名单Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param webhook_id query string false "订阅UUID"
+// @Param status query string false "投递状态" Enums(pending, succeeded, dead)
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=dto.ListWebhookDeliveriesResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/webhook-deliveries [get]
+func (h *BlacklistWebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req dto.ListWebhookDeliveriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.WarnWithTrace(ctx, "参数绑定失败",
+			zap.Error(err))
+		h.responseWriter.Error(c, errors.ErrValidationFailed("参数绑定失败"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	deliveries, total, err := h.webhookService.ListDeliveries(ctx, tenantIDUint64, req.WebhookID, req.Status, req.Page, req.PageSize)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取Webhook投递记录失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	items := make([]dto.WebhookDeliveryInfo, len(deliveries))
+	for i, delivery := range deliveries {
+		items[i] = dto.NewWebhookDeliveryInfo(delivery)
+	}
+
+	h.responseWriter.Success(c, dto.ListWebhookDeliveriesResponse{
+		Items: items,
+		Pagination: dto.PaginationInfo{
+			Page:       req.Page,
+			PageSize:   req.PageSize,
+			Total:      total,
+			TotalPages: (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// GetWebhookDelivery 获取Webhook投递详情
+// @Summary 获取Webhook投递详情
+// @Description 获取投递的事件内容和每次尝试的响应状态码、失败原因及耗时
+// @Tags 黑名单Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "投递ID"
+// @Success 200 {object} response.Response{data=dto.WebhookDeliveryDetailResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/webhook-deliveries/{id} [get]
+func (h *BlacklistWebhookHandler) GetWebhookDelivery(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	deliveryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("无效的投递ID"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	delivery, attempts, err := h.webhookService.GetDelivery(ctx, tenantIDUint64, deliveryID)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "获取Webhook投递详情失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Uint64("delivery_id", deliveryID),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewWebhookDeliveryDetailResponse(delivery, attempts))
+}
+
+// RedeliverWebhook 重新投递
+// @Summary 重新投递
+// @Description 将已结束的投递（通常为死信）重置为待投递并立即投递，投递次数重新计算，事件ID不变
+// @Tags 黑名单Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "投递ID"
+// @Success 200 {object} response.Response{data=dto.WebhookDeliveryInfo}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /admin/blacklist/webhook-deliveries/{id}/redeliver [post]
+func (h *BlacklistWebhookHandler) RedeliverWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	deliveryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		h.responseWriter.Error(c, errors.ErrValidationFailed("无效的投递ID"))
+		return
+	}
+
+	// 获取租户ID
+	tenantID, exists := middleware.GetCurrentTenantID(c)
+	if !exists {
+		h.logger.ErrorWithTrace(ctx, "租户ID未找到")
+		h.responseWriter.Error(c, errors.ErrUnauthorized())
+		return
+	}
+
+	tenantIDUint64, _ := strconv.ParseUint(tenantID, 10, 64)
+
+	delivery, err := h.webhookService.Redeliver(ctx, tenantIDUint64, deliveryID)
+	if err != nil {
+		h.logger.WarnWithTrace(ctx, "重新投递失败",
+			zap.Uint64("tenant_id", tenantIDUint64),
+			zap.Uint64("delivery_id", deliveryID),
+			zap.Error(err))
+		h.responseWriter.Error(c, err)
+		return
+	}
+
+	h.responseWriter.Success(c, dto.NewWebhookDeliveryInfo(delivery))
+}
@@ -25,6 +25,7 @@ var ProviderSet = wire.NewSet(
 	// 黑名单相关Handler
 	NewBlacklistHandler,
 	NewApiCredentialHandler,
+	NewBlacklistWebhookHandler,
 
 	// 这里可以添加其他Handler
 	// NewProductHandler,
@@ -10,6 +10,7 @@ import (
 	"github.com/varluffy/shield/internal/database"
 	"github.com/varluffy/shield/pkg/httpclient"
 	"github.com/varluffy/shield/pkg/logger"
+	"github.com/varluffy/shield/pkg/metrics"
 	"github.com/varluffy/shield/pkg/redis"
 	"github.com/varluffy/shield/pkg/tracing"
 	"github.com/varluffy/shield/pkg/transaction"
@@ -78,6 +79,13 @@ func ProvideDatabase(cfg *config.Config, logger *logger.Logger) (*gorm.DB, error
 		return nil, err
 	}
 
+	// 注册连接池指标
+	if sqlDB, err := db.DB(); err == nil {
+		if err := metrics.RegisterDBPool(cfg.Database.Name, sqlDB); err != nil {
+			logger.Logger.Warn("Failed to register database pool metrics", zap.Error(err))
+		}
+	}
+
 	// 根据配置决定是否执行自动迁移
 	if shouldRunAutoMigrate(cfg) {
 		logger.Logger.Info("Running database auto migration", 
@@ -135,7 +143,14 @@ func ProvideRedis(cfg *config.Config, logger *logger.Logger) *redis.Client {
 		TracingName:   cfg.Redis.TracingName,
 	}
 
-	return redis.NewClient(redisConfig, logger.Logger)
+	client := redis.NewClient(redisConfig, logger.Logger)
+
+	// 注册连接池指标
+	if err := metrics.RegisterRedisPool(client.Stats); err != nil {
+		logger.Logger.Warn("Failed to register redis pool metrics", zap.Error(err))
+	}
+
+	return client
 }
 
 // ProvideZapLogger 提供原始的zap.Logger（用于需要*zap.Logger的组件）
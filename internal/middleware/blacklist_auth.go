@@ -100,7 +100,7 @@ func (m *BlacklistAuthMiddleware) ValidateHMACAuth() gin.HandlerFunc {
 
 		// IP白名单检查
 		clientIP := m.getClientIP(c)
-		if !m.isIPAllowed(clientIP, credential.IPWhitelist) {
+		if !m.authService.IsIPAllowed(clientIP, credential.IPWhitelist) {
 			m.logger.WarnWithTrace(ctx, "IP地址不在白名单中",
 				zap.String("api_key", apiKey),
 				zap.String("client_ip", clientIP),
@@ -179,46 +179,3 @@ func (m *BlacklistAuthMiddleware) getClientIP(c *gin.Context) string {
 	}
 	return ip
 }
-
-// isIPAllowed 检查IP是否在白名单中
-func (m *BlacklistAuthMiddleware) isIPAllowed(clientIP, whitelist string) bool {
-	// 如果白名单为空，表示不限制IP
-	if whitelist == "" {
-		return true
-	}
-
-	// 解析客户端IP
-	clientIPAddr := net.ParseIP(clientIP)
-	if clientIPAddr == nil {
-		return false
-	}
-
-	// 分割白名单中的IP/CIDR
-	allowedIPs := strings.Split(whitelist, ",")
-	for _, allowedIP := range allowedIPs {
-		allowedIP = strings.TrimSpace(allowedIP)
-		if allowedIP == "" {
-			continue
-		}
-
-		// 检查是否为CIDR格式
-		if strings.Contains(allowedIP, "/") {
-			_, ipNet, err := net.ParseCIDR(allowedIP)
-			if err != nil {
-				m.logger.Warn("无效的CIDR格式", zap.String("cidr", allowedIP))
-				continue
-			}
-			if ipNet.Contains(clientIPAddr) {
-				return true
-			}
-		} else {
-			// 单个IP地址
-			allowedIPAddr := net.ParseIP(allowedIP)
-			if allowedIPAddr != nil && allowedIPAddr.Equal(clientIPAddr) {
-				return true
-			}
-		}
-	}
-
-	return false
-}
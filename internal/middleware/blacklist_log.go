@@ -9,6 +9,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/varluffy/shield/internal/models"
 	"github.com/varluffy/shield/internal/services"
 	"github.com/varluffy/shield/pkg/logger"
 	"go.uber.org/zap"
@@ -46,9 +47,10 @@ func (m *BlacklistLogMiddleware) SamplingLogMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
-		// 生成请求ID
+		// 生成请求ID，通过响应头返回给调用方，便于按请求ID查询日志
 		requestID := uuid.New().String()
 		c.Set("request_id", requestID)
+		c.Header("X-Request-ID", requestID)
 
 		// 记录请求开始（仅在采样时）
 		shouldLog := m.shouldLogRequest(c)
@@ -120,21 +122,28 @@ func (m *BlacklistLogMiddleware) logRequestComplete(c *gin.Context, requestID st
 
 	// 异步记录查询日志到统计系统
 	if apiKey != "" {
-		phoneMD5 := ""
-		if md5, exists := c.Get("phone_md5"); exists {
-			phoneMD5 = getStringFromContext(md5)
+		tenantID, _ := c.Get("tenant_id")
+		record := &services.QueryLogRecord{
+			TenantID:       getUint64FromContext(tenantID),
+			APIKey:         apiKey,
+			RequestID:      requestID,
+			IdentifierType: c.GetString("identifier_type"),
+			HashType:       c.GetString("hash_type"),
+			Results:        queryResultsFromContext(c, isHit),
+			IsHit:          isHit,
+			StatusCode:     status,
+			ResponseTime:   responseTime,
+			ClientIP:       c.ClientIP(),
+			UserAgent:      c.GetHeader("User-Agent"),
+			SampleRate:     models.DefaultLogSampleRate,
+		}
+		if value, exists := c.Get("credential"); exists {
+			if credential, ok := value.(*models.BlacklistApiCredential); ok {
+				record.SampleRate = credential.LogSampleRate
+			}
 		}
 
-		m.authService.RecordQueryLog(
-			ctx,
-			apiKey,
-			phoneMD5,
-			isHit,
-			responseTime,
-			c.ClientIP(),
-			c.GetHeader("User-Agent"),
-			requestID,
-		)
+		m.authService.RecordQueryLog(ctx, record)
 	}
 
 	// 根据条件决定是否记录详细日志
@@ -173,6 +182,31 @@ func (m *BlacklistLogMiddleware) logRequestComplete(c *gin.Context, requestID st
 	}
 }
 
+// queryResultsFromContext 获取查询的哈希及是否命中：批量查询由处理器设置blacklist_results，
+// 查询失败时使用query_hashes并记为未命中，单个查询使用phone_md5
+func queryResultsFromContext(c *gin.Context, isHit bool) map[string]bool {
+	if value, exists := c.Get("blacklist_results"); exists {
+		if results, ok := value.(map[string]bool); ok {
+			return results
+		}
+	}
+	if value, exists := c.Get("query_hashes"); exists {
+		if hashes, ok := value.([]string); ok && len(hashes) > 0 {
+			results := make(map[string]bool, len(hashes))
+			for _, hash := range hashes {
+				results[hash] = false
+			}
+			return results
+		}
+	}
+	if value, exists := c.Get("phone_md5"); exists {
+		if hash := getStringFromContext(value); hash != "" {
+			return map[string]bool{hash: isHit}
+		}
+	}
+	return nil
+}
+
 // getStringFromContext 安全地从上下文获取字符串值
 func getStringFromContext(value interface{}) string {
 	if str, ok := value.(string); ok {
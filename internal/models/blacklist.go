@@ -1,19 +1,121 @@
 package models
 
 import (
+	"math"
+	"regexp"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// PhoneBlacklist 手机号黑名单模型
+// 黑名单标识类型
+const (
+	IdentifierTypePhone    = "phone"     // 手机号
+	IdentifierTypeIDCard   = "id_card"   // 身份证号
+	IdentifierTypeDeviceID = "device_id" // 设备指纹
+	IdentifierTypeEmail    = "email"     // 邮箱
+	IdentifierTypeIP       = "ip"        // IP地址
+	IdentifierTypeBankCard = "bank_card" // 银行卡号
+)
+
+// IdentifierTypes 支持的全部标识类型
+var IdentifierTypes = []string{
+	IdentifierTypePhone,
+	IdentifierTypeIDCard,
+	IdentifierTypeDeviceID,
+	IdentifierTypeEmail,
+	IdentifierTypeIP,
+	IdentifierTypeBankCard,
+}
+
+// IsValidIdentifierType 检查标识类型是否支持
+func IsValidIdentifierType(identifierType string) bool {
+	for _, t := range IdentifierTypes {
+		if t == identifierType {
+			return true
+		}
+	}
+	return false
+}
+
+// 标识哈希格式
+const (
+	HashTypeMD5        = "md5"         // MD5(规范化标识)
+	HashTypeSHA256     = "sha256"      // SHA-256(规范化标识)
+	HashTypeHMACSHA256 = "hmac_sha256" // HMAC-SHA256(租户盐, SHA-256十六进制)
+)
+
+// HashTypes 支持的全部哈希格式
+var HashTypes = []string{
+	HashTypeMD5,
+	HashTypeSHA256,
+	HashTypeHMACSHA256,
+}
+
+// HashLength 哈希格式对应的十六进制长度，不支持的格式返回0
+func HashLength(hashType string) int {
+	switch hashType {
+	case HashTypeMD5:
+		return 32
+	case HashTypeSHA256, HashTypeHMACSHA256:
+		return 64
+	default:
+		return 0
+	}
+}
+
+// 风险分类
+const (
+	RiskCategoryFraud                = "fraud"                 // 欺诈
+	RiskCategoryComplaint            = "complaint"             // 投诉
+	RiskCategoryCollectionHarassment = "collection_harassment" // 催收骚扰
+	RiskCategoryOther                = "other"                 // 其他
+)
+
+// DefaultRiskScore 未指定风险分时的默认值（1-100，越高风险越大）
+const DefaultRiskScore = 100
+
+// DefaultLogSampleRate API密钥默认的详细查询日志采样率
+const DefaultLogSampleRate = 0.01
+
+// PhoneBlacklist 黑名单模型
+// 历史原因表名和PhoneMD5字段沿用手机号命名，PhoneMD5存储各类标识规范化后的MD5
+// PhoneMD5和IdentifierSHA256至少有一个不为空，HMAC格式由SHA-256和租户盐实时推导，不落库
 type PhoneBlacklist struct {
 	TenantModel
-	PhoneMD5   string `gorm:"type:char(32);not null;uniqueIndex:uk_tenant_phone_md5" json:"phone_md5"`
-	Source     string `gorm:"type:varchar(50);not null" json:"source"` // manual, import, api
-	Reason     string `gorm:"type:varchar(200)" json:"reason"`         // 加入黑名单原因
-	OperatorID uint64 `gorm:"index" json:"operator_id"`                // 操作人ID
-	IsActive   bool   `gorm:"default:true" json:"is_active"`           // 是否有效
+	ListID           uint64     `gorm:"not null;default:0;uniqueIndex:uk_tenant_identifier,priority:5" json:"list_id"` // 所属名单ID，0表示默认名单
+	IdentifierType   string     `gorm:"type:varchar(20);not null;default:'phone';uniqueIndex:uk_tenant_identifier,priority:2" json:"identifier_type"`
+	PhoneMD5         string     `gorm:"type:char(32);not null;default:'';uniqueIndex:uk_tenant_identifier,priority:3" json:"phone_md5"`
+	IdentifierSHA256 string     `gorm:"column:identifier_sha256;type:char(64);not null;default:'';uniqueIndex:uk_tenant_identifier,priority:4;index" json:"identifier_sha256"`
+	Source           string     `gorm:"type:varchar(50);not null" json:"source"`              // manual, import, api
+	Reason           string     `gorm:"type:varchar(200)" json:"reason"`                      // 加入黑名单原因
+	OperatorID       uint64     `gorm:"index" json:"operator_id"`                             // 操作人ID
+	Category         string     `gorm:"type:varchar(30);not null;default:''" json:"category"` // 风险分类
+	RiskScore        int        `gorm:"not null;default:100" json:"risk_score"`               // 风险分 1-100
+	IsActive         bool       `gorm:"default:true" json:"is_active"`                        // 是否有效
+	ExpiresAt        *time.Time `gorm:"index" json:"expires_at"`                              // 过期时间，为空表示永久有效
+	BatchID          uint64     `gorm:"not null;default:0;index" json:"batch_id"`             // 导入批次ID，0表示非批量导入
+
+	// TenantKey 映射到TenantModel的tenant_id列，不参与读写，仅使AutoMigrate建立的唯一键与迁移脚本一致以tenant_id开头
+	// TenantID定义在各模型共用的TenantModel中，无法单独为本表添加索引标签
+	TenantKey uint64 `gorm:"column:tenant_id;->:false;<-:false;uniqueIndex:uk_tenant_identifier,priority:1" json:"-"`
+
+	// ContributorTenantID 共享名单条目的贡献租户ID，0表示由系统管理员维护，不得返回给其他租户
+	ContributorTenantID uint64 `gorm:"not null;default:0;index" json:"contributor_tenant_id"`
+
+	// HitCount 累计命中次数，LastHitAt 最后命中时间，由后台任务从Redis汇总写入，存在最多约两分钟的延迟
+	HitCount  uint64     `gorm:"not null;default:0;index" json:"hit_count"`
+	LastHitAt *time.Time `gorm:"index" json:"last_hit_at"`
+
+	// RevivedAt 最近一次由失效、过期或已删除的记录恢复的时间，为空表示新建后未恢复过
+	// 批次回滚将该批次恢复的记录改回失效而不删除，保留原记录的ID、UUID和命中统计
+	RevivedAt *time.Time `json:"revived_at"`
+}
+
+// IsExpired 是否已过期
+func (pb *PhoneBlacklist) IsExpired(now time.Time) bool {
+	return pb.ExpiresAt != nil && !pb.ExpiresAt.After(now)
 }
 
 func (PhoneBlacklist) TableName() string {
@@ -25,6 +127,12 @@ func (pb *PhoneBlacklist) BeforeCreate(tx *gorm.DB) error {
 	if pb.UUID == "" {
 		pb.UUID = GenerateUUID()
 	}
+	if pb.IdentifierType == "" {
+		pb.IdentifierType = IdentifierTypePhone
+	}
+	if pb.RiskScore == 0 {
+		pb.RiskScore = DefaultRiskScore
+	}
 	// 从上下文获取租户ID
 	if pb.TenantID == 0 {
 		pb.TenantID = GetTenantIDFromContext(tx)
@@ -35,15 +143,33 @@ func (pb *PhoneBlacklist) BeforeCreate(tx *gorm.DB) error {
 // BlacklistApiCredential 黑名单API密钥模型
 type BlacklistApiCredential struct {
 	TenantModel
-	APIKey      string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"api_key"`
-	APISecret   string     `gorm:"type:varchar(128);not null" json:"api_secret"`
-	Name        string     `gorm:"type:varchar(100);not null" json:"name"`          // 密钥名称
-	Description string     `gorm:"type:text" json:"description"`                    // 描述
-	RateLimit   int        `gorm:"default:1000" json:"rate_limit"`                  // 每秒请求限制
-	IPWhitelist string     `gorm:"type:text" json:"ip_whitelist"`                   // IP白名单，逗号分隔，支持CIDR
-	Status      string     `gorm:"type:varchar(20);default:'active'" json:"status"` // active, inactive, suspended
-	LastUsedAt  *time.Time `json:"last_used_at"`                                    // 最后使用时间
-	ExpiresAt   *time.Time `json:"expires_at"`                                      // 过期时间
+	APIKey          string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"api_key"`
+	APISecret       string     `gorm:"type:varchar(128);not null" json:"api_secret"`
+	Name            string     `gorm:"type:varchar(100);not null" json:"name"`            // 密钥名称
+	Description     string     `gorm:"type:text" json:"description"`                      // 描述
+	RateLimit       int        `gorm:"default:1000" json:"rate_limit"`                    // 每秒请求限制
+	IPWhitelist     string     `gorm:"type:text" json:"ip_whitelist"`                     // IP白名单，逗号分隔，支持CIDR
+	Status          string     `gorm:"type:varchar(20);default:'active'" json:"status"`   // active, inactive, suspended
+	MinRiskScore    int        `gorm:"not null;default:0" json:"min_risk_score"`          // 仅返回风险分不低于该值的命中，0表示全部返回
+	Lists           string     `gorm:"type:varchar(500);not null" json:"lists"`           // 可查询的名单标识，逗号分隔，为空表示仅默认名单
+	LogSampleRate   float64    `gorm:"type:decimal(5,4);not null" json:"log_sample_rate"` // 详细查询日志采样率 0-1，命中和错误请求不受采样率限制
+	RangeQueryLimit int        `gorm:"not null;default:0" json:"range_query_limit"`       // 每日可查询的不同哈希前缀数，0表示不允许前缀范围查询
+	LastUsedAt      *time.Time `json:"last_used_at"`                                      // 最后使用时间
+	ExpiresAt       *time.Time `json:"expires_at"`                                        // 过期时间
+}
+
+// ListNames 可查询的名单标识，未授权任何名单时返回默认名单
+func (bac *BlacklistApiCredential) ListNames() []string {
+	names := make([]string, 0)
+	for _, name := range strings.Split(bac.Lists, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		names = append(names, DefaultListName)
+	}
+	return names
 }
 
 func (BlacklistApiCredential) TableName() string {
@@ -62,19 +188,496 @@ func (bac *BlacklistApiCredential) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// BlacklistQueryLog 黑名单查询日志模型（用于统计分析）
+// BlacklistQueryLog 黑名单查询日志模型，每个查询的标识一条记录，仅追加
+// 命中和错误请求全部记录，其余请求按API密钥的采样率记录
 type BlacklistQueryLog struct {
-	BaseModelWithoutUUID
-	TenantID     uint64 `gorm:"not null;index" json:"tenant_id"`
-	APIKey       string `gorm:"type:varchar(64);not null;index" json:"api_key"`
-	PhoneMD5     string `gorm:"type:char(32);not null" json:"phone_md5"`
-	IsHit        bool   `gorm:"default:false" json:"is_hit"`              // 是否命中黑名单
-	ResponseTime int    `gorm:"not null" json:"response_time"`            // 响应时间(毫秒)
-	ClientIP     string `gorm:"type:varchar(45)" json:"client_ip"`        // 客户端IP
-	UserAgent    string `gorm:"type:text" json:"user_agent"`              // 用户代理
-	RequestID    string `gorm:"type:varchar(64);index" json:"request_id"` // 请求ID
+	ID             uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenantID       uint64    `gorm:"not null;index:idx_tenant_created,priority:1;index:idx_tenant_hash,priority:1" json:"tenant_id"`
+	APIKey         string    `gorm:"type:varchar(64);not null;index" json:"api_key"`
+	RequestID      string    `gorm:"type:varchar(64);index" json:"request_id"` // 请求ID，批量查询的多条记录相同
+	IdentifierType string    `gorm:"type:varchar(20);not null;default:''" json:"identifier_type"`
+	HashType       string    `gorm:"type:varchar(20);not null;default:''" json:"hash_type"`
+	IdentifierHash string    `gorm:"type:varchar(64);not null;default:'';index:idx_tenant_hash,priority:2" json:"identifier_hash"` // 请求参数错误时为空
+	IsHit          bool      `gorm:"default:false" json:"is_hit"`                                                                  // 是否命中黑名单
+	StatusCode     int       `gorm:"not null;default:0" json:"status_code"`                                                        // HTTP状态码
+	ResponseTime   int       `gorm:"not null" json:"response_time"`                                                                // 响应时间(毫秒)
+	Sampled        bool      `gorm:"not null;default:false" json:"sampled"`                                                        // 是否按采样率抽中，命中和错误请求未抽中时也会记录
+	ClientIP       string    `gorm:"type:varchar(45)" json:"client_ip"`                                                            // 客户端IP
+	UserAgent      string    `gorm:"type:text" json:"user_agent"`                                                                  // 用户代理
+	CreatedAt      time.Time `gorm:"autoCreateTime;index:idx_tenant_created,priority:2" json:"created_at"`
 }
 
 func (BlacklistQueryLog) TableName() string {
 	return "blacklist_query_logs"
-}
\ No newline at end of file
+}
+
+// BlacklistTenantSetting 租户黑名单配置
+type BlacklistTenantSetting struct {
+	BaseModelWithoutUUID
+	TenantID        uint64 `gorm:"not null;uniqueIndex" json:"tenant_id"`
+	HashSalt        string `gorm:"type:varchar(64);not null" json:"-"`             // HMAC-SHA256格式使用的租户盐
+	RequireApproval bool   `gorm:"not null;default:false" json:"require_approval"` // 新增和删除条目是否需要审批
+
+	// SharedSubscribed 查询时是否同时查询共享名单，SharedContributor 是否可向共享名单贡献条目
+	SharedSubscribed  bool `gorm:"not null;default:false" json:"shared_subscribed"`
+	SharedContributor bool `gorm:"not null;default:false" json:"shared_contributor"`
+}
+
+func (BlacklistTenantSetting) TableName() string {
+	return "blacklist_tenant_settings"
+}
+
+// 变更申请类型
+const (
+	ChangeRequestActionCreate = "create" // 新增条目
+	ChangeRequestActionDelete = "delete" // 删除条目
+)
+
+// 变更申请状态
+const (
+	ChangeRequestStatusPending  = "pending"  // 待审批
+	ChangeRequestStatusApproved = "approved" // 已通过，变更已生效
+	ChangeRequestStatusRejected = "rejected" // 已驳回
+)
+
+// BlacklistChangeRequest 启用审批的租户新增或删除条目的申请，由申请人以外的用户审批通过后生效
+// 删除申请记录提交时目标条目的标识和风险信息，供审批人核对
+type BlacklistChangeRequest struct {
+	TenantModel
+	Action           string     `gorm:"type:varchar(20);not null" json:"action"`
+	Status           string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	BlacklistID      uint64     `gorm:"not null;default:0;index" json:"blacklist_id"` // 删除申请的目标条目，新增申请通过后为新建条目
+	ListID           uint64     `gorm:"not null;default:0" json:"list_id"`            // 新增申请的目标名单ID，0表示默认名单
+	IdentifierType   string     `gorm:"type:varchar(20);not null;default:'phone'" json:"identifier_type"`
+	PhoneMD5         string     `gorm:"type:char(32);not null;default:''" json:"phone_md5"`
+	IdentifierSHA256 string     `gorm:"column:identifier_sha256;type:char(64);not null;default:''" json:"identifier_sha256"`
+	Source           string     `gorm:"type:varchar(50);not null;default:''" json:"source"`
+	Reason           string     `gorm:"type:varchar(200)" json:"reason"`
+	Category         string     `gorm:"type:varchar(30);not null;default:''" json:"category"`
+	RiskScore        int        `gorm:"not null;default:0" json:"risk_score"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	RequestedBy      uint64     `gorm:"not null;index" json:"requested_by"`
+	ReviewedBy       uint64     `gorm:"not null;default:0" json:"reviewed_by"`
+	ReviewedAt       *time.Time `json:"reviewed_at"`
+	ReviewComment    string     `gorm:"type:varchar(500);not null;default:''" json:"review_comment"`
+}
+
+func (BlacklistChangeRequest) TableName() string {
+	return "blacklist_change_requests"
+}
+
+// BeforeCreate 创建前钩子
+func (r *BlacklistChangeRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.UUID == "" {
+		r.UUID = GenerateUUID()
+	}
+	if r.TenantID == 0 {
+		r.TenantID = GetTenantIDFromContext(tx)
+	}
+	return nil
+}
+
+// BlacklistAllowlistEntry 租户白名单条目，有效期内命中黑名单的标识按未命中返回并标记为已豁免
+// PhoneMD5和IdentifierSHA256至少有一个不为空，HMAC格式由SHA-256和租户盐推导
+type BlacklistAllowlistEntry struct {
+	TenantModel
+	IdentifierType   string     `gorm:"type:varchar(20);not null;default:'phone'" json:"identifier_type"`
+	PhoneMD5         string     `gorm:"type:char(32);not null;default:'';index" json:"phone_md5"`
+	IdentifierSHA256 string     `gorm:"column:identifier_sha256;type:char(64);not null;default:'';index" json:"identifier_sha256"`
+	Reason           string     `gorm:"type:varchar(200);not null" json:"reason"` // 加入白名单原因
+	OperatorID       uint64     `gorm:"not null;default:0" json:"operator_id"`    // 操作人ID
+	ExpiresAt        *time.Time `gorm:"index" json:"expires_at"`                  // 过期时间，为空表示永久有效
+}
+
+// IsExpired 是否已过期
+func (e *BlacklistAllowlistEntry) IsExpired(now time.Time) bool {
+	return e.ExpiresAt != nil && !e.ExpiresAt.After(now)
+}
+
+func (BlacklistAllowlistEntry) TableName() string {
+	return "blacklist_allowlist_entries"
+}
+
+// BeforeCreate 创建前钩子
+func (e *BlacklistAllowlistEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.UUID == "" {
+		e.UUID = GenerateUUID()
+	}
+	if e.IdentifierType == "" {
+		e.IdentifierType = IdentifierTypePhone
+	}
+	if e.TenantID == 0 {
+		e.TenantID = GetTenantIDFromContext(tx)
+	}
+	return nil
+}
+
+// 默认名单，未指定名单的条目归属该名单，不在blacklist_lists表中存储
+const (
+	DefaultListID   uint64 = 0
+	DefaultListName        = "default"
+)
+
+// listNamePattern 名单标识格式：小写字母开头，由小写字母、数字、下划线和连字符组成
+var listNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+// IsValidListName 检查名单标识格式，默认名单标识为保留字
+func IsValidListName(name string) bool {
+	return name != DefaultListName && listNamePattern.MatchString(name)
+}
+
+// BlacklistList 租户内的命名黑名单，条目归属于名单，API密钥按名单授权查询
+type BlacklistList struct {
+	TenantModel
+	Name        string `gorm:"type:varchar(50);not null" json:"name"`                    // 名单标识，租户内唯一，创建后不可修改
+	Description string `gorm:"type:varchar(200);not null;default:''" json:"description"` // 名单描述
+}
+
+func (BlacklistList) TableName() string {
+	return "blacklist_lists"
+}
+
+// BeforeCreate 创建前钩子
+func (l *BlacklistList) BeforeCreate(tx *gorm.DB) error {
+	if l.UUID == "" {
+		l.UUID = GenerateUUID()
+	}
+	if l.TenantID == 0 {
+		l.TenantID = GetTenantIDFromContext(tx)
+	}
+	return nil
+}
+
+// SystemTenantID 系统租户ID，系统管理员所在的租户
+const SystemTenantID uint64 = 0
+
+// SharedTenantID 共享名单条目的归属ID，为不对应任何租户的保留值
+// 租户的黑名单管理接口只写入登录租户自己的数据，共享名单只能通过贡献和撤回接口维护；系统管理员贡献的条目贡献租户为0
+const SharedTenantID uint64 = math.MaxUint64
+
+// 命中来源
+const (
+	HitSourceTenant = "tenant" // 仅命中租户自己的名单
+	HitSourceShared = "shared" // 仅命中共享名单
+	HitSourceBoth   = "both"   // 同时命中租户名单和共享名单
+)
+
+// 导入任务状态
+const (
+	ImportJobStatusPending   = "pending"   // 等待执行
+	ImportJobStatusRunning   = "running"   // 执行中
+	ImportJobStatusCompleted = "completed" // 已完成
+	ImportJobStatusFailed    = "failed"    // 执行失败
+	ImportJobStatusCancelled = "cancelled" // 已取消
+)
+
+// 导入文件内容类型
+const (
+	ImportValueTypePhone  = "phone"  // 明文手机号，服务端规范化后计算哈希
+	ImportValueTypeMD5    = "md5"    // MD5哈希
+	ImportValueTypeSHA256 = "sha256" // SHA-256哈希
+)
+
+// BlacklistImportJob 黑名单异步导入任务
+// ProcessedRows为已提交的数据行数（不含表头和空行），与条目写入在同一事务中更新，重启后从该位置继续
+type BlacklistImportJob struct {
+	TenantModel
+	Status          string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	FileName        string     `gorm:"type:varchar(255);not null" json:"file_name"`
+	FileSize        int64      `gorm:"not null" json:"file_size"`
+	ValueType       string     `gorm:"type:varchar(20);not null;default:'phone'" json:"value_type"`
+	IdentifierType  string     `gorm:"type:varchar(20);not null;default:'phone'" json:"identifier_type"`
+	ColumnIndex     int        `gorm:"not null;default:0" json:"column_index"` // 从0开始
+	ColumnName      string     `gorm:"type:varchar(100);not null;default:''" json:"column_name"`
+	Source          string     `gorm:"type:varchar(50);not null" json:"source"`
+	Reason          string     `gorm:"type:varchar(200)" json:"reason"`
+	Category        string     `gorm:"type:varchar(30);not null;default:''" json:"category"`
+	RiskScore       int        `gorm:"not null;default:0" json:"risk_score"`
+	EntryExpiresAt  *time.Time `json:"entry_expires_at"` // 导入条目的过期时间
+	OperatorID      uint64     `gorm:"index" json:"operator_id"`
+	BatchID         uint64     `gorm:"not null;default:0" json:"-"`          // 导入批次ID，任务写入的条目归属该批次
+	TotalRows       int64      `gorm:"not null;default:0" json:"total_rows"` // 开始执行后统计，0表示尚未统计
+	ProcessedRows   int64      `gorm:"not null;default:0" json:"processed_rows"`
+	AcceptedCount   int64      `gorm:"not null;default:0" json:"accepted_count"`
+	DuplicateCount  int64      `gorm:"not null;default:0" json:"duplicate_count"`
+	InvalidCount    int64      `gorm:"not null;default:0" json:"invalid_count"`
+	BackfilledCount int64      `gorm:"not null;default:0" json:"backfilled_count"`
+	RowErrors       string     `gorm:"type:text" json:"-"` // 无效行样例，JSON数组
+	ErrorMessage    string     `gorm:"type:varchar(500);not null;default:''" json:"error_message"`
+	CancelRequested bool       `gorm:"not null;default:false" json:"cancel_requested"`
+	WorkerID        string     `gorm:"type:varchar(64);not null;default:''" json:"-"`
+	HeartbeatAt     *time.Time `gorm:"index" json:"-"`
+	StartedAt       *time.Time `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+}
+
+func (BlacklistImportJob) TableName() string {
+	return "blacklist_import_jobs"
+}
+
+// IsFinished 是否已结束
+func (j *BlacklistImportJob) IsFinished() bool {
+	switch j.Status {
+	case ImportJobStatusCompleted, ImportJobStatusFailed, ImportJobStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// BeforeCreate 创建前钩子
+func (j *BlacklistImportJob) BeforeCreate(tx *gorm.DB) error {
+	if j.UUID == "" {
+		j.UUID = GenerateUUID()
+	}
+	if j.TenantID == 0 {
+		j.TenantID = GetTenantIDFromContext(tx)
+	}
+	return nil
+}
+
+// BlacklistImportJobFile 导入任务上传文件的分片，任务结束后删除
+type BlacklistImportJobFile struct {
+	ID        uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	JobID     uint64    `gorm:"not null;uniqueIndex:uk_job_seq,priority:1" json:"job_id"`
+	Seq       int       `gorm:"not null;uniqueIndex:uk_job_seq,priority:2" json:"seq"`
+	Data      []byte    `gorm:"type:mediumblob;not null" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (BlacklistImportJobFile) TableName() string {
+	return "blacklist_import_job_files"
+}
+
+// 导入批次方式
+const (
+	ImportBatchMethodAPI  = "api"  // 批量导入接口
+	ImportBatchMethodFile = "file" // 文件导入
+	ImportBatchMethodJob  = "job"  // 异步导入任务
+)
+
+// 导入批次状态
+const (
+	ImportBatchStatusImporting   = "importing"    // 导入中
+	ImportBatchStatusCompleted   = "completed"    // 导入结束
+	ImportBatchStatusRollingBack = "rolling_back" // 回滚中，中断后可重新回滚
+	ImportBatchStatusRolledBack  = "rolled_back"  // 已回滚
+)
+
+// BlacklistImportBatch 黑名单导入批次，每次导入新增的条目通过BatchID归属该批次，可整批回滚
+type BlacklistImportBatch struct {
+	TenantModel
+	Method          string     `gorm:"type:varchar(20);not null" json:"method"`
+	Status          string     `gorm:"type:varchar(20);not null;default:'importing';index" json:"status"`
+	IdentifierType  string     `gorm:"type:varchar(20);not null;default:'phone'" json:"identifier_type"`
+	Source          string     `gorm:"type:varchar(50);not null" json:"source"`
+	Reason          string     `gorm:"type:varchar(200)" json:"reason"`
+	FileName        string     `gorm:"type:varchar(255);not null;default:''" json:"file_name"`
+	ImportJobUUID   string     `gorm:"column:import_job_uuid;type:varchar(36);not null;default:''" json:"import_job_uuid"` // 异步导入任务ID
+	OperatorID      uint64     `gorm:"index" json:"operator_id"`
+	EntryCount      int64      `gorm:"not null;default:0" json:"entry_count"`       // 新增的条目数
+	RolledBackCount int64      `gorm:"not null;default:0" json:"rolled_back_count"` // 回滚时删除的条目数
+	RolledBackBy    uint64     `gorm:"not null;default:0" json:"rolled_back_by"`
+	RolledBackAt    *time.Time `json:"rolled_back_at"`
+}
+
+func (BlacklistImportBatch) TableName() string {
+	return "blacklist_import_batches"
+}
+
+// BeforeCreate 创建前钩子
+func (b *BlacklistImportBatch) BeforeCreate(tx *gorm.DB) error {
+	if b.UUID == "" {
+		b.UUID = GenerateUUID()
+	}
+	if b.TenantID == 0 {
+		b.TenantID = GetTenantIDFromContext(tx)
+	}
+	return nil
+}
+
+// 偏差检查触发方式
+const (
+	DriftTriggerScheduled = "scheduled" // 后台定期检查
+	DriftTriggerManual    = "manual"    // 管理接口手动检查
+)
+
+// BlacklistDriftReport 租户Redis与数据库的偏差检查记录
+type BlacklistDriftReport struct {
+	BaseModelWithoutUUID
+	TenantID     uint64 `gorm:"not null;index" json:"tenant_id"`
+	TriggeredBy  string `gorm:"type:varchar(20);not null" json:"triggered_by"`
+	ActiveCount  int64  `gorm:"not null;default:0" json:"active_count"`  // 数据库中的有效条目数
+	MissingCount int64  `gorm:"not null;default:0" json:"missing_count"` // 数据库中有效但Redis缺失的成员数
+	ExtraCount   int64  `gorm:"not null;default:0" json:"extra_count"`   // Redis中多余的成员数
+	Repaired     bool   `gorm:"not null;default:false" json:"repaired"`  // 是否已按数据库修复
+	ErrorMessage string `gorm:"type:varchar(500);not null;default:''" json:"error_message"`
+	DurationMs   int64  `gorm:"not null;default:0" json:"duration_ms"`
+}
+
+func (BlacklistDriftReport) TableName() string {
+	return "blacklist_drift_reports"
+}
+
+// 查询统计汇总的时段粒度
+const (
+	QueryStatPeriodHour = "hour"
+	QueryStatPeriodDay  = "day"
+)
+
+// BlacklistQueryStat 查询统计汇总，由Redis计数器定期汇总写入，APIKey为空的记录为租户汇总
+type BlacklistQueryStat struct {
+	ID                uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenantID          uint64    `gorm:"not null;uniqueIndex:idx_tenant_key_period,priority:1" json:"tenant_id"`
+	APIKey            string    `gorm:"type:varchar(64);not null;default:'';uniqueIndex:idx_tenant_key_period,priority:2" json:"api_key"`
+	PeriodStart       time.Time `gorm:"type:datetime;not null;uniqueIndex:idx_tenant_key_period,priority:3;index" json:"period_start"` // 统计时段开始时间
+	TotalQueries      int64     `gorm:"not null;default:0" json:"total_queries"`
+	HitCount          int64     `gorm:"not null;default:0" json:"hit_count"`
+	LatencySum        int64     `gorm:"not null;default:0" json:"latency_sum"`         // 延迟总和(毫秒)
+	LatencyCount      int64     `gorm:"not null;default:0" json:"latency_count"`       // 记录延迟的查询次数
+	PeakMinuteQueries int64     `gorm:"not null;default:0" json:"peak_minute_queries"` // 时段内单分钟最大查询次数
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// BlacklistQueryStatHourly 查询小时统计
+type BlacklistQueryStatHourly struct {
+	BlacklistQueryStat
+}
+
+func (BlacklistQueryStatHourly) TableName() string {
+	return "blacklist_query_stats_hourly"
+}
+
+// BlacklistQueryStatDaily 查询日统计，由小时统计按自然日汇总
+type BlacklistQueryStatDaily struct {
+	BlacklistQueryStat
+}
+
+func (BlacklistQueryStatDaily) TableName() string {
+	return "blacklist_query_stats_daily"
+}
+
+// Webhook事件类型
+const (
+	WebhookEventHit             = "blacklist.hit"               // 查询命中黑名单
+	WebhookEventEntryCreated    = "blacklist.entry.created"     // 新增条目
+	WebhookEventEntryDeleted    = "blacklist.entry.deleted"     // 删除条目
+	WebhookEventBatchImported   = "blacklist.batch.imported"    // 导入批次结束
+	WebhookEventBatchRolledBack = "blacklist.batch.rolled_back" // 导入批次已回滚
+)
+
+// WebhookEventTypes 支持订阅的全部事件类型
+var WebhookEventTypes = []string{
+	WebhookEventHit,
+	WebhookEventEntryCreated,
+	WebhookEventEntryDeleted,
+	WebhookEventBatchImported,
+	WebhookEventBatchRolledBack,
+}
+
+// IsValidWebhookEventType 是否为支持订阅的事件类型
+func IsValidWebhookEventType(eventType string) bool {
+	for _, t := range WebhookEventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhook订阅状态
+const (
+	WebhookStatusActive   = "active"   // 启用
+	WebhookStatusDisabled = "disabled" // 停用，不再产生新的投递
+)
+
+// BlacklistWebhook 租户的Webhook订阅
+type BlacklistWebhook struct {
+	TenantModel
+	Name        string `gorm:"type:varchar(100);not null" json:"name"`
+	URL         string `gorm:"type:varchar(500);not null" json:"url"`
+	Secret      string `gorm:"type:varchar(64);not null" json:"-"`            // 签名密钥，仅创建和轮换时返回
+	EventTypes  string `gorm:"type:varchar(255);not null" json:"event_types"` // 订阅的事件类型，逗号分隔
+	Status      string `gorm:"type:varchar(20);not null;default:'active'" json:"status"`
+	Description string `gorm:"type:varchar(255);not null;default:''" json:"description"`
+	CreatedBy   uint64 `gorm:"not null;default:0" json:"created_by"`
+}
+
+func (BlacklistWebhook) TableName() string {
+	return "blacklist_webhooks"
+}
+
+// EventTypeList 订阅的事件类型列表
+func (w *BlacklistWebhook) EventTypeList() []string {
+	if w.EventTypes == "" {
+		return nil
+	}
+	return strings.Split(w.EventTypes, ",")
+}
+
+// Subscribes 是否订阅了指定事件类型
+func (w *BlacklistWebhook) Subscribes(eventType string) bool {
+	for _, t := range w.EventTypeList() {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// BeforeCreate 创建前钩子
+func (w *BlacklistWebhook) BeforeCreate(tx *gorm.DB) error {
+	if w.UUID == "" {
+		w.UUID = GenerateUUID()
+	}
+	if w.TenantID == 0 {
+		w.TenantID = GetTenantIDFromContext(tx)
+	}
+	return nil
+}
+
+// Webhook投递状态
+const (
+	WebhookDeliveryStatusPending   = "pending"   // 等待投递，包括等待重试
+	WebhookDeliveryStatusSucceeded = "succeeded" // 投递成功
+	WebhookDeliveryStatusDead      = "dead"      // 超过最大投递次数或订阅已失效，进入死信，可手动重新投递
+)
+
+// BlacklistWebhookDelivery 一个事件向一个订阅的投递，Payload在重试时保持不变
+type BlacklistWebhookDelivery struct {
+	BaseModelWithoutUUID
+	TenantID       uint64     `gorm:"not null;index:idx_tenant_webhook,priority:1" json:"tenant_id"`
+	WebhookID      uint64     `gorm:"not null;index:idx_tenant_webhook,priority:2" json:"webhook_id"`
+	EventID        string     `gorm:"type:char(36);not null;index" json:"event_id"` // 事件ID，重试时不变，接收方据此去重
+	EventType      string     `gorm:"type:varchar(50);not null" json:"event_type"`
+	Payload        string     `gorm:"type:mediumtext;not null" json:"-"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending';index:idx_status_next,priority:1" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time `gorm:"index:idx_status_next,priority:2" json:"next_attempt_at"`
+	LastStatusCode int        `gorm:"not null;default:0" json:"last_status_code"`
+	LastError      string     `gorm:"type:varchar(500);not null;default:''" json:"last_error"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	WorkerID       string     `gorm:"type:varchar(64);not null;default:''" json:"-"`
+	LeaseUntil     *time.Time `json:"-"` // 投递中的租约，实例退出后到期可被其他实例领取
+}
+
+func (BlacklistWebhookDelivery) TableName() string {
+	return "blacklist_webhook_deliveries"
+}
+
+// BlacklistWebhookAttempt 单次投递尝试的记录
+type BlacklistWebhookAttempt struct {
+	ID         uint64    `gorm:"primaryKey;autoIncrement" json:"id"`
+	DeliveryID uint64    `gorm:"not null;index" json:"delivery_id"`
+	Attempt    int       `gorm:"not null" json:"attempt"`
+	StatusCode int       `gorm:"not null;default:0" json:"status_code"` // 0表示未收到响应
+	Error      string    `gorm:"type:varchar(500);not null;default:''" json:"error"`
+	DurationMs int64     `gorm:"not null;default:0" json:"duration_ms"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (BlacklistWebhookAttempt) TableName() string {
+	return "blacklist_webhook_attempts"
+}
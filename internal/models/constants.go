@@ -46,11 +46,15 @@ const (
 
 // Audit log actions
 const (
-	AuditActionGrant  = "grant"  // 授予权限
-	AuditActionRevoke = "revoke" // 撤销权限
-	AuditActionCreate = "create" // 创建
-	AuditActionUpdate = "update" // 更新
-	AuditActionDelete = "delete" // 删除
+	AuditActionGrant   = "grant"   // 授予权限
+	AuditActionRevoke  = "revoke"  // 撤销权限
+	AuditActionCreate  = "create"  // 创建
+	AuditActionUpdate  = "update"  // 更新
+	AuditActionDelete  = "delete"  // 删除
+	AuditActionExport  = "export"  // 导出
+	AuditActionSubmit  = "submit"  // 提交申请
+	AuditActionApprove = "approve" // 审批通过
+	AuditActionReject  = "reject"  // 驳回
 )
 
 // Audit log target types
@@ -58,6 +62,17 @@ const (
 	AuditTargetUser       = "user"
 	AuditTargetRole       = "role"
 	AuditTargetPermission = "permission"
+	AuditTargetBlacklist  = "blacklist"
+	// AuditTargetBlacklistChange 黑名单变更申请，TargetID为申请ID
+	AuditTargetBlacklistChange = "blacklist_change_request"
+	// AuditTargetBlacklistAllowlist 黑名单白名单条目，TargetID为条目ID
+	AuditTargetBlacklistAllowlist = "blacklist_allowlist"
+	// AuditTargetBlacklistList 黑名单名单，TargetID为名单ID
+	AuditTargetBlacklistList = "blacklist_list"
+	// AuditTargetApiCredential API密钥，TargetID为密钥ID
+	AuditTargetApiCredential = "api_credential"
+	// AuditTargetBlacklistShared 共享名单条目，TargetID为条目ID，记录在贡献租户下
+	AuditTargetBlacklistShared = "blacklist_shared_entry"
 )
 
 // User status
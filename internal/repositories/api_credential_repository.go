@@ -15,8 +15,10 @@ type ApiCredentialRepository interface {
 	Create(ctx context.Context, credential *models.BlacklistApiCredential) error
 	GetByAPIKey(ctx context.Context, apiKey string) (*models.BlacklistApiCredential, error)
 	GetByTenant(ctx context.Context, tenantID uint64) ([]*models.BlacklistApiCredential, error)
+	GetByID(ctx context.Context, tenantID, id uint64) (*models.BlacklistApiCredential, error)
 	Update(ctx context.Context, credential *models.BlacklistApiCredential) error
 	UpdateLastUsedAt(ctx context.Context, apiKey string) error
+	UpdateLists(ctx context.Context, id uint64, lists string) error
 	Delete(ctx context.Context, id uint64) error
 	GetActiveByAPIKey(ctx context.Context, apiKey string) (*models.BlacklistApiCredential, error)
 }
@@ -60,6 +62,18 @@ func (r *apiCredentialRepository) GetByTenant(ctx context.Context, tenantID uint
 	return credentials, err
 }
 
+// GetByID 根据ID获取租户的API密钥记录
+func (r *apiCredentialRepository) GetByID(ctx context.Context, tenantID, id uint64) (*models.BlacklistApiCredential, error) {
+	var credential models.BlacklistApiCredential
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&credential).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
 // Update 更新API密钥记录
 func (r *apiCredentialRepository) Update(ctx context.Context, credential *models.BlacklistApiCredential) error {
 	return r.db.WithContext(ctx).Save(credential).Error
@@ -73,6 +87,13 @@ func (r *apiCredentialRepository) UpdateLastUsedAt(ctx context.Context, apiKey s
 		Update("last_used_at", now).Error
 }
 
+// UpdateLists 更新API密钥可查询的名单
+func (r *apiCredentialRepository) UpdateLists(ctx context.Context, id uint64, lists string) error {
+	return r.db.WithContext(ctx).Model(&models.BlacklistApiCredential{}).
+		Where("id = ?", id).
+		Update("lists", lists).Error
+}
+
 // Delete 删除API密钥记录（软删除）
 func (r *apiCredentialRepository) Delete(ctx context.Context, id uint64) error {
 	return r.db.WithContext(ctx).Delete(&models.BlacklistApiCredential{}, id).Error
@@ -0,0 +1,170 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist allowlist repository.
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+)
+
+// BlacklistAllowlistRepository 黑名单白名单仓储接口
+type BlacklistAllowlistRepository interface {
+	Create(ctx context.Context, entry *models.BlacklistAllowlistEntry) error
+	GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistAllowlistEntry, error)
+	GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.BlacklistAllowlistEntry, int64, error)
+	Update(ctx context.Context, entry *models.BlacklistAllowlistEntry) error
+	Delete(ctx context.Context, id uint64) error
+	GetActiveByTenant(ctx context.Context, tenantID uint64) ([]*models.BlacklistAllowlistEntry, error)
+	GetActiveByIdentifier(ctx context.Context, tenantID uint64, identifierType, phoneMD5, identifierSHA256 string) (*models.BlacklistAllowlistEntry, error)
+	GetActiveByTenantAndHashes(ctx context.Context, tenantID uint64, identifierType, hashType string, hashList []string) ([]*models.BlacklistAllowlistEntry, error)
+	GetIncompleteByHashes(ctx context.Context, tenantIDs []uint64, identifierType string, phoneMD5List, sha256List []string) ([]*models.BlacklistAllowlistEntry, error)
+	UpdateHashes(ctx context.Context, entry *models.BlacklistAllowlistEntry) error
+}
+
+// blacklistAllowlistRepository 黑名单白名单仓储实现
+type blacklistAllowlistRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistAllowlistRepository 创建黑名单白名单仓储
+func NewBlacklistAllowlistRepository(db *gorm.DB) BlacklistAllowlistRepository {
+	return &blacklistAllowlistRepository{
+		db: db,
+	}
+}
+
+// Create 创建白名单条目
+func (r *blacklistAllowlistRepository) Create(ctx context.Context, entry *models.BlacklistAllowlistEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// GetByUUID 根据UUID获取租户的白名单条目
+func (r *blacklistAllowlistRepository) GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistAllowlistEntry, error) {
+	var entry models.BlacklistAllowlistEntry
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND uuid = ?", tenantID, uuid).
+		First(&entry).Error
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetByTenant 分页获取租户的白名单条目，包含已过期的条目，按创建时间倒序
+func (r *blacklistAllowlistRepository) GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.BlacklistAllowlistEntry, int64, error) {
+	var entries []*models.BlacklistAllowlistEntry
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.BlacklistAllowlistEntry{}).
+		Where("tenant_id = ?", tenantID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&entries).Error
+	return entries, total, err
+}
+
+// Update 更新白名单条目的原因和过期时间，过期时间为空表示永久有效
+func (r *blacklistAllowlistRepository) Update(ctx context.Context, entry *models.BlacklistAllowlistEntry) error {
+	return r.db.WithContext(ctx).Model(entry).
+		Select("reason", "operator_id", "expires_at").
+		Updates(entry).Error
+}
+
+// UpdateHashes 更新白名单条目的MD5和SHA-256，用于补全只有一种格式的条目
+func (r *blacklistAllowlistRepository) UpdateHashes(ctx context.Context, entry *models.BlacklistAllowlistEntry) error {
+	return r.db.WithContext(ctx).Model(entry).
+		Select("phone_md5", "identifier_sha256").
+		Updates(entry).Error
+}
+
+// Delete 删除白名单条目
+func (r *blacklistAllowlistRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.BlacklistAllowlistEntry{}, id).Error
+}
+
+// GetActiveByTenant 获取租户所有未过期的白名单条目
+func (r *blacklistAllowlistRepository) GetActiveByTenant(ctx context.Context, tenantID uint64) ([]*models.BlacklistAllowlistEntry, error) {
+	var entries []*models.BlacklistAllowlistEntry
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetActiveByIdentifier 获取与给定MD5或SHA-256相同的未过期白名单条目，用于创建前查重
+func (r *blacklistAllowlistRepository) GetActiveByIdentifier(ctx context.Context, tenantID uint64, identifierType, phoneMD5, identifierSHA256 string) (*models.BlacklistAllowlistEntry, error) {
+	query := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND identifier_type = ?", tenantID, identifierType).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	switch {
+	case phoneMD5 != "" && identifierSHA256 != "":
+		query = query.Where("phone_md5 = ? OR identifier_sha256 = ?", phoneMD5, identifierSHA256)
+	case phoneMD5 != "":
+		query = query.Where("phone_md5 = ?", phoneMD5)
+	default:
+		query = query.Where("identifier_sha256 = ?", identifierSHA256)
+	}
+
+	var entry models.BlacklistAllowlistEntry
+	if err := query.First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetActiveByTenantAndHashes 批量获取指定哈希格式的未过期白名单条目，HMAC格式不落库，返回ErrUnsupportedHashType
+func (r *blacklistAllowlistRepository) GetActiveByTenantAndHashes(ctx context.Context, tenantID uint64, identifierType, hashType string, hashList []string) ([]*models.BlacklistAllowlistEntry, error) {
+	var entries []*models.BlacklistAllowlistEntry
+	if len(hashList) == 0 {
+		return entries, nil
+	}
+
+	column, err := hashColumn(hashType)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.WithContext(ctx).
+		Where("tenant_id = ? AND identifier_type = ?", tenantID, identifierType).
+		Where(column+" IN ?", hashList).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetIncompleteByHashes 获取只有MD5且MD5在phoneMD5List中，或只有SHA-256且SHA-256在sha256List中的未过期白名单条目
+// tenantIDs为空时不按租户过滤
+func (r *blacklistAllowlistRepository) GetIncompleteByHashes(ctx context.Context, tenantIDs []uint64, identifierType string, phoneMD5List, sha256List []string) ([]*models.BlacklistAllowlistEntry, error) {
+	var entries []*models.BlacklistAllowlistEntry
+	if len(phoneMD5List) == 0 && len(sha256List) == 0 {
+		return entries, nil
+	}
+
+	query := r.db.WithContext(ctx).
+		Where("identifier_type = ?", identifierType).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	if len(tenantIDs) > 0 {
+		query = query.Where("tenant_id IN ?", tenantIDs)
+	}
+
+	incomplete := r.db.Where("identifier_sha256 = '' AND phone_md5 IN ?", phoneMD5List)
+	if len(phoneMD5List) == 0 {
+		incomplete = r.db.Where("phone_md5 = '' AND identifier_sha256 IN ?", sha256List)
+	} else if len(sha256List) > 0 {
+		incomplete = incomplete.Or("phone_md5 = '' AND identifier_sha256 IN ?", sha256List)
+	}
+
+	err := query.Where(incomplete).Find(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,119 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist change request repository.
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+)
+
+// BlacklistChangeRequestRepository 黑名单变更申请仓储接口
+type BlacklistChangeRequestRepository interface {
+	Create(ctx context.Context, request *models.BlacklistChangeRequest) error
+	GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistChangeRequest, error)
+	GetByTenant(ctx context.Context, tenantID uint64, status string, offset, limit int) ([]*models.BlacklistChangeRequest, int64, error)
+	ExistsPendingDelete(ctx context.Context, tenantID, blacklistID uint64) (bool, error)
+	Review(ctx context.Context, id uint64, status string, reviewerID uint64, comment string) (bool, error)
+	Reopen(ctx context.Context, id uint64) error
+	SetBlacklistID(ctx context.Context, id, blacklistID uint64) error
+}
+
+// blacklistChangeRequestRepository 黑名单变更申请仓储实现
+type blacklistChangeRequestRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistChangeRequestRepository 创建黑名单变更申请仓储
+func NewBlacklistChangeRequestRepository(db *gorm.DB) BlacklistChangeRequestRepository {
+	return &blacklistChangeRequestRepository{
+		db: db,
+	}
+}
+
+// Create 创建变更申请
+func (r *blacklistChangeRequestRepository) Create(ctx context.Context, request *models.BlacklistChangeRequest) error {
+	return r.db.WithContext(ctx).Create(request).Error
+}
+
+// GetByUUID 根据UUID获取租户的变更申请
+func (r *blacklistChangeRequestRepository) GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistChangeRequest, error) {
+	var request models.BlacklistChangeRequest
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND uuid = ?", tenantID, uuid).
+		First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetByTenant 分页获取租户的变更申请，status为空时不过滤状态，按创建时间倒序
+func (r *blacklistChangeRequestRepository) GetByTenant(ctx context.Context, tenantID uint64, status string, offset, limit int) ([]*models.BlacklistChangeRequest, int64, error) {
+	var requests []*models.BlacklistChangeRequest
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.BlacklistChangeRequest{}).
+		Where("tenant_id = ?", tenantID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&requests).Error
+	return requests, total, err
+}
+
+// ExistsPendingDelete 条目是否已有待审批的删除申请
+func (r *blacklistChangeRequestRepository) ExistsPendingDelete(ctx context.Context, tenantID, blacklistID uint64) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.BlacklistChangeRequest{}).
+		Where("tenant_id = ? AND blacklist_id = ? AND action = ? AND status = ?",
+			tenantID, blacklistID, models.ChangeRequestActionDelete, models.ChangeRequestStatusPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Review 将待审批的申请标记为通过或驳回，返回false表示申请已被处理
+func (r *blacklistChangeRequestRepository) Review(ctx context.Context, id uint64, status string, reviewerID uint64, comment string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.BlacklistChangeRequest{}).
+		Where("id = ? AND status = ?", id, models.ChangeRequestStatusPending).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"reviewed_by":    reviewerID,
+			"reviewed_at":    time.Now(),
+			"review_comment": comment,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Reopen 审批通过后变更未能生效时恢复为待审批
+func (r *blacklistChangeRequestRepository) Reopen(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Model(&models.BlacklistChangeRequest{}).
+		Where("id = ? AND status = ?", id, models.ChangeRequestStatusApproved).
+		Updates(map[string]interface{}{
+			"status":         models.ChangeRequestStatusPending,
+			"reviewed_by":    0,
+			"reviewed_at":    nil,
+			"review_comment": "",
+		}).Error
+}
+
+// SetBlacklistID 记录新增申请通过后创建的条目
+func (r *blacklistChangeRequestRepository) SetBlacklistID(ctx context.Context, id, blacklistID uint64) error {
+	return r.db.WithContext(ctx).Model(&models.BlacklistChangeRequest{}).
+		Where("id = ?", id).
+		Update("blacklist_id", blacklistID).Error
+}
@@ -0,0 +1,64 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist drift report repository.
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+)
+
+// BlacklistDriftReportRepository 黑名单Redis偏差检查记录仓储接口
+type BlacklistDriftReportRepository interface {
+	Create(ctx context.Context, report *models.BlacklistDriftReport) error
+	GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.BlacklistDriftReport, int64, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// blacklistDriftReportRepository 黑名单Redis偏差检查记录仓储实现
+type blacklistDriftReportRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistDriftReportRepository 创建黑名单Redis偏差检查记录仓储
+func NewBlacklistDriftReportRepository(db *gorm.DB) BlacklistDriftReportRepository {
+	return &blacklistDriftReportRepository{
+		db: db,
+	}
+}
+
+// Create 创建检查记录
+func (r *blacklistDriftReportRepository) Create(ctx context.Context, report *models.BlacklistDriftReport) error {
+	return r.db.WithContext(ctx).Create(report).Error
+}
+
+// GetByTenant 分页获取租户的检查记录，按检查时间倒序
+func (r *blacklistDriftReportRepository) GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.BlacklistDriftReport, int64, error) {
+	var reports []*models.BlacklistDriftReport
+	var total int64
+
+	err := r.db.WithContext(ctx).Model(&models.BlacklistDriftReport{}).
+		Where("tenant_id = ?", tenantID).
+		Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&reports).Error
+	return reports, total, err
+}
+
+// DeleteBefore 物理删除指定时间之前的检查记录
+func (r *blacklistDriftReportRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("created_at < ?", before).
+		Delete(&models.BlacklistDriftReport{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,114 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist import batch repository.
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+)
+
+// BlacklistImportBatchRepository 黑名单导入批次仓储接口
+type BlacklistImportBatchRepository interface {
+	Create(ctx context.Context, batch *models.BlacklistImportBatch) error
+	GetByID(ctx context.Context, id uint64) (*models.BlacklistImportBatch, error)
+	GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistImportBatch, error)
+	GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.BlacklistImportBatch, int64, error)
+	Complete(ctx context.Context, id uint64, entryCount int64) error
+	StartRollback(ctx context.Context, id uint64) (bool, error)
+	FinishRollback(ctx context.Context, id uint64, operatorID uint64) error
+}
+
+// blacklistImportBatchRepository 黑名单导入批次仓储实现
+type blacklistImportBatchRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistImportBatchRepository 创建黑名单导入批次仓储
+func NewBlacklistImportBatchRepository(db *gorm.DB) BlacklistImportBatchRepository {
+	return &blacklistImportBatchRepository{
+		db: db,
+	}
+}
+
+// Create 创建导入批次
+func (r *blacklistImportBatchRepository) Create(ctx context.Context, batch *models.BlacklistImportBatch) error {
+	return r.db.WithContext(ctx).Create(batch).Error
+}
+
+// GetByID 根据ID获取导入批次
+func (r *blacklistImportBatchRepository) GetByID(ctx context.Context, id uint64) (*models.BlacklistImportBatch, error) {
+	var batch models.BlacklistImportBatch
+	err := r.db.WithContext(ctx).First(&batch, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetByUUID 根据UUID获取租户的导入批次
+func (r *blacklistImportBatchRepository) GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistImportBatch, error) {
+	var batch models.BlacklistImportBatch
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND uuid = ?", tenantID, uuid).
+		First(&batch).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetByTenant 分页获取租户的导入批次，按创建时间倒序
+func (r *blacklistImportBatchRepository) GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.BlacklistImportBatch, int64, error) {
+	var batches []*models.BlacklistImportBatch
+	var total int64
+
+	err := r.db.WithContext(ctx).Model(&models.BlacklistImportBatch{}).
+		Where("tenant_id = ?", tenantID).
+		Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&batches).Error
+	return batches, total, err
+}
+
+// Complete 标记导入结束并记录新增条目数
+func (r *blacklistImportBatchRepository) Complete(ctx context.Context, id uint64, entryCount int64) error {
+	return r.db.WithContext(ctx).Model(&models.BlacklistImportBatch{}).
+		Where("id = ? AND status = ?", id, models.ImportBatchStatusImporting).
+		Updates(map[string]interface{}{
+			"status":      models.ImportBatchStatusCompleted,
+			"entry_count": entryCount,
+		}).Error
+}
+
+// StartRollback 将导入结束或上次回滚中断的批次标记为回滚中，返回false表示批次状态不允许回滚
+func (r *blacklistImportBatchRepository) StartRollback(ctx context.Context, id uint64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.BlacklistImportBatch{}).
+		Where("id = ? AND status IN ?", id, []string{models.ImportBatchStatusCompleted, models.ImportBatchStatusRollingBack}).
+		Update("status", models.ImportBatchStatusRollingBack)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// FinishRollback 标记批次已回滚
+func (r *blacklistImportBatchRepository) FinishRollback(ctx context.Context, id uint64, operatorID uint64) error {
+	return r.db.WithContext(ctx).Model(&models.BlacklistImportBatch{}).
+		Where("id = ? AND status = ?", id, models.ImportBatchStatusRollingBack).
+		Updates(map[string]interface{}{
+			"status":         models.ImportBatchStatusRolledBack,
+			"rolled_back_by": operatorID,
+			"rolled_back_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,329 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist asynchronous import job repository.
+package repositories
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrImportJobLeaseLost 导入任务已被其他实例接管或状态已变更
+var ErrImportJobLeaseLost = errors.New("import job lease lost")
+
+// BlacklistImportJobRepository 黑名单导入任务仓储接口
+type BlacklistImportJobRepository interface {
+	Create(ctx context.Context, job *models.BlacklistImportJob, batch *models.BlacklistImportBatch, file io.Reader, partSize int) error
+	GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistImportJob, error)
+	GetByID(ctx context.Context, id uint64) (*models.BlacklistImportJob, error)
+	GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.BlacklistImportJob, int64, error)
+	ReadFile(ctx context.Context, jobID uint64, w io.Writer) error
+	ClaimNext(ctx context.Context, workerID string, staleBefore time.Time) (*models.BlacklistImportJob, error)
+	Heartbeat(ctx context.Context, job *models.BlacklistImportJob) error
+	CommitChunk(ctx context.Context, job *models.BlacklistImportJob, committedRows int64, created, backfilled []*models.PhoneBlacklist) error
+	Finish(ctx context.Context, job *models.BlacklistImportJob) error
+	Release(ctx context.Context, job *models.BlacklistImportJob) error
+	RequestCancel(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistImportJob, error)
+}
+
+// blacklistImportJobRepository 黑名单导入任务仓储实现
+type blacklistImportJobRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistImportJobRepository 创建黑名单导入任务仓储
+func NewBlacklistImportJobRepository(db *gorm.DB) BlacklistImportJobRepository {
+	return &blacklistImportJobRepository{
+		db: db,
+	}
+}
+
+// Create 创建导入任务及其导入批次并按分片保存上传文件，文件全部写入后任务才对执行实例可见
+func (r *blacklistImportJobRepository) Create(ctx context.Context, job *models.BlacklistImportJob, batch *models.BlacklistImportBatch, file io.Reader, partSize int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if job.UUID == "" {
+			job.UUID = models.GenerateUUID()
+		}
+		batch.ImportJobUUID = job.UUID
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		job.BatchID = batch.ID
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+
+		buf := make([]byte, partSize)
+		for seq := 0; ; seq++ {
+			n, err := io.ReadFull(file, buf)
+			if n > 0 {
+				part := &models.BlacklistImportJobFile{JobID: job.ID, Seq: seq, Data: append([]byte(nil), buf[:n]...)}
+				if err := tx.Create(part).Error; err != nil {
+					return err
+				}
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// GetByUUID 根据UUID获取租户的导入任务
+func (r *blacklistImportJobRepository) GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistImportJob, error) {
+	var job models.BlacklistImportJob
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND uuid = ?", tenantID, uuid).
+		First(&job).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetByID 根据ID获取导入任务
+func (r *blacklistImportJobRepository) GetByID(ctx context.Context, id uint64) (*models.BlacklistImportJob, error) {
+	var job models.BlacklistImportJob
+	err := r.db.WithContext(ctx).First(&job, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetByTenant 分页获取租户的导入任务，按创建时间倒序
+func (r *blacklistImportJobRepository) GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.BlacklistImportJob, int64, error) {
+	var jobs []*models.BlacklistImportJob
+	var total int64
+
+	err := r.db.WithContext(ctx).Model(&models.BlacklistImportJob{}).
+		Where("tenant_id = ?", tenantID).
+		Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, total, err
+}
+
+// ReadFile 按分片顺序将上传文件写入w，每次只加载一个分片
+func (r *blacklistImportJobRepository) ReadFile(ctx context.Context, jobID uint64, w io.Writer) error {
+	var ids []uint64
+	err := r.db.WithContext(ctx).Model(&models.BlacklistImportJobFile{}).
+		Where("job_id = ?", jobID).
+		Order("seq ASC").
+		Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		var part models.BlacklistImportJobFile
+		if err := r.db.WithContext(ctx).First(&part, id).Error; err != nil {
+			return err
+		}
+		if _, err := w.Write(part.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClaimNext 领取下一个待执行的任务：等待中的任务，或心跳超时（执行实例已退出）的执行中任务
+// 通过条件更新保证同一任务只会被一个实例领取，没有可领取的任务时返回nil
+func (r *blacklistImportJobRepository) ClaimNext(ctx context.Context, workerID string, staleBefore time.Time) (*models.BlacklistImportJob, error) {
+	var candidates []*models.BlacklistImportJob
+	err := r.db.WithContext(ctx).
+		Select("id").
+		Where("status = ? OR (status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?))",
+			models.ImportJobStatusPending, models.ImportJobStatusRunning, staleBefore).
+		Order("id ASC").
+		Limit(10).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, candidate := range candidates {
+		result := r.db.WithContext(ctx).Model(&models.BlacklistImportJob{}).
+			Where("id = ?", candidate.ID).
+			Where("status = ? OR (status = ? AND (heartbeat_at IS NULL OR heartbeat_at < ?))",
+				models.ImportJobStatusPending, models.ImportJobStatusRunning, staleBefore).
+			Updates(map[string]interface{}{
+				"status":       models.ImportJobStatusRunning,
+				"worker_id":    workerID,
+				"heartbeat_at": now,
+				"started_at":   gorm.Expr("COALESCE(started_at, ?)", now),
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return r.GetByID(ctx, candidate.ID)
+		}
+	}
+	return nil, nil
+}
+
+// Heartbeat 更新心跳时间和数据行数
+func (r *blacklistImportJobRepository) Heartbeat(ctx context.Context, job *models.BlacklistImportJob) error {
+	result := r.db.WithContext(ctx).Model(&models.BlacklistImportJob{}).
+		Where("id = ? AND worker_id = ? AND status = ?", job.ID, job.WorkerID, models.ImportJobStatusRunning).
+		Updates(map[string]interface{}{
+			"heartbeat_at": time.Now(),
+			"total_rows":   job.TotalRows,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrImportJobLeaseLost
+	}
+	return nil
+}
+
+// CommitChunk 在同一事务中写入一批条目、为历史MD5条目补充SHA-256并推进任务进度
+// committedRows为本批之前已提交的行数，与数据库中不一致时说明任务已被其他实例接管，整批回滚
+func (r *blacklistImportJobRepository) CommitChunk(ctx context.Context, job *models.BlacklistImportJob, committedRows int64, created, backfilled []*models.PhoneBlacklist) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.BlacklistImportJob{}).
+			Where("id = ? AND worker_id = ? AND status = ? AND processed_rows = ?",
+				job.ID, job.WorkerID, models.ImportJobStatusRunning, committedRows).
+			Updates(map[string]interface{}{
+				"processed_rows":   job.ProcessedRows,
+				"accepted_count":   job.AcceptedCount,
+				"duplicate_count":  job.DuplicateCount,
+				"invalid_count":    job.InvalidCount,
+				"backfilled_count": job.BackfilledCount,
+				"row_errors":       job.RowErrors,
+				"heartbeat_at":     time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrImportJobLeaseLost
+		}
+
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(created, 1000).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&models.BlacklistImportBatch{}).
+				Where("id = ?", job.BatchID).
+				Update("entry_count", gorm.Expr("entry_count + ?", len(created))).Error
+			if err != nil {
+				return err
+			}
+		}
+		for _, blacklist := range backfilled {
+			if err := backfillHashes(tx, blacklist); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Finish 记录任务结束状态、结束导入批次并删除上传文件
+func (r *blacklistImportJobRepository) Finish(ctx context.Context, job *models.BlacklistImportJob) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.BlacklistImportJob{}).
+			Where("id = ? AND worker_id = ? AND status = ?", job.ID, job.WorkerID, models.ImportJobStatusRunning).
+			Updates(map[string]interface{}{
+				"status":        job.Status,
+				"error_message": job.ErrorMessage,
+				"finished_at":   job.FinishedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrImportJobLeaseLost
+		}
+		if err := completeImportBatch(tx, job.BatchID); err != nil {
+			return err
+		}
+		return tx.Where("job_id = ?", job.ID).Delete(&models.BlacklistImportJobFile{}).Error
+	})
+}
+
+// Release 释放执行中的任务，使其可被立即重新领取（用于实例停止）
+func (r *blacklistImportJobRepository) Release(ctx context.Context, job *models.BlacklistImportJob) error {
+	return r.db.WithContext(ctx).Model(&models.BlacklistImportJob{}).
+		Where("id = ? AND worker_id = ? AND status = ?", job.ID, job.WorkerID, models.ImportJobStatusRunning).
+		Updates(map[string]interface{}{
+			"status":       models.ImportJobStatusPending,
+			"worker_id":    "",
+			"heartbeat_at": nil,
+		}).Error
+}
+
+// RequestCancel 取消任务：等待中的任务直接取消，执行中的任务标记取消请求由执行实例在批次间处理
+func (r *blacklistImportJobRepository) RequestCancel(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistImportJob, error) {
+	var job models.BlacklistImportJob
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ? AND uuid = ?", tenantID, uuid).First(&job).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		switch job.Status {
+		case models.ImportJobStatusPending:
+			result := tx.Model(&models.BlacklistImportJob{}).
+				Where("id = ? AND status = ?", job.ID, models.ImportJobStatusPending).
+				Updates(map[string]interface{}{
+					"status":           models.ImportJobStatusCancelled,
+					"cancel_requested": true,
+					"finished_at":      now,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				// 已被执行实例领取，改为标记取消请求
+				return tx.Model(&models.BlacklistImportJob{}).
+					Where("id = ?", job.ID).
+					Update("cancel_requested", true).Error
+			}
+			if err := completeImportBatch(tx, job.BatchID); err != nil {
+				return err
+			}
+			return tx.Where("job_id = ?", job.ID).Delete(&models.BlacklistImportJobFile{}).Error
+		case models.ImportJobStatusRunning:
+			return tx.Model(&models.BlacklistImportJob{}).
+				Where("id = ?", job.ID).
+				Update("cancel_requested", true).Error
+		default:
+			return nil
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, job.ID)
+}
+
+// completeImportBatch 任务结束后导入批次随之结束，已写入的条目可以回滚
+func completeImportBatch(tx *gorm.DB, batchID uint64) error {
+	if batchID == 0 {
+		return nil
+	}
+	return tx.Model(&models.BlacklistImportBatch{}).
+		Where("id = ? AND status = ?", batchID, models.ImportBatchStatusImporting).
+		Update("status", models.ImportBatchStatusCompleted).Error
+}
@@ -0,0 +1,96 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist list repository.
+package repositories
+
+import (
+	"context"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+)
+
+// BlacklistListRepository 黑名单名单仓储接口
+type BlacklistListRepository interface {
+	Create(ctx context.Context, list *models.BlacklistList) error
+	GetByID(ctx context.Context, tenantID, id uint64) (*models.BlacklistList, error)
+	GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistList, error)
+	GetByName(ctx context.Context, tenantID uint64, name string) (*models.BlacklistList, error)
+	GetByTenant(ctx context.Context, tenantID uint64) ([]*models.BlacklistList, error)
+	UpdateDescription(ctx context.Context, id uint64, description string) error
+	Delete(ctx context.Context, id uint64) error
+}
+
+// blacklistListRepository 黑名单名单仓储实现
+type blacklistListRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistListRepository 创建黑名单名单仓储
+func NewBlacklistListRepository(db *gorm.DB) BlacklistListRepository {
+	return &blacklistListRepository{
+		db: db,
+	}
+}
+
+// Create 创建名单
+func (r *blacklistListRepository) Create(ctx context.Context, list *models.BlacklistList) error {
+	return r.db.WithContext(ctx).Create(list).Error
+}
+
+// GetByID 根据ID获取租户的名单
+func (r *blacklistListRepository) GetByID(ctx context.Context, tenantID, id uint64) (*models.BlacklistList, error) {
+	var list models.BlacklistList
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetByUUID 根据UUID获取租户的名单
+func (r *blacklistListRepository) GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistList, error) {
+	var list models.BlacklistList
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND uuid = ?", tenantID, uuid).
+		First(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetByName 根据名单标识获取租户的名单
+func (r *blacklistListRepository) GetByName(ctx context.Context, tenantID uint64, name string) (*models.BlacklistList, error) {
+	var list models.BlacklistList
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND name = ?", tenantID, name).
+		First(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetByTenant 获取租户的全部名单，按名单标识排序
+func (r *blacklistListRepository) GetByTenant(ctx context.Context, tenantID uint64) ([]*models.BlacklistList, error) {
+	var lists []*models.BlacklistList
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("name ASC").
+		Find(&lists).Error
+	return lists, err
+}
+
+// UpdateDescription 更新名单描述
+func (r *blacklistListRepository) UpdateDescription(ctx context.Context, id uint64, description string) error {
+	return r.db.WithContext(ctx).Model(&models.BlacklistList{}).
+		Where("id = ?", id).
+		Update("description", description).Error
+}
+
+// Delete 删除名单
+func (r *blacklistListRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.BlacklistList{}, id).Error
+}
@@ -0,0 +1,131 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist query log repository.
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+)
+
+// BlacklistQueryLogRepository 黑名单查询日志仓储接口
+type BlacklistQueryLogRepository interface {
+	BatchCreate(ctx context.Context, logs []*models.BlacklistQueryLog) error
+	Search(ctx context.Context, filter BlacklistQueryLogFilter, cursor uint64, ascending bool, limit int) ([]*models.BlacklistQueryLog, error)
+	Summarize(ctx context.Context, filter BlacklistQueryLogFilter) (*BlacklistQueryLogSummary, error)
+}
+
+// BlacklistQueryLogFilter 查询日志过滤条件，零值表示不过滤
+type BlacklistQueryLogFilter struct {
+	TenantID       uint64
+	APIKey         string
+	IdentifierHash string
+	IsHit          *bool
+	RequestID      string
+	From           *time.Time // 查询时间下限（含）
+	To             *time.Time // 查询时间上限（不含）
+}
+
+// BlacklistQueryLogSummary 查询日志汇总
+type BlacklistQueryLogSummary struct {
+	Total        int64
+	HitCount     int64
+	FirstQueryAt *time.Time
+	LastQueryAt  *time.Time
+	APIKeys      []string
+}
+
+// blacklistQueryLogRepository 黑名单查询日志仓储实现
+type blacklistQueryLogRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistQueryLogRepository 创建黑名单查询日志仓储
+func NewBlacklistQueryLogRepository(db *gorm.DB) BlacklistQueryLogRepository {
+	return &blacklistQueryLogRepository{
+		db: db,
+	}
+}
+
+// BatchCreate 批量写入查询日志
+func (r *blacklistQueryLogRepository) BatchCreate(ctx context.Context, logs []*models.BlacklistQueryLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&logs).Error
+}
+
+// Search 按ID游标查询日志：倒序时返回ID小于cursor的记录，正序时返回ID大于cursor的记录，cursor为0表示从头开始
+func (r *blacklistQueryLogRepository) Search(ctx context.Context, filter BlacklistQueryLogFilter, cursor uint64, ascending bool, limit int) ([]*models.BlacklistQueryLog, error) {
+	query := r.filtered(ctx, filter)
+	if ascending {
+		if cursor > 0 {
+			query = query.Where("id > ?", cursor)
+		}
+		query = query.Order("id ASC")
+	} else {
+		if cursor > 0 {
+			query = query.Where("id < ?", cursor)
+		}
+		query = query.Order("id DESC")
+	}
+
+	var logs []*models.BlacklistQueryLog
+	err := query.Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// Summarize 汇总符合条件的查询日志
+func (r *blacklistQueryLogRepository) Summarize(ctx context.Context, filter BlacklistQueryLogFilter) (*BlacklistQueryLogSummary, error) {
+	var row struct {
+		Total        int64
+		HitCount     int64
+		FirstQueryAt *time.Time
+		LastQueryAt  *time.Time
+	}
+	err := r.filtered(ctx, filter).
+		Select("COUNT(*) AS total, COALESCE(SUM(is_hit), 0) AS hit_count, MIN(created_at) AS first_query_at, MAX(created_at) AS last_query_at").
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &BlacklistQueryLogSummary{
+		Total:        row.Total,
+		HitCount:     row.HitCount,
+		FirstQueryAt: row.FirstQueryAt,
+		LastQueryAt:  row.LastQueryAt,
+	}
+	err = r.filtered(ctx, filter).
+		Distinct("api_key").
+		Order("api_key ASC").
+		Pluck("api_key", &summary.APIKeys).Error
+	return summary, err
+}
+
+// filtered 构建带过滤条件的查询
+func (r *blacklistQueryLogRepository) filtered(ctx context.Context, filter BlacklistQueryLogFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&models.BlacklistQueryLog{}).
+		Where("tenant_id = ?", filter.TenantID)
+	if filter.APIKey != "" {
+		query = query.Where("api_key = ?", filter.APIKey)
+	}
+	if filter.IdentifierHash != "" {
+		query = query.Where("identifier_hash = ?", filter.IdentifierHash)
+	}
+	if filter.IsHit != nil {
+		query = query.Where("is_hit = ?", *filter.IsHit)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
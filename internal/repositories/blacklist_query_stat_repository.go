@@ -0,0 +1,114 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist query stats rollup repository.
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BlacklistQueryStatRepository 黑名单查询统计汇总仓储接口
+type BlacklistQueryStatRepository interface {
+	UpsertHourly(ctx context.Context, stats []*models.BlacklistQueryStatHourly) error
+	RefreshDaily(ctx context.Context, from, to time.Time) error
+	GetSeries(ctx context.Context, period string, tenantID uint64, apiKey string, from, to time.Time) ([]*models.BlacklistQueryStat, error)
+	SumByAPIKey(ctx context.Context, period string, tenantID uint64, from, to time.Time) ([]*models.BlacklistQueryStat, error)
+	DeleteHourlyBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// blacklistQueryStatRepository 黑名单查询统计汇总仓储实现
+type blacklistQueryStatRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistQueryStatRepository 创建黑名单查询统计汇总仓储
+func NewBlacklistQueryStatRepository(db *gorm.DB) BlacklistQueryStatRepository {
+	return &blacklistQueryStatRepository{
+		db: db,
+	}
+}
+
+// queryStatTable 时段粒度对应的统计表
+func queryStatTable(period string) string {
+	if period == models.QueryStatPeriodDay {
+		return models.BlacklistQueryStatDaily{}.TableName()
+	}
+	return models.BlacklistQueryStatHourly{}.TableName()
+}
+
+// UpsertHourly 写入小时统计，已存在时以Redis中的计数覆盖，单分钟峰值取较大值
+// Redis中的分钟统计只保留2小时，较早时段重新汇总时峰值为0，不能覆盖已有的峰值
+func (r *blacklistQueryStatRepository) UpsertHourly(ctx context.Context, stats []*models.BlacklistQueryStatHourly) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	updates := clause.AssignmentColumns([]string{"total_queries", "hit_count", "latency_sum", "latency_count", "updated_at"})
+	updates = append(updates, clause.Assignment{
+		Column: clause.Column{Name: "peak_minute_queries"},
+		Value:  gorm.Expr("GREATEST(peak_minute_queries, VALUES(peak_minute_queries))"),
+	})
+
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "api_key"}, {Name: "period_start"}},
+			DoUpdates: updates,
+		}).
+		CreateInBatches(stats, 500).Error
+}
+
+// RefreshDaily 按小时统计重新汇总[from, to)内小时所在自然日的日统计
+func (r *blacklistQueryStatRepository) RefreshDaily(ctx context.Context, from, to time.Time) error {
+	return r.db.WithContext(ctx).Exec(`
+		INSERT INTO blacklist_query_stats_daily
+			(tenant_id, api_key, period_start, total_queries, hit_count, latency_sum, latency_count, peak_minute_queries, created_at, updated_at)
+		SELECT tenant_id, api_key, DATE(period_start), SUM(total_queries), SUM(hit_count), SUM(latency_sum),
+			SUM(latency_count), MAX(peak_minute_queries), NOW(3), NOW(3)
+		FROM blacklist_query_stats_hourly
+		WHERE period_start >= DATE(?) AND period_start < DATE(?) + INTERVAL 1 DAY
+		GROUP BY tenant_id, api_key, DATE(period_start)
+		ON DUPLICATE KEY UPDATE
+			total_queries = VALUES(total_queries),
+			hit_count = VALUES(hit_count),
+			latency_sum = VALUES(latency_sum),
+			latency_count = VALUES(latency_count),
+			peak_minute_queries = VALUES(peak_minute_queries),
+			updated_at = VALUES(updated_at)`,
+		from, to.Add(-time.Second)).Error
+}
+
+// GetSeries 获取租户某个API密钥在[from, to)内的统计，apiKey为空时获取租户汇总，按时段升序
+func (r *blacklistQueryStatRepository) GetSeries(ctx context.Context, period string, tenantID uint64, apiKey string, from, to time.Time) ([]*models.BlacklistQueryStat, error) {
+	var stats []*models.BlacklistQueryStat
+	err := r.db.WithContext(ctx).Table(queryStatTable(period)).
+		Where("tenant_id = ? AND api_key = ?", tenantID, apiKey).
+		Where("period_start >= ? AND period_start < ?", from, to).
+		Order("period_start ASC").
+		Find(&stats).Error
+	return stats, err
+}
+
+// SumByAPIKey 按API密钥汇总租户在[from, to)内的统计，不包含租户汇总记录，峰值取各时段最大值
+func (r *blacklistQueryStatRepository) SumByAPIKey(ctx context.Context, period string, tenantID uint64, from, to time.Time) ([]*models.BlacklistQueryStat, error) {
+	var stats []*models.BlacklistQueryStat
+	err := r.db.WithContext(ctx).Table(queryStatTable(period)).
+		Select("tenant_id, api_key, SUM(total_queries) AS total_queries, SUM(hit_count) AS hit_count, "+
+			"SUM(latency_sum) AS latency_sum, SUM(latency_count) AS latency_count, MAX(peak_minute_queries) AS peak_minute_queries").
+		Where("tenant_id = ? AND api_key <> ''", tenantID).
+		Where("period_start >= ? AND period_start < ?", from, to).
+		Group("tenant_id, api_key").
+		Find(&stats).Error
+	return stats, err
+}
+
+// DeleteHourlyBefore 物理删除指定时间之前的小时统计，日统计不受影响
+func (r *blacklistQueryStatRepository) DeleteHourlyBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("period_start < ?", before).
+		Delete(&models.BlacklistQueryStatHourly{})
+	return result.RowsAffected, result.Error
+}
@@ -4,6 +4,9 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/varluffy/shield/internal/models"
 	"gorm.io/gorm"
@@ -13,14 +16,28 @@ import (
 type BlacklistRepository interface {
 	Create(ctx context.Context, blacklist *models.PhoneBlacklist) error
 	GetByID(ctx context.Context, id uint64) (*models.PhoneBlacklist, error)
-	GetByTenantAndMD5(ctx context.Context, tenantID uint64, phoneMD5 string) (*models.PhoneBlacklist, error)
-	GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.PhoneBlacklist, int64, error)
+	GetByTenantAndMD5(ctx context.Context, tenantID uint64, identifierType, phoneMD5 string) (*models.PhoneBlacklist, error)
+	GetByTenant(ctx context.Context, tenantID uint64, order BlacklistOrder, offset, limit int) ([]*models.PhoneBlacklist, int64, error)
+	GetSharedByContributor(ctx context.Context, contributorTenantID uint64, offset, limit int) ([]*models.PhoneBlacklist, int64, error)
 	Update(ctx context.Context, blacklist *models.PhoneBlacklist) error
 	Delete(ctx context.Context, id uint64) error
 	BatchCreate(ctx context.Context, blacklists []*models.PhoneBlacklist) error
-	GetActiveMD5ListByTenant(ctx context.Context, tenantID uint64) ([]string, error)
-	GetActiveMD5ListByTenantAndMD5List(ctx context.Context, tenantID uint64, phoneMD5List []string, result *[]string) error
-	ExistsByTenantAndMD5(ctx context.Context, tenantID uint64, phoneMD5 string) (bool, error)
+	GetActiveMD5ListByTenant(ctx context.Context, tenantID uint64, identifierType string) ([]string, error)
+	GetActiveListByTenant(ctx context.Context, tenantID uint64) ([]*models.PhoneBlacklist, error)
+	GetActiveBatchByTenant(ctx context.Context, tenantID, afterID uint64, limit int) ([]*models.PhoneBlacklist, error)
+	GetTenantIDs(ctx context.Context) ([]uint64, error)
+	GetActiveByTenantAndHashes(ctx context.Context, tenantID uint64, listIDs []uint64, identifierType, hashType string, hashList []string) ([]*models.PhoneBlacklist, error)
+	GetActiveWithSHA256(ctx context.Context, tenantID uint64, listIDs []uint64, identifierType string, limit int) ([]*models.PhoneBlacklist, error)
+	GetActiveByListAndIdentifiers(ctx context.Context, tenantID, listID uint64, identifierType string, md5List, sha256List []string) ([]*models.PhoneBlacklist, error)
+	CountActiveByList(ctx context.Context, tenantID, listID uint64) (int64, error)
+	BackfillHashes(ctx context.Context, blacklists []*models.PhoneBlacklist) error
+	GetExpiredActive(ctx context.Context, now time.Time, limit int) ([]*models.PhoneBlacklist, error)
+	DeactivateExpiredByIDs(ctx context.Context, ids []uint64, now time.Time) error
+	GetExportBatch(ctx context.Context, tenantID uint64, filter BlacklistExportFilter, afterID uint64, limit int) ([]*models.PhoneBlacklist, error)
+	GetByBatch(ctx context.Context, tenantID, batchID uint64, offset, limit int) ([]*models.PhoneBlacklist, int64, error)
+	GetBatchEntriesForRollback(ctx context.Context, batchID uint64, limit int) ([]*models.PhoneBlacklist, error)
+	RollbackBatchEntries(ctx context.Context, batchID uint64, ids []uint64) error
+	AddHits(ctx context.Context, hits []BlacklistHitDelta) error
 }
 
 // blacklistRepository 黑名单仓储实现
@@ -35,9 +52,27 @@ func NewBlacklistRepository(db *gorm.DB) BlacklistRepository {
 	}
 }
 
-// Create 创建黑名单记录
+// Create 创建黑名单记录，相同标识已有失效、过期或已删除的记录时恢复该记录
+// 并发创建相同标识时唯一键冲突返回ErrDuplicateEntry
 func (r *blacklistRepository) Create(ctx context.Context, blacklist *models.PhoneBlacklist) error {
-	return r.db.WithContext(ctx).Create(blacklist).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		remaining, err := reviveInactive(tx, []*models.PhoneBlacklist{blacklist})
+		if err != nil || len(remaining) == 0 {
+			return err
+		}
+		return translateDuplicateKey(tx, tx.Create(blacklist).Error)
+	})
+}
+
+// translateDuplicateKey 将数据库驱动的唯一键冲突错误转换为ErrDuplicateEntry
+func translateDuplicateKey(db *gorm.DB, err error) error {
+	if err == nil {
+		return nil
+	}
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("%w: %v", ErrDuplicateEntry, err)
+	}
+	return err
 }
 
 // GetByID 根据ID获取黑名单记录
@@ -50,11 +85,12 @@ func (r *blacklistRepository) GetByID(ctx context.Context, id uint64) (*models.P
 	return &blacklist, nil
 }
 
-// GetByTenantAndMD5 根据租户ID和手机号MD5获取黑名单记录
-func (r *blacklistRepository) GetByTenantAndMD5(ctx context.Context, tenantID uint64, phoneMD5 string) (*models.PhoneBlacklist, error) {
+// GetByTenantAndMD5 根据租户ID、标识类型和MD5获取黑名单记录
+func (r *blacklistRepository) GetByTenantAndMD5(ctx context.Context, tenantID uint64, identifierType, phoneMD5 string) (*models.PhoneBlacklist, error) {
 	var blacklist models.PhoneBlacklist
 	err := r.db.WithContext(ctx).
-		Where("tenant_id = ? AND phone_md5 = ? AND is_active = ?", tenantID, phoneMD5, true).
+		Where("tenant_id = ? AND identifier_type = ? AND phone_md5 = ? AND is_active = ?", tenantID, identifierType, phoneMD5, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
 		First(&blacklist).Error
 	if err != nil {
 		return nil, err
@@ -62,8 +98,28 @@ func (r *blacklistRepository) GetByTenantAndMD5(ctx context.Context, tenantID ui
 	return &blacklist, nil
 }
 
+// BlacklistOrder 黑名单列表排序，Column为空时按创建时间排序
+type BlacklistOrder struct {
+	Column string // created_at, hit_count, last_hit_at
+	Desc   bool
+}
+
+// clause 排序子句，同值时按ID排序保证分页稳定；升序时从未命中的记录（last_hit_at为空）排在最前
+func (o BlacklistOrder) clause() string {
+	column := "created_at"
+	switch o.Column {
+	case "hit_count", "last_hit_at":
+		column = o.Column
+	}
+	direction := " ASC"
+	if o.Desc {
+		direction = " DESC"
+	}
+	return column + direction + ", id" + direction
+}
+
 // GetByTenant 分页获取租户的黑名单记录
-func (r *blacklistRepository) GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.PhoneBlacklist, int64, error) {
+func (r *blacklistRepository) GetByTenant(ctx context.Context, tenantID uint64, order BlacklistOrder, offset, limit int) ([]*models.PhoneBlacklist, int64, error) {
 	var blacklists []*models.PhoneBlacklist
 	var total int64
 
@@ -78,7 +134,7 @@ func (r *blacklistRepository) GetByTenant(ctx context.Context, tenantID uint64,
 	// 获取分页数据
 	err = r.db.WithContext(ctx).
 		Where("tenant_id = ? AND is_active = ?", tenantID, true).
-		Order("created_at DESC").
+		Order(order.clause()).
 		Offset(offset).
 		Limit(limit).
 		Find(&blacklists).Error
@@ -89,6 +145,26 @@ func (r *blacklistRepository) GetByTenant(ctx context.Context, tenantID uint64,
 	return blacklists, total, nil
 }
 
+// GetSharedByContributor 分页获取租户贡献到共享名单的有效条目
+func (r *blacklistRepository) GetSharedByContributor(ctx context.Context, contributorTenantID uint64, offset, limit int) ([]*models.PhoneBlacklist, int64, error) {
+	var blacklists []*models.PhoneBlacklist
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.PhoneBlacklist{}).
+		Where("tenant_id = ? AND contributor_tenant_id = ? AND is_active = ?", models.SharedTenantID, contributorTenantID, true)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&blacklists).Error
+	return blacklists, total, err
+}
+
 // Update 更新黑名单记录
 func (r *blacklistRepository) Update(ctx context.Context, blacklist *models.PhoneBlacklist) error {
 	return r.db.WithContext(ctx).Save(blacklist).Error
@@ -107,35 +183,390 @@ func (r *blacklistRepository) BatchCreate(ctx context.Context, blacklists []*mod
 
 	// 使用事务批量插入，提高性能
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		return tx.CreateInBatches(blacklists, 1000).Error
+		remaining, err := reviveInactive(tx, blacklists)
+		if err != nil || len(remaining) == 0 {
+			return err
+		}
+		return tx.CreateInBatches(remaining, 1000).Error
 	})
 }
 
-// GetActiveMD5ListByTenant 获取租户所有有效的手机号MD5列表（用于Redis同步）
-func (r *blacklistRepository) GetActiveMD5ListByTenant(ctx context.Context, tenantID uint64) ([]string, error) {
+// reviveBatchSize 每次查询可恢复记录的标识数量
+const reviveBatchSize = 500
+
+// reviveColumns 恢复记录时按新条目覆盖的字段，命中统计保留
+var reviveColumns = []string{"source", "reason", "operator_id", "category", "risk_score", "is_active", "expires_at",
+	"batch_id", "contributor_tenant_id", "deleted_at", "created_at", "revived_at"}
+
+// reviveInactive 将与给定条目标识相同（租户、名单、标识类型、MD5和SHA-256均相同）的已失效、已过期或已删除的记录恢复为给定条目的内容，
+// 恢复的条目填入原记录的ID和UUID，创建时间重置为恢复时间并记录恢复时间，返回需要新建的条目
+// 这些记录仍占用唯一键，直接插入会因重复键失败
+func reviveInactive(tx *gorm.DB, blacklists []*models.PhoneBlacklist) ([]*models.PhoneBlacklist, error) {
+	type identity struct {
+		tenantID         uint64
+		listID           uint64
+		identifierType   string
+		phoneMD5         string
+		identifierSHA256 string
+	}
+	byIdentity := make(map[identity]*models.PhoneBlacklist, len(blacklists))
+	byTenant := make(map[uint64][][]interface{})
+	for _, blacklist := range blacklists {
+		key := identity{blacklist.TenantID, blacklist.ListID, blacklist.IdentifierType, blacklist.PhoneMD5, blacklist.IdentifierSHA256}
+		byIdentity[key] = blacklist
+		byTenant[blacklist.TenantID] = append(byTenant[blacklist.TenantID],
+			[]interface{}{blacklist.ListID, blacklist.IdentifierType, blacklist.PhoneMD5, blacklist.IdentifierSHA256})
+	}
+
+	now := time.Now()
+	revived := make(map[*models.PhoneBlacklist]bool)
+	for tenantID, tuples := range byTenant {
+		for start := 0; start < len(tuples); start += reviveBatchSize {
+			end := start + reviveBatchSize
+			if end > len(tuples) {
+				end = len(tuples)
+			}
+
+			var inactive []*models.PhoneBlacklist
+			err := tx.Unscoped().
+				Select("id", "uuid", "tenant_id", "list_id", "identifier_type", "phone_md5", "identifier_sha256").
+				Where("tenant_id = ? AND (list_id, identifier_type, phone_md5, identifier_sha256) IN ?", tenantID, tuples[start:end]).
+				Where("is_active = ? OR expires_at <= ? OR deleted_at IS NOT NULL", false, now).
+				Find(&inactive).Error
+			if err != nil {
+				return nil, err
+			}
+
+			for _, old := range inactive {
+				blacklist := byIdentity[identity{old.TenantID, old.ListID, old.IdentifierType, old.PhoneMD5, old.IdentifierSHA256}]
+				if blacklist == nil {
+					continue
+				}
+				// 按创建时间筛选和导出时恢复的条目视为新增
+				blacklist.ID, blacklist.UUID, blacklist.CreatedAt = old.ID, old.UUID, now
+				blacklist.RevivedAt = &now
+				blacklist.DeletedAt = gorm.DeletedAt{}
+				blacklist.IsActive = true
+				err := tx.Unscoped().Model(&models.PhoneBlacklist{}).
+					Where("id = ?", old.ID).
+					Select(reviveColumns).
+					Updates(blacklist).Error
+				if err != nil {
+					return nil, err
+				}
+				revived[blacklist] = true
+			}
+		}
+	}
+
+	if len(revived) == 0 {
+		return blacklists, nil
+	}
+	remaining := make([]*models.PhoneBlacklist, 0, len(blacklists)-len(revived))
+	for _, blacklist := range blacklists {
+		if !revived[blacklist] {
+			remaining = append(remaining, blacklist)
+		}
+	}
+	return remaining, nil
+}
+
+// GetActiveMD5ListByTenant 获取租户指定标识类型所有有效的MD5列表
+func (r *blacklistRepository) GetActiveMD5ListByTenant(ctx context.Context, tenantID uint64, identifierType string) ([]string, error) {
 	var md5List []string
 	err := r.db.WithContext(ctx).Model(&models.PhoneBlacklist{}).
-		Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Where("tenant_id = ? AND identifier_type = ? AND phone_md5 <> '' AND is_active = ?", tenantID, identifierType, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
 		Pluck("phone_md5", &md5List).Error
 	return md5List, err
 }
 
-// GetActiveMD5ListByTenantAndMD5List 批量获取租户中存在的手机号MD5列表
-func (r *blacklistRepository) GetActiveMD5ListByTenantAndMD5List(ctx context.Context, tenantID uint64, phoneMD5List []string, result *[]string) error {
-	if len(phoneMD5List) == 0 {
-		return nil
+// GetActiveListByTenant 获取租户所有类型的有效记录（仅包含同步所需字段，用于Redis同步）
+func (r *blacklistRepository) GetActiveListByTenant(ctx context.Context, tenantID uint64) ([]*models.PhoneBlacklist, error) {
+	var blacklists []*models.PhoneBlacklist
+	err := r.db.WithContext(ctx).
+		Select("id", "tenant_id", "list_id", "identifier_type", "phone_md5", "identifier_sha256", "category", "risk_score", "expires_at").
+		Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Find(&blacklists).Error
+	return blacklists, err
+}
+
+// GetActiveBatchByTenant 按ID升序获取afterID之后的一批有效记录（仅包含同步所需字段，用于分批同步Redis）
+func (r *blacklistRepository) GetActiveBatchByTenant(ctx context.Context, tenantID, afterID uint64, limit int) ([]*models.PhoneBlacklist, error) {
+	var blacklists []*models.PhoneBlacklist
+	err := r.db.WithContext(ctx).
+		Select("id", "tenant_id", "list_id", "identifier_type", "phone_md5", "identifier_sha256", "category", "risk_score", "expires_at").
+		Where("tenant_id = ? AND id > ? AND is_active = ?", tenantID, afterID, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("id ASC").
+		Limit(limit).
+		Find(&blacklists).Error
+	return blacklists, err
+}
+
+// GetTenantIDs 获取存在黑名单记录的租户ID（包含已删除的记录，其Redis数据可能尚未清理）
+func (r *blacklistRepository) GetTenantIDs(ctx context.Context) ([]uint64, error) {
+	var tenantIDs []uint64
+	err := r.db.WithContext(ctx).Unscoped().
+		Model(&models.PhoneBlacklist{}).
+		Distinct("tenant_id").
+		Order("tenant_id ASC").
+		Pluck("tenant_id", &tenantIDs).Error
+	return tenantIDs, err
+}
+
+// GetActiveByTenantAndHashes 批量获取租户中指定标识类型和哈希格式的有效记录（仅包含匹配和风险信息字段）
+// listIDs为空时不按名单过滤
+func (r *blacklistRepository) GetActiveByTenantAndHashes(ctx context.Context, tenantID uint64, listIDs []uint64, identifierType, hashType string, hashList []string) ([]*models.PhoneBlacklist, error) {
+	var blacklists []*models.PhoneBlacklist
+	if len(hashList) == 0 {
+		return blacklists, nil
 	}
 
-	return r.db.WithContext(ctx).Model(&models.PhoneBlacklist{}).
-		Where("tenant_id = ? AND phone_md5 IN ? AND is_active = ?", tenantID, phoneMD5List, true).
-		Pluck("phone_md5", result).Error
+	column, err := hashColumn(hashType)
+	if err != nil {
+		return nil, err
+	}
+
+	query := r.db.WithContext(ctx).
+		Select("id", "list_id", "identifier_type", "phone_md5", "identifier_sha256", "category", "risk_score", "expires_at").
+		Where("tenant_id = ? AND identifier_type = ? AND is_active = ?", tenantID, identifierType, true).
+		Where(column+" IN ?", hashList).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	if len(listIDs) > 0 {
+		query = query.Where("list_id IN ?", listIDs)
+	}
+	err = query.Find(&blacklists).Error
+	return blacklists, err
 }
 
-// ExistsByTenantAndMD5 检查指定租户和MD5是否存在黑名单记录
-func (r *blacklistRepository) ExistsByTenantAndMD5(ctx context.Context, tenantID uint64, phoneMD5 string) (bool, error) {
+// GetActiveWithSHA256 获取给定名单中有SHA-256的有效记录（仅包含匹配和风险信息字段），最多返回limit条
+func (r *blacklistRepository) GetActiveWithSHA256(ctx context.Context, tenantID uint64, listIDs []uint64, identifierType string, limit int) ([]*models.PhoneBlacklist, error) {
+	var blacklists []*models.PhoneBlacklist
+	err := r.db.WithContext(ctx).
+		Select("id", "list_id", "identifier_type", "phone_md5", "identifier_sha256", "category", "risk_score").
+		Where("tenant_id = ? AND list_id IN ? AND identifier_type = ? AND identifier_sha256 <> '' AND is_active = ?", tenantID, listIDs, identifierType, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Limit(limit).
+		Find(&blacklists).Error
+	return blacklists, err
+}
+
+// GetActiveByListAndIdentifiers 获取名单中MD5或SHA-256与给定哈希相同的有效记录
+func (r *blacklistRepository) GetActiveByListAndIdentifiers(ctx context.Context, tenantID, listID uint64, identifierType string, md5List, sha256List []string) ([]*models.PhoneBlacklist, error) {
+	var blacklists []*models.PhoneBlacklist
+	if len(md5List) == 0 && len(sha256List) == 0 {
+		return blacklists, nil
+	}
+
+	// 空列表对应的条件恒不成立
+	if len(md5List) == 0 {
+		md5List = []string{""}
+	}
+	if len(sha256List) == 0 {
+		sha256List = []string{""}
+	}
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND list_id = ? AND identifier_type = ? AND is_active = ?", tenantID, listID, identifierType, true).
+		Where("(phone_md5 <> '' AND phone_md5 IN ?) OR (identifier_sha256 <> '' AND identifier_sha256 IN ?)", md5List, sha256List).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Find(&blacklists).Error
+	return blacklists, err
+}
+
+// CountActiveByList 统计名单中的有效记录数
+func (r *blacklistRepository) CountActiveByList(ctx context.Context, tenantID, listID uint64) (int64, error) {
 	var count int64
 	err := r.db.WithContext(ctx).Model(&models.PhoneBlacklist{}).
-		Where("tenant_id = ? AND phone_md5 = ? AND is_active = ?", tenantID, phoneMD5, true).
+		Where("tenant_id = ? AND list_id = ? AND is_active = ?", tenantID, listID, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
 		Count(&count).Error
-	return count > 0, err
+	return count, err
+}
+
+// BackfillHashes 为已有记录补充缺少的MD5或SHA-256，已有的哈希不会被覆盖
+func (r *blacklistRepository) BackfillHashes(ctx context.Context, blacklists []*models.PhoneBlacklist) error {
+	if len(blacklists) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, blacklist := range blacklists {
+			if err := backfillHashes(tx, blacklist); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// backfillHashes 补充单条记录缺少的哈希格式
+func backfillHashes(tx *gorm.DB, blacklist *models.PhoneBlacklist) error {
+	err := tx.Model(&models.PhoneBlacklist{}).
+		Where("id = ? AND phone_md5 IN ? AND identifier_sha256 IN ?", blacklist.ID,
+			[]string{"", blacklist.PhoneMD5}, []string{"", blacklist.IdentifierSHA256}).
+		Updates(map[string]interface{}{
+			"phone_md5":         blacklist.PhoneMD5,
+			"identifier_sha256": blacklist.IdentifierSHA256,
+		}).Error
+	return translateDuplicateKey(tx, err)
+}
+
+// GetExpiredActive 获取已过期但仍处于有效状态的记录
+func (r *blacklistRepository) GetExpiredActive(ctx context.Context, now time.Time, limit int) ([]*models.PhoneBlacklist, error) {
+	var blacklists []*models.PhoneBlacklist
+	err := r.db.WithContext(ctx).
+		Select("id", "tenant_id", "list_id", "identifier_type", "phone_md5", "identifier_sha256", "category", "risk_score", "expires_at").
+		Where("is_active = ? AND expires_at <= ?", true, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Find(&blacklists).Error
+	return blacklists, err
+}
+
+// DeactivateExpiredByIDs 批量将仍已过期的记录标记为无效，查询后被重新添加（已恢复且更新了过期时间）的记录不受影响
+func (r *blacklistRepository) DeactivateExpiredByIDs(ctx context.Context, ids []uint64, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Model(&models.PhoneBlacklist{}).
+		Where("id IN ? AND expires_at <= ?", ids, now).
+		Update("is_active", false).Error
+}
+
+// BlacklistHitDelta 一条记录在一个汇总周期内的命中次数和最后命中时间
+type BlacklistHitDelta struct {
+	ID        uint64
+	Hits      uint64
+	LastHitAt time.Time
+}
+
+// AddHits 累加记录的命中次数并更新最后命中时间，不更新updated_at
+func (r *blacklistRepository) AddHits(ctx context.Context, hits []BlacklistHitDelta) error {
+	if len(hits) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, hit := range hits {
+			err := tx.Model(&models.PhoneBlacklist{}).
+				Where("id = ?", hit.ID).
+				UpdateColumns(map[string]interface{}{
+					"hit_count":   gorm.Expr("hit_count + ?", hit.Hits),
+					"last_hit_at": gorm.Expr("GREATEST(COALESCE(last_hit_at, ?), ?)", hit.LastHitAt, hit.LastHitAt),
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// BlacklistExportFilter 黑名单导出过滤条件，零值表示不过滤
+type BlacklistExportFilter struct {
+	Source      string
+	Active      *bool      // true: 有效且未过期；false: 已失效或已过期
+	CreatedFrom *time.Time // 创建时间下限（含）
+	CreatedTo   *time.Time // 创建时间上限（不含）
+}
+
+// GetExportBatch 按ID升序获取afterID之后的一批记录，用于游标方式流式导出
+func (r *blacklistRepository) GetExportBatch(ctx context.Context, tenantID uint64, filter BlacklistExportFilter, afterID uint64, limit int) ([]*models.PhoneBlacklist, error) {
+	query := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id > ?", tenantID, afterID)
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.Active != nil {
+		now := time.Now()
+		if *filter.Active {
+			query = query.Where("is_active = ? AND (expires_at IS NULL OR expires_at > ?)", true, now)
+		} else {
+			query = query.Where("(is_active = ? OR expires_at <= ?)", false, now)
+		}
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+
+	var blacklists []*models.PhoneBlacklist
+	err := query.Order("id ASC").Limit(limit).Find(&blacklists).Error
+	return blacklists, err
+}
+
+// GetByBatch 分页获取导入批次中仍存在的条目
+func (r *blacklistRepository) GetByBatch(ctx context.Context, tenantID, batchID uint64, offset, limit int) ([]*models.PhoneBlacklist, int64, error) {
+	var blacklists []*models.PhoneBlacklist
+	var total int64
+
+	err := r.db.WithContext(ctx).Model(&models.PhoneBlacklist{}).
+		Where("tenant_id = ? AND batch_id = ?", tenantID, batchID).
+		Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.WithContext(ctx).
+		Where("tenant_id = ? AND batch_id = ?", tenantID, batchID).
+		Order("id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&blacklists).Error
+	return blacklists, total, err
+}
+
+// GetBatchEntriesForRollback 获取导入批次的一批条目（包含已软删除的，仅包含同步所需字段）
+func (r *blacklistRepository) GetBatchEntriesForRollback(ctx context.Context, batchID uint64, limit int) ([]*models.PhoneBlacklist, error) {
+	var blacklists []*models.PhoneBlacklist
+	err := r.db.WithContext(ctx).Unscoped().
+		Select("id", "tenant_id", "list_id", "identifier_type", "phone_md5", "identifier_sha256", "category", "risk_score", "expires_at").
+		Where("batch_id = ?", batchID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&blacklists).Error
+	return blacklists, err
+}
+
+// RollbackBatchEntries 回滚导入批次的条目并在同一事务中累加批次的回滚条目数
+// 批次恢复的历史记录改回失效并解除与批次的关联，批次新增的记录物理删除，回滚后均可重新导入相同的标识
+func (r *blacklistRepository) RollbackBatchEntries(ctx context.Context, batchID uint64, ids []uint64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deactivated := tx.Unscoped().Model(&models.PhoneBlacklist{}).
+			Where("batch_id = ? AND id IN ? AND revived_at IS NOT NULL", batchID, ids).
+			Updates(map[string]interface{}{"is_active": false, "batch_id": 0, "revived_at": nil})
+		if deactivated.Error != nil {
+			return deactivated.Error
+		}
+		deleted := tx.Unscoped().
+			Where("batch_id = ? AND id IN ?", batchID, ids).
+			Delete(&models.PhoneBlacklist{})
+		if deleted.Error != nil {
+			return deleted.Error
+		}
+		return tx.Model(&models.BlacklistImportBatch{}).
+			Where("id = ?", batchID).
+			Update("rolled_back_count", gorm.Expr("rolled_back_count + ?", deactivated.RowsAffected+deleted.RowsAffected)).Error
+	})
+}
+
+// hashColumn 哈希格式对应的存储字段，HMAC格式不落库
+func hashColumn(hashType string) (string, error) {
+	switch hashType {
+	case models.HashTypeMD5:
+		return "phone_md5", nil
+	case models.HashTypeSHA256:
+		return "identifier_sha256", nil
+	default:
+		return "", ErrUnsupportedHashType
+	}
 }
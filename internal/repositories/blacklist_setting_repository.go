@@ -0,0 +1,75 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist tenant setting repository.
+package repositories
+
+import (
+	"context"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BlacklistSettingRepository 租户黑名单配置仓储接口
+type BlacklistSettingRepository interface {
+	GetByTenant(ctx context.Context, tenantID uint64) (*models.BlacklistTenantSetting, error)
+	CreateIfNotExists(ctx context.Context, setting *models.BlacklistTenantSetting) error
+	UpdateHashSalt(ctx context.Context, tenantID uint64, hashSalt string) error
+	UpdateRequireApproval(ctx context.Context, tenantID uint64, requireApproval bool) error
+	UpdateShared(ctx context.Context, tenantID uint64, subscribed, contributor bool) error
+}
+
+// blacklistSettingRepository 租户黑名单配置仓储实现
+type blacklistSettingRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistSettingRepository 创建租户黑名单配置仓储
+func NewBlacklistSettingRepository(db *gorm.DB) BlacklistSettingRepository {
+	return &blacklistSettingRepository{
+		db: db,
+	}
+}
+
+// GetByTenant 获取租户配置
+func (r *blacklistSettingRepository) GetByTenant(ctx context.Context, tenantID uint64) (*models.BlacklistTenantSetting, error) {
+	var setting models.BlacklistTenantSetting
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		First(&setting).Error
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// CreateIfNotExists 创建租户配置，已存在时忽略（并发创建时以先写入的为准）
+func (r *blacklistSettingRepository) CreateIfNotExists(ctx context.Context, setting *models.BlacklistTenantSetting) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(setting).Error
+}
+
+// UpdateHashSalt 更新租户盐
+func (r *blacklistSettingRepository) UpdateHashSalt(ctx context.Context, tenantID uint64, hashSalt string) error {
+	return r.db.WithContext(ctx).Model(&models.BlacklistTenantSetting{}).
+		Where("tenant_id = ?", tenantID).
+		Update("hash_salt", hashSalt).Error
+}
+
+// UpdateRequireApproval 更新租户是否启用审批
+func (r *blacklistSettingRepository) UpdateRequireApproval(ctx context.Context, tenantID uint64, requireApproval bool) error {
+	return r.db.WithContext(ctx).Model(&models.BlacklistTenantSetting{}).
+		Where("tenant_id = ?", tenantID).
+		Update("require_approval", requireApproval).Error
+}
+
+// UpdateShared 更新租户的共享名单订阅和贡献配置
+func (r *blacklistSettingRepository) UpdateShared(ctx context.Context, tenantID uint64, subscribed, contributor bool) error {
+	return r.db.WithContext(ctx).Model(&models.BlacklistTenantSetting{}).
+		Where("tenant_id = ?", tenantID).
+		Updates(map[string]interface{}{
+			"shared_subscribed":  subscribed,
+			"shared_contributor": contributor,
+		}).Error
+}
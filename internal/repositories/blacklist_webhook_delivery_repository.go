@@ -0,0 +1,197 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist webhook delivery repository.
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+)
+
+// ErrWebhookDeliveryLeaseLost 投递租约已过期并被其他实例领取
+var ErrWebhookDeliveryLeaseLost = errors.New("webhook delivery lease lost")
+
+// BlacklistWebhookDeliveryFilter 投递记录过滤条件，零值表示不过滤
+type BlacklistWebhookDeliveryFilter struct {
+	TenantID  uint64
+	WebhookID uint64
+	Status    string
+}
+
+// BlacklistWebhookDeliveryRepository 黑名单Webhook投递仓储接口
+type BlacklistWebhookDeliveryRepository interface {
+	BatchCreate(ctx context.Context, deliveries []*models.BlacklistWebhookDelivery) error
+	GetByID(ctx context.Context, tenantID, id uint64) (*models.BlacklistWebhookDelivery, error)
+	GetByFilter(ctx context.Context, filter BlacklistWebhookDeliveryFilter, offset, limit int) ([]*models.BlacklistWebhookDelivery, int64, error)
+	GetAttempts(ctx context.Context, deliveryID uint64) ([]*models.BlacklistWebhookAttempt, error)
+	ClaimDue(ctx context.Context, claimID string, leaseUntil time.Time, limit int) ([]*models.BlacklistWebhookDelivery, error)
+	RecordAttempt(ctx context.Context, delivery *models.BlacklistWebhookDelivery, attempt *models.BlacklistWebhookAttempt) error
+	Redeliver(ctx context.Context, tenantID, id uint64) (bool, error)
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// blacklistWebhookDeliveryRepository 黑名单Webhook投递仓储实现
+type blacklistWebhookDeliveryRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistWebhookDeliveryRepository 创建黑名单Webhook投递仓储
+func NewBlacklistWebhookDeliveryRepository(db *gorm.DB) BlacklistWebhookDeliveryRepository {
+	return &blacklistWebhookDeliveryRepository{
+		db: db,
+	}
+}
+
+// BatchCreate 批量创建投递
+func (r *blacklistWebhookDeliveryRepository) BatchCreate(ctx context.Context, deliveries []*models.BlacklistWebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(deliveries, 100).Error
+}
+
+// GetByID 根据ID获取租户的投递
+func (r *blacklistWebhookDeliveryRepository) GetByID(ctx context.Context, tenantID, id uint64) (*models.BlacklistWebhookDelivery, error) {
+	var delivery models.BlacklistWebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetByFilter 分页获取投递记录，按创建时间倒序
+func (r *blacklistWebhookDeliveryRepository) GetByFilter(ctx context.Context, filter BlacklistWebhookDeliveryFilter, offset, limit int) ([]*models.BlacklistWebhookDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.BlacklistWebhookDelivery{}).
+		Where("tenant_id = ?", filter.TenantID)
+	if filter.WebhookID != 0 {
+		query = query.Where("webhook_id = ?", filter.WebhookID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []*models.BlacklistWebhookDelivery
+	err := query.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// GetAttempts 获取投递的全部尝试记录，按投递顺序
+func (r *blacklistWebhookDeliveryRepository) GetAttempts(ctx context.Context, deliveryID uint64) ([]*models.BlacklistWebhookAttempt, error) {
+	var attempts []*models.BlacklistWebhookAttempt
+	err := r.db.WithContext(ctx).
+		Where("delivery_id = ?", deliveryID).
+		Order("id ASC").
+		Find(&attempts).Error
+	return attempts, err
+}
+
+// ClaimDue 领取到期的待投递记录：未被领取或租约已过期的记录
+// 通过条件更新写入本次领取的claimID，保证同一记录只会被一个实例领取
+func (r *blacklistWebhookDeliveryRepository) ClaimDue(ctx context.Context, claimID string, leaseUntil time.Time, limit int) ([]*models.BlacklistWebhookDelivery, error) {
+	now := time.Now()
+	var candidates []uint64
+	err := r.db.WithContext(ctx).Model(&models.BlacklistWebhookDelivery{}).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryStatusPending, now).
+		Where("lease_until IS NULL OR lease_until < ?", now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Pluck("id", &candidates).Error
+	if err != nil || len(candidates) == 0 {
+		return nil, err
+	}
+
+	err = r.db.WithContext(ctx).Model(&models.BlacklistWebhookDelivery{}).
+		Where("id IN ? AND status = ?", candidates, models.WebhookDeliveryStatusPending).
+		Where("lease_until IS NULL OR lease_until < ?", now).
+		Updates(map[string]interface{}{
+			"worker_id":   claimID,
+			"lease_until": leaseUntil,
+		}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []*models.BlacklistWebhookDelivery
+	err = r.db.WithContext(ctx).
+		Where("id IN ? AND worker_id = ? AND status = ?", candidates, claimID, models.WebhookDeliveryStatusPending).
+		Order("next_attempt_at ASC").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// RecordAttempt 在同一事务中写入尝试记录并更新投递状态，释放租约
+// 租约已被其他实例领取时整体回滚并返回ErrWebhookDeliveryLeaseLost
+func (r *blacklistWebhookDeliveryRepository) RecordAttempt(ctx context.Context, delivery *models.BlacklistWebhookDelivery, attempt *models.BlacklistWebhookAttempt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(attempt).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.BlacklistWebhookDelivery{}).
+			Where("id = ? AND worker_id = ? AND status = ?", delivery.ID, delivery.WorkerID, models.WebhookDeliveryStatusPending).
+			Updates(map[string]interface{}{
+				"status":           delivery.Status,
+				"attempts":         delivery.Attempts,
+				"next_attempt_at":  delivery.NextAttemptAt,
+				"last_status_code": delivery.LastStatusCode,
+				"last_error":       delivery.LastError,
+				"last_attempt_at":  delivery.LastAttemptAt,
+				"delivered_at":     delivery.DeliveredAt,
+				"worker_id":        "",
+				"lease_until":      nil,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrWebhookDeliveryLeaseLost
+		}
+		return nil
+	})
+}
+
+// Redeliver 将已结束的投递重置为待投递，立即重新投递并重新计算投递次数
+// 投递仍在进行中时返回false
+func (r *blacklistWebhookDeliveryRepository) Redeliver(ctx context.Context, tenantID, id uint64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.BlacklistWebhookDelivery{}).
+		Where("tenant_id = ? AND id = ? AND status <> ?", tenantID, id, models.WebhookDeliveryStatusPending).
+		Updates(map[string]interface{}{
+			"status":          models.WebhookDeliveryStatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+			"worker_id":       "",
+			"lease_until":     nil,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteFinishedBefore 物理删除指定时间之前结束的投递及其尝试记录，待投递的记录保留
+func (r *blacklistWebhookDeliveryRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		finished := tx.Model(&models.BlacklistWebhookDelivery{}).
+			Select("id").
+			Where("status <> ? AND updated_at < ?", models.WebhookDeliveryStatusPending, before)
+		if err := tx.Where("delivery_id IN (?)", finished).Delete(&models.BlacklistWebhookAttempt{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().
+			Where("status <> ? AND updated_at < ?", models.WebhookDeliveryStatusPending, before).
+			Delete(&models.BlacklistWebhookDelivery{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
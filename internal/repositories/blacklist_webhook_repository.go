@@ -0,0 +1,100 @@
+// Package repositories provides data access layer implementations.
+// This file contains blacklist webhook subscription repository.
+package repositories
+
+import (
+	"context"
+
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm"
+)
+
+// BlacklistWebhookRepository 黑名单Webhook订阅仓储接口
+type BlacklistWebhookRepository interface {
+	Create(ctx context.Context, webhook *models.BlacklistWebhook) error
+	GetByID(ctx context.Context, id uint64) (*models.BlacklistWebhook, error)
+	GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistWebhook, error)
+	GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.BlacklistWebhook, int64, error)
+	GetActiveByTenant(ctx context.Context, tenantID uint64) ([]*models.BlacklistWebhook, error)
+	Update(ctx context.Context, webhook *models.BlacklistWebhook) error
+	Delete(ctx context.Context, id uint64) error
+}
+
+// blacklistWebhookRepository 黑名单Webhook订阅仓储实现
+type blacklistWebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewBlacklistWebhookRepository 创建黑名单Webhook订阅仓储
+func NewBlacklistWebhookRepository(db *gorm.DB) BlacklistWebhookRepository {
+	return &blacklistWebhookRepository{
+		db: db,
+	}
+}
+
+// Create 创建订阅
+func (r *blacklistWebhookRepository) Create(ctx context.Context, webhook *models.BlacklistWebhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+// GetByID 根据ID获取订阅
+func (r *blacklistWebhookRepository) GetByID(ctx context.Context, id uint64) (*models.BlacklistWebhook, error) {
+	var webhook models.BlacklistWebhook
+	err := r.db.WithContext(ctx).First(&webhook, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetByUUID 根据UUID获取租户的订阅
+func (r *blacklistWebhookRepository) GetByUUID(ctx context.Context, tenantID uint64, uuid string) (*models.BlacklistWebhook, error) {
+	var webhook models.BlacklistWebhook
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND uuid = ?", tenantID, uuid).
+		First(&webhook).Error
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// GetByTenant 分页获取租户的订阅，按创建时间倒序
+func (r *blacklistWebhookRepository) GetByTenant(ctx context.Context, tenantID uint64, offset, limit int) ([]*models.BlacklistWebhook, int64, error) {
+	var webhooks []*models.BlacklistWebhook
+	var total int64
+
+	err := r.db.WithContext(ctx).Model(&models.BlacklistWebhook{}).
+		Where("tenant_id = ?", tenantID).
+		Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&webhooks).Error
+	return webhooks, total, err
+}
+
+// GetActiveByTenant 获取租户启用的全部订阅
+func (r *blacklistWebhookRepository) GetActiveByTenant(ctx context.Context, tenantID uint64) ([]*models.BlacklistWebhook, error) {
+	var webhooks []*models.BlacklistWebhook
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND status = ?", tenantID, models.WebhookStatusActive).
+		Find(&webhooks).Error
+	return webhooks, err
+}
+
+// Update 更新订阅
+func (r *blacklistWebhookRepository) Update(ctx context.Context, webhook *models.BlacklistWebhook) error {
+	return r.db.WithContext(ctx).Save(webhook).Error
+}
+
+// Delete 删除订阅（软删除）
+func (r *blacklistWebhookRepository) Delete(ctx context.Context, id uint64) error {
+	return r.db.WithContext(ctx).Delete(&models.BlacklistWebhook{}, id).Error
+}
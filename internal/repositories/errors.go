@@ -14,4 +14,10 @@ var (
 
 	// ErrDatabaseConnection 数据库连接错误
 	ErrDatabaseConnection = errors.New("database connection error")
+
+	// ErrUnsupportedHashType 哈希格式不支持数据库查询错误
+	ErrUnsupportedHashType = errors.New("unsupported hash type")
+
+	// ErrDuplicateEntry 唯一键冲突错误
+	ErrDuplicateEntry = errors.New("duplicate entry")
 )
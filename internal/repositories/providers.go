@@ -26,6 +26,17 @@ var ProviderSet = wire.NewSet(
 	// Blacklist相关Repository
 	NewBlacklistRepository,
 	NewApiCredentialRepository,
+	NewBlacklistSettingRepository,
+	NewBlacklistImportJobRepository,
+	NewBlacklistImportBatchRepository,
+	NewBlacklistDriftReportRepository,
+	NewBlacklistQueryLogRepository,
+	NewBlacklistWebhookRepository,
+	NewBlacklistWebhookDeliveryRepository,
+	NewBlacklistQueryStatRepository,
+	NewBlacklistChangeRequestRepository,
+	NewBlacklistAllowlistRepository,
+	NewBlacklistListRepository,
 
 	// 这里可以添加其他Repository
 	// NewProductRepository,
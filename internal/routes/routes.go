@@ -10,6 +10,7 @@ import (
 	"github.com/varluffy/shield/internal/handlers"
 	"github.com/varluffy/shield/internal/middleware"
 	"github.com/varluffy/shield/pkg/logger"
+	"github.com/varluffy/shield/pkg/metrics"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
@@ -24,6 +25,7 @@ func SetupRoutes(
 	fieldPermissionHandler *handlers.FieldPermissionHandler,
 	blacklistHandler *handlers.BlacklistHandler,
 	apiCredentialHandler *handlers.ApiCredentialHandler,
+	webhookHandler *handlers.BlacklistWebhookHandler,
 	authMiddleware *middleware.AuthMiddleware,
 	permissionMiddleware *middleware.PermissionMiddleware,
 	blacklistAuthMiddleware *middleware.BlacklistAuthMiddleware,
@@ -47,6 +49,14 @@ func SetupRoutes(
 		r.Use(otelgin.Middleware(cfg.App.Name))
 	}
 
+	// 添加Prometheus指标中间件，未配置独立端口时在HTTP服务端口上暴露指标
+	if cfg.Metrics != nil && cfg.Metrics.Enabled {
+		r.Use(middleware.MetricsMiddleware())
+		if cfg.Metrics.Port == 0 {
+			r.GET(cfg.Metrics.Path, gin.WrapH(metrics.Handler(cfg.Metrics.Token)))
+		}
+	}
+
 	// 健康检查
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
@@ -153,6 +163,7 @@ func SetupRoutes(
 		{
 			blacklist.POST("/check", blacklistHandler.CheckBlacklist)      // 检查黑名单
 			blacklist.POST("/check-batch", blacklistHandler.CheckBlacklistBatch) // 批量检查黑名单
+			blacklist.POST("/range", blacklistHandler.RangeQuery)                // 按哈希前缀查询黑名单
 		}
 
 		// 黑名单管理API (JWT鉴权)
@@ -161,11 +172,61 @@ func SetupRoutes(
 		{
 			adminBlacklist.POST("", authMiddleware.ValidateAPIPermission(), blacklistHandler.CreateBlacklist)
 			adminBlacklist.POST("/import", authMiddleware.ValidateAPIPermission(), blacklistHandler.BatchImportBlacklist)
+			adminBlacklist.POST("/import/file", authMiddleware.ValidateAPIPermission(), blacklistHandler.ImportBlacklistFile)
+			adminBlacklist.POST("/import-jobs", authMiddleware.ValidateAPIPermission(), blacklistHandler.SubmitImportJob)
+			adminBlacklist.GET("/import-jobs", authMiddleware.ValidateAPIPermission(), blacklistHandler.ListImportJobs)
+			adminBlacklist.GET("/import-jobs/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetImportJob)
+			adminBlacklist.POST("/import-jobs/:id/cancel", authMiddleware.ValidateAPIPermission(), blacklistHandler.CancelImportJob)
+			adminBlacklist.GET("/batches", authMiddleware.ValidateAPIPermission(), blacklistHandler.ListImportBatches)
+			adminBlacklist.GET("/batches/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetImportBatch)
+			adminBlacklist.POST("/batches/:id/rollback", authMiddleware.ValidateAPIPermission(), blacklistHandler.RollbackImportBatch)
 			adminBlacklist.POST("/sync", authMiddleware.ValidateAPIPermission(), blacklistHandler.SyncBlacklistToRedis)
+			adminBlacklist.GET("/drift", authMiddleware.ValidateAPIPermission(), blacklistHandler.ListDriftReports)
+			adminBlacklist.POST("/drift/check", authMiddleware.ValidateAPIPermission(), blacklistHandler.CheckDrift)
+			adminBlacklist.GET("/query-logs", authMiddleware.ValidateAPIPermission(), blacklistHandler.SearchQueryLogs)
+			adminBlacklist.GET("/query-logs/timeline", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetQueryLogTimeline)
+			adminBlacklist.POST("/webhooks", authMiddleware.ValidateAPIPermission(), webhookHandler.CreateWebhook)
+			adminBlacklist.GET("/webhooks", authMiddleware.ValidateAPIPermission(), webhookHandler.ListWebhooks)
+			adminBlacklist.GET("/webhooks/:id", authMiddleware.ValidateAPIPermission(), webhookHandler.GetWebhook)
+			adminBlacklist.PUT("/webhooks/:id", authMiddleware.ValidateAPIPermission(), webhookHandler.UpdateWebhook)
+			adminBlacklist.DELETE("/webhooks/:id", authMiddleware.ValidateAPIPermission(), webhookHandler.DeleteWebhook)
+			adminBlacklist.POST("/webhooks/:id/rotate-secret", authMiddleware.ValidateAPIPermission(), webhookHandler.RotateWebhookSecret)
+			adminBlacklist.GET("/webhook-deliveries", authMiddleware.ValidateAPIPermission(), webhookHandler.ListWebhookDeliveries)
+			adminBlacklist.GET("/webhook-deliveries/:id", authMiddleware.ValidateAPIPermission(), webhookHandler.GetWebhookDelivery)
+			adminBlacklist.POST("/webhook-deliveries/:id/redeliver", authMiddleware.ValidateAPIPermission(), webhookHandler.RedeliverWebhook)
 			adminBlacklist.GET("", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetBlacklistList)
+			adminBlacklist.GET("/export", authMiddleware.ValidateAPIPermission(), blacklistHandler.ExportBlacklist)
 			adminBlacklist.DELETE("/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.DeleteBlacklist)
 			adminBlacklist.GET("/stats", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetQueryStats)
 			adminBlacklist.GET("/stats/minutes", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetMinuteStats)
+			adminBlacklist.GET("/stats/api-keys/:api_key", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetAPIKeyStats)
+			adminBlacklist.GET("/stats/top-consumers", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetTopConsumers)
+			adminBlacklist.GET("/filter/stats", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetFilterStats)
+			adminBlacklist.GET("/hash-salt", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetHashSalt)
+			adminBlacklist.POST("/hash-salt/rotate", authMiddleware.ValidateAPIPermission(), blacklistHandler.RotateHashSalt)
+			adminBlacklist.GET("/approval-setting", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetApprovalSetting)
+			adminBlacklist.PUT("/approval-setting", authMiddleware.ValidateAPIPermission(), blacklistHandler.UpdateApprovalSetting)
+			adminBlacklist.GET("/change-requests", authMiddleware.ValidateAPIPermission(), blacklistHandler.ListChangeRequests)
+			adminBlacklist.GET("/change-requests/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetChangeRequest)
+			adminBlacklist.POST("/change-requests/:id/approve", authMiddleware.ValidateAPIPermission(), blacklistHandler.ApproveChangeRequest) // 审批人权限
+			adminBlacklist.POST("/change-requests/:id/reject", authMiddleware.ValidateAPIPermission(), blacklistHandler.RejectChangeRequest)   // 审批人权限
+			adminBlacklist.POST("/allowlist", authMiddleware.ValidateAPIPermission(), blacklistHandler.CreateAllowlistEntry)
+			adminBlacklist.GET("/allowlist", authMiddleware.ValidateAPIPermission(), blacklistHandler.ListAllowlistEntries)
+			adminBlacklist.GET("/allowlist/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetAllowlistEntry)
+			adminBlacklist.PUT("/allowlist/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.UpdateAllowlistEntry)
+			adminBlacklist.DELETE("/allowlist/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.DeleteAllowlistEntry)
+			adminBlacklist.POST("/lists", authMiddleware.ValidateAPIPermission(), blacklistHandler.CreateList)
+			adminBlacklist.GET("/lists", authMiddleware.ValidateAPIPermission(), blacklistHandler.ListLists)
+			adminBlacklist.GET("/lists/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetList)
+			adminBlacklist.PUT("/lists/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.UpdateList)
+			adminBlacklist.DELETE("/lists/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.DeleteList)
+			adminBlacklist.GET("/credentials/:id/lists", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetCredentialLists)
+			adminBlacklist.PUT("/credentials/:id/lists", authMiddleware.ValidateAPIPermission(), blacklistHandler.UpdateCredentialLists)
+			adminBlacklist.GET("/shared-setting", authMiddleware.ValidateAPIPermission(), blacklistHandler.GetSharedSetting)
+			adminBlacklist.PUT("/shared-setting", authMiddleware.ValidateAPIPermission(), blacklistHandler.UpdateSharedSetting)
+			adminBlacklist.POST("/shared/contributions", authMiddleware.ValidateAPIPermission(), blacklistHandler.ContributeShared)
+			adminBlacklist.GET("/shared/contributions", authMiddleware.ValidateAPIPermission(), blacklistHandler.ListSharedContributions)
+			adminBlacklist.DELETE("/shared/contributions/:id", authMiddleware.ValidateAPIPermission(), blacklistHandler.WithdrawSharedContribution)
 		}
 
 		// API密钥管理API (JWT鉴权)
@@ -122,9 +122,10 @@ func (s *apiCredentialService) UpdateCredential(ctx context.Context, credential
 		return fmt.Errorf("获取现有密钥信息失败: %w", err)
 	}
 
-	// 保留原有的APIKey和APISecret
+	// 保留原有的APIKey和APISecret，名单授权通过单独的接口维护
 	credential.APIKey = existingCredential.APIKey
 	credential.APISecret = existingCredential.APISecret
+	credential.Lists = existingCredential.Lists
 
 	err = s.credentialRepo.Update(ctx, credential)
 	if err != nil {
@@ -0,0 +1,420 @@
+// Package services provides business logic layer implementations.
+// This file contains tenant allowlist entries that override blacklist hits.
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/pkg/errors"
+	"github.com/varluffy/shield/pkg/logger"
+	redisClient "github.com/varluffy/shield/pkg/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// AllowlistService 租户白名单服务接口，白名单内的标识命中黑名单时按未命中返回
+type AllowlistService interface {
+	CreateAllowlistEntry(ctx context.Context, entry *models.BlacklistAllowlistEntry) error
+	ListAllowlistEntries(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.BlacklistAllowlistEntry, int64, error)
+	GetAllowlistEntry(ctx context.Context, tenantID uint64, entryID string) (*models.BlacklistAllowlistEntry, error)
+	UpdateAllowlistEntry(ctx context.Context, tenantID uint64, entryID string, params *AllowlistUpdateParams) (*models.BlacklistAllowlistEntry, *models.BlacklistAllowlistEntry, error)
+	DeleteAllowlistEntry(ctx context.Context, tenantID uint64, entryID string) (*models.BlacklistAllowlistEntry, error)
+}
+
+// allowlistService 租户白名单服务实现，查询时的豁免和随黑名单写入补全由黑名单服务处理
+type allowlistService struct {
+	allowlistRepo repositories.BlacklistAllowlistRepository
+	blacklistRepo repositories.BlacklistRepository
+	settingRepo   repositories.BlacklistSettingRepository
+	shared        SharedListService
+	redis         *redisClient.Client
+	logger        *logger.Logger
+}
+
+// NewAllowlistService 创建租户白名单服务
+func NewAllowlistService(
+	allowlistRepo repositories.BlacklistAllowlistRepository,
+	blacklistRepo repositories.BlacklistRepository,
+	settingRepo repositories.BlacklistSettingRepository,
+	shared SharedListService,
+	redis *redisClient.Client,
+	logger *logger.Logger,
+) AllowlistService {
+	return &allowlistService{
+		allowlistRepo: allowlistRepo,
+		blacklistRepo: blacklistRepo,
+		settingRepo:   settingRepo,
+		shared:        shared,
+		redis:         redis,
+		logger:        logger,
+	}
+}
+
+// AllowlistUpdateParams 白名单条目更新参数，标识不可修改
+type AllowlistUpdateParams struct {
+	Reason     string
+	ExpiresAt  *time.Time // 为空表示永久有效
+	OperatorID uint64
+}
+
+// blacklistAllowlistKey 租户白名单HASH的Redis key
+// field为各哈希格式的过滤器元素值，value为过期时间的Unix秒数，0表示永久有效
+func blacklistAllowlistKey(tenantID uint64) string {
+	return fmt.Sprintf("blacklist:allowlist:tenant:%d", tenantID)
+}
+
+// allowlistFields 白名单条目在Redis HASH中的field/value对
+func allowlistFields(entry *models.BlacklistAllowlistEntry, salt string) []interface{} {
+	var expiresAt int64
+	if entry.ExpiresAt != nil {
+		expiresAt = entry.ExpiresAt.Unix()
+	}
+	members := hashMembers(entry.PhoneMD5, entry.IdentifierSHA256, salt)
+	fields := make([]interface{}, 0, len(members)*2)
+	for _, member := range members {
+		fields = append(fields, blacklistFilterValue(entry.IdentifierType, member.hashType, member.value), expiresAt)
+	}
+	return fields
+}
+
+// allowlistFieldNames 白名单条目在Redis HASH中的field
+func allowlistFieldNames(entry *models.BlacklistAllowlistEntry, salt string) []string {
+	members := hashMembers(entry.PhoneMD5, entry.IdentifierSHA256, salt)
+	names := make([]string, 0, len(members))
+	for _, member := range members {
+		names = append(names, blacklistFilterValue(entry.IdentifierType, member.hashType, member.value))
+	}
+	return names
+}
+
+// applyAllowlist 将命中结果中在租户白名单内的条目改为未命中并标记为已豁免
+// 白名单查询失败时保持命中，宁可拦截也不放行
+func (s *blacklistService) applyAllowlist(ctx context.Context, tenantID uint64, identifierType, hashType string, results map[string]*CheckResult) {
+	hits := make([]string, 0)
+	for hash, result := range results {
+		if result.Hit {
+			hits = append(hits, hash)
+		}
+	}
+	if len(hits) == 0 {
+		return
+	}
+
+	allowed, err := s.allowlistedHashes(ctx, tenantID, identifierType, hashType, hits)
+	if err != nil {
+		s.logger.ErrorWithTrace(ctx, "白名单查询失败，按黑名单结果返回",
+			zap.Error(err),
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("identifier_type", identifierType),
+			zap.String("hash_type", hashType),
+			zap.Int("hit_count", len(hits)))
+		return
+	}
+	for hash := range allowed {
+		*results[hash] = CheckResult{Overridden: true, OverriddenRiskScore: results[hash].RiskScore}
+	}
+}
+
+// allowlistedHashes 返回在租户白名单有效期内的哈希，Redis失败时回退到数据库查询（HMAC格式无法回退）
+func (s *blacklistService) allowlistedHashes(ctx context.Context, tenantID uint64, identifierType, hashType string, hashList []string) (map[string]bool, error) {
+	fields := make([]string, len(hashList))
+	for i, hash := range hashList {
+		fields[i] = blacklistFilterValue(identifierType, hashType, hash)
+	}
+
+	allowed := make(map[string]bool)
+	values, err := s.redis.HMGet(ctx, blacklistAllowlistKey(tenantID), fields...).Result()
+	if err == nil {
+		now := time.Now().Unix()
+		for i, value := range values {
+			str, ok := value.(string)
+			if !ok {
+				continue
+			}
+			expiresAt, err := strconv.ParseInt(str, 10, 64)
+			if err == nil && (expiresAt == 0 || expiresAt > now) {
+				allowed[hashList[i]] = true
+			}
+		}
+		return allowed, nil
+	}
+
+	s.logger.WarnWithTrace(ctx, "Redis白名单查询失败，回退到数据库查询",
+		zap.Error(err),
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("hash_type", hashType))
+
+	entries, err := s.allowlistRepo.GetActiveByTenantAndHashes(ctx, tenantID, identifierType, hashType, hashList)
+	if err != nil {
+		return nil, fmt.Errorf("数据库白名单查询失败: %w", err)
+	}
+	for _, entry := range entries {
+		if hashType == models.HashTypeSHA256 {
+			allowed[entry.IdentifierSHA256] = true
+		} else {
+			allowed[entry.PhoneMD5] = true
+		}
+	}
+	return allowed, nil
+}
+
+// rebuildAllowlistCache 按数据库重建租户白名单的Redis HASH，用于重新同步和租户盐轮换后刷新HMAC格式
+func (s *blacklistService) rebuildAllowlistCache(ctx context.Context, tenantID uint64, salt string) error {
+	entries, err := s.allowlistRepo.GetActiveByTenant(ctx, tenantID)
+	if err != nil {
+		return fmt.Errorf("获取白名单数据失败: %w", err)
+	}
+
+	key := blacklistAllowlistKey(tenantID)
+	pipe := s.redis.TxPipeline()
+	pipe.Del(ctx, key)
+	for _, entry := range entries {
+		pipe.HSet(ctx, key, allowlistFields(entry, salt)...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("写入白名单缓存失败: %w", err)
+	}
+	return nil
+}
+
+// completeAllowlistEntries 写入的黑名单条目同时有MD5和SHA-256时，补全相同标识只有其中一种格式的白名单条目并刷新其Redis缓存
+// 共享名单条目补全已订阅租户的白名单条目；失败只记录日志，白名单仍按已有格式匹配
+func (s *blacklistService) completeAllowlistEntries(ctx context.Context, tenantID uint64, blacklists []*models.PhoneBlacklist, salt string) {
+	// 按标识类型分组的MD5到SHA-256映射
+	pairs := make(map[string]map[string]string)
+	for _, blacklist := range blacklists {
+		if blacklist.PhoneMD5 == "" || blacklist.IdentifierSHA256 == "" {
+			continue
+		}
+		if pairs[blacklist.IdentifierType] == nil {
+			pairs[blacklist.IdentifierType] = make(map[string]string)
+		}
+		pairs[blacklist.IdentifierType][blacklist.PhoneMD5] = blacklist.IdentifierSHA256
+	}
+	if len(pairs) == 0 {
+		return
+	}
+
+	var tenantIDs []uint64
+	if tenantID != models.SharedTenantID {
+		tenantIDs = []uint64{tenantID}
+	}
+	salts := map[uint64]string{tenantID: salt}
+	for identifierType, md5ToSHA256 := range pairs {
+		md5List := make([]string, 0, len(md5ToSHA256))
+		sha256List := make([]string, 0, len(md5ToSHA256))
+		sha256ToMD5 := make(map[string]string, len(md5ToSHA256))
+		for phoneMD5, identifierSHA256 := range md5ToSHA256 {
+			md5List = append(md5List, phoneMD5)
+			sha256List = append(sha256List, identifierSHA256)
+			sha256ToMD5[identifierSHA256] = phoneMD5
+		}
+
+		entries, err := s.allowlistRepo.GetIncompleteByHashes(ctx, tenantIDs, identifierType, md5List, sha256List)
+		if err != nil {
+			s.logger.WarnWithTrace(ctx, "查询待补全的白名单条目失败",
+				zap.Error(err),
+				zap.Uint64("tenant_id", tenantID),
+				zap.String("identifier_type", identifierType))
+			continue
+		}
+
+		for _, entry := range entries {
+			if tenantID == models.SharedTenantID && !s.shared.Subscribed(ctx, entry.TenantID, models.HashTypeMD5) {
+				continue
+			}
+			if entry.PhoneMD5 != "" {
+				entry.IdentifierSHA256 = md5ToSHA256[entry.PhoneMD5]
+			} else {
+				entry.PhoneMD5 = sha256ToMD5[entry.IdentifierSHA256]
+			}
+			if err := s.allowlistRepo.UpdateHashes(ctx, entry); err != nil {
+				s.logger.WarnWithTrace(ctx, "补全白名单条目失败",
+					zap.Error(err),
+					zap.Uint64("tenant_id", entry.TenantID),
+					zap.String("entry_id", entry.UUID))
+				continue
+			}
+
+			entrySalt, ok := salts[entry.TenantID]
+			if !ok {
+				entrySalt, err = s.getHashSalt(ctx, entry.TenantID)
+				if err != nil {
+					s.logger.WarnWithTrace(ctx, "获取租户盐失败，白名单在下次重新同步后生效",
+						zap.Error(err),
+						zap.Uint64("tenant_id", entry.TenantID))
+					continue
+				}
+				salts[entry.TenantID] = entrySalt
+			}
+			if err := s.redis.HSet(ctx, blacklistAllowlistKey(entry.TenantID), allowlistFields(entry, entrySalt)...).Err(); err != nil {
+				s.logger.WarnWithTrace(ctx, "白名单写入Redis失败",
+					zap.Error(err),
+					zap.Uint64("tenant_id", entry.TenantID),
+					zap.String("entry_id", entry.UUID))
+			}
+		}
+	}
+}
+
+// completeAllowlistHashes 白名单条目只有一种哈希格式时，从租户名单和已订阅的共享名单中相同标识的有效条目补全另一种格式
+// 补全后条目按全部派生格式写入Redis，按任一格式（含HMAC）查询都能匹配；没有可用条目时保持原样，待黑名单写入时补全
+func (s *allowlistService) completeAllowlistHashes(ctx context.Context, entry *models.BlacklistAllowlistEntry) error {
+	if (entry.PhoneMD5 == "") == (entry.IdentifierSHA256 == "") {
+		return nil
+	}
+	hashType, hash := models.HashTypeMD5, entry.PhoneMD5
+	if hash == "" {
+		hashType, hash = models.HashTypeSHA256, entry.IdentifierSHA256
+	}
+
+	owners := []uint64{entry.TenantID}
+	if s.shared.Subscribed(ctx, entry.TenantID, hashType) {
+		owners = append(owners, models.SharedTenantID)
+	}
+	for _, ownerID := range owners {
+		blacklists, err := s.blacklistRepo.GetActiveByTenantAndHashes(ctx, ownerID, nil, entry.IdentifierType, hashType, []string{hash})
+		if err != nil {
+			return fmt.Errorf("查询黑名单失败: %w", err)
+		}
+		for _, blacklist := range blacklists {
+			if blacklist.PhoneMD5 != "" && blacklist.IdentifierSHA256 != "" {
+				entry.PhoneMD5, entry.IdentifierSHA256 = blacklist.PhoneMD5, blacklist.IdentifierSHA256
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// CreateAllowlistEntry 创建白名单条目，同一标识已有有效条目时返回冲突
+func (s *allowlistService) CreateAllowlistEntry(ctx context.Context, entry *models.BlacklistAllowlistEntry) error {
+	if entry.IdentifierType == "" {
+		entry.IdentifierType = models.IdentifierTypePhone
+	}
+	if entry.IsExpired(time.Now()) {
+		return errors.NewBusinessError(errors.CodeValidationError, "过期时间必须晚于当前时间")
+	}
+
+	if err := s.completeAllowlistHashes(ctx, entry); err != nil {
+		return err
+	}
+
+	_, err := s.allowlistRepo.GetActiveByIdentifier(ctx, entry.TenantID, entry.IdentifierType, entry.PhoneMD5, entry.IdentifierSHA256)
+	if err == nil {
+		return errors.NewBusinessError(errors.CodeConflict, "标识已在白名单中")
+	}
+	if !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("查询白名单失败: %w", err)
+	}
+
+	salt, err := loadHashSalt(ctx, s.settingRepo, entry.TenantID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.allowlistRepo.Create(ctx, entry); err != nil {
+		return fmt.Errorf("创建白名单条目失败: %w", err)
+	}
+
+	// 写入失败时条目在下次重新同步后生效，期间仍按黑名单拦截
+	if err := s.redis.HSet(ctx, blacklistAllowlistKey(entry.TenantID), allowlistFields(entry, salt)...).Err(); err != nil {
+		s.logger.WarnWithTrace(ctx, "白名单写入Redis失败",
+			zap.Error(err),
+			zap.Uint64("tenant_id", entry.TenantID),
+			zap.String("entry_id", entry.UUID))
+	}
+
+	s.logger.InfoWithTrace(ctx, "白名单条目创建成功",
+		zap.Uint64("tenant_id", entry.TenantID),
+		zap.String("entry_id", entry.UUID),
+		zap.String("identifier_type", entry.IdentifierType))
+
+	return nil
+}
+
+// ListAllowlistEntries 分页获取租户的白名单条目
+func (s *allowlistService) ListAllowlistEntries(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.BlacklistAllowlistEntry, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.allowlistRepo.GetByTenant(ctx, tenantID, offset, pageSize)
+}
+
+// GetAllowlistEntry 获取租户的白名单条目
+func (s *allowlistService) GetAllowlistEntry(ctx context.Context, tenantID uint64, entryID string) (*models.BlacklistAllowlistEntry, error) {
+	entry, err := s.allowlistRepo.GetByUUID(ctx, tenantID, entryID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.NewBusinessError(errors.CodeNotFound, "白名单条目不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取白名单条目失败: %w", err)
+	}
+	return entry, nil
+}
+
+// UpdateAllowlistEntry 更新白名单条目的原因和过期时间，返回更新前和更新后的条目
+// Redis更新失败时返回错误，避免缩短的有效期未生效
+func (s *allowlistService) UpdateAllowlistEntry(ctx context.Context, tenantID uint64, entryID string, params *AllowlistUpdateParams) (*models.BlacklistAllowlistEntry, *models.BlacklistAllowlistEntry, error) {
+	entry, err := s.GetAllowlistEntry(ctx, tenantID, entryID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, nil, errors.NewBusinessError(errors.CodeValidationError, "过期时间必须晚于当前时间")
+	}
+
+	salt, err := loadHashSalt(ctx, s.settingRepo, tenantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	old := *entry
+	entry.Reason = params.Reason
+	entry.ExpiresAt = params.ExpiresAt
+	entry.OperatorID = params.OperatorID
+	if err := s.allowlistRepo.Update(ctx, entry); err != nil {
+		return nil, nil, fmt.Errorf("更新白名单条目失败: %w", err)
+	}
+
+	if err := s.redis.HSet(ctx, blacklistAllowlistKey(tenantID), allowlistFields(entry, salt)...).Err(); err != nil {
+		return nil, nil, fmt.Errorf("更新白名单缓存失败: %w", err)
+	}
+
+	s.logger.InfoWithTrace(ctx, "白名单条目更新成功",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("entry_id", entry.UUID))
+
+	return &old, entry, nil
+}
+
+// DeleteAllowlistEntry 删除白名单条目，先从Redis移除再删除数据库记录，避免已删除的条目继续放行
+func (s *allowlistService) DeleteAllowlistEntry(ctx context.Context, tenantID uint64, entryID string) (*models.BlacklistAllowlistEntry, error) {
+	entry, err := s.GetAllowlistEntry(ctx, tenantID, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := loadHashSalt(ctx, s.settingRepo, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.redis.HDel(ctx, blacklistAllowlistKey(tenantID), allowlistFieldNames(entry, salt)...).Err(); err != nil {
+		return nil, fmt.Errorf("移除白名单缓存失败: %w", err)
+	}
+
+	if err := s.allowlistRepo.Delete(ctx, entry.ID); err != nil {
+		return nil, fmt.Errorf("删除白名单条目失败: %w", err)
+	}
+
+	s.logger.InfoWithTrace(ctx, "白名单条目删除成功",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("entry_id", entry.UUID))
+
+	return entry, nil
+}
@@ -0,0 +1,478 @@
+// Package services provides business logic layer implementations.
+// This file contains per-API-key query statistics, the top consumers ranking and date range reads from the rollup tables.
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// 统计粒度
+const (
+	StatsGranularityMinute = "minute"
+	StatsGranularityHour   = "hour"
+	StatsGranularityDay    = "day"
+)
+
+// 各粒度可查询的最大数据点数，minute和hour粒度的最近数据点与Redis统计key的保留时间一致
+const (
+	maxMinuteStatsPoints    = 120 // 分钟级统计保留2小时
+	maxHourStatsPoints      = 48  // 小时级统计保留48小时
+	maxDayStatsPoints       = 366 // 日统计读取MySQL汇总表
+	maxHourRangeStatsPoints = 744 // 指定时间范围时hour粒度最多31天
+)
+
+// 排行排序字段
+const (
+	TopConsumersSortByTotal   = "total"
+	TopConsumersSortByHits    = "hits"
+	TopConsumersSortByLatency = "latency"
+)
+
+// StatsWindow 统计时间窗口
+// 未指定时间范围时为最近Points个Granularity粒度的时间段，包含当前时间段，minute和hour粒度读取Redis
+// 指定From和To时为[From, To)内的时间段，From向下对齐到粒度，仅支持hour和day粒度；指定时间范围或day粒度时读取MySQL汇总表
+type StatsWindow struct {
+	Granularity string
+	Points      int
+	From        time.Time
+	To          time.Time
+}
+
+// HasRange 是否指定了时间范围
+func (w StatsWindow) HasRange() bool {
+	return !w.From.IsZero() || !w.To.IsZero()
+}
+
+// fromRollup 是否从MySQL汇总表读取
+func (w StatsWindow) fromRollup() bool {
+	return w.HasRange() || w.Granularity == StatsGranularityDay
+}
+
+// Validate 校验统计窗口，最近数据点数不能超过Redis中保留的范围，时间范围不能超过粒度的最大数据点数
+func (w StatsWindow) Validate() error {
+	var limit int
+	switch w.Granularity {
+	case StatsGranularityMinute:
+		limit = maxMinuteStatsPoints
+	case StatsGranularityHour:
+		limit = maxHourStatsPoints
+		if w.HasRange() {
+			limit = maxHourRangeStatsPoints
+		}
+	case StatsGranularityDay:
+		limit = maxDayStatsPoints
+	default:
+		return errors.ErrValidationFailed(fmt.Sprintf("不支持的统计粒度: %s", w.Granularity))
+	}
+
+	if !w.HasRange() {
+		if w.Points < 1 || w.Points > limit {
+			return errors.ErrValidationFailed(fmt.Sprintf("%s粒度的数据点数必须在1到%d之间", w.Granularity, limit))
+		}
+		return nil
+	}
+
+	if w.Granularity == StatsGranularityMinute {
+		return errors.ErrValidationFailed("指定时间范围时统计粒度只能是hour或day")
+	}
+	if w.From.IsZero() || w.To.IsZero() || !w.From.Before(w.To) {
+		return errors.ErrValidationFailed("时间范围无效，from和to需同时指定且from早于to")
+	}
+	points := 0
+	for t := w.truncate(w.From); t.Before(w.To); t = w.next(t) {
+		if points++; points > limit {
+			return errors.ErrValidationFailed(fmt.Sprintf("%s粒度的时间范围最多包含%d个数据点", w.Granularity, limit))
+		}
+	}
+	return nil
+}
+
+// periods 窗口内各时间段的开始时间（升序）和最后一个时间段的结束时间
+func (w StatsWindow) periods(now time.Time) ([]time.Time, time.Time) {
+	var starts []time.Time
+	if w.HasRange() {
+		for t := w.truncate(w.From); t.Before(w.To); t = w.next(t) {
+			starts = append(starts, t)
+		}
+	} else {
+		t := w.truncate(now)
+		for i := 1; i < w.Points; i++ {
+			t = w.prev(t)
+		}
+		starts = make([]time.Time, 0, w.Points)
+		for i := 0; i < w.Points; i++ {
+			starts = append(starts, t)
+			t = w.next(t)
+		}
+	}
+	if len(starts) == 0 {
+		return starts, now
+	}
+	return starts, w.next(starts[len(starts)-1])
+}
+
+// truncate t所在时间段的开始时间，day粒度按本地时区的自然日对齐
+func (w StatsWindow) truncate(t time.Time) time.Time {
+	switch w.Granularity {
+	case StatsGranularityDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case StatsGranularityHour:
+		return t.Truncate(time.Hour)
+	}
+	return t.Truncate(time.Minute)
+}
+
+// next 下一个时间段的开始时间
+func (w StatsWindow) next(t time.Time) time.Time {
+	switch w.Granularity {
+	case StatsGranularityDay:
+		return t.AddDate(0, 0, 1)
+	case StatsGranularityHour:
+		return t.Add(time.Hour)
+	}
+	return t.Add(time.Minute)
+}
+
+// prev 上一个时间段的开始时间
+func (w StatsWindow) prev(t time.Time) time.Time {
+	switch w.Granularity {
+	case StatsGranularityDay:
+		return t.AddDate(0, 0, -1)
+	case StatsGranularityHour:
+		return t.Add(-time.Hour)
+	}
+	return t.Add(-time.Minute)
+}
+
+// statsKey API密钥在t所在时间段的Redis统计key，仅用于minute和hour粒度
+func (w StatsWindow) statsKey(apiKey string, t time.Time) string {
+	if w.Granularity == StatsGranularityHour {
+		return apiHourStatsKey(apiKey, t)
+	}
+	return apiMinuteStatsKey(apiKey, t)
+}
+
+// rollupPeriod 粒度对应的MySQL汇总表时段
+func (w StatsWindow) rollupPeriod() string {
+	if w.Granularity == StatsGranularityDay {
+		return models.QueryStatPeriodDay
+	}
+	return models.QueryStatPeriodHour
+}
+
+// StatsPoint 统计数据点
+type StatsPoint struct {
+	Time         time.Time // 时间段开始时间
+	TotalQueries int64
+	HitCount     int64
+	HitRate      float64 // 百分比
+	QPS          float64
+	AvgLatency   float64 // 毫秒
+}
+
+// APIKeyStats API密钥的查询统计
+type APIKeyStats struct {
+	APIKey       string
+	Name         string
+	Status       string
+	TotalQueries int64
+	HitCount     int64
+	MissCount    int64
+	HitRate      float64 // 百分比
+	QPS          float64 // 窗口内平均QPS
+	PeakQPS      float64 // 窗口内数据点的最大QPS，汇总表中记录了单分钟峰值时取单分钟峰值
+	AvgLatency   float64 // 毫秒
+	Series       []StatsPoint
+}
+
+// apiMinuteStatsKey API密钥的分钟级统计key
+func apiMinuteStatsKey(apiKey string, t time.Time) string {
+	return fmt.Sprintf("stats:minute:api:%s:%s", apiKey, t.Format("200601021504"))
+}
+
+// apiHourStatsKey API密钥的小时级统计key
+func apiHourStatsKey(apiKey string, t time.Time) string {
+	return fmt.Sprintf("stats:query:api:%s:%s", apiKey, t.Format("2006010215"))
+}
+
+// tenantMinuteStatsKey 租户的分钟级统计key
+func tenantMinuteStatsKey(tenantID uint64, t time.Time) string {
+	return fmt.Sprintf("stats:minute:tenant:%d:%s", tenantID, t.Format("200601021504"))
+}
+
+// tenantHourStatsKey 租户的小时级统计key
+func tenantHourStatsKey(tenantID uint64, t time.Time) string {
+	return fmt.Sprintf("stats:query:tenant:%d:%s", tenantID, t.Format("2006010215"))
+}
+
+// GetAPIKeyStats 获取租户某个API密钥在统计窗口内的汇总和时间序列，序列按时间升序
+func (s *blacklistService) GetAPIKeyStats(ctx context.Context, tenantID uint64, apiKey string, window StatsWindow) (*APIKeyStats, error) {
+	if err := window.Validate(); err != nil {
+		return nil, err
+	}
+
+	credential, err := s.credentialRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewBusinessError(errors.CodeNotFound, "API密钥不存在")
+		}
+		return nil, fmt.Errorf("获取API密钥失败: %w", err)
+	}
+	if credential.TenantID != tenantID {
+		return nil, errors.NewBusinessError(errors.CodeNotFound, "API密钥不存在")
+	}
+
+	if window.fromRollup() {
+		stats, err := s.readRollupSeries(ctx, tenantID, apiKey, window)
+		if err != nil {
+			return nil, err
+		}
+		stats.Name = credential.Name
+		stats.Status = credential.Status
+		return stats, nil
+	}
+
+	stats, err := s.readAPIKeyStats(ctx, []*models.BlacklistApiCredential{credential}, window)
+	if err != nil {
+		return nil, err
+	}
+	return stats[0], nil
+}
+
+// GetTenantStats 从MySQL汇总表获取租户在统计窗口内的汇总和时间序列，返回的APIKey为空
+// 窗口需指定时间范围或使用day粒度
+func (s *blacklistService) GetTenantStats(ctx context.Context, tenantID uint64, window StatsWindow) (*APIKeyStats, error) {
+	if err := window.Validate(); err != nil {
+		return nil, err
+	}
+	if !window.fromRollup() {
+		return nil, errors.ErrValidationFailed("租户统计需指定时间范围或使用day粒度")
+	}
+	return s.readRollupSeries(ctx, tenantID, "", window)
+}
+
+// GetTopConsumers 获取租户查询量最大的API密钥排行，不包含时间序列
+// 从MySQL汇总表读取时包含已删除的API密钥，其名称和状态为空
+func (s *blacklistService) GetTopConsumers(ctx context.Context, tenantID uint64, window StatsWindow, sortBy string, limit int) ([]*APIKeyStats, error) {
+	if err := window.Validate(); err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.credentialRepo.GetByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥列表失败: %w", err)
+	}
+
+	var stats []*APIKeyStats
+	if window.fromRollup() {
+		stats, err = s.readRollupTotals(ctx, tenantID, credentials, window)
+	} else if len(credentials) > 0 {
+		stats, err = s.readAPIKeyStats(ctx, credentials, window)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return []*APIKeyStats{}, nil
+	}
+
+	less := func(a, b *APIKeyStats) bool { return a.TotalQueries > b.TotalQueries }
+	switch sortBy {
+	case TopConsumersSortByHits:
+		less = func(a, b *APIKeyStats) bool { return a.HitCount > b.HitCount }
+	case TopConsumersSortByLatency:
+		less = func(a, b *APIKeyStats) bool { return a.AvgLatency > b.AvgLatency }
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if less(stats[i], stats[j]) {
+			return true
+		}
+		if less(stats[j], stats[i]) {
+			return false
+		}
+		return stats[i].APIKey < stats[j].APIKey
+	})
+
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+	for _, item := range stats {
+		item.Series = nil
+	}
+	return stats, nil
+}
+
+// statsValues 一个时间段的统计计数
+type statsValues struct {
+	total      int64
+	hits       int64
+	latency    int64
+	count      int64
+	peakMinute int64 // 单分钟最大查询次数，仅MySQL汇总表中有记录
+}
+
+// readAPIKeyStats 通过一次Pipeline读取多个API密钥在统计窗口内的数据
+func (s *blacklistService) readAPIKeyStats(ctx context.Context, credentials []*models.BlacklistApiCredential, window StatsWindow) ([]*APIKeyStats, error) {
+	starts, end := window.periods(time.Now())
+
+	pipe := s.redis.Pipeline()
+	cmds := make([][]*redis.SliceCmd, len(credentials))
+	for i, credential := range credentials {
+		cmds[i] = make([]*redis.SliceCmd, len(starts))
+		for p, start := range starts {
+			cmds[i][p] = pipe.HMGet(ctx, window.statsKey(credential.APIKey, start), "total", "hits", "latency", "count")
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("读取API密钥统计失败: %w", err)
+	}
+
+	result := make([]*APIKeyStats, 0, len(credentials))
+	for i, credential := range credentials {
+		values := make([]statsValues, len(starts))
+		for p, cmd := range cmds[i] {
+			values[p].total, values[p].hits, values[p].latency, values[p].count = parseStatsValues(cmd.Val())
+		}
+		stats := newAPIKeyStats(credential.APIKey, starts, end, values)
+		stats.Name = credential.Name
+		stats.Status = credential.Status
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+// readRollupSeries 从MySQL汇总表读取API密钥在统计窗口内的数据，apiKey为空时读取租户汇总，缺失的时间段计为0
+func (s *blacklistService) readRollupSeries(ctx context.Context, tenantID uint64, apiKey string, window StatsWindow) (*APIKeyStats, error) {
+	starts, end := window.periods(time.Now())
+	rows, err := s.statsRepo.GetSeries(ctx, window.rollupPeriod(), tenantID, apiKey, starts[0], end)
+	if err != nil {
+		return nil, fmt.Errorf("读取查询统计汇总失败: %w", err)
+	}
+
+	byPeriod := make(map[int64]*models.BlacklistQueryStat, len(rows))
+	for _, row := range rows {
+		byPeriod[row.PeriodStart.Unix()] = row
+	}
+	values := make([]statsValues, len(starts))
+	for p, start := range starts {
+		if row, ok := byPeriod[start.Unix()]; ok {
+			values[p] = rollupValues(row)
+		}
+	}
+	return newAPIKeyStats(apiKey, starts, end, values), nil
+}
+
+// readRollupTotals 从MySQL汇总表按API密钥汇总统计窗口内的数据
+func (s *blacklistService) readRollupTotals(ctx context.Context, tenantID uint64, credentials []*models.BlacklistApiCredential, window StatsWindow) ([]*APIKeyStats, error) {
+	starts, end := window.periods(time.Now())
+	rows, err := s.statsRepo.SumByAPIKey(ctx, window.rollupPeriod(), tenantID, starts[0], end)
+	if err != nil {
+		return nil, fmt.Errorf("读取查询统计汇总失败: %w", err)
+	}
+
+	byKey := make(map[string]*models.BlacklistApiCredential, len(credentials))
+	for _, credential := range credentials {
+		byKey[credential.APIKey] = credential
+	}
+	result := make([]*APIKeyStats, 0, len(rows))
+	for _, row := range rows {
+		// 整个窗口作为一个时间段计算汇总，排行不返回序列
+		stats := newAPIKeyStats(row.APIKey, starts[:1], end, []statsValues{rollupValues(row)})
+		if credential, ok := byKey[row.APIKey]; ok {
+			stats.Name = credential.Name
+			stats.Status = credential.Status
+		}
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+// rollupValues 汇总表记录的统计计数
+func rollupValues(row *models.BlacklistQueryStat) statsValues {
+	return statsValues{
+		total:      row.TotalQueries,
+		hits:       row.HitCount,
+		latency:    row.LatencySum,
+		count:      row.LatencyCount,
+		peakMinute: row.PeakMinuteQueries,
+	}
+}
+
+// newAPIKeyStats 根据各时间段的计数计算序列和汇总，starts为各时间段开始时间，end为最后一个时间段的结束时间
+// 峰值QPS取单分钟最大查询次数和各时间段平均QPS中的较大值
+func newAPIKeyStats(apiKey string, starts []time.Time, end time.Time, values []statsValues) *APIKeyStats {
+	stats := &APIKeyStats{
+		APIKey: apiKey,
+		Series: make([]StatsPoint, 0, len(starts)),
+	}
+
+	var totalLatency, totalCount int64
+	for p, start := range starts {
+		periodEnd := end
+		if p+1 < len(starts) {
+			periodEnd = starts[p+1]
+		}
+		v := values[p]
+
+		point := StatsPoint{
+			Time:         start,
+			TotalQueries: v.total,
+			HitCount:     v.hits,
+			QPS:          float64(v.total) / periodEnd.Sub(start).Seconds(),
+		}
+		if v.total > 0 {
+			point.HitRate = float64(v.hits) / float64(v.total) * 100
+		}
+		if v.count > 0 {
+			point.AvgLatency = float64(v.latency) / float64(v.count)
+		}
+		if point.QPS > stats.PeakQPS {
+			stats.PeakQPS = point.QPS
+		}
+		if peak := float64(v.peakMinute) / 60; peak > stats.PeakQPS {
+			stats.PeakQPS = peak
+		}
+		stats.Series = append(stats.Series, point)
+
+		stats.TotalQueries += v.total
+		stats.HitCount += v.hits
+		totalLatency += v.latency
+		totalCount += v.count
+	}
+
+	stats.MissCount = stats.TotalQueries - stats.HitCount
+	if len(starts) > 0 {
+		stats.QPS = float64(stats.TotalQueries) / end.Sub(starts[0]).Seconds()
+	}
+	if stats.TotalQueries > 0 {
+		stats.HitRate = float64(stats.HitCount) / float64(stats.TotalQueries) * 100
+	}
+	if totalCount > 0 {
+		stats.AvgLatency = float64(totalLatency) / float64(totalCount)
+	}
+	return stats
+}
+
+// parseStatsValues 解析统计hash的total、hits、latency和count字段，缺失的字段为0
+func parseStatsValues(values []interface{}) (total, hits, latency, count int64) {
+	parsed := make([]int64, 4)
+	for i := 0; i < len(values) && i < len(parsed); i++ {
+		if values[i] == nil {
+			continue
+		}
+		if n, err := strconv.ParseInt(fmt.Sprintf("%v", values[i]), 10, 64); err == nil {
+			parsed[i] = n
+		}
+	}
+	return parsed[0], parsed[1], parsed[2], parsed[3]
+}
@@ -0,0 +1,357 @@
+// Package services provides business logic layer implementations.
+// This file contains the maker-checker approval workflow for blacklist additions and removals.
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/pkg/errors"
+	"github.com/varluffy/shield/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ApprovalService 黑名单审批服务接口，租户启用审批后新增和删除条目需经另一名管理员审批
+type ApprovalService interface {
+	GetApprovalRequired(ctx context.Context, tenantID uint64) (bool, error)
+	SetApprovalRequired(ctx context.Context, tenantID uint64, required bool) error
+	RequestCreateBlacklist(ctx context.Context, blacklist *models.PhoneBlacklist) (*models.BlacklistChangeRequest, error)
+	RequestDeleteBlacklist(ctx context.Context, tenantID, id, operatorID uint64) (*models.BlacklistChangeRequest, error)
+	ListChangeRequests(ctx context.Context, tenantID uint64, status string, page, pageSize int) ([]*models.BlacklistChangeRequest, int64, error)
+	GetChangeRequest(ctx context.Context, tenantID uint64, requestID string) (*models.BlacklistChangeRequest, error)
+	ApproveChangeRequest(ctx context.Context, tenantID uint64, requestID string, reviewerID uint64, comment string) (*models.BlacklistChangeRequest, error)
+	RejectChangeRequest(ctx context.Context, tenantID uint64, requestID string, reviewerID uint64, comment string) (*models.BlacklistChangeRequest, error)
+}
+
+// approvalService 黑名单审批服务实现，审批通过的变更通过黑名单服务写入
+type approvalService struct {
+	changeRepo    repositories.BlacklistChangeRequestRepository
+	settingRepo   repositories.BlacklistSettingRepository
+	blacklistRepo repositories.BlacklistRepository
+	listRepo      repositories.BlacklistListRepository
+	blacklists    BlacklistService
+	logger        *logger.Logger
+}
+
+// NewApprovalService 创建黑名单审批服务
+func NewApprovalService(
+	changeRepo repositories.BlacklistChangeRequestRepository,
+	settingRepo repositories.BlacklistSettingRepository,
+	blacklistRepo repositories.BlacklistRepository,
+	listRepo repositories.BlacklistListRepository,
+	blacklists BlacklistService,
+	logger *logger.Logger,
+) ApprovalService {
+	return &approvalService{
+		changeRepo:    changeRepo,
+		settingRepo:   settingRepo,
+		blacklistRepo: blacklistRepo,
+		listRepo:      listRepo,
+		blacklists:    blacklists,
+		logger:        logger,
+	}
+}
+
+// approvalRequired 租户新增和删除条目是否需要审批，没有配置记录时不需要审批
+func approvalRequired(ctx context.Context, settingRepo repositories.BlacklistSettingRepository, tenantID uint64) (bool, error) {
+	setting, err := settingRepo.GetByTenant(ctx, tenantID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("获取租户配置失败: %w", err)
+	}
+	return setting.RequireApproval, nil
+}
+
+// ensureDirectWriteAllowed 租户启用审批时拒绝无法逐条审批的写入（批量导入、文件导入、异步导入任务、批次回滚和共享名单贡献）
+func (s *blacklistService) ensureDirectWriteAllowed(ctx context.Context, tenantID uint64, operation string) error {
+	required, err := approvalRequired(ctx, s.settingRepo, tenantID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.NewBusinessError(errors.CodeForbidden, fmt.Sprintf("租户已启用审批，%s不可用，请逐条提交新增或删除申请", operation))
+	}
+	return nil
+}
+
+// GetApprovalRequired 获取租户新增和删除条目是否需要审批，没有配置记录时不需要审批
+func (s *approvalService) GetApprovalRequired(ctx context.Context, tenantID uint64) (bool, error) {
+	return approvalRequired(ctx, s.settingRepo, tenantID)
+}
+
+// SetApprovalRequired 设置租户新增和删除条目是否需要审批，关闭审批不影响已提交的申请
+func (s *approvalService) SetApprovalRequired(ctx context.Context, tenantID uint64, required bool) error {
+	// 确保配置记录存在
+	if _, err := loadHashSalt(ctx, s.settingRepo, tenantID); err != nil {
+		return err
+	}
+
+	if err := s.settingRepo.UpdateRequireApproval(ctx, tenantID, required); err != nil {
+		return fmt.Errorf("更新租户配置失败: %w", err)
+	}
+
+	s.logger.InfoWithTrace(ctx, "租户审批配置已更新",
+		zap.Uint64("tenant_id", tenantID),
+		zap.Bool("require_approval", required))
+
+	return nil
+}
+
+// RequestCreateBlacklist 新增条目，租户启用审批时仅创建待审批的申请并返回，否则直接创建条目并返回nil
+func (s *approvalService) RequestCreateBlacklist(ctx context.Context, blacklist *models.PhoneBlacklist) (*models.BlacklistChangeRequest, error) {
+	required, err := s.GetApprovalRequired(ctx, blacklist.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !required {
+		return nil, s.blacklists.CreateBlacklist(ctx, blacklist)
+	}
+
+	if blacklist.IdentifierType == "" {
+		blacklist.IdentifierType = models.IdentifierTypePhone
+	}
+	request := &models.BlacklistChangeRequest{
+		TenantModel:      models.TenantModel{TenantID: blacklist.TenantID},
+		Action:           models.ChangeRequestActionCreate,
+		Status:           models.ChangeRequestStatusPending,
+		ListID:           blacklist.ListID,
+		IdentifierType:   blacklist.IdentifierType,
+		PhoneMD5:         blacklist.PhoneMD5,
+		IdentifierSHA256: blacklist.IdentifierSHA256,
+		Source:           blacklist.Source,
+		Reason:           blacklist.Reason,
+		Category:         blacklist.Category,
+		RiskScore:        blacklist.RiskScore,
+		ExpiresAt:        blacklist.ExpiresAt,
+		RequestedBy:      blacklist.OperatorID,
+	}
+	if err := s.changeRepo.Create(ctx, request); err != nil {
+		return nil, fmt.Errorf("创建变更申请失败: %w", err)
+	}
+
+	s.logger.InfoWithTrace(ctx, "黑名单新增申请已提交",
+		zap.Uint64("tenant_id", request.TenantID),
+		zap.String("request_id", request.UUID),
+		zap.String("identifier_type", request.IdentifierType),
+		zap.Uint64("requested_by", request.RequestedBy))
+
+	return request, nil
+}
+
+// RequestDeleteBlacklist 删除租户的条目，租户启用审批时仅创建待审批的申请并返回，否则直接删除条目并返回nil
+func (s *approvalService) RequestDeleteBlacklist(ctx context.Context, tenantID, id, operatorID uint64) (*models.BlacklistChangeRequest, error) {
+	blacklist, err := s.blacklistRepo.GetByID(ctx, id)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) || (err == nil && blacklist.TenantID != tenantID) {
+		return nil, errors.NewBusinessError(errors.CodeNotFound, "黑名单记录不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取黑名单记录失败: %w", err)
+	}
+
+	required, err := s.GetApprovalRequired(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !required {
+		return nil, s.blacklists.DeleteBlacklist(ctx, id)
+	}
+
+	exists, err := s.changeRepo.ExistsPendingDelete(ctx, tenantID, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取变更申请失败: %w", err)
+	}
+	if exists {
+		return nil, errors.NewBusinessError(errors.CodeConflict, "该条目已有待审批的删除申请")
+	}
+
+	// 记录目标条目的当前信息，供审批人核对
+	request := &models.BlacklistChangeRequest{
+		TenantModel:      models.TenantModel{TenantID: tenantID},
+		Action:           models.ChangeRequestActionDelete,
+		Status:           models.ChangeRequestStatusPending,
+		BlacklistID:      blacklist.ID,
+		ListID:           blacklist.ListID,
+		IdentifierType:   blacklist.IdentifierType,
+		PhoneMD5:         blacklist.PhoneMD5,
+		IdentifierSHA256: blacklist.IdentifierSHA256,
+		Source:           blacklist.Source,
+		Reason:           blacklist.Reason,
+		Category:         blacklist.Category,
+		RiskScore:        blacklist.RiskScore,
+		ExpiresAt:        blacklist.ExpiresAt,
+		RequestedBy:      operatorID,
+	}
+	if err := s.changeRepo.Create(ctx, request); err != nil {
+		return nil, fmt.Errorf("创建变更申请失败: %w", err)
+	}
+
+	s.logger.InfoWithTrace(ctx, "黑名单删除申请已提交",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("request_id", request.UUID),
+		zap.Uint64("blacklist_id", id),
+		zap.Uint64("requested_by", operatorID))
+
+	return request, nil
+}
+
+// ListChangeRequests 分页获取租户的变更申请，status为空时返回全部状态
+func (s *approvalService) ListChangeRequests(ctx context.Context, tenantID uint64, status string, page, pageSize int) ([]*models.BlacklistChangeRequest, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.changeRepo.GetByTenant(ctx, tenantID, status, offset, pageSize)
+}
+
+// GetChangeRequest 获取租户的变更申请
+func (s *approvalService) GetChangeRequest(ctx context.Context, tenantID uint64, requestID string) (*models.BlacklistChangeRequest, error) {
+	request, err := s.changeRepo.GetByUUID(ctx, tenantID, requestID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.NewBusinessError(errors.CodeNotFound, "变更申请不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取变更申请失败: %w", err)
+	}
+	return request, nil
+}
+
+// ApproveChangeRequest 审批通过变更申请并执行变更，审批人不能是申请人
+// 先标记为已通过再执行变更，并发审批时只有一个生效；变更失败时恢复为待审批
+func (s *approvalService) ApproveChangeRequest(ctx context.Context, tenantID uint64, requestID string, reviewerID uint64, comment string) (*models.BlacklistChangeRequest, error) {
+	request, err := s.getReviewableChangeRequest(ctx, tenantID, requestID, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Action == models.ChangeRequestActionCreate && request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, errors.NewBusinessError(errors.CodeValidationError, "申请的条目已过期，请驳回后重新提交")
+	}
+
+	if err := s.reviewChangeRequest(ctx, request, models.ChangeRequestStatusApproved, reviewerID, comment); err != nil {
+		return nil, err
+	}
+
+	if err := s.applyChangeRequest(ctx, request); err != nil {
+		if reopenErr := s.changeRepo.Reopen(context.WithoutCancel(ctx), request.ID); reopenErr != nil {
+			s.logger.ErrorWithTrace(ctx, "恢复变更申请状态失败",
+				zap.String("request_id", request.UUID),
+				zap.Error(reopenErr))
+		}
+		return nil, err
+	}
+
+	s.logger.InfoWithTrace(ctx, "黑名单变更申请已通过",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("request_id", request.UUID),
+		zap.String("action", request.Action),
+		zap.Uint64("blacklist_id", request.BlacklistID),
+		zap.Uint64("reviewed_by", reviewerID))
+
+	return request, nil
+}
+
+// RejectChangeRequest 驳回变更申请，审批人不能是申请人
+func (s *approvalService) RejectChangeRequest(ctx context.Context, tenantID uint64, requestID string, reviewerID uint64, comment string) (*models.BlacklistChangeRequest, error) {
+	request, err := s.getReviewableChangeRequest(ctx, tenantID, requestID, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reviewChangeRequest(ctx, request, models.ChangeRequestStatusRejected, reviewerID, comment); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithTrace(ctx, "黑名单变更申请已驳回",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("request_id", request.UUID),
+		zap.String("action", request.Action),
+		zap.Uint64("reviewed_by", reviewerID))
+
+	return request, nil
+}
+
+// getReviewableChangeRequest 获取可由reviewerID审批的待审批申请
+func (s *approvalService) getReviewableChangeRequest(ctx context.Context, tenantID uint64, requestID string, reviewerID uint64) (*models.BlacklistChangeRequest, error) {
+	request, err := s.GetChangeRequest(ctx, tenantID, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != models.ChangeRequestStatusPending {
+		return nil, errors.NewBusinessError(errors.CodeConflict, "变更申请已处理")
+	}
+	if request.RequestedBy == reviewerID {
+		return nil, errors.NewBusinessError(errors.CodeForbidden, "不能审批自己提交的变更申请")
+	}
+	return request, nil
+}
+
+// reviewChangeRequest 将待审批的申请更新为审批结果，并同步更新request
+func (s *approvalService) reviewChangeRequest(ctx context.Context, request *models.BlacklistChangeRequest, status string, reviewerID uint64, comment string) error {
+	reviewed, err := s.changeRepo.Review(ctx, request.ID, status, reviewerID, comment)
+	if err != nil {
+		return fmt.Errorf("更新变更申请状态失败: %w", err)
+	}
+	if !reviewed {
+		return errors.NewBusinessError(errors.CodeConflict, "变更申请已处理")
+	}
+
+	now := time.Now()
+	request.Status = status
+	request.ReviewedBy = reviewerID
+	request.ReviewedAt = &now
+	request.ReviewComment = comment
+	return nil
+}
+
+// applyChangeRequest 执行已通过的变更申请，写入数据库并同步Redis
+func (s *approvalService) applyChangeRequest(ctx context.Context, request *models.BlacklistChangeRequest) error {
+	switch request.Action {
+	case models.ChangeRequestActionCreate:
+		// 申请提交后目标名单可能已被删除
+		if request.ListID != models.DefaultListID {
+			_, err := s.listRepo.GetByID(ctx, request.TenantID, request.ListID)
+			if stderrors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.NewBusinessError(errors.CodeNotFound, "目标名单已不存在，请驳回该申请")
+			}
+			if err != nil {
+				return fmt.Errorf("获取名单失败: %w", err)
+			}
+		}
+		blacklist := &models.PhoneBlacklist{
+			TenantModel:      models.TenantModel{TenantID: request.TenantID},
+			ListID:           request.ListID,
+			IdentifierType:   request.IdentifierType,
+			PhoneMD5:         request.PhoneMD5,
+			IdentifierSHA256: request.IdentifierSHA256,
+			Source:           request.Source,
+			Reason:           request.Reason,
+			Category:         request.Category,
+			RiskScore:        request.RiskScore,
+			OperatorID:       request.RequestedBy,
+			IsActive:         true,
+			ExpiresAt:        request.ExpiresAt,
+		}
+		if err := s.blacklists.CreateBlacklist(ctx, blacklist); err != nil {
+			return err
+		}
+		request.BlacklistID = blacklist.ID
+		if err := s.changeRepo.SetBlacklistID(ctx, request.ID, blacklist.ID); err != nil {
+			s.logger.WarnWithTrace(ctx, "记录变更申请的条目ID失败",
+				zap.String("request_id", request.UUID),
+				zap.Uint64("blacklist_id", blacklist.ID),
+				zap.Error(err))
+		}
+		return nil
+	case models.ChangeRequestActionDelete:
+		err := s.blacklists.DeleteBlacklist(ctx, request.BlacklistID)
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.NewBusinessError(errors.CodeNotFound, "待删除的黑名单记录已不存在，请驳回该申请")
+		}
+		return err
+	default:
+		return fmt.Errorf("不支持的变更类型: %s", request.Action)
+	}
+}
@@ -9,7 +9,10 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"math/rand"
+	"net"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -24,27 +27,83 @@ import (
 type BlacklistAuthService interface {
 	ValidateHMACSignature(ctx context.Context, apiKey, timestamp, nonce, signature, body string) (*models.BlacklistApiCredential, error)
 	CheckRateLimit(ctx context.Context, apiKey string) error
-	RecordQueryLog(ctx context.Context, apiKey string, phoneMD5 string, isHit bool, responseTime int, clientIP, userAgent, requestID string)
+	RecordQueryLog(ctx context.Context, record *QueryLogRecord)
 	UpdateAPIKeyUsage(ctx context.Context, apiKey string) error
+	IsIPAllowed(clientIP, whitelist string) bool
+}
+
+// QueryLogRecord 一次查询请求的日志信息
+type QueryLogRecord struct {
+	TenantID       uint64
+	APIKey         string
+	RequestID      string
+	IdentifierType string
+	HashType       string
+	Results        map[string]bool // 查询的哈希及是否命中，请求参数错误时为空
+	IsHit          bool            // 单个查询是否命中，用于实时统计
+	StatusCode     int
+	ResponseTime   int
+	ClientIP       string
+	UserAgent      string
+	SampleRate     float64 // API密钥的详细日志采样率
+}
+
+// queryLogs 构建需要记录的查询日志：错误请求和抽中的请求记录全部标识，其余请求只记录命中的标识
+func (r *QueryLogRecord) queryLogs(sampled bool) []*models.BlacklistQueryLog {
+	failed := r.StatusCode >= 400
+	newLog := func(hash string, isHit bool) *models.BlacklistQueryLog {
+		return &models.BlacklistQueryLog{
+			TenantID:       r.TenantID,
+			APIKey:         r.APIKey,
+			RequestID:      r.RequestID,
+			IdentifierType: r.IdentifierType,
+			HashType:       r.HashType,
+			IdentifierHash: hash,
+			IsHit:          isHit,
+			StatusCode:     r.StatusCode,
+			ResponseTime:   r.ResponseTime,
+			Sampled:        sampled,
+			ClientIP:       r.ClientIP,
+			UserAgent:      r.UserAgent,
+		}
+	}
+
+	if len(r.Results) == 0 {
+		if failed {
+			return []*models.BlacklistQueryLog{newLog("", false)}
+		}
+		return nil
+	}
+
+	logs := make([]*models.BlacklistQueryLog, 0, len(r.Results))
+	for hash, isHit := range r.Results {
+		if failed || sampled || isHit {
+			logs = append(logs, newLog(hash, isHit))
+		}
+	}
+	return logs
 }
 
 // blacklistAuthService 黑名单鉴权服务实现
 type blacklistAuthService struct {
-	apiCredRepo repositories.ApiCredentialRepository
-	redis       *redisClient.Client
-	logger      *logger.Logger
+	apiCredRepo    repositories.ApiCredentialRepository
+	queryLogWriter QueryLogWriter
+	redis          *redisClient.Client
+	logger         *logger.Logger
 }
 
 // NewBlacklistAuthService 创建黑名单鉴权服务
 func NewBlacklistAuthService(
 	apiCredRepo repositories.ApiCredentialRepository,
+	queryLogWriter QueryLogWriter,
 	redis *redisClient.Client,
 	logger *logger.Logger,
 ) BlacklistAuthService {
 	return &blacklistAuthService{
-		apiCredRepo: apiCredRepo,
-		redis:       redis,
-		logger:      logger,
+		apiCredRepo:    apiCredRepo,
+		queryLogWriter: queryLogWriter,
+		redis:          redis,
+		logger:         logger,
 	}
 }
 
@@ -161,15 +220,13 @@ func (s *blacklistAuthService) CheckRateLimit(ctx context.Context, apiKey string
 }
 
 // RecordQueryLog 记录查询日志（异步采样）
-func (s *blacklistAuthService) RecordQueryLog(ctx context.Context, apiKey string, phoneMD5 string, isHit bool, responseTime int, clientIP, userAgent, requestID string) {
-	// 异步记录，不阻塞主流程
-	go func() {
-		// 更新实时统计
-		s.updateRealTimeStats(context.Background(), apiKey, isHit, responseTime)
-
-		// 这里可以根据采样率决定是否记录详细日志
-		// 暂时只记录统计信息，详细日志可以后续添加
-	}()
+// 详细日志按API密钥的采样率抽样，命中和错误请求全部记录，由写入器批量写入数据库
+func (s *blacklistAuthService) RecordQueryLog(ctx context.Context, record *QueryLogRecord) {
+	// 队列已满时写入器直接丢弃，不阻塞主流程
+	sampled := record.SampleRate > 0 && rand.Float64() < record.SampleRate
+	if logs := record.queryLogs(sampled); len(logs) > 0 {
+		s.queryLogWriter.Write(logs...)
+	}
 }
 
 // UpdateAPIKeyUsage 更新API密钥使用时间
@@ -186,6 +243,49 @@ func (s *blacklistAuthService) UpdateAPIKeyUsage(ctx context.Context, apiKey str
 	return nil
 }
 
+// IsIPAllowed 检查IP是否在白名单中，白名单为逗号分隔的IP或CIDR
+func (s *blacklistAuthService) IsIPAllowed(clientIP, whitelist string) bool {
+	// 如果白名单为空，表示不限制IP
+	if whitelist == "" {
+		return true
+	}
+
+	// 解析客户端IP
+	clientIPAddr := net.ParseIP(clientIP)
+	if clientIPAddr == nil {
+		return false
+	}
+
+	// 分割白名单中的IP/CIDR
+	allowedIPs := strings.Split(whitelist, ",")
+	for _, allowedIP := range allowedIPs {
+		allowedIP = strings.TrimSpace(allowedIP)
+		if allowedIP == "" {
+			continue
+		}
+
+		// 检查是否为CIDR格式
+		if strings.Contains(allowedIP, "/") {
+			_, ipNet, err := net.ParseCIDR(allowedIP)
+			if err != nil {
+				s.logger.Warn("无效的CIDR格式", zap.String("cidr", allowedIP))
+				continue
+			}
+			if ipNet.Contains(clientIPAddr) {
+				return true
+			}
+		} else {
+			// 单个IP地址
+			allowedIPAddr := net.ParseIP(allowedIP)
+			if allowedIPAddr != nil && allowedIPAddr.Equal(clientIPAddr) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // generateHMACSignature 生成HMAC签名
 func (s *blacklistAuthService) generateHMACSignature(apiKey, timestamp, nonce, body, secret string) string {
 	// 签名字符串格式: apiKey + timestamp + nonce + body
@@ -197,41 +297,15 @@ func (s *blacklistAuthService) generateHMACSignature(apiKey, timestamp, nonce, b
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-// updateRealTimeStats 更新实时统计信息
-func (s *blacklistAuthService) updateRealTimeStats(ctx context.Context, apiKey string, isHit bool, responseTime int) {
-	// 按小时聚合统计
-	now := time.Now()
-	statsKey := fmt.Sprintf("stats:query:%s:%s", apiKey, now.Format("2006010215"))
-
-	// 使用Redis Pipeline批量更新
-	pipe := s.redis.Pipeline()
-
-	// 总请求数+1
-	pipe.HIncrBy(ctx, statsKey, "total", 1)
-
-	// 命中数
-	if isHit {
-		pipe.HIncrBy(ctx, statsKey, "hits", 1)
-	}
-
-	// 累计响应时间
-	pipe.HIncrBy(ctx, statsKey, "latency", int64(responseTime))
-
-	// 设置过期时间（保留48小时）
-	pipe.Expire(ctx, statsKey, 48*time.Hour)
-
-	_, err := pipe.Exec(ctx)
-	if err != nil {
-		s.logger.WarnWithTrace(ctx, "更新实时统计失败",
-			zap.String("api_key", apiKey),
-			zap.Error(err))
-	}
+// apiCredentialCacheKey API密钥信息的缓存key
+func apiCredentialCacheKey(apiKey string) string {
+	return fmt.Sprintf("api_credential:%s", apiKey)
 }
 
 // getAPICredentialWithCache 获取API密钥信息（带缓存）
 func (s *blacklistAuthService) getAPICredentialWithCache(ctx context.Context, apiKey string) (*models.BlacklistApiCredential, error) {
 	// 缓存key
-	cacheKey := fmt.Sprintf("api_credential:%s", apiKey)
+	cacheKey := apiCredentialCacheKey(apiKey)
 
 	// 1. 尝试从缓存获取
 	credentialJSON, err := s.redis.Get(ctx, cacheKey).Result()
@@ -269,7 +343,7 @@ func (s *blacklistAuthService) getAPICredentialWithCache(ctx context.Context, ap
 
 // invalidateAPICredentialCache 清除API密钥缓存
 func (s *blacklistAuthService) invalidateAPICredentialCache(ctx context.Context, apiKey string) {
-	cacheKey := fmt.Sprintf("api_credential:%s", apiKey)
+	cacheKey := apiCredentialCacheKey(apiKey)
 	err := s.redis.Del(ctx, cacheKey).Err()
 	if err != nil {
 		s.logger.WarnWithTrace(ctx, "清除API密钥缓存失败",
@@ -0,0 +1,101 @@
+// Package services provides business logic layer implementations.
+// This file contains the background sweeper that retires expired blacklist entries.
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/varluffy/shield/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// blacklistExpirySweepInterval 过期条目清理间隔
+	blacklistExpirySweepInterval = time.Minute
+	// blacklistExpirySweepBatch 每批清理的条目数量
+	blacklistExpirySweepBatch = 500
+)
+
+// isExpiredScore 根据ZSCORE结果判断条目是否已过期（未设置过期时间时返回false）
+func isExpiredScore(cmd *redis.FloatCmd, now time.Time) bool {
+	score, err := cmd.Result()
+	if err != nil {
+		return false
+	}
+	return int64(score) <= now.Unix()
+}
+
+// sweepExpiredLoop 定期清理已过期的黑名单条目
+func (s *blacklistService) sweepExpiredLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(blacklistExpirySweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.sweepExpired(context.Background()); err != nil {
+				s.logger.Warn("清理过期黑名单失败", zap.Error(err))
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// sweepExpired 将已过期的条目从Redis中移除并标记为无效，返回处理数量
+// 多实例同时执行时操作是幂等的
+func (s *blacklistService) sweepExpired(ctx context.Context) (int, error) {
+	total := 0
+
+	for {
+		now := time.Now()
+		expired, err := s.blacklistRepo.GetExpiredActive(ctx, now, blacklistExpirySweepBatch)
+		if err != nil {
+			return total, fmt.Errorf("查询过期黑名单失败: %w", err)
+		}
+		if len(expired) == 0 {
+			return total, nil
+		}
+
+		// 按租户分组从Redis中移除全部哈希格式
+		byTenant := make(map[uint64][]*models.PhoneBlacklist)
+		ids := make([]uint64, 0, len(expired))
+		for _, blacklist := range expired {
+			byTenant[blacklist.TenantID] = append(byTenant[blacklist.TenantID], blacklist)
+			ids = append(ids, blacklist.ID)
+		}
+
+		// Redis移除失败时不更新数据库，下次清理时重试
+		for tenantID, blacklists := range byTenant {
+			salt, err := s.getHashSalt(ctx, tenantID)
+			if err != nil {
+				return total, err
+			}
+			if err := s.removeEntriesFromRedis(ctx, tenantID, blacklists, salt); err != nil {
+				return total, fmt.Errorf("从Redis移除过期黑名单失败: %w", err)
+			}
+		}
+
+		// 期间被重新添加的条目不会被标记为无效，其Redis成员由对账任务补回
+		if err := s.blacklistRepo.DeactivateExpiredByIDs(ctx, ids, now); err != nil {
+			return total, fmt.Errorf("标记过期黑名单失败: %w", err)
+		}
+
+		for tenantID, blacklists := range byTenant {
+			s.filter.remove(tenantID, len(blacklists))
+		}
+
+		total += len(expired)
+		s.logger.Info("清理过期黑名单",
+			zap.Int("count", len(expired)),
+			zap.Int("tenants", len(byTenant)))
+
+		if len(expired) < blacklistExpirySweepBatch {
+			return total, nil
+		}
+	}
+}
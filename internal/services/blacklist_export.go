@@ -0,0 +1,191 @@
+// Package services provides business logic layer implementations.
+// This file contains streamed blacklist export.
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"go.uber.org/zap"
+)
+
+// 导出格式
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// exportBatchSize 每次从数据库读取的记录数
+const exportBatchSize = 1000
+
+// exportColumns CSV表头，与exportRecord字段一一对应
+var exportColumns = []string{
+	"uuid", "identifier_type", "phone_md5", "identifier_sha256", "source", "reason",
+	"category", "risk_score", "operator_id", "is_active", "expires_at", "created_at",
+}
+
+// ExportParams 黑名单导出参数
+type ExportParams struct {
+	TenantID    uint64
+	Format      string
+	Source      string
+	Active      *bool      // true: 有效且未过期；false: 已失效或已过期
+	CreatedFrom *time.Time // 创建时间下限（含）
+	CreatedTo   *time.Time // 创建时间上限（不含）
+}
+
+// exportRecord 导出的单条记录
+type exportRecord struct {
+	UUID             string     `json:"uuid"`
+	IdentifierType   string     `json:"identifier_type"`
+	PhoneMD5         string     `json:"phone_md5"`
+	IdentifierSHA256 string     `json:"identifier_sha256"`
+	Source           string     `json:"source"`
+	Reason           string     `json:"reason"`
+	Category         string     `json:"category"`
+	RiskScore        int        `json:"risk_score"`
+	OperatorID       uint64     `json:"operator_id"`
+	IsActive         bool       `json:"is_active"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// newExportRecord 构建导出记录，已过期的条目导出为无效
+func newExportRecord(blacklist *models.PhoneBlacklist, now time.Time) *exportRecord {
+	return &exportRecord{
+		UUID:             blacklist.UUID,
+		IdentifierType:   blacklist.IdentifierType,
+		PhoneMD5:         blacklist.PhoneMD5,
+		IdentifierSHA256: blacklist.IdentifierSHA256,
+		Source:           blacklist.Source,
+		Reason:           blacklist.Reason,
+		Category:         blacklist.Category,
+		RiskScore:        blacklist.RiskScore,
+		OperatorID:       blacklist.OperatorID,
+		IsActive:         blacklist.IsActive && !blacklist.IsExpired(now),
+		ExpiresAt:        blacklist.ExpiresAt,
+		CreatedAt:        blacklist.CreatedAt,
+	}
+}
+
+// csvFields CSV行，时间使用RFC3339格式，永久有效的条目expires_at为空
+func (r *exportRecord) csvFields() []string {
+	expiresAt := ""
+	if r.ExpiresAt != nil {
+		expiresAt = r.ExpiresAt.Format(time.RFC3339)
+	}
+	return []string{
+		r.UUID, r.IdentifierType, r.PhoneMD5, r.IdentifierSHA256, r.Source, r.Reason,
+		r.Category, strconv.Itoa(r.RiskScore), strconv.FormatUint(r.OperatorID, 10),
+		strconv.FormatBool(r.IsActive), expiresAt, r.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// exportEncoder 按格式逐条写出记录
+type exportEncoder interface {
+	encode(record *exportRecord) error
+	flush() error
+}
+
+// csvExportEncoder CSV编码器，首行为表头
+type csvExportEncoder struct {
+	writer *csv.Writer
+}
+
+func (e *csvExportEncoder) encode(record *exportRecord) error {
+	return e.writer.Write(record.csvFields())
+}
+
+func (e *csvExportEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// ndjsonExportEncoder NDJSON编码器，每行一个JSON对象
+type ndjsonExportEncoder struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExportEncoder) encode(record *exportRecord) error {
+	return e.encoder.Encode(record)
+}
+
+func (e *ndjsonExportEncoder) flush() error {
+	return e.buf.Flush()
+}
+
+// newExportEncoder 创建指定格式的编码器
+func newExportEncoder(format string, w io.Writer) (exportEncoder, error) {
+	switch format {
+	case ExportFormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(exportColumns); err != nil {
+			return nil, err
+		}
+		return &csvExportEncoder{writer: writer}, nil
+	case ExportFormatNDJSON:
+		buf := bufio.NewWriter(w)
+		encoder := json.NewEncoder(buf)
+		encoder.SetEscapeHTML(false)
+		return &ndjsonExportEncoder{buf: buf, encoder: encoder}, nil
+	default:
+		return nil, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+}
+
+// ExportBlacklist 按ID游标分批读取租户黑名单并流式写入w，内存中最多保留一批记录，返回导出的记录数
+// 每批写完后刷新到w，调用方可据此边读边向客户端发送
+func (s *blacklistService) ExportBlacklist(ctx context.Context, params *ExportParams, w io.Writer) (int64, error) {
+	encoder, err := newExportEncoder(params.Format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := repositories.BlacklistExportFilter{
+		Source:      params.Source,
+		Active:      params.Active,
+		CreatedFrom: params.CreatedFrom,
+		CreatedTo:   params.CreatedTo,
+	}
+
+	var exported int64
+	var afterID uint64
+	for {
+		blacklists, err := s.blacklistRepo.GetExportBatch(ctx, params.TenantID, filter, afterID, exportBatchSize)
+		if err != nil {
+			return exported, fmt.Errorf("读取黑名单失败: %w", err)
+		}
+
+		now := time.Now()
+		for _, blacklist := range blacklists {
+			if err := encoder.encode(newExportRecord(blacklist, now)); err != nil {
+				return exported, fmt.Errorf("写入导出数据失败: %w", err)
+			}
+			exported++
+		}
+		if err := encoder.flush(); err != nil {
+			return exported, fmt.Errorf("写入导出数据失败: %w", err)
+		}
+
+		if len(blacklists) < exportBatchSize {
+			break
+		}
+		afterID = blacklists[len(blacklists)-1].ID
+	}
+
+	s.logger.InfoWithTrace(ctx, "导出黑名单完成",
+		zap.Uint64("tenant_id", params.TenantID),
+		zap.String("format", params.Format),
+		zap.Int64("exported", exported))
+
+	return exported, nil
+}
@@ -0,0 +1,360 @@
+// Package services provides business logic layer implementations.
+// This file contains plaintext phone file import for blacklists.
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/pkg/errors"
+	"github.com/varluffy/shield/pkg/sheet"
+	"go.uber.org/zap"
+)
+
+// 文件导入限制
+const (
+	FileImportChunkSize = 1000   // 每批写入数据库的行数
+	MaxFileImportRows   = 100000 // 单个文件最多导入的行数，超出部分不处理
+)
+
+// 文件导入行状态
+const (
+	ImportRowAccepted  = "accepted"  // 新增到黑名单
+	ImportRowDuplicate = "duplicate" // 文件内重复或已在黑名单中
+	ImportRowInvalid   = "invalid"   // 手机号为空或格式错误
+)
+
+// FileImportParams 文件导入参数
+type FileImportParams struct {
+	TenantID   uint64
+	Rows       sheet.Reader
+	FileName   string
+	Column     int    // 手机号所在列，从0开始
+	ColumnName string // 按表头名称定位手机号列，不为空时优先于Column
+	Source     string
+	Reason     string
+	Category   string
+	RiskScore  int
+	OperatorID uint64
+	ExpiresAt  *time.Time
+}
+
+// FileImportRow 单行导入结果
+type FileImportRow struct {
+	Row    int    // 文件中的行号
+	Value  string // 原始单元格值
+	Status string
+	Reason string
+}
+
+// FileImportResult 文件导入结果
+type FileImportResult struct {
+	BatchID    string // 导入批次UUID，可用于回滚
+	Accepted   int
+	Duplicate  int
+	Invalid    int
+	Backfilled int  // 已在黑名单中的条目补充缺少哈希格式的数量，计入Duplicate
+	Truncated  bool // 超过MaxFileImportRows时为true，超出的行未处理
+	Rows       []FileImportRow
+}
+
+// pendingPhone 等待写入的手机号
+type pendingPhone struct {
+	index  int // 在结果Rows中的下标
+	hashes IdentifierHashes
+}
+
+// NormalizePhone 规范化明文手机号：去除空格、横线和+86/0086前缀，校验为11位1开头的数字
+func NormalizePhone(raw string) (string, bool) {
+	phone := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '\t', '\u00a0', '\u3000':
+			return -1
+		}
+		return r
+	}, raw)
+
+	switch {
+	case strings.HasPrefix(phone, "+86"):
+		phone = phone[3:]
+	case strings.HasPrefix(phone, "0086"):
+		phone = phone[4:]
+	case len(phone) == 13 && strings.HasPrefix(phone, "86"):
+		phone = phone[2:]
+	}
+
+	if len(phone) != 11 || phone[0] != '1' {
+		return "", false
+	}
+	for _, c := range phone {
+		if c < '0' || c > '9' {
+			return "", false
+		}
+	}
+	return phone, true
+}
+
+// ImportBlacklistFile 逐行读取明文手机号文件，服务端规范化并计算哈希后分批导入
+// 每批独立提交，重复导入同一文件时已导入的行会报告为重复，不会产生重复条目
+func (s *blacklistService) ImportBlacklistFile(ctx context.Context, params *FileImportParams) (*FileImportResult, error) {
+	if err := s.ensureDirectWriteAllowed(ctx, params.TenantID, "文件导入"); err != nil {
+		return nil, err
+	}
+
+	salt, err := s.getHashSalt(ctx, params.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	importParams := &BatchImportParams{
+		TenantID:       params.TenantID,
+		IdentifierType: models.IdentifierTypePhone,
+		Source:         params.Source,
+		Reason:         params.Reason,
+		Category:       params.Category,
+		RiskScore:      params.RiskScore,
+		OperatorID:     params.OperatorID,
+		ExpiresAt:      params.ExpiresAt,
+	}
+
+	batch, err := s.startImportBatch(ctx, importParams, models.IdentifierTypePhone, models.ImportBatchMethodFile, params.FileName)
+	if err != nil {
+		return nil, err
+	}
+	importParams.BatchID = batch.ID
+
+	result := &FileImportResult{BatchID: batch.UUID, Rows: make([]FileImportRow, 0)}
+	// 每批独立提交，中途失败时已提交的条目同样归属该批次
+	defer func() {
+		s.completeImportBatch(ctx, batch, int64(result.Accepted))
+	}()
+	source := newImportRowSource(params.Rows, params.Column, params.ColumnName)
+	seen := make(map[string]int) // 规范化手机号 -> 首次出现的行号
+	pending := make([]pendingPhone, 0, FileImportChunkSize)
+
+	for {
+		row, raw, err := source.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(result.Rows) >= MaxFileImportRows {
+			result.Truncated = true
+			break
+		}
+
+		phone, ok := NormalizePhone(raw)
+		switch {
+		case raw == "":
+			result.addRow(row.Number, raw, ImportRowInvalid, "手机号为空")
+		case !ok:
+			result.addRow(row.Number, raw, ImportRowInvalid, "手机号格式错误")
+		case seen[phone] > 0:
+			result.addRow(row.Number, raw, ImportRowDuplicate, fmt.Sprintf("与第%d行重复", seen[phone]))
+		default:
+			seen[phone] = row.Number
+			pending = append(pending, pendingPhone{index: len(result.Rows), hashes: NewIdentifierHashes(phone)})
+			result.Rows = append(result.Rows, FileImportRow{Row: row.Number, Value: raw})
+		}
+
+		if len(pending) >= FileImportChunkSize {
+			if err := s.importPendingPhones(ctx, importParams, salt, pending, result); err != nil {
+				return nil, err
+			}
+			pending = pending[:0]
+		}
+	}
+
+	if err := s.importPendingPhones(ctx, importParams, salt, pending, result); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithTrace(ctx, "文件导入黑名单完成",
+		zap.Uint64("tenant_id", params.TenantID),
+		zap.Int("accepted", result.Accepted),
+		zap.Int("duplicate", result.Duplicate),
+		zap.Int("invalid", result.Invalid),
+		zap.Int("backfilled", result.Backfilled),
+		zap.Bool("truncated", result.Truncated),
+		zap.String("source", params.Source))
+
+	return result, nil
+}
+
+// importPendingPhones 写入一批手机号：已在黑名单中的标记为重复（已有条目补充缺少的哈希格式），其余新增
+func (s *blacklistService) importPendingPhones(ctx context.Context, params *BatchImportParams, salt string, pending []pendingPhone, result *FileImportResult) error {
+	if len(pending) == 0 {
+		return nil
+	}
+
+	hashes := make([]IdentifierHashes, 0, len(pending))
+	for _, p := range pending {
+		hashes = append(hashes, p.hashes)
+	}
+	existing, err := s.existingHashes(ctx, params.TenantID, models.IdentifierTypePhone, hashes)
+	if err != nil {
+		return err
+	}
+
+	duplicates := make([]IdentifierHashes, 0)
+	accepted := make([]pendingPhone, 0, len(pending))
+	items := make([]IdentifierHashes, 0, len(pending))
+	for _, p := range pending {
+		if existing.contains(p.hashes) {
+			duplicates = append(duplicates, p.hashes)
+			result.Rows[p.index].Status = ImportRowDuplicate
+			result.Rows[p.index].Reason = "已在黑名单中"
+			result.Duplicate++
+			continue
+		}
+		accepted = append(accepted, p)
+		items = append(items, p.hashes)
+	}
+
+	backfilled, _, err := s.backfillHashes(ctx, params.TenantID, models.DefaultListID, models.IdentifierTypePhone, duplicates)
+	if err != nil {
+		return err
+	}
+	created, err := s.createEntries(ctx, params, models.IdentifierTypePhone, items)
+	if err != nil {
+		return err
+	}
+	for _, p := range accepted {
+		result.Rows[p.index].Status = ImportRowAccepted
+	}
+	result.Accepted += len(created)
+	result.Backfilled += len(backfilled)
+
+	s.syncEntries(ctx, params.TenantID, append(created, backfilled...), salt)
+	return nil
+}
+
+// hashSet 已存在条目的MD5和SHA-256集合
+type hashSet map[string]bool
+
+// contains 任一哈希格式已存在即视为已存在
+func (h hashSet) contains(hashes IdentifierHashes) bool {
+	return (hashes.MD5 != "" && h[hashes.MD5]) || (hashes.SHA256 != "" && h[hashes.SHA256])
+}
+
+// existingHashes 查询已在默认名单中的有效条目，返回其全部哈希（文件导入和异步导入只写入默认名单）
+func (s *blacklistService) existingHashes(ctx context.Context, tenantID uint64, identifierType string, items []IdentifierHashes) (hashSet, error) {
+	md5List := make([]string, 0, len(items))
+	sha256List := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.MD5 != "" {
+			md5List = append(md5List, item.MD5)
+		}
+		if item.SHA256 != "" {
+			sha256List = append(sha256List, item.SHA256)
+		}
+	}
+
+	existingByMD5, err := s.blacklistRepo.GetActiveByTenantAndHashes(ctx, tenantID, []uint64{models.DefaultListID}, identifierType, models.HashTypeMD5, md5List)
+	if err != nil {
+		return nil, fmt.Errorf("查询已存在条目失败: %w", err)
+	}
+	existingBySHA256, err := s.blacklistRepo.GetActiveByTenantAndHashes(ctx, tenantID, []uint64{models.DefaultListID}, identifierType, models.HashTypeSHA256, sha256List)
+	if err != nil {
+		return nil, fmt.Errorf("查询已存在条目失败: %w", err)
+	}
+
+	existing := make(hashSet, len(existingByMD5)+len(existingBySHA256))
+	for _, blacklist := range append(existingByMD5, existingBySHA256...) {
+		if blacklist.PhoneMD5 != "" {
+			existing[blacklist.PhoneMD5] = true
+		}
+		if blacklist.IdentifierSHA256 != "" {
+			existing[blacklist.IdentifierSHA256] = true
+		}
+	}
+	return existing, nil
+}
+
+// importRowSource 从表格中逐个读取数据行：处理表头、跳过空行，返回数据列的值
+type importRowSource struct {
+	rows       sheet.Reader
+	column     int
+	columnName string
+	started    bool
+}
+
+// newImportRowSource 创建数据行读取器，column从0开始，columnName不为空时按表头定位
+func newImportRowSource(rows sheet.Reader, column int, columnName string) *importRowSource {
+	return &importRowSource{rows: rows, column: column, columnName: columnName}
+}
+
+// next 返回下一个数据行及其数据列的值（已去除首尾空格），读取完毕返回io.EOF
+func (r *importRowSource) next() (*sheet.Row, string, error) {
+	for {
+		row, err := r.rows.Read()
+		if err == io.EOF {
+			return nil, "", io.EOF
+		}
+		if err != nil {
+			return nil, "", errors.ErrValidationFailed(fmt.Sprintf("文件解析失败: %v", err))
+		}
+
+		// 第一行按表头处理：指定列名时定位数据列，否则不含数字的单元格视为表头
+		if !r.started {
+			r.started = true
+			if r.columnName != "" {
+				if r.column = headerColumn(row, r.columnName); r.column < 0 {
+					return nil, "", errors.ErrValidationFailed(fmt.Sprintf("表头中不存在列: %s", r.columnName))
+				}
+				continue
+			}
+			if isHeaderCell(row.Cell(r.column)) {
+				continue
+			}
+		}
+
+		if isBlankRow(row) {
+			continue
+		}
+		return row, strings.TrimSpace(row.Cell(r.column)), nil
+	}
+}
+
+// addRow 记录无需写入的行
+func (r *FileImportResult) addRow(number int, value, status, reason string) {
+	r.Rows = append(r.Rows, FileImportRow{Row: number, Value: value, Status: status, Reason: reason})
+	switch status {
+	case ImportRowDuplicate:
+		r.Duplicate++
+	case ImportRowInvalid:
+		r.Invalid++
+	}
+}
+
+// headerColumn 按名称查找表头列，忽略大小写和首尾空格
+func headerColumn(row *sheet.Row, name string) int {
+	for idx, cell := range row.Cells {
+		if strings.EqualFold(strings.TrimSpace(cell), strings.TrimSpace(name)) {
+			return idx
+		}
+	}
+	return -1
+}
+
+// isHeaderCell 不含数字的非空单元格视为表头
+func isHeaderCell(cell string) bool {
+	cell = strings.TrimSpace(cell)
+	return cell != "" && !strings.ContainsAny(cell, "0123456789")
+}
+
+// isBlankRow 所有单元格均为空的行
+func isBlankRow(row *sheet.Row) bool {
+	for _, cell := range row.Cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,535 @@
+// Package services provides business logic layer implementations.
+// This file contains the in-process bloom filter tier used in front of Redis for blacklist checks.
+package services
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/varluffy/shield/pkg/bloom"
+	"github.com/varluffy/shield/pkg/logger"
+	redisClient "github.com/varluffy/shield/pkg/redis"
+	"go.uber.org/zap"
+)
+
+const (
+	// blacklistFilterChannel 跨实例同步新增条目和重建通知的Redis频道
+	blacklistFilterChannel = "blacklist:filter:events"
+	// blacklistFilterMinCapacity 过滤器最小容量
+	blacklistFilterMinCapacity = 10000
+	// blacklistFilterFPRate 过滤器设计误判率
+	blacklistFilterFPRate = 0.001
+	// blacklistFilterRebuildInterval 定期重建间隔（兜底丢失的同步消息并清理已删除条目）
+	blacklistFilterRebuildInterval = 30 * time.Minute
+	// blacklistFilterRetryInterval 重建失败后的重试间隔
+	blacklistFilterRetryInterval = 10 * time.Second
+	// blacklistFilterVerifyInterval 版本校验的有效期，超过后不信任过滤器的阴性判定，直接回源
+	blacklistFilterVerifyInterval = time.Second
+	// blacklistFilterVerifyRetryInterval 版本校验的最小间隔，避免校验失败或版本落后时每次查询都访问Redis
+	blacklistFilterVerifyRetryInterval = 100 * time.Millisecond
+	// blacklistFilterSyncGrace 版本落后时等待在途同步消息的时长，超过后视为消息丢失并重建
+	blacklistFilterSyncGrace = time.Second
+)
+
+// blacklistFilterVersionKey 租户过滤器同步版本，每次广播新增条目或重建通知时递增
+func blacklistFilterVersionKey(tenantID uint64) string {
+	return fmt.Sprintf("blacklist:filter:version:tenant:%d", tenantID)
+}
+
+// filterDecision 过滤器判定结果
+type filterDecision int
+
+const (
+	filterBypass   filterDecision = iota // 过滤器未就绪，直接回源
+	filterNegative                       // 一定不在黑名单中
+	filterPositive                       // 可能在黑名单中，需要回源确认
+)
+
+// BlacklistFilterStats 本地过滤器统计信息
+type BlacklistFilterStats struct {
+	TenantID                   uint64    `json:"tenant_id"`
+	Ready                      bool      `json:"ready"`
+	Entries                    int64     `json:"entries"`
+	Capacity                   int       `json:"capacity"`
+	SizeBytes                  int       `json:"size_bytes"`
+	HashFunctions              int       `json:"hash_functions"`
+	Lookups                    int64     `json:"lookups"`
+	Negatives                  int64     `json:"negatives"`
+	Positives                  int64     `json:"positives"`
+	FalsePositives             int64     `json:"false_positives"`
+	Bypassed                   int64     `json:"bypassed"`
+	Removals                   int64     `json:"removals"`
+	SyncGaps                   int64     `json:"sync_gaps"` // 发现同步消息丢失而重建的次数
+	ObservedFalsePositiveRate  float64   `json:"observed_false_positive_rate"`
+	EstimatedFalsePositiveRate float64   `json:"estimated_false_positive_rate"`
+	BuiltAt                    time.Time `json:"built_at"`
+}
+
+// blacklistFilterEvent 跨实例同步消息
+type blacklistFilterEvent struct {
+	Origin   string   `json:"origin"`
+	TenantID uint64   `json:"tenant_id"`
+	Values   []string `json:"values,omitempty"`
+	Rebuild  bool     `json:"rebuild,omitempty"` // 数据源整体变化，需要重建
+	Version  int64    `json:"version,omitempty"` // 广播时递增后的租户同步版本，递增失败时为0
+}
+
+// tenantFilter 单个租户的过滤器状态
+type tenantFilter struct {
+	filter      atomic.Pointer[bloom.Filter]
+	builtAt     atomic.Int64
+	attemptedAt atomic.Int64
+	building    atomic.Bool
+
+	// 重建期间的新增条目，重建完成后补写到新过滤器
+	mu      sync.Mutex
+	pending []string
+
+	// 同步进度，均由mu保护：过滤器包含baseVersion及之前的全部条目，received为之后收到的同步消息数，
+	// Redis中的版本减去两者之和即为未收到的消息数；seen为重建期间收到的消息版本，重建完成后按新的baseVersion计入received
+	baseVersion int64
+	received    int64
+	seen        []int64
+	gapSince    time.Time
+
+	// unversioned 递增同步版本失败后未计入版本的广播数，下次广播时一并计入，使漏收消息的实例发现缺口
+	unversioned atomic.Int64
+
+	verifiedAt  atomic.Int64 // 最近一次确认没有未收到的同步消息的时间
+	verifyingAt atomic.Int64 // 最近一次发起版本校验的时间
+	verifying   atomic.Bool
+
+	removals       atomic.Int64
+	syncGaps       atomic.Int64
+	lookups        atomic.Int64
+	negatives      atomic.Int64
+	positives      atomic.Int64
+	falsePositives atomic.Int64
+	bypassed       atomic.Int64
+}
+
+// blacklistFilter 按租户维护的本地布隆过滤器
+// 过滤器只会产生假阳性：新增条目同步写入，删除条目仅计数并在阈值后重建
+// 跨实例的新增条目通过Pub/Sub同步，消息可能在断线或广播失败时丢失，阴性判定只在最近一次版本校验确认没有丢失消息后才被信任
+type blacklistFilter struct {
+	tenants sync.Map // key: uint64 tenantID, value: *tenantFilter
+	origin  string   // 实例标识，用于忽略自己发出的同步消息
+	loader  func(ctx context.Context, tenantID uint64) ([]string, error)
+	redis   *redisClient.Client
+	logger  *logger.Logger
+}
+
+// newBlacklistFilter 创建本地过滤器并订阅跨实例同步消息
+func newBlacklistFilter(
+	loader func(ctx context.Context, tenantID uint64) ([]string, error),
+	redis *redisClient.Client,
+	logger *logger.Logger,
+) *blacklistFilter {
+	f := &blacklistFilter{
+		origin: uuid.New().String(),
+		loader: loader,
+		redis:  redis,
+		logger: logger,
+	}
+
+	if redis != nil {
+		go f.subscribe()
+	}
+
+	return f
+}
+
+// tenant 获取租户过滤器状态
+func (f *blacklistFilter) tenant(tenantID uint64) *tenantFilter {
+	if tf, ok := f.tenants.Load(tenantID); ok {
+		return tf.(*tenantFilter)
+	}
+	tf, _ := f.tenants.LoadOrStore(tenantID, &tenantFilter{})
+	return tf.(*tenantFilter)
+}
+
+// check 使用本地过滤器判定
+func (f *blacklistFilter) check(tenantID uint64, value string) filterDecision {
+	tf := f.tenant(tenantID)
+	tf.lookups.Add(1)
+
+	bf := tf.filter.Load()
+	if bf == nil || f.needsRebuild(tf, bf) {
+		f.rebuildAsync(tenantID)
+	}
+	if bf == nil {
+		tf.bypassed.Add(1)
+		return filterBypass
+	}
+
+	if bf.MayContain(value) {
+		tf.positives.Add(1)
+		return filterPositive
+	}
+	if !f.verified(tenantID, tf) {
+		tf.bypassed.Add(1)
+		return filterBypass
+	}
+
+	tf.negatives.Add(1)
+	return filterNegative
+}
+
+// verified 过滤器是否已确认包含其他实例广播的全部新增条目
+// 校验在有效期过半时提前异步发起，校验过期（Redis不可用或存在未收到的消息）时返回false
+func (f *blacklistFilter) verified(tenantID uint64, tf *tenantFilter) bool {
+	if f.redis == nil {
+		return true
+	}
+	age := time.Since(time.Unix(0, tf.verifiedAt.Load()))
+	if age > blacklistFilterVerifyInterval/2 {
+		f.verifyAsync(tenantID, tf)
+	}
+	return age <= blacklistFilterVerifyInterval
+}
+
+// verifyAsync 异步比较Redis中的同步版本和已收到的消息，消息丢失时重建过滤器
+func (f *blacklistFilter) verifyAsync(tenantID uint64, tf *tenantFilter) {
+	if time.Since(time.Unix(0, tf.verifyingAt.Load())) < blacklistFilterVerifyRetryInterval {
+		return
+	}
+	if !tf.verifying.CompareAndSwap(false, true) {
+		return
+	}
+	tf.verifyingAt.Store(time.Now().UnixNano())
+
+	go func() {
+		defer tf.verifying.Store(false)
+
+		version, err := f.redis.Get(context.Background(), blacklistFilterVersionKey(tenantID)).Int64()
+		if err != nil && !stderrors.Is(err, redis.Nil) {
+			f.logger.Debug("获取黑名单过滤器同步版本失败",
+				zap.Uint64("tenant_id", tenantID),
+				zap.Error(err))
+			return
+		}
+
+		now := time.Now()
+		lost := false
+		tf.mu.Lock()
+		switch {
+		case version <= tf.baseVersion+tf.received:
+			tf.gapSince = time.Time{}
+			tf.verifiedAt.Store(now.UnixNano())
+		case tf.gapSince.IsZero():
+			// 消息可能仍在投递中，下次校验时再判断
+			tf.gapSince = now
+		case now.Sub(tf.gapSince) > blacklistFilterSyncGrace:
+			tf.gapSince = time.Time{}
+			lost = true
+		}
+		missing := version - tf.baseVersion - tf.received
+		tf.mu.Unlock()
+
+		if lost {
+			tf.syncGaps.Add(1)
+			f.logger.Warn("黑名单过滤器同步消息丢失，重建过滤器",
+				zap.Uint64("tenant_id", tenantID),
+				zap.Int64("version", version),
+				zap.Int64("missing", missing))
+			f.reset(tenantID)
+		}
+	}()
+}
+
+// record 记录收到的同步消息版本
+func (f *blacklistFilter) record(tenantID uint64, version int64) {
+	tf := f.tenant(tenantID)
+
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+	if version > tf.baseVersion {
+		tf.received++
+	}
+	if tf.building.Load() {
+		tf.seen = append(tf.seen, version)
+	}
+}
+
+// recordFalsePositive 记录回源后确认未命中的次数
+func (f *blacklistFilter) recordFalsePositive(tenantID uint64, count int) {
+	if count > 0 {
+		f.tenant(tenantID).falsePositives.Add(int64(count))
+	}
+}
+
+// add 将新增条目写入本地过滤器
+func (f *blacklistFilter) add(tenantID uint64, values ...string) {
+	if len(values) == 0 {
+		return
+	}
+
+	tf := f.tenant(tenantID)
+
+	tf.mu.Lock()
+	if tf.building.Load() {
+		tf.pending = append(tf.pending, values...)
+	}
+	bf := tf.filter.Load()
+	tf.mu.Unlock()
+
+	if bf != nil {
+		for _, value := range values {
+			bf.Add(value)
+		}
+	}
+}
+
+// publish 写入本地过滤器并广播给其他实例
+func (f *blacklistFilter) publish(ctx context.Context, tenantID uint64, values ...string) {
+	f.add(tenantID, values...)
+
+	if f.redis == nil || len(values) == 0 {
+		return
+	}
+
+	f.broadcast(ctx, blacklistFilterEvent{Origin: f.origin, TenantID: tenantID, Values: values})
+}
+
+// broadcast 递增租户同步版本并向其他实例发送同步消息，发送失败时其他实例在版本校验时发现并重建
+// 递增版本失败时仍发送不带版本的消息，并在下次递增成功时补上本次的版本，
+// 未收到该消息的实例届时发现缺口并重建；在此之前它们的过滤器可能缺少这些条目
+func (f *blacklistFilter) broadcast(ctx context.Context, event blacklistFilterEvent) {
+	tf := f.tenant(event.TenantID)
+	unversioned := tf.unversioned.Swap(0)
+	version, err := f.redis.IncrBy(ctx, blacklistFilterVersionKey(event.TenantID), unversioned+1).Result()
+	if err != nil {
+		tf.unversioned.Add(unversioned + 1)
+		f.logger.WarnWithTrace(ctx, "递增黑名单过滤器同步版本失败，发送不带版本的同步消息",
+			zap.Uint64("tenant_id", event.TenantID),
+			zap.Error(err))
+	} else {
+		// 补上的版本对应本实例发出的消息，本实例不缺少这些条目
+		for v := version - unversioned; v <= version; v++ {
+			f.record(event.TenantID, v)
+		}
+		event.Version = version
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	if err := f.redis.Publish(ctx, blacklistFilterChannel, payload).Err(); err != nil {
+		f.logger.WarnWithTrace(ctx, "广播黑名单过滤器更新失败",
+			zap.Uint64("tenant_id", event.TenantID),
+			zap.Int("count", len(event.Values)),
+			zap.Bool("rebuild", event.Rebuild),
+			zap.Error(err))
+	}
+}
+
+// remove 记录删除的条目数量，超过阈值后触发重建
+func (f *blacklistFilter) remove(tenantID uint64, count int) {
+	if count > 0 {
+		f.tenant(tenantID).removals.Add(int64(count))
+	}
+}
+
+// invalidate 数据源整体变化时通知其他实例并触发本实例重建，先递增版本使本实例重建时读取的版本包含本次通知
+func (f *blacklistFilter) invalidate(ctx context.Context, tenantID uint64) {
+	if f.redis != nil {
+		f.broadcast(ctx, blacklistFilterEvent{Origin: f.origin, TenantID: tenantID, Rebuild: true})
+	}
+
+	f.reset(tenantID)
+}
+
+// reset 忽略重试间隔立即触发重建
+func (f *blacklistFilter) reset(tenantID uint64) {
+	f.tenant(tenantID).attemptedAt.Store(0)
+	f.rebuildAsync(tenantID)
+}
+
+// needsRebuild 判断过滤器是否需要重建
+func (f *blacklistFilter) needsRebuild(tf *tenantFilter, bf *bloom.Filter) bool {
+	if time.Since(time.Unix(0, tf.builtAt.Load())) > blacklistFilterRebuildInterval {
+		return true
+	}
+	// 超出设计容量后误判率会快速上升
+	if bf.Count() > int64(bf.Capacity()) {
+		return true
+	}
+	// 删除过多时位图中残留大量无效位
+	return tf.removals.Load() > bf.Count()/4+1
+}
+
+// rebuildAsync 异步重建租户过滤器
+func (f *blacklistFilter) rebuildAsync(tenantID uint64) {
+	tf := f.tenant(tenantID)
+	// 重建失败后短时间内不再重试，避免数据库异常时每次查询都触发重建
+	if time.Since(time.Unix(0, tf.attemptedAt.Load())) < blacklistFilterRetryInterval {
+		return
+	}
+	if !f.beginRebuild(tf) {
+		return
+	}
+	go func() {
+		if err := f.finishRebuild(context.Background(), tenantID, tf); err != nil {
+			f.logger.Warn("重建黑名单过滤器失败",
+				zap.Uint64("tenant_id", tenantID),
+				zap.Error(err))
+		}
+	}()
+}
+
+// rebuild 从数据库同步重建租户过滤器
+func (f *blacklistFilter) rebuild(ctx context.Context, tenantID uint64) error {
+	tf := f.tenant(tenantID)
+	if !f.beginRebuild(tf) {
+		return nil
+	}
+	return f.finishRebuild(ctx, tenantID, tf)
+}
+
+// beginRebuild 标记重建开始，之后的新增条目会暂存到pending
+func (f *blacklistFilter) beginRebuild(tf *tenantFilter) bool {
+	tf.mu.Lock()
+	defer tf.mu.Unlock()
+
+	if !tf.building.CompareAndSwap(false, true) {
+		return false
+	}
+	tf.pending = nil
+	tf.seen = nil
+	tf.attemptedAt.Store(time.Now().UnixNano())
+	return true
+}
+
+// finishRebuild 加载数据构建新过滤器并替换
+// 同步版本在加载数据前读取：递增版本的写入在广播前已提交到数据库，不晚于该版本的条目都包含在加载的数据中
+func (f *blacklistFilter) finishRebuild(ctx context.Context, tenantID uint64, tf *tenantFilter) error {
+	var version int64
+	versionAt := time.Now()
+	var err error
+	if f.redis != nil {
+		version, err = f.redis.Get(ctx, blacklistFilterVersionKey(tenantID)).Int64()
+		if stderrors.Is(err, redis.Nil) {
+			err = nil
+		}
+	}
+	var values []string
+	if err == nil {
+		values, err = f.loader(ctx, tenantID)
+	}
+	if err != nil {
+		tf.mu.Lock()
+		tf.pending = nil
+		tf.seen = nil
+		tf.building.Store(false)
+		tf.mu.Unlock()
+		return err
+	}
+
+	capacity := len(values) * 2
+	if capacity < blacklistFilterMinCapacity {
+		capacity = blacklistFilterMinCapacity
+	}
+
+	bf := bloom.New(capacity, blacklistFilterFPRate)
+	for _, value := range values {
+		bf.Add(value)
+	}
+
+	tf.mu.Lock()
+	for _, value := range tf.pending {
+		bf.Add(value)
+	}
+	tf.pending = nil
+	tf.baseVersion = version
+	tf.received = 0
+	for _, seen := range tf.seen {
+		if seen > version {
+			tf.received++
+		}
+	}
+	tf.seen = nil
+	tf.gapSince = time.Time{}
+	tf.verifiedAt.Store(versionAt.UnixNano())
+	tf.filter.Store(bf)
+	tf.builtAt.Store(time.Now().UnixNano())
+	tf.removals.Store(0)
+	tf.building.Store(false)
+	tf.mu.Unlock()
+
+	f.logger.Debug("黑名单过滤器重建完成",
+		zap.Uint64("tenant_id", tenantID),
+		zap.Int("entries", len(values)),
+		zap.Int("capacity", capacity),
+		zap.Int("size_bytes", bf.SizeBytes()))
+
+	return nil
+}
+
+// subscribe 订阅其他实例的新增条目，断线期间丢失的消息由版本校验发现
+func (f *blacklistFilter) subscribe() {
+	ctx := context.Background()
+	pubsub := f.redis.Subscribe(ctx, blacklistFilterChannel)
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		var event blacklistFilterEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+			f.logger.Warn("解析黑名单过滤器同步消息失败", zap.Error(err))
+			continue
+		}
+		if event.Origin == f.origin {
+			continue
+		}
+		// 只更新本实例已加载的租户，未加载的租户会在首次查询时从数据库构建
+		if _, ok := f.tenants.Load(event.TenantID); !ok {
+			continue
+		}
+		f.record(event.TenantID, event.Version)
+		if event.Rebuild {
+			f.reset(event.TenantID)
+		} else {
+			f.add(event.TenantID, event.Values...)
+		}
+	}
+}
+
+// stats 获取租户过滤器统计
+func (f *blacklistFilter) stats(tenantID uint64) *BlacklistFilterStats {
+	tf := f.tenant(tenantID)
+
+	stats := &BlacklistFilterStats{
+		TenantID:       tenantID,
+		Lookups:        tf.lookups.Load(),
+		Negatives:      tf.negatives.Load(),
+		Positives:      tf.positives.Load(),
+		FalsePositives: tf.falsePositives.Load(),
+		Bypassed:       tf.bypassed.Load(),
+		Removals:       tf.removals.Load(),
+		SyncGaps:       tf.syncGaps.Load(),
+	}
+
+	if bf := tf.filter.Load(); bf != nil {
+		stats.Ready = true
+		stats.Entries = bf.Count()
+		stats.Capacity = bf.Capacity()
+		stats.SizeBytes = bf.SizeBytes()
+		stats.HashFunctions = bf.HashFunctions()
+		stats.EstimatedFalsePositiveRate = bf.EstimatedFalsePositiveRate()
+		stats.BuiltAt = time.Unix(0, tf.builtAt.Load())
+	}
+
+	// 实际误判率 = 假阳性 / (假阳性 + 过滤器判定的阴性)
+	if negatives := stats.Negatives + stats.FalsePositives; negatives > 0 {
+		stats.ObservedFalsePositiveRate = float64(stats.FalsePositives) / float64(negatives)
+	}
+
+	return stats
+}
@@ -0,0 +1,288 @@
+// Package services provides business logic layer implementations.
+// This file contains identifier hash formats, per-tenant hash salts and Redis member helpers for blacklist entries.
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// IdentifierHashes 同一标识的多种哈希格式，至少包含一种
+type IdentifierHashes struct {
+	MD5    string
+	SHA256 string
+}
+
+// NewIdentifierHashes 根据规范化后的明文标识计算各格式哈希
+func NewIdentifierHashes(normalized string) IdentifierHashes {
+	md5Sum := md5.Sum([]byte(normalized))
+	sha256Sum := sha256.Sum256([]byte(normalized))
+	return IdentifierHashes{
+		MD5:    hex.EncodeToString(md5Sum[:]),
+		SHA256: hex.EncodeToString(sha256Sum[:]),
+	}
+}
+
+// hmacIdentifier 计算HMAC-SHA256格式：以租户盐为密钥，对SHA-256十六进制字符串签名
+func hmacIdentifier(salt, sha256Hex string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(sha256Hex))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateHashSalt 生成租户盐
+func generateHashSalt() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// blacklistMember 条目在某种哈希格式下的Redis成员
+type blacklistMember struct {
+	hashType string
+	value    string
+}
+
+// entryMembers 条目在Redis中对应的全部成员：MD5、SHA-256以及由SHA-256推导的HMAC
+func entryMembers(blacklist *models.PhoneBlacklist, salt string) []blacklistMember {
+	return hashMembers(blacklist.PhoneMD5, blacklist.IdentifierSHA256, salt)
+}
+
+// hashMembers 标识MD5和SHA-256对应的全部哈希格式成员，为空的哈希跳过
+func hashMembers(phoneMD5, identifierSHA256, salt string) []blacklistMember {
+	members := make([]blacklistMember, 0, 3)
+	if phoneMD5 != "" {
+		members = append(members, blacklistMember{hashType: models.HashTypeMD5, value: phoneMD5})
+	}
+	if identifierSHA256 != "" {
+		members = append(members,
+			blacklistMember{hashType: models.HashTypeSHA256, value: identifierSHA256},
+			blacklistMember{hashType: models.HashTypeHMACSHA256, value: hmacIdentifier(salt, identifierSHA256)})
+	}
+	return members
+}
+
+// entryFilterValues 条目在本地过滤器中的全部元素
+func entryFilterValues(blacklists []*models.PhoneBlacklist, salt string) []string {
+	values := make([]string, 0, len(blacklists))
+	for _, blacklist := range blacklists {
+		for _, member := range entryMembers(blacklist, salt) {
+			values = append(values, listFilterValue(blacklist.ListID, blacklist.IdentifierType, member.hashType, member.value))
+		}
+	}
+	return values
+}
+
+// encodeEntryMeta 编码条目在某种哈希格式下的风险信息，默认风险信息返回空字符串表示无需存储
+// HMAC格式无法由哈希反查条目，始终存储并附带条目的SHA-256，命中计数按SHA-256定位条目
+func encodeEntryMeta(blacklist *models.PhoneBlacklist, hashType string) string {
+	score := blacklist.RiskScore
+	if score == 0 {
+		score = models.DefaultRiskScore
+	}
+	if hashType == models.HashTypeHMACSHA256 {
+		return strconv.Itoa(score) + "|" + blacklist.Category + "|" + blacklist.IdentifierSHA256
+	}
+	if blacklist.Category == "" && score == models.DefaultRiskScore {
+		return ""
+	}
+	return strconv.Itoa(score) + "|" + blacklist.Category
+}
+
+// newHitResult 根据风险信息HASH查询结果构建命中结果，未存储时使用默认风险信息
+func newHitResult(cmd *redis.StringCmd) *CheckResult {
+	result := &CheckResult{Hit: true, RiskScore: models.DefaultRiskScore}
+	meta, err := cmd.Result()
+	if err != nil {
+		return result
+	}
+
+	parts := strings.SplitN(meta, "|", 3)
+	if score, err := strconv.Atoi(parts[0]); err == nil {
+		result.RiskScore = score
+	}
+	if len(parts) > 1 {
+		result.Category = parts[1]
+	}
+	if len(parts) > 2 {
+		result.entrySHA256 = parts[2]
+	}
+	return result
+}
+
+// entryKeyMembers 按Redis key分组的集合成员、过期时间成员和风险信息
+type entryKeyMembers struct {
+	sets     map[string][]interface{}
+	expiries map[string][]interface{}
+	scores   map[string][]redis.Z
+	metas    map[string][]interface{} // 风险信息HASH的field/value对
+	metaKeys map[string][]string      // 需要清理的风险信息HASH field
+	prefixes map[string][]redis.Z     // 前缀索引ZSET成员，分值均为0，按字典序范围查询
+	// 写入时需要清除的旧过期时间和旧风险信息，条目可能是已失效或已删除后重新添加的，清理任务执行前其旧值仍在Redis中
+	staleExpiries map[string][]interface{}
+	staleMetas    map[string][]string
+}
+
+// groupEntryMembers 将同一租户的条目按名单和Redis key分组
+func groupEntryMembers(tenantID uint64, blacklists []*models.PhoneBlacklist, salt string) *entryKeyMembers {
+	grouped := &entryKeyMembers{
+		sets:     make(map[string][]interface{}),
+		expiries: make(map[string][]interface{}),
+		scores:   make(map[string][]redis.Z),
+		metas:    make(map[string][]interface{}),
+		metaKeys: make(map[string][]string),
+		prefixes: make(map[string][]redis.Z),
+
+		staleExpiries: make(map[string][]interface{}),
+		staleMetas:    make(map[string][]string),
+	}
+	for _, blacklist := range blacklists {
+		for _, member := range entryMembers(blacklist, salt) {
+			meta := encodeEntryMeta(blacklist, member.hashType)
+			setKey := blacklistSetKey(tenantID, blacklist.ListID, blacklist.IdentifierType, member.hashType)
+			expiryKey := blacklistExpiryKey(tenantID, blacklist.ListID, blacklist.IdentifierType, member.hashType)
+			metaKey := blacklistMetaKey(tenantID, blacklist.ListID, blacklist.IdentifierType, member.hashType)
+			prefixKey := blacklistPrefixKey(tenantID, blacklist.ListID, blacklist.IdentifierType, member.hashType)
+			grouped.sets[setKey] = append(grouped.sets[setKey], member.value)
+			grouped.prefixes[prefixKey] = append(grouped.prefixes[prefixKey], redis.Z{Member: member.value})
+			grouped.expiries[expiryKey] = append(grouped.expiries[expiryKey], member.value)
+			grouped.metaKeys[metaKey] = append(grouped.metaKeys[metaKey], member.value)
+			if blacklist.ExpiresAt != nil {
+				grouped.scores[expiryKey] = append(grouped.scores[expiryKey],
+					redis.Z{Score: float64(blacklist.ExpiresAt.Unix()), Member: member.value})
+			} else {
+				grouped.staleExpiries[expiryKey] = append(grouped.staleExpiries[expiryKey], member.value)
+			}
+			if meta != "" {
+				grouped.metas[metaKey] = append(grouped.metas[metaKey], member.value, meta)
+			} else {
+				grouped.staleMetas[metaKey] = append(grouped.staleMetas[metaKey], member.value)
+			}
+		}
+	}
+	return grouped
+}
+
+// addEntries 将条目写入Redis管道
+func (g *entryKeyMembers) addEntries(ctx context.Context, pipe redis.Pipeliner) {
+	for key, values := range g.sets {
+		pipe.SAdd(ctx, key, values...)
+	}
+	for key, values := range g.staleExpiries {
+		pipe.ZRem(ctx, key, values...)
+	}
+	for key, fields := range g.staleMetas {
+		pipe.HDel(ctx, key, fields...)
+	}
+	for key, members := range g.scores {
+		pipe.ZAdd(ctx, key, members...)
+	}
+	for key, pairs := range g.metas {
+		pipe.HSet(ctx, key, pairs...)
+	}
+	for key, members := range g.prefixes {
+		pipe.ZAdd(ctx, key, members...)
+	}
+}
+
+// removeEntries 将条目从Redis管道中移除
+func (g *entryKeyMembers) removeEntries(ctx context.Context, pipe redis.Pipeliner) {
+	for key, values := range g.sets {
+		pipe.SRem(ctx, key, values...)
+	}
+	for key, values := range g.expiries {
+		pipe.ZRem(ctx, key, values...)
+	}
+	for key, fields := range g.metaKeys {
+		pipe.HDel(ctx, key, fields...)
+	}
+	for key, members := range g.prefixes {
+		values := make([]interface{}, len(members))
+		for i, member := range members {
+			values[i] = member.Member
+		}
+		pipe.ZRem(ctx, key, values...)
+	}
+}
+
+// getHashSalt 获取租户盐，不存在时创建
+func (s *blacklistService) getHashSalt(ctx context.Context, tenantID uint64) (string, error) {
+	return loadHashSalt(ctx, s.settingRepo, tenantID)
+}
+
+// loadHashSalt 获取租户盐，不存在时创建租户配置记录，其他服务修改租户配置前也通过它确保记录存在
+func loadHashSalt(ctx context.Context, settingRepo repositories.BlacklistSettingRepository, tenantID uint64) (string, error) {
+	setting, err := settingRepo.GetByTenant(ctx, tenantID)
+	if err == nil {
+		return setting.HashSalt, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("获取租户盐失败: %w", err)
+	}
+
+	salt, err := generateHashSalt()
+	if err != nil {
+		return "", fmt.Errorf("生成租户盐失败: %w", err)
+	}
+	err = settingRepo.CreateIfNotExists(ctx, &models.BlacklistTenantSetting{TenantID: tenantID, HashSalt: salt})
+	if err != nil {
+		return "", fmt.Errorf("保存租户盐失败: %w", err)
+	}
+
+	// 并发创建时以数据库中的值为准
+	setting, err = settingRepo.GetByTenant(ctx, tenantID)
+	if err != nil {
+		return "", fmt.Errorf("获取租户盐失败: %w", err)
+	}
+	return setting.HashSalt, nil
+}
+
+// GetHashSalt 获取租户HMAC-SHA256格式使用的盐
+func (s *blacklistService) GetHashSalt(ctx context.Context, tenantID uint64) (string, error) {
+	return s.getHashSalt(ctx, tenantID)
+}
+
+// RotateHashSalt 轮换租户盐并按新盐重建Redis中的HMAC集合
+func (s *blacklistService) RotateHashSalt(ctx context.Context, tenantID uint64) (string, error) {
+	// 确保配置记录存在
+	if _, err := s.getHashSalt(ctx, tenantID); err != nil {
+		return "", err
+	}
+
+	salt, err := generateHashSalt()
+	if err != nil {
+		return "", fmt.Errorf("生成租户盐失败: %w", err)
+	}
+
+	// 获得同步标记后再更新租户盐，同步期间写入的条目使用新盐，正在同步时不轮换
+	_, err = s.resyncRedis(ctx, tenantID, func() error {
+		if err := s.settingRepo.UpdateHashSalt(ctx, tenantID, salt); err != nil {
+			return fmt.Errorf("更新租户盐失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", resyncError(err)
+	}
+
+	s.logger.InfoWithTrace(ctx, "租户盐轮换成功",
+		zap.Uint64("tenant_id", tenantID))
+
+	return salt, nil
+}
@@ -0,0 +1,350 @@
+// Package services provides business logic layer implementations.
+// This file contains per-entry hit counters aggregated in Redis and flushed to MySQL in the background.
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"go.uber.org/zap"
+)
+
+const (
+	// entryHitFlushInterval 条目命中次数写入MySQL的间隔
+	entryHitFlushInterval = time.Minute
+	// entryHitFlushLockKey 每轮写入只由一个实例执行
+	entryHitFlushLockKey = "blacklist:hits:flush:lock"
+	// entryHitFlushWatermarkKey 下一轮需要写入的最早分钟，之前的分钟已写入完成
+	entryHitFlushWatermarkKey = "blacklist:hits:flush:watermark"
+	// entryHitRetention 分钟命中计数key的保留时长，超过后未写入的计数丢弃
+	entryHitRetention = 2 * time.Hour
+	// entryHitFlushLookback 没有写入记录时回溯的时长，小于保留时长以免读取即将过期的key
+	entryHitFlushLookback = entryHitRetention - 10*time.Minute
+	// entryHitResolveBatchSize 按哈希查询条目时每批的哈希数
+	entryHitResolveBatchSize = 1000
+)
+
+// entryHit 一次查询中命中的一个名单条目，field为entryHitField
+type entryHit struct {
+	tenantID uint64
+	field    string
+}
+
+// entryHitCountKey 租户在minute内各条目命中次数的HASH
+func entryHitCountKey(tenantID uint64, minute time.Time) string {
+	return fmt.Sprintf("blacklist:hits:tenant:%d:%s", tenantID, minute.Format("200601021504"))
+}
+
+// entryHitLastKey 租户在minute内各条目最后命中时间的HASH，value为Unix毫秒数
+func entryHitLastKey(tenantID uint64, minute time.Time) string {
+	return fmt.Sprintf("blacklist:hits:last:tenant:%d:%s", tenantID, minute.Format("200601021504"))
+}
+
+// entryHitTenantsKey minute内有命中的租户SET
+func entryHitTenantsKey(minute time.Time) string {
+	return fmt.Sprintf("blacklist:hits:tenants:%s", minute.Format("200601021504"))
+}
+
+// entryHitField 条目命中计数HASH的field，按名单、标识类型、哈希格式和哈希定位条目
+// HMAC格式的命中按条目的SHA-256记录，写入时无需按租户盐重新计算HMAC
+func entryHitField(listID uint64, identifierType, hashType, hash string) string {
+	return fmt.Sprintf("%d|%s|%s|%s", listID, identifierType, hashType, hash)
+}
+
+// parseEntryHitField 解析entryHitField
+func parseEntryHitField(field string) (listID uint64, identifierType, hashType, hash string, ok bool) {
+	parts := strings.SplitN(field, "|", 4)
+	if len(parts) != 4 {
+		return 0, "", "", "", false
+	}
+	listID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", "", "", false
+	}
+	return listID, parts[1], parts[2], parts[3], true
+}
+
+// collectEntryHits 收集查询结果中命中的名单条目，被白名单豁免的命中不计入，共享名单的命中计入共享名单的条目
+// HMAC格式的命中记录为条目的SHA-256，未记录SHA-256的条目（重新同步前写入Redis的条目）不计入
+func collectEntryHits(tenantID uint64, identifierType, hashType string, results map[string]*CheckResult) []entryHit {
+	var hits []entryHit
+	for hash, result := range results {
+		if !result.Hit {
+			continue
+		}
+		for _, hit := range result.Lists {
+			hitTenantID := tenantID
+			if hit.Shared {
+				hitTenantID = models.SharedTenantID
+			}
+			hitHashType, hitHash := hashType, hash
+			if hashType == models.HashTypeHMACSHA256 {
+				if hit.sha256 == "" {
+					continue
+				}
+				hitHashType, hitHash = models.HashTypeSHA256, hit.sha256
+			}
+			hits = append(hits, entryHit{
+				tenantID: hitTenantID,
+				field:    entryHitField(hit.listID, identifierType, hitHashType, hitHash),
+			})
+		}
+	}
+	return hits
+}
+
+// recordEntryHits 在Redis中累加条目的分钟命中次数并记录最后命中时间，失败时仅记录日志
+func (s *blacklistService) recordEntryHits(ctx context.Context, hits []entryHit) {
+	now := time.Now()
+	minute := now.Truncate(time.Minute)
+	tenantsKey := entryHitTenantsKey(minute)
+
+	pipe := s.redis.Pipeline()
+	tenants := make(map[uint64]bool, 2)
+	for _, hit := range hits {
+		countKey := entryHitCountKey(hit.tenantID, minute)
+		lastKey := entryHitLastKey(hit.tenantID, minute)
+		pipe.HIncrBy(ctx, countKey, hit.field, 1)
+		pipe.HSet(ctx, lastKey, hit.field, now.UnixMilli())
+		if !tenants[hit.tenantID] {
+			tenants[hit.tenantID] = true
+			pipe.Expire(ctx, countKey, entryHitRetention)
+			pipe.Expire(ctx, lastKey, entryHitRetention)
+			pipe.SAdd(ctx, tenantsKey, hit.tenantID)
+		}
+	}
+	pipe.Expire(ctx, tenantsKey, entryHitRetention)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.WarnWithTrace(ctx, "记录条目命中次数失败",
+			zap.Error(err),
+			zap.Int("hit_count", len(hits)))
+	}
+}
+
+// flushEntryHitsLoop 定期将Redis中的条目命中次数写入MySQL
+func (s *blacklistService) flushEntryHitsLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(entryHitFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flushEntryHits(context.Background())
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// flushEntryHits 从上次写入的分钟起逐分钟写入条目命中次数
+// 上一分钟可能仍有实例在异步写入或存在时钟偏差，只写入更早的分钟，写入完成的分钟计数随即删除
+func (s *blacklistService) flushEntryHits(ctx context.Context) {
+	// 标记在本轮结束后自然过期，避免多实例在同一周期重复扫描；写入耗时超过标记有效期时，各租户分钟计数的原子领取保证不会重复累加
+	acquired, err := s.redis.SetNX(ctx, entryHitFlushLockKey, s.workerID, entryHitFlushInterval-10*time.Second).Result()
+	if err != nil {
+		s.logger.Warn("获取条目命中写入标记失败", zap.Error(err))
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	until := time.Now().Truncate(time.Minute).Add(-time.Minute)
+	from := until.Add(-entryHitFlushLookback)
+	watermark, err := s.redis.Get(ctx, entryHitFlushWatermarkKey).Int64()
+	switch {
+	case err == nil:
+		if t := time.Unix(watermark, 0); t.After(from) {
+			from = t
+		}
+	case !stderrors.Is(err, redis.Nil):
+		s.logger.Warn("获取条目命中写入进度失败", zap.Error(err))
+		return
+	}
+
+	entries := 0
+	next := from
+	for ; next.Before(until); next = next.Add(time.Minute) {
+		select {
+		case <-s.stopCh:
+			return
+		default:
+		}
+
+		n, err := s.flushEntryHitMinute(ctx, next)
+		entries += n
+		if err != nil {
+			s.logger.Warn("写入条目命中次数失败", zap.Error(err), zap.Time("minute", next))
+			break
+		}
+	}
+	if !next.After(from) {
+		return
+	}
+	// [from, next)内的分钟已写入，失败的分钟在下一轮重试
+	if err := s.redis.Set(ctx, entryHitFlushWatermarkKey, next.Unix(), 0).Err(); err != nil {
+		s.logger.Warn("保存条目命中写入进度失败", zap.Error(err))
+	}
+
+	if entries > 0 {
+		s.logger.Info("条目命中次数写入完成",
+			zap.Time("from", from),
+			zap.Time("next", next),
+			zap.Int("entries", entries))
+	}
+}
+
+// flushEntryHitMinute 写入一分钟内各租户的条目命中次数，返回更新的条目数
+func (s *blacklistService) flushEntryHitMinute(ctx context.Context, minute time.Time) (int, error) {
+	tenantsKey := entryHitTenantsKey(minute)
+	members, err := s.redis.SMembers(ctx, tenantsKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("获取有命中的租户失败: %w", err)
+	}
+
+	entries := 0
+	for _, member := range members {
+		tenantID, err := strconv.ParseUint(member, 10, 64)
+		if err != nil {
+			continue
+		}
+		n, err := s.flushTenantEntryHits(ctx, tenantID, minute)
+		entries += n
+		if err != nil {
+			return entries, err
+		}
+	}
+
+	if len(members) > 0 {
+		if err := s.redis.Del(ctx, tenantsKey).Err(); err != nil {
+			return entries, fmt.Errorf("删除有命中的租户失败: %w", err)
+		}
+	}
+	return entries, nil
+}
+
+// flushTenantEntryHits 领取租户一分钟内的条目命中次数并写入数据库
+// 读取和删除计数在同一个事务中执行，多个实例并发写入同一分钟时只有一个实例领取到计数；写入数据库失败时放回计数，在下一轮重试
+func (s *blacklistService) flushTenantEntryHits(ctx context.Context, tenantID uint64, minute time.Time) (int, error) {
+	countKey := entryHitCountKey(tenantID, minute)
+	lastKey := entryHitLastKey(tenantID, minute)
+
+	// 前缀钩子只为第一个key加前缀，逐个删除
+	pipe := s.redis.TxPipeline()
+	countsCmd := pipe.HGetAll(ctx, countKey)
+	lastsCmd := pipe.HGetAll(ctx, lastKey)
+	pipe.Del(ctx, countKey)
+	pipe.Del(ctx, lastKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("领取条目命中次数失败: %w", err)
+	}
+	counts, lasts := countsCmd.Val(), lastsCmd.Val()
+	if len(counts) == 0 {
+		return 0, nil
+	}
+
+	hits, err := s.resolveEntryHits(ctx, tenantID, minute, counts, lasts)
+	if err == nil {
+		if err = s.blacklistRepo.AddHits(ctx, hits); err != nil {
+			err = fmt.Errorf("更新条目命中次数失败: %w", err)
+		}
+	}
+	if err != nil {
+		s.restoreEntryHits(ctx, countKey, lastKey, counts, lasts)
+		return 0, err
+	}
+	return len(hits), nil
+}
+
+// restoreEntryHits 写入失败时放回领取的计数，期间新记录的命中次数累加保留
+func (s *blacklistService) restoreEntryHits(ctx context.Context, countKey, lastKey string, counts, lasts map[string]string) {
+	pipe := s.redis.Pipeline()
+	for field, value := range counts {
+		if count, err := strconv.ParseInt(value, 10, 64); err == nil {
+			pipe.HIncrBy(ctx, countKey, field, count)
+		}
+	}
+	for field, value := range lasts {
+		pipe.HSetNX(ctx, lastKey, field, value)
+	}
+	pipe.Expire(ctx, countKey, entryHitRetention)
+	pipe.Expire(ctx, lastKey, entryHitRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.Warn("放回条目命中次数失败，本分钟的命中次数丢失",
+			zap.Error(err),
+			zap.String("count_key", countKey))
+	}
+}
+
+// resolveEntryHits 将按哈希记录的命中次数对应到有效条目，同一条目多种哈希格式的命中合并计数
+// 命中按MD5或SHA-256记录，按哈希查询数据库；已删除或失效的条目的命中丢弃
+func (s *blacklistService) resolveEntryHits(ctx context.Context, tenantID uint64, minute time.Time, counts, lasts map[string]string) ([]repositories.BlacklistHitDelta, error) {
+	byID := make(map[uint64]*repositories.BlacklistHitDelta)
+	add := func(entry *models.PhoneBlacklist, hashType, hash string) {
+		field := entryHitField(entry.ListID, entry.IdentifierType, hashType, hash)
+		count, err := strconv.ParseUint(counts[field], 10, 64)
+		if err != nil || count == 0 {
+			return
+		}
+		lastHitAt := minute
+		if ms, err := strconv.ParseInt(lasts[field], 10, 64); err == nil {
+			lastHitAt = time.UnixMilli(ms)
+		}
+		hit, exists := byID[entry.ID]
+		if !exists {
+			hit = &repositories.BlacklistHitDelta{ID: entry.ID}
+			byID[entry.ID] = hit
+		}
+		hit.Hits += count
+		if lastHitAt.After(hit.LastHitAt) {
+			hit.LastHitAt = lastHitAt
+		}
+	}
+
+	// 按标识类型和哈希格式分组
+	groups := make(map[[2]string][]string)
+	for field := range counts {
+		_, identifierType, hashType, hash, ok := parseEntryHitField(field)
+		if !ok || (hashType != models.HashTypeMD5 && hashType != models.HashTypeSHA256) {
+			continue
+		}
+		key := [2]string{identifierType, hashType}
+		groups[key] = append(groups[key], hash)
+	}
+
+	for key, hashList := range groups {
+		identifierType, hashType := key[0], key[1]
+		for start := 0; start < len(hashList); start += entryHitResolveBatchSize {
+			end := min(start+entryHitResolveBatchSize, len(hashList))
+			entries, err := s.blacklistRepo.GetActiveByTenantAndHashes(ctx, tenantID, nil, identifierType, hashType, hashList[start:end])
+			if err != nil {
+				return nil, fmt.Errorf("查询命中条目失败: %w", err)
+			}
+			for _, entry := range entries {
+				if hashType == models.HashTypeSHA256 {
+					add(entry, hashType, entry.IdentifierSHA256)
+				} else {
+					add(entry, hashType, entry.PhoneMD5)
+				}
+			}
+		}
+	}
+
+	// 按ID排序，避免多个事务以不同顺序加锁
+	hits := make([]repositories.BlacklistHitDelta, 0, len(byID))
+	for _, hit := range byID {
+		hits = append(hits, *hit)
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].ID < hits[j].ID })
+	return hits, nil
+}
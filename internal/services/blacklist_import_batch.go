@@ -0,0 +1,152 @@
+// Package services provides business logic layer implementations.
+// This file contains blacklist import batches and batch rollback.
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/pkg/errors"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// importBatchRollbackChunkSize 回滚时每批删除的条目数
+const importBatchRollbackChunkSize = 1000
+
+// startImportBatch 创建导入批次，导入结束后需调用completeImportBatch
+func (s *blacklistService) startImportBatch(ctx context.Context, params *BatchImportParams, identifierType, method, fileName string) (*models.BlacklistImportBatch, error) {
+	batch := &models.BlacklistImportBatch{
+		TenantModel:    models.TenantModel{TenantID: params.TenantID},
+		Method:         method,
+		Status:         models.ImportBatchStatusImporting,
+		IdentifierType: identifierType,
+		Source:         params.Source,
+		Reason:         params.Reason,
+		FileName:       fileName,
+		OperatorID:     params.OperatorID,
+	}
+	if err := s.batchRepo.Create(ctx, batch); err != nil {
+		return nil, fmt.Errorf("创建导入批次失败: %w", err)
+	}
+	return batch, nil
+}
+
+// completeImportBatch 标记导入批次结束，导入中途失败时已写入的条目同样归属该批次，可以回滚
+func (s *blacklistService) completeImportBatch(ctx context.Context, batch *models.BlacklistImportBatch, entryCount int64) {
+	// 请求取消后仍需结束批次，否则批次无法回滚
+	if err := s.batchRepo.Complete(context.WithoutCancel(ctx), batch.ID, entryCount); err != nil {
+		s.logger.WarnWithTrace(ctx, "更新导入批次状态失败",
+			zap.Error(err),
+			zap.Uint64("tenant_id", batch.TenantID),
+			zap.String("batch_id", batch.UUID))
+		return
+	}
+	batch.Status = models.ImportBatchStatusCompleted
+	batch.EntryCount = entryCount
+	s.webhooks.Publish(ctx, batch.TenantID, models.WebhookEventBatchImported, newWebhookBatchData(batch))
+}
+
+// ListImportBatches 分页获取租户的导入批次
+func (s *blacklistService) ListImportBatches(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.BlacklistImportBatch, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.batchRepo.GetByTenant(ctx, tenantID, offset, pageSize)
+}
+
+// GetImportBatch 获取租户的导入批次
+func (s *blacklistService) GetImportBatch(ctx context.Context, tenantID uint64, batchID string) (*models.BlacklistImportBatch, error) {
+	batch, err := s.batchRepo.GetByUUID(ctx, tenantID, batchID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.NewBusinessError(errors.CodeNotFound, "导入批次不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取导入批次失败: %w", err)
+	}
+	return batch, nil
+}
+
+// GetImportBatchEntries 分页获取导入批次中仍存在的条目
+func (s *blacklistService) GetImportBatchEntries(ctx context.Context, batch *models.BlacklistImportBatch, page, pageSize int) ([]*models.PhoneBlacklist, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.blacklistRepo.GetByBatch(ctx, batch.TenantID, batch.ID, offset, pageSize)
+}
+
+// RollbackImportBatch 回滚整个导入批次：逐批从Redis移除条目后从数据库物理删除
+// 先移除Redis再删除数据库记录，中途失败时批次保持回滚中状态，剩余条目仍在数据库中，可重新回滚
+// 导入时为历史MD5条目补充的SHA-256不属于该批次，回滚后保留
+func (s *blacklistService) RollbackImportBatch(ctx context.Context, tenantID uint64, batchID string, operatorID uint64) (*models.BlacklistImportBatch, error) {
+	if err := s.ensureDirectWriteAllowed(ctx, tenantID, "批次回滚"); err != nil {
+		return nil, err
+	}
+
+	batch, err := s.GetImportBatch(ctx, tenantID, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch batch.Status {
+	case models.ImportBatchStatusImporting:
+		return nil, errors.NewBusinessError(errors.CodeConflict, "导入尚未结束，无法回滚")
+	case models.ImportBatchStatusRolledBack:
+		return nil, errors.NewBusinessError(errors.CodeConflict, "导入批次已回滚")
+	}
+
+	started, err := s.batchRepo.StartRollback(ctx, batch.ID)
+	if err != nil {
+		return nil, fmt.Errorf("更新导入批次状态失败: %w", err)
+	}
+	if !started {
+		return nil, errors.NewBusinessError(errors.CodeConflict, "导入批次状态已变更，请刷新后重试")
+	}
+
+	salt, err := s.getHashSalt(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	removed := 0
+	for {
+		blacklists, err := s.blacklistRepo.GetBatchEntriesForRollback(ctx, batch.ID, importBatchRollbackChunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取批次条目失败: %w", err)
+		}
+		if len(blacklists) == 0 {
+			break
+		}
+
+		if err := s.removeEntriesFromRedis(ctx, tenantID, blacklists, salt); err != nil {
+			return nil, fmt.Errorf("从Redis中移除批次条目失败: %w", err)
+		}
+
+		ids := make([]uint64, 0, len(blacklists))
+		for _, blacklist := range blacklists {
+			ids = append(ids, blacklist.ID)
+		}
+		if err := s.blacklistRepo.RollbackBatchEntries(ctx, batch.ID, ids); err != nil {
+			return nil, fmt.Errorf("删除批次条目失败: %w", err)
+		}
+
+		// 过滤器无法删除元素，仅记录删除数量，累计到阈值后重建
+		s.filter.remove(tenantID, len(blacklists))
+		removed += len(blacklists)
+	}
+
+	if err := s.batchRepo.FinishRollback(ctx, batch.ID, operatorID); err != nil {
+		return nil, fmt.Errorf("更新导入批次状态失败: %w", err)
+	}
+
+	s.logger.InfoWithTrace(ctx, "导入批次回滚成功",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("batch_id", batchID),
+		zap.Int("removed", removed),
+		zap.Uint64("operator_id", operatorID))
+
+	batch, err = s.GetImportBatch(ctx, tenantID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	s.webhooks.Publish(ctx, tenantID, models.WebhookEventBatchRolledBack, newWebhookBatchData(batch))
+	return batch, nil
+}
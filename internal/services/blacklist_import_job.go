@@ -0,0 +1,540 @@
+// Package services provides business logic layer implementations.
+// This file contains asynchronous blacklist import jobs and the background worker that runs them.
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/pkg/errors"
+	"github.com/varluffy/shield/pkg/sheet"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	// importJobPollInterval 领取导入任务的轮询间隔
+	importJobPollInterval = 2 * time.Second
+	// importJobLeaseTimeout 执行实例心跳超时时间，超时后任务可被其他实例接管
+	importJobLeaseTimeout = 2 * time.Minute
+	// importJobChunkSize 每批提交的数据行数
+	importJobChunkSize = 1000
+	// importJobFilePartSize 上传文件分片大小
+	importJobFilePartSize = 4 << 20
+	// maxImportJobRowErrors 保留的无效行样例数量
+	maxImportJobRowErrors = 100
+)
+
+// errImportJobStopped 实例停止，任务需要释放给其他实例继续执行
+var errImportJobStopped = stderrors.New("import job worker stopped")
+
+// errImportJobCancelled 任务已被取消
+var errImportJobCancelled = stderrors.New("import job cancelled")
+
+// errImportJobApprovalRequired 任务提交后租户启用了审批，剩余数据不再写入
+var errImportJobApprovalRequired = stderrors.New("租户已启用审批，导入任务停止执行")
+
+// ImportJobParams 导入任务参数
+type ImportJobParams struct {
+	TenantID       uint64
+	FileName       string
+	FileSize       int64
+	ValueType      string // phone, md5, sha256
+	IdentifierType string // ValueType为哈希时有效，为空时默认为手机号
+	Column         int    // 数据所在列，从0开始
+	ColumnName     string // 按表头名称定位数据列，不为空时优先于Column
+	Source         string
+	Reason         string
+	Category       string
+	RiskScore      int
+	OperatorID     uint64
+	ExpiresAt      *time.Time
+}
+
+// ImportRowError 无效行
+type ImportRowError struct {
+	Row    int    `json:"row"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+// DecodeImportRowErrors 解析导入任务保存的无效行样例
+func DecodeImportRowErrors(raw string) []ImportRowError {
+	rowErrors := make([]ImportRowError, 0)
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &rowErrors)
+	}
+	return rowErrors
+}
+
+// importJobValue 解析后的数据行
+type importJobValue struct {
+	row    int
+	raw    string
+	hashes IdentifierHashes
+	reason string // 不为空表示无效行
+}
+
+// SubmitImportJob 保存上传文件并创建导入任务，由后台任务异步执行
+func (s *blacklistService) SubmitImportJob(ctx context.Context, params *ImportJobParams, file io.Reader) (*models.BlacklistImportJob, error) {
+	identifierType := models.IdentifierTypePhone
+	switch params.ValueType {
+	case models.ImportValueTypePhone:
+	case models.ImportValueTypeMD5, models.ImportValueTypeSHA256:
+		if params.IdentifierType != "" {
+			identifierType = params.IdentifierType
+		}
+	default:
+		return nil, errors.ErrValidationFailed(fmt.Sprintf("不支持的文件内容类型: %s", params.ValueType))
+	}
+	if err := s.ensureDirectWriteAllowed(ctx, params.TenantID, "异步导入任务"); err != nil {
+		return nil, err
+	}
+
+	job := &models.BlacklistImportJob{
+		TenantModel:    models.TenantModel{TenantID: params.TenantID},
+		Status:         models.ImportJobStatusPending,
+		FileName:       params.FileName,
+		FileSize:       params.FileSize,
+		ValueType:      params.ValueType,
+		IdentifierType: identifierType,
+		ColumnIndex:    params.Column,
+		ColumnName:     params.ColumnName,
+		Source:         params.Source,
+		Reason:         params.Reason,
+		Category:       params.Category,
+		RiskScore:      params.RiskScore,
+		EntryExpiresAt: params.ExpiresAt,
+		OperatorID:     params.OperatorID,
+	}
+	batch := &models.BlacklistImportBatch{
+		TenantModel:    models.TenantModel{TenantID: params.TenantID},
+		Method:         models.ImportBatchMethodJob,
+		Status:         models.ImportBatchStatusImporting,
+		IdentifierType: identifierType,
+		Source:         params.Source,
+		Reason:         params.Reason,
+		FileName:       params.FileName,
+		OperatorID:     params.OperatorID,
+	}
+	if err := s.importJobRepo.Create(ctx, job, batch, file, importJobFilePartSize); err != nil {
+		return nil, fmt.Errorf("创建导入任务失败: %w", err)
+	}
+
+	s.logger.InfoWithTrace(ctx, "导入任务已创建",
+		zap.Uint64("tenant_id", params.TenantID),
+		zap.String("job_id", job.UUID),
+		zap.String("file_name", params.FileName),
+		zap.Int64("file_size", params.FileSize),
+		zap.String("value_type", params.ValueType))
+
+	return job, nil
+}
+
+// GetImportJob 获取租户的导入任务
+func (s *blacklistService) GetImportJob(ctx context.Context, tenantID uint64, jobID string) (*models.BlacklistImportJob, error) {
+	job, err := s.importJobRepo.GetByUUID(ctx, tenantID, jobID)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewBusinessError(errors.CodeNotFound, "导入任务不存在")
+		}
+		return nil, fmt.Errorf("获取导入任务失败: %w", err)
+	}
+	return job, nil
+}
+
+// ListImportJobs 分页获取租户的导入任务
+func (s *blacklistService) ListImportJobs(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.BlacklistImportJob, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.importJobRepo.GetByTenant(ctx, tenantID, offset, pageSize)
+}
+
+// CancelImportJob 取消导入任务，执行中的任务在当前批次提交后停止，已提交的条目保留
+func (s *blacklistService) CancelImportJob(ctx context.Context, tenantID uint64, jobID string) (*models.BlacklistImportJob, error) {
+	job, err := s.importJobRepo.RequestCancel(ctx, tenantID, jobID)
+	if err != nil {
+		if stderrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewBusinessError(errors.CodeNotFound, "导入任务不存在")
+		}
+		return nil, fmt.Errorf("取消导入任务失败: %w", err)
+	}
+	if job.IsFinished() && job.Status != models.ImportJobStatusCancelled {
+		return nil, errors.NewBusinessError(errors.CodeConflict, "导入任务已结束，无法取消")
+	}
+
+	s.logger.InfoWithTrace(ctx, "导入任务取消请求已提交",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("job_id", jobID),
+		zap.String("status", job.Status))
+
+	return job, nil
+}
+
+// newImportWorkerID 生成执行实例标识
+func newImportWorkerID() string {
+	hostname, _ := os.Hostname()
+	buf := make([]byte, 4)
+	_, _ = rand.Read(buf)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(buf))
+}
+
+// importJobLoop 定期领取并执行导入任务，每个实例同时只执行一个任务
+func (s *blacklistService) importJobLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(importJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// 连续执行直到没有可领取的任务或实例停止
+			for s.runNextImportJob(context.Background()) {
+				select {
+				case <-s.stopCh:
+					return
+				default:
+				}
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// runNextImportJob 领取并执行一个导入任务，没有可领取的任务或实例停止时返回false
+func (s *blacklistService) runNextImportJob(ctx context.Context) bool {
+	job, err := s.importJobRepo.ClaimNext(ctx, s.workerID, time.Now().Add(-importJobLeaseTimeout))
+	if err != nil {
+		s.logger.Warn("领取导入任务失败", zap.Error(err))
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	s.logger.Info("开始执行导入任务",
+		zap.String("job_id", job.UUID),
+		zap.Uint64("tenant_id", job.TenantID),
+		zap.Int64("processed_rows", job.ProcessedRows))
+
+	err = s.runImportJob(ctx, job)
+	switch {
+	case stderrors.Is(err, errImportJobStopped):
+		if err := s.importJobRepo.Release(ctx, job); err != nil {
+			s.logger.Warn("释放导入任务失败", zap.String("job_id", job.UUID), zap.Error(err))
+		}
+		return false
+	case stderrors.Is(err, repositories.ErrImportJobLeaseLost):
+		s.logger.Warn("导入任务已被其他实例接管", zap.String("job_id", job.UUID))
+		return true
+	case stderrors.Is(err, errImportJobCancelled):
+		job.Status = models.ImportJobStatusCancelled
+	case err != nil:
+		job.Status = models.ImportJobStatusFailed
+		job.ErrorMessage = truncateString(err.Error(), 500)
+	default:
+		job.Status = models.ImportJobStatusCompleted
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+	if err := s.importJobRepo.Finish(ctx, job); err != nil {
+		s.logger.Warn("更新导入任务状态失败", zap.String("job_id", job.UUID), zap.Error(err))
+		return true
+	}
+
+	s.logger.Info("导入任务结束",
+		zap.String("job_id", job.UUID),
+		zap.Uint64("tenant_id", job.TenantID),
+		zap.String("status", job.Status),
+		zap.Int64("processed_rows", job.ProcessedRows),
+		zap.Int64("accepted", job.AcceptedCount),
+		zap.Int64("duplicate", job.DuplicateCount),
+		zap.Int64("invalid", job.InvalidCount),
+		zap.String("error", job.ErrorMessage))
+
+	// 任务结束后导入批次随之结束，取消或失败前已写入的条目同样归属该批次
+	if job.BatchID != 0 {
+		batch, err := s.batchRepo.GetByID(ctx, job.BatchID)
+		if err != nil {
+			s.logger.Warn("获取导入批次失败", zap.String("job_id", job.UUID), zap.Error(err))
+			return true
+		}
+		s.webhooks.Publish(ctx, job.TenantID, models.WebhookEventBatchImported, newWebhookBatchData(batch))
+	}
+	return true
+}
+
+// runImportJob 从上次提交的位置继续执行导入任务
+func (s *blacklistService) runImportJob(ctx context.Context, job *models.BlacklistImportJob) error {
+	// 恢复中断的任务：上次提交的批次可能未写入Redis，先按数据库重建
+	if job.ProcessedRows > 0 {
+		// 其他实例正在同步时由其完成重建
+		if _, err := s.resyncRedis(ctx, job.TenantID, nil); err != nil && !stderrors.Is(err, errResyncInProgress) {
+			return err
+		}
+	}
+
+	// 提交后租户启用了审批的任务（包括中断后恢复的任务）按失败结束，已提交的条目保留
+	required, err := approvalRequired(ctx, s.settingRepo, job.TenantID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errImportJobApprovalRequired
+	}
+
+	file, err := os.CreateTemp("", "blacklist-import-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := s.importJobRepo.ReadFile(ctx, job.ID, file); err != nil {
+		return fmt.Errorf("读取上传文件失败: %w", err)
+	}
+
+	if job.TotalRows == 0 {
+		total, err := countImportJobRows(file, job)
+		if err != nil {
+			return err
+		}
+		job.TotalRows = total
+		if err := s.importJobRepo.Heartbeat(ctx, job); err != nil {
+			return err
+		}
+	}
+
+	source, err := openImportJobRows(file, job)
+	if err != nil {
+		return err
+	}
+
+	salt, err := s.getHashSalt(ctx, job.TenantID)
+	if err != nil {
+		return err
+	}
+	params := &BatchImportParams{
+		TenantID:       job.TenantID,
+		IdentifierType: job.IdentifierType,
+		Source:         job.Source,
+		Reason:         job.Reason,
+		Category:       job.Category,
+		RiskScore:      job.RiskScore,
+		OperatorID:     job.OperatorID,
+		ExpiresAt:      job.EntryExpiresAt,
+		BatchID:        job.BatchID,
+	}
+	rowErrors := DecodeImportRowErrors(job.RowErrors)
+
+	// 跳过已提交的数据行
+	for skipped := int64(0); skipped < job.ProcessedRows; skipped++ {
+		if _, _, err := source.next(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+
+	chunk := make([]importJobValue, 0, importJobChunkSize)
+	for {
+		row, raw, err := source.next()
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if row != nil {
+			chunk = append(chunk, parseImportJobValue(job.ValueType, row.Number, raw))
+		}
+		if len(chunk) < importJobChunkSize && err == nil {
+			continue
+		}
+
+		if len(chunk) > 0 {
+			if err := s.commitImportJobChunk(ctx, job, params, salt, chunk, &rowErrors); err != nil {
+				return err
+			}
+			chunk = chunk[:0]
+		}
+		if err == io.EOF {
+			return nil
+		}
+
+		// 批次之间响应实例停止和取消请求
+		select {
+		case <-s.stopCh:
+			return errImportJobStopped
+		default:
+		}
+		latest, err := s.importJobRepo.GetByID(ctx, job.ID)
+		if err != nil {
+			return fmt.Errorf("获取导入任务失败: %w", err)
+		}
+		if latest.CancelRequested {
+			return errImportJobCancelled
+		}
+	}
+}
+
+// commitImportJobChunk 处理一批数据行，与任务进度在同一事务中提交后写入Redis
+func (s *blacklistService) commitImportJobChunk(ctx context.Context, job *models.BlacklistImportJob, params *BatchImportParams, salt string, chunk []importJobValue, rowErrors *[]ImportRowError) error {
+	committedRows := job.ProcessedRows
+	progress := *job
+
+	// 批次内去重
+	seen := make(map[IdentifierHashes]bool, len(chunk))
+	items := make([]IdentifierHashes, 0, len(chunk))
+	for _, value := range chunk {
+		switch {
+		case value.reason != "":
+			progress.InvalidCount++
+			if len(*rowErrors) < maxImportJobRowErrors {
+				*rowErrors = append(*rowErrors, ImportRowError{Row: value.row, Value: truncateString(value.raw, 100), Reason: value.reason})
+			}
+		case seen[value.hashes]:
+			progress.DuplicateCount++
+		default:
+			seen[value.hashes] = true
+			items = append(items, value.hashes)
+		}
+	}
+
+	existing, err := s.existingHashes(ctx, job.TenantID, job.IdentifierType, items)
+	if err != nil {
+		return err
+	}
+	duplicates := make([]IdentifierHashes, 0)
+	newItems := make([]IdentifierHashes, 0, len(items))
+	for _, item := range items {
+		if existing.contains(item) {
+			duplicates = append(duplicates, item)
+			continue
+		}
+		newItems = append(newItems, item)
+	}
+
+	backfilled, _, err := s.backfillCandidates(ctx, job.TenantID, models.DefaultListID, job.IdentifierType, duplicates)
+	if err != nil {
+		return err
+	}
+	created := newEntries(params, job.IdentifierType, newItems)
+
+	progress.ProcessedRows += int64(len(chunk))
+	progress.AcceptedCount += int64(len(created))
+	progress.DuplicateCount += int64(len(duplicates))
+	progress.BackfilledCount += int64(len(backfilled))
+	if len(*rowErrors) > 0 {
+		encoded, _ := json.Marshal(*rowErrors)
+		progress.RowErrors = string(encoded)
+	}
+
+	if err := s.importJobRepo.CommitChunk(ctx, &progress, committedRows, created, backfilled); err != nil {
+		if stderrors.Is(err, repositories.ErrImportJobLeaseLost) {
+			return err
+		}
+		return fmt.Errorf("提交导入批次失败: %w", err)
+	}
+	*job = progress
+
+	s.syncEntries(ctx, job.TenantID, append(created, backfilled...), salt)
+	return nil
+}
+
+// parseImportJobValue 按文件内容类型解析数据行
+func parseImportJobValue(valueType string, row int, raw string) importJobValue {
+	value := importJobValue{row: row, raw: raw}
+	if raw == "" {
+		value.reason = "值为空"
+		return value
+	}
+
+	switch valueType {
+	case models.ImportValueTypeMD5:
+		if hash, ok := normalizeHexHash(raw, models.HashLength(models.HashTypeMD5)); ok {
+			value.hashes = IdentifierHashes{MD5: hash}
+			return value
+		}
+		value.reason = "MD5格式错误"
+	case models.ImportValueTypeSHA256:
+		if hash, ok := normalizeHexHash(raw, models.HashLength(models.HashTypeSHA256)); ok {
+			value.hashes = IdentifierHashes{SHA256: hash}
+			return value
+		}
+		value.reason = "SHA-256格式错误"
+	default:
+		if phone, ok := NormalizePhone(raw); ok {
+			value.hashes = NewIdentifierHashes(phone)
+			return value
+		}
+		value.reason = "手机号格式错误"
+	}
+	return value
+}
+
+// openImportJobRows 从头打开任务文件的数据行
+func openImportJobRows(file *os.File, job *models.BlacklistImportJob) (*importRowSource, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("读取临时文件失败: %w", err)
+	}
+	rows, err := sheet.NewReader(file, info.Size(), job.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("文件解析失败: %w", err)
+	}
+	return newImportRowSource(rows, job.ColumnIndex, job.ColumnName), nil
+}
+
+// countImportJobRows 统计任务文件的数据行数
+func countImportJobRows(file *os.File, job *models.BlacklistImportJob) (int64, error) {
+	source, err := openImportJobRows(file, job)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for {
+		if _, _, err := source.next(); err != nil {
+			if err == io.EOF {
+				return total, nil
+			}
+			return 0, err
+		}
+		total++
+	}
+}
+
+// normalizeHexHash 校验十六进制哈希长度和字符，统一为小写
+func normalizeHexHash(hash string, length int) (string, bool) {
+	if len(hash) != length {
+		return "", false
+	}
+	hash = strings.ToLower(hash)
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return "", false
+		}
+	}
+	return hash, true
+}
+
+// truncateString 按字节截断字符串，保证不截断多字节字符
+func truncateString(value string, maxBytes int) string {
+	if len(value) <= maxBytes {
+		return value
+	}
+	for maxBytes > 0 && !utf8.RuneStart(value[maxBytes]) {
+		maxBytes--
+	}
+	return value[:maxBytes]
+}
@@ -0,0 +1,368 @@
+// Package services provides business logic layer implementations.
+// This file contains named blacklist lists and per-credential list grants.
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/pkg/errors"
+	"github.com/varluffy/shield/pkg/logger"
+	redisClient "github.com/varluffy/shield/pkg/redis"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// ListService 黑名单名单服务接口，管理租户的命名名单和API密钥的名单授权
+type ListService interface {
+	CreateList(ctx context.Context, list *models.BlacklistList) error
+	ListLists(ctx context.Context, tenantID uint64) ([]*models.BlacklistList, error)
+	GetList(ctx context.Context, tenantID uint64, listID string) (*models.BlacklistList, error)
+	UpdateList(ctx context.Context, tenantID uint64, listID, description string) (*models.BlacklistList, *models.BlacklistList, error)
+	DeleteList(ctx context.Context, tenantID uint64, listID string) (*models.BlacklistList, error)
+	ResolveListID(ctx context.Context, tenantID uint64, name string) (uint64, error)
+	ResolveCheckLists(ctx context.Context, tenantID uint64, credential *models.BlacklistApiCredential, selector []string) ([]*models.BlacklistList, error)
+	// TenantListIDs 从数据库获取租户全部名单的ID（包含默认名单），用于遍历Redis key
+	TenantListIDs(ctx context.Context, tenantID uint64) ([]uint64, error)
+	GetCredentialLists(ctx context.Context, tenantID, credentialID uint64) ([]string, error)
+	SetCredentialLists(ctx context.Context, tenantID, credentialID uint64, names []string) ([]string, []string, error)
+}
+
+// blacklistListCacheTTL 租户名单本地缓存的有效期，其他实例的名单变更最迟在该时间后生效
+const blacklistListCacheTTL = 30 * time.Second
+
+// cachedTenantLists 租户名单的本地缓存，包含默认名单
+type cachedTenantLists struct {
+	lists    []*models.BlacklistList
+	loadedAt time.Time
+}
+
+// listService 黑名单名单服务实现
+type listService struct {
+	listRepo       repositories.BlacklistListRepository
+	blacklistRepo  repositories.BlacklistRepository
+	credentialRepo repositories.ApiCredentialRepository
+	redis          *redisClient.Client
+	logger         *logger.Logger
+	cache          sync.Map // 租户名单的本地缓存，tenantID -> *cachedTenantLists
+}
+
+// NewListService 创建黑名单名单服务
+func NewListService(
+	listRepo repositories.BlacklistListRepository,
+	blacklistRepo repositories.BlacklistRepository,
+	credentialRepo repositories.ApiCredentialRepository,
+	redis *redisClient.Client,
+	logger *logger.Logger,
+) ListService {
+	return &listService{
+		listRepo:       listRepo,
+		blacklistRepo:  blacklistRepo,
+		credentialRepo: credentialRepo,
+		redis:          redis,
+		logger:         logger,
+	}
+}
+
+// defaultBlacklistList 隐式的默认名单
+func defaultBlacklistList() *models.BlacklistList {
+	return &models.BlacklistList{
+		TenantModel: models.TenantModel{ID: models.DefaultListID},
+		Name:        models.DefaultListName,
+	}
+}
+
+// tenantLists 获取租户的全部名单（默认名单在前），优先使用本地缓存
+func (s *listService) tenantLists(ctx context.Context, tenantID uint64) ([]*models.BlacklistList, error) {
+	if value, ok := s.cache.Load(tenantID); ok {
+		cached := value.(*cachedTenantLists)
+		if time.Since(cached.loadedAt) < blacklistListCacheTTL {
+			return cached.lists, nil
+		}
+	}
+
+	named, err := s.listRepo.GetByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取名单失败: %w", err)
+	}
+	lists := append([]*models.BlacklistList{defaultBlacklistList()}, named...)
+	s.cache.Store(tenantID, &cachedTenantLists{lists: lists, loadedAt: time.Now()})
+	return lists, nil
+}
+
+// TenantListIDs 从数据库获取租户全部名单的ID（包含默认名单），用于遍历Redis key
+func (s *listService) TenantListIDs(ctx context.Context, tenantID uint64) ([]uint64, error) {
+	named, err := s.listRepo.GetByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取名单失败: %w", err)
+	}
+	listIDs := []uint64{models.DefaultListID}
+	for _, list := range named {
+		listIDs = append(listIDs, list.ID)
+	}
+	return listIDs, nil
+}
+
+// ResolveListID 将名单标识解析为名单ID，为空或为默认名单时返回0
+func (s *listService) ResolveListID(ctx context.Context, tenantID uint64, name string) (uint64, error) {
+	if name == "" || name == models.DefaultListName {
+		return models.DefaultListID, nil
+	}
+	list, err := s.listRepo.GetByName(ctx, tenantID, name)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, errors.NewBusinessError(errors.CodeNotFound, "名单不存在: "+name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("获取名单失败: %w", err)
+	}
+	return list.ID, nil
+}
+
+// ResolveCheckLists 按API密钥的名单授权和请求指定的名单确定查询范围
+// 未指定名单时查询全部已授权的名单，指定了未授权的名单时返回无权限，credential为空时仅查询默认名单
+func (s *listService) ResolveCheckLists(ctx context.Context, tenantID uint64, credential *models.BlacklistApiCredential, selector []string) ([]*models.BlacklistList, error) {
+	granted := []string{models.DefaultListName}
+	if credential != nil {
+		granted = credential.ListNames()
+	}
+
+	grantedSet := make(map[string]bool, len(granted))
+	for _, name := range granted {
+		grantedSet[name] = true
+	}
+	names := granted
+	if len(selector) > 0 {
+		for _, name := range selector {
+			if !grantedSet[name] {
+				return nil, errors.NewBusinessError(errors.CodeForbidden, "无权查询名单: "+name)
+			}
+		}
+		names = selector
+	}
+
+	// 仅查询默认名单时无需加载租户名单
+	if len(names) == 1 && names[0] == models.DefaultListName {
+		return []*models.BlacklistList{defaultBlacklistList()}, nil
+	}
+
+	lists, err := s.tenantLists(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*models.BlacklistList, len(lists))
+	for _, list := range lists {
+		byName[list.Name] = list
+	}
+
+	resolved := make([]*models.BlacklistList, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		list, ok := byName[name]
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		resolved = append(resolved, list)
+	}
+	if len(resolved) == 0 {
+		return nil, errors.NewBusinessError(errors.CodeNotFound, "查询的名单不存在")
+	}
+	return resolved, nil
+}
+
+// CreateList 创建名单，名单标识在租户内唯一
+func (s *listService) CreateList(ctx context.Context, list *models.BlacklistList) error {
+	if !models.IsValidListName(list.Name) {
+		return errors.NewBusinessError(errors.CodeValidationError, "名单标识只能包含小写字母、数字、下划线和连字符，且不能为default")
+	}
+
+	_, err := s.listRepo.GetByName(ctx, list.TenantID, list.Name)
+	if err == nil {
+		return errors.NewBusinessError(errors.CodeConflict, "名单标识已存在")
+	}
+	if !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("获取名单失败: %w", err)
+	}
+
+	if err := s.listRepo.Create(ctx, list); err != nil {
+		return fmt.Errorf("创建名单失败: %w", err)
+	}
+	s.cache.Delete(list.TenantID)
+
+	s.logger.InfoWithTrace(ctx, "名单创建成功",
+		zap.Uint64("tenant_id", list.TenantID),
+		zap.String("list_id", list.UUID),
+		zap.String("name", list.Name))
+
+	return nil
+}
+
+// ListLists 获取租户的全部名单，不包含隐式的默认名单
+func (s *listService) ListLists(ctx context.Context, tenantID uint64) ([]*models.BlacklistList, error) {
+	lists, err := s.listRepo.GetByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取名单失败: %w", err)
+	}
+	return lists, nil
+}
+
+// GetList 获取租户的名单
+func (s *listService) GetList(ctx context.Context, tenantID uint64, listID string) (*models.BlacklistList, error) {
+	list, err := s.listRepo.GetByUUID(ctx, tenantID, listID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.NewBusinessError(errors.CodeNotFound, "名单不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取名单失败: %w", err)
+	}
+	return list, nil
+}
+
+// UpdateList 更新名单描述，返回更新前和更新后的名单，名单标识不可修改
+func (s *listService) UpdateList(ctx context.Context, tenantID uint64, listID, description string) (*models.BlacklistList, *models.BlacklistList, error) {
+	list, err := s.GetList(ctx, tenantID, listID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	old := *list
+	list.Description = description
+	if err := s.listRepo.UpdateDescription(ctx, list.ID, description); err != nil {
+		return nil, nil, fmt.Errorf("更新名单失败: %w", err)
+	}
+	s.cache.Delete(tenantID)
+
+	return &old, list, nil
+}
+
+// DeleteList 删除名单，名单中仍有有效条目或已授权给API密钥时不允许删除
+func (s *listService) DeleteList(ctx context.Context, tenantID uint64, listID string) (*models.BlacklistList, error) {
+	list, err := s.GetList(ctx, tenantID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.blacklistRepo.CountActiveByList(ctx, tenantID, list.ID)
+	if err != nil {
+		return nil, fmt.Errorf("统计名单条目失败: %w", err)
+	}
+	if count > 0 {
+		return nil, errors.NewBusinessError(errors.CodeConflict, fmt.Sprintf("名单中仍有%d条有效条目，请先删除", count))
+	}
+
+	credentials, err := s.credentialRepo.GetByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥失败: %w", err)
+	}
+	for _, credential := range credentials {
+		for _, name := range credential.ListNames() {
+			if name == list.Name {
+				return nil, errors.NewBusinessError(errors.CodeConflict, "名单已授权给API密钥"+credential.Name+"，请先取消授权")
+			}
+		}
+	}
+
+	if err := s.listRepo.Delete(ctx, list.ID); err != nil {
+		return nil, fmt.Errorf("删除名单失败: %w", err)
+	}
+	s.cache.Delete(tenantID)
+
+	// 名单已无有效条目，清理可能残留的Redis key（前缀钩子只处理DEL的第一个key，需逐个删除）
+	pipe := s.redis.Pipeline()
+	forEachBlacklistKey(tenantID, []uint64{list.ID}, func(setKey, expiryKey, metaKey, prefixKey string) {
+		pipe.Del(ctx, setKey)
+		pipe.Del(ctx, expiryKey)
+		pipe.Del(ctx, metaKey)
+		pipe.Del(ctx, prefixKey)
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		s.logger.WarnWithTrace(ctx, "清理名单Redis数据失败",
+			zap.Error(err),
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("list_id", list.UUID))
+	}
+
+	s.logger.InfoWithTrace(ctx, "名单删除成功",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("list_id", list.UUID),
+		zap.String("name", list.Name))
+
+	return list, nil
+}
+
+// GetCredentialLists 获取API密钥可查询的名单标识
+func (s *listService) GetCredentialLists(ctx context.Context, tenantID, credentialID uint64) ([]string, error) {
+	credential, err := s.getTenantCredential(ctx, tenantID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	return credential.ListNames(), nil
+}
+
+// SetCredentialLists 设置API密钥可查询的名单，返回设置前和设置后的名单标识
+// 名单为空时仅可查询默认名单
+func (s *listService) SetCredentialLists(ctx context.Context, tenantID, credentialID uint64, names []string) ([]string, []string, error) {
+	credential, err := s.getTenantCredential(ctx, tenantID, credentialID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lists, err := s.listRepo.GetByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取名单失败: %w", err)
+	}
+	existing := map[string]bool{models.DefaultListName: true}
+	for _, list := range lists {
+		existing[list.Name] = true
+	}
+
+	granted := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !existing[name] {
+			return nil, nil, errors.NewBusinessError(errors.CodeNotFound, "名单不存在: "+name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			granted = append(granted, name)
+		}
+	}
+
+	old := credential.ListNames()
+	credential.Lists = strings.Join(granted, ",")
+	if err := s.credentialRepo.UpdateLists(ctx, credential.ID, credential.Lists); err != nil {
+		return nil, nil, fmt.Errorf("更新API密钥名单授权失败: %w", err)
+	}
+
+	// 清除鉴权缓存，使新的授权立即生效
+	if err := s.redis.Del(ctx, apiCredentialCacheKey(credential.APIKey)).Err(); err != nil {
+		s.logger.WarnWithTrace(ctx, "清除API密钥缓存失败",
+			zap.Error(err),
+			zap.String("api_key", credential.APIKey))
+	}
+
+	s.logger.InfoWithTrace(ctx, "API密钥名单授权更新成功",
+		zap.Uint64("tenant_id", tenantID),
+		zap.Uint64("credential_id", credential.ID),
+		zap.Strings("lists", credential.ListNames()))
+
+	return old, credential.ListNames(), nil
+}
+
+// getTenantCredential 获取租户的API密钥
+func (s *listService) getTenantCredential(ctx context.Context, tenantID, credentialID uint64) (*models.BlacklistApiCredential, error) {
+	credential, err := s.credentialRepo.GetByID(ctx, tenantID, credentialID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.NewBusinessError(errors.CodeNotFound, "API密钥不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取API密钥失败: %w", err)
+	}
+	return credential, nil
+}
@@ -0,0 +1,91 @@
+// Package services provides business logic layer implementations.
+// This file contains the query log search and per-hash timeline used for investigating partner queries.
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+)
+
+// QueryLogSearchParams 查询日志搜索参数，过滤条件为空时不过滤
+type QueryLogSearchParams struct {
+	TenantID       uint64
+	APIKey         string
+	IdentifierHash string
+	IsHit          *bool
+	RequestID      string
+	From           *time.Time // 查询时间下限（含）
+	To             *time.Time // 查询时间上限（不含）
+	Cursor         uint64     // 上一页最后一条记录的ID，为0时从第一页开始
+	Limit          int
+}
+
+// filter 转换为仓储层过滤条件
+func (p *QueryLogSearchParams) filter() repositories.BlacklistQueryLogFilter {
+	return repositories.BlacklistQueryLogFilter{
+		TenantID:       p.TenantID,
+		APIKey:         p.APIKey,
+		IdentifierHash: p.IdentifierHash,
+		IsHit:          p.IsHit,
+		RequestID:      p.RequestID,
+		From:           p.From,
+		To:             p.To,
+	}
+}
+
+// QueryLogPage 查询日志分页结果，按ID游标翻页
+type QueryLogPage struct {
+	Items      []*models.BlacklistQueryLog
+	NextCursor uint64 // 下一页游标，HasMore为false时为0
+	HasMore    bool
+}
+
+// QueryLogTimeline 单个哈希的查询时间线，按查询时间正序
+type QueryLogTimeline struct {
+	QueryLogPage
+	Summary *repositories.BlacklistQueryLogSummary // 仅在第一页返回
+}
+
+// SearchQueryLogs 按条件搜索查询日志，按查询时间倒序
+func (s *blacklistService) SearchQueryLogs(ctx context.Context, params *QueryLogSearchParams) (*QueryLogPage, error) {
+	return s.searchQueryLogs(ctx, params, false)
+}
+
+// GetQueryLogTimeline 获取单个哈希的查询时间线，按查询时间正序，第一页附带汇总信息
+func (s *blacklistService) GetQueryLogTimeline(ctx context.Context, params *QueryLogSearchParams) (*QueryLogTimeline, error) {
+	page, err := s.searchQueryLogs(ctx, params, true)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &QueryLogTimeline{QueryLogPage: *page}
+	if params.Cursor == 0 {
+		summary, err := s.queryLogRepo.Summarize(ctx, params.filter())
+		if err != nil {
+			return nil, fmt.Errorf("汇总查询日志失败: %w", err)
+		}
+		timeline.Summary = summary
+	}
+	return timeline, nil
+}
+
+// searchQueryLogs 多取一条判断是否还有下一页
+func (s *blacklistService) searchQueryLogs(ctx context.Context, params *QueryLogSearchParams, ascending bool) (*QueryLogPage, error) {
+	limit := params.Limit
+	logs, err := s.queryLogRepo.Search(ctx, params.filter(), params.Cursor, ascending, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("查询日志失败: %w", err)
+	}
+
+	page := &QueryLogPage{Items: logs}
+	if len(logs) > limit {
+		page.Items = logs[:limit]
+		page.HasMore = true
+		page.NextCursor = page.Items[limit-1].ID
+	}
+	return page, nil
+}
@@ -0,0 +1,215 @@
+// Package services provides business logic layer implementations.
+// This file contains the bounded, batched asynchronous writer for blacklist query logs.
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 查询日志写入参数默认值
+const (
+	defaultQueryLogQueueSize     = 10000       // 队列容量，写满后丢弃新日志
+	defaultQueryLogBatchSize     = 200         // 每批写入的最大条数
+	defaultQueryLogFlushInterval = time.Second // 未写满一批时的最长等待时间
+)
+
+// QueryLogWriter 查询日志异步写入器
+type QueryLogWriter interface {
+	// Write 将日志放入队列，不阻塞调用方，队列已满或写入器已关闭时丢弃并返回false
+	Write(logs ...*models.BlacklistQueryLog) bool
+	// Close 停止接收日志并写入队列中剩余的日志，ctx到期时放弃等待
+	Close(ctx context.Context) error
+	// Stats 返回写入统计
+	Stats() QueryLogWriterStats
+}
+
+// QueryLogWriterOptions 查询日志写入器参数，为0时使用默认值
+type QueryLogWriterOptions struct {
+	QueueSize     int
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+// QueryLogWriterStats 查询日志写入统计
+type QueryLogWriterStats struct {
+	Queued  int   // 队列中待写入的条数
+	Written int64 // 已写入的条数
+	Dropped int64 // 队列已满或写入器已关闭时丢弃的条数
+	Failed  int64 // 写入数据库失败而丢弃的条数
+}
+
+// queryLogWriter 查询日志写入器实现
+type queryLogWriter struct {
+	repo          repositories.BlacklistQueryLogRepository
+	logger        *logger.Logger
+	queue         chan *models.BlacklistQueryLog
+	batchSize     int
+	flushInterval time.Duration
+
+	mu       sync.RWMutex // 保护closed，避免关闭后继续入队
+	closed   bool
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+
+	written         atomic.Int64
+	dropped         atomic.Int64
+	failed          atomic.Int64
+	reportedDropped int64 // 已告警的丢弃数量，仅由写入goroutine访问
+}
+
+// NewQueryLogWriter 创建查询日志写入器
+func NewQueryLogWriter(repo repositories.BlacklistQueryLogRepository, logger *logger.Logger) QueryLogWriter {
+	return NewQueryLogWriterWithOptions(repo, logger, QueryLogWriterOptions{})
+}
+
+// NewQueryLogWriterWithOptions 使用指定参数创建查询日志写入器
+func NewQueryLogWriterWithOptions(repo repositories.BlacklistQueryLogRepository, logger *logger.Logger, opts QueryLogWriterOptions) QueryLogWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultQueryLogQueueSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultQueryLogBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultQueryLogFlushInterval
+	}
+
+	w := &queryLogWriter{
+		repo:          repo,
+		logger:        logger,
+		queue:         make(chan *models.BlacklistQueryLog, opts.QueueSize),
+		batchSize:     opts.BatchSize,
+		flushInterval: opts.FlushInterval,
+		stopCh:        make(chan struct{}),
+		doneCh:        make(chan struct{}),
+	}
+
+	// 启动批量写入的goroutine
+	go w.run()
+
+	return w
+}
+
+// Write 将日志放入队列
+func (w *queryLogWriter) Write(logs ...*models.BlacklistQueryLog) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		w.dropped.Add(int64(len(logs)))
+		return false
+	}
+
+	for i, log := range logs {
+		select {
+		case w.queue <- log:
+		default:
+			// 数据库写入跟不上时丢弃，不阻塞查询请求
+			w.dropped.Add(int64(len(logs) - i))
+			return false
+		}
+	}
+	return true
+}
+
+// Close 停止接收日志并写入剩余日志
+func (w *queryLogWriter) Close(ctx context.Context) error {
+	w.stopOnce.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.mu.Unlock()
+		close(w.stopCh)
+	})
+
+	select {
+	case <-w.doneCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats 返回写入统计
+func (w *queryLogWriter) Stats() QueryLogWriterStats {
+	return QueryLogWriterStats{
+		Queued:  len(w.queue),
+		Written: w.written.Load(),
+		Dropped: w.dropped.Load(),
+		Failed:  w.failed.Load(),
+	}
+}
+
+// run 从队列中读取日志，写满一批或到达刷新间隔时写入数据库
+func (w *queryLogWriter) run() {
+	defer close(w.doneCh)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.BlacklistQueryLog, 0, w.batchSize)
+	for {
+		select {
+		case log := <-w.queue:
+			batch = append(batch, log)
+			if len(batch) >= w.batchSize {
+				batch = w.flush(batch)
+			}
+		case <-ticker.C:
+			batch = w.flush(batch)
+			w.reportDropped()
+		case <-w.stopCh:
+			// 关闭后不再有新日志入队，写完队列中剩余的日志
+			for {
+				select {
+				case log := <-w.queue:
+					batch = append(batch, log)
+					if len(batch) >= w.batchSize {
+						batch = w.flush(batch)
+					}
+				default:
+					w.flush(batch)
+					w.reportDropped()
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush 写入一批日志，失败时丢弃该批次，返回清空后的切片
+func (w *queryLogWriter) flush(batch []*models.BlacklistQueryLog) []*models.BlacklistQueryLog {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := w.repo.BatchCreate(context.Background(), batch); err != nil {
+		w.failed.Add(int64(len(batch)))
+		w.logger.Warn("写入黑名单查询日志失败",
+			zap.Error(err),
+			zap.Int("count", len(batch)))
+	} else {
+		w.written.Add(int64(len(batch)))
+	}
+
+	return batch[:0]
+}
+
+// reportDropped 告警自上次告警以来丢弃的日志数量
+func (w *queryLogWriter) reportDropped() {
+	dropped := w.dropped.Load()
+	if dropped > w.reportedDropped {
+		w.logger.Warn("黑名单查询日志队列已满，部分日志已丢弃",
+			zap.Int64("dropped", dropped-w.reportedDropped),
+			zap.Int("queue_size", cap(w.queue)))
+		w.reportedDropped = dropped
+	}
+}
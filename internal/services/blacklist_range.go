@@ -0,0 +1,195 @@
+// Package services provides business logic layer implementations.
+// This file contains k-anonymity prefix range queries backed by the per-list prefix index.
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// 前缀范围查询参数
+const (
+	RangePrefixMinLength = 5              // 前缀最少的十六进制字符数，过短的前缀一次可取出过多条目
+	RangePrefixMaxLength = 10             // 前缀最多的十六进制字符数，过长的前缀失去k-匿名效果
+	rangeMaxCandidates   = 1000           // 单个前缀最多匹配的条目数，超过时要求调用方使用更长的前缀
+	rangeQuotaTTL        = 25 * time.Hour // 每日前缀配额集合的有效期，覆盖跨天时区误差
+)
+
+// RangeMatch 前缀范围查询匹配的哈希及其命中结果
+type RangeMatch struct {
+	Hash   string
+	Result *CheckResult
+}
+
+// blacklistRangeQuotaKey API密钥当日已查询前缀的Redis SET key
+func blacklistRangeQuotaKey(apiKey string, day time.Time) string {
+	return fmt.Sprintf("blacklist:range:prefixes:%s:%s", apiKey, day.Format("20060102"))
+}
+
+// rangeCheck 一个候选哈希在一个名单中的过期时间和风险信息查询
+type rangeCheck struct {
+	list   *models.BlacklistList // 为空表示共享名单
+	hash   string
+	expiry *redis.FloatCmd
+	meta   *redis.StringCmd
+}
+
+// RangeQuery 按哈希前缀查询给定名单中的有效条目，返回命中且未被白名单豁免的哈希，按哈希排序
+// 调用方只提交前缀，服务端不知道其要查询的完整哈希；前缀长度、单次匹配条目数和API密钥每日不同前缀数共同限制对名单的枚举
+// 前缀索引仅存储在Redis中，Redis失败时不回退到数据库查询
+func (s *blacklistService) RangeQuery(ctx context.Context, credential *models.BlacklistApiCredential, identifierType, hashType, prefix string, lists []*models.BlacklistList) ([]*RangeMatch, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) < RangePrefixMinLength || len(prefix) > RangePrefixMaxLength || !isHexString(prefix) {
+		return nil, errors.NewBusinessError(errors.CodeValidationError,
+			fmt.Sprintf("前缀须为%d-%d位十六进制字符", RangePrefixMinLength, RangePrefixMaxLength))
+	}
+	if err := s.consumeRangeQuota(ctx, credential, identifierType, hashType, prefix); err != nil {
+		return nil, err
+	}
+
+	tenantID := credential.TenantID
+	if len(lists) == 0 {
+		lists = []*models.BlacklistList{defaultBlacklistList()}
+	}
+	scopes := make([]*models.BlacklistList, 0, len(lists)+1)
+	scopes = append(scopes, lists...)
+	if s.shared.Subscribed(ctx, tenantID, hashType) {
+		scopes = append(scopes, nil)
+	}
+
+	// 多取一个成员用于判断是否超过上限
+	bound := &redis.ZRangeBy{Min: "[" + prefix, Max: "[" + prefix + "\xff", Count: rangeMaxCandidates + 1}
+	pipe := s.redis.Pipeline()
+	ranges := make([]*redis.StringSliceCmd, len(scopes))
+	for i, list := range scopes {
+		ranges[i] = pipe.ZRangeByLex(ctx, rangePrefixKey(tenantID, list, identifierType, hashType), bound)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("查询前缀索引失败: %w", err)
+	}
+
+	checks := make([]*rangeCheck, 0)
+	results := make(map[string]*CheckResult)
+	for i, list := range scopes {
+		for _, hash := range ranges[i].Val() {
+			checks = append(checks, &rangeCheck{list: list, hash: hash})
+			if _, exists := results[hash]; !exists {
+				results[hash] = &CheckResult{}
+			}
+		}
+	}
+	if len(results) > rangeMaxCandidates {
+		return nil, errors.NewBusinessError(errors.CodeValidationError, "前缀匹配的条目过多，请使用更长的前缀")
+	}
+	if len(checks) == 0 {
+		return []*RangeMatch{}, nil
+	}
+
+	// 获取过期时间和风险信息（清理任务执行前过期的条目视为未命中），未设置时返回redis.Nil属于正常情况
+	pipe = s.redis.Pipeline()
+	for _, check := range checks {
+		ownerID, listID := rangeScope(tenantID, check.list)
+		check.expiry = pipe.ZScore(ctx, blacklistExpiryKey(ownerID, listID, identifierType, hashType), check.hash)
+		check.meta = pipe.HGet(ctx, blacklistMetaKey(ownerID, listID, identifierType, hashType), check.hash)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("查询条目信息失败: %w", err)
+	}
+
+	now := time.Now()
+	for _, check := range checks {
+		if isExpiredScore(check.expiry, now) {
+			continue
+		}
+		if check.list == nil {
+			results[check.hash].addSharedHit(newHitResult(check.meta))
+		} else {
+			results[check.hash].addListHit(check.list, newHitResult(check.meta))
+		}
+	}
+
+	// 命中的标识在租户白名单中时不返回
+	s.applyAllowlist(ctx, tenantID, identifierType, hashType, results)
+
+	matches := make([]*RangeMatch, 0, len(results))
+	for hash, result := range results {
+		if result.Hit {
+			matches = append(matches, &RangeMatch{Hash: hash, Result: result})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Hash < matches[j].Hash })
+
+	s.logger.DebugWithTrace(ctx, "前缀范围查询完成",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("identifier_type", identifierType),
+		zap.String("hash_type", hashType),
+		zap.Int("prefix_length", len(prefix)),
+		zap.Int("list_count", len(lists)),
+		zap.Int("match_count", len(matches)))
+
+	return matches, nil
+}
+
+// consumeRangeQuota 记录API密钥当日查询的前缀，重复查询同一前缀不占用配额，超过每日不同前缀数时拒绝
+func (s *blacklistService) consumeRangeQuota(ctx context.Context, credential *models.BlacklistApiCredential, identifierType, hashType, prefix string) error {
+	if credential == nil || credential.RangeQueryLimit <= 0 {
+		return errors.NewBusinessError(errors.CodeForbidden, "API密钥未开启前缀范围查询")
+	}
+
+	key := blacklistRangeQuotaKey(credential.APIKey, time.Now())
+	member := identifierType + "|" + hashType + "|" + prefix
+	pipe := s.redis.TxPipeline()
+	added := pipe.SAdd(ctx, key, member)
+	count := pipe.SCard(ctx, key)
+	pipe.Expire(ctx, key, rangeQuotaTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("检查前缀查询配额失败: %w", err)
+	}
+
+	if added.Val() > 0 && count.Val() > int64(credential.RangeQueryLimit) {
+		if err := s.redis.SRem(ctx, key, member).Err(); err != nil {
+			s.logger.WarnWithTrace(ctx, "回退前缀查询配额失败",
+				zap.Error(err),
+				zap.String("api_key", credential.APIKey))
+		}
+		s.logger.WarnWithTrace(ctx, "API密钥前缀查询超过每日配额",
+			zap.Uint64("tenant_id", credential.TenantID),
+			zap.String("api_key", credential.APIKey),
+			zap.Int("range_query_limit", credential.RangeQueryLimit))
+		// 每日配额当天内无法恢复，不使用频率超限错误码，避免客户端按频率超限重试
+		return errors.NewBusinessError(errors.CodeForbidden, "今日可查询的前缀数已达上限")
+	}
+	return nil
+}
+
+// rangeScope 名单对应的条目归属ID和名单ID，共享名单为共享名单归属下的默认名单
+func rangeScope(tenantID uint64, list *models.BlacklistList) (uint64, uint64) {
+	if list == nil {
+		return models.SharedTenantID, models.DefaultListID
+	}
+	return tenantID, list.ID
+}
+
+// rangePrefixKey 名单的前缀索引key
+func rangePrefixKey(tenantID uint64, list *models.BlacklistList, identifierType, hashType string) string {
+	ownerID, listID := rangeScope(tenantID, list)
+	return blacklistPrefixKey(ownerID, listID, identifierType, hashType)
+}
+
+// isHexString 是否只包含小写十六进制字符
+func isHexString(value string) bool {
+	for _, c := range value {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
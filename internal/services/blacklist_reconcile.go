@@ -0,0 +1,249 @@
+// Package services provides business logic layer implementations.
+// This file contains the background reconciler that repairs drift between MySQL and Redis blacklist sets.
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/varluffy/shield/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// blacklistReconcileInterval Redis偏差检查间隔
+	blacklistReconcileInterval = 15 * time.Minute
+	// blacklistReconcileLockKey 每轮检查只由一个实例执行
+	blacklistReconcileLockKey = "blacklist:reconcile:lock"
+	// blacklistDriftReportRetention 检查记录保留时长
+	blacklistDriftReportRetention = 30 * 24 * time.Hour
+)
+
+// driftCounts Redis与数据库的偏差
+type driftCounts struct {
+	active  int64
+	missing int64 // 包含前缀索引缺失的成员
+	extra   int64 // 包含前缀索引多余的成员
+	prefix  int64 // 前缀索引与集合成员数之差的绝对值
+}
+
+// reconcileLoop 定期检查所有租户的Redis数据与数据库是否一致，发现偏差时按数据库修复
+func (s *blacklistService) reconcileLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(blacklistReconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.reconcileAll(context.Background())
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// reconcileAll 检查所有租户，单个租户失败不影响其他租户
+func (s *blacklistService) reconcileAll(ctx context.Context) {
+	// 标记在本轮结束后自然过期，保证多实例每个周期只检查一次
+	acquired, err := s.redis.SetNX(ctx, blacklistReconcileLockKey, s.workerID, blacklistReconcileInterval-time.Minute).Result()
+	if err != nil {
+		s.logger.Warn("获取偏差检查标记失败", zap.Error(err))
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	tenantIDs, err := s.blacklistRepo.GetTenantIDs(ctx)
+	if err != nil {
+		s.logger.Warn("获取黑名单租户失败", zap.Error(err))
+		return
+	}
+
+	drifted := 0
+	for _, tenantID := range tenantIDs {
+		select {
+		case <-s.stopCh:
+			return
+		default:
+		}
+
+		report, err := s.reconcileTenant(ctx, tenantID, models.DriftTriggerScheduled)
+		if err != nil {
+			s.logger.Warn("检查黑名单Redis偏差失败", zap.Error(err), zap.Uint64("tenant_id", tenantID))
+			continue
+		}
+		if report != nil && (report.MissingCount > 0 || report.ExtraCount > 0) {
+			drifted++
+		}
+	}
+
+	if _, err := s.driftRepo.DeleteBefore(ctx, time.Now().Add(-blacklistDriftReportRetention)); err != nil {
+		s.logger.Warn("清理偏差检查记录失败", zap.Error(err))
+	}
+
+	s.logger.Info("黑名单Redis偏差检查完成",
+		zap.Int("tenants", len(tenantIDs)),
+		zap.Int("drifted", drifted))
+}
+
+// CheckDrift 立即检查租户的Redis偏差，发现偏差时按数据库修复
+func (s *blacklistService) CheckDrift(ctx context.Context, tenantID uint64) (*models.BlacklistDriftReport, error) {
+	report, err := s.reconcileTenant(ctx, tenantID, models.DriftTriggerManual)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, resyncError(errResyncInProgress)
+	}
+	return report, nil
+}
+
+// ListDriftReports 分页获取租户的偏差检查记录
+func (s *blacklistService) ListDriftReports(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.BlacklistDriftReport, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.driftRepo.GetByTenant(ctx, tenantID, offset, pageSize)
+}
+
+// reconcileTenant 检查租户偏差并记录结果，租户正在同步时跳过并返回nil
+// 修复失败时仍记录检查结果，错误信息写入记录
+func (s *blacklistService) reconcileTenant(ctx context.Context, tenantID uint64, triggeredBy string) (*models.BlacklistDriftReport, error) {
+	resyncing, err := s.redis.Exists(ctx, blacklistResyncKey(tenantID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("获取同步标记失败: %w", err)
+	}
+	if resyncing > 0 {
+		return nil, nil
+	}
+
+	start := time.Now()
+	drift, err := s.measureDrift(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.BlacklistDriftReport{
+		TenantID:     tenantID,
+		TriggeredBy:  triggeredBy,
+		ActiveCount:  drift.active,
+		MissingCount: drift.missing,
+		ExtraCount:   drift.extra,
+	}
+
+	if drift.missing > 0 || drift.extra > 0 {
+		// 检查期间的并发写入可能造成误报，重新同步对一致的数据无副作用
+		_, err := s.resyncRedis(ctx, tenantID, nil)
+		switch {
+		case err == nil:
+			report.Repaired = true
+		case stderrors.Is(err, errResyncInProgress):
+			// 其他同步已在进行，由其完成修复
+		default:
+			report.ErrorMessage = truncateString(err.Error(), 500)
+		}
+
+		s.logger.WarnWithTrace(ctx, "黑名单Redis数据与数据库不一致",
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("triggered_by", triggeredBy),
+			zap.Int64("missing", drift.missing),
+			zap.Int64("extra", drift.extra),
+			zap.Int64("prefix_drift", drift.prefix),
+			zap.Bool("repaired", report.Repaired))
+	}
+
+	report.DurationMs = time.Since(start).Milliseconds()
+	if err := s.driftRepo.Create(ctx, report); err != nil {
+		return nil, fmt.Errorf("保存偏差检查记录失败: %w", err)
+	}
+	return report, nil
+}
+
+// measureDrift 按ID分批读取数据库中的有效条目，统计Redis缺失和多余的成员数
+// Redis中已过期但尚未被清理任务移除的成员不计为多余；前缀索引与集合的成员应一一对应，
+// 两者成员数之差计入缺失或多余，未建立前缀索引的历史数据由此在检查时自动补建
+func (s *blacklistService) measureDrift(ctx context.Context, tenantID uint64) (*driftCounts, error) {
+	salt, err := s.getHashSalt(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := &driftCounts{}
+	present := make(map[string]int64)
+	var afterID uint64
+	for {
+		blacklists, err := s.blacklistRepo.GetActiveBatchByTenant(ctx, tenantID, afterID, resyncChunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取黑名单数据失败: %w", err)
+		}
+		if len(blacklists) == 0 {
+			break
+		}
+
+		pipe := s.redis.Pipeline()
+		memberCmds := make(map[string][]*redis.BoolCmd)
+		for key, values := range groupEntryMembers(tenantID, blacklists, salt).sets {
+			for _, value := range values {
+				memberCmds[key] = append(memberCmds[key], pipe.SIsMember(ctx, key, value))
+			}
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, fmt.Errorf("检查Redis成员失败: %w", err)
+		}
+
+		for key, cmds := range memberCmds {
+			for _, cmd := range cmds {
+				if cmd.Val() {
+					present[key]++
+				} else {
+					drift.missing++
+				}
+			}
+		}
+		drift.active += int64(len(blacklists))
+		afterID = blacklists[len(blacklists)-1].ID
+
+		if len(blacklists) < resyncChunkSize {
+			break
+		}
+	}
+
+	listIDs, err := s.lists.TenantListIDs(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	cards := make(map[string]*redis.IntCmd)
+	expired := make(map[string]*redis.IntCmd)
+	prefixCards := make(map[string]*redis.IntCmd)
+	pipe := s.redis.Pipeline()
+	forEachBlacklistKey(tenantID, listIDs, func(setKey, expiryKey, metaKey, prefixKey string) {
+		cards[setKey] = pipe.SCard(ctx, setKey)
+		expired[setKey] = pipe.ZCount(ctx, expiryKey, "-inf", now)
+		prefixCards[setKey] = pipe.ZCard(ctx, prefixKey)
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("统计Redis成员失败: %w", err)
+	}
+
+	for key, card := range cards {
+		if extra := card.Val() - expired[key].Val() - present[key]; extra > 0 {
+			drift.extra += extra
+		}
+		// 已过期的成员由清理任务同时从集合和前缀索引中移除，直接比较成员数
+		switch diff := card.Val() - prefixCards[key].Val(); {
+		case diff > 0:
+			drift.missing += diff
+			drift.prefix += diff
+		case diff < 0:
+			drift.extra -= diff
+			drift.prefix -= diff
+		}
+	}
+	return drift, nil
+}
@@ -0,0 +1,445 @@
+// Package services provides business logic layer implementations.
+// This file contains zero-downtime Redis resync for tenant blacklists.
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Redis重新同步参数
+const (
+	resyncChunkSize   = 1000             // 每批从数据库读取并写入暂存key的条目数
+	resyncLockTTL     = 10 * time.Minute // 同步标记的有效期，每批写入后续期，进程退出后自动释放
+	resyncStagingSufx = ":staging"       // 暂存key后缀
+	resyncSentinel    = "__resync__"     // 暂存key中的占位成员，保证RENAME时暂存key存在，切换后移除
+)
+
+// errResyncInProgress 租户正在重新同步
+var errResyncInProgress = stderrors.New("blacklist resync in progress")
+
+// SyncResult Redis同步结果，新增和移除数量按全部哈希格式的集合成员统计
+type SyncResult struct {
+	Total   int // 数据库中的有效条目数
+	Added   int // 同步前Redis中缺失的成员数
+	Removed int // 同步前Redis中多余的成员数
+}
+
+// blacklistResyncKey 租户重新同步标记，存在时写入和移除条目需同时作用于暂存key
+func blacklistResyncKey(tenantID uint64) string {
+	return fmt.Sprintf("blacklist:resync:tenant:%d", tenantID)
+}
+
+// blacklistResyncRemovedKey 重新同步期间被移除的条目，切换前从暂存key中剔除
+func blacklistResyncRemovedKey(tenantID uint64) string {
+	return fmt.Sprintf("blacklist:resync:removed:tenant:%d", tenantID)
+}
+
+// resyncStagingKey 暂存key
+func resyncStagingKey(key string) string {
+	return key + resyncStagingSufx
+}
+
+// staged 将分组中的key替换为暂存key
+func (g *entryKeyMembers) staged() *entryKeyMembers {
+	staged := &entryKeyMembers{
+		sets:     make(map[string][]interface{}, len(g.sets)),
+		expiries: make(map[string][]interface{}, len(g.expiries)),
+		scores:   make(map[string][]redis.Z, len(g.scores)),
+		metas:    make(map[string][]interface{}, len(g.metas)),
+		metaKeys: make(map[string][]string, len(g.metaKeys)),
+		prefixes: make(map[string][]redis.Z, len(g.prefixes)),
+
+		staleExpiries: make(map[string][]interface{}, len(g.staleExpiries)),
+		staleMetas:    make(map[string][]string, len(g.staleMetas)),
+	}
+	for key, values := range g.sets {
+		staged.sets[resyncStagingKey(key)] = values
+	}
+	for key, values := range g.expiries {
+		staged.expiries[resyncStagingKey(key)] = values
+	}
+	for key, members := range g.scores {
+		staged.scores[resyncStagingKey(key)] = members
+	}
+	for key, pairs := range g.metas {
+		staged.metas[resyncStagingKey(key)] = pairs
+	}
+	for key, fields := range g.metaKeys {
+		staged.metaKeys[resyncStagingKey(key)] = fields
+	}
+	for key, members := range g.prefixes {
+		staged.prefixes[resyncStagingKey(key)] = members
+	}
+	for key, values := range g.staleExpiries {
+		staged.staleExpiries[resyncStagingKey(key)] = values
+	}
+	for key, fields := range g.staleMetas {
+		staged.staleMetas[resyncStagingKey(key)] = fields
+	}
+	return staged
+}
+
+// encodeRemovedEntry 编码重新同步期间被移除的条目
+func encodeRemovedEntry(blacklist *models.PhoneBlacklist) string {
+	return blacklist.IdentifierType + "|" + blacklist.PhoneMD5 + "|" + blacklist.IdentifierSHA256 + "|" + strconv.FormatUint(blacklist.ListID, 10)
+}
+
+// decodeRemovedEntry 解码重新同步期间被移除的条目，不带名单ID的旧格式属于默认名单
+func decodeRemovedEntry(value string) (*models.PhoneBlacklist, bool) {
+	parts := strings.Split(value, "|")
+	if len(parts) != 3 && len(parts) != 4 {
+		return nil, false
+	}
+	blacklist := &models.PhoneBlacklist{IdentifierType: parts[0], PhoneMD5: parts[1], IdentifierSHA256: parts[2]}
+	if len(parts) == 4 {
+		listID, err := strconv.ParseUint(parts[3], 10, 64)
+		if err != nil {
+			return nil, false
+		}
+		blacklist.ListID = listID
+	}
+	return blacklist, true
+}
+
+// addEntriesToRedis 将条目的全部哈希格式写入Redis，租户正在重新同步时同时写入暂存key
+func (s *blacklistService) addEntriesToRedis(ctx context.Context, tenantID uint64, blacklists []*models.PhoneBlacklist, salt string) error {
+	return s.updateRedisEntries(ctx, tenantID, blacklists, nil, salt, false)
+}
+
+// removeEntriesFromRedis 从Redis中移除条目的全部哈希格式，租户正在重新同步时同时从暂存key移除并记录
+// 同一标识的哈希可能被其他有效记录持有（如同一名单中分别只有MD5和SHA-256的两条记录），这些记录在同一事务中写回
+func (s *blacklistService) removeEntriesFromRedis(ctx context.Context, tenantID uint64, blacklists []*models.PhoneBlacklist, salt string) error {
+	if len(blacklists) == 0 {
+		return nil
+	}
+	holders, err := s.activeHolders(ctx, tenantID, blacklists)
+	if err != nil {
+		return err
+	}
+	return s.updateRedisEntries(ctx, tenantID, blacklists, holders, salt, true)
+}
+
+// activeHolders 查询与给定条目任一哈希相同的其他有效记录，给定条目自身即使仍有效也不包含在内
+func (s *blacklistService) activeHolders(ctx context.Context, tenantID uint64, blacklists []*models.PhoneBlacklist) ([]*models.PhoneBlacklist, error) {
+	excluded := make(map[uint64]bool, len(blacklists))
+	hashLists := make(map[string]map[string][]string)
+	for _, blacklist := range blacklists {
+		if blacklist.ID != 0 {
+			excluded[blacklist.ID] = true
+		}
+		byHashType := hashLists[blacklist.IdentifierType]
+		if byHashType == nil {
+			byHashType = make(map[string][]string, 2)
+			hashLists[blacklist.IdentifierType] = byHashType
+		}
+		if blacklist.PhoneMD5 != "" {
+			byHashType[models.HashTypeMD5] = append(byHashType[models.HashTypeMD5], blacklist.PhoneMD5)
+		}
+		if blacklist.IdentifierSHA256 != "" {
+			byHashType[models.HashTypeSHA256] = append(byHashType[models.HashTypeSHA256], blacklist.IdentifierSHA256)
+		}
+	}
+
+	holders := make([]*models.PhoneBlacklist, 0)
+	seen := make(map[uint64]bool)
+	for identifierType, byHashType := range hashLists {
+		for hashType, hashList := range byHashType {
+			active, err := s.blacklistRepo.GetActiveByTenantAndHashes(ctx, tenantID, nil, identifierType, hashType, hashList)
+			if err != nil {
+				return nil, fmt.Errorf("查询仍有效的相同标识条目失败: %w", err)
+			}
+			for _, blacklist := range active {
+				if excluded[blacklist.ID] || seen[blacklist.ID] {
+					continue
+				}
+				seen[blacklist.ID] = true
+				holders = append(holders, blacklist)
+			}
+		}
+	}
+	return holders, nil
+}
+
+// updateRedisEntries 写入或移除条目，移除时随后写回retained中的条目
+// 同步期间的变更同时作用于暂存key；移除的条目另行记录，切换前从暂存key剔除并按数据库复核，避免被同步读取的旧数据写回
+func (s *blacklistService) updateRedisEntries(ctx context.Context, tenantID uint64, blacklists, retained []*models.PhoneBlacklist, salt string, remove bool) error {
+	if len(blacklists) == 0 {
+		return nil
+	}
+
+	resyncing, err := s.redis.Exists(ctx, blacklistResyncKey(tenantID)).Result()
+	if err != nil {
+		return err
+	}
+
+	grouped := groupEntryMembers(tenantID, blacklists, salt)
+	targets := []*entryKeyMembers{grouped}
+	kept := groupEntryMembers(tenantID, retained, salt)
+	keptTargets := []*entryKeyMembers{kept}
+	if resyncing > 0 {
+		targets = append(targets, grouped.staged())
+		keptTargets = append(keptTargets, kept.staged())
+	}
+
+	pipe := s.redis.TxPipeline()
+	for _, target := range targets {
+		if remove {
+			target.removeEntries(ctx, pipe)
+		} else {
+			target.addEntries(ctx, pipe)
+		}
+	}
+	for _, target := range keptTargets {
+		target.addEntries(ctx, pipe)
+	}
+	if remove && resyncing > 0 {
+		// 同步可能在移除前读到了该条目，切换前需再次剔除
+		removed := make([]interface{}, 0, len(blacklists))
+		for _, blacklist := range blacklists {
+			removed = append(removed, encodeRemovedEntry(blacklist))
+		}
+		pipe.SAdd(ctx, blacklistResyncRemovedKey(tenantID), removed...)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// SyncToRedis 按数据库重新同步租户黑名单到Redis，同步期间查询不受影响
+func (s *blacklistService) SyncToRedis(ctx context.Context, tenantID uint64) (*SyncResult, error) {
+	result, err := s.resyncRedis(ctx, tenantID, nil)
+	if err != nil {
+		return nil, resyncError(err)
+	}
+	return result, nil
+}
+
+// resyncError 将同步冲突转换为业务错误
+func resyncError(err error) error {
+	if stderrors.Is(err, errResyncInProgress) {
+		return errors.NewBusinessError(errors.CodeConflict, "黑名单正在同步，请稍后重试")
+	}
+	return err
+}
+
+// resyncRedis 分批将数据库中的有效条目写入暂存key，再通过RENAME原子替换正式key
+// 同步期间的写入和移除由updateRedisEntries同时作用于暂存key，同一租户同时只允许一个同步
+// prepare在获得同步标记后、读取租户盐之前执行，用于轮换租户盐
+func (s *blacklistService) resyncRedis(ctx context.Context, tenantID uint64, prepare func() error) (result *SyncResult, err error) {
+	lockKey := blacklistResyncKey(tenantID)
+	acquired, err := s.redis.SetNX(ctx, lockKey, s.workerID, resyncLockTTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("设置同步标记失败: %w", err)
+	}
+	if !acquired {
+		return nil, errResyncInProgress
+	}
+	defer func() {
+		if err != nil {
+			s.redis.Del(context.WithoutCancel(ctx), lockKey)
+		}
+	}()
+
+	if prepare != nil {
+		if err := prepare(); err != nil {
+			return nil, err
+		}
+	}
+
+	salt, err := s.getHashSalt(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同步期间新建的名单不参与切换，其条目已由写入流程直接写入正式key
+	listIDs, err := s.lists.TenantListIDs(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 清理上次中断遗留的暂存key，写入占位成员（前缀钩子只处理DEL的第一个key，需逐个删除）
+	pipe := s.redis.TxPipeline()
+	pipe.Del(ctx, blacklistResyncRemovedKey(tenantID))
+	forEachBlacklistKey(tenantID, listIDs, func(setKey, expiryKey, metaKey, prefixKey string) {
+		pipe.Del(ctx, resyncStagingKey(setKey))
+		pipe.Del(ctx, resyncStagingKey(expiryKey))
+		pipe.Del(ctx, resyncStagingKey(metaKey))
+		pipe.Del(ctx, resyncStagingKey(prefixKey))
+		pipe.SAdd(ctx, resyncStagingKey(setKey), resyncSentinel)
+		pipe.ZAdd(ctx, resyncStagingKey(expiryKey), redis.Z{Member: resyncSentinel})
+		pipe.HSet(ctx, resyncStagingKey(metaKey), resyncSentinel, "")
+		pipe.ZAdd(ctx, resyncStagingKey(prefixKey), redis.Z{Member: resyncSentinel})
+	})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("初始化暂存数据失败: %w", err)
+	}
+
+	result = &SyncResult{}
+	var afterID uint64
+	for {
+		blacklists, err := s.blacklistRepo.GetActiveBatchByTenant(ctx, tenantID, afterID, resyncChunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("获取黑名单数据失败: %w", err)
+		}
+		if len(blacklists) == 0 {
+			break
+		}
+
+		added, err := s.stageEntries(ctx, tenantID, blacklists, salt)
+		if err != nil {
+			return nil, err
+		}
+		result.Total += len(blacklists)
+		result.Added += added
+		afterID = blacklists[len(blacklists)-1].ID
+
+		if len(blacklists) < resyncChunkSize {
+			break
+		}
+	}
+
+	if err := s.dropRemovedFromStaging(ctx, tenantID, salt); err != nil {
+		return nil, err
+	}
+
+	removed, err := s.swapStagingKeys(ctx, tenantID, listIDs, result.Added)
+	if err != nil {
+		return nil, err
+	}
+	result.Removed = removed
+
+	// 白名单条目较少，整体重建，租户盐轮换后同时刷新HMAC格式
+	if err := s.rebuildAllowlistCache(ctx, tenantID, salt); err != nil {
+		return nil, err
+	}
+
+	// 数据源整体刷新，重建本实例和其他实例的本地过滤器
+	s.filter.invalidate(ctx, tenantID)
+
+	s.logger.InfoWithTrace(ctx, "黑名单数据同步到Redis成功",
+		zap.Uint64("tenant_id", tenantID),
+		zap.Int("count", result.Total),
+		zap.Int("added", result.Added),
+		zap.Int("removed", result.Removed))
+
+	return result, nil
+}
+
+// stageEntries 将一批条目写入暂存key并续期同步标记，返回正式key中缺失的成员数
+func (s *blacklistService) stageEntries(ctx context.Context, tenantID uint64, blacklists []*models.PhoneBlacklist, salt string) (int, error) {
+	grouped := groupEntryMembers(tenantID, blacklists, salt)
+
+	pipe := s.redis.Pipeline()
+	memberCmds := make([]*redis.BoolCmd, 0, len(blacklists))
+	for key, values := range grouped.sets {
+		for _, value := range values {
+			memberCmds = append(memberCmds, pipe.SIsMember(ctx, key, value))
+		}
+	}
+	grouped.staged().addEntries(ctx, pipe)
+	pipe.Expire(ctx, blacklistResyncKey(tenantID), resyncLockTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("写入暂存数据失败: %w", err)
+	}
+
+	added := 0
+	for _, cmd := range memberCmds {
+		if !cmd.Val() {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// dropRemovedFromStaging 剔除同步期间被移除、但可能已被写入暂存key的条目，数据库中仍有效的重新写入
+func (s *blacklistService) dropRemovedFromStaging(ctx context.Context, tenantID uint64, salt string) error {
+	values, err := s.redis.SMembers(ctx, blacklistResyncRemovedKey(tenantID)).Result()
+	if err != nil {
+		return fmt.Errorf("获取同步期间移除的条目失败: %w", err)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	removed := make([]*models.PhoneBlacklist, 0, len(values))
+	for _, value := range values {
+		blacklist, ok := decodeRemovedEntry(value)
+		if !ok {
+			continue
+		}
+		removed = append(removed, blacklist)
+	}
+
+	// 记录中不含ID，相同标识仍有效的记录（包括被重新添加的条目）全部写回
+	active, err := s.activeHolders(ctx, tenantID, removed)
+	if err != nil {
+		return fmt.Errorf("查询同步期间移除的条目失败: %w", err)
+	}
+
+	pipe := s.redis.TxPipeline()
+	groupEntryMembers(tenantID, removed, salt).staged().removeEntries(ctx, pipe)
+	groupEntryMembers(tenantID, active, salt).staged().addEntries(ctx, pipe)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("剔除同步期间移除的条目失败: %w", err)
+	}
+	return nil
+}
+
+// swapStagingKeys 在同一事务中用暂存key替换正式key、移除占位成员并清除同步标记，返回移除的成员数
+func (s *blacklistService) swapStagingKeys(ctx context.Context, tenantID uint64, listIDs []uint64, added int) (int, error) {
+	liveCards := make([]*redis.IntCmd, 0)
+	stagingCards := make([]*redis.IntCmd, 0)
+
+	pipe := s.redis.TxPipeline()
+	forEachBlacklistKey(tenantID, listIDs, func(setKey, expiryKey, metaKey, prefixKey string) {
+		liveCards = append(liveCards, pipe.SCard(ctx, setKey))
+		stagingCards = append(stagingCards, pipe.SCard(ctx, resyncStagingKey(setKey)))
+		for _, key := range []string{setKey, expiryKey, metaKey, prefixKey} {
+			pipe.Rename(ctx, resyncStagingKey(key), key)
+		}
+		pipe.SRem(ctx, setKey, resyncSentinel)
+		pipe.ZRem(ctx, expiryKey, resyncSentinel)
+		pipe.HDel(ctx, metaKey, resyncSentinel)
+		pipe.ZRem(ctx, prefixKey, resyncSentinel)
+	})
+	pipe.Del(ctx, blacklistResyncKey(tenantID))
+	pipe.Del(ctx, blacklistResyncRemovedKey(tenantID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("切换Redis数据失败: %w", err)
+	}
+
+	// 正式key中保留的成员数 = 暂存成员数(不含占位成员) - 新增成员数
+	removed := 0
+	for i := range liveCards {
+		removed += int(liveCards[i].Val())
+		removed -= int(stagingCards[i].Val()) - 1
+	}
+	removed += added
+	if removed < 0 {
+		removed = 0
+	}
+	return removed, nil
+}
+
+// forEachBlacklistKey 遍历租户给定名单所有标识类型和哈希格式的集合、过期时间、风险信息和前缀索引key
+func forEachBlacklistKey(tenantID uint64, listIDs []uint64, fn func(setKey, expiryKey, metaKey, prefixKey string)) {
+	for _, listID := range listIDs {
+		for _, identifierType := range models.IdentifierTypes {
+			for _, hashType := range models.HashTypes {
+				fn(blacklistSetKey(tenantID, listID, identifierType, hashType),
+					blacklistExpiryKey(tenantID, listID, identifierType, hashType),
+					blacklistMetaKey(tenantID, listID, identifierType, hashType),
+					blacklistPrefixKey(tenantID, listID, identifierType, hashType))
+			}
+		}
+	}
+}
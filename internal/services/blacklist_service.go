@@ -4,14 +4,19 @@ package services
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/varluffy/shield/internal/models"
 	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/pkg/errors"
 	"github.com/varluffy/shield/pkg/logger"
+	"github.com/varluffy/shield/pkg/metrics"
 	redisClient "github.com/varluffy/shield/pkg/redis"
 	"go.uber.org/zap"
 )
@@ -20,14 +25,161 @@ import (
 type BlacklistService interface {
 	CheckPhoneMD5(ctx context.Context, tenantID uint64, phoneMD5 string) (bool, error)
 	CheckPhoneMD5Batch(ctx context.Context, tenantID uint64, phoneMD5List []string) (map[string]bool, error)
+	CheckIdentifier(ctx context.Context, tenantID uint64, identifierType, hashType, hash string, lists []*models.BlacklistList) (*CheckResult, error)
+	CheckIdentifierBatch(ctx context.Context, tenantID uint64, identifierType, hashType string, hashList []string, lists []*models.BlacklistList) (map[string]*CheckResult, error)
+	RangeQuery(ctx context.Context, credential *models.BlacklistApiCredential, identifierType, hashType, prefix string, lists []*models.BlacklistList) ([]*RangeMatch, error)
 	CreateBlacklist(ctx context.Context, blacklist *models.PhoneBlacklist) error
-	BatchImportBlacklist(ctx context.Context, tenantID uint64, phoneMD5List []string, source, reason string, operatorID uint64) error
-	GetBlacklistByTenant(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.PhoneBlacklist, int64, error)
+	BatchImportBlacklist(ctx context.Context, params *BatchImportParams) (*BatchImportResult, error)
+	ImportBlacklistFile(ctx context.Context, params *FileImportParams) (*FileImportResult, error)
+	SubmitImportJob(ctx context.Context, params *ImportJobParams, file io.Reader) (*models.BlacklistImportJob, error)
+	GetImportJob(ctx context.Context, tenantID uint64, jobID string) (*models.BlacklistImportJob, error)
+	ListImportJobs(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.BlacklistImportJob, int64, error)
+	CancelImportJob(ctx context.Context, tenantID uint64, jobID string) (*models.BlacklistImportJob, error)
+	ListImportBatches(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.BlacklistImportBatch, int64, error)
+	GetImportBatch(ctx context.Context, tenantID uint64, batchID string) (*models.BlacklistImportBatch, error)
+	GetImportBatchEntries(ctx context.Context, batch *models.BlacklistImportBatch, page, pageSize int) ([]*models.PhoneBlacklist, int64, error)
+	RollbackImportBatch(ctx context.Context, tenantID uint64, batchID string, operatorID uint64) (*models.BlacklistImportBatch, error)
+	GetBlacklistByTenant(ctx context.Context, tenantID uint64, sortBy, order string, page, pageSize int) ([]*models.PhoneBlacklist, int64, error)
+	ExportBlacklist(ctx context.Context, params *ExportParams, w io.Writer) (int64, error)
 	DeleteBlacklist(ctx context.Context, id uint64) error
-	SyncToRedis(ctx context.Context, tenantID uint64) error
+	SyncToRedis(ctx context.Context, tenantID uint64) (*SyncResult, error)
+	CheckDrift(ctx context.Context, tenantID uint64) (*models.BlacklistDriftReport, error)
+	ListDriftReports(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.BlacklistDriftReport, int64, error)
+	SearchQueryLogs(ctx context.Context, params *QueryLogSearchParams) (*QueryLogPage, error)
+	GetQueryLogTimeline(ctx context.Context, params *QueryLogSearchParams) (*QueryLogTimeline, error)
 	GetQueryStats(ctx context.Context, tenantID uint64, hours int) (*QueryStats, error)
 	GetMinuteStats(ctx context.Context, tenantID uint64, minutes int) (*MinuteStats, error)
+	GetAPIKeyStats(ctx context.Context, tenantID uint64, apiKey string, window StatsWindow) (*APIKeyStats, error)
+	GetTopConsumers(ctx context.Context, tenantID uint64, window StatsWindow, sortBy string, limit int) ([]*APIKeyStats, error)
+	GetTenantStats(ctx context.Context, tenantID uint64, window StatsWindow) (*APIKeyStats, error)
 	UpdateQueryMetrics(ctx context.Context, tenantID uint64, apiKey string, isHit bool, latencyMs int64)
+	GetFilterStats(ctx context.Context, tenantID uint64) (*BlacklistFilterStats, error)
+	GetHashSalt(ctx context.Context, tenantID uint64) (string, error)
+	RotateHashSalt(ctx context.Context, tenantID uint64) (string, error)
+	ContributeShared(ctx context.Context, contributorTenantID uint64, blacklist *models.PhoneBlacklist) error
+	ListSharedContributions(ctx context.Context, contributorTenantID uint64, page, pageSize int) ([]*models.PhoneBlacklist, int64, error)
+	WithdrawSharedContribution(ctx context.Context, contributorTenantID, id uint64) (*models.PhoneBlacklist, error)
+	// Close 停止后台任务并等待其结束，进行中的导入任务在当前批次提交后停止，ctx到期时放弃等待
+	Close(ctx context.Context) error
+}
+
+// BatchImportParams 批量导入参数
+type BatchImportParams struct {
+	TenantID       uint64
+	ListID         uint64 // 目标名单ID，0表示默认名单
+	IdentifierType string // 为空时默认为手机号
+	Items          []IdentifierHashes
+	Source         string
+	Reason         string
+	Category       string
+	RiskScore      int // 为0时使用默认风险分
+	OperatorID     uint64
+	ExpiresAt      *time.Time
+	BatchID        uint64 // 导入批次ID，由导入流程创建批次后设置
+}
+
+// CheckResult 黑名单查询结果，未命中时风险信息为空
+// 命中多个名单时风险信息取风险分最高的名单，命中黑名单但在租户白名单中的标识Hit为false、Overridden为true
+type CheckResult struct {
+	Hit                 bool
+	Overridden          bool
+	OverriddenRiskScore int // 被白名单豁免的命中的风险分
+	Category            string
+	RiskScore           int
+	Lists               []ListHit // 命中的名单，按查询的名单顺序排列，共享名单在最后
+
+	entrySHA256 string // HMAC格式命中的条目SHA-256，用于记录条目命中次数
+}
+
+// ListHit 命中的名单及该名单中条目的风险信息，共享名单的List为空且不包含贡献租户
+type ListHit struct {
+	List      string
+	Shared    bool
+	Category  string
+	RiskScore int
+	listID    uint64 // 用于记录条目命中次数
+	sha256    string // HMAC格式命中的条目SHA-256，用于记录条目命中次数
+}
+
+// addListHit 记录一个名单的命中，整体风险信息取风险分最高的名单
+func (r *CheckResult) addListHit(list *models.BlacklistList, hit *CheckResult) {
+	r.addHit(ListHit{List: list.Name, Category: hit.Category, RiskScore: hit.RiskScore, listID: list.ID, sha256: hit.entrySHA256})
+}
+
+// addSharedHit 记录共享名单的命中
+func (r *CheckResult) addSharedHit(hit *CheckResult) {
+	r.addHit(ListHit{Shared: true, Category: hit.Category, RiskScore: hit.RiskScore, sha256: hit.entrySHA256})
+}
+
+// addHit 记录命中，整体风险信息取风险分最高的名单
+func (r *CheckResult) addHit(hit ListHit) {
+	if !r.Hit || hit.RiskScore > r.RiskScore {
+		r.Category = hit.Category
+		r.RiskScore = hit.RiskScore
+	}
+	r.Hit = true
+	r.Lists = append(r.Lists, hit)
+}
+
+// OverriddenAbove 被白名单豁免的命中风险分是否不低于minRiskScore
+// 低于阈值的命中本就按未命中返回，不应通过豁免标记暴露
+func (r *CheckResult) OverriddenAbove(minRiskScore int) bool {
+	return r.Overridden && r.OverriddenRiskScore >= minRiskScore
+}
+
+// HitLists 风险分不低于minRiskScore的租户命中名单，不包含共享名单
+func (r *CheckResult) HitLists(minRiskScore int) []string {
+	names := make([]string, 0, len(r.Lists))
+	for _, hit := range r.Lists {
+		if !hit.Shared && hit.RiskScore >= minRiskScore {
+			names = append(names, hit.List)
+		}
+	}
+	return names
+}
+
+// HitSource 风险分不低于minRiskScore的命中来自租户名单、共享名单还是两者，均未命中时返回空
+func (r *CheckResult) HitSource(minRiskScore int) string {
+	tenant, shared := false, false
+	for _, hit := range r.Lists {
+		if hit.RiskScore < minRiskScore {
+			continue
+		}
+		if hit.Shared {
+			shared = true
+		} else {
+			tenant = true
+		}
+	}
+	switch {
+	case tenant && shared:
+		return models.HitSourceBoth
+	case shared:
+		return models.HitSourceShared
+	case tenant:
+		return models.HitSourceTenant
+	}
+	return ""
+}
+
+// 黑名单列表排序字段和排序方向
+const (
+	BlacklistSortByCreated = "created"
+	BlacklistSortByHits    = "hits"
+	BlacklistSortByLastHit = "last_hit"
+	BlacklistSortOrderAsc  = "asc"
+	BlacklistSortOrderDesc = "desc"
+)
+
+// hmacFallbackMaxEntries Redis不可用时HMAC格式回退数据库查询最多计算的条目数
+const hmacFallbackMaxEntries = 20000
+
+// BatchImportResult 批量导入结果
+type BatchImportResult struct {
+	BatchID    string // 导入批次UUID，可用于回滚
+	Created    int    // 新增条目数
+	Backfilled int    // 为名单中已有记录补充缺少哈希格式的数量
+	Duplicate  int    // 名单中已存在、无需补充的条目数
 }
 
 // QueryStats 查询统计信息
@@ -60,134 +212,449 @@ type MinutePoint struct {
 	AvgLatency   float64 `json:"avg_latency_ms"`
 }
 
+// blacklistSetKey 黑名单SET的Redis key，默认名单的手机号MD5沿用原有key保持兼容
+func blacklistSetKey(tenantID, listID uint64, identifierType, hashType string) string {
+	return blacklistKey("blacklist:tenant", tenantID, listID, identifierType, hashType)
+}
+
+// blacklistExpiryKey 黑名单过期时间ZSET的Redis key
+func blacklistExpiryKey(tenantID, listID uint64, identifierType, hashType string) string {
+	return blacklistKey("blacklist:expiry:tenant", tenantID, listID, identifierType, hashType)
+}
+
+// blacklistMetaKey 黑名单风险信息HASH的Redis key，仅存储非默认风险信息的条目
+func blacklistMetaKey(tenantID, listID uint64, identifierType, hashType string) string {
+	return blacklistKey("blacklist:meta:tenant", tenantID, listID, identifierType, hashType)
+}
+
+// blacklistPrefixKey 黑名单前缀索引ZSET的Redis key，供前缀范围查询按字典序取出同一前缀的哈希
+func blacklistPrefixKey(tenantID, listID uint64, identifierType, hashType string) string {
+	return blacklistKey("blacklist:prefix:tenant", tenantID, listID, identifierType, hashType)
+}
+
+// blacklistKey 按名单、标识类型和哈希格式拼接Redis key，默认名单不带名单后缀，MD5格式不带哈希后缀
+func blacklistKey(prefix string, tenantID, listID uint64, identifierType, hashType string) string {
+	if identifierType == "" {
+		identifierType = models.IdentifierTypePhone
+	}
+	base := fmt.Sprintf("%s:%d", prefix, tenantID)
+	if listID != models.DefaultListID {
+		base = fmt.Sprintf("%s:list:%d", base, listID)
+	}
+	if hashType == "" || hashType == models.HashTypeMD5 {
+		if identifierType == models.IdentifierTypePhone {
+			return base
+		}
+		return fmt.Sprintf("%s:%s", base, identifierType)
+	}
+	return fmt.Sprintf("%s:%s:%s", base, identifierType, hashType)
+}
+
+// blacklistFilterValue 本地过滤器中的元素值，按标识类型和哈希格式加前缀区分
+func blacklistFilterValue(identifierType, hashType, hash string) string {
+	if identifierType == "" {
+		identifierType = models.IdentifierTypePhone
+	}
+	if hashType == "" || hashType == models.HashTypeMD5 {
+		if identifierType == models.IdentifierTypePhone {
+			return hash
+		}
+		return identifierType + ":" + hash
+	}
+	return identifierType + ":" + hashType + ":" + hash
+}
+
+// listFilterValue 名单条目在本地过滤器中的元素值，默认名单与blacklistFilterValue相同
+func listFilterValue(listID uint64, identifierType, hashType, hash string) string {
+	value := blacklistFilterValue(identifierType, hashType, hash)
+	if listID == models.DefaultListID {
+		return value
+	}
+	return fmt.Sprintf("list:%d:%s", listID, value)
+}
+
 // blacklistService 黑名单服务实现
 type blacklistService struct {
-	blacklistRepo repositories.BlacklistRepository
-	redis         *redisClient.Client
-	logger        *logger.Logger
+	blacklistRepo  repositories.BlacklistRepository
+	settingRepo    repositories.BlacklistSettingRepository
+	importJobRepo  repositories.BlacklistImportJobRepository
+	batchRepo      repositories.BlacklistImportBatchRepository
+	driftRepo      repositories.BlacklistDriftReportRepository
+	queryLogRepo   repositories.BlacklistQueryLogRepository
+	credentialRepo repositories.ApiCredentialRepository
+	statsRepo      repositories.BlacklistQueryStatRepository
+	allowlistRepo  repositories.BlacklistAllowlistRepository
+	lists          ListService
+	shared         SharedListService
+	webhooks       WebhookService
+	redis          *redisClient.Client
+	logger         *logger.Logger
+	filter         *blacklistFilter
+	workerID       string // 实例标识，用于导入任务租约和Redis标记
+	stopCh         chan struct{}
+	stopOnce       sync.Once
+	wg             sync.WaitGroup // 后台任务，关闭时等待其结束
 }
 
 // NewBlacklistService 创建黑名单服务
 func NewBlacklistService(
 	blacklistRepo repositories.BlacklistRepository,
+	settingRepo repositories.BlacklistSettingRepository,
+	importJobRepo repositories.BlacklistImportJobRepository,
+	batchRepo repositories.BlacklistImportBatchRepository,
+	driftRepo repositories.BlacklistDriftReportRepository,
+	queryLogRepo repositories.BlacklistQueryLogRepository,
+	credentialRepo repositories.ApiCredentialRepository,
+	statsRepo repositories.BlacklistQueryStatRepository,
+	allowlistRepo repositories.BlacklistAllowlistRepository,
+	lists ListService,
+	shared SharedListService,
+	webhooks WebhookService,
 	redis *redisClient.Client,
 	logger *logger.Logger,
 ) BlacklistService {
-	return &blacklistService{
-		blacklistRepo: blacklistRepo,
-		redis:         redis,
-		logger:        logger,
+	service := &blacklistService{
+		blacklistRepo:  blacklistRepo,
+		settingRepo:    settingRepo,
+		importJobRepo:  importJobRepo,
+		batchRepo:      batchRepo,
+		driftRepo:      driftRepo,
+		queryLogRepo:   queryLogRepo,
+		credentialRepo: credentialRepo,
+		statsRepo:      statsRepo,
+		allowlistRepo:  allowlistRepo,
+		lists:          lists,
+		shared:         shared,
+		webhooks:       webhooks,
+		redis:          redis,
+		logger:         logger,
+		workerID:       newImportWorkerID(),
+		stopCh:         make(chan struct{}),
 	}
+	service.filter = newBlacklistFilter(service.loadFilterValues, redis, logger)
+	service.wg.Add(5)
+
+	// 启动过期条目清理的goroutine
+	go service.sweepExpiredLoop()
+
+	// 启动导入任务执行的goroutine
+	go service.importJobLoop()
+
+	// 启动Redis偏差检查的goroutine
+	go service.reconcileLoop()
+
+	// 启动查询统计汇总的goroutine
+	go service.rollupStatsLoop()
+
+	// 启动条目命中次数写入的goroutine
+	go service.flushEntryHitsLoop()
+
+	return service
 }
 
-// CheckPhoneMD5 检查手机号MD5是否在黑名单中
-func (s *blacklistService) CheckPhoneMD5(ctx context.Context, tenantID uint64, phoneMD5 string) (bool, error) {
-	// 构建Redis key
-	redisKey := fmt.Sprintf("blacklist:tenant:%d", tenantID)
+// Close 停止后台任务
+func (s *blacklistService) Close(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	// 从Redis SET中检查
-	exists, err := s.redis.SIsMember(ctx, redisKey, phoneMD5).Result()
+// loadFilterValues 加载租户所有类型的有效条目，用于构建本地过滤器
+func (s *blacklistService) loadFilterValues(ctx context.Context, tenantID uint64) ([]string, error) {
+	blacklists, err := s.blacklistRepo.GetActiveListByTenant(ctx, tenantID)
 	if err != nil {
-		s.logger.ErrorWithTrace(ctx, "Redis查询失败，回退到数据库查询",
-			zap.Error(err),
-			zap.Uint64("tenant_id", tenantID),
-			zap.String("phone_md5", phoneMD5))
+		return nil, err
+	}
 
-		// Redis失败时回退到数据库查询
-		return s.blacklistRepo.ExistsByTenantAndMD5(ctx, tenantID, phoneMD5)
+	salt, err := s.getHashSalt(ctx, tenantID)
+	if err != nil {
+		return nil, err
 	}
 
-	s.logger.DebugWithTrace(ctx, "黑名单查询完成",
-		zap.Uint64("tenant_id", tenantID),
-		zap.String("phone_md5", phoneMD5),
-		zap.Bool("is_hit", exists))
+	return entryFilterValues(blacklists, salt), nil
+}
 
-	return exists, nil
+// CheckPhoneMD5 检查手机号MD5是否在默认名单中，在租户白名单中的命中返回false
+func (s *blacklistService) CheckPhoneMD5(ctx context.Context, tenantID uint64, phoneMD5 string) (bool, error) {
+	result, err := s.CheckIdentifier(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeMD5, phoneMD5, nil)
+	if err != nil {
+		return false, err
+	}
+	return result.Hit, nil
 }
 
-// CheckPhoneMD5Batch 批量检查手机号MD5是否在黑名单中
+// CheckPhoneMD5Batch 批量检查手机号MD5是否在默认名单中，在租户白名单中的命中返回false
 func (s *blacklistService) CheckPhoneMD5Batch(ctx context.Context, tenantID uint64, phoneMD5List []string) (map[string]bool, error) {
-	if len(phoneMD5List) == 0 {
-		return make(map[string]bool), nil
+	results, err := s.CheckIdentifierBatch(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeMD5, phoneMD5List, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	// 构建Redis key
-	redisKey := fmt.Sprintf("blacklist:tenant:%d", tenantID)
-	
-	// 使用Redis Pipeline批量查询，提高性能
-	pipe := s.redis.Pipeline()
-	
-	// 为每个MD5创建查询命令
-	cmdMap := make(map[string]*redis.BoolCmd)
-	for _, phoneMD5 := range phoneMD5List {
-		cmd := pipe.SIsMember(ctx, redisKey, phoneMD5)
-		cmdMap[phoneMD5] = cmd
+	hits := make(map[string]bool, len(results))
+	for hash, result := range results {
+		hits[hash] = result.Hit
 	}
+	return hits, nil
+}
 
-	// 执行Pipeline
-	_, err := pipe.Exec(ctx)
+// CheckIdentifier 检查指定类型标识的哈希是否在给定名单中，lists为空时查询默认名单，命中时返回风险分类和风险分
+// 命中但在租户白名单有效期内的标识返回未命中并标记为已豁免
+func (s *blacklistService) CheckIdentifier(ctx context.Context, tenantID uint64, identifierType, hashType, hash string, lists []*models.BlacklistList) (*CheckResult, error) {
+	results, err := s.checkLists(ctx, tenantID, identifierType, hashType, []string{hash}, lists)
 	if err != nil {
-		s.logger.WarnWithTrace(ctx, "Redis批量查询失败，回退到数据库查询",
-			zap.Error(err),
-			zap.Uint64("tenant_id", tenantID),
-			zap.Int("batch_size", len(phoneMD5List)))
+		return nil, err
+	}
+	result := results[hash]
+
+	s.logger.DebugWithTrace(ctx, "黑名单查询完成",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("identifier_type", identifierType),
+		zap.String("hash_type", hashType),
+		zap.String("hash", hash),
+		zap.Int("list_count", len(lists)),
+		zap.Bool("is_hit", result.Hit))
 
-		// Redis失败时回退到数据库查询
-		return s.batchCheckFromDatabase(ctx, tenantID, phoneMD5List)
+	return result, nil
+}
+
+// CheckIdentifierBatch 批量检查指定类型标识的哈希是否在给定名单中，lists为空时查询默认名单
+func (s *blacklistService) CheckIdentifierBatch(ctx context.Context, tenantID uint64, identifierType, hashType string, hashList []string, lists []*models.BlacklistList) (map[string]*CheckResult, error) {
+	if len(hashList) == 0 {
+		return make(map[string]*CheckResult), nil
 	}
 
-	// 收集结果
-	results := make(map[string]bool)
-	for phoneMD5, cmd := range cmdMap {
-		exists, err := cmd.Result()
-		if err != nil {
-			s.logger.WarnWithTrace(ctx, "获取Redis查询结果失败",
-				zap.Error(err),
-				zap.String("phone_md5", phoneMD5))
-			// 单个查询失败时设置为false
-			results[phoneMD5] = false
-		} else {
-			results[phoneMD5] = exists
-		}
+	results, err := s.checkLists(ctx, tenantID, identifierType, hashType, hashList, lists)
+	if err != nil {
+		return nil, err
 	}
 
 	s.logger.DebugWithTrace(ctx, "批量黑名单查询完成",
 		zap.Uint64("tenant_id", tenantID),
-		zap.Int("total_count", len(phoneMD5List)),
+		zap.String("identifier_type", identifierType),
+		zap.String("hash_type", hashType),
+		zap.Int("list_count", len(lists)),
+		zap.Int("total_count", len(hashList)),
 		zap.Int("hit_count", s.countHits(results)))
 
 	return results, nil
 }
 
-// batchCheckFromDatabase 从数据库批量检查黑名单
-func (s *blacklistService) batchCheckFromDatabase(ctx context.Context, tenantID uint64, phoneMD5List []string) (map[string]bool, error) {
-	results := make(map[string]bool)
-	
-	// 初始化所有结果为false
-	for _, phoneMD5 := range phoneMD5List {
-		results[phoneMD5] = false
+// listCheck 一个哈希在一个名单中的Redis查询，共享名单的tenantID为SharedTenantID
+type listCheck struct {
+	tenantID uint64
+	list     *models.BlacklistList
+	hash     string
+	positive bool // 本地过滤器判定可能存在
+	member   *redis.BoolCmd
+	expiry   *redis.FloatCmd
+	meta     *redis.StringCmd
+}
+
+// checkLists 查询哈希在各名单中的命中情况，任一名单命中即为命中，租户订阅了共享名单时同时查询共享名单
+// 本地过滤器判定一定不存在的名单跳过，其余在同一个Pipeline中回源，Redis失败时回退到数据库查询
+func (s *blacklistService) checkLists(ctx context.Context, tenantID uint64, identifierType, hashType string, hashList []string, lists []*models.BlacklistList) (map[string]*CheckResult, error) {
+	if len(lists) == 0 {
+		lists = []*models.BlacklistList{defaultBlacklistList()}
 	}
+	shared := s.shared.Subscribed(ctx, tenantID, hashType)
 
-	// 从数据库批量查询存在的MD5
-	existingMD5List := make([]string, 0)
-	err := s.blacklistRepo.GetActiveMD5ListByTenantAndMD5List(ctx, tenantID, phoneMD5List, &existingMD5List)
-	if err != nil {
-		return nil, fmt.Errorf("数据库批量查询失败: %w", err)
+	results := make(map[string]*CheckResult, len(hashList))
+	candidates := make([]string, 0, len(hashList))
+	checks := make([]*listCheck, 0, len(hashList))
+	for _, hash := range hashList {
+		if _, exists := results[hash]; exists {
+			continue
+		}
+		results[hash] = &CheckResult{}
+		candidate := false
+		for _, list := range lists {
+			decision := s.filter.check(tenantID, listFilterValue(list.ID, identifierType, hashType, hash))
+			if decision == filterNegative {
+				continue
+			}
+			checks = append(checks, &listCheck{tenantID: tenantID, list: list, hash: hash, positive: decision == filterPositive})
+			candidate = true
+		}
+		if shared {
+			decision := s.filter.check(models.SharedTenantID, blacklistFilterValue(identifierType, hashType, hash))
+			if decision != filterNegative {
+				checks = append(checks, &listCheck{tenantID: models.SharedTenantID, hash: hash, positive: decision == filterPositive})
+				candidate = true
+			}
+		}
+		if candidate {
+			candidates = append(candidates, hash)
+		}
+	}
+	metrics.AddBlacklistChecks(tenantID, metrics.SourceFilter, 0, len(results)-len(candidates))
+	if len(checks) == 0 {
+		return results, nil
+	}
+
+	// 从Redis SET中检查，同时获取过期时间和风险信息（清理任务执行前过期的条目视为未命中）
+	pipe := s.redis.Pipeline()
+	for _, check := range checks {
+		listID := models.DefaultListID
+		if check.list != nil {
+			listID = check.list.ID
+		}
+		check.member = pipe.SIsMember(ctx, blacklistSetKey(check.tenantID, listID, identifierType, hashType), check.hash)
+		check.expiry = pipe.ZScore(ctx, blacklistExpiryKey(check.tenantID, listID, identifierType, hashType), check.hash)
+		check.meta = pipe.HGet(ctx, blacklistMetaKey(check.tenantID, listID, identifierType, hashType), check.hash)
 	}
 
-	// 标记存在的MD5为true
-	for _, phoneMD5 := range existingMD5List {
-		results[phoneMD5] = true
+	// 未设置过期时间或风险信息的条目返回redis.Nil，属于正常情况
+	candidateResults := make(map[string]*CheckResult, len(candidates))
+	for _, hash := range candidates {
+		candidateResults[hash] = results[hash]
+	}
+	source := metrics.SourceRedis
+	falsePositives := make(map[uint64]int, 2)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		s.logger.WarnWithTrace(ctx, "Redis查询失败，回退到数据库查询",
+			zap.Error(err),
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("identifier_type", identifierType),
+			zap.String("hash_type", hashType),
+			zap.Int("batch_size", len(candidates)))
+
+		if err := s.checkListsFromDatabase(ctx, tenantID, identifierType, hashType, candidates, lists, shared, candidateResults); err != nil {
+			return nil, err
+		}
+		source = metrics.SourceDB
+	} else {
+		now := time.Now()
+		for _, check := range checks {
+			switch {
+			case check.member.Val() && !isExpiredScore(check.expiry, now):
+				if check.list == nil {
+					candidateResults[check.hash].addSharedHit(newHitResult(check.meta))
+				} else {
+					candidateResults[check.hash].addListHit(check.list, newHitResult(check.meta))
+				}
+			case check.positive:
+				falsePositives[check.tenantID]++
+			}
+		}
+	}
+	for filterTenantID, count := range falsePositives {
+		s.filter.recordFalsePositive(filterTenantID, count)
+	}
+
+	// 命中的标识在租户白名单中时按未命中返回，指标按豁免后的结果统计
+	s.applyAllowlist(ctx, tenantID, identifierType, hashType, candidateResults)
+	s.observeChecks(tenantID, source, candidateResults)
+
+	// 异步记录条目命中次数，不增加查询延迟
+	if hits := collectEntryHits(tenantID, identifierType, hashType, candidateResults); len(hits) > 0 {
+		go s.recordEntryHits(context.WithoutCancel(ctx), hits)
 	}
 
 	return results, nil
 }
 
+// checkListsFromDatabase 从数据库批量检查哈希在各名单中的命中情况，结果写入results
+func (s *blacklistService) checkListsFromDatabase(ctx context.Context, tenantID uint64, identifierType, hashType string, hashList []string, lists []*models.BlacklistList, shared bool, results map[string]*CheckResult) error {
+	listIDs := make([]uint64, 0, len(lists))
+	byID := make(map[uint64]*models.BlacklistList, len(lists))
+	for _, list := range lists {
+		listIDs = append(listIDs, list.ID)
+		byID[list.ID] = list
+	}
+	// HMAC格式不订阅共享名单，shared恒为false
+	if hashType == models.HashTypeHMACSHA256 {
+		return s.checkHMACFromDatabase(ctx, tenantID, identifierType, listIDs, byID, results)
+	}
+
+	blacklists, err := s.blacklistRepo.GetActiveByTenantAndHashes(ctx, tenantID, listIDs, identifierType, hashType, hashList)
+	if err != nil {
+		return fmt.Errorf("数据库批量查询失败: %w", err)
+	}
+
+	// 标记存在的哈希为命中
+	for _, blacklist := range blacklists {
+		hash := blacklist.PhoneMD5
+		if hashType == models.HashTypeSHA256 {
+			hash = blacklist.IdentifierSHA256
+		}
+		if result, ok := results[hash]; ok {
+			result.addListHit(byID[blacklist.ListID], &CheckResult{Category: blacklist.Category, RiskScore: blacklist.RiskScore})
+		}
+	}
+	if !shared {
+		return nil
+	}
+
+	sharedEntries, err := s.blacklistRepo.GetActiveByTenantAndHashes(ctx, models.SharedTenantID, []uint64{models.DefaultListID}, identifierType, hashType, hashList)
+	if err != nil {
+		return fmt.Errorf("数据库查询共享名单失败: %w", err)
+	}
+	for _, blacklist := range sharedEntries {
+		hash := blacklist.PhoneMD5
+		if hashType == models.HashTypeSHA256 {
+			hash = blacklist.IdentifierSHA256
+		}
+		if result, ok := results[hash]; ok {
+			result.addSharedHit(&CheckResult{Category: blacklist.Category, RiskScore: blacklist.RiskScore})
+		}
+	}
+	return nil
+}
+
+// checkHMACFromDatabase HMAC格式不落库，从数据库取出名单中有SHA-256的有效条目按租户盐计算HMAC后匹配
+// 计算量随条目数增长，条目数超过hmacFallbackMaxEntries时返回服务暂不可用，调用方可改用SHA-256格式查询
+func (s *blacklistService) checkHMACFromDatabase(ctx context.Context, tenantID uint64, identifierType string, listIDs []uint64, byID map[uint64]*models.BlacklistList, results map[string]*CheckResult) error {
+	blacklists, err := s.blacklistRepo.GetActiveWithSHA256(ctx, tenantID, listIDs, identifierType, hmacFallbackMaxEntries+1)
+	if err != nil {
+		return fmt.Errorf("数据库查询HMAC条目失败: %w", err)
+	}
+	if len(blacklists) > hmacFallbackMaxEntries {
+		s.logger.WarnWithTrace(ctx, "Redis不可用且名单条目过多，无法回退查询HMAC格式",
+			zap.Uint64("tenant_id", tenantID),
+			zap.Int("max_entries", hmacFallbackMaxEntries))
+		return errors.NewBusinessError(errors.CodeServiceUnavailable, "HMAC-SHA256格式查询暂不可用，请稍后重试或改用SHA-256格式")
+	}
+
+	salt, err := s.getHashSalt(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	for _, blacklist := range blacklists {
+		if result, ok := results[hmacIdentifier(salt, blacklist.IdentifierSHA256)]; ok {
+			result.addListHit(byID[blacklist.ListID], &CheckResult{
+				Category:    blacklist.Category,
+				RiskScore:   blacklist.RiskScore,
+				entrySHA256: blacklist.IdentifierSHA256,
+			})
+		}
+	}
+	return nil
+}
+
+// observeChecks 按查询来源记录命中和未命中的指标
+func (s *blacklistService) observeChecks(tenantID uint64, source string, results map[string]*CheckResult) {
+	hits := s.countHits(results)
+	metrics.AddBlacklistChecks(tenantID, source, hits, len(results)-hits)
+}
+
 // countHits 计算命中数量
-func (s *blacklistService) countHits(results map[string]bool) int {
+func (s *blacklistService) countHits(results map[string]*CheckResult) int {
 	count := 0
-	for _, isHit := range results {
-		if isHit {
+	for _, result := range results {
+		if result.Hit {
 			count++
 		}
 	}
@@ -196,80 +663,250 @@ func (s *blacklistService) countHits(results map[string]bool) int {
 
 // CreateBlacklist 创建黑名单记录
 func (s *blacklistService) CreateBlacklist(ctx context.Context, blacklist *models.PhoneBlacklist) error {
-	// 创建数据库记录
-	err := s.blacklistRepo.Create(ctx, blacklist)
+	if blacklist.IdentifierType == "" {
+		blacklist.IdentifierType = models.IdentifierTypePhone
+	}
+
+	salt, err := s.getHashSalt(ctx, blacklist.TenantID)
 	if err != nil {
-		return fmt.Errorf("创建黑名单记录失败: %w", err)
+		return err
 	}
 
-	// 同步到Redis
-	redisKey := fmt.Sprintf("blacklist:tenant:%d", blacklist.TenantID)
-	err = s.redis.SAdd(ctx, redisKey, blacklist.PhoneMD5).Err()
+	// 同一名单中已有相同标识（任一哈希格式相同）的记录时补充该记录缺少的哈希格式，不再新建
+	backfilled, remaining, err := s.backfillHashes(ctx, blacklist.TenantID, blacklist.ListID, blacklist.IdentifierType,
+		[]IdentifierHashes{{MD5: blacklist.PhoneMD5, SHA256: blacklist.IdentifierSHA256}})
 	if err != nil {
-		s.logger.WarnWithTrace(ctx, "同步黑名单到Redis失败",
-			zap.Error(err),
-			zap.Uint64("tenant_id", blacklist.TenantID),
-			zap.String("phone_md5", blacklist.PhoneMD5))
+		return err
+	}
+	if len(backfilled) > 0 {
+		*blacklist = *backfilled[0]
+		s.syncEntries(ctx, blacklist.TenantID, backfilled, salt)
+		return nil
 	}
+	if len(remaining) == 0 {
+		return errors.NewBusinessError(errors.CodeConflict, "标识已存在于名单中")
+	}
+
+	// 创建数据库记录
+	err = s.blacklistRepo.Create(ctx, blacklist)
+	if err != nil {
+		if stderrors.Is(err, repositories.ErrDuplicateEntry) {
+			return errors.NewBusinessError(errors.CodeConflict, "标识已存在于名单中")
+		}
+		return fmt.Errorf("创建黑名单记录失败: %w", err)
+	}
+
+	s.syncEntries(ctx, blacklist.TenantID, []*models.PhoneBlacklist{blacklist}, salt)
+	s.webhooks.Publish(ctx, blacklist.TenantID, models.WebhookEventEntryCreated, newWebhookEntryData(blacklist))
 
 	s.logger.InfoWithTrace(ctx, "黑名单记录创建成功",
 		zap.Uint64("tenant_id", blacklist.TenantID),
-		zap.String("phone_md5", blacklist.PhoneMD5),
+		zap.Uint64("list_id", blacklist.ListID),
+		zap.String("identifier_type", blacklist.IdentifierType),
+		zap.String("md5", blacklist.PhoneMD5),
+		zap.String("sha256", blacklist.IdentifierSHA256),
 		zap.String("source", blacklist.Source))
 
 	return nil
 }
 
 // BatchImportBlacklist 批量导入黑名单
-func (s *blacklistService) BatchImportBlacklist(ctx context.Context, tenantID uint64, phoneMD5List []string, source, reason string, operatorID uint64) error {
-	if len(phoneMD5List) == 0 {
-		return fmt.Errorf("导入列表为空")
+func (s *blacklistService) BatchImportBlacklist(ctx context.Context, params *BatchImportParams) (*BatchImportResult, error) {
+	if len(params.Items) == 0 {
+		return nil, fmt.Errorf("导入列表为空")
+	}
+	if err := s.ensureDirectWriteAllowed(ctx, params.TenantID, "批量导入"); err != nil {
+		return nil, err
+	}
+
+	identifierType := params.IdentifierType
+	if identifierType == "" {
+		identifierType = models.IdentifierTypePhone
+	}
+
+	salt, err := s.getHashSalt(ctx, params.TenantID)
+	if err != nil {
+		return nil, err
 	}
 
-	// 准备批量数据
-	blacklists := make([]*models.PhoneBlacklist, 0, len(phoneMD5List))
-	redisValues := make([]interface{}, 0, len(phoneMD5List))
+	// 名单中已有相同标识的条目补充到已有记录，避免同一标识产生多条记录
+	backfilled, items, err := s.backfillHashes(ctx, params.TenantID, params.ListID, identifierType, params.Items)
+	if err != nil {
+		return nil, err
+	}
+	duplicate := len(params.Items) - len(items) - len(backfilled)
 
-	for _, phoneMD5 := range phoneMD5List {
+	// 创建导入批次，新增的条目归属该批次
+	batch, err := s.startImportBatch(ctx, params, identifierType, models.ImportBatchMethodAPI, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// 批量插入数据库
+	entryParams := *params
+	entryParams.BatchID = batch.ID
+	blacklists, err := s.createEntries(ctx, &entryParams, identifierType, items)
+	s.completeImportBatch(ctx, batch, int64(len(blacklists)))
+	if err != nil {
+		return nil, err
+	}
+
+	// 同步到Redis和本地过滤器
+	s.syncEntries(ctx, params.TenantID, append(blacklists, backfilled...), salt)
+
+	s.logger.InfoWithTrace(ctx, "批量导入黑名单成功",
+		zap.Uint64("tenant_id", params.TenantID),
+		zap.Uint64("list_id", params.ListID),
+		zap.String("identifier_type", identifierType),
+		zap.Int("created", len(blacklists)),
+		zap.Int("backfilled", len(backfilled)),
+		zap.Int("duplicate", duplicate),
+		zap.String("source", params.Source))
+
+	return &BatchImportResult{BatchID: batch.UUID, Created: len(blacklists), Backfilled: len(backfilled), Duplicate: duplicate}, nil
+}
+
+// createEntries 按导入参数批量创建条目，不同步Redis
+func (s *blacklistService) createEntries(ctx context.Context, params *BatchImportParams, identifierType string, items []IdentifierHashes) ([]*models.PhoneBlacklist, error) {
+	blacklists := newEntries(params, identifierType, items)
+	if err := s.blacklistRepo.BatchCreate(ctx, blacklists); err != nil {
+		return nil, fmt.Errorf("批量导入黑名单失败: %w", err)
+	}
+	return blacklists, nil
+}
+
+// newEntries 按导入参数构建条目
+func newEntries(params *BatchImportParams, identifierType string, items []IdentifierHashes) []*models.PhoneBlacklist {
+	blacklists := make([]*models.PhoneBlacklist, 0, len(items))
+	for _, item := range items {
 		blacklists = append(blacklists, &models.PhoneBlacklist{
-			TenantModel: models.TenantModel{TenantID: tenantID},
-			PhoneMD5:    phoneMD5,
-			Source:      source,
-			Reason:      reason,
-			OperatorID:  operatorID,
-			IsActive:    true,
+			TenantModel:      models.TenantModel{TenantID: params.TenantID},
+			ListID:           params.ListID,
+			IdentifierType:   identifierType,
+			PhoneMD5:         item.MD5,
+			IdentifierSHA256: item.SHA256,
+			Source:           params.Source,
+			Reason:           params.Reason,
+			Category:         params.Category,
+			RiskScore:        params.RiskScore,
+			OperatorID:       params.OperatorID,
+			IsActive:         true,
+			ExpiresAt:        params.ExpiresAt,
+			BatchID:          params.BatchID,
 		})
-		redisValues = append(redisValues, phoneMD5)
 	}
+	return blacklists
+}
 
-	// 批量插入数据库
-	err := s.blacklistRepo.BatchCreate(ctx, blacklists)
+// backfillHashes 为名单中与条目标识相同的已有记录补充缺少的哈希格式，返回已补充的记录和名单中不存在、需要新建的条目
+func (s *blacklistService) backfillHashes(ctx context.Context, tenantID, listID uint64, identifierType string, items []IdentifierHashes) ([]*models.PhoneBlacklist, []IdentifierHashes, error) {
+	blacklists, remaining, err := s.backfillCandidates(ctx, tenantID, listID, identifierType, items)
 	if err != nil {
-		return fmt.Errorf("批量导入黑名单失败: %w", err)
+		return nil, nil, err
+	}
+
+	if err := s.blacklistRepo.BackfillHashes(ctx, blacklists); err != nil {
+		if stderrors.Is(err, repositories.ErrDuplicateEntry) {
+			return nil, nil, errors.NewBusinessError(errors.CodeConflict, "标识已存在于名单中")
+		}
+		return nil, nil, fmt.Errorf("补充哈希格式失败: %w", err)
+	}
+
+	if len(blacklists) > 0 {
+		s.logger.InfoWithTrace(ctx, "已有条目补充哈希格式",
+			zap.Uint64("tenant_id", tenantID),
+			zap.String("identifier_type", identifierType),
+			zap.Int("count", len(blacklists)))
+	}
+
+	return blacklists, remaining, nil
+}
+
+// backfillCandidates 查询名单中与条目任一哈希格式相同的有效记录，返回需补充的记录（已填入待补充的哈希）和名单中不存在的条目
+// MD5和SHA-256分别命中不同记录、或记录已有全部提供的哈希格式时视为已存在，既不补充也不新建
+func (s *blacklistService) backfillCandidates(ctx context.Context, tenantID, listID uint64, identifierType string, items []IdentifierHashes) ([]*models.PhoneBlacklist, []IdentifierHashes, error) {
+	md5List := make([]string, 0, len(items))
+	sha256List := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.MD5 != "" {
+			md5List = append(md5List, item.MD5)
+		}
+		if item.SHA256 != "" {
+			sha256List = append(sha256List, item.SHA256)
+		}
 	}
 
-	// 批量同步到Redis
-	redisKey := fmt.Sprintf("blacklist:tenant:%d", tenantID)
-	err = s.redis.SAdd(ctx, redisKey, redisValues...).Err()
+	existing, err := s.blacklistRepo.GetActiveByListAndIdentifiers(ctx, tenantID, listID, identifierType, md5List, sha256List)
 	if err != nil {
-		s.logger.WarnWithTrace(ctx, "批量同步黑名单到Redis失败",
+		return nil, nil, fmt.Errorf("查询已有条目失败: %w", err)
+	}
+	byMD5 := make(map[string]*models.PhoneBlacklist, len(existing))
+	bySHA256 := make(map[string]*models.PhoneBlacklist, len(existing))
+	for _, blacklist := range existing {
+		if blacklist.PhoneMD5 != "" {
+			byMD5[blacklist.PhoneMD5] = blacklist
+		}
+		if blacklist.IdentifierSHA256 != "" {
+			bySHA256[blacklist.IdentifierSHA256] = blacklist
+		}
+	}
+
+	backfilled := make([]*models.PhoneBlacklist, 0)
+	remaining := make([]IdentifierHashes, 0, len(items))
+	for _, item := range items {
+		var md5Match, sha256Match *models.PhoneBlacklist
+		if item.MD5 != "" {
+			md5Match = byMD5[item.MD5]
+		}
+		if item.SHA256 != "" {
+			sha256Match = bySHA256[item.SHA256]
+		}
+
+		switch {
+		case md5Match == nil && sha256Match == nil:
+			remaining = append(remaining, item)
+		case md5Match != nil && sha256Match == nil && item.SHA256 != "" && md5Match.IdentifierSHA256 == "":
+			md5Match.IdentifierSHA256 = item.SHA256
+			bySHA256[item.SHA256] = md5Match
+			backfilled = append(backfilled, md5Match)
+		case sha256Match != nil && md5Match == nil && item.MD5 != "" && sha256Match.PhoneMD5 == "":
+			sha256Match.PhoneMD5 = item.MD5
+			byMD5[item.MD5] = sha256Match
+			backfilled = append(backfilled, sha256Match)
+		}
+	}
+	return backfilled, remaining, nil
+}
+
+// syncEntries 将新增条目的全部哈希格式写入Redis，写入本地过滤器通知其他实例，并补全相同标识只有一种格式的白名单条目
+func (s *blacklistService) syncEntries(ctx context.Context, tenantID uint64, blacklists []*models.PhoneBlacklist, salt string) {
+	if len(blacklists) == 0 {
+		return
+	}
+
+	if err := s.addEntriesToRedis(ctx, tenantID, blacklists, salt); err != nil {
+		s.logger.WarnWithTrace(ctx, "同步黑名单到Redis失败",
 			zap.Error(err),
 			zap.Uint64("tenant_id", tenantID),
-			zap.Int("count", len(phoneMD5List)))
+			zap.Int("count", len(blacklists)))
 	}
 
-	s.logger.InfoWithTrace(ctx, "批量导入黑名单成功",
-		zap.Uint64("tenant_id", tenantID),
-		zap.Int("count", len(phoneMD5List)),
-		zap.String("source", source))
-
-	return nil
+	s.filter.publish(ctx, tenantID, entryFilterValues(blacklists, salt)...)
+	s.completeAllowlistEntries(ctx, tenantID, blacklists, salt)
 }
 
-// GetBlacklistByTenant 分页获取租户黑名单
-func (s *blacklistService) GetBlacklistByTenant(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.PhoneBlacklist, int64, error) {
+// GetBlacklistByTenant 分页获取租户黑名单，sortBy为空时按创建时间排序，order为asc时升序，其余为降序
+func (s *blacklistService) GetBlacklistByTenant(ctx context.Context, tenantID uint64, sortBy, order string, page, pageSize int) ([]*models.PhoneBlacklist, int64, error) {
+	sort := repositories.BlacklistOrder{Column: "created_at", Desc: order != BlacklistSortOrderAsc}
+	switch sortBy {
+	case BlacklistSortByHits:
+		sort.Column = "hit_count"
+	case BlacklistSortByLastHit:
+		sort.Column = "last_hit_at"
+	}
+
 	offset := (page - 1) * pageSize
-	return s.blacklistRepo.GetByTenant(ctx, tenantID, offset, pageSize)
+	return s.blacklistRepo.GetByTenant(ctx, tenantID, sort, offset, pageSize)
 }
 
 // DeleteBlacklist 删除黑名单记录
@@ -286,56 +923,28 @@ func (s *blacklistService) DeleteBlacklist(ctx context.Context, id uint64) error
 		return fmt.Errorf("删除黑名单记录失败: %w", err)
 	}
 
-	// 从Redis中移除
-	redisKey := fmt.Sprintf("blacklist:tenant:%d", blacklist.TenantID)
-	err = s.redis.SRem(ctx, redisKey, blacklist.PhoneMD5).Err()
+	// 从Redis中移除全部哈希格式
+	salt, err := s.getHashSalt(ctx, blacklist.TenantID)
+	if err == nil {
+		err = s.removeEntriesFromRedis(ctx, blacklist.TenantID, []*models.PhoneBlacklist{blacklist}, salt)
+	}
 	if err != nil {
 		s.logger.WarnWithTrace(ctx, "从Redis中移除黑名单失败",
 			zap.Error(err),
 			zap.Uint64("tenant_id", blacklist.TenantID),
-			zap.String("phone_md5", blacklist.PhoneMD5))
+			zap.String("identifier_type", blacklist.IdentifierType),
+			zap.String("md5", blacklist.PhoneMD5))
 	}
 
+	// 过滤器无法删除元素，仅记录删除数量，累计到阈值后重建
+	s.filter.remove(blacklist.TenantID, 1)
+	s.webhooks.Publish(ctx, blacklist.TenantID, models.WebhookEventEntryDeleted, newWebhookEntryData(blacklist))
+
 	s.logger.InfoWithTrace(ctx, "黑名单记录删除成功",
 		zap.Uint64("id", id),
 		zap.Uint64("tenant_id", blacklist.TenantID),
-		zap.String("phone_md5", blacklist.PhoneMD5))
-
-	return nil
-}
-
-// SyncToRedis 同步租户黑名单到Redis
-func (s *blacklistService) SyncToRedis(ctx context.Context, tenantID uint64) error {
-	// 从数据库获取所有有效的MD5
-	md5List, err := s.blacklistRepo.GetActiveMD5ListByTenant(ctx, tenantID)
-	if err != nil {
-		return fmt.Errorf("获取黑名单数据失败: %w", err)
-	}
-
-	redisKey := fmt.Sprintf("blacklist:tenant:%d", tenantID)
-
-	// 清空现有Redis数据
-	err = s.redis.Del(ctx, redisKey).Err()
-	if err != nil {
-		return fmt.Errorf("清空Redis数据失败: %w", err)
-	}
-
-	// 如果有数据，批量添加到Redis
-	if len(md5List) > 0 {
-		values := make([]interface{}, len(md5List))
-		for i, md5 := range md5List {
-			values[i] = md5
-		}
-
-		err = s.redis.SAdd(ctx, redisKey, values...).Err()
-		if err != nil {
-			return fmt.Errorf("同步数据到Redis失败: %w", err)
-		}
-	}
-
-	s.logger.InfoWithTrace(ctx, "黑名单数据同步到Redis成功",
-		zap.Uint64("tenant_id", tenantID),
-		zap.Int("count", len(md5List)))
+		zap.String("identifier_type", blacklist.IdentifierType),
+		zap.String("md5", blacklist.PhoneMD5))
 
 	return nil
 }
@@ -462,8 +1071,8 @@ func (s *blacklistService) UpdateQueryMetrics(ctx context.Context, tenantID uint
 	now := time.Now()
 
 	// 分钟级统计key
-	minuteKey := fmt.Sprintf("stats:minute:tenant:%d:%s", tenantID, now.Format("200601021504"))
-	hourKey := fmt.Sprintf("stats:query:tenant:%d:%s", tenantID, now.Format("2006010215"))
+	minuteKey := tenantMinuteStatsKey(tenantID, now)
+	hourKey := tenantHourStatsKey(tenantID, now)
 
 	// 使用Pipeline批量更新
 	pipe := s.redis.Pipeline()
@@ -486,12 +1095,29 @@ func (s *blacklistService) UpdateQueryMetrics(ctx context.Context, tenantID uint
 	pipe.Expire(ctx, hourKey, 48*time.Hour) // 保留48小时
 
 	// 更新API Key的分钟级统计
-	apiMinuteKey := fmt.Sprintf("stats:minute:api:%s:%s", apiKey, now.Format("200601021504"))
+	apiMinuteKey := apiMinuteStatsKey(apiKey, now)
 	pipe.HIncrBy(ctx, apiMinuteKey, "total", 1)
 	if isHit {
 		pipe.HIncrBy(ctx, apiMinuteKey, "hits", 1)
 	}
-	pipe.Expire(ctx, apiMinuteKey, 1*time.Hour) // API统计保留1小时
+	pipe.HIncrBy(ctx, apiMinuteKey, "latency", latencyMs)
+	pipe.HIncrBy(ctx, apiMinuteKey, "count", 1)
+	pipe.Expire(ctx, apiMinuteKey, 2*time.Hour) // 保留2小时
+
+	// 更新API Key的小时级统计
+	apiHourKey := apiHourStatsKey(apiKey, now)
+	pipe.HIncrBy(ctx, apiHourKey, "total", 1)
+	if isHit {
+		pipe.HIncrBy(ctx, apiHourKey, "hits", 1)
+	}
+	pipe.HIncrBy(ctx, apiHourKey, "latency", latencyMs)
+	pipe.HIncrBy(ctx, apiHourKey, "count", 1)
+	pipe.Expire(ctx, apiHourKey, 48*time.Hour) // 保留48小时
+
+	// 记录本小时有查询的API Key，供统计汇总使用
+	keysKey := statsKeysKey(now)
+	pipe.SAdd(ctx, keysKey, statsKeysMember(tenantID, apiKey))
+	pipe.Expire(ctx, keysKey, 48*time.Hour) // 与小时级统计一致
 
 	_, err := pipe.Exec(ctx)
 	if err != nil {
@@ -501,3 +1127,14 @@ func (s *blacklistService) UpdateQueryMetrics(ctx context.Context, tenantID uint
 			zap.Error(err))
 	}
 }
+
+// GetFilterStats 获取本地过滤器统计信息
+func (s *blacklistService) GetFilterStats(ctx context.Context, tenantID uint64) (*BlacklistFilterStats, error) {
+	// 未加载的租户先同步构建，便于观察容量和内存占用
+	if s.filter.tenant(tenantID).filter.Load() == nil {
+		if err := s.filter.rebuild(ctx, tenantID); err != nil {
+			return nil, fmt.Errorf("构建黑名单过滤器失败: %w", err)
+		}
+	}
+	return s.filter.stats(tenantID), nil
+}
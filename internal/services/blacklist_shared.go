@@ -0,0 +1,229 @@
+// Package services provides business logic layer implementations.
+// This file contains the consortium shared blacklist owned by the system tenant:
+// tenant subscription settings and the contributions written into it.
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/pkg/errors"
+	"github.com/varluffy/shield/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// SharedListService 共享名单订阅服务接口，管理租户的订阅和贡献配置
+type SharedListService interface {
+	GetSharedSetting(ctx context.Context, tenantID uint64) (*SharedSetting, error)
+	SetSharedSetting(ctx context.Context, tenantID uint64, setting *SharedSetting) (*SharedSetting, error)
+	// Subscribed 租户本次查询是否需要同时查询共享名单，使用本地缓存
+	Subscribed(ctx context.Context, tenantID uint64, hashType string) bool
+}
+
+// SharedSetting 租户的共享名单配置
+type SharedSetting struct {
+	Subscribed  bool // 查询时是否同时查询共享名单
+	Contributor bool // 是否可向共享名单贡献条目
+}
+
+// sharedSettingCacheTTL 租户共享名单订阅的本地缓存有效期，其他实例的订阅变更最迟在该时间后生效
+const sharedSettingCacheTTL = 30 * time.Second
+
+// cachedSharedSubscription 租户共享名单订阅的本地缓存
+type cachedSharedSubscription struct {
+	subscribed bool
+	loadedAt   time.Time
+}
+
+// sharedListService 共享名单订阅服务实现
+type sharedListService struct {
+	settingRepo repositories.BlacklistSettingRepository
+	logger      *logger.Logger
+	cache       sync.Map // 租户共享名单订阅的本地缓存，tenantID -> *cachedSharedSubscription
+}
+
+// NewSharedListService 创建共享名单订阅服务
+func NewSharedListService(
+	settingRepo repositories.BlacklistSettingRepository,
+	logger *logger.Logger,
+) SharedListService {
+	return &sharedListService{
+		settingRepo: settingRepo,
+		logger:      logger,
+	}
+}
+
+// Subscribed 租户本次查询是否需要查询共享名单，优先使用本地缓存
+// 共享名单条目的HMAC格式使用共享名单自己的盐，无法匹配租户的HMAC查询；配置读取失败时仅查询租户名单
+func (s *sharedListService) Subscribed(ctx context.Context, tenantID uint64, hashType string) bool {
+	if tenantID == models.SystemTenantID || tenantID == models.SharedTenantID || hashType == models.HashTypeHMACSHA256 {
+		return false
+	}
+
+	if value, ok := s.cache.Load(tenantID); ok {
+		cached := value.(*cachedSharedSubscription)
+		if time.Since(cached.loadedAt) < sharedSettingCacheTTL {
+			return cached.subscribed
+		}
+	}
+
+	setting, err := s.GetSharedSetting(ctx, tenantID)
+	if err != nil {
+		s.logger.WarnWithTrace(ctx, "获取共享名单订阅失败，仅查询租户名单",
+			zap.Error(err),
+			zap.Uint64("tenant_id", tenantID))
+		return false
+	}
+	s.cache.Store(tenantID, &cachedSharedSubscription{subscribed: setting.Subscribed, loadedAt: time.Now()})
+	return setting.Subscribed
+}
+
+// GetSharedSetting 获取租户的共享名单配置，未配置时均为关闭
+func (s *sharedListService) GetSharedSetting(ctx context.Context, tenantID uint64) (*SharedSetting, error) {
+	setting, err := s.settingRepo.GetByTenant(ctx, tenantID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return &SharedSetting{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取租户配置失败: %w", err)
+	}
+	return &SharedSetting{Subscribed: setting.SharedSubscribed, Contributor: setting.SharedContributor}, nil
+}
+
+// SetSharedSetting 设置租户的共享名单订阅和贡献，返回设置前的配置
+// 退出贡献不影响已贡献的条目，系统租户维护共享名单，无需配置
+func (s *sharedListService) SetSharedSetting(ctx context.Context, tenantID uint64, setting *SharedSetting) (*SharedSetting, error) {
+	if tenantID == models.SystemTenantID {
+		return nil, errors.NewBusinessError(errors.CodeValidationError, "系统租户维护共享名单，无需订阅或贡献")
+	}
+
+	old, err := s.GetSharedSetting(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 确保配置记录存在
+	if _, err := loadHashSalt(ctx, s.settingRepo, tenantID); err != nil {
+		return nil, err
+	}
+
+	if err := s.settingRepo.UpdateShared(ctx, tenantID, setting.Subscribed, setting.Contributor); err != nil {
+		return nil, fmt.Errorf("更新租户配置失败: %w", err)
+	}
+	s.cache.Delete(tenantID)
+
+	s.logger.InfoWithTrace(ctx, "租户共享名单配置已更新",
+		zap.Uint64("tenant_id", tenantID),
+		zap.Bool("shared_subscribed", setting.Subscribed),
+		zap.Bool("shared_contributor", setting.Contributor))
+
+	return old, nil
+}
+
+// ContributeShared 租户向共享名单贡献条目，条目归属共享名单并记录贡献租户，系统租户无需加入贡献
+// 同一标识已在共享名单中时返回冲突，不返回已有条目的贡献方
+func (s *blacklistService) ContributeShared(ctx context.Context, contributorTenantID uint64, blacklist *models.PhoneBlacklist) error {
+	setting, err := s.shared.GetSharedSetting(ctx, contributorTenantID)
+	if err != nil {
+		return err
+	}
+	if contributorTenantID != models.SystemTenantID && !setting.Contributor {
+		return errors.NewBusinessError(errors.CodeForbidden, "租户未加入共享名单贡献")
+	}
+	if err := s.ensureDirectWriteAllowed(ctx, contributorTenantID, "共享名单贡献"); err != nil {
+		return err
+	}
+
+	if blacklist.IdentifierType == "" {
+		blacklist.IdentifierType = models.IdentifierTypePhone
+	}
+	exists, err := s.sharedEntryExists(ctx, blacklist)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errors.NewBusinessError(errors.CodeConflict, "标识已在共享名单中")
+	}
+
+	blacklist.TenantID = models.SharedTenantID
+	blacklist.ListID = models.DefaultListID
+	blacklist.ContributorTenantID = contributorTenantID
+	if err := s.CreateBlacklist(ctx, blacklist); err != nil {
+		return err
+	}
+
+	s.logger.InfoWithTrace(ctx, "共享名单条目贡献成功",
+		zap.Uint64("contributor_tenant_id", contributorTenantID),
+		zap.Uint64("blacklist_id", blacklist.ID),
+		zap.String("identifier_type", blacklist.IdentifierType))
+
+	return nil
+}
+
+// sharedEntryExists 共享名单中是否已有相同MD5或SHA-256的有效条目
+// 仅有MD5的已有条目同样视为存在，不为其他租户贡献的条目补充SHA-256，避免返回该条目时暴露贡献方
+func (s *blacklistService) sharedEntryExists(ctx context.Context, blacklist *models.PhoneBlacklist) (bool, error) {
+	listIDs := []uint64{models.DefaultListID}
+	if blacklist.PhoneMD5 != "" {
+		entries, err := s.blacklistRepo.GetActiveByTenantAndHashes(ctx, models.SharedTenantID, listIDs, blacklist.IdentifierType, models.HashTypeMD5, []string{blacklist.PhoneMD5})
+		if err != nil {
+			return false, fmt.Errorf("查询共享名单失败: %w", err)
+		}
+		if len(entries) > 0 {
+			return true, nil
+		}
+	}
+	if blacklist.IdentifierSHA256 != "" {
+		entries, err := s.blacklistRepo.GetActiveByTenantAndHashes(ctx, models.SharedTenantID, listIDs, blacklist.IdentifierType, models.HashTypeSHA256, []string{blacklist.IdentifierSHA256})
+		if err != nil {
+			return false, fmt.Errorf("查询共享名单失败: %w", err)
+		}
+		if len(entries) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListSharedContributions 分页获取租户贡献到共享名单的条目，系统租户获取共享名单的全部条目
+func (s *blacklistService) ListSharedContributions(ctx context.Context, contributorTenantID uint64, page, pageSize int) ([]*models.PhoneBlacklist, int64, error) {
+	offset := (page - 1) * pageSize
+	if contributorTenantID == models.SystemTenantID {
+		return s.blacklistRepo.GetByTenant(ctx, models.SharedTenantID, repositories.BlacklistOrder{Column: "created_at", Desc: true}, offset, pageSize)
+	}
+	return s.blacklistRepo.GetSharedByContributor(ctx, contributorTenantID, offset, pageSize)
+}
+
+// WithdrawSharedContribution 撤回租户贡献到共享名单的条目，只能撤回本租户贡献的条目，系统租户可删除任意条目
+func (s *blacklistService) WithdrawSharedContribution(ctx context.Context, contributorTenantID, id uint64) (*models.PhoneBlacklist, error) {
+	blacklist, err := s.blacklistRepo.GetByID(ctx, id)
+	if err == nil && (blacklist.TenantID != models.SharedTenantID ||
+		(contributorTenantID != models.SystemTenantID && blacklist.ContributorTenantID != contributorTenantID)) {
+		err = gorm.ErrRecordNotFound
+	}
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.NewBusinessError(errors.CodeNotFound, "共享名单条目不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取共享名单条目失败: %w", err)
+	}
+	if err := s.ensureDirectWriteAllowed(ctx, contributorTenantID, "撤回共享名单贡献"); err != nil {
+		return nil, err
+	}
+
+	if err := s.DeleteBlacklist(ctx, id); err != nil {
+		return nil, err
+	}
+
+	s.logger.InfoWithTrace(ctx, "共享名单条目已撤回",
+		zap.Uint64("contributor_tenant_id", contributorTenantID),
+		zap.Uint64("blacklist_id", id))
+
+	return blacklist, nil
+}
@@ -0,0 +1,212 @@
+// Package services provides business logic layer implementations.
+// This file contains the background rollup that folds Redis query counters into hourly and daily MySQL tables.
+package services
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/varluffy/shield/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// blacklistStatsRollupInterval 查询统计汇总间隔，汇总表中当前小时的数据最多落后一个间隔
+	blacklistStatsRollupInterval = 5 * time.Minute
+	// blacklistStatsRollupLockKey 每轮汇总只由一个实例执行
+	blacklistStatsRollupLockKey = "stats:rollup:lock"
+	// blacklistStatsRollupWatermarkKey 下一轮需要汇总的最早小时，之前的小时已汇总完成
+	blacklistStatsRollupWatermarkKey = "stats:rollup:watermark"
+	// blacklistStatsRollupLookback 没有汇总记录时回溯的时长，小时级统计key保留48小时
+	blacklistStatsRollupLookback = 47 * time.Hour
+	// blacklistHourlyStatsRetention 小时统计保留时长，日统计长期保留
+	blacklistHourlyStatsRetention = 90 * 24 * time.Hour
+)
+
+// statsKeysKey 记录t所在小时有查询的租户和API Key的SET
+func statsKeysKey(t time.Time) string {
+	return fmt.Sprintf("stats:keys:%s", t.Format("2006010215"))
+}
+
+// statsKeysMember 租户和API Key在statsKeysKey中的成员值
+func statsKeysMember(tenantID uint64, apiKey string) string {
+	return fmt.Sprintf("%d:%s", tenantID, apiKey)
+}
+
+// rollupStatsLoop 定期将Redis中的查询统计汇总到MySQL
+func (s *blacklistService) rollupStatsLoop() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(blacklistStatsRollupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.rollupStats(context.Background())
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// rollupStats 从上次汇总的小时到当前小时逐小时汇总，刷新涉及日期的日统计并清理过期的小时统计
+// 当前小时每轮都会重新汇总，进入下一小时后再汇总一次以补齐最后几分钟的数据
+func (s *blacklistService) rollupStats(ctx context.Context) {
+	// 标记在本轮结束后自然过期，保证多实例每个周期只汇总一次
+	acquired, err := s.redis.SetNX(ctx, blacklistStatsRollupLockKey, s.workerID, blacklistStatsRollupInterval-time.Minute).Result()
+	if err != nil {
+		s.logger.Warn("获取统计汇总标记失败", zap.Error(err))
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	current := time.Now().Truncate(time.Hour)
+	from := current.Add(-blacklistStatsRollupLookback)
+	watermark, err := s.redis.Get(ctx, blacklistStatsRollupWatermarkKey).Int64()
+	switch {
+	case err == nil:
+		if t := time.Unix(watermark, 0); t.After(from) {
+			from = t
+		}
+	case !stderrors.Is(err, redis.Nil):
+		s.logger.Warn("获取统计汇总进度失败", zap.Error(err))
+		return
+	}
+
+	rows := 0
+	next := from
+	for ; !next.After(current); next = next.Add(time.Hour) {
+		select {
+		case <-s.stopCh:
+			return
+		default:
+		}
+
+		n, err := s.rollupHour(ctx, next)
+		if err != nil {
+			s.logger.Warn("汇总小时查询统计失败", zap.Error(err), zap.Time("hour", next))
+			break
+		}
+		rows += n
+	}
+	// [from, next)内的小时已汇总，失败的小时在下一轮重试
+	if next.After(from) {
+		if err := s.statsRepo.RefreshDaily(ctx, from, next); err != nil {
+			s.logger.Warn("刷新日查询统计失败", zap.Error(err))
+			return
+		}
+	}
+	if next.After(current) {
+		// 当前小时仍在写入，下一轮从当前小时开始
+		next = current
+	}
+	if err := s.redis.Set(ctx, blacklistStatsRollupWatermarkKey, next.Unix(), 0).Err(); err != nil {
+		s.logger.Warn("保存统计汇总进度失败", zap.Error(err))
+	}
+
+	if _, err := s.statsRepo.DeleteHourlyBefore(ctx, time.Now().Add(-blacklistHourlyStatsRetention)); err != nil {
+		s.logger.Warn("清理小时查询统计失败", zap.Error(err))
+	}
+
+	s.logger.Info("查询统计汇总完成",
+		zap.Time("from", from),
+		zap.Time("next", next),
+		zap.Int("rows", rows))
+}
+
+// rollupHour 将一个小时内各租户和API Key的Redis统计写入小时统计表，返回写入的记录数
+// 分钟级统计只保留2小时，仅最近的小时能计算单分钟峰值
+func (s *blacklistService) rollupHour(ctx context.Context, hour time.Time) (int, error) {
+	members, err := s.redis.SMembers(ctx, statsKeysKey(hour)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("获取统计成员失败: %w", err)
+	}
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	withPeak := time.Since(hour) < 3*time.Hour
+	pipe := s.redis.Pipeline()
+	var stats []*models.BlacklistQueryStatHourly
+	var hourCmds []*redis.SliceCmd
+	var minuteCmds [][]*redis.StringCmd
+
+	// add 添加一条汇总记录及其需要读取的Redis key，apiKey为空时为租户汇总
+	add := func(tenantID uint64, apiKey string) {
+		hourKey := tenantHourStatsKey(tenantID, hour)
+		if apiKey != "" {
+			hourKey = apiHourStatsKey(apiKey, hour)
+		}
+		stats = append(stats, &models.BlacklistQueryStatHourly{BlacklistQueryStat: models.BlacklistQueryStat{
+			TenantID:    tenantID,
+			APIKey:      apiKey,
+			PeriodStart: hour,
+		}})
+		hourCmds = append(hourCmds, pipe.HMGet(ctx, hourKey, "total", "hits", "latency", "count"))
+
+		var cmds []*redis.StringCmd
+		if withPeak {
+			cmds = make([]*redis.StringCmd, 0, 60)
+			for minute := hour; minute.Before(hour.Add(time.Hour)); minute = minute.Add(time.Minute) {
+				minuteKey := tenantMinuteStatsKey(tenantID, minute)
+				if apiKey != "" {
+					minuteKey = apiMinuteStatsKey(apiKey, minute)
+				}
+				cmds = append(cmds, pipe.HGet(ctx, minuteKey, "total"))
+			}
+		}
+		minuteCmds = append(minuteCmds, cmds)
+	}
+
+	tenants := make(map[uint64]bool)
+	for _, member := range members {
+		tenant, apiKey, ok := strings.Cut(member, ":")
+		tenantID, err := strconv.ParseUint(tenant, 10, 64)
+		if !ok || err != nil || apiKey == "" {
+			continue
+		}
+		if !tenants[tenantID] {
+			tenants[tenantID] = true
+			add(tenantID, "")
+		}
+		add(tenantID, apiKey)
+	}
+	if len(stats) == 0 {
+		return 0, nil
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil && !stderrors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("读取查询统计失败: %w", err)
+	}
+
+	rows := make([]*models.BlacklistQueryStatHourly, 0, len(stats))
+	for i, stat := range stats {
+		stat.TotalQueries, stat.HitCount, stat.LatencySum, stat.LatencyCount = parseStatsValues(hourCmds[i].Val())
+		if stat.TotalQueries == 0 {
+			// 统计key已过期，保留已有的汇总记录
+			continue
+		}
+		if stat.APIKey == "" {
+			// 租户的小时统计没有count字段，每次查询都记录了延迟
+			stat.LatencyCount = stat.TotalQueries
+		}
+		for _, cmd := range minuteCmds[i] {
+			if n, err := cmd.Int64(); err == nil && n > stat.PeakMinuteQueries {
+				stat.PeakMinuteQueries = n
+			}
+		}
+		rows = append(rows, stat)
+	}
+
+	if err := s.statsRepo.UpsertHourly(ctx, rows); err != nil {
+		return 0, fmt.Errorf("写入小时查询统计失败: %w", err)
+	}
+	return len(rows), nil
+}
@@ -0,0 +1,918 @@
+// Package services provides business logic layer implementations.
+// This file contains blacklist webhook subscriptions and the signed, retried event delivery.
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/varluffy/shield/internal/config"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/pkg/errors"
+	"github.com/varluffy/shield/pkg/httpclient"
+	"github.com/varluffy/shield/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// Webhook投递参数默认值
+const (
+	defaultWebhookQueueSize    = 1000             // 待分发事件队列容量，写满后丢弃新事件
+	defaultWebhookMaxAttempts  = 8                // 最大投递次数，超过后进入死信
+	defaultWebhookBaseBackoff  = 30 * time.Second // 第一次重试的等待时间，之后每次翻倍
+	defaultWebhookMaxBackoff   = time.Hour        // 重试等待时间上限
+	defaultWebhookPollInterval = 5 * time.Second  // 检查到期重试的间隔
+)
+
+const (
+	// webhookRequestTimeout 单次投递的超时时间
+	webhookRequestTimeout = 10 * time.Second
+	// webhookLeaseTimeout 投递租约时长，实例退出后到期由其他实例重新投递
+	webhookLeaseTimeout = time.Minute
+	// webhookClaimBatchSize 每次领取的投递数
+	webhookClaimBatchSize = 20
+	// webhookDeliveryConcurrency 同时进行的投递数
+	webhookDeliveryConcurrency = 10
+	// webhookSubscriberCacheTTL 租户订阅缓存时长，其他实例修改订阅后最多延迟该时长生效
+	webhookSubscriberCacheTTL = 30 * time.Second
+	// webhookDeliveryRetention 已结束的投递记录保留时长
+	webhookDeliveryRetention = 30 * 24 * time.Hour
+	// webhookCleanupInterval 清理过期投递记录的间隔
+	webhookCleanupInterval = time.Hour
+)
+
+// WebhookService 黑名单Webhook服务接口
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, webhook *models.BlacklistWebhook) error
+	GetWebhook(ctx context.Context, tenantID uint64, webhookID string) (*models.BlacklistWebhook, error)
+	ListWebhooks(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.BlacklistWebhook, int64, error)
+	UpdateWebhook(ctx context.Context, tenantID uint64, webhookID string, params *WebhookUpdateParams) (*models.BlacklistWebhook, error)
+	DeleteWebhook(ctx context.Context, tenantID uint64, webhookID string) error
+	RotateWebhookSecret(ctx context.Context, tenantID uint64, webhookID string) (*models.BlacklistWebhook, error)
+	ListDeliveries(ctx context.Context, tenantID uint64, webhookID, status string, page, pageSize int) ([]*models.BlacklistWebhookDelivery, int64, error)
+	GetDelivery(ctx context.Context, tenantID, deliveryID uint64) (*models.BlacklistWebhookDelivery, []*models.BlacklistWebhookAttempt, error)
+	Redeliver(ctx context.Context, tenantID, deliveryID uint64) (*models.BlacklistWebhookDelivery, error)
+	// Publish 发布事件，不阻塞调用方，由后台按租户的订阅生成投递
+	Publish(ctx context.Context, tenantID uint64, eventType string, data interface{})
+	// Close 停止接收事件，分发队列中剩余的事件并等待进行中的投递结束，ctx到期时放弃等待
+	Close(ctx context.Context) error
+}
+
+// WebhookOptions Webhook服务参数，为0时使用默认值
+type WebhookOptions struct {
+	HTTPClient   httpclient.HTTPClient // 为nil时创建不自动重试且拒绝连接内网地址的客户端，由服务按退避策略重试
+	QueueSize    int
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+	// AllowPrivateNetwork 允许接收地址为回环、内网、链路本地等地址，仅用于测试
+	AllowPrivateNetwork bool
+}
+
+// WebhookUpdateParams 更新订阅参数，为nil的字段不修改
+type WebhookUpdateParams struct {
+	Name        *string
+	URL         *string
+	EventTypes  []string
+	Status      *string
+	Description *string
+}
+
+// WebhookEvent 推送给订阅方的事件，作为投递的请求体
+type WebhookEvent struct {
+	ID        string      `json:"id"` // 事件ID，重试时不变，接收方据此去重
+	Type      string      `json:"type"`
+	TenantID  uint64      `json:"tenant_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookHitData blacklist.hit事件内容，一次查询命中的全部标识
+type WebhookHitData struct {
+	APIKey         string           `json:"api_key"`
+	RequestID      string           `json:"request_id"`
+	IdentifierType string           `json:"identifier_type"`
+	HashType       string           `json:"hash_type"`
+	Hits           []WebhookHitItem `json:"hits"`
+}
+
+// WebhookHitItem 命中的标识
+type WebhookHitItem struct {
+	IdentifierHash string `json:"identifier_hash"`
+	Category       string `json:"category"`
+	RiskScore      int    `json:"risk_score"`
+}
+
+// webhookEntryData blacklist.entry.*事件内容
+type webhookEntryData struct {
+	UUID             string     `json:"uuid"`
+	IdentifierType   string     `json:"identifier_type"`
+	PhoneMD5         string     `json:"phone_md5"`
+	IdentifierSHA256 string     `json:"identifier_sha256"`
+	Source           string     `json:"source"`
+	Category         string     `json:"category"`
+	RiskScore        int        `json:"risk_score"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	OperatorID       uint64     `json:"operator_id"`
+}
+
+// newWebhookEntryData 从条目构建事件内容
+func newWebhookEntryData(blacklist *models.PhoneBlacklist) webhookEntryData {
+	return webhookEntryData{
+		UUID:             blacklist.UUID,
+		IdentifierType:   blacklist.IdentifierType,
+		PhoneMD5:         blacklist.PhoneMD5,
+		IdentifierSHA256: blacklist.IdentifierSHA256,
+		Source:           blacklist.Source,
+		Category:         blacklist.Category,
+		RiskScore:        blacklist.RiskScore,
+		ExpiresAt:        blacklist.ExpiresAt,
+		OperatorID:       blacklist.OperatorID,
+	}
+}
+
+// webhookBatchData blacklist.batch.*事件内容
+type webhookBatchData struct {
+	BatchID         string `json:"batch_id"`
+	Method          string `json:"method"`
+	IdentifierType  string `json:"identifier_type"`
+	Source          string `json:"source"`
+	ImportJobID     string `json:"import_job_id"`
+	EntryCount      int64  `json:"entry_count"`
+	RolledBackCount int64  `json:"rolled_back_count"`
+	OperatorID      uint64 `json:"operator_id"`
+}
+
+// newWebhookBatchData 从导入批次构建事件内容
+func newWebhookBatchData(batch *models.BlacklistImportBatch) webhookBatchData {
+	return webhookBatchData{
+		BatchID:         batch.UUID,
+		Method:          batch.Method,
+		IdentifierType:  batch.IdentifierType,
+		Source:          batch.Source,
+		ImportJobID:     batch.ImportJobUUID,
+		EntryCount:      batch.EntryCount,
+		RolledBackCount: batch.RolledBackCount,
+		OperatorID:      batch.OperatorID,
+	}
+}
+
+// SignWebhookPayload 计算投递签名，与查询接口的X-Signature相同：
+// hex(HMAC-SHA256(secret, webhookID + timestamp + nonce + body))
+func SignWebhookPayload(secret, webhookID, timestamp, nonce, body string) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(webhookID + timestamp + nonce + body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// webhookSubscribers 租户订阅缓存
+type webhookSubscribers struct {
+	webhooks  []*models.BlacklistWebhook
+	expiresAt time.Time
+}
+
+// webhookService Webhook服务实现
+type webhookService struct {
+	webhookRepo  repositories.BlacklistWebhookRepository
+	deliveryRepo repositories.BlacklistWebhookDeliveryRepository
+	httpClient   httpclient.HTTPClient
+	logger       *logger.Logger
+
+	// allowPrivateNetwork 为true时不校验接收地址是否为内网地址
+	allowPrivateNetwork bool
+
+	events       chan *WebhookEvent
+	maxAttempts  int
+	baseBackoff  time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+
+	cacheMu     sync.Mutex
+	subscribers map[uint64]*webhookSubscribers
+
+	workerID string
+	claimSeq atomic.Int64
+	wakeCh   chan struct{} // 新投递创建后唤醒投递goroutine
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+
+	dropped         atomic.Int64
+	reportedDropped int64 // 已告警的丢弃数量，仅由分发goroutine访问
+}
+
+// NewWebhookService 创建Webhook服务
+func NewWebhookService(
+	webhookRepo repositories.BlacklistWebhookRepository,
+	deliveryRepo repositories.BlacklistWebhookDeliveryRepository,
+	logger *logger.Logger,
+) WebhookService {
+	return NewWebhookServiceWithOptions(webhookRepo, deliveryRepo, logger, WebhookOptions{})
+}
+
+// NewWebhookServiceWithOptions 使用指定参数创建Webhook服务
+func NewWebhookServiceWithOptions(
+	webhookRepo repositories.BlacklistWebhookRepository,
+	deliveryRepo repositories.BlacklistWebhookDeliveryRepository,
+	logger *logger.Logger,
+	opts WebhookOptions,
+) WebhookService {
+	if opts.HTTPClient == nil {
+		// 客户端不自动重试，失败的投递按退避策略重新投递并记录每次尝试
+		var transport http.RoundTripper
+		if !opts.AllowPrivateNetwork {
+			transport = newWebhookTransport()
+		}
+		opts.HTTPClient = httpclient.NewHTTPClientWithTransport(&config.HTTPClientConfig{
+			Timeout:     int(webhookRequestTimeout / time.Second),
+			RetryCount:  0,
+			EnableTrace: true,
+			UserAgent:   "Shield-Webhook/1.0",
+		}, logger, transport)
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = defaultWebhookQueueSize
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultWebhookMaxAttempts
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = defaultWebhookBaseBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultWebhookMaxBackoff
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultWebhookPollInterval
+	}
+
+	s := &webhookService{
+		webhookRepo:         webhookRepo,
+		deliveryRepo:        deliveryRepo,
+		httpClient:          opts.HTTPClient,
+		logger:              logger,
+		allowPrivateNetwork: opts.AllowPrivateNetwork,
+		events:              make(chan *WebhookEvent, opts.QueueSize),
+		maxAttempts:         opts.MaxAttempts,
+		baseBackoff:         opts.BaseBackoff,
+		maxBackoff:          opts.MaxBackoff,
+		pollInterval:        opts.PollInterval,
+		subscribers:         make(map[uint64]*webhookSubscribers),
+		workerID:            newImportWorkerID(),
+		wakeCh:              make(chan struct{}, 1),
+		stopCh:              make(chan struct{}),
+	}
+
+	// 启动事件分发和投递的goroutine
+	s.wg.Add(2)
+	go s.dispatchLoop()
+	go s.deliverLoop()
+
+	return s
+}
+
+// CreateWebhook 创建订阅并生成签名密钥
+func (s *webhookService) CreateWebhook(ctx context.Context, webhook *models.BlacklistWebhook) error {
+	if err := s.validateWebhookURL(ctx, webhook.URL); err != nil {
+		return err
+	}
+	eventTypes, err := normalizeWebhookEventTypes(webhook.EventTypeList())
+	if err != nil {
+		return err
+	}
+
+	webhook.EventTypes = eventTypes
+	webhook.Secret = generateRandomString(64)
+	if webhook.Status == "" {
+		webhook.Status = models.WebhookStatusActive
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return fmt.Errorf("创建Webhook订阅失败: %w", err)
+	}
+	s.invalidate(webhook.TenantID)
+
+	s.logger.InfoWithTrace(ctx, "Webhook订阅创建成功",
+		zap.Uint64("tenant_id", webhook.TenantID),
+		zap.String("webhook_id", webhook.UUID),
+		zap.String("event_types", webhook.EventTypes))
+	return nil
+}
+
+// GetWebhook 获取租户的订阅
+func (s *webhookService) GetWebhook(ctx context.Context, tenantID uint64, webhookID string) (*models.BlacklistWebhook, error) {
+	webhook, err := s.webhookRepo.GetByUUID(ctx, tenantID, webhookID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.NewBusinessError(errors.CodeNotFound, "Webhook订阅不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取Webhook订阅失败: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListWebhooks 分页获取租户的订阅
+func (s *webhookService) ListWebhooks(ctx context.Context, tenantID uint64, page, pageSize int) ([]*models.BlacklistWebhook, int64, error) {
+	offset := (page - 1) * pageSize
+	return s.webhookRepo.GetByTenant(ctx, tenantID, offset, pageSize)
+}
+
+// UpdateWebhook 更新订阅，停用后不再产生新的投递，已产生的投递在下次投递时进入死信
+func (s *webhookService) UpdateWebhook(ctx context.Context, tenantID uint64, webhookID string, params *WebhookUpdateParams) (*models.BlacklistWebhook, error) {
+	webhook, err := s.GetWebhook(ctx, tenantID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Name != nil {
+		webhook.Name = *params.Name
+	}
+	if params.URL != nil {
+		if err := s.validateWebhookURL(ctx, *params.URL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *params.URL
+	}
+	if params.EventTypes != nil {
+		eventTypes, err := normalizeWebhookEventTypes(params.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+		webhook.EventTypes = eventTypes
+	}
+	if params.Status != nil {
+		webhook.Status = *params.Status
+	}
+	if params.Description != nil {
+		webhook.Description = *params.Description
+	}
+
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("更新Webhook订阅失败: %w", err)
+	}
+	s.invalidate(tenantID)
+	return webhook, nil
+}
+
+// DeleteWebhook 删除订阅，尚未完成的投递在下次投递时进入死信
+func (s *webhookService) DeleteWebhook(ctx context.Context, tenantID uint64, webhookID string) error {
+	webhook, err := s.GetWebhook(ctx, tenantID, webhookID)
+	if err != nil {
+		return err
+	}
+	if err := s.webhookRepo.Delete(ctx, webhook.ID); err != nil {
+		return fmt.Errorf("删除Webhook订阅失败: %w", err)
+	}
+	s.invalidate(tenantID)
+
+	s.logger.InfoWithTrace(ctx, "Webhook订阅删除成功",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("webhook_id", webhookID))
+	return nil
+}
+
+// RotateWebhookSecret 重新生成签名密钥，之后的投递（包括重试）使用新密钥签名
+func (s *webhookService) RotateWebhookSecret(ctx context.Context, tenantID uint64, webhookID string) (*models.BlacklistWebhook, error) {
+	webhook, err := s.GetWebhook(ctx, tenantID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = generateRandomString(64)
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return nil, fmt.Errorf("更新Webhook签名密钥失败: %w", err)
+	}
+	s.invalidate(tenantID)
+
+	s.logger.InfoWithTrace(ctx, "Webhook签名密钥已轮换",
+		zap.Uint64("tenant_id", tenantID),
+		zap.String("webhook_id", webhookID))
+	return webhook, nil
+}
+
+// ListDeliveries 分页获取投递记录，webhookID和status为空时不过滤
+func (s *webhookService) ListDeliveries(ctx context.Context, tenantID uint64, webhookID, status string, page, pageSize int) ([]*models.BlacklistWebhookDelivery, int64, error) {
+	filter := repositories.BlacklistWebhookDeliveryFilter{TenantID: tenantID, Status: status}
+	if webhookID != "" {
+		webhook, err := s.GetWebhook(ctx, tenantID, webhookID)
+		if err != nil {
+			return nil, 0, err
+		}
+		filter.WebhookID = webhook.ID
+	}
+
+	offset := (page - 1) * pageSize
+	return s.deliveryRepo.GetByFilter(ctx, filter, offset, pageSize)
+}
+
+// GetDelivery 获取投递及其全部尝试记录
+func (s *webhookService) GetDelivery(ctx context.Context, tenantID, deliveryID uint64) (*models.BlacklistWebhookDelivery, []*models.BlacklistWebhookAttempt, error) {
+	delivery, err := s.deliveryRepo.GetByID(ctx, tenantID, deliveryID)
+	if stderrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, errors.NewBusinessError(errors.CodeNotFound, "投递记录不存在")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取投递记录失败: %w", err)
+	}
+
+	attempts, err := s.deliveryRepo.GetAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取投递尝试记录失败: %w", err)
+	}
+	return delivery, attempts, nil
+}
+
+// Redeliver 重新投递已结束的投递（通常为死信），投递次数重新计算
+func (s *webhookService) Redeliver(ctx context.Context, tenantID, deliveryID uint64) (*models.BlacklistWebhookDelivery, error) {
+	delivery, _, err := s.GetDelivery(ctx, tenantID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	reset, err := s.deliveryRepo.Redeliver(ctx, tenantID, delivery.ID)
+	if err != nil {
+		return nil, fmt.Errorf("重新投递失败: %w", err)
+	}
+	if !reset {
+		return nil, errors.NewBusinessError(errors.CodeConflict, "投递正在进行中，无需重新投递")
+	}
+	s.wake()
+
+	s.logger.InfoWithTrace(ctx, "Webhook已重新投递",
+		zap.Uint64("tenant_id", tenantID),
+		zap.Uint64("delivery_id", deliveryID),
+		zap.String("previous_status", delivery.Status))
+
+	delivery, _, err = s.GetDelivery(ctx, tenantID, deliveryID)
+	return delivery, err
+}
+
+// Publish 发布事件，队列已满或服务已关闭时丢弃
+// 共享名单的归属ID不对应任何租户，共享名单条目的变更不发布事件，避免贡献方以外的租户收到其他租户贡献的内容
+func (s *webhookService) Publish(ctx context.Context, tenantID uint64, eventType string, data interface{}) {
+	if tenantID == models.SharedTenantID {
+		return
+	}
+
+	select {
+	case <-s.stopCh:
+		s.dropped.Add(1)
+		return
+	default:
+	}
+
+	event := &WebhookEvent{
+		ID:        models.GenerateUUID(),
+		Type:      eventType,
+		TenantID:  tenantID,
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+	select {
+	case s.events <- event:
+	default:
+		// 分发跟不上时丢弃，不阻塞查询和管理请求
+		s.dropped.Add(1)
+	}
+}
+
+// Close 停止后台任务
+func (s *webhookService) Close(ctx context.Context) error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dispatchLoop 为事件生成投递，关闭时分发队列中剩余的事件
+func (s *webhookService) dispatchLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-s.events:
+			s.dispatch(event)
+		case <-ticker.C:
+			s.reportDropped()
+		case <-s.stopCh:
+			for {
+				select {
+				case event := <-s.events:
+					s.dispatch(event)
+				default:
+					s.reportDropped()
+					return
+				}
+			}
+		}
+	}
+}
+
+// dispatch 为订阅了该事件类型的每个订阅创建一条投递
+func (s *webhookService) dispatch(event *WebhookEvent) {
+	ctx := context.Background()
+
+	webhooks, err := s.subscribersOf(ctx, event.TenantID)
+	if err != nil {
+		s.logger.Warn("获取Webhook订阅失败，事件已丢弃",
+			zap.Error(err),
+			zap.Uint64("tenant_id", event.TenantID),
+			zap.String("event_type", event.Type))
+		return
+	}
+
+	var payload []byte
+	var deliveries []*models.BlacklistWebhookDelivery
+	now := time.Now()
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				s.logger.Warn("序列化Webhook事件失败", zap.Error(err), zap.String("event_type", event.Type))
+				return
+			}
+		}
+		deliveries = append(deliveries, &models.BlacklistWebhookDelivery{
+			TenantID:      event.TenantID,
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryStatusPending,
+			NextAttemptAt: &now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	if err := s.deliveryRepo.BatchCreate(ctx, deliveries); err != nil {
+		s.logger.Warn("创建Webhook投递失败，事件已丢弃",
+			zap.Error(err),
+			zap.Uint64("tenant_id", event.TenantID),
+			zap.String("event_id", event.ID),
+			zap.String("event_type", event.Type))
+		return
+	}
+	s.wake()
+}
+
+// subscribersOf 获取租户启用的订阅，缓存一段时间避免每个事件查询数据库
+func (s *webhookService) subscribersOf(ctx context.Context, tenantID uint64) ([]*models.BlacklistWebhook, error) {
+	s.cacheMu.Lock()
+	cached, ok := s.subscribers[tenantID]
+	s.cacheMu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.webhooks, nil
+	}
+
+	webhooks, err := s.webhookRepo.GetActiveByTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheMu.Lock()
+	s.subscribers[tenantID] = &webhookSubscribers{
+		webhooks:  webhooks,
+		expiresAt: time.Now().Add(webhookSubscriberCacheTTL),
+	}
+	s.cacheMu.Unlock()
+	return webhooks, nil
+}
+
+// invalidate 订阅变更后清除本实例的缓存
+func (s *webhookService) invalidate(tenantID uint64) {
+	s.cacheMu.Lock()
+	delete(s.subscribers, tenantID)
+	s.cacheMu.Unlock()
+}
+
+// reportDropped 告警自上次告警以来丢弃的事件数量
+func (s *webhookService) reportDropped() {
+	dropped := s.dropped.Load()
+	if dropped > s.reportedDropped {
+		s.logger.Warn("Webhook事件队列已满，部分事件已丢弃",
+			zap.Int64("dropped", dropped-s.reportedDropped),
+			zap.Int("queue_size", cap(s.events)))
+		s.reportedDropped = dropped
+	}
+}
+
+// wake 唤醒投递goroutine立即投递
+func (s *webhookService) wake() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// deliverLoop 投递到期的记录，定期清理已结束的投递记录
+func (s *webhookService) deliverLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(webhookCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.deliverDue()
+		case <-s.wakeCh:
+			s.deliverDue()
+		case <-cleanup.C:
+			if _, err := s.deliveryRepo.DeleteFinishedBefore(context.Background(), time.Now().Add(-webhookDeliveryRetention)); err != nil {
+				s.logger.Warn("清理Webhook投递记录失败", zap.Error(err))
+			}
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+// deliverDue 分批领取并并发投递到期的记录，直到没有到期记录或服务关闭
+func (s *webhookService) deliverDue() {
+	ctx := context.Background()
+	for {
+		claimID := fmt.Sprintf("%s-%d", s.workerID, s.claimSeq.Add(1))
+		deliveries, err := s.deliveryRepo.ClaimDue(ctx, claimID, time.Now().Add(webhookLeaseTimeout), webhookClaimBatchSize)
+		if err != nil {
+			s.logger.Warn("领取Webhook投递失败", zap.Error(err))
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+
+		sem := make(chan struct{}, webhookDeliveryConcurrency)
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(delivery *models.BlacklistWebhookDelivery) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				s.deliver(ctx, delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(deliveries) < webhookClaimBatchSize {
+			return
+		}
+		select {
+		case <-s.stopCh:
+			return
+		default:
+		}
+	}
+}
+
+// deliver 投递一次并记录结果：成功、按退避时间等待重试，或超过最大次数进入死信
+func (s *webhookService) deliver(ctx context.Context, delivery *models.BlacklistWebhookDelivery) {
+	webhook, err := s.webhookRepo.GetByID(ctx, delivery.WebhookID)
+	if err != nil && !stderrors.Is(err, gorm.ErrRecordNotFound) {
+		// 租约到期后重新投递
+		s.logger.Warn("获取Webhook订阅失败", zap.Error(err), zap.Uint64("delivery_id", delivery.ID))
+		return
+	}
+
+	start := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &start
+	attempt := &models.BlacklistWebhookAttempt{
+		DeliveryID: delivery.ID,
+		Attempt:    delivery.Attempts,
+	}
+
+	var sendErr error
+	if webhook == nil || webhook.Status != models.WebhookStatusActive {
+		// 订阅已删除或已停用，不再重试
+		sendErr = fmt.Errorf("Webhook订阅已删除或已停用")
+		delivery.Attempts = s.maxAttempts
+	} else {
+		attempt.StatusCode, sendErr = s.send(ctx, webhook, delivery)
+	}
+	attempt.DurationMs = time.Since(start).Milliseconds()
+
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = ""
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.WebhookDeliveryStatusSucceeded
+		delivery.DeliveredAt = &start
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= s.maxAttempts:
+		attempt.Error = truncateString(sendErr.Error(), 500)
+		delivery.LastError = attempt.Error
+		delivery.Status = models.WebhookDeliveryStatusDead
+		delivery.NextAttemptAt = nil
+	default:
+		attempt.Error = truncateString(sendErr.Error(), 500)
+		delivery.LastError = attempt.Error
+		next := time.Now().Add(s.backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := s.deliveryRepo.RecordAttempt(ctx, delivery, attempt); err != nil {
+		s.logger.Warn("记录Webhook投递结果失败",
+			zap.Error(err),
+			zap.Uint64("delivery_id", delivery.ID))
+		return
+	}
+
+	if delivery.Status == models.WebhookDeliveryStatusDead {
+		s.logger.Warn("Webhook投递失败，已进入死信",
+			zap.Uint64("tenant_id", delivery.TenantID),
+			zap.Uint64("delivery_id", delivery.ID),
+			zap.String("event_type", delivery.EventType),
+			zap.Int("attempts", attempt.Attempt),
+			zap.String("error", delivery.LastError))
+	}
+}
+
+// send 签名并发送事件，返回响应状态码，非2xx响应视为失败
+func (s *webhookService) send(ctx context.Context, webhook *models.BlacklistWebhook, delivery *models.BlacklistWebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := models.GenerateUUID()
+	headers := map[string]string{
+		"X-Webhook-ID":       webhook.UUID,
+		"X-Event-ID":         delivery.EventID,
+		"X-Event-Type":       delivery.EventType,
+		"X-Delivery-Attempt": strconv.Itoa(delivery.Attempts),
+		"X-Timestamp":        timestamp,
+		"X-Nonce":            nonce,
+		"X-Signature":        SignWebhookPayload(webhook.Secret, webhook.UUID, timestamp, nonce, delivery.Payload),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookRequestTimeout)
+	defer cancel()
+
+	resp, err := s.httpClient.Request(ctx, http.MethodPost, webhook.URL, []byte(delivery.Payload), headers)
+	if err != nil {
+		return 0, err
+	}
+	if !resp.IsSuccess {
+		return resp.StatusCode, fmt.Errorf("接收方返回状态码%d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff 第n次投递失败后的等待时间：基础等待时间每次翻倍，不超过上限，附加最多10%的随机抖动
+func (s *webhookService) backoff(attempts int) time.Duration {
+	delay := s.baseBackoff
+	for i := 1; i < attempts && delay < s.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > s.maxBackoff {
+		delay = s.maxBackoff
+	}
+	if jitter := int64(delay / 10); jitter > 0 {
+		delay += time.Duration(rand.Int63n(jitter))
+	}
+	return delay
+}
+
+// validateWebhookURL 接收地址必须为http或https绝对地址，且不能指向回环、内网、链路本地等地址
+func (s *webhookService) validateWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.ErrValidationFailed("接收地址必须为http或https地址")
+	}
+	if s.allowPrivateNetwork {
+		return nil
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if isBlockedWebhookIP(ip) {
+			return errors.ErrValidationFailed("接收地址不能为内网地址")
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return errors.ErrValidationFailed(fmt.Sprintf("无法解析接收地址: %s", host))
+	}
+	for _, addr := range addrs {
+		if isBlockedWebhookIP(addr.IP) {
+			return errors.ErrValidationFailed("接收地址不能为内网地址")
+		}
+	}
+	return nil
+}
+
+// blockedWebhookNetworks 不在net.IP判断方法覆盖范围内、但同样不允许投递的网段
+var blockedWebhookNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // 本网络
+	"100.64.0.0/10", // 运营商级NAT，部分云厂商的元数据服务（如100.100.100.200）位于该网段
+	"192.0.0.0/24",  // IETF协议分配
+	"198.18.0.0/15", // 基准测试
+	"240.0.0.0/4",   // 保留地址
+	"64:ff9b::/96",  // NAT64，可映射到任意IPv4地址
+)
+
+// isBlockedWebhookIP 回环、内网、链路本地（含169.254.169.254元数据地址）、组播、未指定及保留地址不允许投递
+func isBlockedWebhookIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// newWebhookTransport 创建投递用的Transport，在建立连接前检查解析得到的IP，
+// 防止接收地址在创建后通过DNS重绑定指向内网；不使用环境变量中的代理，避免代理绕过检查
+func newWebhookTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   webhookRequestTimeout,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || isBlockedWebhookIP(ip) {
+				return fmt.Errorf("拒绝连接内网地址: %s", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// mustParseCIDRs 解析网段列表，格式错误时panic
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// normalizeWebhookEventTypes 校验事件类型并去重，返回逗号分隔的字符串
+func normalizeWebhookEventTypes(eventTypes []string) (string, error) {
+	seen := make(map[string]bool, len(eventTypes))
+	normalized := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if !models.IsValidWebhookEventType(eventType) {
+			return "", errors.ErrValidationFailed(fmt.Sprintf("不支持的事件类型: %s", eventType))
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			normalized = append(normalized, eventType)
+		}
+	}
+	if len(normalized) == 0 {
+		return "", errors.ErrValidationFailed("至少需要订阅一种事件类型")
+	}
+	return strings.Join(normalized, ","), nil
+}
@@ -30,6 +30,22 @@ type PermissionAuditService interface {
 	LogUserRoleAssign(ctx context.Context, req LogUserRoleRequest) error
 	// LogUserRoleRevoke 记录用户角色撤销操作
 	LogUserRoleRevoke(ctx context.Context, req LogUserRoleRequest) error
+	// LogBlacklistExport 记录黑名单导出操作
+	LogBlacklistExport(ctx context.Context, req LogBlacklistExportRequest) error
+	// LogBlacklistChange 记录黑名单变更申请的提交和审批操作
+	LogBlacklistChange(ctx context.Context, req LogBlacklistChangeRequest) error
+	// LogBlacklistApprovalSetting 记录租户黑名单审批配置的变更
+	LogBlacklistApprovalSetting(ctx context.Context, req LogBlacklistApprovalSettingRequest) error
+	// LogBlacklistAllowlist 记录黑名单白名单条目的创建、更新和删除
+	LogBlacklistAllowlist(ctx context.Context, req LogBlacklistAllowlistRequest) error
+	// LogBlacklistList 记录黑名单名单的创建、更新和删除
+	LogBlacklistList(ctx context.Context, req LogBlacklistListRequest) error
+	// LogApiCredentialLists 记录API密钥名单授权的变更
+	LogApiCredentialLists(ctx context.Context, req LogApiCredentialListsRequest) error
+	// LogBlacklistSharedSetting 记录租户共享名单订阅和贡献配置的变更
+	LogBlacklistSharedSetting(ctx context.Context, req LogBlacklistSharedSettingRequest) error
+	// LogBlacklistSharedContribution 记录租户向共享名单贡献和撤回条目
+	LogBlacklistSharedContribution(ctx context.Context, req LogBlacklistSharedContributionRequest) error
 	// GetAuditLogs 获取审计日志
 	GetAuditLogs(ctx context.Context, filter repositories.AuditLogFilter) ([]models.PermissionAuditLog, int64, error)
 }
@@ -89,6 +105,94 @@ type AuditLogFilter struct {
 	Limit      int     `json:"limit"`
 }
 
+// LogBlacklistExportRequest 黑名单导出日志请求
+type LogBlacklistExportRequest struct {
+	TenantID   uint64                 `json:"tenant_id"`
+	OperatorID uint64                 `json:"operator_id"`
+	Format     string                 `json:"format"`
+	Filters    map[string]interface{} `json:"filters"`
+	Exported   int64                  `json:"exported"`
+	Completed  bool                   `json:"completed"`
+	IPAddress  string                 `json:"ip_address"`
+	UserAgent  string                 `json:"user_agent"`
+}
+
+// LogBlacklistChangeRequest 黑名单变更申请日志请求
+type LogBlacklistChangeRequest struct {
+	TenantID      uint64                         `json:"tenant_id"`
+	OperatorID    uint64                         `json:"operator_id"`
+	Action        string                         `json:"action"` // submit, approve, reject
+	ChangeRequest *models.BlacklistChangeRequest `json:"change_request"`
+	Reason        string                         `json:"reason"`
+	IPAddress     string                         `json:"ip_address"`
+	UserAgent     string                         `json:"user_agent"`
+}
+
+// LogBlacklistApprovalSettingRequest 租户黑名单审批配置日志请求
+type LogBlacklistApprovalSettingRequest struct {
+	TenantID   uint64 `json:"tenant_id"`
+	OperatorID uint64 `json:"operator_id"`
+	OldValue   bool   `json:"old_value"`
+	NewValue   bool   `json:"new_value"`
+	IPAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+}
+
+// LogBlacklistAllowlistRequest 黑名单白名单条目操作日志请求，创建时OldEntry为空，删除时NewEntry为空
+type LogBlacklistAllowlistRequest struct {
+	TenantID   uint64                          `json:"tenant_id"`
+	OperatorID uint64                          `json:"operator_id"`
+	Action     string                          `json:"action"` // create, update, delete
+	OldEntry   *models.BlacklistAllowlistEntry `json:"old_entry"`
+	NewEntry   *models.BlacklistAllowlistEntry `json:"new_entry"`
+	IPAddress  string                          `json:"ip_address"`
+	UserAgent  string                          `json:"user_agent"`
+}
+
+// LogBlacklistListRequest 黑名单名单操作日志请求，创建时OldList为空，删除时NewList为空
+type LogBlacklistListRequest struct {
+	TenantID   uint64                `json:"tenant_id"`
+	OperatorID uint64                `json:"operator_id"`
+	Action     string                `json:"action"` // create, update, delete
+	OldList    *models.BlacklistList `json:"old_list"`
+	NewList    *models.BlacklistList `json:"new_list"`
+	IPAddress  string                `json:"ip_address"`
+	UserAgent  string                `json:"user_agent"`
+}
+
+// LogApiCredentialListsRequest API密钥名单授权变更日志请求
+type LogApiCredentialListsRequest struct {
+	TenantID     uint64   `json:"tenant_id"`
+	OperatorID   uint64   `json:"operator_id"`
+	CredentialID uint64   `json:"credential_id"`
+	OldLists     []string `json:"old_lists"`
+	NewLists     []string `json:"new_lists"`
+	IPAddress    string   `json:"ip_address"`
+	UserAgent    string   `json:"user_agent"`
+}
+
+// LogBlacklistSharedSettingRequest 租户共享名单配置日志请求
+type LogBlacklistSharedSettingRequest struct {
+	TenantID       uint64 `json:"tenant_id"`
+	OperatorID     uint64 `json:"operator_id"`
+	OldSubscribed  bool   `json:"old_subscribed"`
+	OldContributor bool   `json:"old_contributor"`
+	NewSubscribed  bool   `json:"new_subscribed"`
+	NewContributor bool   `json:"new_contributor"`
+	IPAddress      string `json:"ip_address"`
+	UserAgent      string `json:"user_agent"`
+}
+
+// LogBlacklistSharedContributionRequest 共享名单条目贡献日志请求，TenantID为贡献租户
+type LogBlacklistSharedContributionRequest struct {
+	TenantID   uint64                 `json:"tenant_id"`
+	OperatorID uint64                 `json:"operator_id"`
+	Action     string                 `json:"action"` // create, delete
+	Entry      *models.PhoneBlacklist `json:"entry"`
+	IPAddress  string                 `json:"ip_address"`
+	UserAgent  string                 `json:"user_agent"`
+}
+
 // permissionAuditService 权限审计服务实现
 type permissionAuditService struct {
 	auditRepo repositories.PermissionAuditRepository
@@ -178,6 +282,183 @@ func (s *permissionAuditService) LogUserRoleRevoke(ctx context.Context, req LogU
 	return s.logUserRoleOperation(ctx, req, models.AuditActionRevoke)
 }
 
+// LogBlacklistExport 记录黑名单导出操作，导出格式、过滤条件和导出条数记录在NewValue中
+func (s *permissionAuditService) LogBlacklistExport(ctx context.Context, req LogBlacklistExportRequest) error {
+	exportData := map[string]interface{}{
+		"format":    req.Format,
+		"filters":   req.Filters,
+		"exported":  req.Exported,
+		"completed": req.Completed,
+	}
+
+	exportDataJSON, _ := json.Marshal(exportData)
+
+	auditLog := &models.PermissionAuditLog{
+		TenantID:   req.TenantID,
+		OperatorID: req.OperatorID,
+		TargetType: models.AuditTargetBlacklist,
+		TargetID:   req.TenantID,
+		Action:     models.AuditActionExport,
+		NewValue:   string(exportDataJSON),
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}
+
+	return s.createAuditLog(ctx, auditLog)
+}
+
+// LogBlacklistChange 记录黑名单变更申请操作，申请的当前状态和内容记录在NewValue中
+func (s *permissionAuditService) LogBlacklistChange(ctx context.Context, req LogBlacklistChangeRequest) error {
+	changeDataJSON, _ := json.Marshal(req.ChangeRequest)
+
+	auditLog := &models.PermissionAuditLog{
+		TenantID:   req.TenantID,
+		OperatorID: req.OperatorID,
+		TargetType: models.AuditTargetBlacklistChange,
+		TargetID:   req.ChangeRequest.ID,
+		Action:     req.Action,
+		NewValue:   string(changeDataJSON),
+		Reason:     req.Reason,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}
+
+	return s.createAuditLog(ctx, auditLog)
+}
+
+// LogBlacklistApprovalSetting 记录租户黑名单审批配置的变更
+func (s *permissionAuditService) LogBlacklistApprovalSetting(ctx context.Context, req LogBlacklistApprovalSettingRequest) error {
+	oldValueJSON, _ := json.Marshal(map[string]bool{"require_approval": req.OldValue})
+	newValueJSON, _ := json.Marshal(map[string]bool{"require_approval": req.NewValue})
+
+	auditLog := &models.PermissionAuditLog{
+		TenantID:   req.TenantID,
+		OperatorID: req.OperatorID,
+		TargetType: models.AuditTargetBlacklist,
+		TargetID:   req.TenantID,
+		Action:     models.AuditActionUpdate,
+		OldValue:   string(oldValueJSON),
+		NewValue:   string(newValueJSON),
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}
+
+	return s.createAuditLog(ctx, auditLog)
+}
+
+// LogBlacklistAllowlist 记录黑名单白名单条目的创建、更新和删除，条目变更前后的内容记录在OldValue和NewValue中
+func (s *permissionAuditService) LogBlacklistAllowlist(ctx context.Context, req LogBlacklistAllowlistRequest) error {
+	auditLog := &models.PermissionAuditLog{
+		TenantID:   req.TenantID,
+		OperatorID: req.OperatorID,
+		TargetType: models.AuditTargetBlacklistAllowlist,
+		Action:     req.Action,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}
+	if req.OldEntry != nil {
+		oldValueJSON, _ := json.Marshal(req.OldEntry)
+		auditLog.TargetID = req.OldEntry.ID
+		auditLog.OldValue = string(oldValueJSON)
+		auditLog.Reason = req.OldEntry.Reason
+	}
+	if req.NewEntry != nil {
+		newValueJSON, _ := json.Marshal(req.NewEntry)
+		auditLog.TargetID = req.NewEntry.ID
+		auditLog.NewValue = string(newValueJSON)
+		auditLog.Reason = req.NewEntry.Reason
+	}
+
+	return s.createAuditLog(ctx, auditLog)
+}
+
+// LogBlacklistList 记录黑名单名单的创建、更新和删除，名单变更前后的内容记录在OldValue和NewValue中
+func (s *permissionAuditService) LogBlacklistList(ctx context.Context, req LogBlacklistListRequest) error {
+	auditLog := &models.PermissionAuditLog{
+		TenantID:   req.TenantID,
+		OperatorID: req.OperatorID,
+		TargetType: models.AuditTargetBlacklistList,
+		Action:     req.Action,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}
+	if req.OldList != nil {
+		oldValueJSON, _ := json.Marshal(req.OldList)
+		auditLog.TargetID = req.OldList.ID
+		auditLog.OldValue = string(oldValueJSON)
+	}
+	if req.NewList != nil {
+		newValueJSON, _ := json.Marshal(req.NewList)
+		auditLog.TargetID = req.NewList.ID
+		auditLog.NewValue = string(newValueJSON)
+	}
+
+	return s.createAuditLog(ctx, auditLog)
+}
+
+// LogApiCredentialLists 记录API密钥名单授权的变更
+func (s *permissionAuditService) LogApiCredentialLists(ctx context.Context, req LogApiCredentialListsRequest) error {
+	oldValueJSON, _ := json.Marshal(map[string][]string{"lists": req.OldLists})
+	newValueJSON, _ := json.Marshal(map[string][]string{"lists": req.NewLists})
+
+	auditLog := &models.PermissionAuditLog{
+		TenantID:   req.TenantID,
+		OperatorID: req.OperatorID,
+		TargetType: models.AuditTargetApiCredential,
+		TargetID:   req.CredentialID,
+		Action:     models.AuditActionUpdate,
+		OldValue:   string(oldValueJSON),
+		NewValue:   string(newValueJSON),
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}
+
+	return s.createAuditLog(ctx, auditLog)
+}
+
+// LogBlacklistSharedSetting 记录租户共享名单订阅和贡献配置的变更
+func (s *permissionAuditService) LogBlacklistSharedSetting(ctx context.Context, req LogBlacklistSharedSettingRequest) error {
+	oldValueJSON, _ := json.Marshal(map[string]bool{"shared_subscribed": req.OldSubscribed, "shared_contributor": req.OldContributor})
+	newValueJSON, _ := json.Marshal(map[string]bool{"shared_subscribed": req.NewSubscribed, "shared_contributor": req.NewContributor})
+
+	auditLog := &models.PermissionAuditLog{
+		TenantID:   req.TenantID,
+		OperatorID: req.OperatorID,
+		TargetType: models.AuditTargetBlacklist,
+		TargetID:   req.TenantID,
+		Action:     models.AuditActionUpdate,
+		OldValue:   string(oldValueJSON),
+		NewValue:   string(newValueJSON),
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}
+
+	return s.createAuditLog(ctx, auditLog)
+}
+
+// LogBlacklistSharedContribution 记录租户向共享名单贡献和撤回条目，贡献时条目记录在NewValue中，撤回时记录在OldValue中
+func (s *permissionAuditService) LogBlacklistSharedContribution(ctx context.Context, req LogBlacklistSharedContributionRequest) error {
+	entryJSON, _ := json.Marshal(req.Entry)
+
+	auditLog := &models.PermissionAuditLog{
+		TenantID:   req.TenantID,
+		OperatorID: req.OperatorID,
+		TargetType: models.AuditTargetBlacklistShared,
+		TargetID:   req.Entry.ID,
+		Action:     req.Action,
+		Reason:     req.Entry.Reason,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+	}
+	if req.Action == models.AuditActionDelete {
+		auditLog.OldValue = string(entryJSON)
+	} else {
+		auditLog.NewValue = string(entryJSON)
+	}
+
+	return s.createAuditLog(ctx, auditLog)
+}
+
 // GetAuditLogs 获取审计日志
 func (s *permissionAuditService) GetAuditLogs(ctx context.Context, filter repositories.AuditLogFilter) ([]models.PermissionAuditLog, int64, error) {
 	s.logger.DebugWithTrace(ctx, "Getting audit logs",
@@ -17,12 +17,12 @@ func NewPermissionCacheServiceWithFallback(
 	// 如果Redis配置存在且可用，使用Redis缓存
 	if cfg.Redis != nil && len(cfg.Redis.Addrs) > 0 && redisClient != nil {
 		logger.Info("Using Redis permission cache")
-		return NewPermissionCacheService(redisClient, logger)
+		return withCacheMetrics(NewPermissionCacheService(redisClient, logger), permissionCacheBackendRedis)
 	}
 
 	// 否则使用内存缓存
 	logger.Info("Using memory permission cache (Redis not available or not configured)")
-	return NewMemoryPermissionCacheService(logger)
+	return withCacheMetrics(NewMemoryPermissionCacheService(logger), permissionCacheBackendMemory)
 }
 
 // NewPermissionCacheServiceForce 强制创建指定类型的权限缓存服务
@@ -35,15 +35,15 @@ func NewPermissionCacheServiceForce(
 	case "redis":
 		if redisClient == nil {
 			logger.Warn("Redis client is nil, falling back to memory cache")
-			return NewMemoryPermissionCacheService(logger)
+			return withCacheMetrics(NewMemoryPermissionCacheService(logger), permissionCacheBackendMemory)
 		}
 		logger.Info("Using Redis permission cache (forced)")
-		return NewPermissionCacheService(redisClient, logger)
+		return withCacheMetrics(NewPermissionCacheService(redisClient, logger), permissionCacheBackendRedis)
 	case "memory":
 		logger.Info("Using memory permission cache (forced)")
-		return NewMemoryPermissionCacheService(logger)
+		return withCacheMetrics(NewMemoryPermissionCacheService(logger), permissionCacheBackendMemory)
 	default:
 		logger.Warn("Unknown cache type, using memory cache")
-		return NewMemoryPermissionCacheService(logger)
+		return withCacheMetrics(NewMemoryPermissionCacheService(logger), permissionCacheBackendMemory)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,43 @@
+// Package services contains business logic and cache implementations.
+package services
+
+import (
+	"context"
+
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/pkg/metrics"
+)
+
+// 权限缓存后端，用于指标标签
+const (
+	permissionCacheBackendRedis  = "redis"
+	permissionCacheBackendMemory = "memory"
+)
+
+// metricsPermissionCacheService 记录权限缓存命中指标的装饰器
+type metricsPermissionCacheService struct {
+	PermissionCacheService
+	backend string
+}
+
+// withCacheMetrics 为权限缓存服务添加命中指标
+func withCacheMetrics(cache PermissionCacheService, backend string) PermissionCacheService {
+	return &metricsPermissionCacheService{
+		PermissionCacheService: cache,
+		backend:                backend,
+	}
+}
+
+// GetUserPermissions 从缓存获取用户权限，返回nil表示未命中
+func (s *metricsPermissionCacheService) GetUserPermissions(ctx context.Context, userID, tenantID string) ([]models.Permission, error) {
+	permissions, err := s.PermissionCacheService.GetUserPermissions(ctx, userID, tenantID)
+	metrics.ObservePermissionCache("permissions", s.backend, permissions != nil)
+	return permissions, err
+}
+
+// GetUserRoles 从缓存获取用户角色，返回nil表示未命中
+func (s *metricsPermissionCacheService) GetUserRoles(ctx context.Context, userID, tenantID string) ([]models.Role, error) {
+	roles, err := s.PermissionCacheService.GetUserRoles(ctx, userID, tenantID)
+	metrics.ObservePermissionCache("roles", s.backend, roles != nil)
+	return roles, err
+}
@@ -28,7 +28,13 @@ var ProviderSet = wire.NewSet(
 	// Blacklist相关Service
 	NewBlacklistService,
 	NewBlacklistAuthService,
+	NewQueryLogWriter,
 	NewApiCredentialService,
+	NewWebhookService,
+	NewListService,
+	NewSharedListService,
+	NewAllowlistService,
+	NewApprovalService,
 
 	// 这里可以添加其他Service
 	// NewProductService,
@@ -8,6 +8,7 @@ package wire
 import (
 	"github.com/google/wire"
 	"github.com/varluffy/shield/internal/config"
+	"github.com/varluffy/shield/internal/grpcserver"
 	"github.com/varluffy/shield/internal/handlers"
 	"github.com/varluffy/shield/internal/infrastructure"
 	"github.com/varluffy/shield/internal/middleware"
@@ -37,6 +38,9 @@ var ProviderSet = wire.NewSet(
 	// 中间件层 - 认证、日志等中间件
 	middleware.ProviderSet,
 
+	// gRPC层 - 黑名单查询gRPC服务
+	grpcserver.ProviderSet,
+
 	// 认证层 - JWT服务等认证相关组件
 	auth.AuthProviderSet,
 
@@ -73,10 +77,15 @@ type App struct {
 	FieldPermissionHandler  *handlers.FieldPermissionHandler
 	BlacklistHandler        *handlers.BlacklistHandler
 	ApiCredentialHandler    *handlers.ApiCredentialHandler
+	WebhookHandler          *handlers.BlacklistWebhookHandler
 	AuthMiddleware          *middleware.AuthMiddleware
 	PermissionMiddleware    *middleware.PermissionMiddleware
 	BlacklistAuthMiddleware *middleware.BlacklistAuthMiddleware
 	BlacklistLogMiddleware  *middleware.BlacklistLogMiddleware
+	QueryLogWriter          services.QueryLogWriter
+	BlacklistService        services.BlacklistService
+	WebhookService          services.WebhookService
+	GRPCServer              *grpcserver.Server
 }
 
 // NewApp 创建应用实例
@@ -91,10 +100,15 @@ func NewApp(
 	fieldPermissionHandler *handlers.FieldPermissionHandler,
 	blacklistHandler *handlers.BlacklistHandler,
 	apiCredentialHandler *handlers.ApiCredentialHandler,
+	webhookHandler *handlers.BlacklistWebhookHandler,
 	authMiddleware *middleware.AuthMiddleware,
 	permissionMiddleware *middleware.PermissionMiddleware,
 	blacklistAuthMiddleware *middleware.BlacklistAuthMiddleware,
 	blacklistLogMiddleware *middleware.BlacklistLogMiddleware,
+	queryLogWriter services.QueryLogWriter,
+	blacklistService services.BlacklistService,
+	webhookService services.WebhookService,
+	grpcServer *grpcserver.Server,
 ) *App {
 	return &App{
 		Config:                  cfg,
@@ -107,9 +121,14 @@ func NewApp(
 		FieldPermissionHandler:  fieldPermissionHandler,
 		BlacklistHandler:        blacklistHandler,
 		ApiCredentialHandler:    apiCredentialHandler,
+		WebhookHandler:          webhookHandler,
 		AuthMiddleware:          authMiddleware,
 		PermissionMiddleware:    permissionMiddleware,
 		BlacklistAuthMiddleware: blacklistAuthMiddleware,
 		BlacklistLogMiddleware:  blacklistLogMiddleware,
+		QueryLogWriter:          queryLogWriter,
+		BlacklistService:        blacklistService,
+		WebhookService:          webhookService,
+		GRPCServer:              grpcServer,
 	}
 }
@@ -0,0 +1,380 @@
+// Package blacklistclient provides the Go client SDK for the HMAC-signed blacklist query API.
+// It signs every request, retries rate-limited requests with fresh nonces and splits large batch checks.
+package blacklistclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	mathrand "math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/varluffy/shield/internal/config"
+	"github.com/varluffy/shield/pkg/httpclient"
+	"github.com/varluffy/shield/pkg/logger"
+)
+
+// 查询接口路径
+const (
+	checkPath      = "/api/v1/blacklist/check"
+	checkBatchPath = "/api/v1/blacklist/check-batch"
+	rangePath      = "/api/v1/blacklist/range"
+)
+
+// MaxBatchSize 服务端单次批量查询的哈希上限，CheckBatch按此大小自动拆分
+const MaxBatchSize = 100
+
+// 标识类型
+const (
+	IdentifierTypePhone    = "phone"
+	IdentifierTypeIDCard   = "id_card"
+	IdentifierTypeDeviceID = "device_id"
+	IdentifierTypeEmail    = "email"
+	IdentifierTypeIP       = "ip"
+	IdentifierTypeBankCard = "bank_card"
+)
+
+// 哈希格式
+const (
+	HashTypeMD5        = "md5"
+	HashTypeSHA256     = "sha256"
+	HashTypeHMACSHA256 = "hmac_sha256"
+)
+
+// 客户端参数默认值
+const (
+	defaultTimeout          = 10 * time.Second
+	defaultMaxRetries       = 3
+	defaultRetryWaitTime    = 200 * time.Millisecond
+	defaultRetryMaxWaitTime = 5 * time.Second
+	defaultUserAgent        = "Shield-Blacklist-Go-SDK/1.0"
+)
+
+// Config 客户端配置
+type Config struct {
+	BaseURL   string // 服务地址，如https://shield.example.com
+	APIKey    string
+	APISecret string
+
+	Timeout          time.Duration // 单次请求超时，默认10秒
+	MaxRetries       int           // 频率超限时的最大重试次数，默认3，小于0时不重试
+	RetryWaitTime    time.Duration // 首次重试等待时间，之后按2倍递增，默认200毫秒
+	RetryMaxWaitTime time.Duration // 单次重试最大等待时间，默认5秒
+	UserAgent        string
+
+	// Logger 不为空时记录请求和响应日志，日志中包含签名请求头
+	Logger *logger.Logger
+}
+
+// Client 黑名单查询客户端接口
+type Client interface {
+	// Check 查询单个标识哈希
+	Check(ctx context.Context, req *CheckRequest) (*CheckResult, error)
+
+	// CheckBatch 批量查询，超过MaxBatchSize时按顺序拆分为多次请求，结果顺序与请求一致
+	CheckBatch(ctx context.Context, req *CheckBatchRequest) ([]CheckResult, error)
+
+	// Range 按哈希前缀查询，只发送前缀，返回相同前缀的命中条目后缀，需API密钥开启前缀查询
+	Range(ctx context.Context, req *RangeRequest) (*RangeResult, error)
+}
+
+// CheckRequest 单个标识查询请求
+type CheckRequest struct {
+	IdentifierType string   // 为空时默认phone
+	HashType       string   // 为空时默认md5
+	IdentifierHash string   // 十六进制哈希
+	Lists          []string // 查询的名单，为空时查询API密钥已授权的全部名单
+}
+
+// CheckBatchRequest 批量查询请求
+type CheckBatchRequest struct {
+	IdentifierType   string // 为空时默认phone
+	HashType         string // 为空时默认md5
+	IdentifierHashes []string
+	Lists            []string // 查询的名单，为空时查询API密钥已授权的全部名单
+}
+
+// CheckResult 单个标识的查询结果
+type CheckResult struct {
+	IsBlacklist    bool     `json:"is_blacklist"`
+	IdentifierType string   `json:"identifier_type"`
+	HashType       string   `json:"hash_type"`
+	IdentifierHash string   `json:"identifier_hash"`
+	Category       string   `json:"category,omitempty"`   // 风险分类，仅命中时返回
+	RiskScore      int      `json:"risk_score,omitempty"` // 风险分，仅命中时返回
+	Overridden     bool     `json:"overridden,omitempty"` // 命中黑名单但在租户白名单中，IsBlacklist为false
+	HitLists       []string `json:"hit_lists,omitempty"`  // 命中的名单，仅命中时返回
+	HitSource      string   `json:"hit_source,omitempty"` // 命中来源 tenant/shared/both，仅命中时返回
+	RequestID      string   `json:"-"`                    // 请求ID，用于查询日志排查
+}
+
+// RangeRequest 前缀查询请求
+type RangeRequest struct {
+	IdentifierType string   // 为空时默认phone
+	HashType       string   // 为空时默认md5
+	Prefix         string   // 哈希的前5-10位十六进制字符
+	Lists          []string // 查询的名单，为空时查询API密钥已授权的全部名单
+}
+
+// RangeResult 前缀查询结果
+type RangeResult struct {
+	IdentifierType string        `json:"identifier_type"`
+	HashType       string        `json:"hash_type"`
+	Prefix         string        `json:"prefix"`
+	Suffixes       []RangeSuffix `json:"suffixes"`
+	RequestID      string        `json:"-"` // 请求ID，用于查询日志排查
+}
+
+// RangeSuffix 前缀查询匹配的条目，前缀与后缀拼接即为完整哈希
+type RangeSuffix struct {
+	Suffix    string   `json:"suffix"`
+	Category  string   `json:"category,omitempty"`
+	RiskScore int      `json:"risk_score"`
+	HitLists  []string `json:"hit_lists,omitempty"`
+	HitSource string   `json:"hit_source"`
+}
+
+// Match 在本地比对完整哈希，命中时返回对应条目，未命中或前缀不一致时返回nil
+func (r *RangeResult) Match(hash string) *RangeSuffix {
+	hash = strings.ToLower(hash)
+	if !strings.HasPrefix(hash, r.Prefix) {
+		return nil
+	}
+	suffix := hash[len(r.Prefix):]
+	for i := range r.Suffixes {
+		if r.Suffixes[i].Suffix == suffix {
+			return &r.Suffixes[i]
+		}
+	}
+	return nil
+}
+
+// checkBody 单个查询请求体
+type checkBody struct {
+	IdentifierType string   `json:"identifier_type,omitempty"`
+	HashType       string   `json:"hash_type,omitempty"`
+	IdentifierHash string   `json:"identifier_hash"`
+	Lists          []string `json:"lists,omitempty"`
+}
+
+// checkBatchBody 批量查询请求体
+type checkBatchBody struct {
+	IdentifierType     string   `json:"identifier_type,omitempty"`
+	HashType           string   `json:"hash_type,omitempty"`
+	IdentifierHashList []string `json:"identifier_hash_list"`
+	Lists              []string `json:"lists,omitempty"`
+}
+
+// rangeBody 前缀查询请求体
+type rangeBody struct {
+	IdentifierType string   `json:"identifier_type,omitempty"`
+	HashType       string   `json:"hash_type,omitempty"`
+	Prefix         string   `json:"prefix"`
+	Lists          []string `json:"lists,omitempty"`
+}
+
+// checkBatchData 批量查询响应数据
+type checkBatchData struct {
+	Results []CheckResult `json:"results"`
+}
+
+// envelope 服务端统一响应结构
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// client 黑名单查询客户端实现
+type client struct {
+	cfg  Config
+	http httpclient.HTTPClient
+}
+
+// NewClient 创建黑名单查询客户端
+func NewClient(cfg Config) (Client, error) {
+	if cfg.BaseURL == "" || cfg.APIKey == "" || cfg.APISecret == "" {
+		return nil, fmt.Errorf("BaseURL、APIKey和APISecret不能为空")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = defaultMaxRetries
+	}
+	if cfg.RetryWaitTime <= 0 {
+		cfg.RetryWaitTime = defaultRetryWaitTime
+	}
+	if cfg.RetryMaxWaitTime <= 0 {
+		cfg.RetryMaxWaitTime = defaultRetryMaxWaitTime
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = defaultUserAgent
+	}
+
+	// 重试由客户端自行处理：每次重试都需要新的nonce和签名，底层HTTP客户端不能原样重发
+	httpClient := httpclient.NewHTTPClient(&config.HTTPClientConfig{
+		EnableLog:      cfg.Logger != nil,
+		EnableTrace:    true,
+		MaxLogBodySize: 10240,
+		UserAgent:      cfg.UserAgent,
+	}, cfg.Logger)
+	httpClient.SetBaseURL(strings.TrimRight(cfg.BaseURL, "/")).SetTimeout(cfg.Timeout)
+
+	return &client{
+		cfg:  cfg,
+		http: httpClient,
+	}, nil
+}
+
+// Check 查询单个标识哈希
+func (c *client) Check(ctx context.Context, req *CheckRequest) (*CheckResult, error) {
+	var result CheckResult
+	requestID, err := c.post(ctx, checkPath, checkBody{
+		IdentifierType: req.IdentifierType,
+		HashType:       req.HashType,
+		IdentifierHash: req.IdentifierHash,
+		Lists:          req.Lists,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = requestID
+	return &result, nil
+}
+
+// CheckBatch 批量查询，超过MaxBatchSize时按顺序拆分为多次请求，结果顺序与请求一致
+// 任一分批失败时返回错误，已完成分批的结果不返回
+func (c *client) CheckBatch(ctx context.Context, req *CheckBatchRequest) ([]CheckResult, error) {
+	results := make([]CheckResult, 0, len(req.IdentifierHashes))
+	for start := 0; start < len(req.IdentifierHashes); start += MaxBatchSize {
+		end := start + MaxBatchSize
+		if end > len(req.IdentifierHashes) {
+			end = len(req.IdentifierHashes)
+		}
+
+		var data checkBatchData
+		requestID, err := c.post(ctx, checkBatchPath, checkBatchBody{
+			IdentifierType:     req.IdentifierType,
+			HashType:           req.HashType,
+			IdentifierHashList: req.IdentifierHashes[start:end],
+			Lists:              req.Lists,
+		}, &data)
+		if err != nil {
+			return nil, err
+		}
+		for i := range data.Results {
+			data.Results[i].RequestID = requestID
+		}
+		results = append(results, data.Results...)
+	}
+	return results, nil
+}
+
+// Range 按哈希前缀查询
+func (c *client) Range(ctx context.Context, req *RangeRequest) (*RangeResult, error) {
+	var result RangeResult
+	requestID, err := c.post(ctx, rangePath, rangeBody{
+		IdentifierType: req.IdentifierType,
+		HashType:       req.HashType,
+		Prefix:         req.Prefix,
+		Lists:          req.Lists,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+	result.RequestID = requestID
+	return &result, nil
+}
+
+// post 发送签名请求并解析响应数据，频率超限时等待后使用新的nonce重新签名发送
+func (c *client) post(ctx context.Context, path string, body, data interface{}) (string, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		requestID, err := c.send(ctx, path, payload, data)
+		if err == nil || !IsRetryable(err) || attempt >= c.cfg.MaxRetries {
+			return requestID, err
+		}
+
+		timer := time.NewTimer(c.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return requestID, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// send 发送一次签名请求
+func (c *client) send(ctx context.Context, path string, payload []byte, data interface{}) (string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce, err := NewNonce()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.http.Request(ctx, http.MethodPost, path, payload, map[string]string{
+		"X-API-Key":   c.cfg.APIKey,
+		"X-Timestamp": timestamp,
+		"X-Nonce":     nonce,
+		"X-Signature": Sign(c.cfg.APIKey, c.cfg.APISecret, timestamp, nonce, string(payload)),
+	})
+	if err != nil {
+		return "", fmt.Errorf("请求失败: %w", err)
+	}
+
+	requestID := http.Header(resp.Headers).Get("X-Request-ID")
+	var env envelope
+	if err := json.Unmarshal(resp.Body, &env); err != nil {
+		if !resp.IsSuccess {
+			return requestID, newAPIError(resp.StatusCode, 0, resp.String(), requestID)
+		}
+		return requestID, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if !resp.IsSuccess || env.Code != 0 {
+		return requestID, newAPIError(resp.StatusCode, env.Code, env.Message, requestID)
+	}
+
+	if err := json.Unmarshal(env.Data, data); err != nil {
+		return requestID, fmt.Errorf("解析响应数据失败: %w", err)
+	}
+	return requestID, nil
+}
+
+// backoff 第attempt次重试前的等待时间，按2倍递增并加入随机抖动，避免多个客户端同时重试
+func (c *client) backoff(attempt int) time.Duration {
+	wait := float64(c.cfg.RetryWaitTime) * math.Pow(2, float64(attempt))
+	if wait > float64(c.cfg.RetryMaxWaitTime) {
+		wait = float64(c.cfg.RetryMaxWaitTime)
+	}
+	return time.Duration(wait/2 + mathrand.Float64()*wait/2)
+}
+
+// Sign 计算请求签名：hex(HMAC-SHA256(api_secret, api_key + timestamp + nonce + body))
+func Sign(apiKey, apiSecret, timestamp, nonce, body string) string {
+	h := hmac.New(sha256.New, []byte(apiSecret))
+	h.Write([]byte(apiKey + timestamp + nonce + body))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NewNonce 生成32位十六进制随机数，服务端5分钟内拒绝重复的nonce
+func NewNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
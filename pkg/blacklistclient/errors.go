@@ -0,0 +1,85 @@
+package blacklistclient
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+
+	"github.com/varluffy/shield/pkg/errors"
+)
+
+// 错误分类，通过errors.Is判断，错误详情通过errors.As获取*APIError
+var (
+	ErrUnauthorized = stderrors.New("blacklist: unauthorized")      // 密钥无效、签名错误、请求过期或nonce重复
+	ErrForbidden    = stderrors.New("blacklist: forbidden")         // IP地址不在白名单中，或前缀查询未开启、超过每日配额
+	ErrInvalid      = stderrors.New("blacklist: invalid request")   // 标识类型或哈希格式错误
+	ErrRateLimited  = stderrors.New("blacklist: rate limited")      // 超出API密钥的速率限制，已按配置重试
+	ErrServer       = stderrors.New("blacklist: server error")      // 服务端查询失败
+	ErrUnknown      = stderrors.New("blacklist: unexpected status") // 其他错误
+)
+
+// APIError 服务端返回的错误
+type APIError struct {
+	StatusCode int    // HTTP状态码
+	Code       int    // 业务错误码，见pkg/errors
+	Message    string // 错误消息
+	RequestID  string // 请求ID，用于查询日志排查
+	kind       error
+}
+
+// Error 实现error接口
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: status %d, code %d, message: %s, request_id: %s",
+		e.kind, e.StatusCode, e.Code, e.Message, e.RequestID)
+}
+
+// Unwrap 返回错误分类
+func (e *APIError) Unwrap() error {
+	return e.kind
+}
+
+// newAPIError 根据业务错误码创建错误，响应中没有业务错误码时按HTTP状态码分类
+func newAPIError(statusCode, code int, message, requestID string) *APIError {
+	return &APIError{
+		StatusCode: statusCode,
+		Code:       code,
+		Message:    message,
+		RequestID:  requestID,
+		kind:       classify(statusCode, code),
+	}
+}
+
+// classify 将业务错误码映射为错误分类
+func classify(statusCode, code int) error {
+	switch code {
+	case errors.CodeUnauthorized, errors.CodeInvalidCredentials:
+		return ErrUnauthorized
+	case errors.CodeForbidden:
+		return ErrForbidden
+	case errors.CodeInvalidRequest, errors.CodeValidationError:
+		return ErrInvalid
+	case errors.CodeRateLimitError, errors.CodeAPIRateLimitExceeded:
+		return ErrRateLimited
+	case errors.CodeInternalError, errors.CodeDatabaseError, errors.CodeServiceUnavailable:
+		return ErrServer
+	}
+
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case statusCode == http.StatusForbidden:
+		return ErrForbidden
+	case statusCode == http.StatusBadRequest:
+		return ErrInvalid
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode >= http.StatusInternalServerError:
+		return ErrServer
+	}
+	return ErrUnknown
+}
+
+// IsRetryable 是否可以安全重试：频率超限的请求未被服务端处理，重新签名后重试不会重复计数
+func IsRetryable(err error) bool {
+	return stderrors.Is(err, ErrRateLimited)
+}
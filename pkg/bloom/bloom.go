@@ -0,0 +1,107 @@
+// Package bloom provides a concurrency-safe Bloom filter backed by an atomic bitmap.
+// It is used as an in-process negative cache in front of remote set lookups.
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+// Filter 布隆过滤器（位图实现，支持并发读写）
+type Filter struct {
+	bits     []uint64
+	m        uint64 // 位数
+	k        uint32 // 哈希函数个数
+	capacity int    // 设计容量
+	count    atomic.Int64
+}
+
+// New 根据预期元素数量和误判率创建布隆过滤器
+func New(expectedItems int, falsePositiveRate float64) *Filter {
+	if expectedItems < 1 {
+		expectedItems = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	// m = -n*ln(p) / (ln2)^2, k = m/n * ln2
+	n := float64(expectedItems)
+	m := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint32(math.Round(float64(m) / n * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &Filter{
+		bits:     make([]uint64, (m+63)/64),
+		m:        m,
+		k:        k,
+		capacity: expectedItems,
+	}
+}
+
+// Add 添加元素
+func (f *Filter) Add(value string) {
+	h1, h2 := hashPair(value)
+	for i := uint32(0); i < f.k; i++ {
+		idx := (h1 + uint64(i)*h2) % f.m
+		atomic.OrUint64(&f.bits[idx>>6], 1<<(idx&63))
+	}
+	f.count.Add(1)
+}
+
+// MayContain 判断元素是否可能存在（false表示一定不存在）
+func (f *Filter) MayContain(value string) bool {
+	h1, h2 := hashPair(value)
+	for i := uint32(0); i < f.k; i++ {
+		idx := (h1 + uint64(i)*h2) % f.m
+		if atomic.LoadUint64(&f.bits[idx>>6])&(1<<(idx&63)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count 已添加的元素数量（重复添加会重复计数）
+func (f *Filter) Count() int64 {
+	return f.count.Load()
+}
+
+// Capacity 设计容量
+func (f *Filter) Capacity() int {
+	return f.capacity
+}
+
+// SizeBytes 位图占用的内存字节数
+func (f *Filter) SizeBytes() int {
+	return len(f.bits) * 8
+}
+
+// HashFunctions 哈希函数个数
+func (f *Filter) HashFunctions() int {
+	return int(f.k)
+}
+
+// EstimatedFalsePositiveRate 根据当前元素数量估算的理论误判率
+func (f *Filter) EstimatedFalsePositiveRate() float64 {
+	n := float64(f.count.Load())
+	return math.Pow(1-math.Exp(-float64(f.k)*n/float64(f.m)), float64(f.k))
+}
+
+// hashPair 计算两个独立哈希值，用于双重哈希生成k个位置
+func hashPair(value string) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(value))
+	h1 := h.Sum64()
+
+	h = fnv.New64()
+	_, _ = h.Write([]byte(value))
+	h2 := h.Sum64() | 1 // 保证为奇数，避免步长退化
+
+	return h1, h2
+}
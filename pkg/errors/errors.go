@@ -10,16 +10,17 @@ import (
 // 业务错误码定义
 const (
 	// 通用错误码 (1000-1999)
-	CodeSuccess         = 0    // 成功
-	CodeInternalError   = 1000 // 内部错误
-	CodeInvalidRequest  = 1001 // 无效请求
-	CodeValidationError = 1002 // 验证错误
-	CodeUnauthorized    = 1003 // 未授权
-	CodeForbidden       = 1004 // 禁止访问
-	CodeNotFound        = 1005 // 未找到
-	CodeConflict        = 1006 // 冲突
-	CodeRateLimitError  = 1007 // 请求频率限制
-	CodeTimeout         = 1008 // 请求超时
+	CodeSuccess            = 0    // 成功
+	CodeInternalError      = 1000 // 内部错误
+	CodeInvalidRequest     = 1001 // 无效请求
+	CodeValidationError    = 1002 // 验证错误
+	CodeUnauthorized       = 1003 // 未授权
+	CodeForbidden          = 1004 // 禁止访问
+	CodeNotFound           = 1005 // 未找到
+	CodeConflict           = 1006 // 冲突
+	CodeRateLimitError     = 1007 // 请求频率限制
+	CodeTimeout            = 1008 // 请求超时
+	CodeServiceUnavailable = 1009 // 服务暂不可用
 
 	// 用户相关错误码 (2000-2999)
 	CodeUserNotFound        = 2001 // 用户不存在
@@ -58,16 +59,17 @@ const (
 
 // 错误码到消息的映射
 var errorMessages = map[int]string{
-	CodeSuccess:         "success",
-	CodeInternalError:   "内部服务器错误",
-	CodeInvalidRequest:  "无效的请求",
-	CodeValidationError: "参数验证失败",
-	CodeUnauthorized:    "未授权访问",
-	CodeForbidden:       "禁止访问",
-	CodeNotFound:        "资源不存在",
-	CodeConflict:        "资源冲突",
-	CodeRateLimitError:  "请求频率超限",
-	CodeTimeout:         "请求超时",
+	CodeSuccess:            "success",
+	CodeInternalError:      "内部服务器错误",
+	CodeInvalidRequest:     "无效的请求",
+	CodeValidationError:    "参数验证失败",
+	CodeUnauthorized:       "未授权访问",
+	CodeForbidden:          "禁止访问",
+	CodeNotFound:           "资源不存在",
+	CodeConflict:           "资源冲突",
+	CodeRateLimitError:     "请求频率超限",
+	CodeTimeout:            "请求超时",
+	CodeServiceUnavailable: "服务暂不可用",
 
 	CodeUserNotFound:        "用户不存在",
 	CodeUserAlreadyExists:   "用户已存在",
@@ -102,16 +104,17 @@ var errorMessages = map[int]string{
 
 // 错误码到HTTP状态码的映射
 var codeToHTTPStatus = map[int]int{
-	CodeSuccess:         http.StatusOK,
-	CodeInternalError:   http.StatusInternalServerError,
-	CodeInvalidRequest:  http.StatusBadRequest,
-	CodeValidationError: http.StatusBadRequest,
-	CodeUnauthorized:    http.StatusUnauthorized,
-	CodeForbidden:       http.StatusForbidden,
-	CodeNotFound:        http.StatusNotFound,
-	CodeConflict:        http.StatusConflict,
-	CodeRateLimitError:  http.StatusTooManyRequests,
-	CodeTimeout:         http.StatusRequestTimeout,
+	CodeSuccess:            http.StatusOK,
+	CodeInternalError:      http.StatusInternalServerError,
+	CodeInvalidRequest:     http.StatusBadRequest,
+	CodeValidationError:    http.StatusBadRequest,
+	CodeUnauthorized:       http.StatusUnauthorized,
+	CodeForbidden:          http.StatusForbidden,
+	CodeNotFound:           http.StatusNotFound,
+	CodeConflict:           http.StatusConflict,
+	CodeRateLimitError:     http.StatusTooManyRequests,
+	CodeTimeout:            http.StatusRequestTimeout,
+	CodeServiceUnavailable: http.StatusServiceUnavailable,
 
 	CodeUserNotFound:        http.StatusNotFound,
 	CodeUserAlreadyExists:   http.StatusConflict,
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -69,7 +70,15 @@ type HTTPClientImpl struct {
 
 // NewHTTPClient 创建HTTP客户端
 func NewHTTPClient(cfg *config.HTTPClientConfig, logger *logger.Logger) HTTPClient {
+	return NewHTTPClientWithTransport(cfg, logger, nil)
+}
+
+// NewHTTPClientWithTransport 使用指定的Transport创建HTTP客户端，transport为nil时使用默认Transport
+func NewHTTPClientWithTransport(cfg *config.HTTPClientConfig, logger *logger.Logger, transport http.RoundTripper) HTTPClient {
 	client := resty.New()
+	if transport != nil {
+		client.SetTransport(transport)
+	}
 
 	// 基础配置
 	client.SetTimeout(time.Duration(cfg.Timeout) * time.Second)
@@ -174,11 +183,13 @@ func (h *HTTPClientImpl) Request(ctx context.Context, method, url string, body i
 		return nil, fmt.Errorf("unsupported HTTP method: %s", method)
 	}
 
-	// 构造响应
+	// 构造响应，请求未发出时resp为nil
 	response := &Response{
-		Headers: resp.Header(),
 		TraceID: tracing.GetTraceIDFromContext(ctx),
 	}
+	if resp != nil {
+		response.Headers = resp.Header()
+	}
 
 	if err != nil {
 		response.Error = err
@@ -224,8 +235,8 @@ func (h *HTTPClientImpl) SetTimeout(timeout time.Duration) HTTPClient {
 	return h
 }
 
-// UnmarshalJSON 便捷方法：将响应体解析为JSON
-func (r *Response) UnmarshalJSON(v interface{}) error {
+// DecodeJSON 便捷方法：将响应体解析为JSON
+func (r *Response) DecodeJSON(v interface{}) error {
 	if !r.IsSuccess {
 		return fmt.Errorf("response not successful: status %d", r.StatusCode)
 	}
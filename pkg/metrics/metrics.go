@@ -0,0 +1,163 @@
+// Package metrics provides Prometheus metrics for the application.
+// It holds the metric registry, the collectors updated by the HTTP, blacklist and cache layers,
+// and the pool collectors for Redis and the database.
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+// namespace 指标名称前缀
+const namespace = "shield"
+
+// 黑名单查询来源
+const (
+	SourceFilter = "filter" // 本地过滤器判定一定不存在
+	SourceRedis  = "redis"  // Redis查询
+	SourceDB     = "db"     // Redis失败后回退到数据库查询
+)
+
+// registry 应用的指标注册表，不使用默认注册表以免引入依赖库注册的指标
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP请求耗时，route为路由模板，未匹配的请求为unmatched",
+		Buckets:   []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"method", "route", "status"})
+
+	blacklistChecks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "blacklist",
+		Name:      "checks_total",
+		Help:      "黑名单查询的标识数量，result为hit或miss，source为filter、redis或db",
+	}, []string{"tenant_id", "result", "source"})
+
+	permissionCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "permission_cache",
+		Name:      "requests_total",
+		Help:      "权限缓存读取次数，cache为permissions或roles，backend为redis或memory，result为hit或miss",
+	}, []string{"cache", "backend", "result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestDuration,
+		blacklistChecks,
+		permissionCacheRequests,
+	)
+}
+
+// Handler 返回指标的HTTP处理器，token不为空时要求请求携带Authorization: Bearer {token}
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return handler
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// ObserveHTTPRequest 记录一次HTTP请求
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// AddBlacklistChecks 记录租户从某个来源查询的命中和未命中标识数量
+func AddBlacklistChecks(tenantID uint64, source string, hits, misses int) {
+	tenant := strconv.FormatUint(tenantID, 10)
+	if hits > 0 {
+		blacklistChecks.WithLabelValues(tenant, "hit", source).Add(float64(hits))
+	}
+	if misses > 0 {
+		blacklistChecks.WithLabelValues(tenant, "miss", source).Add(float64(misses))
+	}
+}
+
+// ObservePermissionCache 记录一次权限缓存读取
+func ObservePermissionCache(cache, backend string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	permissionCacheRequests.WithLabelValues(cache, backend, result).Inc()
+}
+
+// RegisterDBPool 注册数据库连接池指标，同名连接池只能注册一次
+func RegisterDBPool(name string, db *sql.DB) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterRedisPool 注册Redis连接池指标，stats通常为redis.Client.Stats
+func RegisterRedisPool(stats func() *redis.PoolStats) error {
+	return registry.Register(&redisPoolCollector{stats: stats})
+}
+
+// redisPoolCollector 在每次采集时读取Redis连接池统计
+type redisPoolCollector struct {
+	stats func() *redis.PoolStats
+}
+
+var (
+	redisPoolHitsDesc = prometheus.NewDesc(namespace+"_redis_pool_hits_total",
+		"从连接池获取到空闲连接的次数", nil, nil)
+	redisPoolMissesDesc = prometheus.NewDesc(namespace+"_redis_pool_misses_total",
+		"连接池没有空闲连接需要新建连接的次数", nil, nil)
+	redisPoolTimeoutsDesc = prometheus.NewDesc(namespace+"_redis_pool_timeouts_total",
+		"等待连接超时的次数", nil, nil)
+	redisPoolTotalConnsDesc = prometheus.NewDesc(namespace+"_redis_pool_total_connections",
+		"连接池中的连接数", nil, nil)
+	redisPoolIdleConnsDesc = prometheus.NewDesc(namespace+"_redis_pool_idle_connections",
+		"连接池中的空闲连接数", nil, nil)
+	redisPoolStaleConnsDesc = prometheus.NewDesc(namespace+"_redis_pool_stale_connections_total",
+		"因过期被关闭的连接数", nil, nil)
+)
+
+// Describe 实现prometheus.Collector接口
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- redisPoolHitsDesc
+	ch <- redisPoolMissesDesc
+	ch <- redisPoolTimeoutsDesc
+	ch <- redisPoolTotalConnsDesc
+	ch <- redisPoolIdleConnsDesc
+	ch <- redisPoolStaleConnsDesc
+}
+
+// Collect 实现prometheus.Collector接口
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	if stats == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(redisPoolHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(redisPoolMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(redisPoolTimeoutsDesc, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(redisPoolTotalConnsDesc, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(redisPoolIdleConnsDesc, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(redisPoolStaleConnsDesc, prometheus.CounterValue, float64(stats.StaleConns))
+}
@@ -231,7 +231,7 @@ func (h *prefixTracingHook) addPrefixToCmd(cmd redis.Cmder) {
 				args[1] = h.prefix + key
 			}
 		}
-	case "mget", "mset", "msetnx":
+	case "mget", "mset", "msetnx", "rename", "renamenx":
 		// 多个key的命令
 		h.addPrefixToMultiKeys(args, cmdName)
 	case "hget", "hset", "hdel", "hexists", "hgetall", "hkeys", "hvals", "hlen", "hmget", "hmset":
@@ -271,8 +271,8 @@ func (h *prefixTracingHook) addPrefixToCmd(cmd redis.Cmder) {
 // addPrefixToMultiKeys 为多key命令添加前缀
 func (h *prefixTracingHook) addPrefixToMultiKeys(args []interface{}, cmdName string) {
 	switch cmdName {
-	case "mget":
-		// MGET key1 key2 key3...
+	case "mget", "rename", "renamenx":
+		// MGET key1 key2 key3... / RENAME key newkey
 		for i := 1; i < len(args); i++ {
 			if key, ok := args[i].(string); ok {
 				args[i] = h.prefix + key
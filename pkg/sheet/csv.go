@@ -0,0 +1,39 @@
+package sheet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+)
+
+// utf8BOM 表格软件导出CSV时常带的UTF-8 BOM
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// csvReader CSV读取器
+type csvReader struct {
+	reader *csv.Reader
+}
+
+// NewCSVReader 创建CSV读取器，自动跳过UTF-8 BOM，允许各行列数不一致
+func NewCSVReader(r io.Reader) Reader {
+	br := bufio.NewReader(r)
+	if head, err := br.Peek(len(utf8BOM)); err == nil && bytes.Equal(head, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+
+	reader := csv.NewReader(br)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return &csvReader{reader: reader}
+}
+
+// Read 读取下一行，空行会被跳过
+func (r *csvReader) Read() (*Row, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	line, _ := r.reader.FieldPos(0)
+	return &Row{Number: line, Cells: record}, nil
+}
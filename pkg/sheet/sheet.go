@@ -0,0 +1,65 @@
+// Package sheet provides streaming row readers for CSV and XLSX files.
+// XLSX files are parsed with the standard library (zip + xml) so that only the
+// shared string table is held in memory while worksheet rows are streamed.
+package sheet
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// 支持的文件格式
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ErrUnsupportedFormat 不支持的文件格式
+var ErrUnsupportedFormat = errors.New("unsupported file format, only csv and xlsx are supported")
+
+// Row 表格行
+type Row struct {
+	Number int      // 行号，从1开始，与表格软件中显示的一致
+	Cells  []string // 单元格值，空单元格为空字符串
+}
+
+// Cell 获取指定列（从0开始）的值，列不存在时返回空字符串
+func (r *Row) Cell(index int) string {
+	if index < 0 || index >= len(r.Cells) {
+		return ""
+	}
+	return r.Cells[index]
+}
+
+// Reader 逐行读取表格，读取完毕返回io.EOF
+type Reader interface {
+	Read() (*Row, error)
+}
+
+// DetectFormat 根据文件名扩展名识别格式
+func DetectFormat(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv", ".txt":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// NewReader 根据文件名创建对应格式的读取器，XLSX为zip格式需要随机读取
+func NewReader(r io.ReaderAt, size int64, filename string) (Reader, error) {
+	format, err := DetectFormat(filename)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatXLSX:
+		return NewXLSXReader(r, size)
+	default:
+		return NewCSVReader(io.NewSectionReader(r, 0, size)), nil
+	}
+}
@@ -0,0 +1,276 @@
+package sheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxSharedStringsSize 共享字符串表解压后的大小上限，防止压缩炸弹
+const maxSharedStringsSize = 64 << 20
+
+// xlsxReader XLSX读取器，仅读取第一个工作表
+type xlsxReader struct {
+	sheet   io.ReadCloser
+	decoder *xml.Decoder
+	strings []string
+	lastRow int
+}
+
+// NewXLSXReader 创建XLSX读取器
+func NewXLSXReader(r io.ReaderAt, size int64) (Reader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	sheetFile, ok := files[sheetPath]
+	if !ok {
+		return nil, fmt.Errorf("invalid xlsx file: worksheet %s not found", sheetPath)
+	}
+
+	sharedStrings, err := readSharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return nil, err
+	}
+
+	sheet, err := sheetFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open worksheet: %w", err)
+	}
+
+	return &xlsxReader{
+		sheet:   sheet,
+		decoder: xml.NewDecoder(sheet),
+		strings: sharedStrings,
+	}, nil
+}
+
+// Read 读取下一行，没有任何单元格的行会被跳过
+func (r *xlsxReader) Read() (*Row, error) {
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			_ = r.sheet.Close()
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("read worksheet: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		row, err := r.readRow(start)
+		if err != nil {
+			return nil, err
+		}
+		if len(row.Cells) > 0 {
+			return row, nil
+		}
+	}
+}
+
+// readRow 读取<row>元素中的全部单元格
+func (r *xlsxReader) readRow(start xml.StartElement) (*Row, error) {
+	number := r.lastRow + 1
+	if value := attr(start, "r"); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			number = n
+		}
+	}
+	r.lastRow = number
+
+	row := &Row{Number: number}
+	for {
+		token, err := r.decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("read worksheet row %d: %w", number, err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "c" {
+				continue
+			}
+			column := len(row.Cells)
+			if ref := attr(t, "r"); ref != "" {
+				column = columnIndex(ref)
+			}
+			value, err := r.readCell(t)
+			if err != nil {
+				return nil, fmt.Errorf("read worksheet row %d: %w", number, err)
+			}
+			for len(row.Cells) < column {
+				row.Cells = append(row.Cells, "")
+			}
+			row.Cells = append(row.Cells, value)
+		case xml.EndElement:
+			if t.Name.Local == "row" {
+				return row, nil
+			}
+		}
+	}
+}
+
+// xlsxCell 单元格内容
+type xlsxCell struct {
+	Value  string   `xml:"v"`
+	Inline []string `xml:"is>t"`
+	Rich   []string `xml:"is>r>t"`
+}
+
+// readCell 读取<c>元素并按类型转换为字符串
+func (r *xlsxReader) readCell(start xml.StartElement) (string, error) {
+	var cell xlsxCell
+	if err := r.decoder.DecodeElement(&cell, &start); err != nil {
+		return "", err
+	}
+
+	switch attr(start, "t") {
+	case "s":
+		index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+		if err != nil || index < 0 || index >= len(r.strings) {
+			return "", fmt.Errorf("invalid shared string index %q", cell.Value)
+		}
+		return r.strings[index], nil
+	case "inlineStr":
+		return strings.Join(cell.Inline, "") + strings.Join(cell.Rich, ""), nil
+	case "str", "b", "e":
+		return cell.Value, nil
+	default:
+		return formatNumber(cell.Value), nil
+	}
+}
+
+// formatNumber 将科学计数法表示的整数还原为普通数字，如1.3800138E10
+func formatNumber(value string) string {
+	if !strings.ContainsAny(value, "eE") {
+		return value
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return value
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// columnIndex 将单元格引用（如C12）转换为从0开始的列号
+func columnIndex(ref string) int {
+	index := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		index = index*26 + int(c-'A'+1)
+	}
+	return index - 1
+}
+
+// attr 获取元素属性值
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// firstSheetPath 根据workbook.xml及其关系文件定位第一个工作表
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeFile(files["xl/workbook.xml"], &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return fallback, nil
+	}
+
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeFile(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return fallback, nil
+}
+
+// readSharedStrings 读取共享字符串表，文件不存在时返回空表
+func readSharedStrings(f *zip.File) ([]string, error) {
+	if f == nil {
+		return nil, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open shared strings: %w", err)
+	}
+	defer rc.Close()
+
+	var sst struct {
+		Items []struct {
+			Text string   `xml:"t"`
+			Rich []string `xml:"r>t"`
+		} `xml:"si"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(rc, maxSharedStringsSize)).Decode(&sst); err != nil {
+		return nil, fmt.Errorf("read shared strings: %w", err)
+	}
+
+	values := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		values[i] = item.Text + strings.Join(item.Rich, "")
+	}
+	return values, nil
+}
+
+// decodeFile 解析zip中的XML文件，文件不存在时保持零值
+func decodeFile(f *zip.File, v interface{}) error {
+	if f == nil {
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("read %s: %w", f.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+// Package test contains unit tests for the blacklist client SDK.
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/varluffy/shield/pkg/blacklistclient"
+	bizerrors "github.com/varluffy/shield/pkg/errors"
+)
+
+// fakeBlacklistAPI 校验签名和nonce的查询接口，前rateLimited个请求返回频率超限
+type fakeBlacklistAPI struct {
+	mu          sync.Mutex
+	nonces      map[string]bool
+	batchSizes  []int
+	rateLimited int
+	calls       int
+}
+
+func (a *fakeBlacklistAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls++
+
+	writeJSON := func(status, code int, message string, data interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-ID", fmt.Sprintf("req-%d", a.calls))
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": code, "message": message, "data": data})
+	}
+
+	nonce := r.Header.Get("X-Nonce")
+	expected := blacklistclient.Sign(r.Header.Get("X-API-Key"), "client-secret", r.Header.Get("X-Timestamp"), nonce, string(body))
+	if r.Header.Get("X-API-Key") != "ak_client" || r.Header.Get("X-Signature") != expected || a.nonces[nonce] {
+		writeJSON(http.StatusUnauthorized, bizerrors.CodeUnauthorized, "未授权访问", nil)
+		return
+	}
+	a.nonces[nonce] = true
+
+	if a.rateLimited > 0 {
+		a.rateLimited--
+		writeJSON(http.StatusTooManyRequests, bizerrors.CodeAPIRateLimitExceeded, "API调用频率超限", nil)
+		return
+	}
+
+	result := func(hash string) map[string]interface{} {
+		hit := hash == "5d41402abc4b2a76b9719d911017c592"
+		item := map[string]interface{}{"is_blacklist": hit, "identifier_type": "phone", "hash_type": "md5", "identifier_hash": hash}
+		if hit {
+			item["category"] = "fraud"
+			item["risk_score"] = 90
+		}
+		return item
+	}
+
+	switch r.URL.Path {
+	case "/api/v1/blacklist/check":
+		var req struct {
+			IdentifierHash string `json:"identifier_hash"`
+		}
+		_ = json.Unmarshal(body, &req)
+		if len(req.IdentifierHash) != 32 {
+			writeJSON(http.StatusBadRequest, bizerrors.CodeValidationError, "参数验证失败", nil)
+			return
+		}
+		writeJSON(http.StatusOK, 0, "success", result(req.IdentifierHash))
+	case "/api/v1/blacklist/check-batch":
+		var req struct {
+			IdentifierHashList []string `json:"identifier_hash_list"`
+		}
+		_ = json.Unmarshal(body, &req)
+		a.batchSizes = append(a.batchSizes, len(req.IdentifierHashList))
+		results := make([]map[string]interface{}, 0, len(req.IdentifierHashList))
+		for _, hash := range req.IdentifierHashList {
+			results = append(results, result(hash))
+		}
+		writeJSON(http.StatusOK, 0, "success", map[string]interface{}{"results": results})
+	case "/api/v1/blacklist/range":
+		var req struct {
+			Prefix string `json:"prefix"`
+		}
+		_ = json.Unmarshal(body, &req)
+		suffixes := make([]map[string]interface{}, 0, 1)
+		if req.Prefix == "5d414" {
+			suffixes = append(suffixes, map[string]interface{}{"suffix": "02abc4b2a76b9719d911017c592", "category": "fraud", "risk_score": 90, "hit_source": "tenant"})
+		}
+		writeJSON(http.StatusOK, 0, "success", map[string]interface{}{"identifier_type": "phone", "hash_type": "md5", "prefix": req.Prefix, "suffixes": suffixes})
+	default:
+		writeJSON(http.StatusNotFound, bizerrors.CodeNotFound, "资源不存在", nil)
+	}
+}
+
+// TestBlacklistClient 黑名单查询SDK测试
+func TestBlacklistClient(t *testing.T) {
+	newClient := func(t *testing.T, api *fakeBlacklistAPI, secret string, maxRetries int) blacklistclient.Client {
+		api.nonces = make(map[string]bool)
+		server := httptest.NewServer(api)
+		t.Cleanup(server.Close)
+
+		client, err := blacklistclient.NewClient(blacklistclient.Config{
+			BaseURL:          server.URL,
+			APIKey:           "ak_client",
+			APISecret:        secret,
+			MaxRetries:       maxRetries,
+			RetryWaitTime:    time.Millisecond,
+			RetryMaxWaitTime: 5 * time.Millisecond,
+		})
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("Test Check Signs Request", func(t *testing.T) {
+		client := newClient(t, &fakeBlacklistAPI{}, "client-secret", 0)
+
+		result, err := client.Check(context.Background(), &blacklistclient.CheckRequest{
+			IdentifierHash: "5d41402abc4b2a76b9719d911017c592",
+		})
+		require.NoError(t, err)
+		assert.True(t, result.IsBlacklist)
+		assert.Equal(t, "fraud", result.Category)
+		assert.Equal(t, 90, result.RiskScore)
+		assert.Equal(t, "req-1", result.RequestID)
+	})
+
+	t.Run("Test Rate Limited Requests Retry With New Nonce", func(t *testing.T) {
+		api := &fakeBlacklistAPI{rateLimited: 2}
+		client := newClient(t, api, "client-secret", 3)
+
+		result, err := client.Check(context.Background(), &blacklistclient.CheckRequest{
+			IdentifierHash: "098f6bcd4621d373cade4e832627b4f6",
+		})
+		require.NoError(t, err)
+		assert.False(t, result.IsBlacklist)
+		assert.Equal(t, 3, api.calls)
+		assert.Len(t, api.nonces, 3)
+	})
+
+	t.Run("Test Rate Limit Error After Retries", func(t *testing.T) {
+		api := &fakeBlacklistAPI{rateLimited: 10}
+		client := newClient(t, api, "client-secret", 2)
+
+		_, err := client.Check(context.Background(), &blacklistclient.CheckRequest{
+			IdentifierHash: "098f6bcd4621d373cade4e832627b4f6",
+		})
+		assert.ErrorIs(t, err, blacklistclient.ErrRateLimited)
+		assert.Equal(t, 3, api.calls)
+	})
+
+	t.Run("Test Typed Errors Are Not Retried", func(t *testing.T) {
+		api := &fakeBlacklistAPI{}
+		client := newClient(t, api, "wrong-secret", 3)
+
+		_, err := client.Check(context.Background(), &blacklistclient.CheckRequest{
+			IdentifierHash: "098f6bcd4621d373cade4e832627b4f6",
+		})
+		assert.ErrorIs(t, err, blacklistclient.ErrUnauthorized)
+		var apiErr *blacklistclient.APIError
+		require.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+		assert.Equal(t, bizerrors.CodeUnauthorized, apiErr.Code)
+		assert.Equal(t, 1, api.calls)
+
+		client = newClient(t, &fakeBlacklistAPI{}, "client-secret", 3)
+		_, err = client.Check(context.Background(), &blacklistclient.CheckRequest{IdentifierHash: "abc"})
+		assert.ErrorIs(t, err, blacklistclient.ErrInvalid)
+	})
+
+	t.Run("Test Range Sends Prefix And Matches Locally", func(t *testing.T) {
+		client := newClient(t, &fakeBlacklistAPI{}, "client-secret", 0)
+
+		result, err := client.Range(context.Background(), &blacklistclient.RangeRequest{Prefix: "5d414"})
+		require.NoError(t, err)
+		require.Len(t, result.Suffixes, 1)
+		assert.Equal(t, "req-1", result.RequestID)
+
+		match := result.Match("5D41402ABC4B2A76B9719D911017C592")
+		require.NotNil(t, match)
+		assert.Equal(t, "fraud", match.Category)
+		assert.Equal(t, 90, match.RiskScore)
+		assert.Nil(t, result.Match("5d414000000000000000000000000000"))
+		assert.Nil(t, result.Match("098f6bcd4621d373cade4e832627b4f6"))
+	})
+
+	t.Run("Test Check Batch Splits Large Requests", func(t *testing.T) {
+		api := &fakeBlacklistAPI{}
+		client := newClient(t, api, "client-secret", 0)
+
+		hashes := make([]string, 250)
+		for i := range hashes {
+			hashes[i] = fmt.Sprintf("%032x", i)
+		}
+		hashes[150] = "5d41402abc4b2a76b9719d911017c592"
+
+		results, err := client.CheckBatch(context.Background(), &blacklistclient.CheckBatchRequest{IdentifierHashes: hashes})
+		require.NoError(t, err)
+		require.Len(t, results, 250)
+		assert.Equal(t, []int{100, 100, 50}, api.batchSizes)
+		for i, result := range results {
+			assert.Equal(t, hashes[i], result.IdentifierHash)
+			assert.Equal(t, i == 150, result.IsBlacklist)
+		}
+		assert.Equal(t, "req-2", results[150].RequestID)
+	})
+}
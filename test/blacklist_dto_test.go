@@ -0,0 +1,145 @@
+// Package test contains unit tests for blacklist DTOs.
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/varluffy/shield/internal/dto"
+	"github.com/varluffy/shield/internal/models"
+)
+
+// TestCheckBlacklistRequestResolve 查询请求标识解析测试
+func TestCheckBlacklistRequestResolve(t *testing.T) {
+	const (
+		md5    = "5d41402abc4b2a76b9719d911017c592"
+		sha256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	)
+
+	t.Run("Test Legacy Phone Request", func(t *testing.T) {
+		req := dto.CheckBlacklistRequest{PhoneMD5: md5}
+		identifierType, hashType, valueMD5, ok := req.Resolve()
+		assert.True(t, ok)
+		assert.Equal(t, models.IdentifierTypePhone, identifierType)
+		assert.Equal(t, models.HashTypeMD5, hashType)
+		assert.Equal(t, md5, valueMD5)
+
+		resp := dto.NewCheckBlacklistResponse(identifierType, hashType, valueMD5, true)
+		assert.Equal(t, md5, resp.PhoneMD5, "手机号查询应继续返回phone_md5")
+	})
+
+	t.Run("Test Identifier Request", func(t *testing.T) {
+		req := dto.CheckBlacklistRequest{IdentifierType: models.IdentifierTypeDeviceID, IdentifierMD5: md5}
+		identifierType, hashType, valueMD5, ok := req.Resolve()
+		assert.True(t, ok)
+		assert.Equal(t, models.IdentifierTypeDeviceID, identifierType)
+		assert.Equal(t, md5, valueMD5)
+
+		resp := dto.NewCheckBlacklistResponse(identifierType, hashType, valueMD5, false).
+			WithRisk(models.RiskCategoryFraud, 90)
+		assert.Empty(t, resp.PhoneMD5)
+		assert.Empty(t, resp.Category, "未命中时不返回风险信息")
+		assert.Zero(t, resp.RiskScore)
+		assert.Equal(t, md5, resp.IdentifierMD5)
+	})
+
+	t.Run("Test Non Phone Type Ignores PhoneMD5", func(t *testing.T) {
+		req := dto.CheckBlacklistRequest{IdentifierType: models.IdentifierTypeEmail, PhoneMD5: md5}
+		_, _, _, ok := req.Resolve()
+		assert.False(t, ok, "非手机号类型必须使用identifier_md5")
+	})
+
+	t.Run("Test Missing Identifier", func(t *testing.T) {
+		req := dto.CheckBlacklistRequest{}
+		_, _, _, ok := req.Resolve()
+		assert.False(t, ok)
+	})
+
+	t.Run("Test SHA256 Request", func(t *testing.T) {
+		req := dto.CheckBlacklistRequest{HashType: models.HashTypeSHA256, IdentifierHash: strings.ToUpper(sha256)}
+		identifierType, hashType, hash, ok := req.Resolve()
+		assert.True(t, ok)
+		assert.Equal(t, models.IdentifierTypePhone, identifierType)
+		assert.Equal(t, models.HashTypeSHA256, hashType)
+		assert.Equal(t, sha256, hash, "哈希应统一为小写")
+
+		resp := dto.NewCheckBlacklistResponse(identifierType, hashType, hash, true).
+			WithRisk(models.RiskCategoryComplaint, 60)
+		assert.Equal(t, models.RiskCategoryComplaint, resp.Category)
+		assert.Equal(t, 60, resp.RiskScore)
+		assert.Empty(t, resp.PhoneMD5)
+		assert.Empty(t, resp.IdentifierMD5)
+		assert.Equal(t, sha256, resp.IdentifierHash)
+	})
+
+	t.Run("Test Hash Length Mismatch", func(t *testing.T) {
+		req := dto.CheckBlacklistRequest{HashType: models.HashTypeHMACSHA256, IdentifierHash: md5}
+		_, _, _, ok := req.Resolve()
+		assert.False(t, ok, "HMAC-SHA256格式长度必须为64")
+
+		req = dto.CheckBlacklistRequest{HashType: models.HashTypeSHA256, PhoneMD5: md5}
+		_, _, _, ok = req.Resolve()
+		assert.False(t, ok, "非MD5格式必须使用identifier_hash")
+	})
+
+	t.Run("Test Batch Legacy Phone Request", func(t *testing.T) {
+		req := dto.CheckBlacklistBatchRequest{PhoneMD5List: []string{md5}}
+		identifierType, hashType, values, ok := req.Resolve()
+		assert.True(t, ok)
+		assert.Equal(t, models.IdentifierTypePhone, identifierType)
+		assert.Equal(t, models.HashTypeMD5, hashType)
+		assert.Equal(t, []string{md5}, values)
+
+		req = dto.CheckBlacklistBatchRequest{PhoneMD5List: []string{md5, "not-a-hash"}}
+		_, _, _, ok = req.Resolve()
+		assert.False(t, ok, "列表中任一哈希格式错误应拒绝")
+	})
+
+	t.Run("Test Batch Import Mixed Formats", func(t *testing.T) {
+		req := dto.BatchImportBlacklistRequest{
+			PhoneMD5List:         []string{md5},
+			IdentifierSHA256List: []string{sha256},
+			Items:                []dto.BlacklistHashItem{{MD5: md5, SHA256: sha256}},
+		}
+		identifierType, items, ok := req.Resolve()
+		assert.True(t, ok)
+		assert.Equal(t, models.IdentifierTypePhone, identifierType)
+		assert.Equal(t, []dto.BlacklistHashItem{{MD5: md5}, {SHA256: sha256}, {MD5: md5, SHA256: sha256}}, items)
+
+		req = dto.BatchImportBlacklistRequest{Items: []dto.BlacklistHashItem{{}}}
+		_, _, ok = req.Resolve()
+		assert.False(t, ok, "条目至少需要一种哈希")
+	})
+}
+
+// TestNewImportJobInfo 导入任务进度计算测试
+func TestNewImportJobInfo(t *testing.T) {
+	job := &models.BlacklistImportJob{Status: models.ImportJobStatusRunning, TotalRows: 3, ProcessedRows: 1}
+	assert.Equal(t, 33.33, dto.NewImportJobInfo(job).Progress)
+
+	job = &models.BlacklistImportJob{Status: models.ImportJobStatusPending}
+	assert.Equal(t, float64(0), dto.NewImportJobInfo(job).Progress, "尚未统计行数时进度为0")
+
+	job = &models.BlacklistImportJob{Status: models.ImportJobStatusCompleted}
+	assert.Equal(t, float64(100), dto.NewImportJobInfo(job).Progress, "空文件完成后进度为100")
+}
+
+// TestExportBlacklistRequest 导出请求过滤条件测试
+func TestExportBlacklistRequest(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	active := true
+
+	req := dto.ExportBlacklistRequest{Format: "csv", Active: &active, CreatedFrom: &from, CreatedTo: &to}
+	assert.True(t, req.ValidRange())
+	assert.Equal(t, map[string]interface{}{
+		"active":       true,
+		"created_from": "2025-01-01T00:00:00Z",
+		"created_to":   "2025-01-02T00:00:00Z",
+	}, req.AuditFilters(), "未指定的条件不记录")
+
+	req = dto.ExportBlacklistRequest{CreatedFrom: &to, CreatedTo: &from}
+	assert.False(t, req.ValidRange())
+}
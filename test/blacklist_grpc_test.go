@@ -0,0 +1,454 @@
+// Package test contains unit tests for the blacklist gRPC service.
+package test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	blacklistv1 "github.com/varluffy/shield/api/proto/blacklist/v1"
+	"github.com/varluffy/shield/internal/config"
+	"github.com/varluffy/shield/internal/grpcserver"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/services"
+	"github.com/varluffy/shield/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	grpcTestAPIKey    = "ak_grpc_test"
+	grpcTestAPISecret = "grpc-test-secret"
+	grpcTestHitHash   = "5d41402abc4b2a76b9719d911017c592"
+	grpcTestLowHash   = "7d793037a0760186574b0282f2f435e7"
+	grpcTestMissHash  = "098f6bcd4621d373cade4e832627b4f6"
+	// 租户名单命中低于阈值，共享名单命中
+	grpcTestSharedHash = "827ccb0eea8a706c4c34a16891f84e7b"
+	// 在白名单中，分别高于和低于风险分阈值
+	grpcTestAllowedHash    = "fcea920f7412b5da7be0cf42b8c93759"
+	grpcTestLowAllowedHash = "c33367701511b4f6020ec61ded352059"
+	// 查询时分别返回服务暂不可用和普通错误
+	grpcTestUnavailableHash = "e10adc3949ba59abbe56e057f20f883e"
+	grpcTestFailedHash      = "25d55ad283aa400af464c76d713c07ad"
+)
+
+// fakeGRPCBlacklistService 按哈希返回固定的查询结果，记录查询统计，同时作为名单服务记录请求的名单选择
+type fakeGRPCBlacklistService struct {
+	services.BlacklistService
+	services.ListService
+
+	mu        sync.Mutex
+	metrics   []bool
+	selectors [][]string
+}
+
+func (s *fakeGRPCBlacklistService) result(hash string) *services.CheckResult {
+	switch hash {
+	case grpcTestHitHash:
+		return &services.CheckResult{Hit: true, Category: "fraud", RiskScore: 90, Lists: []services.ListHit{
+			{List: "loan_fraud", Category: "fraud", RiskScore: 90},
+			{List: "spam", Category: "spam", RiskScore: 30},
+		}}
+	case grpcTestLowHash:
+		return &services.CheckResult{Hit: true, Category: "spam", RiskScore: 30}
+	case grpcTestAllowedHash:
+		return &services.CheckResult{Overridden: true, OverriddenRiskScore: 90}
+	case grpcTestLowAllowedHash:
+		return &services.CheckResult{Overridden: true, OverriddenRiskScore: 30}
+	case grpcTestSharedHash:
+		return &services.CheckResult{Hit: true, Category: "fraud", RiskScore: 80, Lists: []services.ListHit{
+			{List: "spam", Category: "spam", RiskScore: 30},
+			{Shared: true, Category: "fraud", RiskScore: 80},
+		}}
+	}
+	return &services.CheckResult{}
+}
+
+func (s *fakeGRPCBlacklistService) ResolveCheckLists(ctx context.Context, tenantID uint64, credential *models.BlacklistApiCredential, selector []string) ([]*models.BlacklistList, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.selectors = append(s.selectors, selector)
+	for _, name := range selector {
+		if name == "missing" {
+			return nil, errors.NewBusinessError(errors.CodeNotFound, "查询的名单不存在")
+		}
+	}
+	return nil, nil
+}
+
+func (s *fakeGRPCBlacklistService) CheckIdentifier(ctx context.Context, tenantID uint64, identifierType, hashType, hash string, lists []*models.BlacklistList) (*services.CheckResult, error) {
+	switch hash {
+	case grpcTestUnavailableHash:
+		return nil, errors.NewBusinessError(errors.CodeServiceUnavailable, "条目过多，无法计算HMAC")
+	case grpcTestFailedHash:
+		return nil, fmt.Errorf("数据库连接失败")
+	}
+	return s.result(hash), nil
+}
+
+func (s *fakeGRPCBlacklistService) CheckIdentifierBatch(ctx context.Context, tenantID uint64, identifierType, hashType string, hashList []string, lists []*models.BlacklistList) (map[string]*services.CheckResult, error) {
+	results := make(map[string]*services.CheckResult, len(hashList))
+	for _, hash := range hashList {
+		results[hash] = s.result(hash)
+	}
+	return results, nil
+}
+
+func (s *fakeGRPCBlacklistService) UpdateQueryMetrics(ctx context.Context, tenantID uint64, apiKey string, isHit bool, latencyMs int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metrics = append(s.metrics, isHit)
+}
+
+func (s *fakeGRPCBlacklistService) metricCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.metrics)
+}
+
+// fakeGRPCAuthService 使用固定密钥验证签名，按调用次数限流
+type fakeGRPCAuthService struct {
+	services.BlacklistAuthService
+
+	mu        sync.Mutex
+	rateLimit int
+	calls     int
+	bodies    []string
+	logs      []*services.QueryLogRecord
+}
+
+func (s *fakeGRPCAuthService) ValidateHMACSignature(ctx context.Context, apiKey, timestamp, nonce, signature, body string) (*models.BlacklistApiCredential, error) {
+	s.mu.Lock()
+	s.bodies = append(s.bodies, body)
+	s.mu.Unlock()
+	if apiKey != grpcTestAPIKey || signature != grpcHMAC(apiKey+timestamp+nonce+body) {
+		return nil, fmt.Errorf("签名验证失败")
+	}
+	return &models.BlacklistApiCredential{
+		TenantModel:   models.TenantModel{TenantID: 1},
+		APIKey:        apiKey,
+		APISecret:     grpcTestAPISecret,
+		IPWhitelist:   "10.0.0.1",
+		MinRiskScore:  50,
+		LogSampleRate: 1,
+	}, nil
+}
+
+func (s *fakeGRPCAuthService) IsIPAllowed(clientIP, whitelist string) bool {
+	return clientIP == whitelist
+}
+
+func (s *fakeGRPCAuthService) CheckRateLimit(ctx context.Context, apiKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.rateLimit > 0 && s.calls > s.rateLimit {
+		return fmt.Errorf("超出速率限制")
+	}
+	return nil
+}
+
+func (s *fakeGRPCAuthService) UpdateAPIKeyUsage(ctx context.Context, apiKey string) error {
+	return nil
+}
+
+func (s *fakeGRPCAuthService) RecordQueryLog(ctx context.Context, record *services.QueryLogRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logs = append(s.logs, record)
+}
+
+// fakeGRPCWebhookService 记录发布的事件
+type fakeGRPCWebhookService struct {
+	services.WebhookService
+
+	mu   sync.Mutex
+	hits []services.WebhookHitData
+}
+
+func (s *fakeGRPCWebhookService) Publish(ctx context.Context, tenantID uint64, eventType string, data interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if hit, ok := data.(services.WebhookHitData); ok && eventType == models.WebhookEventHit {
+		s.hits = append(s.hits, hit)
+	}
+}
+
+// grpcHMAC 使用测试密钥计算签名
+func grpcHMAC(message string) string {
+	h := hmac.New(sha256.New, []byte(grpcTestAPISecret))
+	h.Write([]byte(message))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// grpcPayload 请求消息的签名内容，建立流时为空
+func grpcPayload(req proto.Message) string {
+	if req == nil {
+		return ""
+	}
+	data, err := proto.Marshal(req)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+// signedContext 构造携带鉴权元数据的调用上下文，签名覆盖请求消息，建立流时req为nil
+func signedContext(fullMethod, clientIP string, req proto.Message) context.Context {
+	ctx, _ := signedStreamContext(fullMethod, clientIP, req)
+	return ctx
+}
+
+// signedStreamContext 构造携带鉴权元数据的调用上下文，同时返回nonce用于流中请求签名
+func signedStreamContext(fullMethod, clientIP string, req proto.Message) (context.Context, string) {
+	timestamp := fmt.Sprintf("%d", time.Now().Unix())
+	nonce := models.GenerateUUID()
+	ctx := metadata.AppendToOutgoingContext(context.Background(),
+		"x-api-key", grpcTestAPIKey,
+		"x-timestamp", timestamp,
+		"x-nonce", nonce,
+		"x-signature", grpcHMAC(grpcTestAPIKey+timestamp+nonce+fullMethod+grpcPayload(req)),
+		"x-real-ip", clientIP,
+	)
+	return ctx, nonce
+}
+
+// signStreamRequest 为流中第sequence条请求签名
+func signStreamRequest(nonce string, sequence int, req *blacklistv1.CheckRequest) *blacklistv1.CheckRequest {
+	req.Signature = grpcHMAC(nonce + fmt.Sprint(sequence) + grpcPayload(req))
+	return req
+}
+
+// TestBlacklistGRPC 黑名单gRPC服务测试
+func TestBlacklistGRPC(t *testing.T) {
+	testLogger, err := NewTestLogger()
+	require.NoError(t, err)
+
+	newClient := func(t *testing.T, auth *fakeGRPCAuthService) (blacklistv1.BlacklistServiceClient, *fakeGRPCBlacklistService, *fakeGRPCWebhookService) {
+		blacklistService := &fakeGRPCBlacklistService{}
+		webhookService := &fakeGRPCWebhookService{}
+		cfg := &config.Config{GRPC: &config.GRPCConfig{Enabled: true}}
+		server := grpcserver.NewServer(cfg, blacklistService, blacklistService, auth, webhookService, testLogger)
+
+		lis := bufconn.Listen(1 << 20)
+		go func() { _ = server.Serve(lis) }()
+		t.Cleanup(func() { server.Stop(context.Background()) })
+
+		conn, err := grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return lis.DialContext(ctx)
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		)
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return blacklistv1.NewBlacklistServiceClient(conn), blacklistService, webhookService
+	}
+
+	t.Run("Test Check Applies Min Risk Score", func(t *testing.T) {
+		auth := &fakeGRPCAuthService{}
+		client, blacklistService, webhookService := newClient(t, auth)
+
+		req := &blacklistv1.CheckRequest{IdentifierHash: grpcTestHitHash, CorrelationId: "c-1"}
+		ctx := signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.1", req)
+		var header metadata.MD
+		resp, err := client.Check(ctx, req, grpc.Header(&header))
+		require.NoError(t, err)
+		assert.True(t, resp.IsBlacklist)
+		assert.Equal(t, "fraud", resp.Category)
+		assert.Equal(t, int32(90), resp.RiskScore)
+		assert.Equal(t, models.IdentifierTypePhone, resp.IdentifierType)
+		assert.Equal(t, "c-1", resp.CorrelationId)
+		assert.Equal(t, []string{resp.RequestId}, header.Get("x-request-id"))
+
+		req = &blacklistv1.CheckRequest{IdentifierHash: grpcTestLowHash}
+		ctx = signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.1", req)
+		resp, err = client.Check(ctx, req)
+		require.NoError(t, err)
+		assert.False(t, resp.IsBlacklist)
+		assert.Empty(t, resp.Category)
+
+		require.Eventually(t, func() bool { return blacklistService.metricCount() == 2 }, time.Second, 10*time.Millisecond)
+		assert.Len(t, webhookService.hits, 1)
+		assert.Len(t, auth.logs, 2)
+		assert.Equal(t, 200, auth.logs[0].StatusCode)
+		assert.Equal(t, []string{blacklistv1.BlacklistService_Check_FullMethodName + grpcPayload(&blacklistv1.CheckRequest{IdentifierHash: grpcTestHitHash, CorrelationId: "c-1"})}, auth.bodies[:1])
+	})
+
+	t.Run("Test Check Rejects Invalid Calls", func(t *testing.T) {
+		client, _, _ := newClient(t, &fakeGRPCAuthService{})
+
+		_, err := client.Check(context.Background(), &blacklistv1.CheckRequest{IdentifierHash: grpcTestHitHash})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		// 为其他方法生成的签名不能用于本方法
+		req := &blacklistv1.CheckRequest{IdentifierHash: grpcTestHitHash}
+		ctx := signedContext(blacklistv1.BlacklistService_CheckBatch_FullMethodName, "10.0.0.1", req)
+		_, err = client.Check(ctx, req)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		// 签名覆盖请求消息，不能替换为其他请求
+		ctx = signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.1", req)
+		_, err = client.Check(ctx, &blacklistv1.CheckRequest{IdentifierHash: grpcTestMissHash})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		ctx = signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.2", req)
+		_, err = client.Check(ctx, req)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+		req = &blacklistv1.CheckRequest{IdentifierHash: "not-a-hash"}
+		ctx = signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.1", req)
+		_, err = client.Check(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Test Check Selects Lists", func(t *testing.T) {
+		auth := &fakeGRPCAuthService{}
+		client, blacklistService, _ := newClient(t, auth)
+
+		req := &blacklistv1.CheckRequest{IdentifierHash: grpcTestHitHash, Lists: []string{"loan_fraud", "spam"}}
+		resp, err := client.Check(signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.1", req), req)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loan_fraud"}, resp.HitLists, "低于风险分阈值的名单不返回")
+		assert.Equal(t, models.HitSourceTenant, resp.HitSource)
+		assert.Equal(t, []string{"loan_fraud", "spam"}, blacklistService.selectors[0])
+
+		batchReq := &blacklistv1.CheckBatchRequest{IdentifierHashes: []string{grpcTestHitHash, grpcTestMissHash}, Lists: []string{"loan_fraud"}}
+		batchResp, err := client.CheckBatch(signedContext(blacklistv1.BlacklistService_CheckBatch_FullMethodName, "10.0.0.1", batchReq), batchReq)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"loan_fraud"}, batchResp.Results[0].HitLists)
+		assert.Empty(t, batchResp.Results[1].HitLists)
+		assert.Empty(t, batchResp.Results[1].HitSource)
+		assert.Equal(t, []string{"loan_fraud"}, blacklistService.selectors[1])
+
+		req = &blacklistv1.CheckRequest{IdentifierHash: grpcTestHitHash, Lists: []string{"missing"}}
+		_, err = client.Check(signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.1", req), req)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		req = &blacklistv1.CheckRequest{IdentifierHash: grpcTestHitHash, Lists: make([]string, 21)}
+		_, err = client.Check(signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.1", req), req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Test Check Returns Hit Source", func(t *testing.T) {
+		client, _, _ := newClient(t, &fakeGRPCAuthService{})
+
+		req := &blacklistv1.CheckRequest{IdentifierHash: grpcTestSharedHash}
+		resp, err := client.Check(signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.1", req), req)
+		require.NoError(t, err)
+		assert.True(t, resp.IsBlacklist)
+		assert.Equal(t, models.HitSourceShared, resp.HitSource, "低于风险分阈值的租户名单命中不计入来源")
+		assert.Empty(t, resp.HitLists)
+	})
+
+	t.Run("Test Check Reports Overridden Above Min Risk Score", func(t *testing.T) {
+		client, _, _ := newClient(t, &fakeGRPCAuthService{})
+
+		req := &blacklistv1.CheckBatchRequest{IdentifierHashes: []string{grpcTestAllowedHash, grpcTestLowAllowedHash}}
+		resp, err := client.CheckBatch(signedContext(blacklistv1.BlacklistService_CheckBatch_FullMethodName, "10.0.0.1", req), req)
+		require.NoError(t, err)
+		assert.False(t, resp.Results[0].IsBlacklist)
+		assert.True(t, resp.Results[0].Overridden)
+		assert.False(t, resp.Results[1].Overridden, "低于风险分阈值的命中不应报告豁免")
+	})
+
+	t.Run("Test Check Maps Business Errors", func(t *testing.T) {
+		auth := &fakeGRPCAuthService{}
+		client, _, _ := newClient(t, auth)
+
+		req := &blacklistv1.CheckRequest{IdentifierHash: grpcTestUnavailableHash}
+		_, err := client.Check(signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.1", req), req)
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Equal(t, "条目过多，无法计算HMAC", status.Convert(err).Message())
+
+		req = &blacklistv1.CheckRequest{IdentifierHash: grpcTestFailedHash}
+		_, err = client.Check(signedContext(blacklistv1.BlacklistService_Check_FullMethodName, "10.0.0.1", req), req)
+		assert.Equal(t, codes.Internal, status.Code(err))
+
+		require.Len(t, auth.logs, 2)
+		assert.Equal(t, 503, auth.logs[0].StatusCode, "查询日志记录实际的状态码")
+		assert.Equal(t, 500, auth.logs[1].StatusCode)
+	})
+
+	t.Run("Test Check Batch Keeps Request Order", func(t *testing.T) {
+		client, blacklistService, _ := newClient(t, &fakeGRPCAuthService{})
+
+		hashes := []string{grpcTestMissHash, grpcTestHitHash, grpcTestLowHash}
+		req := &blacklistv1.CheckBatchRequest{IdentifierHashes: hashes}
+		ctx := signedContext(blacklistv1.BlacklistService_CheckBatch_FullMethodName, "10.0.0.1", req)
+		resp, err := client.CheckBatch(ctx, req)
+		require.NoError(t, err)
+		require.Len(t, resp.Results, 3)
+		for i, result := range resp.Results {
+			assert.Equal(t, hashes[i], result.IdentifierHash)
+		}
+		assert.Equal(t, []bool{false, true, false}, []bool{resp.Results[0].IsBlacklist, resp.Results[1].IsBlacklist, resp.Results[2].IsBlacklist})
+		assert.NotEmpty(t, resp.RequestId)
+		require.Eventually(t, func() bool { return blacklistService.metricCount() == 1 }, time.Second, 10*time.Millisecond)
+
+		tooMany := make([]string, 101)
+		for i := range tooMany {
+			tooMany[i] = grpcTestMissHash
+		}
+		req = &blacklistv1.CheckBatchRequest{IdentifierHashes: tooMany}
+		ctx = signedContext(blacklistv1.BlacklistService_CheckBatch_FullMethodName, "10.0.0.1", req)
+		_, err = client.CheckBatch(ctx, req)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Test Check Stream Rate Limits Each Message", func(t *testing.T) {
+		auth := &fakeGRPCAuthService{rateLimit: 2}
+		client, blacklistService, _ := newClient(t, auth)
+
+		ctx, nonce := signedStreamContext(blacklistv1.BlacklistService_CheckStream_FullMethodName, "10.0.0.1", nil)
+		stream, err := client.CheckStream(ctx)
+		require.NoError(t, err)
+
+		for i, hash := range []string{grpcTestHitHash, grpcTestMissHash} {
+			req := &blacklistv1.CheckRequest{IdentifierHash: hash, CorrelationId: fmt.Sprint(i)}
+			require.NoError(t, stream.Send(signStreamRequest(nonce, i+1, req)))
+			resp, err := stream.Recv()
+			require.NoError(t, err)
+			assert.Equal(t, fmt.Sprint(i), resp.CorrelationId)
+			assert.Equal(t, hash == grpcTestHitHash, resp.IsBlacklist)
+		}
+
+		require.NoError(t, stream.Send(signStreamRequest(nonce, 3, &blacklistv1.CheckRequest{IdentifierHash: grpcTestHitHash})))
+		_, err = stream.Recv()
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		require.Eventually(t, func() bool { return blacklistService.metricCount() == 2 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Test Check Stream Verifies Each Message", func(t *testing.T) {
+		client, blacklistService, _ := newClient(t, &fakeGRPCAuthService{})
+
+		ctx, nonce := signedStreamContext(blacklistv1.BlacklistService_CheckStream_FullMethodName, "10.0.0.1", nil)
+		stream, err := client.CheckStream(ctx)
+		require.NoError(t, err)
+
+		require.NoError(t, stream.Send(signStreamRequest(nonce, 1, &blacklistv1.CheckRequest{IdentifierHash: grpcTestMissHash})))
+		_, err = stream.Recv()
+		require.NoError(t, err)
+
+		// 签名绑定请求内容：为其他请求生成的签名不能用于本请求
+		req := signStreamRequest(nonce, 2, &blacklistv1.CheckRequest{IdentifierHash: grpcTestMissHash})
+		req.IdentifierHash = grpcTestHitHash
+		require.NoError(t, stream.Send(req))
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		require.Eventually(t, func() bool { return blacklistService.metricCount() == 1 }, time.Second, 10*time.Millisecond)
+	})
+}
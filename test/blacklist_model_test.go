@@ -0,0 +1,36 @@
+// Package test contains unit tests for blacklist model schema.
+package test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/varluffy/shield/internal/models"
+	"gorm.io/gorm/schema"
+)
+
+// TestPhoneBlacklistUniqueIndex AutoMigrate建立的唯一键与迁移脚本一致，同一标识可在不同租户中存在
+func TestPhoneBlacklistUniqueIndex(t *testing.T) {
+	s, err := schema.Parse(&models.PhoneBlacklist{}, &sync.Map{}, schema.NamingStrategy{})
+	require.NoError(t, err)
+
+	var columns []string
+	for _, index := range s.ParseIndexes() {
+		if index.Name != "uk_tenant_identifier" {
+			continue
+		}
+		assert.Equal(t, "UNIQUE", index.Class)
+		for _, field := range index.Fields {
+			columns = append(columns, field.DBName)
+		}
+	}
+	assert.Equal(t, []string{"tenant_id", "identifier_type", "phone_md5", "identifier_sha256", "list_id"}, columns)
+
+	// tenant_id仍由TenantModel读写
+	field := s.LookUpField("tenant_id")
+	require.NotNil(t, field)
+	assert.Equal(t, []string{"TenantModel", "TenantID"}, field.BindNames)
+	assert.True(t, field.Creatable)
+}
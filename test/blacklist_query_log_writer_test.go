@@ -0,0 +1,100 @@
+// Package test contains unit tests for the blacklist query log writer.
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/internal/services"
+)
+
+// queryLogRecorder 记录写入的查询日志，block关闭前阻塞写入，写入器只调用BatchCreate
+type queryLogRecorder struct {
+	repositories.BlacklistQueryLogRepository
+
+	mu      sync.Mutex
+	batches [][]*models.BlacklistQueryLog
+	block   chan struct{}
+}
+
+func (r *queryLogRecorder) BatchCreate(ctx context.Context, logs []*models.BlacklistQueryLog) error {
+	if r.block != nil {
+		<-r.block
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, append([]*models.BlacklistQueryLog(nil), logs...))
+	return nil
+}
+
+func (r *queryLogRecorder) total() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	total := 0
+	for _, batch := range r.batches {
+		total += len(batch)
+	}
+	return total
+}
+
+// TestQueryLogWriter 查询日志写入器测试
+func TestQueryLogWriter(t *testing.T) {
+	testLogger, err := NewTestLogger()
+	require.NoError(t, err)
+
+	newLog := func(hash string) *models.BlacklistQueryLog {
+		return &models.BlacklistQueryLog{TenantID: 1, APIKey: "ak_test", IdentifierHash: hash}
+	}
+
+	t.Run("Test Batches And Flushes On Close", func(t *testing.T) {
+		repo := &queryLogRecorder{}
+		writer := services.NewQueryLogWriterWithOptions(repo, testLogger, services.QueryLogWriterOptions{
+			QueueSize:     100,
+			BatchSize:     3,
+			FlushInterval: time.Hour,
+		})
+
+		for i := 0; i < 7; i++ {
+			assert.True(t, writer.Write(newLog("hash")))
+		}
+
+		require.NoError(t, writer.Close(context.Background()))
+		assert.Equal(t, 7, repo.total(), "关闭时应写入队列中剩余的日志")
+		for _, batch := range repo.batches {
+			assert.LessOrEqual(t, len(batch), 3)
+		}
+		assert.Equal(t, int64(7), writer.Stats().Written)
+
+		assert.False(t, writer.Write(newLog("hash")), "关闭后应丢弃新日志")
+		assert.Equal(t, int64(1), writer.Stats().Dropped)
+	})
+
+	t.Run("Test Drops When Queue Is Full", func(t *testing.T) {
+		repo := &queryLogRecorder{block: make(chan struct{})}
+		writer := services.NewQueryLogWriterWithOptions(repo, testLogger, services.QueryLogWriterOptions{
+			QueueSize:     2,
+			BatchSize:     1,
+			FlushInterval: time.Hour,
+		})
+
+		// 第一条被写入goroutine取出后阻塞在数据库写入，队列最多再容纳2条
+		accepted := 0
+		for i := 0; i < 10; i++ {
+			if writer.Write(newLog("hash")) {
+				accepted++
+			}
+		}
+		assert.Less(t, accepted, 10, "队列已满时不应阻塞")
+		assert.Equal(t, int64(10-accepted), writer.Stats().Dropped)
+
+		close(repo.block)
+		require.NoError(t, writer.Close(context.Background()))
+		assert.Equal(t, accepted, repo.total())
+	})
+}
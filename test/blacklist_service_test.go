@@ -2,14 +2,26 @@
 package test
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/internal/services"
+	"github.com/varluffy/shield/pkg/errors"
+	redisClient "github.com/varluffy/shield/pkg/redis"
+	"github.com/varluffy/shield/pkg/sheet"
 )
 
 // TestBlacklistServiceUnitTests 黑名单服务单元测试
@@ -42,6 +54,15 @@ func TestBlacklistServiceUnitTests(t *testing.T) {
 		return fmt.Sprintf("%x", hash)
 	}
 
+	// 辅助函数：将MD5列表转换为导入条目
+	md5Items := func(md5List ...string) []services.IdentifierHashes {
+		items := make([]services.IdentifierHashes, 0, len(md5List))
+		for _, valueMD5 := range md5List {
+			items = append(items, services.IdentifierHashes{MD5: valueMD5})
+		}
+		return items
+	}
+
 	t.Run("Test CreateBlacklist Success", func(t *testing.T) {
 		ctx := context.Background()
 
@@ -102,7 +123,13 @@ func TestBlacklistServiceUnitTests(t *testing.T) {
 			generatePhoneMD5("13800138013"),
 		}
 
-		err := components.BlacklistService.BatchImportBlacklist(ctx, 1, phoneMD5List, "batch_import", "批量测试导入", 1)
+		_, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   1,
+			Items:      md5Items(phoneMD5List...),
+			Source:     "batch_import",
+			Reason:     "批量测试导入",
+			OperatorID: 1,
+		})
 		require.NoError(t, err)
 
 		// 验证批量导入的数据
@@ -113,6 +140,464 @@ func TestBlacklistServiceUnitTests(t *testing.T) {
 		}
 	})
 
+	t.Run("Test Expired Entry Not Hit", func(t *testing.T) {
+		ctx := context.Background()
+
+		phoneMD5 := generatePhoneMD5("13800138031")
+		expiresAt := time.Now().Add(-time.Minute)
+		blacklist := &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: 1},
+			PhoneMD5:    phoneMD5,
+			Source:      "manual",
+			Reason:      "测试过期",
+			OperatorID:  1,
+			IsActive:    true,
+			ExpiresAt:   &expiresAt,
+		}
+		err := components.BlacklistService.CreateBlacklist(ctx, blacklist)
+		require.NoError(t, err)
+
+		isBlacklisted, err := components.BlacklistService.CheckPhoneMD5(ctx, 1, phoneMD5)
+		require.NoError(t, err)
+		assert.False(t, isBlacklisted, "已过期的记录不应该命中")
+
+		results, err := components.BlacklistService.CheckPhoneMD5Batch(ctx, 1, []string{phoneMD5})
+		require.NoError(t, err)
+		assert.False(t, results[phoneMD5], "已过期的记录批量查询不应该命中")
+
+		md5List, err := components.BlacklistRepo.GetActiveMD5ListByTenant(ctx, 1, models.IdentifierTypePhone)
+		require.NoError(t, err)
+		assert.NotContains(t, md5List, phoneMD5, "有效列表不应包含已过期记录")
+	})
+
+	t.Run("Test Re-add Expired Entry", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(28)
+
+		// 过期并被清理任务标记为无效的记录仍占用唯一键，重新添加时恢复该记录
+		phoneMD5 := generatePhoneMD5("13800138033")
+		expiresAt := time.Now().Add(-time.Minute)
+		expired := &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    phoneMD5,
+			Source:      "manual",
+			Reason:      "测试过期后重新添加",
+			OperatorID:  1,
+			IsActive:    true,
+			ExpiresAt:   &expiresAt,
+		}
+		require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, expired))
+		require.NoError(t, components.BlacklistRepo.DeactivateExpiredByIDs(ctx, []uint64{expired.ID}, time.Now()))
+
+		readded := &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    phoneMD5,
+			Source:      "manual",
+			Reason:      "重新添加",
+			OperatorID:  1,
+			IsActive:    true,
+		}
+		require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, readded), "过期条目应可重新添加")
+		assert.Equal(t, expired.ID, readded.ID, "应恢复原记录")
+
+		isBlacklisted, err := components.BlacklistService.CheckPhoneMD5(ctx, tenantID, phoneMD5)
+		require.NoError(t, err)
+		assert.True(t, isBlacklisted, "重新添加的永久条目应该命中")
+
+		// 过期但尚未清理的记录通过批量导入重新添加
+		importMD5 := generatePhoneMD5("13800138034")
+		require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    importMD5,
+			Source:      "manual",
+			Reason:      "测试过期后重新导入",
+			OperatorID:  1,
+			IsActive:    true,
+			ExpiresAt:   &expiresAt,
+		}))
+		result, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   tenantID,
+			Items:      md5Items(importMD5, generatePhoneMD5("13800138035")),
+			Source:     "batch_import",
+			Reason:     "重新导入",
+			OperatorID: 1,
+		})
+		require.NoError(t, err, "过期条目不应导致整批导入失败")
+		assert.Equal(t, 2, result.Created)
+
+		isBlacklisted, err = components.BlacklistService.CheckPhoneMD5(ctx, tenantID, importMD5)
+		require.NoError(t, err)
+		assert.True(t, isBlacklisted, "重新导入的条目应该命中")
+	})
+
+	t.Run("Test Unexpired Entry Hit", func(t *testing.T) {
+		ctx := context.Background()
+
+		phoneMD5 := generatePhoneMD5("13800138032")
+		expiresAt := time.Now().Add(time.Hour)
+		_, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   1,
+			Items:      md5Items(phoneMD5),
+			Source:     "batch_import",
+			Reason:     "测试未过期",
+			OperatorID: 1,
+			ExpiresAt:  &expiresAt,
+		})
+		require.NoError(t, err)
+
+		isBlacklisted, err := components.BlacklistService.CheckPhoneMD5(ctx, 1, phoneMD5)
+		require.NoError(t, err)
+		assert.True(t, isBlacklisted, "未过期的记录应该命中")
+	})
+
+	t.Run("Test Identifier Types Isolated", func(t *testing.T) {
+		ctx := context.Background()
+
+		// 同一MD5在不同标识类型下互不影响
+		valueMD5 := generatePhoneMD5("110101199003070011")
+		blacklist := &models.PhoneBlacklist{
+			TenantModel:    models.TenantModel{TenantID: 1},
+			IdentifierType: models.IdentifierTypeIDCard,
+			PhoneMD5:       valueMD5,
+			Source:         "manual",
+			Reason:         "测试身份证",
+			OperatorID:     1,
+			IsActive:       true,
+		}
+		err := components.BlacklistService.CreateBlacklist(ctx, blacklist)
+		require.NoError(t, err)
+
+		result, err := components.BlacklistService.CheckIdentifier(ctx, 1, models.IdentifierTypeIDCard, models.HashTypeMD5, valueMD5, nil)
+		require.NoError(t, err)
+		assert.True(t, result.Hit, "身份证类型应该命中")
+
+		isBlacklisted, err := components.BlacklistService.CheckPhoneMD5(ctx, 1, valueMD5)
+		require.NoError(t, err)
+		assert.False(t, isBlacklisted, "手机号类型不应该命中")
+
+		_, err = components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:       1,
+			IdentifierType: models.IdentifierTypeEmail,
+			Items:          md5Items(generatePhoneMD5("test@example.com")),
+			Source:         "batch_import",
+			OperatorID:     1,
+		})
+		require.NoError(t, err)
+
+		results, err := components.BlacklistService.CheckIdentifierBatch(ctx, 1, models.IdentifierTypeEmail, models.HashTypeMD5,
+			[]string{generatePhoneMD5("test@example.com"), valueMD5}, nil)
+		require.NoError(t, err)
+		assert.True(t, results[generatePhoneMD5("test@example.com")].Hit)
+		assert.False(t, results[valueMD5].Hit)
+	})
+
+	t.Run("Test SHA256 And HMAC Formats", func(t *testing.T) {
+		ctx := context.Background()
+
+		hashes := services.NewIdentifierHashes("13800138041")
+		blacklist := &models.PhoneBlacklist{
+			TenantModel:      models.TenantModel{TenantID: 1},
+			IdentifierSHA256: hashes.SHA256,
+			Source:           "manual",
+			Reason:           "测试SHA-256",
+			OperatorID:       1,
+			IsActive:         true,
+		}
+		err := components.BlacklistService.CreateBlacklist(ctx, blacklist)
+		require.NoError(t, err)
+
+		result, err := components.BlacklistService.CheckIdentifier(ctx, 1, models.IdentifierTypePhone, models.HashTypeSHA256, hashes.SHA256, nil)
+		require.NoError(t, err)
+		assert.True(t, result.Hit, "SHA-256格式应该命中")
+
+		salt, err := components.BlacklistService.GetHashSalt(ctx, 1)
+		require.NoError(t, err)
+		mac := hmac.New(sha256.New, []byte(salt))
+		mac.Write([]byte(hashes.SHA256))
+		hmacHash := hex.EncodeToString(mac.Sum(nil))
+
+		result, err = components.BlacklistService.CheckIdentifier(ctx, 1, models.IdentifierTypePhone, models.HashTypeHMACSHA256, hmacHash, nil)
+		require.NoError(t, err)
+		assert.True(t, result.Hit, "HMAC-SHA256格式应该命中")
+		assert.Equal(t, models.DefaultRiskScore, result.RiskScore)
+		assert.Empty(t, result.Category, "HMAC成员风险信息中的SHA-256不应作为风险分类返回")
+
+		// 轮换租户盐后旧的HMAC不再命中
+		_, err = components.BlacklistService.RotateHashSalt(ctx, 1)
+		require.NoError(t, err)
+		result, err = components.BlacklistService.CheckIdentifier(ctx, 1, models.IdentifierTypePhone, models.HashTypeHMACSHA256, hmacHash, nil)
+		require.NoError(t, err)
+		assert.False(t, result.Hit, "轮换租户盐后旧HMAC不应该命中")
+	})
+
+	t.Run("Test Backfill SHA256 For Legacy MD5", func(t *testing.T) {
+		ctx := context.Background()
+
+		hashes := services.NewIdentifierHashes("13800138042")
+		_, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   1,
+			Items:      md5Items(hashes.MD5),
+			Source:     "batch_import",
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+
+		// 成对导入时补充历史条目而不是新增
+		result, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   1,
+			Items:      []services.IdentifierHashes{hashes},
+			Source:     "batch_import",
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Created)
+		assert.Equal(t, 1, result.Backfilled)
+
+		checkResult, err := components.BlacklistService.CheckIdentifier(ctx, 1, models.IdentifierTypePhone, models.HashTypeSHA256, hashes.SHA256, nil)
+		require.NoError(t, err)
+		assert.True(t, checkResult.Hit, "补充后SHA-256格式应该命中")
+
+		isBlacklisted, err := components.BlacklistService.CheckPhoneMD5(ctx, 1, hashes.MD5)
+		require.NoError(t, err)
+		assert.True(t, isBlacklisted, "补充后MD5格式仍应命中")
+	})
+
+	t.Run("Test Risk Category And Score", func(t *testing.T) {
+		ctx := context.Background()
+
+		fraudMD5 := generatePhoneMD5("13800138051")
+		_, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   1,
+			Items:      md5Items(fraudMD5),
+			Source:     "batch_import",
+			Category:   models.RiskCategoryFraud,
+			RiskScore:  90,
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+
+		defaultMD5 := generatePhoneMD5("13800138052")
+		err = components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: 1},
+			PhoneMD5:    defaultMD5,
+			Source:      "manual",
+			OperatorID:  1,
+			IsActive:    true,
+		})
+		require.NoError(t, err)
+
+		result, err := components.BlacklistService.CheckIdentifier(ctx, 1, models.IdentifierTypePhone, models.HashTypeMD5, fraudMD5, nil)
+		require.NoError(t, err)
+		assert.True(t, result.Hit)
+		assert.Equal(t, models.RiskCategoryFraud, result.Category)
+		assert.Equal(t, 90, result.RiskScore)
+
+		results, err := components.BlacklistService.CheckIdentifierBatch(ctx, 1, models.IdentifierTypePhone, models.HashTypeMD5,
+			[]string{fraudMD5, defaultMD5}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, 90, results[fraudMD5].RiskScore)
+		assert.Equal(t, models.DefaultRiskScore, results[defaultMD5].RiskScore, "未指定风险分时使用默认值")
+		assert.Empty(t, results[defaultMD5].Category)
+	})
+
+	t.Run("Test ImportBlacklistFile Row Report", func(t *testing.T) {
+		ctx := context.Background()
+
+		existingMD5 := generatePhoneMD5("13800138061")
+		_, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   1,
+			Items:      md5Items(existingMD5),
+			Source:     "batch_import",
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+
+		data := []byte("姓名,手机号\n甲,+86 138-0013-8060\n乙,13800138061\n丙,138 0013 8060\n丁,12345\n戊,\n")
+		rows, err := sheet.NewReader(bytes.NewReader(data), int64(len(data)), "phones.csv")
+		require.NoError(t, err)
+
+		result, err := components.BlacklistService.ImportBlacklistFile(ctx, &services.FileImportParams{
+			TenantID:   1,
+			Rows:       rows,
+			ColumnName: "手机号",
+			Source:     "file_import",
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Accepted)
+		assert.Equal(t, 2, result.Duplicate)
+		assert.Equal(t, 2, result.Invalid)
+		assert.Equal(t, 1, result.Backfilled, "已存在的历史MD5条目应补充SHA-256")
+
+		statuses := make(map[int]string, len(result.Rows))
+		for _, row := range result.Rows {
+			statuses[row.Row] = row.Status
+		}
+		assert.Equal(t, map[int]string{
+			2: services.ImportRowAccepted,
+			3: services.ImportRowDuplicate,
+			4: services.ImportRowDuplicate,
+			5: services.ImportRowInvalid,
+			6: services.ImportRowInvalid,
+		}, statuses)
+
+		hashes := services.NewIdentifierHashes("13800138060")
+		checkResult, err := components.BlacklistService.CheckIdentifier(ctx, 1, models.IdentifierTypePhone, models.HashTypeSHA256, hashes.SHA256, nil)
+		require.NoError(t, err)
+		assert.True(t, checkResult.Hit, "导入的手机号应同时支持SHA-256查询")
+	})
+
+	t.Run("Test ExportBlacklist With Filters", func(t *testing.T) {
+		ctx := context.Background()
+
+		_, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   1,
+			Items:      md5Items(generatePhoneMD5("13800138071"), generatePhoneMD5("13800138072")),
+			Source:     "export_test",
+			Category:   models.RiskCategoryFraud,
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		exported, err := components.BlacklistService.ExportBlacklist(ctx, &services.ExportParams{
+			TenantID: 1,
+			Format:   services.ExportFormatCSV,
+			Source:   "export_test",
+		}, &buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), exported)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 3, "表头加两条记录")
+		assert.True(t, strings.HasPrefix(lines[0], "uuid,identifier_type,phone_md5"))
+		assert.Contains(t, lines[1], generatePhoneMD5("13800138071"))
+
+		buf.Reset()
+		active := false
+		exported, err = components.BlacklistService.ExportBlacklist(ctx, &services.ExportParams{
+			TenantID: 1,
+			Format:   services.ExportFormatNDJSON,
+			Source:   "export_test",
+			Active:   &active,
+		}, &buf)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), exported, "新导入的条目均有效")
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("Test RollbackImportBatch", func(t *testing.T) {
+		ctx := context.Background()
+
+		md5List := []string{generatePhoneMD5("13800138081"), generatePhoneMD5("13800138082")}
+		result, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   1,
+			Items:      md5Items(md5List...),
+			Source:     "batch_import",
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, result.BatchID)
+
+		batch, err := components.BlacklistService.GetImportBatch(ctx, 1, result.BatchID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ImportBatchStatusCompleted, batch.Status)
+		assert.Equal(t, int64(2), batch.EntryCount)
+
+		batch, err = components.BlacklistService.RollbackImportBatch(ctx, 1, result.BatchID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, models.ImportBatchStatusRolledBack, batch.Status)
+		assert.Equal(t, int64(2), batch.RolledBackCount)
+		assert.Equal(t, uint64(2), batch.RolledBackBy)
+
+		isHit, err := components.BlacklistService.CheckPhoneMD5(ctx, 1, md5List[0])
+		require.NoError(t, err)
+		assert.False(t, isHit, "回滚后条目应从Redis中移除")
+
+		_, err = components.BlacklistService.RollbackImportBatch(ctx, 1, result.BatchID, 2)
+		assert.Error(t, err, "已回滚的批次不能重复回滚")
+
+		// 回滚为物理删除，可以重新导入相同的标识
+		result, err = components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   1,
+			Items:      md5Items(md5List...),
+			Source:     "batch_import",
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Created)
+	})
+
+	t.Run("Test Rollback Restores Revived Entries", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(35)
+
+		// 导入前已失效的历史记录
+		revivedMD5 := generatePhoneMD5("13800138111")
+		expiresAt := time.Now().Add(-time.Minute)
+		history := &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    revivedMD5,
+			Source:      "manual",
+			Reason:      "历史记录",
+			OperatorID:  1,
+			IsActive:    true,
+			ExpiresAt:   &expiresAt,
+		}
+		require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, history))
+		require.NoError(t, components.BlacklistRepo.DeactivateExpiredByIDs(ctx, []uint64{history.ID}, time.Now()))
+
+		importedAt := time.Now().Add(-time.Second)
+		newMD5 := generatePhoneMD5("13800138112")
+		result, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   tenantID,
+			Items:      md5Items(revivedMD5, newMD5),
+			Source:     "batch_import",
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+
+		batch, err := components.BlacklistService.GetImportBatch(ctx, tenantID, result.BatchID)
+		require.NoError(t, err)
+		revived, err := components.BlacklistRepo.GetByID(ctx, history.ID)
+		require.NoError(t, err)
+		assert.Equal(t, batch.ID, revived.BatchID)
+		assert.NotNil(t, revived.RevivedAt)
+		assert.True(t, revived.CreatedAt.After(importedAt), "恢复的记录按恢复时间计为新增")
+
+		batch, err = components.BlacklistService.RollbackImportBatch(ctx, tenantID, result.BatchID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), batch.RolledBackCount)
+
+		// 恢复的历史记录改回失效，批次新增的记录删除
+		restored, err := components.BlacklistRepo.GetByID(ctx, history.ID)
+		require.NoError(t, err, "回滚不应删除导入前已存在的记录")
+		assert.Equal(t, history.UUID, restored.UUID)
+		assert.False(t, restored.IsActive)
+		assert.Zero(t, restored.BatchID)
+		assert.Nil(t, restored.RevivedAt)
+
+		isHit, err := components.BlacklistService.CheckPhoneMD5(ctx, tenantID, revivedMD5)
+		require.NoError(t, err)
+		assert.False(t, isHit, "回滚后恢复的记录不再拦截")
+		isHit, err = components.BlacklistService.CheckPhoneMD5(ctx, tenantID, newMD5)
+		require.NoError(t, err)
+		assert.False(t, isHit)
+
+		// 回滚后可以再次导入
+		result, err = components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   tenantID,
+			Items:      md5Items(revivedMD5, newMD5),
+			Source:     "batch_import",
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Created)
+		revived, err = components.BlacklistRepo.GetByID(ctx, history.ID)
+		require.NoError(t, err)
+		assert.True(t, revived.IsActive)
+	})
+
 	t.Run("Test GetBlacklistByTenant Success", func(t *testing.T) {
 		ctx := context.Background()
 
@@ -133,7 +618,7 @@ func TestBlacklistServiceUnitTests(t *testing.T) {
 		}
 
 		// 获取黑名单列表
-		blacklists, total, err := components.BlacklistService.GetBlacklistByTenant(ctx, 1, 1, 10)
+		blacklists, total, err := components.BlacklistService.GetBlacklistByTenant(ctx, 1, "", "", 1, 10)
 		require.NoError(t, err)
 		assert.Greater(t, total, int64(0), "应该有黑名单数据")
 		assert.NotEmpty(t, blacklists, "黑名单列表不应该为空")
@@ -141,11 +626,11 @@ func TestBlacklistServiceUnitTests(t *testing.T) {
 		// 验证分页功能
 		if total > 5 {
 			// 测试第二页
-			secondPageBlacklists, _, err := components.BlacklistService.GetBlacklistByTenant(ctx, 1, 2, 5)
+			secondPageBlacklists, _, err := components.BlacklistService.GetBlacklistByTenant(ctx, 1, "", "", 2, 5)
 			require.NoError(t, err)
 			
 			// 第二页的数据不应该与第一页重复
-			firstPageBlacklists, _, err := components.BlacklistService.GetBlacklistByTenant(ctx, 1, 1, 5)
+			firstPageBlacklists, _, err := components.BlacklistService.GetBlacklistByTenant(ctx, 1, "", "", 1, 5)
 			require.NoError(t, err)
 			
 			if len(secondPageBlacklists) > 0 && len(firstPageBlacklists) > 0 {
@@ -186,6 +671,78 @@ func TestBlacklistServiceUnitTests(t *testing.T) {
 		assert.False(t, isBlacklisted, "删除后不应该在黑名单中")
 	})
 
+	t.Run("Test Same Identifier Merged Across Formats", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(33)
+		hashes := services.NewIdentifierHashes("13800138090")
+
+		md5Only := &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    hashes.MD5,
+			Source:      "manual",
+			OperatorID:  1,
+			IsActive:    true,
+		}
+		require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, md5Only))
+
+		// 同时提供两种格式时补充已有记录，不产生第二条记录
+		both := &models.PhoneBlacklist{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			PhoneMD5:         hashes.MD5,
+			IdentifierSHA256: hashes.SHA256,
+			Source:           "manual",
+			OperatorID:       1,
+			IsActive:         true,
+		}
+		require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, both))
+		assert.Equal(t, md5Only.ID, both.ID, "应补充到已有记录")
+
+		// 只提供SHA-256的相同标识视为已存在
+		err := components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			IdentifierSHA256: hashes.SHA256,
+			Source:           "manual",
+			OperatorID:       1,
+			IsActive:         true,
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "已存在")
+
+		// 升级前可能已存在同一标识的两条记录，删除其中一条时另一条仍应命中
+		legacy := services.NewIdentifierHashes("13800138091")
+		legacyMD5Only := &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			ListID:      models.DefaultListID,
+			PhoneMD5:    legacy.MD5,
+			Source:      "manual",
+			OperatorID:  1,
+			IsActive:    true,
+		}
+		legacyBoth := &models.PhoneBlacklist{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			ListID:           models.DefaultListID,
+			PhoneMD5:         legacy.MD5,
+			IdentifierSHA256: legacy.SHA256,
+			Source:           "manual",
+			OperatorID:       1,
+			IsActive:         true,
+		}
+		require.NoError(t, db.Create(legacyMD5Only).Error)
+		require.NoError(t, db.Create(legacyBoth).Error)
+		_, err = components.BlacklistService.SyncToRedis(ctx, tenantID)
+		require.NoError(t, err)
+
+		require.NoError(t, components.BlacklistService.DeleteBlacklist(ctx, legacyMD5Only.ID))
+
+		isBlacklisted, err := components.BlacklistService.CheckPhoneMD5(ctx, tenantID, legacy.MD5)
+		require.NoError(t, err)
+		assert.True(t, isBlacklisted, "另一条有效记录仍持有该MD5")
+
+		result, err := components.BlacklistService.CheckIdentifier(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeSHA256, legacy.SHA256, nil)
+		require.NoError(t, err)
+		assert.True(t, result.Hit)
+	})
+
 	t.Run("Test SyncToRedis Success", func(t *testing.T) {
 		ctx := context.Background()
 
@@ -212,8 +769,15 @@ func TestBlacklistServiceUnitTests(t *testing.T) {
 		}
 
 		// 同步到Redis
-		err := components.BlacklistService.SyncToRedis(ctx, 1)
+		result, err := components.BlacklistService.SyncToRedis(ctx, 1)
 		require.NoError(t, err)
+		assert.GreaterOrEqual(t, result.Total, len(testPhones))
+
+		// Redis与数据库一致时再次同步不应新增或移除成员
+		result, err = components.BlacklistService.SyncToRedis(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.Added)
+		assert.Equal(t, 0, result.Removed)
 
 		// 验证同步后的查询结果
 		for _, phone := range testPhones {
@@ -224,6 +788,124 @@ func TestBlacklistServiceUnitTests(t *testing.T) {
 		}
 	})
 
+	t.Run("Test CheckDrift Repairs Missing Entries", func(t *testing.T) {
+		ctx := context.Background()
+
+		// 先修复已有偏差
+		_, err := components.BlacklistService.CheckDrift(ctx, 1)
+		require.NoError(t, err)
+
+		// 绕过服务直接写入数据库，模拟Redis写入失败
+		phoneMD5 := generatePhoneMD5("13800138091")
+		err = components.BlacklistRepo.Create(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: 1},
+			PhoneMD5:    phoneMD5,
+			Source:      "drift_test",
+			OperatorID:  1,
+			IsActive:    true,
+		})
+		require.NoError(t, err)
+
+		report, err := components.BlacklistService.CheckDrift(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), report.MissingCount)
+		assert.Equal(t, int64(0), report.ExtraCount)
+		assert.True(t, report.Repaired)
+
+		isBlacklisted, err := components.BlacklistService.CheckPhoneMD5(ctx, 1, phoneMD5)
+		require.NoError(t, err)
+		assert.True(t, isBlacklisted, "修复后应该在黑名单中")
+
+		report, err = components.BlacklistService.CheckDrift(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), report.MissingCount)
+		assert.False(t, report.Repaired)
+
+		reports, total, err := components.BlacklistService.ListDriftReports(ctx, 1, 1, 10)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, total, int64(3))
+		assert.Equal(t, report.ID, reports[0].ID)
+	})
+
+	t.Run("Test Query Log Search And Timeline", func(t *testing.T) {
+		ctx := context.Background()
+
+		apiKey := fmt.Sprintf("ak_search_%d", time.Now().UnixNano())
+		phoneMD5 := generatePhoneMD5("13800138092")
+		requestIDs := []string{"req-search-1", "req-search-2", "req-search-3"}
+		var logs []*models.BlacklistQueryLog
+		for i, requestID := range requestIDs {
+			logs = append(logs, &models.BlacklistQueryLog{
+				TenantID:       1,
+				APIKey:         apiKey,
+				RequestID:      requestID,
+				IdentifierType: models.IdentifierTypePhone,
+				HashType:       models.HashTypeMD5,
+				IdentifierHash: phoneMD5,
+				IsHit:          i != 1,
+				StatusCode:     200,
+			})
+		}
+		require.NoError(t, components.BlacklistQueryLogRepo.BatchCreate(ctx, logs))
+
+		// 倒序翻页
+		page, err := components.BlacklistService.SearchQueryLogs(ctx, &services.QueryLogSearchParams{
+			TenantID: 1,
+			APIKey:   apiKey,
+			Limit:    2,
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2)
+		assert.True(t, page.HasMore)
+		assert.Equal(t, "req-search-3", page.Items[0].RequestID)
+
+		page, err = components.BlacklistService.SearchQueryLogs(ctx, &services.QueryLogSearchParams{
+			TenantID: 1,
+			APIKey:   apiKey,
+			Cursor:   page.NextCursor,
+			Limit:    2,
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.False(t, page.HasMore)
+		assert.Equal(t, "req-search-1", page.Items[0].RequestID)
+
+		// 按命中结果和请求ID过滤
+		isHit := false
+		page, err = components.BlacklistService.SearchQueryLogs(ctx, &services.QueryLogSearchParams{
+			TenantID: 1,
+			APIKey:   apiKey,
+			IsHit:    &isHit,
+			Limit:    10,
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, "req-search-2", page.Items[0].RequestID)
+
+		page, err = components.BlacklistService.SearchQueryLogs(ctx, &services.QueryLogSearchParams{
+			TenantID:  2,
+			RequestID: "req-search-1",
+			Limit:     10,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, page.Items, "不应返回其他租户的日志")
+
+		// 时间线正序，第一页附带汇总
+		timeline, err := components.BlacklistService.GetQueryLogTimeline(ctx, &services.QueryLogSearchParams{
+			TenantID:       1,
+			APIKey:         apiKey,
+			IdentifierHash: phoneMD5,
+			Limit:          10,
+		})
+		require.NoError(t, err)
+		require.Len(t, timeline.Items, 3)
+		assert.Equal(t, "req-search-1", timeline.Items[0].RequestID)
+		require.NotNil(t, timeline.Summary)
+		assert.Equal(t, int64(3), timeline.Summary.Total)
+		assert.Equal(t, int64(2), timeline.Summary.HitCount)
+		assert.Equal(t, []string{apiKey}, timeline.Summary.APIKeys)
+	})
+
 	t.Run("Test UpdateQueryMetrics", func(t *testing.T) {
 		ctx := context.Background()
 
@@ -280,6 +962,887 @@ func TestBlacklistServiceUnitTests(t *testing.T) {
 		assert.GreaterOrEqual(t, minuteStats.QPS, float64(0), "QPS应该>=0")
 		assert.NotNil(t, minuteStats.MinuteData, "分钟数据不应该为nil")
 	})
+
+	t.Run("Test API Key Stats And Top Consumers", func(t *testing.T) {
+		ctx := context.Background()
+
+		tenantID := uint64(time.Now().UnixNano()%1000000 + 1000000)
+		credentialRepo := repositories.NewApiCredentialRepository(db)
+		busyKey := fmt.Sprintf("ak_busy_%d", time.Now().UnixNano())
+		quietKey := fmt.Sprintf("ak_quiet_%d", time.Now().UnixNano())
+		for _, apiKey := range []string{busyKey, quietKey} {
+			require.NoError(t, credentialRepo.Create(ctx, &models.BlacklistApiCredential{
+				TenantModel:   models.TenantModel{TenantID: tenantID},
+				APIKey:        apiKey,
+				APISecret:     "secret",
+				Name:          apiKey,
+				Status:        "active",
+				LogSampleRate: models.DefaultLogSampleRate,
+			}))
+		}
+
+		components.BlacklistService.UpdateQueryMetrics(ctx, tenantID, busyKey, true, 10)
+		components.BlacklistService.UpdateQueryMetrics(ctx, tenantID, busyKey, false, 30)
+		components.BlacklistService.UpdateQueryMetrics(ctx, tenantID, busyKey, false, 20)
+		components.BlacklistService.UpdateQueryMetrics(ctx, tenantID, quietKey, true, 40)
+
+		window := services.StatsWindow{Granularity: services.StatsGranularityMinute, Points: 5}
+		stats, err := components.BlacklistService.GetAPIKeyStats(ctx, tenantID, busyKey, window)
+		if err != nil {
+			t.Logf("获取API密钥统计失败（可能是Redis不可用）: %v", err)
+			return
+		}
+		assert.Equal(t, int64(3), stats.TotalQueries)
+		assert.Equal(t, int64(1), stats.HitCount)
+		assert.InDelta(t, 20.0, stats.AvgLatency, 0.001)
+		require.Len(t, stats.Series, 5)
+
+		top, err := components.BlacklistService.GetTopConsumers(ctx, tenantID, window, services.TopConsumersSortByTotal, 10)
+		require.NoError(t, err)
+		require.Len(t, top, 2)
+		assert.Equal(t, busyKey, top[0].APIKey)
+		assert.Nil(t, top[0].Series)
+
+		top, err = components.BlacklistService.GetTopConsumers(ctx, tenantID, window, services.TopConsumersSortByLatency, 1)
+		require.NoError(t, err)
+		require.Len(t, top, 1)
+		assert.Equal(t, quietKey, top[0].APIKey)
+
+		_, err = components.BlacklistService.GetAPIKeyStats(ctx, tenantID+1, busyKey, window)
+		assert.Error(t, err, "其他租户的API密钥不可查询")
+
+		_, err = components.BlacklistService.GetAPIKeyStats(ctx, tenantID, busyKey, services.StatsWindow{Granularity: services.StatsGranularityHour, Points: 49})
+		assert.Error(t, err, "超出保留范围的数据点数应报错")
+	})
+
+	t.Run("Test Stats From Rollup Tables", func(t *testing.T) {
+		ctx := context.Background()
+
+		tenantID := uint64(time.Now().UnixNano()%1000000 + 2000000)
+		apiKey := fmt.Sprintf("ak_rollup_%d", time.Now().UnixNano())
+		deletedKey := apiKey + "_deleted"
+		credentialRepo := repositories.NewApiCredentialRepository(db)
+		require.NoError(t, credentialRepo.Create(ctx, &models.BlacklistApiCredential{
+			TenantModel:   models.TenantModel{TenantID: tenantID},
+			APIKey:        apiKey,
+			APISecret:     "secret",
+			Name:          "rollup",
+			Status:        "active",
+			LogSampleRate: models.DefaultLogSampleRate,
+		}))
+
+		// 前天10点和11点、昨天10点的小时统计
+		today := time.Now()
+		today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+		day1 := today.AddDate(0, 0, -2)
+		day2 := today.AddDate(0, 0, -1)
+		hourly := func(key string, hour time.Time, total, hits, latency, peak int64) *models.BlacklistQueryStatHourly {
+			return &models.BlacklistQueryStatHourly{BlacklistQueryStat: models.BlacklistQueryStat{
+				TenantID: tenantID, APIKey: key, PeriodStart: hour,
+				TotalQueries: total, HitCount: hits, LatencySum: latency, LatencyCount: total, PeakMinuteQueries: peak,
+			}}
+		}
+		statRepo := repositories.NewBlacklistQueryStatRepository(db)
+		require.NoError(t, statRepo.UpsertHourly(ctx, []*models.BlacklistQueryStatHourly{
+			hourly("", day1.Add(10*time.Hour), 130, 13, 1300, 60),
+			hourly("", day1.Add(11*time.Hour), 70, 7, 1400, 30),
+			hourly("", day2.Add(10*time.Hour), 100, 50, 500, 120),
+			hourly(apiKey, day1.Add(10*time.Hour), 100, 10, 1000, 60),
+			hourly(apiKey, day1.Add(11*time.Hour), 70, 7, 1400, 30),
+			hourly(deletedKey, day1.Add(10*time.Hour), 30, 3, 300, 10),
+			hourly(deletedKey, day2.Add(10*time.Hour), 100, 50, 500, 120),
+		}))
+		// 重复汇总时计数覆盖，峰值不降低
+		require.NoError(t, statRepo.UpsertHourly(ctx, []*models.BlacklistQueryStatHourly{
+			hourly(apiKey, day1.Add(10*time.Hour), 100, 10, 1000, 0),
+		}))
+		require.NoError(t, statRepo.RefreshDaily(ctx, day1, today))
+
+		window := services.StatsWindow{Granularity: services.StatsGranularityDay, From: day1, To: today}
+		tenantStats, err := components.BlacklistService.GetTenantStats(ctx, tenantID, window)
+		require.NoError(t, err)
+		assert.Equal(t, int64(300), tenantStats.TotalQueries)
+		assert.Equal(t, int64(70), tenantStats.HitCount)
+		assert.InDelta(t, 3200.0/300.0, tenantStats.AvgLatency, 0.001)
+		assert.InDelta(t, 2.0, tenantStats.PeakQPS, 0.001)
+		require.Len(t, tenantStats.Series, 2)
+		assert.Equal(t, int64(200), tenantStats.Series[0].TotalQueries)
+		assert.Equal(t, int64(100), tenantStats.Series[1].TotalQueries)
+
+		hourWindow := services.StatsWindow{Granularity: services.StatsGranularityHour, From: day1.Add(9 * time.Hour), To: day1.Add(12 * time.Hour)}
+		keyStats, err := components.BlacklistService.GetAPIKeyStats(ctx, tenantID, apiKey, hourWindow)
+		require.NoError(t, err)
+		assert.Equal(t, "rollup", keyStats.Name)
+		assert.Equal(t, int64(170), keyStats.TotalQueries)
+		assert.InDelta(t, 1.0, keyStats.PeakQPS, 0.001)
+		require.Len(t, keyStats.Series, 3)
+		assert.Equal(t, int64(0), keyStats.Series[0].TotalQueries, "缺失的时段计为0")
+
+		top, err := components.BlacklistService.GetTopConsumers(ctx, tenantID, window, services.TopConsumersSortByTotal, 10)
+		require.NoError(t, err)
+		require.Len(t, top, 2)
+		assert.Equal(t, apiKey, top[0].APIKey)
+		assert.Equal(t, deletedKey, top[1].APIKey)
+		assert.Equal(t, int64(130), top[1].TotalQueries)
+		assert.Empty(t, top[1].Name, "已删除的密钥没有名称")
+
+		_, err = components.BlacklistService.GetTenantStats(ctx, tenantID, services.StatsWindow{Granularity: services.StatsGranularityHour, Points: 24})
+		assert.Error(t, err, "租户统计需指定时间范围或使用day粒度")
+	})
+
+	t.Run("Test Approval Workflow", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(20)
+
+		require.NoError(t, components.ApprovalService.SetApprovalRequired(ctx, tenantID, true))
+		defer func() {
+			_ = components.ApprovalService.SetApprovalRequired(ctx, tenantID, false)
+		}()
+
+		// 新增申请通过前不生效
+		phoneMD5 := generatePhoneMD5("13800138091")
+		createRequest, err := components.ApprovalService.RequestCreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    phoneMD5,
+			Source:      "manual",
+			Reason:      "审批测试",
+			OperatorID:  1,
+			IsActive:    true,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, createRequest, "启用审批时应创建申请")
+		assert.Equal(t, models.ChangeRequestStatusPending, createRequest.Status)
+
+		isHit, err := components.BlacklistService.CheckPhoneMD5(ctx, tenantID, phoneMD5)
+		require.NoError(t, err)
+		assert.False(t, isHit, "审批通过前不应命中")
+
+		_, err = components.ApprovalService.ApproveChangeRequest(ctx, tenantID, createRequest.UUID, 1, "")
+		assert.Error(t, err, "申请人不能审批自己的申请")
+
+		approved, err := components.ApprovalService.ApproveChangeRequest(ctx, tenantID, createRequest.UUID, 2, "已核实")
+		require.NoError(t, err)
+		assert.Equal(t, models.ChangeRequestStatusApproved, approved.Status)
+		assert.Equal(t, uint64(2), approved.ReviewedBy)
+		require.NotZero(t, approved.BlacklistID)
+
+		isHit, err = components.BlacklistService.CheckPhoneMD5(ctx, tenantID, phoneMD5)
+		require.NoError(t, err)
+		assert.True(t, isHit, "审批通过后应命中")
+
+		_, err = components.ApprovalService.RejectChangeRequest(ctx, tenantID, createRequest.UUID, 3, "")
+		assert.Error(t, err, "已处理的申请不能再次审批")
+
+		_, err = components.ApprovalService.RequestDeleteBlacklist(ctx, 1, approved.BlacklistID, 2)
+		assert.Error(t, err, "不能删除其他租户的条目")
+
+		// 删除申请被驳回后条目保留
+		deleteRequest, err := components.ApprovalService.RequestDeleteBlacklist(ctx, tenantID, approved.BlacklistID, 2)
+		require.NoError(t, err)
+		require.NotNil(t, deleteRequest)
+		assert.Equal(t, phoneMD5, deleteRequest.PhoneMD5, "删除申请应记录目标条目")
+
+		_, err = components.ApprovalService.RequestDeleteBlacklist(ctx, tenantID, approved.BlacklistID, 2)
+		assert.Error(t, err, "同一条目不能重复提交删除申请")
+
+		rejected, err := components.ApprovalService.RejectChangeRequest(ctx, tenantID, deleteRequest.UUID, 1, "证据不足")
+		require.NoError(t, err)
+		assert.Equal(t, models.ChangeRequestStatusRejected, rejected.Status)
+
+		isHit, err = components.BlacklistService.CheckPhoneMD5(ctx, tenantID, phoneMD5)
+		require.NoError(t, err)
+		assert.True(t, isHit, "驳回删除申请后条目应保留")
+
+		// 删除申请通过后条目移除
+		deleteRequest, err = components.ApprovalService.RequestDeleteBlacklist(ctx, tenantID, approved.BlacklistID, 2)
+		require.NoError(t, err)
+		_, err = components.ApprovalService.ApproveChangeRequest(ctx, tenantID, deleteRequest.UUID, 1, "")
+		require.NoError(t, err)
+
+		isHit, err = components.BlacklistService.CheckPhoneMD5(ctx, tenantID, phoneMD5)
+		require.NoError(t, err)
+		assert.False(t, isHit, "删除申请通过后不应命中")
+
+		requests, total, err := components.ApprovalService.ListChangeRequests(ctx, tenantID, models.ChangeRequestStatusApproved, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, requests, 2)
+	})
+
+	t.Run("Test Approval Blocks Bulk Writes", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(29)
+
+		// 启用审批前导入一个批次并贡献一个共享名单条目，供回滚和撤回使用
+		imported, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   tenantID,
+			Items:      md5Items(generatePhoneMD5("13800138095")),
+			Source:     "batch_import",
+			OperatorID: 1,
+		})
+		require.NoError(t, err)
+		_, err = components.SharedListService.SetSharedSetting(ctx, tenantID, &services.SharedSetting{Contributor: true})
+		require.NoError(t, err)
+		contributed := &models.PhoneBlacklist{
+			PhoneMD5:   generatePhoneMD5("13800138096"),
+			Source:     "manual",
+			OperatorID: 1,
+			IsActive:   true,
+		}
+		require.NoError(t, components.BlacklistService.ContributeShared(ctx, tenantID, contributed))
+
+		require.NoError(t, components.ApprovalService.SetApprovalRequired(ctx, tenantID, true))
+		defer func() {
+			_ = components.ApprovalService.SetApprovalRequired(ctx, tenantID, false)
+		}()
+
+		assertForbidden := func(err error, operation string) {
+			var bizErr *errors.BusinessError
+			require.ErrorAs(t, err, &bizErr, operation+"应返回业务错误")
+			assert.Equal(t, errors.CodeForbidden, bizErr.Code, operation+"在启用审批时应被拒绝")
+		}
+
+		_, err = components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   tenantID,
+			Items:      md5Items(generatePhoneMD5("13800138097")),
+			Source:     "batch_import",
+			OperatorID: 1,
+		})
+		assertForbidden(err, "批量导入")
+
+		data := []byte("手机号\n13800138098\n")
+		rows, err := sheet.NewReader(bytes.NewReader(data), int64(len(data)), "phones.csv")
+		require.NoError(t, err)
+		_, err = components.BlacklistService.ImportBlacklistFile(ctx, &services.FileImportParams{
+			TenantID:   tenantID,
+			Rows:       rows,
+			ColumnName: "手机号",
+			Source:     "file_import",
+			OperatorID: 1,
+		})
+		assertForbidden(err, "文件导入")
+
+		_, err = components.BlacklistService.SubmitImportJob(ctx, &services.ImportJobParams{
+			TenantID:   tenantID,
+			FileName:   "phones.csv",
+			FileSize:   int64(len(data)),
+			ValueType:  models.ImportValueTypePhone,
+			ColumnName: "手机号",
+			Source:     "file_import",
+			OperatorID: 1,
+		}, bytes.NewReader(data))
+		assertForbidden(err, "异步导入任务")
+
+		_, err = components.BlacklistService.RollbackImportBatch(ctx, tenantID, imported.BatchID, 1)
+		assertForbidden(err, "批次回滚")
+
+		err = components.BlacklistService.ContributeShared(ctx, tenantID, &models.PhoneBlacklist{
+			PhoneMD5:   generatePhoneMD5("13800138099"),
+			Source:     "manual",
+			OperatorID: 1,
+			IsActive:   true,
+		})
+		assertForbidden(err, "共享名单贡献")
+
+		_, err = components.BlacklistService.WithdrawSharedContribution(ctx, tenantID, contributed.ID)
+		assertForbidden(err, "撤回共享名单贡献")
+
+		// 被拒绝的写入没有生效
+		isBlacklisted, err := components.BlacklistService.CheckPhoneMD5(ctx, tenantID, generatePhoneMD5("13800138097"))
+		require.NoError(t, err)
+		assert.False(t, isBlacklisted)
+		batch, err := components.BlacklistService.GetImportBatch(ctx, tenantID, imported.BatchID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ImportBatchStatusCompleted, batch.Status, "批次不应被回滚")
+	})
+
+	t.Run("Test Allowlist Overrides Hits", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(21)
+
+		hashes := services.NewIdentifierHashes("13800138092")
+		err := components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			PhoneMD5:         hashes.MD5,
+			IdentifierSHA256: hashes.SHA256,
+			Source:           "manual",
+			Reason:           "白名单测试",
+			OperatorID:       1,
+			IsActive:         true,
+		})
+		require.NoError(t, err)
+
+		entry := &models.BlacklistAllowlistEntry{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			PhoneMD5:         hashes.MD5,
+			IdentifierSHA256: hashes.SHA256,
+			Reason:           "客户申诉已核实",
+			OperatorID:       1,
+		}
+		require.NoError(t, components.AllowlistService.CreateAllowlistEntry(ctx, entry))
+
+		err = components.AllowlistService.CreateAllowlistEntry(ctx, &models.BlacklistAllowlistEntry{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    hashes.MD5,
+			Reason:      "重复条目",
+		})
+		assert.Error(t, err, "同一标识不能重复加入白名单")
+
+		isHit, err := components.BlacklistService.CheckPhoneMD5(ctx, tenantID, hashes.MD5)
+		require.NoError(t, err)
+		assert.False(t, isHit, "白名单中的标识不应拦截")
+
+		result, err := components.BlacklistService.CheckIdentifier(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeSHA256, hashes.SHA256, nil)
+		require.NoError(t, err)
+		assert.False(t, result.Hit)
+		assert.True(t, result.Overridden, "SHA-256格式同样应被豁免")
+		assert.True(t, result.OverriddenAbove(models.DefaultRiskScore))
+		assert.False(t, result.OverriddenAbove(models.DefaultRiskScore+1), "低于风险分阈值的命中不应报告豁免")
+
+		results, err := components.BlacklistService.CheckPhoneMD5Batch(ctx, tenantID, []string{hashes.MD5})
+		require.NoError(t, err)
+		assert.False(t, results[hashes.MD5], "批量查询中白名单标识不应拦截")
+
+		// 过期时间不能早于当前时间
+		past := time.Now().Add(-time.Hour)
+		_, _, err = components.AllowlistService.UpdateAllowlistEntry(ctx, tenantID, entry.UUID, &services.AllowlistUpdateParams{
+			Reason:    "已过期",
+			ExpiresAt: &past,
+		})
+		assert.Error(t, err, "过期时间必须晚于当前时间")
+
+		_, err = components.AllowlistService.GetAllowlistEntry(ctx, 1, entry.UUID)
+		assert.Error(t, err, "不能获取其他租户的条目")
+
+		// 删除后恢复拦截
+		deleted, err := components.AllowlistService.DeleteAllowlistEntry(ctx, tenantID, entry.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, entry.ID, deleted.ID)
+
+		result, err = components.BlacklistService.CheckIdentifier(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeMD5, hashes.MD5, nil)
+		require.NoError(t, err)
+		assert.True(t, result.Hit, "删除白名单条目后应恢复拦截")
+		assert.False(t, result.Overridden)
+	})
+
+	t.Run("Test Allowlist Matches All Formats", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(27)
+
+		salt, err := components.BlacklistService.GetHashSalt(ctx, tenantID)
+		require.NoError(t, err)
+		hmacOf := func(sha256Hex string) string {
+			mac := hmac.New(sha256.New, []byte(salt))
+			mac.Write([]byte(sha256Hex))
+			return hex.EncodeToString(mac.Sum(nil))
+		}
+
+		// 先按MD5加入白名单，后写入同时有MD5和SHA-256的黑名单条目
+		before := services.NewIdentifierHashes("13800138093")
+		require.NoError(t, components.AllowlistService.CreateAllowlistEntry(ctx, &models.BlacklistAllowlistEntry{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    before.MD5,
+			Reason:      "仅提供MD5",
+			OperatorID:  1,
+		}))
+		err = components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			PhoneMD5:         before.MD5,
+			IdentifierSHA256: before.SHA256,
+			Source:           "manual",
+			Reason:           "白名单格式测试",
+			OperatorID:       1,
+			IsActive:         true,
+		})
+		require.NoError(t, err)
+
+		// 先写入黑名单条目，后按MD5加入白名单
+		after := services.NewIdentifierHashes("13800138094")
+		err = components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			PhoneMD5:         after.MD5,
+			IdentifierSHA256: after.SHA256,
+			Source:           "manual",
+			Reason:           "白名单格式测试",
+			OperatorID:       1,
+			IsActive:         true,
+		})
+		require.NoError(t, err)
+		require.NoError(t, components.AllowlistService.CreateAllowlistEntry(ctx, &models.BlacklistAllowlistEntry{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    after.MD5,
+			Reason:      "仅提供MD5",
+			OperatorID:  1,
+		}))
+
+		for _, hashes := range []services.IdentifierHashes{before, after} {
+			result, err := components.BlacklistService.CheckIdentifier(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeSHA256, hashes.SHA256, nil)
+			require.NoError(t, err)
+			assert.False(t, result.Hit)
+			assert.True(t, result.Overridden, "仅按MD5加入白名单的标识按SHA-256查询也应被豁免")
+
+			result, err = components.BlacklistService.CheckIdentifier(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeHMACSHA256, hmacOf(hashes.SHA256), nil)
+			require.NoError(t, err)
+			assert.False(t, result.Hit)
+			assert.True(t, result.Overridden, "仅按MD5加入白名单的标识按HMAC查询也应被豁免")
+		}
+	})
+
+	t.Run("Test Named Lists", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(22)
+
+		list := &models.BlacklistList{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			Name:        "loan_fraud",
+			Description: "信贷欺诈名单",
+		}
+		require.NoError(t, components.ListService.CreateList(ctx, list))
+
+		err := components.ListService.CreateList(ctx, &models.BlacklistList{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			Name:        models.DefaultListName,
+		})
+		assert.Error(t, err, "默认名单标识为保留标识")
+
+		listID, err := components.ListService.ResolveListID(ctx, tenantID, "loan_fraud")
+		require.NoError(t, err)
+		assert.Equal(t, list.ID, listID)
+
+		hashes := services.NewIdentifierHashes("13800138093")
+		err = components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			ListID:           listID,
+			PhoneMD5:         hashes.MD5,
+			IdentifierSHA256: hashes.SHA256,
+			Source:           "manual",
+			Reason:           "名单测试",
+			OperatorID:       1,
+			IsActive:         true,
+		})
+		require.NoError(t, err)
+
+		// 未授权名单的API密钥仅查询默认名单
+		isHit, err := components.BlacklistService.CheckPhoneMD5(ctx, tenantID, hashes.MD5)
+		require.NoError(t, err)
+		assert.False(t, isHit, "命名名单中的条目不应在默认名单中命中")
+
+		credential := &models.BlacklistApiCredential{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			Lists:       "default,loan_fraud",
+		}
+		lists, err := components.ListService.ResolveCheckLists(ctx, tenantID, credential, nil)
+		require.NoError(t, err)
+		assert.Len(t, lists, 2)
+
+		result, err := components.BlacklistService.CheckIdentifier(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeSHA256, hashes.SHA256, lists)
+		require.NoError(t, err)
+		assert.True(t, result.Hit)
+		assert.Equal(t, []string{"loan_fraud"}, result.HitLists(0))
+
+		_, err = components.ListService.ResolveCheckLists(ctx, tenantID, &models.BlacklistApiCredential{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+		}, []string{"loan_fraud"})
+		assert.Error(t, err, "不能查询未授权的名单")
+
+		lists, err = components.ListService.ResolveCheckLists(ctx, tenantID, credential, []string{models.DefaultListName})
+		require.NoError(t, err)
+		result, err = components.BlacklistService.CheckIdentifier(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeMD5, hashes.MD5, lists)
+		require.NoError(t, err)
+		assert.False(t, result.Hit, "仅查询默认名单时不应命中")
+
+		_, err = components.ListService.GetList(ctx, 1, list.UUID)
+		assert.Error(t, err, "不能获取其他租户的名单")
+
+		_, err = components.ListService.DeleteList(ctx, tenantID, list.UUID)
+		assert.Error(t, err, "名单中仍有有效条目时不允许删除")
+	})
+
+	t.Run("Test Shared List", func(t *testing.T) {
+		ctx := context.Background()
+		subscriberID := uint64(23)
+		contributorID := uint64(24)
+
+		hashes := services.NewIdentifierHashes("13800138094")
+		err := components.BlacklistService.ContributeShared(ctx, models.SystemTenantID, &models.PhoneBlacklist{
+			PhoneMD5:         hashes.MD5,
+			IdentifierSHA256: hashes.SHA256,
+			Source:           "manual",
+			Reason:           "共享名单测试",
+			OperatorID:       1,
+			IsActive:         true,
+		})
+		require.NoError(t, err)
+
+		// 系统租户自己的名单条目不属于共享名单
+		systemHashes := services.NewIdentifierHashes("13800138093")
+		require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: models.SystemTenantID},
+			PhoneMD5:    systemHashes.MD5,
+			Source:      "manual",
+			OperatorID:  1,
+			IsActive:    true,
+		}))
+
+		result, err := components.BlacklistService.CheckIdentifier(ctx, subscriberID, models.IdentifierTypePhone, models.HashTypeMD5, hashes.MD5, nil)
+		require.NoError(t, err)
+		assert.False(t, result.Hit, "未订阅时不应命中共享名单")
+
+		_, err = components.SharedListService.SetSharedSetting(ctx, subscriberID, &services.SharedSetting{Subscribed: true})
+		require.NoError(t, err)
+
+		result, err = components.BlacklistService.CheckIdentifier(ctx, subscriberID, models.IdentifierTypePhone, models.HashTypeSHA256, hashes.SHA256, nil)
+		require.NoError(t, err)
+		assert.True(t, result.Hit)
+		assert.Equal(t, models.HitSourceShared, result.HitSource(0))
+		assert.Empty(t, result.HitLists(0), "共享名单命中不属于租户名单")
+
+		result, err = components.BlacklistService.CheckIdentifier(ctx, subscriberID, models.IdentifierTypePhone, models.HashTypeMD5, systemHashes.MD5, nil)
+		require.NoError(t, err)
+		assert.False(t, result.Hit, "系统租户自己的条目不应作为共享名单命中")
+
+		contributed := &models.PhoneBlacklist{
+			PhoneMD5:   services.NewIdentifierHashes("13800138095").MD5,
+			Source:     "shared",
+			OperatorID: 1,
+			IsActive:   true,
+		}
+		err = components.BlacklistService.ContributeShared(ctx, subscriberID, contributed)
+		assert.Error(t, err, "未加入贡献的租户不能贡献条目")
+
+		_, err = components.SharedListService.SetSharedSetting(ctx, contributorID, &services.SharedSetting{Contributor: true})
+		require.NoError(t, err)
+		require.NoError(t, components.BlacklistService.ContributeShared(ctx, contributorID, contributed))
+		assert.Equal(t, models.SharedTenantID, contributed.TenantID)
+
+		err = components.BlacklistService.ContributeShared(ctx, contributorID, &models.PhoneBlacklist{
+			PhoneMD5:   hashes.MD5,
+			Source:     "shared",
+			OperatorID: 1,
+			IsActive:   true,
+		})
+		var bizErr *errors.BusinessError
+		require.ErrorAs(t, err, &bizErr, "标识已在共享名单中")
+		assert.Equal(t, errors.CodeConflict, bizErr.Code)
+
+		// 并发贡献越过存在检查时由唯一键拒绝，同样返回冲突
+		err = components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: models.SharedTenantID},
+			PhoneMD5:    contributed.PhoneMD5,
+			Source:      "shared",
+			OperatorID:  1,
+			IsActive:    true,
+		})
+		require.ErrorAs(t, err, &bizErr, "唯一键冲突应返回业务错误")
+		assert.Equal(t, errors.CodeConflict, bizErr.Code)
+
+		items, total, err := components.BlacklistService.ListSharedContributions(ctx, contributorID, 1, 20)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		require.Len(t, items, 1)
+		assert.Equal(t, contributed.ID, items[0].ID)
+
+		_, systemTotal, err := components.BlacklistService.ListSharedContributions(ctx, models.SystemTenantID, 1, 20)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, systemTotal, int64(2), "系统租户可查看所有共享名单条目")
+
+		_, err = components.BlacklistService.WithdrawSharedContribution(ctx, subscriberID, contributed.ID)
+		assert.Error(t, err, "不能撤回其他租户贡献的条目")
+
+		_, err = components.BlacklistService.WithdrawSharedContribution(ctx, contributorID, contributed.ID)
+		require.NoError(t, err)
+	})
+
+	t.Run("Test Entry Hit Tracking", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(25)
+
+		entries := make([]*models.PhoneBlacklist, 0, 2)
+		for _, phone := range []string{"13800138096", "13800138097"} {
+			entry := &models.PhoneBlacklist{
+				TenantModel: models.TenantModel{TenantID: tenantID},
+				PhoneMD5:    generatePhoneMD5(phone),
+				Source:      "manual",
+				OperatorID:  1,
+				IsActive:    true,
+			}
+			require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, entry))
+			entries = append(entries, entry)
+		}
+
+		lastHitAt := time.Now().Truncate(time.Second)
+		repo := repositories.NewBlacklistRepository(db)
+		require.NoError(t, repo.AddHits(ctx, []repositories.BlacklistHitDelta{{ID: entries[0].ID, Hits: 3, LastHitAt: lastHitAt}}))
+		require.NoError(t, repo.AddHits(ctx, []repositories.BlacklistHitDelta{{ID: entries[0].ID, Hits: 2, LastHitAt: lastHitAt.Add(-time.Hour)}}))
+
+		items, _, err := components.BlacklistService.GetBlacklistByTenant(ctx, tenantID, services.BlacklistSortByHits, services.BlacklistSortOrderDesc, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, entries[0].ID, items[0].ID)
+		assert.Equal(t, uint64(5), items[0].HitCount)
+		require.NotNil(t, items[0].LastHitAt)
+		assert.True(t, items[0].LastHitAt.Equal(lastHitAt), "最后命中时间不应被更早的命中覆盖")
+
+		items, _, err = components.BlacklistService.GetBlacklistByTenant(ctx, tenantID, services.BlacklistSortByLastHit, services.BlacklistSortOrderAsc, 1, 10)
+		require.NoError(t, err)
+		require.Len(t, items, 2)
+		assert.Equal(t, entries[1].ID, items[0].ID, "从未命中的条目应排在最前")
+		assert.Nil(t, items[0].LastHitAt)
+	})
+
+	t.Run("Test Prefix Range Query", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(26)
+
+		hit := services.NewIdentifierHashes("13800138098")
+		allowed := services.NewIdentifierHashes("13800138099")
+		for _, hashes := range []services.IdentifierHashes{hit, allowed} {
+			require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+				TenantModel:      models.TenantModel{TenantID: tenantID},
+				PhoneMD5:         hashes.MD5,
+				IdentifierSHA256: hashes.SHA256,
+				Source:           "manual",
+				Category:         "fraud",
+				RiskScore:        80,
+				OperatorID:       1,
+				IsActive:         true,
+			}))
+		}
+		require.NoError(t, components.AllowlistService.CreateAllowlistEntry(ctx, &models.BlacklistAllowlistEntry{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    allowed.MD5,
+			Reason:      "前缀查询白名单测试",
+			OperatorID:  1,
+		}))
+
+		credential := &models.BlacklistApiCredential{
+			TenantModel:     models.TenantModel{TenantID: tenantID},
+			APIKey:          fmt.Sprintf("ak_range_%d", time.Now().UnixNano()),
+			RangeQueryLimit: 2,
+		}
+
+		matches, err := components.BlacklistService.RangeQuery(ctx, credential, models.IdentifierTypePhone, models.HashTypeSHA256, hit.SHA256[:6], nil)
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, hit.SHA256, matches[0].Hash)
+		assert.Equal(t, 80, matches[0].Result.RiskScore)
+
+		// 白名单豁免的条目不返回
+		matches, err = components.BlacklistService.RangeQuery(ctx, credential, models.IdentifierTypePhone, models.HashTypeMD5, allowed.MD5[:6], nil)
+		require.NoError(t, err)
+		assert.Empty(t, matches)
+
+		// 重复查询同一前缀不占用配额，超过每日不同前缀数后拒绝
+		_, err = components.BlacklistService.RangeQuery(ctx, credential, models.IdentifierTypePhone, models.HashTypeSHA256, hit.SHA256[:6], nil)
+		require.NoError(t, err)
+		_, err = components.BlacklistService.RangeQuery(ctx, credential, models.IdentifierTypePhone, models.HashTypeSHA256, hit.SHA256[:7], nil)
+		assert.Error(t, err, "超过每日前缀配额应拒绝")
+
+		_, err = components.BlacklistService.RangeQuery(ctx, credential, models.IdentifierTypePhone, models.HashTypeSHA256, hit.SHA256[:4], nil)
+		assert.Error(t, err, "前缀过短应拒绝")
+
+		credential.RangeQueryLimit = 0
+		_, err = components.BlacklistService.RangeQuery(ctx, credential, models.IdentifierTypePhone, models.HashTypeSHA256, hit.SHA256[:6], nil)
+		assert.Error(t, err, "未开启前缀查询的API密钥应拒绝")
+	})
+
+	t.Run("Test Filter Detects Lost Sync Messages", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(30)
+
+		require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    generatePhoneMD5("13800138098"),
+			Source:      "manual",
+			OperatorID:  1,
+			IsActive:    true,
+		}))
+		_, err := components.BlacklistService.GetFilterStats(ctx, tenantID)
+		require.NoError(t, err)
+
+		// 模拟其他实例写入条目后同步消息丢失：数据库和Redis已有条目，本实例过滤器未收到
+		redisCache := redisClient.NewClient(&redisClient.Config{Addrs: []string{"localhost:6379"}, DB: 1}, testLogger.Logger)
+		defer redisCache.Close()
+		lostMD5 := generatePhoneMD5("13800138099")
+		require.NoError(t, components.BlacklistRepo.Create(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    lostMD5,
+			Source:      "manual",
+			OperatorID:  1,
+			IsActive:    true,
+		}))
+		require.NoError(t, redisCache.SAdd(ctx, fmt.Sprintf("blacklist:tenant:%d", tenantID), lostMD5).Err())
+		require.NoError(t, redisCache.Incr(ctx, fmt.Sprintf("blacklist:filter:version:tenant:%d", tenantID)).Err())
+
+		assert.Eventually(t, func() bool {
+			isBlacklisted, err := components.BlacklistService.CheckPhoneMD5(ctx, tenantID, lostMD5)
+			return err == nil && isBlacklisted
+		}, 5*time.Second, 100*time.Millisecond, "版本校验过期后应回源查询")
+
+		assert.Eventually(t, func() bool {
+			_, _ = components.BlacklistService.CheckPhoneMD5(ctx, tenantID, generatePhoneMD5("13800138100"))
+			stats, err := components.BlacklistService.GetFilterStats(ctx, tenantID)
+			return err == nil && stats.SyncGaps > 0
+		}, 5*time.Second, 100*time.Millisecond, "发现消息丢失后应重建过滤器")
+	})
+
+	t.Run("Test Filter Broadcasts When Version Increment Fails", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(34)
+		versionKey := fmt.Sprintf("blacklist:filter:version:tenant:%d", tenantID)
+		redisCache := redisClient.NewClient(&redisClient.Config{Addrs: []string{"localhost:6379"}, DB: 1}, testLogger.Logger)
+		defer redisCache.Close()
+
+		pubsub := redisCache.Subscribe(ctx, "blacklist:filter:events")
+		defer pubsub.Close()
+		_, err := pubsub.Receive(ctx)
+		require.NoError(t, err)
+		nextEvent := func() map[string]interface{} {
+			for {
+				select {
+				case msg := <-pubsub.Channel():
+					var event map[string]interface{}
+					require.NoError(t, json.Unmarshal([]byte(msg.Payload), &event))
+					if uint64(event["tenant_id"].(float64)) == tenantID {
+						return event
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("未收到同步消息")
+					return nil
+				}
+			}
+		}
+		create := func(phone string) {
+			require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+				TenantModel: models.TenantModel{TenantID: tenantID},
+				PhoneMD5:    generatePhoneMD5(phone),
+				Source:      "manual",
+				OperatorID:  1,
+				IsActive:    true,
+			}))
+		}
+
+		create("13800138101")
+		nextEvent()
+		_, err = components.BlacklistService.GetFilterStats(ctx, tenantID)
+		require.NoError(t, err)
+		version, err := redisCache.Get(ctx, versionKey).Int64()
+		require.NoError(t, err)
+
+		// 版本无法递增时仍广播新增条目，消息不带版本
+		require.NoError(t, redisCache.Set(ctx, versionKey, "corrupted", 0).Err())
+		create("13800138102")
+		event := nextEvent()
+		assert.NotEmpty(t, event["values"])
+		assert.Nil(t, event["version"])
+
+		// 恢复后下次广播补上未计入的版本，漏收消息的实例据此发现缺口
+		require.NoError(t, redisCache.Set(ctx, versionKey, version, 0).Err())
+		create("13800138103")
+		event = nextEvent()
+		assert.Equal(t, float64(version+2), event["version"])
+		current, err := redisCache.Get(ctx, versionKey).Int64()
+		require.NoError(t, err)
+		assert.Equal(t, version+2, current)
+
+		// 补上的版本由本实例发出，本实例不应视为消息丢失，持续查询超过版本校验的等待时长
+		for i := 0; i < 30; i++ {
+			_, _ = components.BlacklistService.CheckPhoneMD5(ctx, tenantID, generatePhoneMD5("13800138104"))
+			time.Sleep(100 * time.Millisecond)
+		}
+		stats, err := components.BlacklistService.GetFilterStats(ctx, tenantID)
+		require.NoError(t, err)
+		assert.Zero(t, stats.SyncGaps)
+	})
+
+	t.Run("Test HMAC Database Fallback", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(31)
+
+		hashes := services.NewIdentifierHashes("13800138090")
+		require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel:      models.TenantModel{TenantID: tenantID},
+			IdentifierSHA256: hashes.SHA256,
+			Source:           "manual",
+			Category:         models.RiskCategoryFraud,
+			RiskScore:        90,
+			OperatorID:       1,
+			IsActive:         true,
+		}))
+		salt, err := components.BlacklistService.GetHashSalt(ctx, tenantID)
+		require.NoError(t, err)
+		mac := hmac.New(sha256.New, []byte(salt))
+		mac.Write([]byte(hashes.SHA256))
+		hmacHash := hex.EncodeToString(mac.Sum(nil))
+
+		// 连接不可用的Redis，查询回退到数据库
+		offlineRedis := redisClient.NewClient(&redisClient.Config{Addrs: []string{"localhost:1"}, DialTimeout: 100 * time.Millisecond}, testLogger.Logger)
+		offline := services.NewBlacklistService(
+			repositories.NewBlacklistRepository(db),
+			repositories.NewBlacklistSettingRepository(db),
+			repositories.NewBlacklistImportJobRepository(db),
+			repositories.NewBlacklistImportBatchRepository(db),
+			repositories.NewBlacklistDriftReportRepository(db),
+			repositories.NewBlacklistQueryLogRepository(db),
+			repositories.NewApiCredentialRepository(db),
+			repositories.NewBlacklistQueryStatRepository(db),
+			repositories.NewBlacklistAllowlistRepository(db),
+			components.ListService,
+			components.SharedListService,
+			components.WebhookService,
+			offlineRedis,
+			testLogger,
+		)
+		defer func() {
+			closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			_ = offline.Close(closeCtx)
+			_ = offlineRedis.Close()
+		}()
+
+		result, err := offline.CheckIdentifier(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeHMACSHA256, hmacHash, nil)
+		require.NoError(t, err, "Redis不可用时HMAC格式应回退到数据库")
+		assert.True(t, result.Hit)
+		assert.Equal(t, models.RiskCategoryFraud, result.Category)
+		assert.Equal(t, 90, result.RiskScore)
+
+		result, err = offline.CheckIdentifier(ctx, tenantID, models.IdentifierTypePhone, models.HashTypeHMACSHA256, strings.Repeat("0", 64), nil)
+		require.NoError(t, err)
+		assert.False(t, result.Hit)
+	})
+
+	t.Run("Test CheckDrift Backfills Prefix Index", func(t *testing.T) {
+		ctx := context.Background()
+		tenantID := uint64(32)
+
+		require.NoError(t, components.BlacklistService.CreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: tenantID},
+			PhoneMD5:    generatePhoneMD5("13800138089"),
+			Source:      "manual",
+			OperatorID:  1,
+			IsActive:    true,
+		}))
+
+		// 删除前缀索引，模拟升级前写入的条目
+		redisCache := redisClient.NewClient(&redisClient.Config{Addrs: []string{"localhost:6379"}, DB: 1}, testLogger.Logger)
+		defer redisCache.Close()
+		prefixKey := fmt.Sprintf("blacklist:prefix:tenant:%d", tenantID)
+		require.NoError(t, redisCache.Del(ctx, prefixKey).Err())
+
+		report, err := components.BlacklistService.CheckDrift(ctx, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), report.MissingCount, "前缀索引缺失应计为偏差")
+		assert.True(t, report.Repaired)
+
+		count, err := redisCache.ZCard(ctx, prefixKey).Result()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count, "修复后应补建前缀索引")
+
+		report, err = components.BlacklistService.CheckDrift(ctx, tenantID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), report.MissingCount)
+		assert.False(t, report.Repaired)
+	})
 }
 
 // TestBlacklistServiceErrorCases 黑名单服务错误场景测试
@@ -370,7 +1933,13 @@ func TestBlacklistServiceErrorCases(t *testing.T) {
 	t.Run("Test BatchImportBlacklist Empty List", func(t *testing.T) {
 		ctx := context.Background()
 
-		err := components.BlacklistService.BatchImportBlacklist(ctx, 1, []string{}, "test", "空列表测试", 1)
+		_, err := components.BlacklistService.BatchImportBlacklist(ctx, &services.BatchImportParams{
+			TenantID:   1,
+			Items:      []services.IdentifierHashes{},
+			Source:     "test",
+			Reason:     "空列表测试",
+			OperatorID: 1,
+		})
 		// 空列表可能不会报错，但应该正常处理
 		require.NoError(t, err, "空列表导入应该正常处理")
 	})
@@ -386,14 +1955,14 @@ func TestBlacklistServiceErrorCases(t *testing.T) {
 		ctx := context.Background()
 
 		// 测试无效的分页参数
-		_, _, err := components.BlacklistService.GetBlacklistByTenant(ctx, 1, 0, 0)
+		_, _, err := components.BlacklistService.GetBlacklistByTenant(ctx, 1, "", "", 0, 0)
 		// 可能会报错或自动调整参数，取决于实现
 		if err != nil {
 			assert.Error(t, err, "无效的分页参数应该报错")
 		}
 
 		// 测试负数分页参数
-		_, _, err = components.BlacklistService.GetBlacklistByTenant(ctx, 1, -1, -10)
+		_, _, err = components.BlacklistService.GetBlacklistByTenant(ctx, 1, "", "", -1, -10)
 		if err != nil {
 			assert.Error(t, err, "负数分页参数应该报错")
 		}
@@ -402,11 +1971,44 @@ func TestBlacklistServiceErrorCases(t *testing.T) {
 	t.Run("Test SyncToRedis Invalid TenantID", func(t *testing.T) {
 		ctx := context.Background()
 
-		err := components.BlacklistService.SyncToRedis(ctx, 99999)
+		_, err := components.BlacklistService.SyncToRedis(ctx, 99999)
 		// 无效租户ID的同步可能不会报错，但也不会同步任何数据
 		// 这取决于具体实现
 		if err != nil {
 			t.Logf("无效租户ID同步报错: %v", err)
 		}
 	})
-}
\ No newline at end of file
+}
+
+// TestStatsWindowValidate 统计窗口校验测试
+func TestStatsWindowValidate(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name   string
+		window services.StatsWindow
+		valid  bool
+	}{
+		{"最近60分钟", services.StatsWindow{Granularity: services.StatsGranularityMinute, Points: 60}, true},
+		{"最近30天", services.StatsWindow{Granularity: services.StatsGranularityDay, Points: 30}, true},
+		{"超出日统计数据点数", services.StatsWindow{Granularity: services.StatsGranularityDay, Points: 367}, false},
+		{"31天小时统计", services.StatsWindow{Granularity: services.StatsGranularityHour, From: from, To: from.AddDate(0, 0, 31)}, true},
+		{"超过31天的小时统计", services.StatsWindow{Granularity: services.StatsGranularityHour, From: from, To: from.AddDate(0, 0, 32)}, false},
+		{"一年的日统计", services.StatsWindow{Granularity: services.StatsGranularityDay, From: from, To: from.AddDate(1, 0, 0)}, true},
+		{"分钟粒度不支持时间范围", services.StatsWindow{Granularity: services.StatsGranularityMinute, From: from, To: from.Add(time.Hour)}, false},
+		{"只指定开始时间", services.StatsWindow{Granularity: services.StatsGranularityDay, From: from}, false},
+		{"结束时间早于开始时间", services.StatsWindow{Granularity: services.StatsGranularityDay, From: from, To: from.Add(-time.Hour)}, false},
+		{"不支持的粒度", services.StatsWindow{Granularity: "week", Points: 1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.window.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Package test contains unit tests for blacklist tenant settings services.
+package test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/internal/services"
+	"gorm.io/gorm"
+)
+
+// memorySettingRepo 内存中的租户配置仓储，记录读取次数
+type memorySettingRepo struct {
+	mu       sync.Mutex
+	settings map[uint64]*models.BlacklistTenantSetting
+	reads    int
+}
+
+func newMemorySettingRepo() *memorySettingRepo {
+	return &memorySettingRepo{settings: make(map[uint64]*models.BlacklistTenantSetting)}
+}
+
+func (r *memorySettingRepo) GetByTenant(ctx context.Context, tenantID uint64) (*models.BlacklistTenantSetting, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+	setting, ok := r.settings[tenantID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *setting
+	return &copied, nil
+}
+
+func (r *memorySettingRepo) CreateIfNotExists(ctx context.Context, setting *models.BlacklistTenantSetting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.settings[setting.TenantID]; !ok {
+		copied := *setting
+		r.settings[setting.TenantID] = &copied
+	}
+	return nil
+}
+
+func (r *memorySettingRepo) UpdateHashSalt(ctx context.Context, tenantID uint64, hashSalt string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings[tenantID].HashSalt = hashSalt
+	return nil
+}
+
+func (r *memorySettingRepo) UpdateRequireApproval(ctx context.Context, tenantID uint64, requireApproval bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings[tenantID].RequireApproval = requireApproval
+	return nil
+}
+
+func (r *memorySettingRepo) UpdateShared(ctx context.Context, tenantID uint64, subscribed, contributor bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings[tenantID].SharedSubscribed = subscribed
+	r.settings[tenantID].SharedContributor = contributor
+	return nil
+}
+
+// memoryChangeRequestRepo 内存中的变更申请仓储，审批申请只调用Create
+type memoryChangeRequestRepo struct {
+	repositories.BlacklistChangeRequestRepository
+
+	requests []*models.BlacklistChangeRequest
+}
+
+func (r *memoryChangeRequestRepo) Create(ctx context.Context, request *models.BlacklistChangeRequest) error {
+	r.requests = append(r.requests, request)
+	return nil
+}
+
+// fakeApprovalBlacklistService 记录审批服务直接写入的条目
+type fakeApprovalBlacklistService struct {
+	services.BlacklistService
+
+	created []*models.PhoneBlacklist
+}
+
+func (s *fakeApprovalBlacklistService) CreateBlacklist(ctx context.Context, blacklist *models.PhoneBlacklist) error {
+	s.created = append(s.created, blacklist)
+	return nil
+}
+
+// TestBlacklistSettingServices 共享名单订阅和审批服务测试
+func TestBlacklistSettingServices(t *testing.T) {
+	testLogger, err := NewTestLogger()
+	require.NoError(t, err)
+
+	t.Run("Test Shared Subscription Is Cached Until Changed", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newMemorySettingRepo()
+		shared := services.NewSharedListService(repo, testLogger)
+
+		assert.False(t, shared.Subscribed(ctx, 2, models.HashTypeMD5))
+		reads := repo.reads
+		assert.False(t, shared.Subscribed(ctx, 2, models.HashTypeMD5))
+		assert.Equal(t, reads, repo.reads, "订阅应使用本地缓存")
+
+		old, err := shared.SetSharedSetting(ctx, 2, &services.SharedSetting{Subscribed: true, Contributor: true})
+		require.NoError(t, err)
+		assert.False(t, old.Subscribed)
+		assert.True(t, shared.Subscribed(ctx, 2, models.HashTypeMD5), "修改订阅后应立即生效")
+		assert.False(t, shared.Subscribed(ctx, 2, models.HashTypeHMACSHA256), "HMAC格式不查询共享名单")
+		assert.NotEmpty(t, repo.settings[2].HashSalt, "修改配置时应创建租户配置记录")
+
+		_, err = shared.SetSharedSetting(ctx, models.SystemTenantID, &services.SharedSetting{Subscribed: true})
+		assert.Error(t, err, "系统租户无需订阅")
+	})
+
+	t.Run("Test Approval Routes Writes By Tenant Setting", func(t *testing.T) {
+		ctx := context.Background()
+		repo := newMemorySettingRepo()
+		changes := &memoryChangeRequestRepo{}
+		blacklists := &fakeApprovalBlacklistService{}
+		approval := services.NewApprovalService(changes, repo, nil, nil, blacklists, testLogger)
+
+		request, err := approval.RequestCreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: 2},
+			PhoneMD5:    services.NewIdentifierHashes("13800138105").MD5,
+			OperatorID:  1,
+		})
+		require.NoError(t, err)
+		assert.Nil(t, request)
+		assert.Len(t, blacklists.created, 1, "未启用审批时直接写入")
+
+		require.NoError(t, approval.SetApprovalRequired(ctx, 2, true))
+		required, err := approval.GetApprovalRequired(ctx, 2)
+		require.NoError(t, err)
+		assert.True(t, required)
+
+		request, err = approval.RequestCreateBlacklist(ctx, &models.PhoneBlacklist{
+			TenantModel: models.TenantModel{TenantID: 2},
+			PhoneMD5:    services.NewIdentifierHashes("13800138106").MD5,
+			OperatorID:  1,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, request)
+		assert.Equal(t, models.ChangeRequestStatusPending, request.Status)
+		assert.Equal(t, models.IdentifierTypePhone, request.IdentifierType)
+		assert.Len(t, changes.requests, 1)
+		assert.Len(t, blacklists.created, 1, "启用审批后只创建申请")
+	})
+}
@@ -0,0 +1,309 @@
+// Package test contains unit tests for blacklist webhook delivery.
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/varluffy/shield/internal/models"
+	"github.com/varluffy/shield/internal/repositories"
+	"github.com/varluffy/shield/internal/services"
+)
+
+// memoryWebhookRepo 内存中的订阅仓储，投递只调用GetActiveByTenant和GetByID
+type memoryWebhookRepo struct {
+	repositories.BlacklistWebhookRepository
+
+	webhooks []*models.BlacklistWebhook
+}
+
+func (r *memoryWebhookRepo) Create(ctx context.Context, webhook *models.BlacklistWebhook) error {
+	r.webhooks = append(r.webhooks, webhook)
+	return nil
+}
+
+func (r *memoryWebhookRepo) GetActiveByTenant(ctx context.Context, tenantID uint64) ([]*models.BlacklistWebhook, error) {
+	var active []*models.BlacklistWebhook
+	for _, webhook := range r.webhooks {
+		if webhook.TenantID == tenantID && webhook.Status == models.WebhookStatusActive {
+			active = append(active, webhook)
+		}
+	}
+	return active, nil
+}
+
+func (r *memoryWebhookRepo) GetByID(ctx context.Context, id uint64) (*models.BlacklistWebhook, error) {
+	for _, webhook := range r.webhooks {
+		if webhook.ID == id {
+			return webhook, nil
+		}
+	}
+	return nil, nil
+}
+
+// memoryDeliveryRepo 内存中的投递仓储
+type memoryDeliveryRepo struct {
+	repositories.BlacklistWebhookDeliveryRepository
+
+	mu         sync.Mutex
+	nextID     uint64
+	deliveries map[uint64]*models.BlacklistWebhookDelivery
+	attempts   map[uint64][]*models.BlacklistWebhookAttempt
+}
+
+func newMemoryDeliveryRepo() *memoryDeliveryRepo {
+	return &memoryDeliveryRepo{
+		deliveries: make(map[uint64]*models.BlacklistWebhookDelivery),
+		attempts:   make(map[uint64][]*models.BlacklistWebhookAttempt),
+	}
+}
+
+func (r *memoryDeliveryRepo) BatchCreate(ctx context.Context, deliveries []*models.BlacklistWebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range deliveries {
+		r.nextID++
+		delivery.ID = r.nextID
+		copied := *delivery
+		r.deliveries[delivery.ID] = &copied
+	}
+	return nil
+}
+
+func (r *memoryDeliveryRepo) ClaimDue(ctx context.Context, claimID string, leaseUntil time.Time, limit int) ([]*models.BlacklistWebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	var claimed []*models.BlacklistWebhookDelivery
+	for _, delivery := range r.deliveries {
+		if len(claimed) >= limit {
+			break
+		}
+		if delivery.Status != models.WebhookDeliveryStatusPending || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if delivery.LeaseUntil != nil && delivery.LeaseUntil.After(now) {
+			continue
+		}
+		delivery.WorkerID = claimID
+		delivery.LeaseUntil = &leaseUntil
+		copied := *delivery
+		claimed = append(claimed, &copied)
+	}
+	return claimed, nil
+}
+
+func (r *memoryDeliveryRepo) RecordAttempt(ctx context.Context, delivery *models.BlacklistWebhookDelivery, attempt *models.BlacklistWebhookAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := r.deliveries[delivery.ID]
+	if stored.WorkerID != delivery.WorkerID || stored.Status != models.WebhookDeliveryStatusPending {
+		return repositories.ErrWebhookDeliveryLeaseLost
+	}
+	copied := *delivery
+	copied.WorkerID = ""
+	copied.LeaseUntil = nil
+	r.deliveries[delivery.ID] = &copied
+	r.attempts[delivery.ID] = append(r.attempts[delivery.ID], attempt)
+	return nil
+}
+
+func (r *memoryDeliveryRepo) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// snapshot 返回全部投递的副本
+func (r *memoryDeliveryRepo) snapshot() []models.BlacklistWebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deliveries := make([]models.BlacklistWebhookDelivery, 0, len(r.deliveries))
+	for _, delivery := range r.deliveries {
+		deliveries = append(deliveries, *delivery)
+	}
+	return deliveries
+}
+
+// finished 全部投递是否已结束
+func (r *memoryDeliveryRepo) finished() bool {
+	deliveries := r.snapshot()
+	for _, delivery := range deliveries {
+		if delivery.Status == models.WebhookDeliveryStatusPending {
+			return false
+		}
+	}
+	return len(deliveries) > 0
+}
+
+// TestWebhookDelivery Webhook投递测试
+func TestWebhookDelivery(t *testing.T) {
+	testLogger, err := NewTestLogger()
+	require.NoError(t, err)
+
+	newWebhook := func(id uint64, url, eventTypes string) *models.BlacklistWebhook {
+		return &models.BlacklistWebhook{
+			TenantModel: models.TenantModel{ID: id, UUID: models.GenerateUUID(), TenantID: 1},
+			Name:        "test",
+			URL:         url,
+			Secret:      "webhook-secret",
+			EventTypes:  eventTypes,
+			Status:      models.WebhookStatusActive,
+		}
+	}
+	newService := func(webhooks []*models.BlacklistWebhook, deliveries *memoryDeliveryRepo, maxAttempts int) services.WebhookService {
+		return services.NewWebhookServiceWithOptions(&memoryWebhookRepo{webhooks: webhooks}, deliveries, testLogger, services.WebhookOptions{
+			MaxAttempts:  maxAttempts,
+			BaseBackoff:  10 * time.Millisecond,
+			MaxBackoff:   50 * time.Millisecond,
+			PollInterval: 10 * time.Millisecond,
+			// 测试接收方监听在回环地址
+			AllowPrivateNetwork: true,
+		})
+	}
+
+	t.Run("Test Signed Delivery Retries Until Success", func(t *testing.T) {
+		var calls atomic.Int32
+		var mu sync.Mutex
+		var signatureValid bool
+		var received services.WebhookEvent
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			expected := services.SignWebhookPayload("webhook-secret", r.Header.Get("X-Webhook-ID"),
+				r.Header.Get("X-Timestamp"), r.Header.Get("X-Nonce"), string(body))
+			signatureValid = r.Header.Get("X-Signature") == expected && r.Header.Get("X-Delivery-Attempt") == "2"
+			_ = json.Unmarshal(body, &received)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		deliveries := newMemoryDeliveryRepo()
+		webhook := newWebhook(1, server.URL, models.WebhookEventHit)
+		service := newService([]*models.BlacklistWebhook{webhook}, deliveries, 5)
+
+		service.Publish(context.Background(), 1, models.WebhookEventHit, services.WebhookHitData{APIKey: "ak_test"})
+		require.Eventually(t, deliveries.finished, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, service.Close(context.Background()))
+
+		delivered := deliveries.snapshot()
+		require.Len(t, delivered, 1)
+		assert.Equal(t, models.WebhookDeliveryStatusSucceeded, delivered[0].Status)
+		assert.Equal(t, 2, delivered[0].Attempts)
+		assert.Len(t, deliveries.attempts[delivered[0].ID], 2)
+		assert.Equal(t, http.StatusServiceUnavailable, deliveries.attempts[delivered[0].ID][0].StatusCode)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.True(t, signatureValid)
+		assert.Equal(t, delivered[0].EventID, received.ID)
+		assert.Equal(t, models.WebhookEventHit, received.Type)
+	})
+
+	t.Run("Test Delivery Becomes Dead After Max Attempts", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		deliveries := newMemoryDeliveryRepo()
+		webhook := newWebhook(1, server.URL, models.WebhookEventEntryCreated)
+		service := newService([]*models.BlacklistWebhook{webhook}, deliveries, 3)
+
+		service.Publish(context.Background(), 1, models.WebhookEventEntryCreated, map[string]string{"uuid": "entry"})
+		require.Eventually(t, deliveries.finished, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, service.Close(context.Background()))
+
+		delivered := deliveries.snapshot()
+		require.Len(t, delivered, 1)
+		assert.Equal(t, models.WebhookDeliveryStatusDead, delivered[0].Status)
+		assert.Equal(t, 3, delivered[0].Attempts)
+		assert.Equal(t, http.StatusInternalServerError, delivered[0].LastStatusCode)
+		assert.Nil(t, delivered[0].NextAttemptAt)
+	})
+
+	t.Run("Test Only Subscribed Event Types Are Delivered", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		deliveries := newMemoryDeliveryRepo()
+		hitOnly := newWebhook(1, server.URL, models.WebhookEventHit)
+		batchOnly := newWebhook(2, server.URL, models.WebhookEventBatchImported+","+models.WebhookEventBatchRolledBack)
+		service := newService([]*models.BlacklistWebhook{hitOnly, batchOnly}, deliveries, 3)
+
+		service.Publish(context.Background(), 1, models.WebhookEventBatchImported, map[string]string{"batch_id": "batch"})
+		service.Publish(context.Background(), 2, models.WebhookEventBatchImported, map[string]string{"batch_id": "other tenant"})
+		require.Eventually(t, deliveries.finished, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, service.Close(context.Background()))
+
+		delivered := deliveries.snapshot()
+		require.Len(t, delivered, 1)
+		assert.Equal(t, uint64(2), delivered[0].WebhookID)
+		assert.Equal(t, models.WebhookEventBatchImported, delivered[0].EventType)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+	t.Run("Test Private Receiver Addresses Are Rejected", func(t *testing.T) {
+		service := services.NewWebhookService(&memoryWebhookRepo{}, newMemoryDeliveryRepo(), testLogger)
+		defer service.Close(context.Background())
+
+		for _, url := range []string{
+			"http://127.0.0.1:8080/hook",
+			"http://localhost/hook",
+			"http://[::1]/hook",
+			"http://10.0.0.1/hook",
+			"http://172.16.0.1/hook",
+			"http://192.168.1.1/hook",
+			"http://169.254.169.254/latest/meta-data",
+			"http://100.100.100.200/latest/meta-data",
+			"http://0.0.0.0/hook",
+			"http://[::ffff:127.0.0.1]/hook",
+			"http://[fd00::1]/hook",
+		} {
+			webhook := newWebhook(0, url, models.WebhookEventHit)
+			assert.Error(t, service.CreateWebhook(context.Background(), webhook), url)
+		}
+		assert.NoError(t, service.CreateWebhook(context.Background(), newWebhook(0, "https://203.0.113.10/hook", models.WebhookEventHit)))
+	})
+
+	t.Run("Test Delivery Refuses To Connect To Private Addresses", func(t *testing.T) {
+		// 模拟创建后通过DNS重绑定指向内网的接收地址，投递时应在连接前被拒绝
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		deliveries := newMemoryDeliveryRepo()
+		webhook := newWebhook(1, server.URL, models.WebhookEventHit)
+		service := services.NewWebhookServiceWithOptions(&memoryWebhookRepo{webhooks: []*models.BlacklistWebhook{webhook}}, deliveries, testLogger, services.WebhookOptions{
+			MaxAttempts:  1,
+			PollInterval: 10 * time.Millisecond,
+		})
+
+		service.Publish(context.Background(), 1, models.WebhookEventHit, services.WebhookHitData{APIKey: "ak_test"})
+		require.Eventually(t, deliveries.finished, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, service.Close(context.Background()))
+
+		delivered := deliveries.snapshot()
+		require.Len(t, delivered, 1)
+		assert.Equal(t, models.WebhookDeliveryStatusDead, delivered[0].Status)
+		assert.Equal(t, int32(0), calls.Load())
+	})
+}
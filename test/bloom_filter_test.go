@@ -0,0 +1,78 @@
+// Package test contains unit tests for the bloom filter.
+package test
+
+import (
+	"crypto/md5"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/varluffy/shield/pkg/bloom"
+)
+
+// TestBloomFilter 布隆过滤器单元测试
+func TestBloomFilter(t *testing.T) {
+	phoneMD5 := func(i int) string {
+		return fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("138%08d", i))))
+	}
+
+	t.Run("Test No False Negatives", func(t *testing.T) {
+		f := bloom.New(10000, 0.001)
+		for i := 0; i < 10000; i++ {
+			f.Add(phoneMD5(i))
+		}
+
+		for i := 0; i < 10000; i++ {
+			assert.True(t, f.MayContain(phoneMD5(i)), "已添加的元素必须返回可能存在")
+		}
+		assert.Equal(t, int64(10000), f.Count())
+	})
+
+	t.Run("Test False Positive Rate", func(t *testing.T) {
+		f := bloom.New(10000, 0.001)
+		for i := 0; i < 10000; i++ {
+			f.Add(phoneMD5(i))
+		}
+
+		falsePositives := 0
+		for i := 10000; i < 110000; i++ {
+			if f.MayContain(phoneMD5(i)) {
+				falsePositives++
+			}
+		}
+
+		rate := float64(falsePositives) / 100000
+		assert.Less(t, rate, 0.005, "满容量时误判率应接近设计值")
+		assert.InDelta(t, 0.001, f.EstimatedFalsePositiveRate(), 0.001)
+	})
+
+	t.Run("Test Concurrent Add And Check", func(t *testing.T) {
+		f := bloom.New(20000, 0.001)
+
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				for i := w * 5000; i < (w+1)*5000; i++ {
+					f.Add(phoneMD5(i))
+					_ = f.MayContain(phoneMD5(i + 100000))
+				}
+			}(w)
+		}
+		wg.Wait()
+
+		for i := 0; i < 20000; i++ {
+			assert.True(t, f.MayContain(phoneMD5(i)))
+		}
+	})
+
+	t.Run("Test Invalid Parameters", func(t *testing.T) {
+		f := bloom.New(0, 2)
+		f.Add("a")
+		assert.True(t, f.MayContain("a"))
+		assert.Greater(t, f.SizeBytes(), 0)
+		assert.GreaterOrEqual(t, f.HashFunctions(), 1)
+	})
+}
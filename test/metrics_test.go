@@ -0,0 +1,63 @@
+// Package test contains unit tests for the Prometheus metrics.
+package test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/varluffy/shield/internal/middleware"
+	"github.com/varluffy/shield/pkg/metrics"
+)
+
+// TestMetrics 指标接口测试
+func TestMetrics(t *testing.T) {
+	scrape := func(t *testing.T, handler http.Handler, token string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		body, _ := io.ReadAll(w.Body)
+		return w.Code, string(body)
+	}
+
+	t.Run("Test Token Required", func(t *testing.T) {
+		handler := metrics.Handler("metrics-token")
+
+		code, _ := scrape(t, handler, "")
+		assert.Equal(t, http.StatusUnauthorized, code)
+		code, _ = scrape(t, handler, "wrong-token")
+		assert.Equal(t, http.StatusUnauthorized, code)
+		code, _ = scrape(t, handler, "metrics-token")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("Test Exposes Recorded Metrics", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.Use(middleware.MetricsMiddleware())
+		r.GET("/api/v1/items/:id", func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/items/42", nil))
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		metrics.AddBlacklistChecks(9001, metrics.SourceRedis, 2, 3)
+		metrics.ObservePermissionCache("permissions", "memory", true)
+
+		code, body := scrape(t, metrics.Handler(""), "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Contains(t, body, `shield_http_request_duration_seconds_count{method="GET",route="/api/v1/items/:id",status="204"}`)
+		assert.Contains(t, body, `shield_blacklist_checks_total{result="hit",source="redis",tenant_id="9001"} 2`)
+		assert.Contains(t, body, `shield_blacklist_checks_total{result="miss",source="redis",tenant_id="9001"} 3`)
+		assert.Contains(t, body, `shield_permission_cache_requests_total{backend="memory",cache="permissions",result="hit"}`)
+		assert.Contains(t, body, "go_goroutines")
+	})
+}
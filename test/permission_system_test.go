@@ -48,6 +48,7 @@ func TestPermissionSystemIntegration(t *testing.T) {
 		components.FieldPermissionHandler,
 		nil, // blacklistHandler - 测试中不需要
 		nil, // apiCredentialHandler - 测试中不需要
+		nil, // webhookHandler - 测试中不需要
 		components.AuthMiddleware,
 		nil, // permissionMiddleware - 测试中暂不需要
 		nil, // blacklistAuthMiddleware - 测试中不需要
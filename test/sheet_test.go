@@ -0,0 +1,121 @@
+// Package test contains unit tests for CSV/XLSX row readers and phone normalization.
+package test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/varluffy/shield/internal/services"
+	"github.com/varluffy/shield/pkg/sheet"
+)
+
+// buildTestXLSX 生成包含共享字符串、内联字符串和数字单元格的最小XLSX文件
+func buildTestXLSX(t *testing.T) []byte {
+	files := map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="名单" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/data.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>姓名</t></si><si><t>手机号</t></si><si><r><t>+86 138</t></r><r><t>-0013-8000</t></r></si>
+</sst>`,
+		"xl/worksheets/data.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>张三</t></is></c><c r="B2" t="s"><v>2</v></c></row>
+<row r="4"><c r="B4"><v>1.3900139E10</v></c></row>
+</sheetData></worksheet>`,
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// readAllRows 读取全部行
+func readAllRows(t *testing.T, reader sheet.Reader) []*sheet.Row {
+	rows := make([]*sheet.Row, 0)
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return rows
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+// TestSheetReader 表格读取器单元测试
+func TestSheetReader(t *testing.T) {
+	t.Run("Test CSV With BOM", func(t *testing.T) {
+		data := []byte("\xEF\xBB\xBFphone,name\n13800138000,a\n\n\"139 0013 9000\"\n")
+		reader, err := sheet.NewReader(bytes.NewReader(data), int64(len(data)), "list.CSV")
+		require.NoError(t, err)
+
+		rows := readAllRows(t, reader)
+		require.Len(t, rows, 3)
+		assert.Equal(t, "phone", rows[0].Cell(0))
+		assert.Equal(t, 2, rows[1].Number)
+		assert.Equal(t, "13800138000", rows[1].Cell(0))
+		assert.Equal(t, 4, rows[2].Number, "空行被跳过但行号保持不变")
+		assert.Equal(t, "", rows[2].Cell(1), "不存在的列返回空字符串")
+	})
+
+	t.Run("Test XLSX First Sheet", func(t *testing.T) {
+		data := buildTestXLSX(t)
+		reader, err := sheet.NewReader(bytes.NewReader(data), int64(len(data)), "list.xlsx")
+		require.NoError(t, err)
+
+		rows := readAllRows(t, reader)
+		require.Len(t, rows, 3)
+		assert.Equal(t, []string{"姓名", "手机号"}, rows[0].Cells)
+		assert.Equal(t, []string{"张三", "+86 138-0013-8000"}, rows[1].Cells)
+		assert.Equal(t, 4, rows[2].Number)
+		assert.Equal(t, []string{"", "13900139000"}, rows[2].Cells, "缺失单元格补空，科学计数法还原为整数")
+	})
+
+	t.Run("Test Unsupported Format", func(t *testing.T) {
+		_, err := sheet.NewReader(bytes.NewReader(nil), 0, "list.xls")
+		assert.ErrorIs(t, err, sheet.ErrUnsupportedFormat)
+
+		_, err = sheet.NewReader(bytes.NewReader([]byte("not a zip")), 9, "list.xlsx")
+		assert.Error(t, err)
+	})
+}
+
+// TestNormalizePhone 明文手机号规范化单元测试
+func TestNormalizePhone(t *testing.T) {
+	valid := map[string]string{
+		"13800138000":       "13800138000",
+		"+86 138 0013 8000": "13800138000",
+		"+86-138-0013-8000": "13800138000",
+		"0086 13800138000":  "13800138000",
+		"8613800138000":     "13800138000",
+		" 138 0013\t8000 ":  "13800138000",
+	}
+	for raw, expected := range valid {
+		phone, ok := services.NormalizePhone(raw)
+		assert.True(t, ok, raw)
+		assert.Equal(t, expected, phone, raw)
+	}
+
+	for _, raw := range []string{"", "1380013800", "23800138000", "138001380001", "1380013800a", "+1 13800138000"} {
+		_, ok := services.NormalizePhone(raw)
+		assert.False(t, ok, raw)
+	}
+}
@@ -29,12 +29,13 @@ import (
 // TestComponents 测试组件集合
 type TestComponents struct {
 	// Repositories
-	UserRepo            repositories.UserRepository
-	RoleRepo            repositories.RoleRepository
-	PermissionRepo      repositories.PermissionRepository
-	TenantRepo          repositories.TenantRepository
-	PermissionAuditRepo repositories.PermissionAuditRepository
-	BlacklistRepo       repositories.BlacklistRepository
+	UserRepo              repositories.UserRepository
+	RoleRepo              repositories.RoleRepository
+	PermissionRepo        repositories.PermissionRepository
+	TenantRepo            repositories.TenantRepository
+	PermissionAuditRepo   repositories.PermissionAuditRepository
+	BlacklistRepo         repositories.BlacklistRepository
+	BlacklistQueryLogRepo repositories.BlacklistQueryLogRepository
 
 	// Services
 	UserService            services.UserService
@@ -44,6 +45,11 @@ type TestComponents struct {
 	PermissionCacheService services.PermissionCacheService
 	PermissionAuditService services.PermissionAuditService
 	BlacklistService       services.BlacklistService
+	WebhookService         services.WebhookService
+	ListService            services.ListService
+	SharedListService      services.SharedListService
+	AllowlistService       services.AllowlistService
+	ApprovalService        services.ApprovalService
 
 	// Handlers
 	UserHandler            *handlers.UserHandler
@@ -151,6 +157,18 @@ func NewTestComponents(db *gorm.DB, testLogger *logger.Logger) *TestComponents {
 	tenantRepo := repositories.NewTenantRepository(db, txManager, testLogger)
 	permissionAuditRepo := repositories.NewPermissionAuditRepository(db, txManager, testLogger)
 	blacklistRepo := repositories.NewBlacklistRepository(db)
+	blacklistSettingRepo := repositories.NewBlacklistSettingRepository(db)
+	blacklistImportJobRepo := repositories.NewBlacklistImportJobRepository(db)
+	blacklistImportBatchRepo := repositories.NewBlacklistImportBatchRepository(db)
+	blacklistDriftReportRepo := repositories.NewBlacklistDriftReportRepository(db)
+	blacklistQueryLogRepo := repositories.NewBlacklistQueryLogRepository(db)
+	blacklistWebhookRepo := repositories.NewBlacklistWebhookRepository(db)
+	blacklistWebhookDeliveryRepo := repositories.NewBlacklistWebhookDeliveryRepository(db)
+	blacklistQueryStatRepo := repositories.NewBlacklistQueryStatRepository(db)
+	blacklistChangeRequestRepo := repositories.NewBlacklistChangeRequestRepository(db)
+	blacklistAllowlistRepo := repositories.NewBlacklistAllowlistRepository(db)
+	blacklistListRepo := repositories.NewBlacklistListRepository(db)
+	apiCredentialRepo := repositories.NewApiCredentialRepository(db)
 
 	// 创建Services
 	var permissionCacheService services.PermissionCacheService
@@ -169,7 +187,12 @@ func NewTestComponents(db *gorm.DB, testLogger *logger.Logger) *TestComponents {
 	fieldPermissionRepo := repositories.NewFieldPermissionRepository(db, txManager, testLogger)
 	fieldPermissionService := services.NewFieldPermissionService(fieldPermissionRepo, userRepo, testLogger)
 	permissionAuditService := services.NewPermissionAuditService(permissionAuditRepo, testLogger)
-	blacklistService := services.NewBlacklistService(blacklistRepo, redisCache, testLogger)
+	webhookService := services.NewWebhookService(blacklistWebhookRepo, blacklistWebhookDeliveryRepo, testLogger)
+	listService := services.NewListService(blacklistListRepo, blacklistRepo, apiCredentialRepo, redisCache, testLogger)
+	sharedListService := services.NewSharedListService(blacklistSettingRepo, testLogger)
+	allowlistService := services.NewAllowlistService(blacklistAllowlistRepo, blacklistRepo, blacklistSettingRepo, sharedListService, redisCache, testLogger)
+	blacklistService := services.NewBlacklistService(blacklistRepo, blacklistSettingRepo, blacklistImportJobRepo, blacklistImportBatchRepo, blacklistDriftReportRepo, blacklistQueryLogRepo, apiCredentialRepo, blacklistQueryStatRepo, blacklistAllowlistRepo, listService, sharedListService, webhookService, redisCache, testLogger)
+	approvalService := services.NewApprovalService(blacklistChangeRequestRepo, blacklistSettingRepo, blacklistRepo, blacklistListRepo, blacklistService, testLogger)
 
 	// 创建ResponseWriter
 	responseWriter := response.NewResponseWriter(testLogger)
@@ -186,12 +209,13 @@ func NewTestComponents(db *gorm.DB, testLogger *logger.Logger) *TestComponents {
 
 	return &TestComponents{
 		// Repositories
-		UserRepo:            userRepo,
-		RoleRepo:            roleRepo,
-		PermissionRepo:      permissionRepo,
-		TenantRepo:          tenantRepo,
-		PermissionAuditRepo: permissionAuditRepo,
-		BlacklistRepo:       blacklistRepo,
+		UserRepo:              userRepo,
+		RoleRepo:              roleRepo,
+		PermissionRepo:        permissionRepo,
+		TenantRepo:            tenantRepo,
+		PermissionAuditRepo:   permissionAuditRepo,
+		BlacklistRepo:         blacklistRepo,
+		BlacklistQueryLogRepo: blacklistQueryLogRepo,
 
 		// Services
 		UserService:            userService,
@@ -201,6 +225,11 @@ func NewTestComponents(db *gorm.DB, testLogger *logger.Logger) *TestComponents {
 		PermissionCacheService: permissionCacheService,
 		PermissionAuditService: permissionAuditService,
 		BlacklistService:       blacklistService,
+		WebhookService:         webhookService,
+		ListService:            listService,
+		SharedListService:      sharedListService,
+		AllowlistService:       allowlistService,
+		ApprovalService:        approvalService,
 
 		// Handlers
 		UserHandler:            userHandler,